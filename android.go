@@ -0,0 +1,529 @@
+package contacts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+	"github.com/google/uuid"
+)
+
+// androidUIDNamespace is the UUID namespace of the UIDs given to Android
+// cards without one.
+var androidUIDNamespace = uuid.MustParse("c3e8a1d4-7b26-4f90-b5e2-8d41f6a09c37")
+
+// sqliteHeader starts every SQLite database file, such as contacts2.db.
+const sqliteHeader = "SQLite format 3\x00"
+
+// androidRelationTypes maps Android's ContactsContract.CommonDataKinds.Relation
+// type codes to vCard RELATED types.
+var androidRelationTypes = map[string]string{
+	"1":  "co-worker", // assistant
+	"2":  "sibling",   // brother
+	"3":  "child",
+	"4":  "spouse", // domestic partner
+	"5":  "parent", // father
+	"6":  "friend",
+	"7":  "co-worker", // manager
+	"8":  "parent",    // mother
+	"9":  "parent",
+	"10": "sweetheart", // partner
+	"11": "acquaintance",
+	"12": "kin",
+	"13": "sibling", // sister
+	"14": "spouse",
+}
+
+// ParseAndroidVCF reads the VCF bundle written by the Android Contacts app's
+// "Export to storage". It handles vCard 2.1 syntax (bare TYPE parameters,
+// quoted-printable values, base64 photos) and the Android-specific
+// X-ANDROID-CUSTOM fields, returning vCard 4.0 cards. A card that cannot
+// be decoded is left out and reported in failed, so one damaged entry does
+// not stop the import. A copy of the phone's contacts2.db database is
+// read too. Use AndroidReader to read a large export card by card.
+func ParseAndroidVCF(r io.Reader) (cards []vcard.Card, failed []ImportFailure, err error) {
+	ar, err := NewAndroidReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	for {
+		card, err := ar.Next()
+		var recErr *RecordError
+		switch {
+		case err == io.EOF:
+			return cards, failed, nil
+		case errors.As(err, &recErr):
+			failed = append(failed, ImportFailure{Record: recErr.Record, Error: recErr.Err.Error()})
+		case err != nil:
+			return nil, nil, err
+		default:
+			cards = append(cards, card)
+		}
+	}
+}
+
+// RecordError is an input record that could not be parsed. Reading can
+// continue with the next record.
+type RecordError struct {
+	// Record is the 1-based position of the record in the input.
+	Record int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Record, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// AndroidReader reads the cards of an Android VCF export, or of a copy of
+// Android's contacts2.db database, one at a time; see ParseAndroidVCF.
+type AndroidReader struct {
+	r      *bufio.Reader
+	next   []byte // a BEGIN:VCARD line read ahead
+	record int
+	// db holds the cards read from a contacts2.db that are still to be
+	// returned; a database is read whole.
+	db []vcard.Card
+}
+
+// NewAndroidReader returns a reader for r, which is either a VCF export or
+// a contacts2.db database.
+func NewAndroidReader(r io.Reader) (*AndroidReader, error) {
+	br := bufio.NewReader(r)
+	if head, _ := br.Peek(len(sqliteHeader)); string(head) != sqliteHeader {
+		return &AndroidReader{r: br}, nil
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read android contact database: %w", err)
+	}
+	cards, err := parseAndroidDB(data)
+	if err != nil {
+		return nil, err
+	}
+	return &AndroidReader{db: cards}, nil
+}
+
+// Next returns the next card, or io.EOF after the last one. A card that
+// cannot be decoded is reported as a *RecordError. Cards without a UID are
+// given one derived from their content, so importing the same export again
+// finds them rather than creating copies.
+func (ar *AndroidReader) Next() (vcard.Card, error) {
+	if ar.r == nil {
+		if len(ar.db) == 0 {
+			return nil, io.EOF
+		}
+		card := ar.db[0]
+		ar.db = ar.db[1:]
+		ar.record++
+		return card, nil
+	}
+	block := ar.next
+	ar.next = nil
+	for {
+		line, err := ar.r.ReadBytes('\n')
+		if len(line) > 0 {
+			if strings.EqualFold(strings.TrimSpace(string(line)), "BEGIN:VCARD") && block != nil {
+				ar.next = line
+				break
+			}
+			if block != nil || strings.EqualFold(strings.TrimSpace(string(line)), "BEGIN:VCARD") {
+				block = append(block, line...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read android export: %w", err)
+		}
+	}
+	if block == nil {
+		return nil, io.EOF
+	}
+	ar.record++
+	card, err := decodeAndroidCard(block)
+	if err != nil {
+		return nil, &RecordError{Record: ar.record, Err: err}
+	}
+	return card, nil
+}
+
+// decodeAndroidCard decodes one card of an Android export.
+func decodeAndroidCard(block []byte) (vcard.Card, error) {
+	normalized, err := normalizeVCard21(block)
+	if err != nil {
+		return nil, err
+	}
+	card, err := vcard.NewDecoder(bytes.NewReader(normalized)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode android contact: %w", err)
+	}
+	mapAndroidCustom(card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+	if CardUID(card) == "" {
+		card.SetValue(vcard.FieldUID, uuid.NewSHA1(androidUIDNamespace, block).String())
+	}
+	repairCard(card)
+	return card, nil
+}
+
+// normalizeVCard21 rewrites vCard 2.1 content lines into a form the vCard
+// decoder understands.
+func normalizeVCard21(data []byte) ([]byte, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	rawLines := strings.Split(text, "\n")
+
+	// Unfold: quoted-printable soft line breaks end a line with "=", and
+	// folded lines (including base64 photo data) start with whitespace.
+	var lines []string
+	for i := 0; i < len(rawLines); i++ {
+		line := rawLines[i]
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += strings.TrimLeft(line, " \t")
+			continue
+		}
+		for isQuotedPrintable(line) && strings.HasSuffix(line, "=") && i+1 < len(rawLines) {
+			i++
+			line = line[:len(line)-1] + rawLines[i]
+		}
+		lines = append(lines, line)
+	}
+
+	var out strings.Builder
+	for _, line := range lines {
+		converted, err := convertVCard21Line(line)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(converted)
+		out.WriteString("\r\n")
+	}
+	return []byte(out.String()), nil
+}
+
+func isQuotedPrintable(line string) bool {
+	head, _, _ := strings.Cut(line, ":")
+	return strings.Contains(strings.ToUpper(head), "QUOTED-PRINTABLE")
+}
+
+// convertVCard21Line turns bare parameters into TYPE= parameters, decodes
+// quoted-printable values and turns base64 photos into data URIs.
+func convertVCard21Line(line string) (string, error) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return line, nil
+	}
+	parts := strings.Split(head, ";")
+	name := strings.ToUpper(parts[0])
+
+	var params []string
+	var encoding, imageType string
+	for _, p := range parts[1:] {
+		key, val, hasVal := strings.Cut(p, "=")
+		key = strings.ToUpper(key)
+		switch {
+		case key == "ENCODING":
+			encoding = strings.ToUpper(val)
+		case key == "CHARSET":
+			// Android always exports UTF-8.
+		case !hasVal && (key == "QUOTED-PRINTABLE" || key == "BASE64" || key == "B"):
+			encoding = key
+		case !hasVal && name == vcard.FieldPhoto:
+			imageType = strings.ToLower(key)
+		case key == "TYPE" && name == vcard.FieldPhoto:
+			imageType = strings.ToLower(val)
+		case !hasVal:
+			params = append(params, "TYPE="+strings.ToLower(key))
+		default:
+			params = append(params, p)
+		}
+	}
+
+	switch encoding {
+	case "QUOTED-PRINTABLE":
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(value)))
+		if err != nil {
+			return "", fmt.Errorf("failed to decode quoted-printable %s: %w", name, err)
+		}
+		value = strings.ReplaceAll(string(decoded), "\r\n", "\n")
+		value = strings.ReplaceAll(value, "\n", `\n`)
+	case "BASE64", "B":
+		if name == vcard.FieldPhoto {
+			if imageType == "" {
+				imageType = "jpeg"
+			}
+			value = "data:image/" + imageType + ";base64," + value
+		}
+	}
+
+	if name == vcard.FieldVersion {
+		value = "4.0"
+	}
+	return strings.Join(append([]string{name}, params...), ";") + ":" + value, nil
+}
+
+// mapAndroidCustom converts X-ANDROID-CUSTOM fields for nicknames,
+// relations and events into standard vCard properties. Unrecognized
+// custom fields are kept as they are.
+func mapAndroidCustom(card vcard.Card) {
+	var keep []*vcard.Field
+	for _, f := range card["X-ANDROID-CUSTOM"] {
+		parts := strings.Split(f.Value, ";")
+		kind := strings.TrimPrefix(parts[0], "vnd.android.cursor.item/")
+		data := func(i int) string {
+			if i < len(parts) {
+				return parts[i]
+			}
+			return ""
+		}
+		if !addAndroidData(card, kind, data) {
+			keep = append(keep, f)
+		}
+	}
+	if len(keep) > 0 {
+		card["X-ANDROID-CUSTOM"] = keep
+	} else {
+		delete(card, "X-ANDROID-CUSTOM")
+	}
+}
+
+// addAndroidData adds a nickname, relation or event stored as an Android
+// data row of the given kind, reading its data1, data2, ... columns with
+// data. It reports whether it recognized the row.
+func addAndroidData(card vcard.Card, kind string, data func(int) string) bool {
+	switch kind {
+	case "nickname":
+		if data(1) != "" {
+			card.Add(vcard.FieldNickname, &vcard.Field{Value: data(1)})
+		}
+	case "relation":
+		if data(1) == "" {
+			return true
+		}
+		params := vcard.Params{vcard.ParamValue: {"text"}}
+		if t, ok := androidRelationTypes[data(2)]; ok {
+			params[vcard.ParamType] = []string{t}
+		}
+		card.Add(vcard.FieldRelated, &vcard.Field{Value: data(1), Params: params})
+	case "contact_event":
+		date := strings.ReplaceAll(data(1), "-", "")
+		if strings.HasPrefix(data(1), "--") {
+			date = "--" + date
+		}
+		switch data(2) {
+		case "1":
+			card.Add(vcard.FieldAnniversary, &vcard.Field{Value: date})
+		case "3":
+			if card.Value(vcard.FieldBirthday) == "" {
+				card.SetValue(vcard.FieldBirthday, date)
+			}
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// androidPhoneTypes maps Android's Phone type codes to vCard TEL types.
+var androidPhoneTypes = map[string][]string{
+	"1":  {"home"},
+	"2":  {"cell"},
+	"3":  {"work"},
+	"4":  {"work", "fax"},
+	"5":  {"home", "fax"},
+	"6":  {"pager"},
+	"17": {"work", "cell"},
+	"18": {"work", "pager"},
+}
+
+// androidPlaceTypes maps Android's Email and StructuredPostal type codes to
+// vCard TYPE values.
+var androidPlaceTypes = map[string]string{
+	"1": "home",
+	"2": "work",
+}
+
+// parseAndroidDB reads the contacts of an Android contacts2.db database.
+// The raw contacts Android has linked into one contact become one card,
+// and deleted raw contacts are left out. The database must be copied with
+// its -wal file checkpointed, as "adb backup" and most backup apps do;
+// changes still in the log are not read.
+func parseAndroidDB(data []byte) ([]vcard.Card, error) {
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read android contact database: %w", err)
+	}
+	mimetypes := map[int64]string{}
+	err = db.rows("mimetypes", func(row sqliteRow) error {
+		id, _ := row["_id"].(int64)
+		mimetype, _ := row["mimetype"].(string)
+		mimetypes[id] = strings.TrimPrefix(mimetype, "vnd.android.cursor.item/")
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read android contact database: %w", err)
+	}
+
+	// Raw contacts without a contact_id have not been linked yet; negative
+	// keys keep them apart from the linked ones.
+	contactOf := map[int64]int64{}
+	byContact := map[int64]vcard.Card{}
+	var order []int64
+	err = db.rows("raw_contacts", func(row sqliteRow) error {
+		if deleted, _ := row["deleted"].(int64); deleted != 0 {
+			return nil
+		}
+		id, _ := row["_id"].(int64)
+		key := -id
+		if contact, ok := row["contact_id"].(int64); ok {
+			key = contact
+		}
+		contactOf[id] = key
+		if byContact[key] == nil {
+			byContact[key] = vcard.Card{}
+			order = append(order, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read android contact database: %w", err)
+	}
+	err = db.rows("data", func(row sqliteRow) error {
+		raw, _ := row["raw_contact_id"].(int64)
+		key, ok := contactOf[raw]
+		if !ok {
+			return nil
+		}
+		mimetype, _ := row["mimetype_id"].(int64)
+		addAndroidRow(byContact[key], mimetypes[mimetype], row)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read android contact database: %w", err)
+	}
+
+	cards := make([]vcard.Card, 0, len(order))
+	for _, key := range order {
+		card := byContact[key]
+		if len(card) == 0 {
+			continue
+		}
+		card.SetValue(vcard.FieldVersion, "4.0")
+		repairCard(card)
+		encoded, err := EncodeCard(card)
+		if err != nil {
+			return nil, err
+		}
+		card.SetValue(vcard.FieldUID, uuid.NewSHA1(androidUIDNamespace, encoded).String())
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// addAndroidRow adds one row of contacts2.db's data table, of the given
+// kind, to card. A value another linked raw contact already added is not
+// repeated.
+func addAndroidRow(card vcard.Card, kind string, row sqliteRow) {
+	data := func(i int) string {
+		switch v := row[fmt.Sprintf("data%d", i)].(type) {
+		case string:
+			return v
+		case int64:
+			return strconv.FormatInt(v, 10)
+		}
+		return ""
+	}
+	add := func(name string, f *vcard.Field) {
+		if f.Value == "" {
+			return
+		}
+		for _, existing := range card[name] {
+			if existing.Value == f.Value {
+				return
+			}
+		}
+		card.Add(name, f)
+	}
+	typed := func(types ...string) vcard.Params {
+		if len(types) == 0 || types[0] == "" {
+			return nil
+		}
+		return vcard.Params{vcard.ParamType: types}
+	}
+
+	switch kind {
+	case "name":
+		if card.Name() == nil {
+			card.SetName(&vcard.Name{
+				GivenName:       data(2),
+				FamilyName:      data(3),
+				HonorificPrefix: data(4),
+				AdditionalName:  data(5),
+				HonorificSuffix: data(6),
+			})
+		}
+		if card.Value(vcard.FieldFormattedName) == "" && data(1) != "" {
+			card.SetValue(vcard.FieldFormattedName, data(1))
+		}
+	case "phone_v2":
+		add(vcard.FieldTelephone, &vcard.Field{Value: data(1), Params: typed(androidPhoneTypes[data(2)]...)})
+	case "email_v2":
+		add(vcard.FieldEmail, &vcard.Field{Value: data(1), Params: typed(androidPlaceTypes[data(2)])})
+	case "postal-address_v2":
+		adr := &vcard.Address{
+			Field:         &vcard.Field{Params: typed(androidPlaceTypes[data(2)])},
+			PostOfficeBox: data(5),
+			StreetAddress: data(4),
+			Locality:      data(7),
+			Region:        data(8),
+			PostalCode:    data(9),
+			Country:       data(10),
+		}
+		if adr.StreetAddress == "" && adr.Locality == "" && adr.Country == "" {
+			// Only the formatted address was entered.
+			adr.StreetAddress = data(1)
+		}
+		if adr.StreetAddress+adr.PostOfficeBox+adr.Locality+adr.Region+adr.PostalCode+adr.Country != "" {
+			card.AddAddress(adr)
+		}
+	case "organization":
+		org := data(1)
+		if data(5) != "" {
+			org += ";" + data(5)
+		}
+		add(vcard.FieldOrganization, &vcard.Field{Value: org})
+		add(vcard.FieldTitle, &vcard.Field{Value: data(4)})
+	case "website":
+		add(vcard.FieldURL, &vcard.Field{Value: data(1)})
+	case "note":
+		add(vcard.FieldNote, &vcard.Field{Value: data(1)})
+	case "photo":
+		photo, _ := row["data15"].([]byte)
+		if len(photo) == 0 || card.Value(vcard.FieldPhoto) != "" {
+			return
+		}
+		format := imageFormat(photo)
+		if format == "" {
+			format = "jpeg"
+		}
+		card.SetValue(vcard.FieldPhoto, "data:image/"+format+";base64,"+base64.StdEncoding.EncodeToString(photo))
+	default:
+		addAndroidData(card, kind, data)
+	}
+}
@@ -0,0 +1,225 @@
+package contacts
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+const androidExport = "BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"N;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:M=C3=BCller;J=C3=BCrgen;;;\r\n" +
+	"FN;CHARSET=UTF-8;ENCODING=QUOTED-PRINTABLE:J=C3=BCrgen M=C3=BCller\r\n" +
+	"TEL;CELL;PREF:+49 30 1234567\r\n" +
+	"EMAIL;HOME:jm@example.com\r\n" +
+	"NOTE;ENCODING=QUOTED-PRINTABLE:line one=0D=0A=\r\n" +
+	"line two\r\n" +
+	"PHOTO;ENCODING=BASE64;JPEG:/9j/4AAQ\r\n" +
+	" SkZJRg\r\n" +
+	"\r\n" +
+	"X-ANDROID-CUSTOM:vnd.android.cursor.item/nickname;Jürgi;1;;;;;;;;;;;;;\r\n" +
+	"X-ANDROID-CUSTOM:vnd.android.cursor.item/relation;Anna;14;;;;;;;;;;;;;\r\n" +
+	"X-ANDROID-CUSTOM:vnd.android.cursor.item/contact_event;2010-06-12;1;;;;;;;;;;;;;\r\n" +
+	"X-ANDROID-CUSTOM:vnd.com.whatsapp.profile;491234;;;;;;;;;;;;;;\r\n" +
+	"END:VCARD\r\n" +
+	"BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"N:;Bob;;;\r\n" +
+	"TEL;WORK:555-0100\r\n" +
+	"END:VCARD\r\n"
+
+func TestParseAndroidVCF(t *testing.T) {
+	cards, failed, err := ParseAndroidVCF(strings.NewReader(androidExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("failed = %v, want none", failed)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("got %d cards, want 2", len(cards))
+	}
+	card := cards[0]
+
+	if got := CardFullName(card); got != "Jürgen Müller" {
+		t.Errorf("FN = %q", got)
+	}
+	if n := card.Name(); n == nil || n.FamilyName != "Müller" {
+		t.Errorf("N = %+v", n)
+	}
+	if got := card.Value(vcard.FieldVersion); got != "4.0" {
+		t.Errorf("VERSION = %q", got)
+	}
+	if CardUID(card) == "" {
+		t.Error("UID not assigned")
+	}
+	tel := card.Get(vcard.FieldTelephone)
+	if tel == nil || tel.Value != "+49 30 1234567" || !tel.Params.HasType("cell") {
+		t.Errorf("TEL = %+v", tel)
+	}
+	if got := card.Value(vcard.FieldNote); got != "line one\nline two" {
+		t.Errorf("NOTE = %q", got)
+	}
+	if got := card.Value(vcard.FieldPhoto); got != "data:image/jpeg;base64,/9j/4AAQSkZJRg" {
+		t.Errorf("PHOTO = %q", got)
+	}
+	if got := card.Value(vcard.FieldNickname); got != "Jürgi" {
+		t.Errorf("NICKNAME = %q", got)
+	}
+	rel := card.Get(vcard.FieldRelated)
+	if rel == nil || rel.Value != "Anna" || !rel.Params.HasType("spouse") {
+		t.Errorf("RELATED = %+v", rel)
+	}
+	if got := card.Value(vcard.FieldAnniversary); got != "20100612" {
+		t.Errorf("ANNIVERSARY = %q", got)
+	}
+	if custom := card["X-ANDROID-CUSTOM"]; len(custom) != 1 {
+		t.Errorf("unmapped X-ANDROID-CUSTOM fields = %d, want 1", len(custom))
+	}
+
+	if got := CardFullName(cards[1]); got != "Bob" {
+		t.Errorf("second card FN = %q, want Bob (derived from N)", got)
+	}
+}
+
+// testdata/contacts2.db is a copy of Android's contact database schema,
+// with 512-byte pages so that its tables span several pages and the photo
+// overflows. Ada is two linked raw contacts, one raw contact is deleted,
+// Bob is not linked to a contact and has only a formatted address, and
+// thirty filler contacts follow.
+func TestAndroidReader_ContactsDB(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "contacts2.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	cards, failed, err := ParseAndroidVCF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 0 {
+		t.Errorf("failed = %+v", failed)
+	}
+	if len(cards) != 32 {
+		t.Fatalf("got %d cards, want 32", len(cards))
+	}
+	byName := map[string]vcard.Card{}
+	for _, card := range cards {
+		if CardUID(card) == "" {
+			t.Errorf("%s has no UID", CardFullName(card))
+		}
+		byName[CardFullName(card)] = card
+	}
+	if _, ok := byName["Gone Person"]; ok {
+		t.Error("a deleted raw contact was imported")
+	}
+
+	ada := byName["Ada Lovelace"]
+	if ada == nil {
+		t.Fatalf("no Ada in %v", byName)
+	}
+	if n := ada.Name(); n == nil || n.GivenName != "Ada" || n.FamilyName != "Lovelace" {
+		t.Errorf("N = %+v", n)
+	}
+	if tels := ada[vcard.FieldTelephone]; len(tels) != 1 || tels[0].Value != "+44 20 7946 0000" || tels[0].Params.Get(vcard.ParamType) != "cell" {
+		t.Errorf("TEL = %+v, want the linked contacts' number once, typed cell", tels)
+	}
+	if email := ada.Get(vcard.FieldEmail); email == nil || email.Value != "ada@example.com" || email.Params.Get(vcard.ParamType) != "home" {
+		t.Errorf("EMAIL = %+v", email)
+	}
+	if adr := ada.Address(); adr == nil || adr.Locality != "London" || adr.PostalCode != "SW1Y 4JH" || adr.Country != "United Kingdom" {
+		t.Errorf("ADR = %+v", adr)
+	}
+	want := map[string]string{
+		vcard.FieldOrganization: "Analytical Engines;Research",
+		vcard.FieldTitle:        "Programmer",
+		vcard.FieldBirthday:     "18151210",
+		vcard.FieldRelated:      "William King",
+		vcard.FieldNote:         "Wrote the first program.",
+		vcard.FieldNickname:     "Enchantress of Numbers",
+		vcard.FieldURL:          "https://example.com/ada",
+	}
+	for field, value := range want {
+		if got := ada.Value(field); got != value {
+			t.Errorf("%s = %q, want %q", field, got, value)
+		}
+	}
+	if !strings.HasPrefix(ada.Value(vcard.FieldPhoto), "data:image/jpeg;base64,") {
+		t.Errorf("PHOTO = %.40q, want a JPEG data URI", ada.Value(vcard.FieldPhoto))
+	}
+	photo, err := FetchPhoto(ada)
+	if err != nil {
+		t.Fatalf("PHOTO: %v", err)
+	}
+	if len(photo) != 4+256*8 || photo[256] != 252 {
+		t.Errorf("PHOTO has %d bytes, want the whole overflowing blob", len(photo))
+	}
+
+	bob := byName["Bob"]
+	if adr := bob.Address(); adr == nil || adr.StreetAddress != "Somewhere 5, Berlin" {
+		t.Errorf("Bob's ADR = %+v, want the formatted address", adr)
+	}
+	if tel := byName["Filler 29"].Value(vcard.FieldTelephone); tel != "+1 555 0129" {
+		t.Errorf("last filler TEL = %q", tel)
+	}
+}
+
+func TestAndroidReader_DamagedDB(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "contacts2.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = ParseAndroidVCF(bytes.NewReader(data[:len(data)/2]))
+	if err == nil || !strings.Contains(err.Error(), "android contact database") {
+		t.Errorf("got %v, want an error reading a truncated database", err)
+	}
+}
+
+func TestParseAndroidVCF_SkipsDamagedCards(t *testing.T) {
+	// A card cut off before its END line.
+	damaged := "BEGIN:VCARD\r\n" +
+		"VERSION:2.1\r\n" +
+		"FN:Broken\r\n"
+	cards, failed, err := ParseAndroidVCF(strings.NewReader(damaged + androidExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 {
+		t.Errorf("got %d cards, want the 2 intact ones", len(cards))
+	}
+	if len(failed) != 1 || failed[0].Record != 1 || failed[0].Error == "" {
+		t.Errorf("failed = %+v, want record 1", failed)
+	}
+}
+
+func TestAndroidReader(t *testing.T) {
+	ar, err := NewAndroidReader(strings.NewReader("junk before the first card\r\n" + androidExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for {
+		card, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, CardFullName(card))
+	}
+	if got := strings.Join(names, ","); got != "Jürgen Müller,Bob" {
+		t.Errorf("cards = %s", got)
+	}
+
+	first, _, _ := ParseAndroidVCF(strings.NewReader(androidExport))
+	again, _, _ := ParseAndroidVCF(strings.NewReader(androidExport))
+	if CardUID(first[1]) != CardUID(again[1]) {
+		t.Error("a card without a UID got a different one on the second read")
+	}
+}
@@ -0,0 +1,236 @@
+package contacts
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Actors recorded in the audit log.
+const (
+	ActorCLI  = "cli"
+	ActorSync = "sync"
+	ActorAPI  = "api"
+)
+
+// AuditEntry is one create, update or delete recorded in the audit log.
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"`
+	Action string    `json:"action"`
+	UID    string    `json:"uid"`
+	Name   string    `json:"name,omitempty"`
+	Fields []string  `json:"fields,omitempty"`
+	// PreviousUID is the contact's old UID for a "rename".
+	PreviousUID string `json:"previous_uid,omitempty"`
+	// PreviousAddresses are the ADR values an "update" replaced.
+	PreviousAddresses []string `json:"previous_addresses,omitempty"`
+}
+
+// auditIgnoredFields are bookkeeping fields that change on every write and
+// say nothing about what the user changed.
+var auditIgnoredFields = map[string]bool{
+	vcard.FieldRevision: true,
+	"X-LAST-SYNCED":     true,
+	FieldProviderID:     true,
+}
+
+// changedFields summarizes the differences between two versions of a card
+// as "+FIELD" (added), "-FIELD" (removed) and "~FIELD" (changed).
+func changedFields(old, new vcard.Card) []string {
+	var out []string
+	for _, c := range DiffCards(old, new) {
+		out = append(out, c.String())
+	}
+	return out
+}
+
+func fieldValues(fields []*vcard.Field) string {
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f.Value)
+		keys := make([]string, 0, len(f.Params))
+		for k := range f.Params {
+			// A new update stamp alone is not a change to the field.
+			if k != ParamUpdated {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(";" + k + "=" + strings.Join(f.Params[k], ","))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (cm *ContactManager) auditPath() string {
+	return filepath.Join(cm.dir, "audit.log")
+}
+
+func (cm *ContactManager) appendAudit(entry AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	f, err := os.OpenFile(cm.auditPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, cm.fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// auditWrite records the change from old (nil for a new contact) to card
+// and notifies webhooks. Writes that change nothing are not logged.
+func (cm *ContactManager) auditWrite(actor string, old, card vcard.Card) error {
+	entry, ok := writeEntry(actor, old, card)
+	if !ok {
+		return nil
+	}
+	if err := cm.appendAudit(entry); err != nil {
+		return err
+	}
+	cm.notifyContact(entry, card)
+	return nil
+}
+
+// writeEntry returns the audit entry for the change from old (nil for a
+// new contact) to card, and false if nothing changed.
+func writeEntry(actor string, old, card vcard.Card) (AuditEntry, bool) {
+	entry := AuditEntry{
+		Actor:  actor,
+		Action: "update",
+		UID:    CardUID(card),
+		Name:   CardFullName(card),
+	}
+	for _, c := range DiffCards(old, card) {
+		entry.Fields = append(entry.Fields, c.String())
+		if c.Field == vcard.FieldAddress && c.Kind == FieldChanged {
+			for _, adr := range c.Before {
+				entry.PreviousAddresses = append(entry.PreviousAddresses, adr.Value)
+			}
+		}
+	}
+	if old == nil {
+		entry.Action = "create"
+	} else if len(entry.Fields) == 0 {
+		return entry, false
+	}
+	return entry, true
+}
+
+// AuditLog returns audit entries recorded at or after since, oldest first.
+// If contact is non-empty only entries whose UID or name matches it
+// (case-insensitively) are returned.
+func (cm *ContactManager) AuditLog(contact string, since time.Time) ([]AuditEntry, error) {
+	f, err := os.Open(cm.auditPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log: %w", err)
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		if contact != "" && entry.UID != contact && entry.PreviousUID != contact && !strings.EqualFold(entry.Name, contact) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// ParseSince parses a relative age such as "7d", "2w", "6m" (months), "1y"
+// or a Go duration like "36h", counted back from now, or an absolute date
+// (YYYY-MM-DD or RFC 3339).
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if len(s) >= 2 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err == nil && n >= 0 {
+			switch s[len(s)-1] {
+			case 'd':
+				return now.AddDate(0, 0, -n), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*n), nil
+			case 'm':
+				return now.AddDate(0, -n, 0), nil
+			case 'y':
+				return now.AddDate(-n, 0, 0), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a date (2006-01-02) or an age like 7d, 2w, 6m, 1y", s)
+}
+
+// ModifiedSince returns a filter matching contacts created or modified at
+// or after since: their REV or X-LAST-SYNCED timestamp is that recent, or
+// the audit log records a create or update of them in that time.
+func (cm *ContactManager) ModifiedSince(since time.Time) (Filter, error) {
+	entries, err := cm.AuditLog("", since)
+	if err != nil {
+		return nil, err
+	}
+	touched := map[string]bool{}
+	for _, e := range entries {
+		if e.Action != "delete" {
+			touched[e.UID] = true
+		}
+	}
+	return func(card vcard.Card) bool {
+		if touched[CardUID(card)] {
+			return true
+		}
+		if rev, ok := CardRevision(card); ok && !rev.Before(since) {
+			return true
+		}
+		synced, err := time.Parse("20060102T150405Z", card.Value("X-LAST-SYNCED"))
+		return err == nil && !synced.Before(since)
+	}, nil
+}
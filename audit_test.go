@@ -0,0 +1,174 @@
+package contacts
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestChangedFields(t *testing.T) {
+	old := NewCard("Alice")
+	old.SetValue(vcard.FieldNote, "old note")
+	old.SetValue(vcard.FieldTitle, "Engineer")
+	new := NewCard("Alice")
+	new.SetValue(vcard.FieldUID, CardUID(old))
+	new.SetValue(vcard.FieldNote, "new note")
+	new.Add(vcard.FieldEmail, &vcard.Field{Value: "alice@example.com"})
+	new.SetValue(vcard.FieldRevision, "20240101T000000Z")
+	new.SetValue(vcard.FieldFormattedName, "Alice")
+	MarkUpdated(new.Get(vcard.FieldFormattedName), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	got := changedFields(old, new)
+	want := []string{"+EMAIL", "~NOTE", "-TITLE"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestContactManager_AuditLog(t *testing.T) {
+	dir := t.TempDir()
+	cm, err := NewContactManager(nil, dir, WithActor(ActorAPI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Alice")
+	card.SetValue(vcard.FieldUID, "alice")
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	// Unchanged write is not logged
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue(vcard.FieldNote, "met at conference")
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	other := NewCard("Bob")
+	if err := cm.WriteContact(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.DeleteContact("alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := cm.AuditLog("alice", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	for i, action := range []string{"create", "update", "delete"} {
+		if entries[i].Action != action {
+			t.Errorf("entry %d: got %s, want %s", i, entries[i].Action, action)
+		}
+		if entries[i].Actor != ActorAPI {
+			t.Errorf("entry %d: got actor %s, want %s", i, entries[i].Actor, ActorAPI)
+		}
+	}
+	if !reflect.DeepEqual(entries[1].Fields, []string{"+NOTE"}) {
+		t.Errorf("update fields: got %v", entries[1].Fields)
+	}
+
+	all, err := cm.AuditLog("", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("expected 4 entries in total, got %d", len(all))
+	}
+	recent, err := cm.AuditLog("", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 0 {
+		t.Errorf("expected no entries in the future, got %d", len(recent))
+	}
+}
+
+func TestContactManager_SyncAudit(t *testing.T) {
+	dir := t.TempDir()
+	card := NewCard("Synced")
+	card.SetValue(vcard.FieldUID, "s1")
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{card}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cm.SyncContacts(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := cm.AuditLog("", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Actor != ActorSync || entries[0].Action != "create" {
+		t.Errorf("expected a single sync create, got %+v", entries)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"6m", now.AddDate(0, -6, 0)},
+		{"1y", now.AddDate(-1, 0, 0)},
+		{"36h", now.Add(-36 * time.Hour)},
+		{"2024-01-01", time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSince(tt.in, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if _, err := ParseSince("soon", now); err == nil {
+		t.Error("expected error for invalid input")
+	}
+}
+
+func TestContactManager_ModifiedSince(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := NewCard("Written Today")
+	if err := cm.WriteContact(written); err != nil {
+		t.Fatal(err)
+	}
+	since := time.Now().Add(-time.Hour)
+
+	revised := NewCard("Recent REV")
+	revised.SetValue(vcard.FieldRevision, time.Now().UTC().Format("20060102T150405Z"))
+	synced := NewCard("Recently Synced")
+	synced.SetValue("X-LAST-SYNCED", time.Now().UTC().Format("20060102T150405Z"))
+	old := NewCard("Untouched")
+	old.SetValue(vcard.FieldRevision, "20200101T000000Z")
+	old.SetValue("X-LAST-SYNCED", "20200101T000000Z")
+
+	filter, err := cm.ModifiedSince(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, card := range []vcard.Card{written, revised, synced} {
+		if !filter(card) {
+			t.Errorf("%s not matched", CardFullName(card))
+		}
+	}
+	if filter(old) {
+		t.Error("contact untouched since 2020 matched")
+	}
+}
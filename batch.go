@@ -0,0 +1,429 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/emersion/go-vcard"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidBatch means a batch operation is malformed: an unknown
+// operation, a missing card or a mismatched or invalid UID.
+var ErrInvalidBatch = errors.New("invalid batch operation")
+
+// BatchOpKind is what a BatchOp does.
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+// BatchOp is one operation of a batch applied with ApplyBatch.
+type BatchOp struct {
+	Op BatchOpKind
+	// UID names the contact to update or delete. A create takes its UID
+	// from Card, or is given a new one.
+	UID string
+	// Card is the new version of the contact, for creates and updates.
+	Card vcard.Card
+	// IfMatch, if set, is the ETag the stored contact must have.
+	IfMatch string
+}
+
+// BatchResult is the outcome of one BatchOp.
+type BatchResult struct {
+	Op  BatchOpKind
+	UID string
+	// Card is the stored contact; nil for deletes.
+	Card vcard.Card
+	// Pending is true if the change could not be pushed to the provider
+	// yet. It stays queued and is retried before the next sync.
+	Pending bool
+}
+
+// ApplyBatch applies ops to the local store as one transaction: every
+// operation is checked first (creates must not exist, updates and deletes
+// must, and IfMatch must match), and if any check or write fails the store
+// is left as it was. The changes are then queued for the provider and
+// pushed in order; a push that fails stays queued rather than undoing the
+// local change. The store stays locked while the batch is checked and
+// written, but not while it is pushed.
+func (cm *ContactManager) ApplyBatch(ops []BatchOp) ([]BatchResult, error) {
+	results, err := cm.applyBatch(ops)
+	if err != nil || !slices.ContainsFunc(results, func(r BatchResult) bool { return r.Pending }) {
+		return results, err
+	}
+	pushed, _ := cm.PushPending()
+	for i := range results {
+		uid, ok := pushed[results[i].UID]
+		if !ok || !results[i].Pending {
+			continue
+		}
+		results[i].Pending = false
+		if uid != results[i].UID {
+			// The provider assigned its own UID.
+			results[i].UID = uid
+			if results[i].Card, err = cm.GetContact(uid); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// applyBatch checks and writes the batch under the store lock and queues
+// its changes for the provider, marking their results Pending.
+func (cm *ContactManager) applyBatch(ops []BatchOp) ([]BatchResult, error) {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	seen := map[string]bool{}
+	old := make([]vcard.Card, len(ops))
+	for i := range ops {
+		op := &ops[i]
+		if op.Op == BatchCreate && op.Card != nil {
+			if CardUID(op.Card) == "" {
+				op.Card.SetValue(vcard.FieldUID, uuid.New().String())
+			}
+			normalizeUID(op.Card)
+			if cm.stableUIDs {
+				if err := cm.assignStableUID(op.Card); err != nil {
+					return nil, fmt.Errorf("operation %d: %w", i+1, err)
+				}
+			}
+			op.UID = CardUID(op.Card)
+		}
+		existing, err := cm.checkBatchOp(*op)
+		if err == nil && seen[op.UID] {
+			err = fmt.Errorf("%w: %s appears more than once in the batch", ErrConflict, op.UID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i+1, err)
+		}
+		seen[op.UID] = true
+		old[i] = existing
+	}
+
+	index, err := cm.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	ids, err := cm.IDMap()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := map[string][]byte{}
+	// restore puts back the contact files as they were before the batch,
+	// returning what it couldn't.
+	restore := func() error {
+		var errs []error
+		for uid, data := range snapshot {
+			path := filepath.Join(cm.storagePath, uid+".vcf")
+			if data == nil {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					errs = append(errs, fmt.Errorf("failed to restore %s: %w", uid, err))
+				}
+			} else if err := writeFileAtomic(path, data, cm.fileMode); err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", uid, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	rev := time.Now().UTC().Format("20060102T150405Z")
+	for i, op := range ops {
+		path := filepath.Join(cm.storagePath, op.UID+".vcf")
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Join(fmt.Errorf("failed to read contact file: %w", err), restore())
+		}
+		snapshot[op.UID] = data
+		if op.Op == BatchDelete {
+			err = os.Remove(path)
+			delete(index, op.UID)
+		} else {
+			op.Card.SetValue(vcard.FieldRevision, rev)
+			restampEdits(old[i], op.Card)
+			if data, err = EncodeCard(op.Card); err == nil {
+				err = writeFileAtomic(path, data, cm.fileMode)
+				index[op.UID] = indexEntry{Hash: hashContent(data)}
+			}
+		}
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("operation %d: failed to %s %s: %w", i+1, op.Op, op.UID, err), restore())
+		}
+	}
+	// The audit log is written before the index is saved, which commits
+	// the batch, so failing to record it undoes the batch instead of
+	// failing one already made.
+	entries := make([]*AuditEntry, len(ops))
+	for i, op := range ops {
+		entry := AuditEntry{Actor: cm.actor, Action: "delete", UID: op.UID, Name: CardFullName(old[i])}
+		ok := true
+		if op.Op != BatchDelete {
+			entry, ok = writeEntry(cm.actor, old[i], op.Card)
+		}
+		if !ok {
+			continue
+		}
+		if err := cm.appendAudit(entry); err != nil {
+			return nil, errors.Join(err, restore())
+		}
+		entries[i] = &entry
+	}
+	if err := cm.saveIndex(index); err != nil {
+		return nil, errors.Join(err, restore())
+	}
+	cm.invalidateNames()
+	for i, entry := range entries {
+		switch {
+		case entry == nil:
+		case ops[i].Op == BatchDelete:
+			cm.notifyContact(*entry, nil)
+		default:
+			cm.notifyContact(*entry, ops[i].Card)
+		}
+	}
+
+	var queue []pendingPush
+	queued := map[string]bool{}
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = BatchResult{Op: op.Op, UID: op.UID, Card: op.Card}
+		if op.Op == BatchDelete {
+			results[i].Card = nil
+		}
+		switch {
+		case cm.provider == nil, cm.readOnly():
+		case op.Op == BatchDelete:
+			// Contacts that exist only locally have nothing to delete.
+			id, ok := ids.ID(cm.providerName(), op.UID)
+			if !ok {
+				id = ProviderID(old[i])
+			}
+			if id != "" {
+				queue = append(queue, pendingPush{UID: op.UID, ProviderID: id, Delete: true})
+				queued[op.UID] = true
+			}
+		case op.Card.Kind() != vcard.KindGroup:
+			queue = append(queue, pendingPush{UID: op.UID})
+			queued[op.UID] = true
+		}
+	}
+	if len(queue) == 0 {
+		return results, nil
+	}
+	if err := cm.queuePushes(queue...); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Pending = queued[results[i].UID]
+	}
+	return results, nil
+}
+
+// checkBatchOp checks that op can be applied and returns the stored
+// version of its contact, if any.
+func (cm *ContactManager) checkBatchOp(op BatchOp) (vcard.Card, error) {
+	switch op.Op {
+	case BatchCreate, BatchUpdate:
+		if op.Card == nil {
+			return nil, fmt.Errorf("%w: %s needs a card", ErrInvalidBatch, op.Op)
+		}
+		if uid := CardUID(op.Card); uid != op.UID {
+			return nil, fmt.Errorf("%w: card UID %s does not match %s", ErrInvalidBatch, uid, op.UID)
+		}
+	case BatchDelete:
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q, expected create, update or delete", ErrInvalidBatch, op.Op)
+	}
+	if op.Card != nil {
+		if err := ValidateCard(op.Card); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidBatch, err)
+		}
+	} else if !uidInStore(op.UID) {
+		return nil, fmt.Errorf("%w: invalid UID %q", ErrInvalidBatch, op.UID)
+	}
+	existing, err := cm.GetContact(op.UID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := cm.checkWritable(existing); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case op.Op == BatchCreate && existing != nil:
+		return nil, fmt.Errorf("%w: a contact with UID %s already exists", ErrConflict, op.UID)
+	case op.Op != BatchCreate && existing == nil:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, op.UID)
+	}
+	if op.IfMatch != "" {
+		etag, err := CardETag(existing)
+		if err != nil {
+			return nil, err
+		}
+		if !ETagMatches(op.IfMatch, etag) {
+			return nil, fmt.Errorf("%w: %s", ErrPreconditionFailed, op.UID)
+		}
+	}
+	return existing, nil
+}
+
+// pendingPush is a local change waiting to be pushed to the provider.
+type pendingPush struct {
+	UID string `json:"uid"`
+	// ProviderID is the provider's ID of a deleted contact.
+	ProviderID string `json:"provider_id,omitempty"`
+	Delete     bool   `json:"delete,omitempty"`
+}
+
+func (cm *ContactManager) pendingPath() string {
+	return filepath.Join(cm.dir, "pending.json")
+}
+
+func (cm *ContactManager) loadPending() ([]pendingPush, error) {
+	data, err := os.ReadFile(cm.pendingPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending changes: %w", err)
+	}
+	var queue []pendingPush
+	if err := json.Unmarshal(data, &queue); err != nil {
+		return nil, fmt.Errorf("failed to parse pending changes: %w", err)
+	}
+	return queue, nil
+}
+
+func (cm *ContactManager) savePending(queue []pendingPush) error {
+	if len(queue) == 0 {
+		if err := os.Remove(cm.pendingPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove pending changes: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(queue)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending changes: %w", err)
+	}
+	if err := writeFileAtomic(cm.pendingPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write pending changes: %w", err)
+	}
+	return nil
+}
+
+func (cm *ContactManager) queuePushes(pushes ...pendingPush) error {
+	queue, err := cm.loadPending()
+	if err != nil {
+		return err
+	}
+	return cm.savePending(append(queue, pushes...))
+}
+
+// PushPending pushes queued local changes to the provider in order,
+// stopping at the first failure; that change and the rest stay queued. It
+// returns the UIDs pushed, mapped to the UID the provider gave each
+// contact. The store is locked while the queue and the pushed contacts
+// are updated, but not while the provider is called.
+func (cm *ContactManager) PushPending() (map[string]string, error) {
+	pushed := map[string]string{}
+	if cm.provider == nil {
+		return pushed, ErrNotInitialized
+	}
+	for {
+		queue, err := cm.loadPending()
+		if err != nil || len(queue) == 0 {
+			return pushed, err
+		}
+		uid, err := cm.push(queue[0])
+		if err != nil {
+			return pushed, err
+		}
+		pushed[queue[0].UID] = uid
+	}
+}
+
+// push sends one queued change to the provider, then records the result
+// and takes the change off the queue under the store lock. It returns the
+// contact's UID afterwards.
+func (cm *ContactManager) push(p pendingPush) (string, error) {
+	var card vcard.Card
+	var before []byte
+	if p.Delete {
+		if err := cm.provider.DeleteContact(p.ProviderID); err != nil && !errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("failed to delete contact from provider: %w", err)
+		}
+	} else {
+		var err error
+		if card, err = cm.GetContact(p.UID); err != nil {
+			return "", err
+		}
+		// A card deleted locally since it was queued has nothing to push.
+		if card != nil {
+			before, _ = os.ReadFile(filepath.Join(cm.storagePath, p.UID+".vcf"))
+			if err := cm.provider.WriteContact(card); err != nil {
+				return "", fmt.Errorf("failed to write contact to provider: %w", err)
+			}
+		}
+	}
+
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	uid := p.UID
+	name := cm.providerName()
+	switch {
+	case p.Delete:
+		err = cm.updateIDMap(func(ids IDMap) { ids.Unmap(name, p.UID) })
+	case card == nil:
+	case !cm.storedAs(p.UID, before):
+		// Changed locally while it was pushed: keep the change, which is
+		// queued and pushed next, and only record the provider's ID.
+		if ProviderID(card) == "" {
+			card.SetValue(FieldProviderID, CardUID(card))
+		}
+		err = cm.updateIDMap(func(ids IDMap) { ids.Set(name, ProviderID(card), p.UID) })
+	default:
+		uid = CardUID(card)
+		err = cm.adoptProviderID(card, p.UID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return uid, cm.dequeuePush(p)
+}
+
+// storedAs reports whether the contact file of uid holds data.
+func (cm *ContactManager) storedAs(uid string, data []byte) bool {
+	stored, err := os.ReadFile(filepath.Join(cm.storagePath, uid+".vcf"))
+	return err == nil && bytes.Equal(stored, data)
+}
+
+// dequeuePush takes a pushed change off the queue. It is saved after every
+// push, so one that succeeded isn't sent again if a later one fails or the
+// process dies.
+func (cm *ContactManager) dequeuePush(p pendingPush) error {
+	queue, err := cm.loadPending()
+	if err != nil {
+		return err
+	}
+	if i := slices.Index(queue, p); i >= 0 {
+		return cm.savePending(slices.Delete(queue, i, i+1))
+	}
+	return nil
+}
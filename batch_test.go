@@ -0,0 +1,153 @@
+package contacts
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+// flakyProvider fails writes while down is set.
+type flakyProvider struct {
+	recordingProvider
+	down bool
+}
+
+func (p *flakyProvider) WriteContact(c vcard.Card) error {
+	if p.down {
+		return ErrProviderUnavailable
+	}
+	return p.recordingProvider.WriteContact(c)
+}
+
+func TestContactManager_ApplyBatch(t *testing.T) {
+	provider := &flakyProvider{}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada, charles := NewCard("Ada Lovelace"), NewCard("Charles Babbage")
+	if err := cm.WriteContacts([]vcard.Card{ada, charles}); err != nil {
+		t.Fatal(err)
+	}
+	provider.written = nil
+
+	update := NewCard("Ada King")
+	update.SetValue(vcard.FieldUID, CardUID(ada))
+	ops := []BatchOp{
+		{Op: BatchCreate, Card: NewCard("Mary Somerville")},
+		{Op: BatchUpdate, UID: CardUID(ada), Card: update},
+		{Op: BatchDelete, UID: CardUID(charles)},
+		{Op: BatchDelete, UID: "missing"},
+	}
+	if _, err := cm.ApplyBatch(ops); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("ApplyBatch with a missing contact = %v, want ErrNotFound", err)
+	}
+	if list, _ := cm.ListContacts(); len(list) != 2 {
+		t.Errorf("got %d contacts after a rejected batch, want 2", len(list))
+	}
+	if got, _ := cm.GetContact(CardUID(ada)); CardFullName(got) != "Ada Lovelace" {
+		t.Errorf("rejected batch changed %s to %q", CardUID(ada), CardFullName(got))
+	}
+
+	stale := ops[1]
+	stale.IfMatch = `"stale"`
+	if _, err := cm.ApplyBatch([]BatchOp{stale}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("ApplyBatch with a stale If-Match = %v, want ErrPreconditionFailed", err)
+	}
+	if _, err := cm.ApplyBatch([]BatchOp{{Op: "rename", UID: CardUID(ada)}}); !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("ApplyBatch with an unknown op = %v, want ErrInvalidBatch", err)
+	}
+
+	provider.down = true
+	results, err := cm.ApplyBatch(ops[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || !results[0].Pending || !results[1].Pending || CardFullName(results[1].Card) != "Ada King" {
+		t.Fatalf("results = %+v, want three pending changes", results)
+	}
+	if list, _ := cm.ListContacts(); len(list) != 2 {
+		t.Errorf("got %d contacts after the batch, want 2", len(list))
+	}
+	if _, err := cm.SyncContacts(); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("SyncContacts with pending changes and the provider down = %v", err)
+	}
+
+	provider.down = false
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.written) != 2 {
+		t.Errorf("provider writes = %v, want the create and the update", provider.written)
+	}
+	if queue, err := cm.loadPending(); err != nil || len(queue) != 0 {
+		t.Errorf("pending after sync = %v, %v", queue, err)
+	}
+}
+
+func TestContactManager_ApplyBatchAuditFailure(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada Lovelace")
+	if err := cm.WriteContact(ada); err != nil {
+		t.Fatal(err)
+	}
+	// A directory in the audit log's place makes appending to it fail.
+	if err := os.Rename(cm.auditPath(), cm.auditPath()+".old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(cm.auditPath(), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	update := NewCard("Ada King")
+	update.SetValue(vcard.FieldUID, CardUID(ada))
+	ops := []BatchOp{{Op: BatchCreate, Card: NewCard("Mary Somerville")}, {Op: BatchUpdate, UID: CardUID(ada), Card: update}}
+	if _, err := cm.ApplyBatch(ops); err == nil {
+		t.Fatal("ApplyBatch succeeded without an audit log")
+	}
+	if list, _ := cm.ListContacts(); len(list) != 1 || CardFullName(list[0]) != "Ada Lovelace" {
+		t.Errorf("contacts after a failed batch = %v, want Ada Lovelace only", list)
+	}
+	if issues, err := cm.Verify(); err != nil || len(issues) > 0 {
+		t.Errorf("Verify after a failed batch = %v, %v", issues, err)
+	}
+}
+
+// queueCheckingProvider records the queued changes still pending as each
+// write reaches it.
+type queueCheckingProvider struct {
+	mockProvider
+	cm      *ContactManager
+	pending []int
+}
+
+func (p *queueCheckingProvider) WriteContact(c vcard.Card) error {
+	queue, err := p.cm.loadPending()
+	p.pending = append(p.pending, len(queue))
+	return err
+}
+
+func TestContactManager_PushPendingSavesEachPush(t *testing.T) {
+	provider := &queueCheckingProvider{}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.cm = cm
+	ops := []BatchOp{
+		{Op: BatchCreate, Card: NewCard("Ada Lovelace")},
+		{Op: BatchCreate, Card: NewCard("Charles Babbage")},
+		{Op: BatchCreate, Card: NewCard("Mary Somerville")},
+	}
+	if _, err := cm.ApplyBatch(ops); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 2, 1}; !slices.Equal(provider.pending, want) {
+		t.Errorf("queue length at each push = %v, want %v", provider.pending, want)
+	}
+}
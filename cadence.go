@@ -0,0 +1,107 @@
+package contacts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldCadence holds how often the user wants to be in touch with a
+// contact, e.g. "2w".
+const FieldCadence = "X-CADENCE"
+
+// Cadence is a keep-in-touch interval of N days, weeks, months or years.
+type Cadence struct {
+	N    int
+	Unit byte // 'd', 'w', 'm' or 'y'
+}
+
+// ParseCadence parses an interval such as "10d", "2w", "1m" or "1y".
+func ParseCadence(s string) (Cadence, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) >= 2 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if unit := s[len(s)-1]; err == nil && n > 0 && strings.IndexByte("dwmy", unit) >= 0 {
+			return Cadence{N: n, Unit: unit}, nil
+		}
+	}
+	return Cadence{}, fmt.Errorf("invalid cadence %q: use a count and unit like 10d, 2w, 1m or 1y", s)
+}
+
+func (c Cadence) String() string {
+	return strconv.Itoa(c.N) + string(c.Unit)
+}
+
+// After returns when the next contact is due if the last one was at t.
+func (c Cadence) After(t time.Time) time.Time {
+	switch c.Unit {
+	case 'w':
+		return t.AddDate(0, 0, 7*c.N)
+	case 'm':
+		return t.AddDate(0, c.N, 0)
+	case 'y':
+		return t.AddDate(c.N, 0, 0)
+	}
+	return t.AddDate(0, 0, c.N)
+}
+
+// CardCadence returns the card's keep-in-touch cadence.
+func CardCadence(card vcard.Card) (Cadence, bool) {
+	c, err := ParseCadence(card.Value(FieldCadence))
+	return c, err == nil
+}
+
+// SetCadence sets the card's cadence, or removes it if c is zero. The
+// caller saves card.
+func SetCadence(card vcard.Card, c Cadence) {
+	if c.N == 0 {
+		delete(card, FieldCadence)
+		return
+	}
+	card.SetValue(FieldCadence, c.String())
+}
+
+// DueContact is a contact whose keep-in-touch cadence has run out.
+type DueContact struct {
+	Card    vcard.Card
+	Cadence Cadence
+	// Last is the latest logged interaction, zero if there is none.
+	Last time.Time
+	// Due is when the contact fell due; zero if never contacted.
+	Due time.Time
+}
+
+// DueContacts returns the contacts with a cadence whose last interaction
+// is longer ago than the cadence allows at now, most overdue first.
+// Contacts never interacted with come first.
+func (cm *ContactManager) DueContacts(now time.Time) ([]DueContact, error) {
+	list, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	last, err := cm.LastInteractions()
+	if err != nil {
+		return nil, err
+	}
+	var out []DueContact
+	for _, card := range list {
+		c, ok := CardCadence(card)
+		if !ok {
+			continue
+		}
+		d := DueContact{Card: card, Cadence: c, Last: last[CardUID(card)]}
+		if !d.Last.IsZero() {
+			d.Due = c.After(d.Last)
+			if d.Due.After(now) {
+				continue
+			}
+		}
+		out = append(out, d)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Due.Before(out[j].Due) })
+	return out, nil
+}
@@ -0,0 +1,94 @@
+package contacts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseCadence(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"10d", time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)},
+		{"2w", time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)},
+		{"1M", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{"1y", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCadence(tt.in)
+		if err != nil {
+			t.Errorf("ParseCadence(%q): %v", tt.in, err)
+			continue
+		}
+		if got := c.After(start); !got.Equal(tt.want) {
+			t.Errorf("ParseCadence(%q).After = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "w", "0d", "-1w", "2 weeks", "3h"} {
+		if _, err := ParseCadence(bad); err == nil {
+			t.Errorf("ParseCadence(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestContactManager_DueContacts(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	add := func(name, cadence string, last time.Time) vcard.Card {
+		card := NewCard(name)
+		if cadence != "" {
+			c, err := ParseCadence(cadence)
+			if err != nil {
+				t.Fatal(err)
+			}
+			SetCadence(card, c)
+		}
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+		if !last.IsZero() {
+			if err := cm.RecordInteractions(Interaction{Time: last, UID: CardUID(card)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return card
+	}
+	add("Recent", "1m", now.AddDate(0, 0, -10))
+	add("Overdue", "2w", now.AddDate(0, 0, -20))
+	add("Very Overdue", "1w", now.AddDate(0, -2, 0))
+	add("Never", "1y", time.Time{})
+	add("No Cadence", "", time.Time{})
+
+	due, err := cm.DueContacts(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, d := range due {
+		names = append(names, CardFullName(d.Card))
+	}
+	want := []string{"Never", "Very Overdue", "Overdue"}
+	if len(names) != len(want) {
+		t.Fatalf("DueContacts = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("DueContacts = %v, want %v", names, want)
+			break
+		}
+	}
+
+	card := NewCard("Cleared")
+	SetCadence(card, Cadence{N: 1, Unit: 'w'})
+	SetCadence(card, Cadence{})
+	if _, ok := CardCadence(card); ok {
+		t.Error("SetCadence with zero cadence did not clear it")
+	}
+}
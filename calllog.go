@@ -0,0 +1,136 @@
+package contacts
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// LogEntry is a call or message from a phone's call or SMS log.
+type LogEntry struct {
+	Number string
+	Time   time.Time
+	// Kind is InteractionCall or InteractionMessage.
+	Kind string
+}
+
+// Call types in SMS Backup & Restore's calls.xml that were an actual
+// conversation; missed, rejected and blocked calls are skipped.
+const (
+	callIncoming = "1"
+	callOutgoing = "2"
+)
+
+// ParseSMSBackup parses a calls.xml or sms.xml file written by the Android
+// app SMS Backup & Restore. Group MMS yield one entry per other party.
+func ParseSMSBackup(r io.Reader) ([]LogEntry, error) {
+	dec := xml.NewDecoder(r)
+	var out []LogEntry
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sms backup: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		name := start.Name.Local
+		if name != "call" && name != "sms" && name != "mms" {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, a := range start.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		when, err := parseMillis(attrs["date"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date in sms backup %s: %w", name, err)
+		}
+		switch name {
+		case "call":
+			if t := attrs["type"]; t == callIncoming || t == callOutgoing {
+				out = append(out, LogEntry{Number: attrs["number"], Time: when, Kind: InteractionCall})
+			}
+		case "sms":
+			out = append(out, LogEntry{Number: attrs["address"], Time: when, Kind: InteractionMessage})
+		case "mms":
+			// The address attribute lists all parties separated by "~".
+			for _, number := range strings.Split(attrs["address"], "~") {
+				out = append(out, LogEntry{Number: number, Time: when, Kind: InteractionMessage})
+			}
+		}
+	}
+	return out, nil
+}
+
+// parseMillis parses a Unix time in milliseconds. Old backups store MMS
+// dates in seconds, which are recognized by their size.
+func parseMillis(s string) (time.Time, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if n < 1e11 {
+		return time.Unix(n, 0), nil
+	}
+	return time.UnixMilli(n), nil
+}
+
+// MatchLogEntries turns log entries into interactions with the cards whose
+// phone numbers match (see PhonesMatch). Entries matching no card are
+// counted in unmatched.
+func MatchLogEntries(cards []vcard.Card, entries []LogEntry) (matched []Interaction, unmatched int) {
+	uids := map[string]string{}
+	for _, e := range entries {
+		key := NormalizePhone(e.Number)
+		uid, seen := uids[key]
+		if !seen {
+			if card := matchByPhone(cards, e.Number); card != nil {
+				uid = CardUID(card)
+			}
+			uids[key] = uid
+		}
+		if uid == "" {
+			unmatched++
+			continue
+		}
+		matched = append(matched, Interaction{Time: e.Time, UID: uid, Kind: e.Kind})
+	}
+	return matched, unmatched
+}
+
+// ImportInteractions records the interactions not already in the log, so
+// importing the same call log twice does not count calls twice. It returns
+// how many were added.
+func (cm *ContactManager) ImportInteractions(interactions []Interaction) (int, error) {
+	existing, err := cm.Interactions("")
+	if err != nil {
+		return 0, err
+	}
+	type key struct {
+		uid, kind string
+		unix      int64
+	}
+	seen := map[key]bool{}
+	for _, in := range existing {
+		seen[key{in.UID, in.Kind, in.Time.UnixMilli()}] = true
+	}
+	var added []Interaction
+	for _, in := range interactions {
+		k := key{in.UID, in.Kind, in.Time.UnixMilli()}
+		if !seen[k] {
+			seen[k] = true
+			added = append(added, in)
+		}
+	}
+	return len(added), cm.RecordInteractions(added...)
+}
@@ -0,0 +1,85 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+const callsXML = `<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>
+<calls count="3">
+  <call number="+1 555-123-4567" duration="65" date="1717236000000" type="1" presentation="1" />
+  <call number="5551234567" duration="0" date="1717237000000" type="3" presentation="1" />
+  <call number="+44 7700 900123" duration="12" date="1717238000000" type="2" presentation="1" />
+</calls>`
+
+const smsXML = `<?xml version='1.0' encoding='UTF-8' standalone='yes' ?>
+<smses count="2">
+  <sms protocol="0" address="(555) 123-4567" date="1717240000000" type="2" body="hi" />
+  <mms date="1717241000" address="+15551234567~+15559876543" m_type="132">
+    <parts><part seq="0" ct="text/plain" text="group" /></parts>
+  </mms>
+</smses>`
+
+func TestParseSMSBackup(t *testing.T) {
+	calls, err := ParseSMSBackup(strings.NewReader(callsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2 (missed call skipped)", len(calls))
+	}
+	if calls[0].Kind != InteractionCall || !calls[0].Time.Equal(time.UnixMilli(1717236000000)) {
+		t.Errorf("calls[0] = %+v", calls[0])
+	}
+
+	msgs, err := ParseSMSBackup(strings.NewReader(smsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want 3", len(msgs))
+	}
+	if !msgs[1].Time.Equal(time.Unix(1717241000, 0)) || msgs[2].Number != "+15559876543" {
+		t.Errorf("mms entries = %+v", msgs[1:])
+	}
+
+	if _, err := ParseSMSBackup(strings.NewReader(`<calls><call date="yesterday" type="1"/></calls>`)); err == nil {
+		t.Error("invalid date accepted")
+	}
+}
+
+func TestMatchLogEntries(t *testing.T) {
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldTelephone, "+15551234567")
+	entries, err := ParseSMSBackup(strings.NewReader(callsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched, unmatched := MatchLogEntries([]vcard.Card{ada}, entries)
+	if len(matched) != 1 || matched[0].UID != CardUID(ada) || unmatched != 1 {
+		t.Errorf("MatchLogEntries = %+v, %d unmatched", matched, unmatched)
+	}
+}
+
+func TestContactManager_ImportInteractions(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []Interaction{
+		{Time: time.UnixMilli(1717236000000), UID: "ada", Kind: InteractionCall},
+		{Time: time.UnixMilli(1717240000000), UID: "ada", Kind: InteractionMessage},
+	}
+	if n, err := cm.ImportInteractions(in); err != nil || n != 2 {
+		t.Fatalf("first import = %d, %v; want 2", n, err)
+	}
+	if n, err := cm.ImportInteractions(in); err != nil || n != 0 {
+		t.Errorf("second import = %d, %v; want 0", n, err)
+	}
+	if all, _ := cm.Interactions("ada"); len(all) != 2 {
+		t.Errorf("log has %d interactions, want 2", len(all))
+	}
+}
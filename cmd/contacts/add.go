@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/charmbracelet/huh"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	addEmails   []string
+	addPhones   []string
+	addOrg      string
+	addTitle    string
+	addBirthday string
+	addNote     string
+	addOrgCard  string
+	addTemplate string
+	addPhoto    string
+	addForce    bool
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "add a new contact",
+	Long: `Add a new contact.
+
+With --template, fields are pre-filled from a template defined under
+"templates" in config.json, before the interactive form is shown:
+
+  "templates": {
+    "coworker": {"org": "Acme Inc", "email_domain": "acme.com", "tags": ["coworker"]}
+  }
+
+With --org-card, add a card for an organization instead of a person. People
+added later with a matching --org are linked to it.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if addOrgCard != "" {
+			return addOrganization(addOrgCard)
+		}
+		var tmpl contacts.ContactTemplate
+		if addTemplate != "" {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if tmpl, err = cfg.Template(addTemplate); err != nil {
+				return err
+			}
+			if addOrg == "" {
+				addOrg = tmpl.Org
+			}
+			if addTitle == "" {
+				addTitle = tmpl.Title
+			}
+		}
+		name := strings.Join(args, " ")
+		if name == "" {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return fmt.Errorf("a name is required")
+			}
+			var email, phone string
+			form := huh.NewForm(huh.NewGroup(
+				huh.NewInput().Title("Name").Value(&name).
+					Validate(func(s string) error {
+						if strings.TrimSpace(s) == "" {
+							return fmt.Errorf("required")
+						}
+						return nil
+					}),
+				huh.NewInput().Title("Email").Placeholder(emailPlaceholder(tmpl)).Value(&email),
+				huh.NewInput().Title("Phone").Value(&phone),
+				huh.NewInput().Title("Organization").Value(&addOrg),
+			))
+			if err := form.Run(); err != nil {
+				return err
+			}
+			if email = strings.TrimSpace(email); email != "" {
+				addEmails = append(addEmails, email)
+			}
+			if phone = strings.TrimSpace(phone); phone != "" {
+				addPhones = append(addPhones, phone)
+			}
+		}
+
+		card := contacts.NewCard(strings.TrimSpace(name))
+		for _, e := range addEmails {
+			card.Add(vcard.FieldEmail, &vcard.Field{Value: e})
+		}
+		for _, p := range addPhones {
+			card.Add(vcard.FieldTelephone, &vcard.Field{Value: p})
+		}
+		if addOrg != "" {
+			card.SetValue(vcard.FieldOrganization, addOrg)
+		}
+		if addTitle != "" {
+			card.SetValue(vcard.FieldTitle, addTitle)
+		}
+		if addBirthday != "" {
+			bday, err := parseBirthday(addBirthday)
+			if err != nil {
+				return err
+			}
+			card.SetValue(vcard.FieldBirthday, bday)
+		}
+		if addNote != "" {
+			card.SetValue(vcard.FieldNote, addNote)
+		}
+		if addPhoto != "" {
+			photo, err := photoFromFile(addPhoto)
+			if err != nil {
+				return err
+			}
+			card.SetValue(vcard.FieldPhoto, photo)
+		}
+		tmpl.Apply(card)
+		if err := enforcePolicy(card); err != nil {
+			return err
+		}
+
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		if addOrg != "" {
+			org, err := cm.FindOrg(addOrg)
+			if err != nil {
+				return err
+			}
+			if org != nil {
+				if err := contacts.LinkToOrg(card, org); err != nil {
+					return err
+				}
+			}
+		}
+		if !addForce {
+			done, err := resolveSimilar(cm, card)
+			if err != nil || done {
+				return err
+			}
+		}
+		if err := cm.WriteContact(card); err != nil {
+			return err
+		}
+		fmt.Println(contacts.CardUID(card))
+		infof("Added %s.\n", contacts.CardFullName(card))
+		return nil
+	},
+}
+
+// resolveSimilar warns when card looks like an existing contact. On a
+// terminal it offers to edit or merge into the existing contact instead;
+// done reports that it did so and card should not be added.
+func resolveSimilar(cm *contacts.ContactManager, card vcard.Card) (done bool, err error) {
+	list, err := cm.ListContacts()
+	if err != nil {
+		return false, err
+	}
+	similar := contacts.FindSimilar(card, list)
+	if len(similar) == 0 {
+		return false, nil
+	}
+	for _, s := range similar {
+		fmt.Fprintf(os.Stderr, "Warning: %s looks like existing contact %s (%s): %s\n",
+			contacts.CardFullName(card), contacts.CardFullName(s.Card), contacts.CardUID(s.Card), strings.Join(s.Reasons, ", "))
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, nil
+	}
+
+	options := []huh.Option[string]{huh.NewOption("Add as a new contact", "add")}
+	for i, s := range similar {
+		name := contacts.CardFullName(s.Card)
+		options = append(options,
+			huh.NewOption("Merge into "+name, fmt.Sprintf("merge:%d", i)),
+			huh.NewOption("Edit "+name+" instead", fmt.Sprintf("edit:%d", i)),
+		)
+	}
+	options = append(options, huh.NewOption("Cancel", "cancel"))
+	var choice string
+	if err := huh.NewSelect[string]().
+		Title("This contact may already exist").
+		Options(options...).
+		Value(&choice).
+		Run(); err != nil {
+		return false, err
+	}
+
+	action, index, _ := strings.Cut(choice, ":")
+	var existing vcard.Card
+	if i, err := strconv.Atoi(index); err == nil {
+		existing = similar[i].Card
+	}
+	switch action {
+	case "merge":
+		merged := contacts.Merge(existing, card, contacts.StrategyUnion)
+		if err := cm.WriteContact(merged); err != nil {
+			return false, err
+		}
+		infof("Merged into %s.\n", contacts.CardFullName(existing))
+		return true, nil
+	case "edit":
+		return true, editContact(cm, existing)
+	case "cancel":
+		infof("Cancelled.\n")
+		return true, nil
+	}
+	return false, nil
+}
+
+// addOrganization creates a KIND:org card named name.
+func addOrganization(name string) error {
+	cm, err := getManager()
+	if err != nil {
+		return err
+	}
+	existing, err := cm.FindOrg(name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("organization %q already exists (%s)", name, contacts.CardUID(existing))
+	}
+	card := contacts.NewOrgCard(name)
+	if err := cm.WriteContact(card); err != nil {
+		return err
+	}
+	fmt.Println(contacts.CardUID(card))
+	infof("Added organization %s.\n", name)
+	return nil
+}
+
+// emailPlaceholder hints at the template's email domain, which is added to
+// addresses entered without one.
+func emailPlaceholder(tmpl contacts.ContactTemplate) string {
+	if tmpl.EmailDomain == "" {
+		return ""
+	}
+	return tmpl.CompleteEmail("name")
+}
+
+// parseBirthday converts YYYY-MM-DD or --MM-DD (no year) to vCard date form.
+func parseBirthday(s string) (string, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Format("20060102"), nil
+	}
+	if t, err := time.Parse("--01-02", s); err == nil {
+		return t.Format("--0102"), nil
+	}
+	return "", fmt.Errorf("invalid birthday %q: expected YYYY-MM-DD or --MM-DD", s)
+}
+
+func init() {
+	addCmd.Flags().StringArrayVar(&addEmails, "email", nil, "email address; repeatable")
+	addCmd.Flags().StringArrayVar(&addPhones, "phone", nil, "phone number; repeatable")
+	addCmd.Flags().StringVar(&addOrg, "org", "", "organization")
+	addCmd.Flags().StringVar(&addTitle, "title", "", "job title")
+	addCmd.Flags().StringVar(&addBirthday, "birthday", "", "birthday (YYYY-MM-DD, or --MM-DD without a year)")
+	addCmd.Flags().StringVar(&addNote, "note", "", "free-form note")
+	addCmd.Flags().StringVar(&addPhoto, "photo", "", "attach an image file (JPEG, PNG, GIF, WebP or HEIC) as the contact's photo")
+	addCmd.Flags().BoolVarP(&addForce, "force", "f", false, "add without checking for similar existing contacts")
+	addCmd.Flags().StringVar(&addTemplate, "template", "", "pre-fill fields from a template in the config file")
+	addCmd.RegisterFlagCompletionFunc("template", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for name := range cfg.Templates {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+	addCmd.Flags().StringVar(&addOrgCard, "org-card", "", "add an organization card with this name instead of a person")
+	rootCmd.AddCommand(addCmd)
+}
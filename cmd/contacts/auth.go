@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts/provider/google"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "manage the Google OAuth token",
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show token validity, scopes and expiry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, err := getGoogleProvider()
+		if err != nil {
+			return err
+		}
+		info, err := provider.TokenStatus()
+		if err != nil {
+			fmt.Println("Status: invalid")
+			return fmt.Errorf("%w. Run 'contacts init' to re-authorize", err)
+		}
+		fmt.Println("Status: valid")
+		if info.Email != "" {
+			fmt.Printf("Account: %s\n", info.Email)
+		}
+		fmt.Printf("Scopes: %s\n", strings.Join(info.Scopes, " "))
+		fmt.Printf("Expires: %s (in %s)\n", info.Expiry.Local().Format("2006-01-02 15:04:05"), time.Until(info.Expiry).Round(time.Second))
+		return nil
+	},
+}
+
+var authRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "force a token refresh",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		provider, err := getGoogleProvider()
+		if err != nil {
+			return err
+		}
+		expiry, err := provider.RefreshAccessToken()
+		if err != nil {
+			return err
+		}
+		infof("Token refreshed, expires %s.\n", expiry.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+var authRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "revoke the token at Google and remove stored tokens",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		provider, err := google.NewProvider(cfg.Dir)
+		if err != nil {
+			return err
+		}
+		if err := provider.Revoke(); err != nil {
+			return err
+		}
+		infof("Token revoked. Run 'contacts init' to authorize again.\n")
+		return nil
+	},
+}
+
+// getGoogleProvider returns an initialized Google provider for the selected
+// data directory.
+func getGoogleProvider() (*google.Provider, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := google.NewProvider(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.Initialize(); err != nil {
+		return nil, fmt.Errorf("%w. Run 'contacts init' first", err)
+	}
+	return provider, nil
+}
+
+func init() {
+	authCmd.AddCommand(authStatusCmd, authRefreshCmd, authRevokeCmd)
+	rootCmd.AddCommand(authCmd)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	avatarFediverse bool
+	avatarDryRun    bool
+)
+
+var fetchAvatarCmd = &cobra.Command{
+	Use:   "fetch-avatar [contact...]",
+	Short: "fill in missing photos from contacts' online profiles",
+	Long: `Fill in the photo and profile URL of contacts from their online profiles.
+Contacts that already have a photo keep it; only the profile URL is added.
+
+With --fediverse, each contact's fediverse handle (a Mastodon profile or an
+X-SOCIALPROFILE such as @ada@mastodon.social) is resolved with WebFinger.
+
+Without arguments, every contact with a handle and no photo is looked up.`,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		var cards []vcard.Card
+		if len(args) > 0 {
+			for _, arg := range args {
+				card, err := cm.ResolveContact(arg)
+				if err != nil {
+					return err
+				}
+				cards = append(cards, card)
+			}
+		} else {
+			all, err := cm.ListContacts()
+			if err != nil {
+				return err
+			}
+			for _, card := range contacts.FilterCards(all, contacts.OfKind(vcard.KindIndividual)) {
+				if card.Value(vcard.FieldPhoto) == "" && len(contacts.FediverseHandles(card)) > 0 {
+					cards = append(cards, card)
+				}
+			}
+		}
+
+		filled := 0
+		for _, card := range cards {
+			name := contacts.CardFullName(card)
+			profile, changed, err := contacts.FillFromFediverse(card, cfg.Photos)
+			if err != nil {
+				if len(args) == 1 {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				if profile == nil {
+					continue
+				}
+			}
+			if !changed {
+				infof("%s: nothing new from %s\n", name, profile.Handle)
+				continue
+			}
+			if avatarDryRun {
+				infof("%s: would update from %s\n", name, profile.Handle)
+				continue
+			}
+			if err := cm.WriteContact(card); err != nil {
+				return err
+			}
+			infof("%s: updated from %s\n", name, profile.Handle)
+			filled++
+		}
+		if len(cards) == 0 {
+			infof("No contacts with a fediverse handle and no photo.\n")
+		} else if !avatarDryRun {
+			infof("Updated %d of %d contacts.\n", filled, len(cards))
+		}
+		return nil
+	},
+}
+
+func init() {
+	fetchAvatarCmd.Flags().BoolVar(&avatarFediverse, "fediverse", false, "resolve fediverse handles with WebFinger")
+	fetchAvatarCmd.Flags().BoolVar(&avatarDryRun, "dry-run", false, "show which contacts would change without writing them")
+	fetchAvatarCmd.MarkFlagRequired("fediverse")
+	rootCmd.AddCommand(fetchAvatarCmd)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var cadenceCmd = &cobra.Command{
+	Use:   "cadence",
+	Short: "set how often to keep in touch with contacts",
+	Long: `Set how often to keep in touch with contacts.
+
+A cadence such as 2w (every two weeks), 1m or 1y is stored on the contact.
+'contacts due' lists everyone not contacted within their cadence, judged
+by the interactions recorded with 'contacts touch'.`,
+}
+
+var cadenceSetCmd = &cobra.Command{
+	Use:   "set <contact> <interval>",
+	Short: "set a contact's cadence (e.g. 10d, 2w, 1m, 1y)",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return []string{"1w", "2w", "1m", "3m", "6m", "1y"}, cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := contacts.ParseCadence(args[1])
+		if err != nil {
+			return err
+		}
+		return setCadence(args[0], c)
+	},
+}
+
+var cadenceClearCmd = &cobra.Command{
+	Use:   "clear <contact>",
+	Short: "remove a contact's cadence",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setCadence(args[0], contacts.Cadence{})
+	},
+}
+
+func setCadence(query string, c contacts.Cadence) error {
+	cm, err := getManager()
+	if err != nil {
+		return err
+	}
+	card, err := cm.ResolveContact(query)
+	if err != nil {
+		return err
+	}
+	contacts.SetCadence(card, c)
+	if err := cm.WriteContact(card); err != nil {
+		return err
+	}
+	if c.N == 0 {
+		infof("Cleared cadence for %s.\n", contacts.CardFullName(card))
+	} else {
+		infof("Keeping in touch with %s every %s.\n", contacts.CardFullName(card), c)
+	}
+	return nil
+}
+
+var (
+	touchKind string
+	touchNote string
+	touchAt   string
+)
+
+var touchCmd = &cobra.Command{
+	Use:   "touch <contact>",
+	Short: "record that you were in touch with a contact",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		at := time.Now()
+		if touchAt != "" {
+			if at, err = contacts.ParseSince(touchAt, at); err != nil {
+				return err
+			}
+		}
+		if err := cm.RecordInteractions(contacts.Interaction{
+			Time: at,
+			UID:  contacts.CardUID(card),
+			Kind: touchKind,
+			Note: touchNote,
+		}); err != nil {
+			return err
+		}
+		infof("Recorded %s with %s.\n", touchKind, contacts.CardFullName(card))
+		return nil
+	},
+}
+
+var dueOutputFormat string
+
+var dueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "list contacts you are overdue to get in touch with",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		due, err := cm.DueContacts(time.Now())
+		if err != nil {
+			return err
+		}
+		switch dueOutputFormat {
+		case "json":
+			type dueJSON struct {
+				UID     string     `json:"uid"`
+				Name    string     `json:"name"`
+				Cadence string     `json:"cadence"`
+				Last    *time.Time `json:"last,omitempty"`
+			}
+			out := []dueJSON{}
+			for _, d := range due {
+				entry := dueJSON{UID: contacts.CardUID(d.Card), Name: contacts.CardFullName(d.Card), Cadence: d.Cadence.String()}
+				if !d.Last.IsZero() {
+					entry.Last = &d.Last
+				}
+				out = append(out, entry)
+			}
+			if err := printJSON(out); err != nil {
+				return err
+			}
+		default: // table
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCADENCE\tLAST CONTACT\tOVERDUE")
+			for _, d := range due {
+				last, overdue := "never", "-"
+				if !d.Last.IsZero() {
+					last = d.Last.Local().Format("2006-01-02")
+					overdue = fmt.Sprintf("%dd", int(time.Since(d.Due).Hours()/24))
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", contacts.CardFullName(d.Card), d.Cadence, last, overdue)
+			}
+			w.Flush()
+		}
+		infof("%d contacts due.\n", len(due))
+		return nil
+	},
+}
+
+func init() {
+	cadenceCmd.AddCommand(cadenceSetCmd, cadenceClearCmd)
+
+	touchCmd.Flags().StringVarP(&touchKind, "kind", "k", contacts.InteractionOther, "kind of interaction (call|message|email|meeting|other)")
+	touchCmd.Flags().StringVarP(&touchNote, "note", "n", "", "note about the interaction")
+	touchCmd.Flags().StringVar(&touchAt, "at", "", "when it happened, as a date or age (e.g. 2024-05-01, 3d); default now")
+	touchCmd.RegisterFlagCompletionFunc("kind", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"call", "message", "email", "meeting", "other"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	dueCmd.Flags().StringVarP(&dueOutputFormat, "output", "o", "table", "output format (table|json)")
+
+	rootCmd.AddCommand(cadenceCmd, touchCmd, dueCmd)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	callType string
+	callDial bool
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call <contact>",
+	Short: "print a contact's phone number and whether it is a good time to call",
+	Long: `Print a contact's phone number, with their local time and whether it
+falls in the usual call window (9:00 to 21:00), so you don't ring someone at
+3am. The time zone comes from the contact's TZ field or the country of their
+address.
+
+With --dial, the number is handed to the system's tel: handler and the call
+is recorded as an interaction.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		number := pickPhone(card, callType)
+		if number == "" {
+			return fmt.Errorf("%w: %s has no phone number", contacts.ErrNotFound, contacts.CardFullName(card))
+		}
+		fmt.Println(number)
+
+		good := true
+		if zone, _, ok := contacts.ContactTimeZone(card); ok {
+			now := time.Now().In(zone)
+			var hint string
+			good, hint = contacts.CallHint(now)
+			infof("It is %s for %s (%s).\n", now.Format("15:04 Mon"), contacts.CardFullName(card), hint)
+		}
+		if !callDial {
+			return nil
+		}
+		if !good {
+			fmt.Fprintf(os.Stderr, "Warning: calling outside %s's call window\n", contacts.CardFullName(card))
+		}
+		if err := openBrowser("tel:" + strings.ReplaceAll(number, " ", "")); err != nil {
+			return fmt.Errorf("failed to start call: %w", err)
+		}
+		if err := cm.RecordInteractions(contacts.Interaction{
+			Time: time.Now(),
+			UID:  contacts.CardUID(card),
+			Kind: contacts.InteractionCall,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		return nil
+	},
+}
+
+// pickPhone returns the contact's phone number of the given TYPE (any if
+// empty), preferring one marked PREF.
+func pickPhone(card vcard.Card, typ string) string {
+	var first string
+	for _, f := range card[vcard.FieldTelephone] {
+		if typ != "" && !f.Params.HasType(typ) {
+			continue
+		}
+		if contacts.IsPreferred(f) {
+			return f.Value
+		}
+		if first == "" {
+			first = f.Value
+		}
+	}
+	return first
+}
+
+func init() {
+	callCmd.Flags().StringVar(&callType, "type", "", "phone type to call (e.g. cell, work, home)")
+	callCmd.Flags().BoolVar(&callDial, "dial", false, "open the number with the system's tel: handler and record the call")
+	callCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"cell", "work", "home"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(callCmd)
+}
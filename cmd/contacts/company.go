@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	companyDepartment   string
+	companyOutputFormat string
+	companyEmails       bool
+	companyMailto       bool
+)
+
+var companyCmd = &cobra.Command{
+	Use:   "company <name>",
+	Short: "list everyone at a company, by department and title",
+	Long: `List everyone whose ORG is the given company, grouped by department (the
+ORG units after the company name) and sorted by title, with their work email
+and phone.
+
+Quick actions act on the people listed:
+
+  contacts company Acme --department Engineering --emails   # one address per line
+  contacts company Acme --department Engineering --mailto   # compose to all of them`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return companyCompletions(), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.Join(args, " ")
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		var depts []contacts.Department
+		for _, d := range contacts.CompanyDirectory(list, name) {
+			if companyDepartment == "" || strings.EqualFold(d.Name, companyDepartment) {
+				depts = append(depts, d)
+			}
+		}
+		if len(depts) == 0 {
+			if companyDepartment != "" {
+				return fmt.Errorf("%w: no one in %s at %s", contacts.ErrNotFound, companyDepartment, name)
+			}
+			return fmt.Errorf("%w: no one at %s", contacts.ErrNotFound, name)
+		}
+
+		if companyEmails || companyMailto {
+			var addrs []string
+			for _, d := range depts {
+				for _, card := range d.Contacts {
+					if email := pickEmail(card); email != "" {
+						addrs = append(addrs, email)
+					}
+				}
+			}
+			if len(addrs) == 0 {
+				return fmt.Errorf("%w: no one listed has an email address", contacts.ErrNotFound)
+			}
+			if companyEmails {
+				fmt.Println(strings.Join(addrs, "\n"))
+				return nil
+			}
+			if err := openBrowser("mailto:" + url.PathEscape(strings.Join(addrs, ","))); err != nil {
+				return fmt.Errorf("failed to open mail client: %w", err)
+			}
+			infof("Composing to %d people.\n", len(addrs))
+			return nil
+		}
+
+		count := 0
+		switch companyOutputFormat {
+		case "json":
+			type departmentJSON struct {
+				Department string           `json:"department"`
+				Contacts   []map[string]any `json:"contacts"`
+			}
+			out := []departmentJSON{}
+			for _, d := range depts {
+				out = append(out, departmentJSON{Department: d.Name, Contacts: contacts.CardsToMaps(d.Contacts)})
+				count += len(d.Contacts)
+			}
+			if err := printJSON(out); err != nil {
+				return err
+			}
+		default: // table
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for i, d := range depts {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				heading := d.Name
+				if heading == "" {
+					heading = "(no department)"
+				}
+				fmt.Fprintln(w, heading)
+				for _, card := range d.Contacts {
+					phone := pickPhone(card, "work")
+					if phone == "" {
+						phone = pickPhone(card, "")
+					}
+					fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", contacts.CardFullName(card), card.Value(vcard.FieldTitle), pickEmail(card), phone)
+					count++
+				}
+			}
+			w.Flush()
+		}
+		infof("%d people in %d departments.\n", count, len(depts))
+		return nil
+	},
+}
+
+// pickEmail returns the contact's work email address, else the one marked
+// PREF, else the first.
+func pickEmail(card vcard.Card) string {
+	var pref, first string
+	for _, f := range card[vcard.FieldEmail] {
+		if f.Params.HasType("work") {
+			return f.Value
+		}
+		if pref == "" && contacts.IsPreferred(f) {
+			pref = f.Value
+		}
+		if first == "" {
+			first = f.Value
+		}
+	}
+	if pref != "" {
+		return pref
+	}
+	return first
+}
+
+// companyCompletions returns the organizations contacts work at.
+func companyCompletions() []string {
+	cm, err := getManagerQuiet()
+	if err != nil {
+		return nil
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, row := range contacts.OrgReport(list) {
+		names = append(names, row.Name)
+	}
+	return names
+}
+
+func init() {
+	companyCmd.Flags().StringVar(&companyDepartment, "department", "", "only list this department")
+	companyCmd.Flags().StringVarP(&companyOutputFormat, "output", "o", "table", "output format (table|json)")
+	companyCmd.Flags().BoolVar(&companyEmails, "emails", false, "print the email address of everyone listed, one per line")
+	companyCmd.Flags().BoolVar(&companyMailto, "mailto", false, "open the mail client to write to everyone listed")
+	companyCmd.MarkFlagsMutuallyExclusive("emails", "mailto")
+	rootCmd.AddCommand(companyCmd)
+}
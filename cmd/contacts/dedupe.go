@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/charmbracelet/huh"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	dedupeOutputFormat string
+	dedupeMerge        bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "find contacts that share an email address or phone number",
+	Long: `Find contacts that share an email address or phone number.
+
+With --merge, each group of duplicates is merged interactively: for every
+field the contacts set differently you choose which value to keep, or both.
+The merged contact replaces the group.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		groups := contacts.FindDuplicates(list)
+		if dedupeMerge {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return fmt.Errorf("--merge needs an interactive terminal")
+			}
+			return mergeDuplicates(cm, groups)
+		}
+
+		switch dedupeOutputFormat {
+		case "json":
+			var out [][]string
+			for _, g := range groups {
+				var uids []string
+				for _, card := range g {
+					uids = append(uids, contacts.CardUID(card))
+				}
+				out = append(out, uids)
+			}
+			if err := printJSON(out); err != nil {
+				return err
+			}
+		default: // table
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "GROUP\tUID\tNAME\tEMAIL\tPHONE")
+			for i, g := range groups {
+				for _, card := range g {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+						i+1,
+						contacts.CardUID(card),
+						contacts.CardFullName(card),
+						contacts.PrimaryEmail(card),
+						contacts.PrimaryPhone(card),
+					)
+				}
+			}
+			w.Flush()
+		}
+		infof("Found %d groups of possible duplicates.\n", len(groups))
+		return nil
+	},
+}
+
+// mergeDuplicates walks through each group of duplicates, asking field by
+// field how to combine them. The merged card keeps the UID of a
+// provider-backed contact if the group has one; the others are deleted.
+func mergeDuplicates(cm *contacts.ContactManager, groups [][]vcard.Card) error {
+	merged := 0
+	for i, g := range groups {
+		var names []string
+		for _, card := range g {
+			names = append(names, fmt.Sprintf("%s (%s)", contacts.CardFullName(card), contacts.CardUID(card)))
+		}
+		action := "merge"
+		if err := huh.NewSelect[string]().
+			Title(fmt.Sprintf("Group %d of %d", i+1, len(groups))).
+			Description(strings.Join(names, "\n")).
+			Options(
+				huh.NewOption("Merge into one contact", "merge"),
+				huh.NewOption("Skip", "skip"),
+			).
+			Value(&action).
+			Run(); err != nil {
+			return err
+		}
+		if action != "merge" {
+			continue
+		}
+
+		// Keep a contact the provider knows, so the merge updates it
+		// rather than creating another.
+		keep := 0
+		for j, card := range g {
+			if contacts.ProviderID(card) != "" {
+				keep = j
+				break
+			}
+		}
+		result := g[keep]
+		for j, card := range g {
+			if j == keep {
+				continue
+			}
+			var err error
+			result, err = chooseFields(result, card, contacts.CardUID(result), contacts.CardUID(card))
+			if err != nil {
+				return err
+			}
+		}
+		if err := cm.WriteContact(result); err != nil {
+			return err
+		}
+		for j, card := range g {
+			if j == keep {
+				continue
+			}
+			if err := cm.DeleteContact(contacts.CardUID(card)); err != nil {
+				return err
+			}
+		}
+		merged++
+	}
+	infof("Merged %d of %d groups of duplicates.\n", merged, len(groups))
+	return nil
+}
+
+func init() {
+	dedupeCmd.Flags().StringVarP(&dedupeOutputFormat, "output", "o", "table", "output format (table|json)")
+	dedupeCmd.Flags().BoolVar(&dedupeMerge, "merge", false, "merge each group interactively, field by field")
+	rootCmd.AddCommand(dedupeCmd)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var diffOutputFormat string
+
+// fieldChangeJSON is a contacts.FieldChange as diff -o json prints it.
+type fieldChangeJSON struct {
+	Field  string              `json:"field"`
+	Kind   contacts.ChangeKind `json:"kind"`
+	Before []string            `json:"before,omitempty"`
+	After  []string            `json:"after,omitempty"`
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <contact> [<contact>]",
+	Short: "show how two versions of a contact differ",
+	Long: `Show the fields that differ between two contacts, or with one contact,
+between the local copy and the provider's current version of it.
+
+Each field is listed as added (+), removed (-) or changed (~), with the
+values before and after.`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		a, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		var b vcard.Card
+		if len(args) == 2 {
+			if b, err = cm.ResolveContact(args[1]); err != nil {
+				return err
+			}
+		} else {
+			uid := contacts.CardUID(a)
+			if b, err = cm.FetchRemoteContact(uid); err != nil {
+				return err
+			}
+			if b == nil {
+				return fmt.Errorf("%w: %s is not at the provider", contacts.ErrNotFound, uid)
+			}
+		}
+
+		changes := contacts.DiffCards(a, b)
+		if diffOutputFormat == "json" {
+			out := make([]fieldChangeJSON, len(changes))
+			for i, c := range changes {
+				out[i] = fieldChangeJSON{Field: c.Field, Kind: c.Kind, Before: fieldLines(c.Before), After: fieldLines(c.After)}
+			}
+			return printJSON(out)
+		}
+		for _, c := range changes {
+			fmt.Println(c)
+			for _, line := range fieldLines(c.Before) {
+				fmt.Printf("  - %s\n", line)
+			}
+			for _, line := range fieldLines(c.After) {
+				fmt.Printf("  + %s\n", line)
+			}
+		}
+		if len(changes) == 0 {
+			infof("No differences.\n")
+		}
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffOutputFormat, "output", "o", "text", "output format (text|json)")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "check the contact store for problems",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		fileMode, dirMode, err := cfg.Permissions()
+		if err != nil {
+			return err
+		}
+		issues, err := contacts.CheckPermissions(cfg.Dir, fileMode, dirMode)
+		if err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			infof("No problems found.\n")
+			return nil
+		}
+		for _, issue := range issues {
+			fmt.Fprintf(os.Stderr, "%s: mode %04o, want %04o\n", issue.Path, issue.Mode, issue.Want)
+		}
+		if !doctorFix {
+			return fmt.Errorf("%d paths have loose permissions; run 'contacts doctor --fix' to restrict them", len(issues))
+		}
+		if err := contacts.FixPermissions(issues); err != nil {
+			return err
+		}
+		infof("Fixed permissions on %d paths.\n", len(issues))
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "fix the problems found")
+
+	rootCmd.AddCommand(doctorCmd)
+}
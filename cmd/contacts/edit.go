@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var editPhoto string
+
+var editCmd = &cobra.Command{
+	Use:   "edit <name|uid>",
+	Short: "edit a contact in $EDITOR",
+	Long: `Edit a contact in $EDITOR.
+
+With --photo, the contact's photo is replaced by an image file instead, and
+the editor is not opened.`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := strings.Join(args, " ")
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(query)
+		if err != nil {
+			return err
+		}
+		if err := cm.RecordAccess(contacts.CardUID(card)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if editPhoto != "" {
+			photo, err := photoFromFile(editPhoto)
+			if err != nil {
+				return err
+			}
+			card.SetValue(vcard.FieldPhoto, photo)
+			if err := cm.WriteContact(card); err != nil {
+				return err
+			}
+			infof("Updated the photo of %s.\n", contacts.CardFullName(card))
+			return nil
+		}
+		return editContact(cm, card)
+	},
+}
+
+// editContact opens card in the user's editor and saves the result.
+func editContact(cm *contacts.ContactManager, card vcard.Card) error {
+	// Refuse before opening the editor rather than losing the edits.
+	if cm.IsReadOnly(card) {
+		return fmt.Errorf("%w: %s can't be edited", contacts.ErrReadOnly, contacts.CardFullName(card))
+	}
+	uid := contacts.CardUID(card)
+	original, err := contacts.EncodeCard(card)
+	if err != nil {
+		return err
+	}
+	edited, err := editInEditor(original)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, original) {
+		infof("No changes.\n")
+		return nil
+	}
+	updated, err := contacts.DecodeCard(edited)
+	if err != nil {
+		return err
+	}
+	// The UID names the file and links the contact to its provider.
+	updated.SetValue(vcard.FieldUID, uid)
+	if err := enforcePolicy(updated); err != nil {
+		return err
+	}
+	if err := cm.WriteContact(updated); err != nil {
+		return err
+	}
+	infof("Updated %s.\n", contacts.CardFullName(updated))
+	return nil
+}
+
+// editInEditor opens data in the user's editor and returns the saved result.
+func editInEditor(data []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", "contact-*.vcf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	f.Close()
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], f.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("editor failed: %w", err)
+	}
+	return os.ReadFile(f.Name())
+}
+
+func init() {
+	editCmd.Flags().StringVar(&editPhoto, "photo", "", "replace the contact's photo with an image file (JPEG, PNG, GIF, WebP or HEIC)")
+	rootCmd.AddCommand(editCmd)
+}
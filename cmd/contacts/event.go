@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var eventCmd = &cobra.Command{
+	Use:   "event",
+	Short: "manage custom dated events such as the day you met",
+	Long: `Manage custom dated events on a contact, such as the day you first met.
+
+Events recur yearly and are shown by 'contacts remind', emitted as tasks
+and exported to calendars ('contacts export -o ics') alongside birthdays
+and anniversaries.`,
+}
+
+var eventAddCmd = &cobra.Command{
+	Use:   "add <contact> <type> <date>",
+	Short: "set a contact's event date (YYYY-MM-DD, or --MM-DD without a year)",
+	Args:  cobra.ExactArgs(3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		if err := contacts.SetEvent(card, args[1], args[2]); err != nil {
+			return err
+		}
+		if err := cm.WriteContact(card); err != nil {
+			return err
+		}
+		infof("Set %s for %s.\n", args[1], contacts.CardFullName(card))
+		return nil
+	},
+}
+
+var eventRemoveCmd = &cobra.Command{
+	Use:   "remove <contact> <type>",
+	Short: "remove a contact's event",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		if !contacts.RemoveEvent(card, args[1]) {
+			return fmt.Errorf("%w: %s has no %s event", contacts.ErrNotFound, contacts.CardFullName(card), args[1])
+		}
+		if err := cm.WriteContact(card); err != nil {
+			return err
+		}
+		infof("Removed %s from %s.\n", args[1], contacts.CardFullName(card))
+		return nil
+	},
+}
+
+var eventOutputFormat string
+
+var eventListCmd = &cobra.Command{
+	Use:   "list <contact>",
+	Short: "list a contact's events",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		events := contacts.CardEvents(card)
+		if eventOutputFormat == "json" {
+			if events == nil {
+				events = []contacts.Event{}
+			}
+			if err := printJSON(events); err != nil {
+				return err
+			}
+			return nil
+		}
+		if len(events) == 0 {
+			infof("%s has no events.\n", contacts.CardFullName(card))
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tDATE")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\n", e.Kind, e.Date)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	eventListCmd.Flags().StringVarP(&eventOutputFormat, "output", "o", "table", "output format (table|json)")
+	eventCmd.AddCommand(eventAddCmd, eventRemoveCmd, eventListCmd)
+	rootCmd.AddCommand(eventCmd)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// Exit codes returned by the contacts command, so scripts can tell why it
+// failed. Any other failure exits with exitError.
+const (
+	exitOK           = 0
+	exitError        = 1
+	exitNotFound     = 2
+	exitAuthRequired = 3
+	exitConflict     = 4
+	exitProviderDown = 5
+	exitPartialSync  = 6
+)
+
+// errPartialSync means a sync finished but left some contacts unsynced.
+var errPartialSync = errors.New("sync incomplete")
+
+// exitCode maps err to the exit code for it.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, contacts.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, contacts.ErrAuthExpired), errors.Is(err, contacts.ErrNotInitialized):
+		return exitAuthRequired
+	case errors.Is(err, contacts.ErrConflict):
+		return exitConflict
+	case errors.Is(err, contacts.ErrProviderUnavailable):
+		return exitProviderDown
+	case errors.Is(err, errPartialSync):
+		return exitPartialSync
+	default:
+		return exitError
+	}
+}
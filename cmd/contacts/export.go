@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutputFormat string
+	exportGroup        string
+	exportWhere        []string
+	exportSince        string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export contacts, optionally filtered by group or field",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		filters, err := buildFilters(cm, exportGroup, exportWhere)
+		if err != nil {
+			return err
+		}
+		if exportSince != "" {
+			since, err := contacts.ParseSince(exportSince, time.Now())
+			if err != nil {
+				return err
+			}
+			f, err := cm.ModifiedSince(since)
+			if err != nil {
+				return err
+			}
+			filters = append(filters, f)
+		}
+		list = contacts.FilterCards(list, filters...)
+
+		switch exportOutputFormat {
+		case "json":
+			if err := printJSON(contacts.CardsToMaps(list)); err != nil {
+				return err
+			}
+		case "csv":
+			if err := contacts.WriteCSV(os.Stdout, list); err != nil {
+				return err
+			}
+		case "gmail":
+			if err := contacts.WriteGoogleCSV(os.Stdout, list); err != nil {
+				return err
+			}
+		case "ics":
+			if err := contacts.WriteICS(os.Stdout, list); err != nil {
+				return err
+			}
+		default: // vcf
+			if err := contacts.WriteVCF(os.Stdout, list); err != nil {
+				return err
+			}
+		}
+		infof("Exported %d contacts.\n", len(list))
+		return nil
+	},
+}
+
+// buildFilters turns --group and --where flag values into contact filters.
+// A local group expands to its members.
+func buildFilters(cm *contacts.ContactManager, group string, where []string) ([]contacts.Filter, error) {
+	var filters []contacts.Filter
+	if group != "" {
+		f, err := cm.GroupFilter(group)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	for _, expr := range where {
+		f, err := contacts.ParseWhere(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutputFormat, "output", "o", "vcf", "output format (vcf|csv|gmail|json|ics; gmail is a CSV Google Contacts can import, ics exports birthdays, anniversaries and events)")
+	exportCmd.Flags().StringVar(&exportGroup, "group", "", "only export contacts in this group")
+	exportCmd.Flags().StringArrayVar(&exportWhere, "where", nil, "only export contacts matching a field filter (e.g. org=Acme, email~@example.com); repeatable")
+	exportCmd.Flags().StringVar(&exportSince, "since", "", "only export contacts created or modified since a date (2024-01-01) or age (7d, 2w)")
+	exportCmd.RegisterFlagCompletionFunc("group", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return groupCompletions(), cobra.ShellCompDirectiveNoFileComp
+	})
+	exportCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"vcf", "csv", "gmail", "json", "ics"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	grepIgnoreCase bool
+	grepFields     []string
+	grepNamesOnly  bool
+	grepColor      string
+	grepSeparate   bool
+)
+
+const (
+	colorMatch = "\033[1;31m"
+	colorName  = "\033[1;35m"
+	colorReset = "\033[0m"
+)
+
+var grepCmd = &cobra.Command{
+	Use:   "grep <regex>",
+	Short: "search every field of every contact with a regular expression",
+	Long: `Search every field of every contact with a regular expression.
+
+All raw field values are searched, including notes and X- extensions.
+Each matching contact is printed with the lines that matched, the matches
+highlighted when writing to a terminal.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern := args[0]
+		if grepIgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		var color bool
+		switch grepColor {
+		case "always":
+			color = true
+		case "never":
+		case "auto":
+			color = term.IsTerminal(int(os.Stdout.Fd()))
+		default:
+			return fmt.Errorf("invalid --color %q: expected auto, always or never", grepColor)
+		}
+
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		if list, err = mergeLinked(cm, list, grepSeparate); err != nil {
+			return err
+		}
+		matches := contacts.Grep(list, re, grepFields...)
+		for i, m := range matches {
+			name := fmt.Sprintf("%s (%s)", contacts.CardFullName(m.Card), contacts.CardUID(m.Card))
+			if grepNamesOnly {
+				fmt.Println(name)
+				continue
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			if color {
+				name = colorName + name + colorReset
+			}
+			fmt.Println(name)
+			for _, line := range m.Lines {
+				fmt.Printf("  %s: %s\n", line.Field, highlight(line, color))
+			}
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("%w: no contact matches %s", contacts.ErrNotFound, args[0])
+		}
+		return nil
+	},
+}
+
+// highlight wraps a line's matches in terminal colors if color is set.
+func highlight(line contacts.GrepLine, color bool) string {
+	if !color {
+		return line.Value
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range line.Matches {
+		b.WriteString(line.Value[last:m[0]])
+		b.WriteString(colorMatch + line.Value[m[0]:m[1]] + colorReset)
+		last = m[1]
+	}
+	b.WriteString(line.Value[last:])
+	return b.String()
+}
+
+func init() {
+	grepCmd.Flags().BoolVarP(&grepIgnoreCase, "ignore-case", "i", false, "match case-insensitively")
+	grepCmd.Flags().StringSliceVarP(&grepFields, "field", "f", nil, "only search these fields (e.g. note,email,x-twitter)")
+	grepCmd.Flags().BoolVarP(&grepNamesOnly, "names-only", "l", false, "only print the names of matching contacts")
+	grepCmd.Flags().BoolVar(&grepSeparate, "separate", false, "search linked contacts separately instead of as one")
+	grepCmd.Flags().StringVar(&grepColor, "color", "auto", "highlight matches (auto|always|never)")
+	grepCmd.RegisterFlagCompletionFunc("color", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(grepCmd)
+}
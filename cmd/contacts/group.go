@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var groupCmd = &cobra.Command{
+	Use:   "group",
+	Short: "manage local contact groups",
+	Long: `Manage local contact groups.
+
+Groups are stored as KIND:group cards listing their members, and are not
+synced to the provider. Use --group on list and export to expand a group
+into its members.`,
+}
+
+var groupCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "create a group",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		existing, err := cm.FindGroup(args[0])
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("group %q already exists", args[0])
+		}
+		group := contacts.NewGroupCard(args[0])
+		if err := cm.WriteContact(group); err != nil {
+			return err
+		}
+		fmt.Println(contacts.CardUID(group))
+		infof("Created group %s.\n", args[0])
+		return nil
+	},
+}
+
+var groupAddCmd = &cobra.Command{
+	Use:   "add <group> <name|uid>...",
+	Short: "add contacts to a group",
+	Args:  cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return groupCompletions(), cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editGroup(args[0], args[1:], func(group, member vcard.Card) bool {
+			return contacts.AddMember(group, member)
+		})
+	},
+}
+
+var groupRemoveCmd = &cobra.Command{
+	Use:   "remove <group> <name|uid>...",
+	Short: "remove contacts from a group",
+	Args:  cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return groupCompletions(), cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editGroup(args[0], args[1:], func(group, member vcard.Card) bool {
+			return contacts.RemoveMember(group, contacts.CardUID(member))
+		})
+	},
+}
+
+var groupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list groups and their sizes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		groups, err := cm.Groups()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UID\tNAME\tMEMBERS")
+		for _, g := range groups {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", contacts.CardUID(g), contacts.CardFullName(g), len(contacts.GroupMemberUIDs(g)))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// editGroup applies change to each contact named in queries and saves the
+// group if any change was made.
+func editGroup(name string, queries []string, change func(group, member vcard.Card) bool) error {
+	cm, err := getManagerQuiet()
+	if err != nil {
+		return err
+	}
+	group, err := cm.FindGroup(name)
+	if err != nil {
+		return err
+	}
+	if group == nil {
+		return fmt.Errorf("%w: no group named %q", contacts.ErrNotFound, name)
+	}
+	changed := 0
+	for _, q := range queries {
+		member, err := cm.ResolveContact(q)
+		if err != nil {
+			return err
+		}
+		if change(group, member) {
+			changed++
+		}
+	}
+	if changed == 0 {
+		infof("No changes.\n")
+		return nil
+	}
+	if err := cm.WriteContact(group); err != nil {
+		return err
+	}
+	infof("Updated %s: %d members.\n", contacts.CardFullName(group), len(contacts.GroupMemberUIDs(group)))
+	return nil
+}
+
+// groupCompletions returns the names of local groups.
+func groupCompletions() []string {
+	cm, err := getManagerQuiet()
+	if err != nil {
+		return nil
+	}
+	groups, err := cm.Groups()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, g := range groups {
+		names = append(names, contacts.CardFullName(g))
+	}
+	return names
+}
+
+func init() {
+	groupCmd.AddCommand(groupCreateCmd, groupAddCmd, groupRemoveCmd, groupListCmd)
+	rootCmd.AddCommand(groupCmd)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "entry points for other programs to report events",
+	Long: `Entry points for other programs, such as mail clients, to report events.
+
+Hooks are meant to be called from scripts: they print the UIDs of the
+contacts they affected on stdout, one per line, and exit 0 even when no
+contact matched, so a hook never makes the calling program fail.`,
+}
+
+var hookEmailSentCmd = &cobra.Command{
+	Use:   "email-sent <address>...",
+	Short: "record an email interaction with the contacts an email was sent to",
+	Long: `Record an email interaction with the contacts an email was sent to.
+
+Each argument is an address or a header-style list such as
+"Ada <ada@example.com>, bob@example.com". Every contact with a matching
+email address gets an interaction, which 'contacts due' counts toward
+their keep-in-touch cadence.
+
+aerc (aerc.conf):
+
+  [hooks]
+  mail-sent=contacts hook email-sent "$AERC_TO" "$AERC_CC"
+
+himalaya, from a wrapper script after sending:
+
+  himalaya message send < "$draft" &&
+    contacts hook email-sent "$(grep -m1 '^To:' "$draft" | cut -d: -f2-)"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		matched, unmatched, err := cm.RecordEmailSent(time.Now(), args...)
+		if err != nil {
+			return err
+		}
+		for _, card := range matched {
+			fmt.Println(contacts.CardUID(card))
+		}
+		for _, address := range unmatched {
+			infof("No contact with address %s.\n", address)
+		}
+		return nil
+	},
+}
+
+func init() {
+	hookCmd.AddCommand(hookEmailSentCmd)
+	rootCmd.AddCommand(hookCmd)
+}
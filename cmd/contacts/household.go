@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	householdName         string
+	householdOutputFormat string
+)
+
+var householdCmd = &cobra.Command{
+	Use:   "household",
+	Short: "group people who live together",
+	Long: `Group people who live together, so that 'contacts mailmerge --households'
+addresses one envelope to them ("The Smith Family").
+
+People who share a mailing address, or are related as spouses or
+co-residents, are grouped automatically. 'contacts household add' records a
+household by hand, which takes precedence for its members.`,
+}
+
+var householdAddCmd = &cobra.Command{
+	Use:   "add <contact>...",
+	Short: "record that contacts live together",
+	Long: `Record that contacts live together. They join the household named by
+--name, or the household the first contact is already in, and leave any
+other. Without --name a new household is named after its members.`,
+	Args: cobra.MinimumNArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		var uids, names []string
+		for _, arg := range args {
+			card, err := cm.ResolveContact(arg)
+			if err != nil {
+				return err
+			}
+			uids = append(uids, contacts.CardUID(card))
+			names = append(names, contacts.CardFullName(card))
+		}
+		if err := cm.AddToHousehold(householdName, uids...); err != nil {
+			return err
+		}
+		infof("Added %s to a household.\n", strings.Join(names, ", "))
+		return nil
+	},
+}
+
+var householdRemoveCmd = &cobra.Command{
+	Use:   "remove <contact>",
+	Short: "remove a contact from its recorded household",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		if err := cm.RemoveFromHousehold(contacts.CardUID(card)); err != nil {
+			return err
+		}
+		infof("Removed %s from their household.\n", contacts.CardFullName(card))
+		return nil
+	},
+}
+
+var householdListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list recorded and detected households",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		households, err := cm.AllHouseholds(list)
+		if err != nil {
+			return err
+		}
+		names := make(map[string]string, len(list))
+		for _, card := range list {
+			names[contacts.CardUID(card)] = contacts.CardFullName(card)
+		}
+		switch householdOutputFormat {
+		case "json":
+			if households == nil {
+				households = []contacts.Household{}
+			}
+			if err := printJSON(households); err != nil {
+				return err
+			}
+		default: // table
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "HOUSEHOLD\tSOURCE\tMEMBERS")
+			for _, h := range households {
+				source := "recorded"
+				if h.Detected {
+					source = "detected"
+				}
+				var members []string
+				for _, uid := range h.Members {
+					if name, ok := names[uid]; ok {
+						members = append(members, name)
+					}
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", h.Name, source, strings.Join(members, ", "))
+			}
+			w.Flush()
+		}
+		infof("%d households.\n", len(households))
+		return nil
+	},
+}
+
+// mailingEntries returns the mail merge entries for list, one per
+// household when byHousehold is set.
+func mailingEntries(cm *contacts.ContactManager, list []vcard.Card, byHousehold bool) ([]contacts.MailingEntry, error) {
+	if !byHousehold {
+		return contacts.MailingList(list), nil
+	}
+	households, err := cm.AllHouseholds(list)
+	if err != nil {
+		return nil, err
+	}
+	return contacts.MailingListByHousehold(list, households), nil
+}
+
+func init() {
+	householdAddCmd.Flags().StringVar(&householdName, "name", "", `how to address the household (e.g. "The Smith Family")`)
+	householdListCmd.Flags().StringVarP(&householdOutputFormat, "output", "o", "table", "output format (table|json)")
+	householdCmd.AddCommand(householdAddCmd, householdRemoveCmd, householdListCmd)
+	rootCmd.AddCommand(householdCmd)
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importDryRun       bool
+	importOutputFormat string
+	importResume       bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import contacts from another app",
+}
+
+var importAndroidCmd = &cobra.Command{
+	Use:   "android <file.vcf|contacts2.db>",
+	Short: "import the VCF produced by Android's \"Export to storage\" or a copy of contacts2.db",
+	Long: `Import the VCF produced by Android's "Export to storage", or a copy of the
+phone's contact database, contacts2.db, from a backup or a rooted phone.
+
+Raw contacts Android has linked are imported as one contact. Copy the
+database from a backup, or with the Contacts app closed: changes still in
+its contacts2.db-wal file are not read.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importAndroid(args[0])
+	},
+}
+
+var importTelegramCmd = &cobra.Command{
+	Use:   "telegram <result.json>",
+	Short: "merge contacts from a Telegram Desktop data export",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importMessenger(args[0], contacts.ParseTelegramContacts)
+	},
+}
+
+var importSignalCmd = &cobra.Command{
+	Use:   "signal <contacts.json>",
+	Short: "merge contacts from signal-cli's JSON contact list",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return importMessenger(args[0], contacts.ParseSignalContacts)
+	},
+}
+
+var importSMSBackupCmd = &cobra.Command{
+	Use:   "smsbackup <calls.xml|sms.xml>...",
+	Short: "log calls and messages from SMS Backup & Restore files as interactions",
+	Long: `Log calls and messages from SMS Backup & Restore files as interactions.
+
+Each call or message with a number matching a contact is recorded in the
+interaction log, as if with 'contacts touch', which 'contacts due' uses to
+find overdue contacts. Importing the same file again adds nothing new.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var entries []contacts.LogEntry
+		for _, path := range args {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			parsed, err := contacts.ParseSMSBackup(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			entries = append(entries, parsed...)
+		}
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		matched, unmatched := contacts.MatchLogEntries(list, entries)
+		added, err := cm.ImportInteractions(matched)
+		if err != nil {
+			return err
+		}
+		infof("Logged %d new interactions; %d entries matched no contact.\n", added, unmatched)
+		return nil
+	},
+}
+
+// importMessenger merges a messaging app's contacts into the store,
+// matching existing contacts by phone number.
+func importMessenger(path string, parse func(io.Reader) ([]contacts.MessengerContact, error)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	incoming, err := parse(f)
+	if err != nil {
+		return err
+	}
+	cm, err := getManager()
+	if err != nil {
+		return err
+	}
+	existing, err := cm.ListContacts()
+	if err != nil {
+		return err
+	}
+	result := contacts.MergeMessengerContacts(existing, incoming)
+	created, refused, err := filterByPolicy(result.Created)
+	if err != nil {
+		return err
+	}
+	if err := runImport(cm, append(result.Updated, created...), refused); err != nil {
+		return err
+	}
+	infof("%d contacts were already up to date.\n", result.Unchanged)
+	return nil
+}
+
+// importChunk is how many records importAndroid handles between
+// checkpoints.
+const importChunk = 100
+
+// importAndroid streams an Android export into the store, saving a
+// checkpoint every importChunk records so that an interrupted import can
+// continue with --resume.
+func importAndroid(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ar, err := contacts.NewAndroidReader(f)
+	if err != nil {
+		return err
+	}
+	cm, err := getManager()
+	if err != nil {
+		return err
+	}
+	cp, err := contacts.NewImportCheckpoint(path)
+	if err != nil {
+		return err
+	}
+	saved, err := cm.ImportCheckpoint()
+	if err != nil {
+		return err
+	}
+	switch {
+	case importResume && (saved == nil || !saved.SameSource(cp)):
+		return fmt.Errorf("no interrupted import of %s to resume", path)
+	case importResume:
+		cp = *saved
+		infof("Resuming after %d records.\n", cp.Records)
+	case saved != nil && saved.SameSource(cp):
+		infof("An earlier import of %s stopped after %d records; starting over. Use --resume to continue it instead.\n", path, saved.Records)
+	}
+	im, err := cm.NewImporter(importDryRun)
+	if err != nil {
+		return err
+	}
+
+	done := cp.Records
+	record := 0
+	var chunk []vcard.Card
+	flush := func() error {
+		kept, refused, err := filterByPolicy(chunk)
+		if err != nil {
+			return err
+		}
+		for _, card := range kept {
+			entry, err := im.Add(card)
+			if err != nil {
+				return err
+			}
+			cp.Report.Entries = append(cp.Report.Entries, entry)
+		}
+		addRefused(&cp.Report, refused)
+		chunk = chunk[:0]
+		cp.Records = record
+		if importDryRun {
+			return nil
+		}
+		return cm.SaveImportCheckpoint(cp)
+	}
+	for {
+		card, err := ar.Next()
+		if err == io.EOF {
+			break
+		}
+		var recErr *contacts.RecordError
+		if err != nil && !errors.As(err, &recErr) {
+			return err
+		}
+		if record++; record <= done {
+			continue
+		}
+		if recErr != nil {
+			cp.Report.Failed = append(cp.Report.Failed, contacts.ImportFailure{Record: recErr.Record, Error: recErr.Err.Error()})
+		} else {
+			chunk = append(chunk, card)
+		}
+		if len(chunk) >= importChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if !importDryRun {
+		if err := cm.ClearImportCheckpoint(); err != nil {
+			return err
+		}
+	}
+	return printImportReport(cp.Report)
+}
+
+// runImport imports cards, or with --dry-run only plans the import, and
+// prints the report. Contacts the field policy refused are reported as
+// skipped.
+func runImport(cm *contacts.ContactManager, cards []vcard.Card, refused []contacts.PolicyViolation) error {
+	var report contacts.ImportReport
+	var err error
+	if importDryRun {
+		report.Entries, err = cm.PlanImport(cards)
+	} else {
+		report, err = cm.Import(cards)
+	}
+	if err != nil {
+		return err
+	}
+	addRefused(&report, refused)
+	return printImportReport(report)
+}
+
+// addRefused reports the contacts the field policy kept out of an import
+// as skipped.
+func addRefused(report *contacts.ImportReport, refused []contacts.PolicyViolation) {
+	for _, v := range refused {
+		report.Entries = append(report.Entries, contacts.ImportEntry{
+			UID:    v.UID,
+			Name:   v.Name,
+			Action: contacts.ImportSkip,
+			Reason: "field policy: missing " + strings.Join(v.Missing, ", "),
+		})
+	}
+}
+
+// printImportReport prints an import report. The table lists every entry
+// on a dry run; after an import it lists only what needs attention:
+// skipped contacts, possible duplicates and unreadable records.
+func printImportReport(report contacts.ImportReport) error {
+	if importOutputFormat == "json" {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ACTION\tUID\tNAME\tDETAILS")
+		for _, e := range report.Entries {
+			if !importDryRun && e.Action != contacts.ImportSkip && len(e.Similar) == 0 {
+				continue
+			}
+			details := e.Reason
+			if details == "" {
+				details = strings.Join(e.Fields, " ")
+			}
+			for _, m := range e.Similar {
+				details = strings.TrimSpace(fmt.Sprintf("%s similar to %s (%s);", details, m.Name, strings.Join(m.Reasons, ", ")))
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Action, e.UID, e.Name, strings.TrimSuffix(details, ";"))
+		}
+		for _, f := range report.Failed {
+			fmt.Fprintf(w, "failed\t\trecord %d\t%s\n", f.Record, f.Error)
+		}
+		w.Flush()
+	}
+	verb := "Imported"
+	if importDryRun {
+		verb = "Dry run: would import"
+	}
+	infof("%s: created %d, merged %d, skipped %d; %d possible duplicates, %d unreadable records.\n", verb,
+		report.Count(contacts.ImportCreate), report.Count(contacts.ImportMerge), report.Count(contacts.ImportSkip),
+		len(report.Ambiguous()), len(report.Failed))
+	return nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{importAndroidCmd, importTelegramCmd, importSignalCmd} {
+		cmd.Flags().BoolVar(&importDryRun, "dry-run", false, "show which contacts would be created, merged or skipped without changing anything")
+		cmd.Flags().StringVarP(&importOutputFormat, "output", "o", "table", "report format (table|json)")
+	}
+	importAndroidCmd.Flags().BoolVar(&importResume, "resume", false, "continue an interrupted import of the same file")
+	importCmd.AddCommand(importAndroidCmd, importTelegramCmd, importSignalCmd, importSMSBackupCmd)
+	rootCmd.AddCommand(importCmd)
+}
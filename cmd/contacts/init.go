@@ -0,0 +1,662 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/carddav"
+	"github.com/arjungandhi/contacts/provider/ews"
+	"github.com/arjungandhi/contacts/provider/google"
+	"github.com/arjungandhi/contacts/provider/ldap"
+	"github.com/arjungandhi/contacts/provider/macos"
+	"github.com/arjungandhi/contacts/provider/microsoft"
+	"github.com/arjungandhi/contacts/provider/proton"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+)
+
+// providerSetup is the interactive setup flow for one provider.
+type providerSetup struct {
+	name  string
+	label string
+	run   func(cfg *contacts.Config) error
+	// goos limits the provider to one operating system, if set.
+	goos string
+}
+
+// providerSetups lists the providers offered by `contacts init`, in menu
+// order.
+var providerSetups = []providerSetup{
+	{contacts.ProviderGoogle, "Google Contacts", setupGoogle, ""},
+	{contacts.ProviderMicrosoft, "Microsoft 365 / Outlook.com", setupMicrosoft, ""},
+	{contacts.ProviderExchange, "Exchange Server (on-premises)", setupExchange, ""},
+	{contacts.ProviderICloud, "iCloud", setupICloud, ""},
+	{contacts.ProviderNextcloud, "Nextcloud", setupNextcloud, ""},
+	{contacts.ProviderCardDAV, "CardDAV server (Radicale, Baïkal, mailbox.org, ...)", setupCardDAV, ""},
+	{contacts.ProviderLDAP, "LDAP / Active Directory (read-only)", setupLDAP, ""},
+	{contacts.ProviderMacOS, "macOS Contacts on this Mac", setupMacOS, "darwin"},
+	{contacts.ProviderProton, "Proton Mail", setupProton, ""},
+	{contacts.ProviderLocal, "Local only (no sync)", setupLocal, ""},
+}
+
+var initLocal bool
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "choose and set up a contacts provider",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if err := cfg.EnsureDir(); err != nil {
+			return err
+		}
+
+		if initLocal {
+			if err := setupLocal(cfg); err != nil {
+				return err
+			}
+			cfg.Provider = contacts.ProviderLocal
+			return cfg.Save()
+		}
+
+		choice := cfg.Provider
+		if choice == "" {
+			choice = contacts.ProviderGoogle
+		}
+		options := make([]huh.Option[string], 0, len(providerSetups))
+		for _, p := range providerSetups {
+			if p.goos == "" || p.goos == runtime.GOOS {
+				options = append(options, huh.NewOption(p.label, p.name))
+			}
+		}
+		if err := huh.NewSelect[string]().
+			Title("Where are your contacts?").
+			Options(options...).
+			Value(&choice).
+			Run(); err != nil {
+			return err
+		}
+		for _, p := range providerSetups {
+			if p.name != choice {
+				continue
+			}
+			if err := p.run(cfg); err != nil {
+				return err
+			}
+			cfg.Provider = p.name
+			return cfg.Save()
+		}
+		return fmt.Errorf("unknown provider %q", choice)
+	},
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&initLocal, "local", false, "keep contacts on this machine only, without a remote provider")
+}
+
+// setupLocal keeps contacts on this machine only.
+func setupLocal(cfg *contacts.Config) error {
+	opts, err := cfg.ManagerOptions()
+	if err != nil {
+		return err
+	}
+	if _, err := contacts.NewContactManager(nil, cfg.Dir, opts...); err != nil {
+		return err
+	}
+	infof("Local contact store initialized at %s. Use 'contacts add' to add contacts.\n", cfg.Dir)
+	return nil
+}
+
+// setupGoogle collects OAuth client credentials and authorizes access to
+// Google Contacts.
+func setupGoogle(cfg *contacts.Config) error {
+	provider, _ := google.NewProvider(cfg.Dir)
+	existingCreds, _ := provider.LoadCredentials()
+
+	if existingCreds != nil && existingCreds.ClientID != "" {
+		var reauth bool
+		form := huh.NewForm(huh.NewGroup(
+			huh.NewConfirm().
+				Title("Existing credentials found").
+				Description(fmt.Sprintf("Client ID: %s\nDelete and enter new credentials?", existingCreds.ClientID)).
+				Affirmative("Yes, delete").
+				Negative("No, re-authorize").
+				Value(&reauth),
+		))
+		if err := form.Run(); err != nil {
+			return err
+		}
+		if !reauth {
+			return authorize(cfg, provider)
+		}
+	}
+
+	if builtin := google.DefaultCredentials(); builtin != nil {
+		useBuiltin := true
+		form := huh.NewForm(huh.NewGroup(
+			huh.NewConfirm().
+				Title("Google Contacts Setup").
+				Description("This build includes a default OAuth client.\nUse it, or enter credentials from your own Google Cloud project?").
+				Affirmative("Use built-in client").
+				Negative("Use my own").
+				Value(&useBuiltin),
+		))
+		if err := form.Run(); err != nil {
+			return err
+		}
+		if useBuiltin {
+			if err := provider.SaveCredentials(builtin); err != nil {
+				return err
+			}
+			return authorize(cfg, provider)
+		}
+	}
+
+	var clientID, clientSecret string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title("Google Contacts Setup").
+				Description("Steps:\n1. Enable People API at console.cloud.google.com/apis/library/people.googleapis.com\n2. Go to console.cloud.google.com/apis/credentials\n3. Create OAuth 2.0 Client ID (Desktop app)\n4. Add redirect URI: http://localhost:8080/callback"),
+		),
+		huh.NewGroup(
+			huh.NewInput().Title("Client ID").Value(&clientID).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("required")
+					}
+					return nil
+				}),
+			huh.NewInput().Title("Client Secret").Value(&clientSecret).Password(true).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("required")
+					}
+					return nil
+				}),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return err
+	}
+
+	provider, err := google.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	creds := &google.Credentials{
+		ClientID:     strings.TrimSpace(clientID),
+		ClientSecret: strings.TrimSpace(clientSecret),
+	}
+	if err := provider.SaveCredentials(creds); err != nil {
+		return err
+	}
+	if err := provider.Initialize(); err != nil {
+		return err
+	}
+	return authorize(cfg, provider)
+}
+
+// setupCardDAV collects a CardDAV account, finds its address books and
+// lets the user pick the one to sync.
+func setupCardDAV(cfg *contacts.Config) error {
+	provider, err := carddav.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	creds, _ := provider.LoadCredentials()
+	if creds == nil {
+		creds = &carddav.Credentials{}
+	}
+	required := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("required")
+		}
+		return nil
+	}
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("Server URL").
+			Description("The server (e.g. dav.mailbox.org) or an address book URL").
+			Value(&creds.URL).Validate(required),
+		huh.NewInput().Title("Username").Value(&creds.Username).Validate(required),
+		huh.NewInput().Title("Password").
+			Description("Use an app password if your account has two-factor authentication").
+			Value(&creds.Password).Password(true).Validate(required),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	creds.URL = strings.TrimSpace(creds.URL)
+	creds.Username = strings.TrimSpace(creds.Username)
+	if err := provider.SaveCredentials(creds); err != nil {
+		return err
+	}
+
+	books, err := provider.Discover()
+	if err != nil {
+		return fmt.Errorf("failed to find address books: %w", err)
+	}
+	choice := books[0].URL
+	if len(books) > 1 {
+		options := make([]huh.Option[string], 0, len(books))
+		for _, b := range books {
+			options = append(options, huh.NewOption(b.Name, b.URL))
+		}
+		if err := huh.NewSelect[string]().
+			Title("Which address book should be synced?").
+			Options(options...).
+			Value(&choice).
+			Run(); err != nil {
+			return err
+		}
+	}
+	if err := provider.SelectAddressBook(choice); err != nil {
+		return err
+	}
+	infof("CardDAV address book %s selected. Run 'contacts sync' to sync.\n", choice)
+	return nil
+}
+
+func authorize(cfg *contacts.Config, provider *google.Provider) error {
+	if err := provider.Initialize(); err != nil {
+		return err
+	}
+	provider.SetSuccessPage(cfg.OAuthSuccessPage, cfg.OAuthAutoClose)
+	ctx := context.Background()
+	authURL, errChan, err := provider.AuthorizeWithPKCE(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Opening browser for authorization...\nIf it doesn't open, visit:\n\n  %s\n\nWaiting for authorization...\n", authURL)
+	_ = openBrowser(authURL)
+	if err := <-errChan; err != nil {
+		return fmt.Errorf("authorization failed: %w", err)
+	}
+	infof("Google Contacts initialized. Run 'contacts sync' to sync.\n")
+	return nil
+}
+
+// setupNextcloud collects a Nextcloud account and selects its default
+// address book.
+func setupNextcloud(cfg *contacts.Config) error {
+	provider, err := carddav.NewNextcloudProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	var server, username, password string
+	if creds, _ := provider.LoadCredentials(); creds != nil {
+		server, username = creds.URL, creds.Username
+	}
+	required := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("required")
+		}
+		return nil
+	}
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("Server URL").
+			Description("The address of your Nextcloud, e.g. cloud.example.com").
+			Value(&server).Validate(required),
+		huh.NewInput().Title("Username").Value(&username).Validate(required),
+		huh.NewInput().Title("App password").
+			Description("Create one under Settings > Security > Devices & sessions").
+			Value(&password).Password(true).Validate(required),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	book, err := provider.SetupNextcloud(server, strings.TrimSpace(username), password)
+	if err != nil {
+		return err
+	}
+	infof("Nextcloud address book %s selected. Run 'contacts sync' to sync.\n", book.Name)
+	return nil
+}
+
+// setupICloud walks the user through creating an app-specific password
+// for their Apple ID and selects the account's address book.
+func setupICloud(cfg *contacts.Config) error {
+	provider, err := carddav.NewICloudProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	var appleID, password string
+	if creds, _ := provider.LoadCredentials(); creds != nil {
+		appleID = creds.Username
+	}
+	browser := true
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title("iCloud Setup").
+			Description("iCloud needs an app-specific password, not your Apple ID password.\nSteps:\n1. Sign in at appleid.apple.com\n2. Go to Sign-In and Security > App-Specific Passwords\n3. Generate a password and name it e.g. \"contacts\"\n4. Copy the password, in the form xxxx-xxxx-xxxx-xxxx"),
+		huh.NewConfirm().
+			Title("Open appleid.apple.com in your browser?").
+			Value(&browser),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	if browser {
+		if err := openBrowser(carddav.ICloudAppPasswordURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to open browser, visit %s\n", carddav.ICloudAppPasswordURL)
+		}
+	}
+
+	form = huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("Apple ID").
+			Description("The email address you sign in to iCloud with").
+			Value(&appleID).
+			Validate(func(s string) error {
+				if !strings.Contains(s, "@") {
+					return fmt.Errorf("enter the email address of your Apple ID")
+				}
+				return nil
+			}),
+		huh.NewInput().Title("App-specific password").
+			Value(&password).Password(true).
+			Validate(func(s string) error {
+				_, err := carddav.NormalizeICloudAppPassword(s)
+				return err
+			}),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	password, _ = carddav.NormalizeICloudAppPassword(password)
+	book, err := provider.SetupICloud(appleID, password)
+	if err != nil {
+		return err
+	}
+	infof("iCloud address book %s selected. Run 'contacts sync' to sync.\n", book.Name)
+	return nil
+}
+
+// setupExchange collects an on-premises Exchange account and checks that
+// its Contacts folder can be read through EWS.
+func setupExchange(cfg *contacts.Config) error {
+	provider, err := ews.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	var server, username, password string
+	if creds, _ := provider.LoadCredentials(); creds != nil {
+		server, username = creds.URL, creds.Username
+	}
+	required := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("required")
+		}
+		return nil
+	}
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("Server").
+			Description("Your Exchange server, e.g. mail.example.com, or its EWS URL").
+			Value(&server).Validate(required),
+		huh.NewInput().Title("Username").
+			Description("DOMAIN\\user or user@example.com").
+			Value(&username).Validate(required),
+		huh.NewInput().Title("Password").Value(&password).Password(true).Validate(required),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	count, err := provider.Setup(server, strings.TrimSpace(username), password)
+	if err != nil {
+		return err
+	}
+	infof("Found %d items in your Exchange contacts. Run 'contacts sync' to sync.\n", count)
+	return nil
+}
+
+// setupLDAP collects the connection to a corporate LDAP or Active
+// Directory server and checks that a search finds people in it.
+func setupLDAP(cfg *contacts.Config) error {
+	provider, err := ldap.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	creds := &ldap.Credentials{Filter: ldap.DefaultFilter}
+	if existing, _ := provider.LoadCredentials(); existing != nil {
+		creds = existing
+	}
+	var pairs []string
+	for field, attrs := range creds.Attributes {
+		pairs = append(pairs, field+"="+strings.Join(attrs, ","))
+	}
+	sort.Strings(pairs)
+	overrides := strings.Join(pairs, " ")
+	required := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("required")
+		}
+		return nil
+	}
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().Title("Server URL").
+				Description("e.g. ldaps://ldap.example.com or ldap://dc1.corp.example.com").
+				Value(&creds.URL).Validate(required),
+			huh.NewConfirm().Title("Use StartTLS?").
+				Description("Upgrade an ldap:// connection to TLS before signing in").
+				Value(&creds.StartTLS),
+			huh.NewInput().Title("Bind DN").
+				Description("e.g. cn=reader,dc=example,dc=com or user@corp.example.com; empty to bind anonymously").
+				Value(&creds.BindDN),
+			huh.NewInput().Title("Password").Value(&creds.Password).Password(true),
+		),
+		huh.NewGroup(
+			huh.NewInput().Title("Base DN").
+				Description("Where to look for people, e.g. ou=people,dc=example,dc=com").
+				Value(&creds.BaseDN).Validate(required),
+			huh.NewInput().Title("Filter").Value(&creds.Filter),
+			huh.NewInput().Title("Attribute mapping (optional)").
+				Description("Overrides as field=attribute, e.g. title=jobTitle photo=").
+				Value(&overrides).
+				Validate(func(s string) error {
+					_, err := ldap.ParseAttributes(s)
+					return err
+				}),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return err
+	}
+	creds.URL = strings.TrimSpace(creds.URL)
+	creds.BindDN = strings.TrimSpace(creds.BindDN)
+	creds.BaseDN = strings.TrimSpace(creds.BaseDN)
+	creds.Filter = strings.TrimSpace(creds.Filter)
+	if creds.Attributes, err = ldap.ParseAttributes(overrides); err != nil {
+		return err
+	}
+	if err := provider.SaveCredentials(creds); err != nil {
+		return err
+	}
+	if err := provider.Initialize(); err != nil {
+		return err
+	}
+	cards, err := provider.FetchContacts()
+	if err != nil {
+		return err
+	}
+	infof("Found %d people in %s. Run 'contacts sync' to sync; directory contacts are read-only.\n", len(cards), creds.BaseDN)
+	return nil
+}
+
+// setupMacOS mirrors the Contacts app of this Mac, optionally only one of
+// its groups, and checks that macOS allows access to it.
+func setupMacOS(cfg *contacts.Config) error {
+	provider, err := macos.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	config := &macos.Config{}
+	if existing, _ := provider.LoadConfig(); existing != nil {
+		config = existing
+	}
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title("macOS Contacts Setup").
+			Description("macOS will ask to allow access to Contacts; allow it so contacts can sync."),
+		huh.NewInput().Title("Group (optional)").
+			Description("Sync only the members of this Contacts group; empty to sync every contact").
+			Value(&config.Group),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	count, err := provider.Setup(strings.TrimSpace(config.Group))
+	if err != nil {
+		return err
+	}
+	infof("Found %d contacts in the Contacts app. Run 'contacts sync' to sync.\n", count)
+	return nil
+}
+
+// setupProton signs in to a Proton account, asking for a two-factor code
+// and a separate mailbox password if the account has them, and unlocks
+// the keys its contacts are encrypted with.
+func setupProton(cfg *contacts.Config) error {
+	provider, err := proton.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	var username, password string
+	if creds, _ := provider.LoadCredentials(); creds != nil {
+		username = creds.Username
+	}
+	required := func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("required")
+		}
+		return nil
+	}
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewNote().
+			Title("Proton Setup").
+			Description("The password is used to sign in and unlock your keys; only the session and key passphrases are stored."),
+		huh.NewInput().Title("Username").
+			Description("Your Proton address or username").
+			Value(&username).Validate(required),
+		huh.NewInput().Title("Password").Value(&password).Password(true).Validate(required),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	twoFactorCode := func() (string, error) {
+		var code string
+		err := huh.NewInput().Title("Two-factor code").
+			Description("The code from your authenticator app").
+			Value(&code).Validate(required).Run()
+		return strings.TrimSpace(code), err
+	}
+	twoPasswords, err := provider.Login(strings.TrimSpace(username), password, twoFactorCode)
+	if err != nil {
+		return err
+	}
+	if twoPasswords {
+		password = ""
+		err := huh.NewInput().Title("Mailbox password").
+			Description("Your account has a second password for its mailbox").
+			Value(&password).Password(true).Validate(required).Run()
+		if err != nil {
+			return err
+		}
+	}
+	count, err := provider.Unlock(password)
+	if err != nil {
+		return err
+	}
+	infof("Found %d contacts in your Proton account. Run 'contacts sync' to sync.\n", count)
+	return nil
+}
+
+// setupMicrosoft collects an Azure app registration and authorizes access
+// to the contacts of an Outlook.com or Microsoft 365 account.
+func setupMicrosoft(cfg *contacts.Config) error {
+	provider, err := microsoft.NewProvider(cfg.Dir)
+	if err != nil {
+		return err
+	}
+	creds := microsoft.DefaultCredentials()
+	if existing, _ := provider.LoadCredentials(); existing != nil && existing.ClientID != "" {
+		creds = existing
+	}
+	if creds == nil {
+		creds = &microsoft.Credentials{}
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewNote().
+					Title("Microsoft 365 Setup").
+					Description("Steps:\n1. Go to entra.microsoft.com > App registrations > New registration\n2. Allow personal and organizational accounts\n3. Add a Mobile and desktop redirect URI: http://localhost:8080/callback\n4. Under Authentication, enable Allow public client flows"),
+			),
+			huh.NewGroup(
+				huh.NewInput().Title("Application (client) ID").Value(&creds.ClientID).
+					Validate(func(s string) error {
+						if strings.TrimSpace(s) == "" {
+							return fmt.Errorf("required")
+						}
+						return nil
+					}),
+			),
+		)
+		if err := form.Run(); err != nil {
+			return err
+		}
+		creds.ClientID = strings.TrimSpace(creds.ClientID)
+	}
+
+	if creds.Tenant == "" {
+		creds.Tenant = "common"
+	}
+	browser := true
+	form := huh.NewForm(huh.NewGroup(
+		huh.NewInput().Title("Tenant").
+			Description("\"common\" for any account, \"consumers\" for Outlook.com, or your organization's domain").
+			Value(&creds.Tenant),
+		huh.NewConfirm().
+			Title("How do you want to sign in?").
+			Affirmative("Browser").
+			Negative("Code on another device").
+			Value(&browser),
+	))
+	if err := form.Run(); err != nil {
+		return err
+	}
+	creds.Tenant = strings.TrimSpace(creds.Tenant)
+	if err := provider.SaveCredentials(creds); err != nil {
+		return err
+	}
+	if err := provider.Initialize(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if !browser {
+		err = provider.AuthorizeWithDevice(ctx, func(verificationURI, userCode string) {
+			fmt.Fprintf(os.Stderr, "To sign in, visit:\n\n  %s\n\nand enter the code %s\n\nWaiting for authorization...\n", verificationURI, userCode)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		authURL, errChan, err := provider.AuthorizeWithPKCE(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Opening browser for authorization...\nIf it doesn't open, visit:\n\n  %s\n\nWaiting for authorization...\n", authURL)
+		_ = openBrowser(authURL)
+		if err := <-errChan; err != nil {
+			return fmt.Errorf("authorization failed: %w", err)
+		}
+	}
+	infof("Microsoft 365 contacts initialized. Run 'contacts sync' to sync.\n")
+	return nil
+}
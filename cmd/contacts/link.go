@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link <contact> <contact>...",
+	Short: "show several contacts as one person",
+	Long: `Link contacts that are the same person, such as copies of one person
+pulled in from different providers.
+
+Linked contacts stay separate in the store, and sync keeps each copy up to
+date with its own provider. list and grep show them as one contact: the
+first contact's values, plus whatever only the others have. Use
+--separate on those commands to see the copies, and 'contacts unlink' to
+undo a link.`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		var uids, names []string
+		for _, arg := range args {
+			card, err := cm.ResolveContact(arg)
+			if err != nil {
+				return err
+			}
+			uids = append(uids, contacts.CardUID(card))
+			names = append(names, contacts.CardFullName(card))
+		}
+		if err := cm.Link(uids...); err != nil {
+			return err
+		}
+		infof("Linked %s.\n", strings.Join(names, ", "))
+		return nil
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink <contact>",
+	Short: "show a linked contact on its own again",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		if err := cm.Unlink(contacts.CardUID(card)); err != nil {
+			return err
+		}
+		infof("Unlinked %s.\n", contacts.CardFullName(card))
+		return nil
+	},
+}
+
+// mergeLinked applies MergeLinked unless separate is set.
+func mergeLinked(cm *contacts.ContactManager, list []vcard.Card, separate bool) ([]vcard.Card, error) {
+	if separate {
+		return list, nil
+	}
+	merged, err := cm.MergeLinked(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge linked contacts: %w", err)
+	}
+	return merged, nil
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+}
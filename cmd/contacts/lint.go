@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var lintOutputFormat string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "report contacts missing fields required by the config policy",
+	Long: `Report contacts missing fields required by the policy in config.json:
+
+  "policy": {
+    "required": [["email", "phone"], ["name"]],
+    "mode": "refuse"
+  }
+
+Each entry of "required" lists alternatives, any one of which satisfies it.
+With mode "warn" (the default) add, edit and import print a warning for
+contacts that break the policy; with "refuse" they reject them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if len(cfg.Policy.Required) == 0 {
+			return fmt.Errorf("no required fields set under \"policy\" in %s", cfg.Path())
+		}
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		violations := cfg.Policy.Lint(list)
+		switch lintOutputFormat {
+		case "json":
+			if err := printJSON(violations); err != nil {
+				return err
+			}
+		default: // table
+			if len(violations) == 0 {
+				break
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "UID\tNAME\tMISSING")
+			for _, v := range violations {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", v.UID, v.Name, strings.Join(v.Missing, ", "))
+			}
+			w.Flush()
+		}
+		if len(violations) > 0 {
+			return fmt.Errorf("%d contacts break the field policy", len(violations))
+		}
+		infof("All contacts meet the field policy.\n")
+		return nil
+	},
+}
+
+// enforcePolicy checks card against the configured field policy. A
+// violation is printed as a warning, or returned as an error if the policy
+// refuses violations.
+func enforcePolicy(card vcard.Card) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	v := cfg.Policy.Check(card)
+	if v == nil {
+		return nil
+	}
+	if cfg.Policy.Refuses() {
+		return fmt.Errorf("refused by field policy: %w", v)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", v)
+	return nil
+}
+
+// filterByPolicy checks imported cards against the configured field
+// policy, warning about violations. If the policy refuses violations, the
+// violating cards are dropped and returned as refused for the caller to
+// report.
+func filterByPolicy(cards []vcard.Card) (kept []vcard.Card, refused []contacts.PolicyViolation, err error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, card := range cards {
+		v := cfg.Policy.Check(card)
+		switch {
+		case v == nil:
+			kept = append(kept, card)
+		case cfg.Policy.Refuses():
+			refused = append(refused, *v)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", v)
+			kept = append(kept, card)
+		}
+	}
+	return kept, refused, nil
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintOutputFormat, "output", "o", "table", "output format (table|json)")
+	rootCmd.AddCommand(lintCmd)
+}
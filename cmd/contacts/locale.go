@@ -0,0 +1,33 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// displayLocale returns the locale set in the config file.
+func displayLocale() (contacts.Locale, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return contacts.Locale{}, err
+	}
+	return contacts.ParseLocale(cfg.Locale)
+}
+
+// sortByName orders cards by full name using the collation rules of loc,
+// so accented and non-Latin names sort where a reader of that language
+// expects them.
+func sortByName(cards []vcard.Card, loc contacts.Locale) {
+	tag, err := language.Parse(loc.Tag)
+	if err != nil {
+		tag = language.AmericanEnglish
+	}
+	c := collate.New(tag, collate.IgnoreCase)
+	sort.SliceStable(cards, func(i, j int) bool {
+		return c.CompareString(contacts.CardFullName(cards[i]), contacts.CardFullName(cards[j])) < 0
+	})
+}
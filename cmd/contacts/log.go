@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logContact      string
+	logSince        string
+	logOutputFormat string
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "show the audit log of contact changes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		since, err := contacts.ParseSince(logSince, time.Now())
+		if err != nil {
+			return err
+		}
+		query := logContact
+		if query != "" {
+			// Prefer the UID of a live contact; deleted contacts still
+			// match by name or UID in the log itself.
+			if card, err := cm.ResolveContact(query); err == nil && card != nil {
+				query = contacts.CardUID(card)
+			}
+		}
+		entries, err := cm.AuditLog(query, since)
+		if err != nil {
+			return err
+		}
+
+		switch logOutputFormat {
+		case "json":
+			if err := printJSON(entries); err != nil {
+				return err
+			}
+		default: // table
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tACTOR\tACTION\tNAME\tFIELDS")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					e.Time.Local().Format("2006-01-02 15:04"),
+					e.Actor,
+					e.Action,
+					e.Name,
+					strings.Join(e.Fields, " "),
+				)
+			}
+			w.Flush()
+		}
+		return nil
+	},
+}
+
+func init() {
+	logCmd.Flags().StringVar(&logContact, "contact", "", "only show changes to this contact (name or UID)")
+	logCmd.Flags().StringVar(&logSince, "since", "", "only show changes since a date or age (e.g. 7d, 2w, 2024-01-01)")
+	logCmd.Flags().StringVarP(&logOutputFormat, "output", "o", "table", "output format (table|json)")
+	logCmd.RegisterFlagCompletionFunc("contact", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	})
+
+	rootCmd.AddCommand(logCmd)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mailmergeTag        string
+	mailmergeWhere      []string
+	mailmergeFormat     string
+	mailmergeIncomplete bool
+	mailmergeHouseholds bool
+)
+
+var mailmergeCmd = &cobra.Command{
+	Use:   "mailmerge",
+	Short: "build a mail merge list of postal addresses, e.g. for holiday cards",
+	Long: `Print the postal addresses of individual contacts as CSV for a word
+processor's mail merge or as plain-text labels.
+
+Each contact's preferred address is used, else their home address. Contacts
+with no address or one missing a street, city or postal code are listed on
+stderr and left out unless --include-incomplete is given.
+
+With --households, people who live together (see 'contacts household') get
+one entry addressed to the household, such as "The Smith Family".`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		filters, err := buildFilters(cm, mailmergeTag, mailmergeWhere)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, contacts.OfKind(vcard.KindIndividual))
+		list = contacts.FilterCards(list, filters...)
+
+		entries, err := mailingEntries(cm, list, mailmergeHouseholds)
+		if err != nil {
+			return err
+		}
+		var addrs []contacts.MailingAddress
+		incomplete := 0
+		for _, entry := range entries {
+			if len(entry.Problems) > 0 {
+				incomplete++
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", entry.Address.Name, strings.Join(entry.Problems, ", "))
+				if !mailmergeIncomplete || entry.Problems[0] == "no address" {
+					continue
+				}
+			}
+			addrs = append(addrs, entry.Address)
+		}
+
+		switch mailmergeFormat {
+		case "csv":
+			err = contacts.WriteMailMergeCSV(os.Stdout, addrs)
+		case "labels":
+			err = contacts.WriteLabels(os.Stdout, addrs)
+		case "json":
+			var data []byte
+			if addrs == nil {
+				addrs = []contacts.MailingAddress{}
+			}
+			if data, err = json.MarshalIndent(addrs, "", "  "); err == nil {
+				fmt.Println(string(data))
+			}
+		default:
+			return fmt.Errorf("invalid --format %q: expected csv, labels or json", mailmergeFormat)
+		}
+		if err != nil {
+			return err
+		}
+		infof("%d addresses; %d contacts with incomplete addresses.\n", len(addrs), incomplete)
+		return nil
+	},
+}
+
+func init() {
+	mailmergeCmd.Flags().StringVar(&mailmergeTag, "tag", "", "only include contacts with this tag or in this group")
+	mailmergeCmd.Flags().StringArrayVar(&mailmergeWhere, "where", nil, "only include contacts matching a field filter (e.g. country=US); repeatable")
+	mailmergeCmd.Flags().StringVar(&mailmergeFormat, "format", "csv", "output format (csv|labels|json)")
+	mailmergeCmd.Flags().BoolVar(&mailmergeIncomplete, "include-incomplete", false, "include addresses missing a street, city or postal code")
+	mailmergeCmd.Flags().BoolVar(&mailmergeHouseholds, "households", false, "address one entry to each household instead of each person")
+	mailmergeCmd.RegisterFlagCompletionFunc("tag", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return groupCompletions(), cobra.ShellCompDirectiveNoFileComp
+	})
+	mailmergeCmd.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"csv", "labels", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	rootCmd.AddCommand(mailmergeCmd)
+}
@@ -1,14 +1,9 @@
 package main
 
 import (
-	"bytes"
-	"context"
-	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"image"
-	_ "image/jpeg"
-	"image/png"
-	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
@@ -16,161 +11,305 @@ import (
 	"text/tabwriter"
 	"time"
 
-	"golang.org/x/term"
-
 	"github.com/arjungandhi/contacts"
-	"github.com/charmbracelet/huh"
+	"github.com/arjungandhi/contacts/provider/carddav"
+	"github.com/arjungandhi/contacts/provider/ews"
+	"github.com/arjungandhi/contacts/provider/google"
+	"github.com/arjungandhi/contacts/provider/ldap"
+	"github.com/arjungandhi/contacts/provider/macos"
+	"github.com/arjungandhi/contacts/provider/microsoft"
+	"github.com/arjungandhi/contacts/provider/proton"
+	"github.com/arjungandhi/contacts/query"
 	"github.com/emersion/go-vcard"
 	"github.com/spf13/cobra"
 )
 
+// contactCompDirective keeps contact completions in recency order.
+const contactCompDirective = cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+
+// contactCompletions returns contact names starting with toComplete,
+// favorites first and then the most frequently and recently used. It
+// reads the names cache rather than the contact files so completion stays
+// fast on large stores.
 func contactCompletions(toComplete string) []string {
 	cm, err := getManagerQuiet()
 	if err != nil {
 		return nil
 	}
-	cards, err := cm.ListContacts()
+	names, err := cm.ContactNames()
 	if err != nil {
 		return nil
 	}
+	cm.SortNamesByRelevance(names)
 	prefix := strings.ToLower(toComplete)
 	var matches []string
-	for _, card := range cards {
-		name := contacts.CardFullName(card)
-		if name == "" {
+	for _, n := range names {
+		if n.Name == "" {
 			continue
 		}
-		if prefix == "" || strings.HasPrefix(strings.ToLower(name), prefix) {
-			matches = append(matches, name)
+		if prefix == "" || strings.HasPrefix(strings.ToLower(n.Name), prefix) {
+			matches = append(matches, n.Name)
 		}
 	}
 	return matches
 }
 
-var rootCmd = &cobra.Command{
-	Use:          "contacts",
-	Short:        "manage your contacts",
-	SilenceUsage: true,
-}
-
-var initCmd = &cobra.Command{
-	Use:   "init",
-	Short: "initialize google contacts provider",
-	Args:  cobra.NoArgs,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg := contacts.NewConfig()
-		if err := cfg.EnsureDir(); err != nil {
-			return err
-		}
-
-		provider, _ := contacts.NewGoogleContactsProvider(cfg.Dir)
-		existingCreds, _ := provider.LoadCredentials()
-
-		if existingCreds != nil && existingCreds.ClientID != "" {
-			var reauth bool
-			form := huh.NewForm(huh.NewGroup(
-				huh.NewConfirm().
-					Title("Existing credentials found").
-					Description(fmt.Sprintf("Client ID: %s\nDelete and enter new credentials?", existingCreds.ClientID)).
-					Affirmative("Yes, delete").
-					Negative("No, re-authorize").
-					Value(&reauth),
-			))
-			if err := form.Run(); err != nil {
-				return err
-			}
-			if !reauth {
-				return authorize(cfg, provider)
-			}
-		}
+var (
+	// dirFlag overrides CONTACTS_DIR for a single invocation.
+	dirFlag string
+	// quietFlag suppresses informational messages on stderr.
+	quietFlag bool
+	// queryFlag filters JSON output through a jq query.
+	queryFlag string
+)
 
-		var clientID, clientSecret string
-		form := huh.NewForm(
-			huh.NewGroup(
-				huh.NewNote().
-					Title("Google Contacts Setup").
-					Description("Steps:\n1. Enable People API at console.cloud.google.com/apis/library/people.googleapis.com\n2. Go to console.cloud.google.com/apis/credentials\n3. Create OAuth 2.0 Client ID (Desktop app)\n4. Add redirect URI: http://localhost:8080/callback"),
-			),
-			huh.NewGroup(
-				huh.NewInput().Title("Client ID").Value(&clientID).
-					Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("required")
-						}
-						return nil
-					}),
-				huh.NewInput().Title("Client Secret").Value(&clientSecret).Password(true).
-					Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("required")
-						}
-						return nil
-					}),
-			),
-		)
-		if err := form.Run(); err != nil {
-			return err
-		}
+// infof prints an informational message to stderr unless --quiet is set.
+// Data goes to stdout and warnings and errors are always shown.
+func infof(format string, args ...any) {
+	if !quietFlag {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
 
-		provider, err := contacts.NewGoogleContactsProvider(cfg.Dir)
+// printJSON prints v as indented JSON, or with --query, each result of the
+// query over it.
+func printJSON(v any) error {
+	results := []any{v}
+	if queryFlag != "" {
+		q, err := query.ParseQuery(queryFlag)
 		if err != nil {
 			return err
 		}
-		creds := &contacts.GoogleCredentials{
-			ClientID:     strings.TrimSpace(clientID),
-			ClientSecret: strings.TrimSpace(clientSecret),
-		}
-		if err := provider.SaveCredentials(creds); err != nil {
+		if results, err = q.Run(v); err != nil {
 			return err
 		}
-		if err := provider.Initialize(); err != nil {
+	}
+	for _, r := range results {
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
 			return err
 		}
-		return authorize(cfg, provider)
-	},
+		fmt.Println(string(data))
+	}
+	return nil
 }
 
-func authorize(cfg *contacts.Config, provider *contacts.GoogleContactsProvider) error {
-	if err := provider.Initialize(); err != nil {
-		return err
+// cardJSONOutput writes cards for -o json or jsonl as they arrive. With
+// --query the query runs over each card as it is read, as jq does over a
+// stream of cards, and its results are printed one after another: indented
+// for json, one per line for jsonl.
+type cardJSONOutput struct {
+	w     *contacts.CardJSONWriter
+	query *query.Query
+	lines bool
+}
+
+func newCardJSONOutput(format string) (*cardJSONOutput, error) {
+	lines := format == "jsonl"
+	if queryFlag == "" {
+		return &cardJSONOutput{w: contacts.NewCardJSONWriter(os.Stdout, lines)}, nil
+	}
+	q, err := query.ParseQuery(queryFlag)
+	if err != nil {
+		return nil, err
+	}
+	return &cardJSONOutput{query: q, lines: lines}, nil
+}
+
+func (o *cardJSONOutput) write(card vcard.Card) error {
+	if o.w != nil {
+		return o.w.Write(card)
 	}
-	ctx := context.Background()
-	authURL, errChan, err := provider.AuthorizeWithPKCE(ctx)
+	results, err := o.query.Run(contacts.CardToMap(card))
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(os.Stderr, "Opening browser for authorization...\nIf it doesn't open, visit:\n\n  %s\n\nWaiting for authorization...\n", authURL)
-	_ = openBrowser(authURL)
-	if err := <-errChan; err != nil {
-		return fmt.Errorf("authorization failed: %w", err)
+	for _, r := range results {
+		var data []byte
+		if o.lines {
+			data, err = json.Marshal(r)
+		} else {
+			data, err = json.MarshalIndent(r, "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func (o *cardJSONOutput) close() error {
+	if o.w != nil {
+		return o.w.Close()
 	}
-	fmt.Fprintln(os.Stderr, "Google Contacts initialized. Run 'contacts sync' to sync.")
 	return nil
 }
 
+var rootCmd = &cobra.Command{
+	Use:   "contacts",
+	Short: "manage your contacts",
+	Long: `Manage your contacts.
+
+Exit codes:
+  0  success
+  1  other error
+  2  contact not found
+  3  authorization required (run 'contacts init')
+  4  contact changed at the provider
+  5  provider unreachable
+  6  sync finished with conflicts`,
+	SilenceUsage: true,
+}
+
+var (
+	// syncPollInterval repeats sync until interrupted when set.
+	syncPollInterval time.Duration
+	syncOutputFormat string
+	syncPlan         bool
+	syncApply        string
+)
+
 var syncCmd = &cobra.Command{
 	Use:   "sync",
-	Short: "sync contacts from google",
-	Args:  cobra.NoArgs,
+	Short: "sync contacts from the configured provider",
+	Long: `Sync contacts from the provider set up with 'contacts init' (Google,
+Microsoft 365, Exchange Server, iCloud, Nextcloud, another CardDAV server,
+an LDAP directory, the macOS Contacts app or Proton). Contacts synced from
+an LDAP directory are read-only.
+
+Only contacts changed since the last sync are fetched and written. With
+--poll-interval, sync keeps running and checks for changes at that interval.
+
+With --plan, sync prints the changes it would make without applying them.
+Save the plan with -o json, remove any changes you do not want, and apply
+the rest with --apply plan.json.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if syncPlan && syncApply != "" {
+			return fmt.Errorf("--plan and --apply cannot be combined")
+		}
 		cm, err := getManager()
 		if err != nil {
 			return err
 		}
-		fmt.Fprintln(os.Stderr, "Syncing contacts...")
-		if err := cm.SyncContacts(); err != nil {
+		if syncPlan {
+			return printSyncPlan(cm)
+		}
+		if syncApply != "" {
+			return applySyncPlan(cm, syncApply)
+		}
+		err = runSync(cm)
+		if syncPollInterval <= 0 {
 			return err
 		}
-		list, err := cm.ListContacts()
-		if err != nil {
+		// Conflicts left by a round don't stop polling; later rounds may
+		// resolve them.
+		if errors.Is(err, errPartialSync) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if err != nil {
 			return err
 		}
-		fmt.Fprintf(os.Stderr, "Sync complete. %d contacts.\n", len(list))
+		ticker := time.NewTicker(syncPollInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := runSync(cm); err != nil {
+				if errors.Is(err, contacts.ErrNotInitialized) || errors.Is(err, contacts.ErrAuthExpired) {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
 		return nil
 	},
 }
 
-var listOutputFormat string
+func runSync(cm *contacts.ContactManager) error {
+	infof("Syncing contacts...\n")
+	result, err := cm.SyncContacts()
+	if err != nil {
+		return syncError(err)
+	}
+	return reportSync(result)
+}
+
+// syncError explains ErrNotInitialized from a sync.
+func syncError(err error) error {
+	if errors.Is(err, contacts.ErrNotInitialized) {
+		return fmt.Errorf("%w: no provider configured; contacts are stored locally only. Run 'contacts init' to set up sync", err)
+	}
+	return err
+}
+
+// printSyncPlan prints the changes a sync would make.
+func printSyncPlan(cm *contacts.ContactManager) error {
+	plan, err := cm.PlanSync()
+	if err != nil {
+		return syncError(err)
+	}
+	if syncOutputFormat == "json" {
+		if err := printJSON(plan); err != nil {
+			return err
+		}
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tUID\tNAME\tFIELDS")
+	for _, c := range plan.Changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Action, c.UID, c.Name, strings.Join(c.Fields, " "))
+	}
+	w.Flush()
+	infof("%d changes planned, %d contacts unchanged.\n", len(plan.Changes), plan.Skipped)
+	return nil
+}
+
+// applySyncPlan applies a plan saved from sync --plan -o json.
+func applySyncPlan(cm *contacts.ContactManager, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read sync plan: %w", err)
+	}
+	var plan contacts.SyncPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("failed to parse sync plan: %w", err)
+	}
+	result, err := cm.ApplySyncPlan(plan)
+	if err != nil {
+		return syncError(err)
+	}
+	return reportSync(result)
+}
+
+// reportSync prints a sync result and fails with errPartialSync if there
+// were conflicts.
+func reportSync(result contacts.SyncResult) error {
+	if syncOutputFormat == "json" {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		infof("Sync complete in %s: %d created, %d updated, %d deleted, %d unchanged.\n",
+			result.Duration.Round(time.Millisecond), result.Created, result.Updated, result.Deleted, result.Skipped)
+	}
+	for _, invalid := range result.Invalid {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %s\n", invalid)
+	}
+	if len(result.Conflicts) > 0 {
+		fmt.Fprintln(os.Stderr, "Run 'contacts verify' to resolve contacts edited both locally and at the provider.")
+		return fmt.Errorf("%w: %d conflicts: %s", errPartialSync, len(result.Conflicts), strings.Join(result.Conflicts, ", "))
+	}
+	return nil
+}
+
+var (
+	listOutputFormat string
+	listCity         string
+	listCountry      string
+	listKind         string
+	listGroup        string
+	listSort         string
+	listSeparate     bool
+)
 
 var listCmd = &cobra.Command{
 	Use:   "list",
@@ -181,17 +320,81 @@ var listCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		filters, err := buildFilters(cm, listGroup, nil)
+		if err != nil {
+			return err
+		}
+		if listKind != "all" {
+			kind, err := contacts.ParseKind(listKind)
+			if err != nil {
+				return err
+			}
+			filters = append(filters, contacts.OfKind(kind))
+		}
+		if listCity != "" {
+			filters = append(filters, contacts.InCity(listCity))
+		}
+		if listCountry != "" {
+			filters = append(filters, contacts.InCountry(listCountry))
+		}
+		if listSort == "none" && (listOutputFormat == "json" || listOutputFormat == "jsonl") {
+			// With --sort none stream cards straight from disk in storage
+			// order; linking needs every card at once, so linked contacts
+			// are listed separately.
+			out, err := newCardJSONOutput(listOutputFormat)
+			if err != nil {
+				return err
+			}
+			filter := contacts.AllOf(filters...)
+			err = cm.WalkContacts(func(card vcard.Card) error {
+				if !filter(card) {
+					return nil
+				}
+				return out.write(card)
+			})
+			if err != nil {
+				return err
+			}
+			return out.close()
+		}
 		list, err := cm.ListContacts()
 		if err != nil {
 			return err
 		}
+		if list, err = mergeLinked(cm, list, listSeparate); err != nil {
+			return err
+		}
+		list = contacts.FilterCards(list, filters...)
+		loc, err := displayLocale()
+		if err != nil {
+			return err
+		}
+		switch listSort {
+		case "none":
+		case "name":
+			sortByName(list, loc)
+		case "score":
+			sortByName(list, loc)
+			if err := cm.SortByStrength(list); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid --sort %q: expected name, score or none", listSort)
+		}
 		switch listOutputFormat {
-		case "json":
-			out, err := contacts.FormatCardsJSON(list)
+		case "json", "jsonl":
+			out, err := newCardJSONOutput(listOutputFormat)
 			if err != nil {
 				return err
 			}
-			fmt.Println(out)
+			for _, card := range list {
+				if err := out.write(card); err != nil {
+					return err
+				}
+			}
+			if err := out.close(); err != nil {
+				return err
+			}
 		case "vcf":
 			for _, card := range list {
 				data, err := contacts.EncodeCard(card)
@@ -217,14 +420,17 @@ var listCmd = &cobra.Command{
 	},
 }
 
-var getOutputFormat string
+var (
+	getOutputFormat string
+	getField        string
+)
 
 var getCmd = &cobra.Command{
 	Use:   "get <name|uid>",
 	Short: "get a contact by name or UID",
 	Args:  cobra.MinimumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return contactCompletions(toComplete), cobra.ShellCompDirectiveNoFileComp
+		return contactCompletions(toComplete), contactCompDirective
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := strings.Join(args, " ")
@@ -236,16 +442,24 @@ var getCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if card == nil {
-			return fmt.Errorf("contact not found: %s", query)
+		if err := cm.RecordAccess(contacts.CardUID(card)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if getField != "" {
+			values, err := contacts.SelectField(card, getField)
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				fmt.Println(v)
+			}
+			return nil
 		}
 		switch getOutputFormat {
 		case "json":
-			out, err := contacts.FormatCardJSON(card)
-			if err != nil {
+			if err := printJSON(contacts.CardToMap(card)); err != nil {
 				return err
 			}
-			fmt.Println(out)
 		case "vcf":
 			data, err := contacts.EncodeCard(card)
 			if err != nil {
@@ -253,10 +467,27 @@ var getCmd = &cobra.Command{
 			}
 			fmt.Print(string(data))
 		default: // table
-			if supportsKittyGraphics() {
-				renderPhoto(card)
+			if proto := detectGraphics(); proto != graphicsNone {
+				renderPhoto(card, proto)
+			}
+			loc, err := displayLocale()
+			if err != nil {
+				return err
+			}
+			fmt.Println(contacts.FormatCardLocale(card, loc))
+			var members []vcard.Card
+			switch card.Kind() {
+			case vcard.KindOrganization:
+				members, err = cm.OrgMembers(card)
+			case vcard.KindGroup:
+				members, err = cm.GroupMembers(card)
+			}
+			if err != nil {
+				return err
+			}
+			for _, m := range members {
+				fmt.Printf("  Member:    %s\n", contacts.CardFullName(m))
 			}
-			fmt.Println(contacts.FormatCard(card))
 		}
 		return nil
 	},
@@ -267,7 +498,7 @@ var deleteCmd = &cobra.Command{
 	Short: "delete a contact by name or UID",
 	Args:  cobra.MinimumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return contactCompletions(toComplete), cobra.ShellCompDirectiveNoFileComp
+		return contactCompletions(toComplete), contactCompDirective
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := strings.Join(args, " ")
@@ -279,28 +510,51 @@ var deleteCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		if card == nil {
-			return fmt.Errorf("contact not found: %s", query)
-		}
 		uid := contacts.CardUID(card)
 		fmt.Fprintf(os.Stderr, "Delete %q? [y/N] ", contacts.CardFullName(card))
 		var response string
 		fmt.Scanln(&response)
 		if strings.ToLower(response) != "y" {
-			fmt.Fprintln(os.Stderr, "Cancelled.")
+			infof("Cancelled.\n")
 			return nil
 		}
 		if err := cm.DeleteContact(uid); err != nil {
 			return err
 		}
-		fmt.Fprintln(os.Stderr, "Deleted.")
+		infof("Deleted.\n")
 		return nil
 	},
 }
 
 func init() {
-	listCmd.Flags().StringVarP(&listOutputFormat, "output", "o", "table", "output format (table|json|vcf)")
+	rootCmd.PersistentFlags().StringVar(&dirFlag, "dir", "", "contacts data directory (overrides CONTACTS_DIR)")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "suppress informational messages on stderr")
+	rootCmd.PersistentFlags().StringVar(&queryFlag, "query", "", "filter JSON output (-o json) with a jq query. list runs it on each contact in turn, e.g. '.name'; other commands run it once over their whole output, e.g. '.[] | .name'")
+	rootCmd.RegisterFlagCompletionFunc("dir", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+	syncCmd.Flags().DurationVar(&syncPollInterval, "poll-interval", 0, "keep syncing at this interval (e.g. 5m)")
+	syncCmd.Flags().StringVarP(&syncOutputFormat, "output", "o", "text", "output format (text|json)")
+	syncCmd.Flags().BoolVar(&syncPlan, "plan", false, "print the changes a sync would make without applying them")
+	syncCmd.Flags().StringVar(&syncApply, "apply", "", "apply a plan saved with --plan -o json")
+	listCmd.Flags().StringVarP(&listOutputFormat, "output", "o", "table", "output format (table|json|jsonl|vcf)")
+	listCmd.Flags().StringVar(&listKind, "kind", "individual", "kind of card to list (individual|org|group|location|all)")
+	listCmd.RegisterFlagCompletionFunc("kind", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"individual", "org", "group", "location", "all"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	listCmd.Flags().StringVar(&listGroup, "group", "", "only list members of this group")
+	listCmd.RegisterFlagCompletionFunc("group", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return groupCompletions(), cobra.ShellCompDirectiveNoFileComp
+	})
+	listCmd.Flags().StringVar(&listCity, "city", "", "only list contacts in this city")
+	listCmd.Flags().StringVar(&listCountry, "country", "", "only list contacts in this country (name or code, e.g. US)")
+	listCmd.Flags().BoolVar(&listSeparate, "separate", false, "list linked contacts separately instead of as one")
+	listCmd.Flags().StringVar(&listSort, "sort", "name", "sort order (name|score|none); score ranks by relationship strength, none keeps storage order. With none, json and jsonl output is streamed from disk and linked contacts are listed separately")
+	listCmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"name", "score", "none"}, cobra.ShellCompDirectiveNoFileComp
+	})
 	getCmd.Flags().StringVarP(&getOutputFormat, "output", "o", "table", "output format (table|json|vcf)")
+	getCmd.Flags().StringVar(&getField, "field", "", "print only the values of one field, one per line (e.g. email, tel[0], adr.city, email[0].type)")
 	outputFormats := []string{"table", "json", "vcf"}
 	listCmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		return outputFormats, cobra.ShellCompDirectiveNoFileComp
@@ -312,118 +566,105 @@ func init() {
 	rootCmd.AddCommand(initCmd, syncCmd, listCmd, getCmd, deleteCmd)
 }
 
-func getManager() (*contacts.ContactManager, error) {
-	cfg := contacts.NewConfig()
+func getManager(extra ...contacts.ManagerOption) (*contacts.ContactManager, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
 	if err := cfg.EnsureDir(); err != nil {
 		return nil, err
 	}
-	provider, err := contacts.NewGoogleContactsProvider(cfg.Dir)
+	if contacts.StoreExposed(cfg.Dir) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is readable by other users. Run 'contacts doctor --fix' to restrict it.\n", cfg.Dir)
+	}
+	opts, err := managerOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, extra...)
+	var provider interface {
+		contacts.ContactProvider
+		Initialize() error
+	}
+	switch cfg.Provider {
+	case contacts.ProviderLocal:
+		return contacts.NewContactManager(nil, cfg.Dir, opts...)
+	case contacts.ProviderMicrosoft:
+		provider, err = microsoft.NewProvider(cfg.Dir)
+	case contacts.ProviderExchange:
+		provider, err = ews.NewProvider(cfg.Dir)
+	case contacts.ProviderCardDAV:
+		provider, err = carddav.NewProvider(cfg.Dir)
+	case contacts.ProviderNextcloud:
+		provider, err = carddav.NewNextcloudProvider(cfg.Dir)
+	case contacts.ProviderICloud:
+		provider, err = carddav.NewICloudProvider(cfg.Dir)
+	case contacts.ProviderLDAP:
+		provider, err = ldap.NewProvider(cfg.Dir)
+	case contacts.ProviderMacOS:
+		provider, err = macos.NewProvider(cfg.Dir)
+	case contacts.ProviderProton:
+		provider, err = proton.NewProvider(cfg.Dir)
+	default:
+		var g *google.Provider
+		if g, err = google.NewProvider(cfg.Dir); err == nil {
+			g.SetPhotoOptions(cfg.Photos)
+		}
+		provider = g
+	}
 	if err != nil {
 		return nil, err
 	}
 	if err := provider.Initialize(); err != nil {
 		return nil, fmt.Errorf("%w. Run 'contacts init' first", err)
 	}
-	return contacts.NewContactManager(provider, cfg.Dir)
-}
-
-// getManagerQuiet returns a manager without provider init (for completion).
-func getManagerQuiet() (*contacts.ContactManager, error) {
-	cfg := contacts.NewConfig()
-	return contacts.NewContactManager(nil, cfg.Dir)
+	return contacts.NewContactManager(provider, cfg.Dir, opts...)
 }
 
-// supportsKittyGraphics sends a graphics protocol query action followed by a
-// device attributes request. If the terminal understands the protocol it replies
-// to the graphics query; otherwise only the device attributes response arrives.
-func supportsKittyGraphics() bool {
-	fd := int(os.Stdin.Fd())
-	if !term.IsTerminal(fd) {
-		return false
-	}
+// loadedConfig is the configuration loadConfig read, kept so config.json
+// is read once per process.
+var loadedConfig *contacts.Config
 
-	oldState, err := term.MakeRaw(fd)
-	if err != nil {
-		return false
-	}
-	defer term.Restore(fd, oldState)
-
-	// Query: 1x1 pixel, 24-bit, query action, direct transmission + device attributes request
-	os.Stdout.WriteString("\033_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\033\\\033[c")
-
-	// Read response with timeout
-	buf := make([]byte, 256)
-	deadline := time.Now().Add(500 * time.Millisecond)
-	var response []byte
-	for time.Now().Before(deadline) {
-		os.Stdin.SetReadDeadline(deadline)
-		n, err := os.Stdin.Read(buf)
-		if n > 0 {
-			response = append(response, buf[:n]...)
-			// Device attributes response ends with 'c'
-			if bytes.ContainsRune(response, 'c') {
-				break
-			}
-		}
-		if err != nil {
-			break
-		}
+// loadConfig returns the configuration for the selected data directory
+// with its config.json applied.
+func loadConfig() (*contacts.Config, error) {
+	if loadedConfig != nil {
+		return loadedConfig, nil
 	}
-	os.Stdin.SetReadDeadline(time.Time{})
-
-	// If the response contains _G, the terminal answered the graphics query
-	return bytes.Contains(response, []byte("_G"))
-}
-
-// renderPhoto fetches the contact's photo URL and displays it inline
-// using the Kitty graphics protocol (supported by Ghostty, Kitty, etc.).
-func renderPhoto(card vcard.Card) {
-	photos := card[vcard.FieldPhoto]
-	if len(photos) == 0 || photos[0].Value == "" {
-		return
+	cfg := contacts.NewConfig()
+	if dirFlag != "" {
+		cfg.Dir = dirFlag
 	}
-
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Get(photos[0].Value)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return
+	if err := cfg.Load(); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	loadedConfig = cfg
+	return cfg, nil
+}
 
-	img, _, err := image.Decode(resp.Body)
+// getManagerQuiet returns a manager without provider init (for completion).
+func getManagerQuiet() (*contacts.ContactManager, error) {
+	cfg, err := loadConfig()
 	if err != nil {
-		return
+		return nil, err
 	}
-
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, img); err != nil {
-		return
+	opts, err := managerOptions(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return contacts.NewContactManager(nil, cfg.Dir, opts...)
+}
 
-	b64 := base64.StdEncoding.EncodeToString(buf.Bytes())
-
-	const chunkSize = 4096
-	for i := 0; i < len(b64); i += chunkSize {
-		end := i + chunkSize
-		if end > len(b64) {
-			end = len(b64)
-		}
-		chunk := b64[i:end]
-
-		if i == 0 {
-			// First chunk: set action=transmit+display, format=PNG, display height=8 rows
-			m := 0
-			if end < len(b64) {
-				m = 1
-			}
-			fmt.Fprintf(os.Stdout, "\033_Ga=T,f=100,r=8,m=%d;%s\033\\", m, chunk)
-		} else if end >= len(b64) {
-			fmt.Fprintf(os.Stdout, "\033_Gm=0;%s\033\\", chunk)
-		} else {
-			fmt.Fprintf(os.Stdout, "\033_Gm=1;%s\033\\", chunk)
-		}
+// managerOptions returns the config's manager options plus CLI reporting
+// of webhook delivery failures.
+func managerOptions(cfg *contacts.Config) ([]contacts.ManagerOption, error) {
+	opts, err := cfg.ManagerOptions()
+	if err != nil {
+		return nil, err
 	}
-	fmt.Println()
+	return append(opts, contacts.WithWebhookErrorHandler(func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	})), nil
 }
 
 func openBrowser(url string) error {
@@ -447,6 +688,9 @@ func openBrowser(url string) error {
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		if errors.Is(err, contacts.ErrAuthExpired) {
+			fmt.Fprintln(os.Stderr, "Google authorization has expired. Run 'contacts init' to re-authorize.")
+		}
+		os.Exit(exitCode(err))
 	}
 }
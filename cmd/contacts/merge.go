@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/charmbracelet/huh"
+	"github.com/emersion/go-vcard"
+)
+
+// sideWidth is the width of the left column in side-by-side comparisons.
+const sideWidth = 36
+
+// chooseFields asks, for each field the two versions of a contact set
+// differently, whether to keep the left value, the right value or both,
+// and returns the merged card.
+func chooseFields(left, right vcard.Card, leftLabel, rightLabel string) (vcard.Card, error) {
+	choices := map[string]contacts.Choice{}
+	for _, c := range contacts.FieldConflicts(left, right) {
+		choice := contacts.ChooseLeft
+		options := []huh.Option[contacts.Choice]{
+			huh.NewOption("Keep "+leftLabel, contacts.ChooseLeft),
+			huh.NewOption("Keep "+rightLabel, contacts.ChooseRight),
+		}
+		if c.Combinable {
+			options = append(options, huh.NewOption("Keep both", contacts.ChooseBoth))
+		}
+		if err := huh.NewSelect[contacts.Choice]().
+			Title(c.Field).
+			Description(sideBySide(leftLabel, rightLabel, fieldLines(c.Left), fieldLines(c.Right))).
+			Options(options...).
+			Value(&choice).
+			Run(); err != nil {
+			return nil, err
+		}
+		choices[c.Field] = choice
+	}
+	return contacts.MergeChoices(left, right, choices), nil
+}
+
+// fieldLines renders one line per field value, with its TYPE if any.
+func fieldLines(fields []*vcard.Field) []string {
+	var lines []string
+	for _, f := range fields {
+		line := f.Value
+		if types := f.Params.Types(); len(types) > 0 {
+			line += " (" + strings.Join(types, ",") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// sideBySide lays out two columns of values under their labels.
+func sideBySide(leftLabel, rightLabel string, left, right []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-*s │ %s\n", sideWidth, leftLabel, rightLabel)
+	for i := 0; i < max(len(left), len(right)); i++ {
+		var l, r string
+		if i < len(left) {
+			l = truncate(left[i], sideWidth)
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		fmt.Fprintf(&b, "%-*s │ %s\n", sideWidth, l, r)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
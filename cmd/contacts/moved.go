@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	movedSince        string
+	movedOutputFormat string
+)
+
+var movedCmd = &cobra.Command{
+	Use:   "moved",
+	Short: "list contacts whose address changed, e.g. before sending holiday cards",
+	Long: `List contacts whose address changed since a date or age, whether edited
+locally or updated by sync. The previous address is kept in the audit log
+(see 'contacts log -o json').`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		since, err := contacts.ParseSince(movedSince, time.Now())
+		if err != nil {
+			return err
+		}
+		moves, err := cm.Moved(since)
+		if err != nil {
+			return err
+		}
+		if movedOutputFormat == "json" {
+			if err := printJSON(moves); err != nil {
+				return err
+			}
+			return nil
+		}
+		if len(moves) == 0 {
+			infof("No address changes since %s.\n", since.Format("2006-01-02"))
+			return nil
+		}
+		loc, err := displayLocale()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tNAME\tFROM\tTO")
+		for _, m := range moves {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				loc.FormatDate(m.Time.Local()),
+				m.Name,
+				strings.Join(m.From, "; "),
+				strings.Join(m.To, "; "),
+			)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	movedCmd.Flags().StringVar(&movedSince, "since", "1y", "only list moves since a date or age (e.g. 6m, 2024-01-01)")
+	movedCmd.Flags().StringVarP(&movedOutputFormat, "output", "o", "table", "output format (table|json)")
+	rootCmd.AddCommand(movedCmd)
+}
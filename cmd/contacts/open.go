@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open <contact> [service]",
+	Short: "open a contact's social profile in the browser",
+	Long: `Open a contact's profile on a social network (github, linkedin, mastodon,
+twitter or instagram) in the browser. The service may be left out if the
+contact has a single profile.
+
+Profiles come from the contact's X-SOCIALPROFILE fields and from URLs that
+point at a profile on one of these services.`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return contactCompletions(toComplete), contactCompDirective
+		}
+		var types []string
+		for _, s := range contacts.SocialServices {
+			types = append(types, s.Type)
+		}
+		return types, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		// Cards not synced since profiles were detected still have them
+		// only as URLs.
+		contacts.DetectSocialProfiles(card)
+		profiles := contacts.SocialProfiles(card)
+		name := contacts.CardFullName(card)
+
+		var profile *contacts.SocialProfile
+		if len(args) == 2 {
+			service, ok := contacts.SocialServiceByType(args[1])
+			if !ok {
+				return fmt.Errorf("unknown service %q", args[1])
+			}
+			for i := range profiles {
+				if profiles[i].Service.Type == service.Type {
+					profile = &profiles[i]
+					break
+				}
+			}
+			if profile == nil {
+				return fmt.Errorf("%w: %s has no %s profile", contacts.ErrNotFound, name, service.Name)
+			}
+		} else {
+			switch len(profiles) {
+			case 0:
+				return fmt.Errorf("%w: %s has no social profiles", contacts.ErrNotFound, name)
+			case 1:
+				profile = &profiles[0]
+			default:
+				var types []string
+				for _, p := range profiles {
+					types = append(types, p.Service.Type)
+				}
+				return fmt.Errorf("%s has several profiles, pick one of: %s", name, strings.Join(types, ", "))
+			}
+		}
+
+		target := profile.URL
+		if !strings.Contains(target, "://") {
+			target = "https://" + target
+		}
+		fmt.Println(target)
+		if err := openBrowser(target); err != nil {
+			return fmt.Errorf("failed to open browser: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"golang.org/x/term"
+)
+
+// graphicsProtocol is an inline image protocol supported by the terminal.
+type graphicsProtocol int
+
+const (
+	graphicsNone graphicsProtocol = iota
+	graphicsKitty
+	graphicsITerm2
+	graphicsSixel
+)
+
+// photoRows is the height of a rendered photo in terminal rows.
+const photoRows = 8
+
+// detectGraphics works out which inline image protocol the terminal speaks.
+// Well-known terminals are recognized from the environment; otherwise the
+// terminal is queried directly where that is safe (not on Windows consoles).
+func detectGraphics() graphicsProtocol {
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
+		return graphicsNone
+	}
+	if proto := graphicsFromEnv(os.Getenv); proto != graphicsNone {
+		return proto
+	}
+	if runtime.GOOS == "windows" {
+		return graphicsNone
+	}
+	return queryGraphics()
+}
+
+// graphicsFromEnv identifies the terminal from environment variables it sets.
+func graphicsFromEnv(getenv func(string) string) graphicsProtocol {
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+		return graphicsITerm2
+	case "ghostty":
+		return graphicsKitty
+	}
+	if getenv("KITTY_WINDOW_ID") != "" || getenv("TERM") == "xterm-kitty" {
+		return graphicsKitty
+	}
+	return graphicsNone
+}
+
+// queryGraphics sends a kitty graphics query followed by a primary device
+// attributes request. A terminal that understands the kitty protocol answers
+// the graphics query; one that supports sixel lists attribute 4 in its device
+// attributes. Terminals that support neither only send device attributes.
+func queryGraphics() graphicsProtocol {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return graphicsNone
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return graphicsNone
+	}
+	defer term.Restore(fd, oldState)
+
+	// Query: 1x1 pixel, 24-bit, query action, direct transmission + device attributes request
+	os.Stdout.WriteString("\033_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\033\\\033[c")
+
+	// Read response with timeout. Not every platform supports read
+	// deadlines on stdin; without one we can't bound the wait, so give up.
+	deadline := time.Now().Add(500 * time.Millisecond)
+	if err := os.Stdin.SetReadDeadline(deadline); err != nil {
+		return graphicsNone
+	}
+	defer os.Stdin.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, 256)
+	var response []byte
+	for time.Now().Before(deadline) {
+		n, err := os.Stdin.Read(buf)
+		if n > 0 {
+			response = append(response, buf[:n]...)
+			// Device attributes response ends with 'c'
+			if bytes.ContainsRune(response, 'c') {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return parseGraphicsResponse(response)
+}
+
+// parseGraphicsResponse interprets the terminal's answer to queryGraphics.
+func parseGraphicsResponse(response []byte) graphicsProtocol {
+	// If the response contains _G, the terminal answered the graphics query
+	if bytes.Contains(response, []byte("_G")) {
+		return graphicsKitty
+	}
+	// Device attributes look like ESC [ ? 62 ; 4 ; 22 c
+	start := bytes.Index(response, []byte("\033[?"))
+	if start < 0 {
+		return graphicsNone
+	}
+	attrs := response[start+3:]
+	if end := bytes.IndexByte(attrs, 'c'); end >= 0 {
+		attrs = attrs[:end]
+	}
+	for _, attr := range strings.Split(string(attrs), ";") {
+		if attr == "4" {
+			return graphicsSixel
+		}
+	}
+	return graphicsNone
+}
+
+// renderSize is the largest width or height, in pixels, of a photo sent
+// to the terminal.
+const renderSize = 256
+
+// renderPhoto fetches the contact's photo and displays it inline using the
+// given protocol.
+func renderPhoto(card vcard.Card, proto graphicsProtocol) {
+	data, err := contacts.FetchPhoto(card)
+	if err != nil || data == nil {
+		return
+	}
+	img, err := contacts.DecodeImage(data)
+	if err != nil {
+		return
+	}
+	img = contacts.FitImage(img, renderSize)
+
+	switch proto {
+	case graphicsKitty:
+		writeKitty(os.Stdout, img)
+	case graphicsITerm2:
+		writeITerm2(os.Stdout, img)
+	case graphicsSixel:
+		writeSixel(os.Stdout, img)
+	}
+	fmt.Println()
+}
+
+// writeKitty transmits img as PNG using the Kitty graphics protocol
+// (supported by Ghostty, Kitty, etc.).
+func writeKitty(w io.Writer, img image.Image) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return
+	}
+
+	b64 := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	const chunkSize = 4096
+	for i := 0; i < len(b64); i += chunkSize {
+		end := i + chunkSize
+		if end > len(b64) {
+			end = len(b64)
+		}
+		chunk := b64[i:end]
+
+		if i == 0 {
+			// First chunk: set action=transmit+display, format=PNG, display height in rows
+			m := 0
+			if end < len(b64) {
+				m = 1
+			}
+			fmt.Fprintf(w, "\033_Ga=T,f=100,r=%d,m=%d;%s\033\\", photoRows, m, chunk)
+		} else if end >= len(b64) {
+			fmt.Fprintf(w, "\033_Gm=0;%s\033\\", chunk)
+		} else {
+			fmt.Fprintf(w, "\033_Gm=1;%s\033\\", chunk)
+		}
+	}
+}
+
+// writeITerm2 transmits img as PNG using the iTerm2 inline image protocol
+// (supported by iTerm2 and WezTerm).
+func writeITerm2(w io.Writer, img image.Image) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return
+	}
+	fmt.Fprintf(w, "\033]1337;File=inline=1;size=%d;height=%d;preserveAspectRatio=1:%s\a",
+		buf.Len(), photoRows, base64.StdEncoding.EncodeToString(buf.Bytes()))
+}
+
+// sixelHeight is the pixel height photos are scaled to for sixel output,
+// roughly photoRows on a typical terminal font.
+const sixelHeight = 160
+
+// photoFromFile prepares an image file as a PHOTO value using the
+// configured photo options.
+func photoFromFile(path string) (string, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", err
+	}
+	return contacts.PhotoFromFile(path, cfg.Photos)
+}
+
+// writeSixel encodes img as sixel graphics using the web-safe palette.
+func writeSixel(w io.Writer, img image.Image) {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return
+	}
+	height := sixelHeight
+	width := b.Dx() * height / b.Dy()
+	if width == 0 {
+		width = 1
+	}
+
+	// Nearest-neighbour scale, then dither onto the palette.
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scaled.Set(x, y, img.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	pal := image.NewPaletted(scaled.Bounds(), palette.WebSafe)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), scaled, image.Point{})
+
+	var out bytes.Buffer
+	out.WriteString("\033Pq")
+	fmt.Fprintf(&out, "\"1;1;%d;%d", width, height)
+	for i, c := range pal.Palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+	for band := 0; band < height; band += 6 {
+		used := map[uint8]bool{}
+		for y := band; y < band+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				used[pal.ColorIndexAt(x, y)] = true
+			}
+		}
+		first := true
+		for idx := range used {
+			if !first {
+				out.WriteByte('$') // return to the start of the band
+			}
+			first = false
+			fmt.Fprintf(&out, "#%d", idx)
+			var run int
+			var last byte
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && band+dy < height; dy++ {
+					if pal.ColorIndexAt(x, band+dy) == idx {
+						bits |= 1 << dy
+					}
+				}
+				ch := 63 + bits
+				if run > 0 && ch != last {
+					writeSixelRun(&out, last, run)
+					run = 0
+				}
+				last = ch
+				run++
+			}
+			writeSixelRun(&out, last, run)
+		}
+		out.WriteByte('-') // next band
+	}
+	out.WriteString("\033\\")
+	w.Write(out.Bytes())
+}
+
+func writeSixelRun(out *bytes.Buffer, ch byte, n int) {
+	if n > 3 {
+		fmt.Fprintf(out, "!%d%c", n, ch)
+		return
+	}
+	for i := 0; i < n; i++ {
+		out.WriteByte(ch)
+	}
+}
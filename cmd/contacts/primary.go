@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+// primaryFields maps the kinds set-primary accepts to vCard properties.
+var primaryFields = map[string]string{
+	"email": vcard.FieldEmail,
+	"phone": vcard.FieldTelephone,
+}
+
+var setPrimaryCmd = &cobra.Command{
+	Use:   "set-primary <contact> <email|phone> <value>",
+	Short: "mark one of a contact's emails or phones as primary",
+	Long: `Mark one of a contact's email addresses or phone numbers as the primary one,
+by giving it PREF=1 and clearing the preference of the others:
+
+  contacts set-primary "Sam Rivera" email sam@work.example
+  contacts set-primary "Sam Rivera" phone "+1 555 0100"
+
+Phone numbers match regardless of formatting.`,
+	Args: cobra.ExactArgs(3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return contactCompletions(toComplete), contactCompDirective
+		case 1:
+			return []string{"email", "phone"}, cobra.ShellCompDirectiveNoFileComp
+		case 2:
+			cm, err := getManagerQuiet()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			card, err := cm.ResolveContact(args[0])
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return card.Values(primaryFields[args[1]]), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		field, ok := primaryFields[args[1]]
+		if !ok {
+			return fmt.Errorf("unknown field %q: expected email or phone", args[1])
+		}
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		if err := contacts.SetPreferred(card, field, args[2]); err != nil {
+			return err
+		}
+		if err := enforcePolicy(card); err != nil {
+			return err
+		}
+		if err := cm.WriteContact(card); err != nil {
+			return err
+		}
+		infof("Set the primary %s of %s.\n", args[1], contacts.CardFullName(card))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setPrimaryCmd)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recentLimit        int
+	recentOutputFormat string
+)
+
+var recentCmd = &cobra.Command{
+	Use:   "recent",
+	Short: "list recently viewed or edited contacts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.RecentContacts(recentLimit)
+		if err != nil {
+			return err
+		}
+		switch recentOutputFormat {
+		case "json":
+			if err := printJSON(contacts.CardsToMaps(list)); err != nil {
+				return err
+			}
+		default: // table
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "UID\tNAME\tEMAIL\tPHONE")
+			for _, card := range list {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					contacts.CardUID(card),
+					contacts.CardFullName(card),
+					contacts.PrimaryEmail(card),
+					contacts.PrimaryPhone(card),
+				)
+			}
+			w.Flush()
+		}
+		return nil
+	},
+}
+
+func init() {
+	recentCmd.Flags().IntVarP(&recentLimit, "limit", "n", 10, "maximum number of contacts to list (0 for all)")
+	recentCmd.Flags().StringVarP(&recentOutputFormat, "output", "o", "table", "output format (table|json)")
+	rootCmd.AddCommand(recentCmd)
+}
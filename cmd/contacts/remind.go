@@ -0,0 +1,311 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remindDays   int
+	remindLead   []int
+	remindDaemon bool
+	remindAt     string
+	remindEmail  bool
+	remindEmit   string
+)
+
+var remindCmd = &cobra.Command{
+	Use:   "remind",
+	Short: "show upcoming birthdays, anniversaries and events",
+	Long: `Show upcoming birthdays, anniversaries and custom events (see
+'contacts event').
+
+With --emit, print them together with contacts overdue for their
+keep-in-touch cadence as tasks for taskwarrior ('contacts remind --emit
+taskwarrior | task import') or todo.txt ('contacts remind --emit todotxt
+>> todo.txt'). Task IDs are stable, so taskwarrior updates tasks it has
+already imported.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		if remindDaemon {
+			return runReminderDaemon(cm)
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		if remindEmail {
+			return sendReminderDigest(list)
+		}
+		if remindEmit != "" {
+			return emitReminderTasks(cm, list)
+		}
+		occasions := contacts.UpcomingOccasions(list, time.Now(), remindDays)
+		if len(occasions) == 0 {
+			infof("Nothing in the next %d days.\n", remindDays)
+			return nil
+		}
+		loc, err := displayLocale()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tIN\tNAME\tOCCASION")
+		for _, o := range occasions {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				loc.FormatWeekday(o.Date),
+				formatDaysUntil(o.DaysUntil),
+				contacts.CardFullName(o.Card),
+				describeOccasion(o),
+			)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// runReminderDaemon wakes once a day at --at and sends a desktop
+// notification for every occasion that is exactly one of the lead times away.
+func runReminderDaemon(cm *contacts.ContactManager) error {
+	hour, minute, err := parseClock(remindAt)
+	if err != nil {
+		return err
+	}
+	maxLead := 0
+	for _, d := range remindLead {
+		if d > maxLead {
+			maxLead = d
+		}
+	}
+	infof("Reminder daemon started; notifying daily at %02d:%02d.\n", hour, minute)
+	for {
+		now := time.Now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		time.Sleep(time.Until(next))
+
+		list, err := cm.ListContacts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list contacts: %v\n", err)
+			continue
+		}
+		for _, o := range contacts.UpcomingOccasions(list, time.Now(), maxLead) {
+			if !containsInt(remindLead, o.DaysUntil) {
+				continue
+			}
+			title := fmt.Sprintf("%s's %s %s", contacts.CardFullName(o.Card), o.Kind, formatDaysUntil(o.DaysUntil))
+			if err := notify(title, describeOccasion(o)); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to send notification: %v\n", err)
+			}
+		}
+	}
+}
+
+// emitReminderTasks prints upcoming occasions and overdue keep-in-touch
+// contacts in the task format chosen with --emit.
+func emitReminderTasks(cm *contacts.ContactManager, list []vcard.Card) error {
+	now := time.Now()
+	due, err := cm.DueContacts(now)
+	if err != nil {
+		return err
+	}
+	tasks := contacts.ReminderTasks(contacts.UpcomingOccasions(list, now, remindDays), due, now)
+	switch remindEmit {
+	case "taskwarrior":
+		err = contacts.WriteTaskwarrior(os.Stdout, tasks)
+	case "todotxt":
+		err = contacts.WriteTodoTxt(os.Stdout, tasks)
+	default:
+		return fmt.Errorf("invalid --emit %q: expected taskwarrior or todotxt", remindEmit)
+	}
+	if err != nil {
+		return err
+	}
+	infof("Emitted %d tasks.\n", len(tasks))
+	return nil
+}
+
+// sendReminderDigest emails a digest of upcoming occasions and stale
+// contacts using the SMTP settings from the config file.
+func sendReminderDigest(list []vcard.Card) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.SMTP.Host == "" || cfg.SMTP.From == "" || len(cfg.SMTP.To) == 0 {
+		return fmt.Errorf("smtp host, from and to must be set in %s", cfg.Path())
+	}
+	staleDays := cfg.StaleAfterDays
+	if staleDays == 0 {
+		staleDays = 365
+	}
+	loc, err := contacts.ParseLocale(cfg.Locale)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	body := contacts.FormatDigestLocale(
+		contacts.UpcomingOccasions(list, now, remindDays),
+		contacts.StaleContacts(list, now.AddDate(0, 0, -staleDays)),
+		loc,
+	)
+	subject := fmt.Sprintf("Contacts digest for %s", loc.FormatDate(now))
+	if err := sendMail(cfg.SMTP, subject, body); err != nil {
+		return err
+	}
+	infof("Digest sent to %s.\n", strings.Join(cfg.SMTP.To, ", "))
+	return nil
+}
+
+// sendMail delivers a plain-text message. Port 465 uses implicit TLS; other
+// ports use STARTTLS when the server offers it.
+func sendMail(cfg contacts.SMTPConfig, subject, body string) error {
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(cfg.Host, fmt.Sprint(port))
+	msg := []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		cfg.From, strings.Join(cfg.To, ", "), subject, time.Now().Format(time.RFC1123Z),
+		strings.ReplaceAll(body, "\n", "\r\n")))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	if port != 465 {
+		if err := smtp.SendMail(addr, auth, cfg.From, cfg.To, msg); err != nil {
+			return fmt.Errorf("failed to send mail: %w", err)
+		}
+		return nil
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: cfg.Host})
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	for _, to := range cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to send mail to %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return c.Quit()
+}
+
+func describeOccasion(o contacts.Occasion) string {
+	if n := o.Years(); n > 0 {
+		if o.Kind == "birthday" {
+			return fmt.Sprintf("birthday (turns %d)", n)
+		}
+		return fmt.Sprintf("%s (%d years)", o.Kind, n)
+	}
+	return o.Kind
+}
+
+func formatDaysUntil(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
+
+func parseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q: expected HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// notify shows a desktop notification using the platform's native tooling.
+func notify(title, body string) error {
+	var cmd string
+	var args []string
+	switch runtime.GOOS {
+	case "linux":
+		cmd = "notify-send"
+		args = []string{"--app-name=contacts", title, body}
+	case "darwin":
+		cmd = "osascript"
+		args = []string{"-e", fmt.Sprintf("display notification %q with title %q", body, title)}
+	case "windows":
+		cmd = "powershell"
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms, System.Drawing; `+
+			`$n = New-Object System.Windows.Forms.NotifyIcon; `+
+			`$n.Icon = [System.Drawing.SystemIcons]::Information; $n.Visible = $true; `+
+			`$n.ShowBalloonTip(10000, '%s', '%s', 'Info'); Start-Sleep -Seconds 10; $n.Dispose()`,
+			strings.ReplaceAll(title, "'", "''"), strings.ReplaceAll(body, "'", "''"))
+		args = []string{"-NoProfile", "-Command", script}
+	default:
+		return fmt.Errorf("unsupported platform")
+	}
+	return exec.Command(cmd, args...).Run()
+}
+
+func init() {
+	remindCmd.Flags().IntVar(&remindDays, "days", 30, "how many days ahead to look")
+	remindCmd.Flags().BoolVar(&remindDaemon, "daemon", false, "run in the foreground and send desktop notifications")
+	remindCmd.Flags().IntSliceVar(&remindLead, "lead", []int{7, 0}, "with --daemon, notify this many days before each occasion")
+	remindCmd.Flags().BoolVar(&remindEmail, "email", false, "email a digest of upcoming dates and stale contacts using the smtp config")
+	remindCmd.Flags().StringVar(&remindAt, "at", "09:00", "with --daemon, time of day to send notifications (HH:MM)")
+	remindCmd.Flags().StringVar(&remindEmit, "emit", "", "print reminders as tasks (taskwarrior|todotxt)")
+	remindCmd.RegisterFlagCompletionFunc("emit", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"taskwarrior", "todotxt"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	rootCmd.AddCommand(remindCmd)
+}
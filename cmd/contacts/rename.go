@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var renameUIDCmd = &cobra.Command{
+	Use:   "rename-uid <contact> <new-uid>",
+	Short: "change a contact's UID",
+	Long: `Change a contact's UID in the local store.
+
+The contact file is renamed, references to it from other contacts (group
+members and organization links) are updated, and its sync state moves with
+it. The provider is not changed.`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		oldUID := contacts.CardUID(card)
+		if err := cm.RenameUID(oldUID, args[1]); err != nil {
+			return err
+		}
+		infof("Renamed %s to %s.\n", oldUID, args[1])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameUIDCmd)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportOutputFormat string
+	coverageFields     []string
+	coverageMissing    string
+	strengthFading     bool
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "summarize contacts by organization, email domain, field coverage or relationship strength",
+}
+
+var reportOrgsCmd = &cobra.Command{
+	Use:   "orgs",
+	Short: "count contacts per organization",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReport("ORGANIZATION", contacts.OrgReport)
+	},
+}
+
+var reportDomainsCmd = &cobra.Command{
+	Use:   "domains",
+	Short: "count contacts per email domain",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReport("DOMAIN", contacts.DomainReport)
+	},
+}
+
+var reportCoverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "count contacts missing email, phone, birthday, address or photo",
+	Long: `Count how many individual contacts are missing each of a set of fields.
+
+Use --fields to choose the fields and --list-missing to list the contacts
+missing one of them, e.g. to work through during a cleanup.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		list = contacts.FilterCards(list, contacts.OfKind(vcard.KindIndividual))
+
+		if coverageMissing != "" {
+			missing := contacts.MissingField(list, coverageMissing)
+			if reportOutputFormat == "json" {
+				if err := printJSON(contacts.CardsToMaps(missing)); err != nil {
+					return err
+				}
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "UID\tNAME")
+			for _, card := range missing {
+				fmt.Fprintf(w, "%s\t%s\n", contacts.CardUID(card), contacts.CardFullName(card))
+			}
+			w.Flush()
+			infof("%d of %d contacts have no %s.\n", len(missing), len(list), coverageMissing)
+			return nil
+		}
+
+		rows := contacts.CoverageReport(list, coverageFields)
+		if reportOutputFormat == "json" {
+			if err := printJSON(rows); err != nil {
+				return err
+			}
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FIELD\tMISSING\tPERCENT")
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s\t%d\t%.0f%%\n", row.Field, row.Missing, row.Percent)
+		}
+		w.Flush()
+		infof("%d contacts checked.\n", len(list))
+		return nil
+	},
+}
+
+var reportStrengthCmd = &cobra.Command{
+	Use:   "strength",
+	Short: "score relationships by how often and how recently you were in touch",
+	Long: `Score each individual contact from 0 to 100 by the interactions recorded
+with 'contacts touch', calls and messages imported from your phone, and
+emails logged by 'contacts hook email-sent'. Recent interactions count the
+most.
+
+A relationship is fading when its score is lower than it was 90 days ago;
+--fading lists only those.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		list, err := cm.ListContacts()
+		if err != nil {
+			return err
+		}
+		list = contacts.FilterCards(list, contacts.OfKind(vcard.KindIndividual))
+		rels, err := cm.Relationships(list, time.Now())
+		if err != nil {
+			return err
+		}
+		var shown []contacts.Relationship
+		for _, r := range rels {
+			if r.Count == 0 || (strengthFading && !r.Fading()) {
+				continue
+			}
+			shown = append(shown, r)
+		}
+
+		if reportOutputFormat == "json" {
+			type strengthJSON struct {
+				UID          string     `json:"uid"`
+				Name         string     `json:"name"`
+				Score        int        `json:"score"`
+				Previous     int        `json:"previous"`
+				Fading       bool       `json:"fading"`
+				Interactions int        `json:"interactions"`
+				Last         *time.Time `json:"last,omitempty"`
+			}
+			out := []strengthJSON{}
+			for _, r := range shown {
+				last := r.Last
+				out = append(out, strengthJSON{
+					UID:          contacts.CardUID(r.Card),
+					Name:         contacts.CardFullName(r.Card),
+					Score:        r.Score,
+					Previous:     r.Previous,
+					Fading:       r.Fading(),
+					Interactions: r.Count,
+					Last:         &last,
+				})
+			}
+			if err := printJSON(out); err != nil {
+				return err
+			}
+			return nil
+		}
+		loc, err := displayLocale()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SCORE\tTREND\tNAME\tINTERACTIONS\tLAST")
+		for _, r := range shown {
+			trend := ""
+			switch {
+			case r.Fading():
+				trend = fmt.Sprintf("fading (was %d)", r.Previous)
+			case r.Score > r.Previous:
+				trend = "growing"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\n", r.Score, trend, contacts.CardFullName(r.Card), r.Count, loc.FormatDate(r.Last))
+		}
+		w.Flush()
+		if n := len(list) - len(shown); n > 0 && !strengthFading {
+			infof("%d contacts have no recorded interactions.\n", n)
+		}
+		return nil
+	},
+}
+
+func runReport(heading string, build func([]vcard.Card) []contacts.ReportRow) error {
+	cm, err := getManagerQuiet()
+	if err != nil {
+		return err
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		return err
+	}
+	rows := build(list)
+
+	switch reportOutputFormat {
+	case "json":
+		if err := printJSON(rows); err != nil {
+			return err
+		}
+	default: // table
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tCONTACTS\n", heading)
+		for _, row := range rows {
+			fmt.Fprintf(w, "%s\t%d\n", row.Name, row.Count)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+func init() {
+	reportCmd.PersistentFlags().StringVarP(&reportOutputFormat, "output", "o", "table", "output format (table|json)")
+	reportCoverageCmd.Flags().StringSliceVar(&coverageFields, "fields", contacts.DefaultCoverageFields, "fields to check (names or aliases, e.g. email,phone,x-twitter)")
+	reportCoverageCmd.Flags().StringVar(&coverageMissing, "list-missing", "", "list the contacts missing this field")
+	reportStrengthCmd.Flags().BoolVar(&strengthFading, "fading", false, "only list relationships weaker than 90 days ago")
+	reportCmd.AddCommand(reportOrgsCmd, reportDomainsCmd, reportCoverageCmd, reportStrengthCmd)
+	rootCmd.AddCommand(reportCmd)
+}
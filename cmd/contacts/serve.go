@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen      string
+	serveTokens      []string
+	serveTLSCert     string
+	serveTLSKey      string
+	serveClientCA    string
+	serveAllowUnauth bool
+	serveRateLimit   int
+	serveProxies     []string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "serve contacts over HTTP",
+	Long: `Serve the local store over HTTP until interrupted:
+
+  GET    /contacts              all contacts as JSON
+  POST   /contacts              create a contact from a vCard body
+  POST   /contacts:batch        apply a list of creates, updates and deletes
+                                all at once, or none if any fails
+  GET    /contacts/{uid}        one contact as JSON, or as a vCard with
+                                Accept: text/vcard or ?format=vcf
+  PUT    /contacts/{uid}        create or replace a contact from a vCard body
+  DELETE /contacts/{uid}        delete a contact
+  GET    /contacts/{uid}/photo  the contact's photo, cached locally;
+                                ?size=N scales it to fit N×N pixels
+  POST   /inbound               receive a contact from a web form or another
+                                service (JSON, form fields or a vCard); it
+                                is tagged "inbound" and merged into any
+                                contact with the same email or phone
+  GET    /share/{token}         a contact shared with 'contacts share'
+  GET    /healthz               "ok" while the store is readable
+  GET    /openapi.json          an OpenAPI 3 description of this API
+
+Responses carry ETags. GET honors If-None-Match, and PUT and DELETE honor
+If-Match, so clients can poll cheaply and avoid overwriting each other's
+changes. Changes are pushed to the provider and logged with actor "api".
+
+The server listens on localhost by default; --listen unix:/path.sock uses a
+Unix socket readable only by you. To expose the API beyond this machine,
+require bearer tokens (--token, CONTACTS_SERVE_TOKEN, or "serve.tokens" in
+config.json) or client certificates (--client-ca), and enable TLS with
+--tls-cert and --tls-key. Share pages need no token; their links are
+signed.
+
+Each client may make --rate-limit requests a minute (0 disables the limit)
+and every request is logged to stderr unless --quiet is given. Behind a
+reverse proxy, list it with --trusted-proxy so clients are identified by
+X-Forwarded-For rather than the proxy's address. GET /healthz answers
+without a token or rate limit, for health checks.
+
+While the server runs, its address is written to serve.addr in the data
+directory so 'contacts share' can build links to it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		tokens := append(append([]string{}, cfg.Serve.Tokens...), serveTokens...)
+		if env := os.Getenv("CONTACTS_SERVE_TOKEN"); env != "" {
+			tokens = append(tokens, env)
+		}
+		certFile, keyFile, clientCA := firstNonEmpty(serveTLSCert, cfg.Serve.TLSCert), firstNonEmpty(serveTLSKey, cfg.Serve.TLSKey), firstNonEmpty(serveClientCA, cfg.Serve.ClientCA)
+		if (certFile == "") != (keyFile == "") {
+			return fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+		if clientCA != "" && certFile == "" {
+			return fmt.Errorf("--client-ca requires --tls-cert and --tls-key")
+		}
+
+		network, address := "tcp", serveListen
+		if path, ok := strings.CutPrefix(serveListen, "unix:"); ok {
+			network, address = "unix", path
+		} else if len(tokens) == 0 && clientCA == "" && !isLoopback(address) && !serveAllowUnauth {
+			return fmt.Errorf("refusing to serve %s without authentication: set a --token or --client-ca, or pass --allow-unauthenticated", address)
+		}
+
+		cm, err := getManager(contacts.WithActor(contacts.ActorAPI))
+		if err != nil {
+			return err
+		}
+		if network == "unix" {
+			// A socket left behind by a server that did not exit cleanly.
+			os.Remove(address)
+		}
+		ln, err := net.Listen(network, address)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", serveListen, err)
+		}
+		if network == "unix" {
+			defer os.Remove(address)
+			if err := os.Chmod(address, 0o600); err != nil {
+				return fmt.Errorf("failed to restrict socket: %w", err)
+			}
+		}
+
+		opts := []server.ServerOption{server.WithTokens(tokens...)}
+		rateLimit := serveRateLimit
+		if !cmd.Flags().Changed("rate-limit") && cfg.Serve.RateLimit != 0 {
+			rateLimit = cfg.Serve.RateLimit
+		}
+		opts = append(opts, server.WithRateLimit(rateLimit))
+		proxies, err := parsePrefixes(append(append([]string{}, cfg.Serve.TrustedProxies...), serveProxies...))
+		if err != nil {
+			return err
+		}
+		opts = append(opts, server.WithTrustedProxies(proxies...))
+		if !quietFlag {
+			opts = append(opts, server.WithRequestLog(os.Stderr))
+		}
+
+		srv := &http.Server{Handler: server.NewServer(cm, opts...)}
+		scheme := "http"
+		if certFile != "" {
+			scheme = "https"
+			srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			if clientCA != "" {
+				pem, err := os.ReadFile(clientCA)
+				if err != nil {
+					return fmt.Errorf("failed to read client CA: %w", err)
+				}
+				pool := x509.NewCertPool()
+				if !pool.AppendCertsFromPEM(pem) {
+					return fmt.Errorf("no certificates found in %s", clientCA)
+				}
+				srv.TLSConfig.ClientCAs = pool
+				srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+
+		base := serveURL(scheme, ln.Addr())
+		addrFile := filepath.Join(cfg.Dir, "serve.addr")
+		if err := os.WriteFile(addrFile, []byte(base), 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record server address: %v\n", err)
+		}
+		defer os.Remove(addrFile)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			srv.Shutdown(context.Background())
+		}()
+		infof("Serving contacts on %s\n", base)
+		if certFile != "" {
+			err = srv.ServeTLS(ln, certFile, keyFile)
+		} else {
+			err = srv.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server error: %w", err)
+		}
+		return nil
+	},
+}
+
+// serveURL is the base URL clients reach a listener at. Unix sockets are
+// given as unix:/path.
+func serveURL(scheme string, addr net.Addr) string {
+	if addr.Network() == "unix" {
+		return "unix:" + addr.String()
+	}
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return scheme + "://" + addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+// isLoopback reports whether a listen address only accepts connections
+// from this machine.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parsePrefixes parses IP addresses and CIDR ranges.
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, v := range values {
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy range %q: %w", v, err)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address %q: %w", v, err)
+		}
+		out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return out, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "localhost:8080", "address to listen on, or unix:/path.sock for a Unix socket")
+	serveCmd.Flags().StringArrayVar(&serveTokens, "token", nil, "bearer token accepted by the API; repeatable")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file (PEM)")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file (PEM)")
+	serveCmd.Flags().StringVar(&serveClientCA, "client-ca", "", "require client certificates signed by these CAs (PEM)")
+	serveCmd.Flags().BoolVar(&serveAllowUnauth, "allow-unauthenticated", false, "allow serving a non-loopback address without a token or client CA")
+	serveCmd.Flags().IntVar(&serveRateLimit, "rate-limit", 600, "requests a minute allowed per client; 0 disables the limit")
+	serveCmd.Flags().StringArrayVar(&serveProxies, "trusted-proxy", nil, "address or CIDR range of a reverse proxy whose X-Forwarded-For is trusted; repeatable")
+	rootCmd.AddCommand(serveCmd)
+}
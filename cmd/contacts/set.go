@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var setCmd = &cobra.Command{
+	Use:   "set <contact> <field>=<value>...",
+	Short: "set fields of a contact without opening the editor",
+	Long: `Set fields of a contact without opening the editor. A field is a vCard
+property or an alias such as pronouns, gender, title or note. Its values are
+replaced by the one given, and an empty value removes the field:
+
+  contacts set "Sam Rivera" pronouns=they/them gender="O;non-binary"
+  contacts set "Sam Rivera" title=`,
+	Args: cobra.MinimumNArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return []string{"pronouns=", "gender=", "title=", "nickname=", "note="}, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+		}
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		for _, arg := range args[1:] {
+			field, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("invalid assignment %q: expected field=value", arg)
+			}
+			if err := contacts.SetField(card, field, value); err != nil {
+				return err
+			}
+		}
+		if err := enforcePolicy(card); err != nil {
+			return err
+		}
+		if err := cm.WriteContact(card); err != nil {
+			return err
+		}
+		infof("Updated %s.\n", contacts.CardFullName(card))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setCmd)
+}
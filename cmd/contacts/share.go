@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareTTL     time.Duration
+	shareBaseURL string
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share <contact>",
+	Short: "print an expiring link to a contact's page on the running server",
+	Long: `Print a signed link to a page showing the contact, with a button to add
+them to the visitor's address book. The link stops working after --ttl.
+
+Links point at the server started with 'contacts serve'. Pass --base-url
+to link through a tunnel or reverse proxy instead.`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		base := shareBaseURL
+		if base == "" {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(filepath.Join(cfg.Dir, "serve.addr"))
+			if err != nil {
+				return fmt.Errorf("no server is running: start one with 'contacts serve' or pass --base-url")
+			}
+			base = strings.TrimSpace(string(data))
+			if strings.HasPrefix(base, "unix:") {
+				return fmt.Errorf("the server listens on a Unix socket; pass the public --base-url it is reachable at")
+			}
+		}
+		cm, err := getManagerQuiet()
+		if err != nil {
+			return err
+		}
+		card, err := cm.ResolveContact(args[0])
+		if err != nil {
+			return err
+		}
+		expires := time.Now().Add(shareTTL)
+		token, err := cm.ShareToken(contacts.CardUID(card), expires)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s/share/%s\n", strings.TrimSuffix(base, "/"), token)
+		infof("Link to %s expires %s.\n", contacts.CardFullName(card), expires.Format("2006-01-02 15:04"))
+		return nil
+	},
+}
+
+func init() {
+	shareCmd.Flags().DurationVar(&shareTTL, "ttl", time.Hour, "how long the link works")
+	shareCmd.Flags().StringVar(&shareBaseURL, "base-url", "", "public base URL of the server (default: the running 'contacts serve')")
+	rootCmd.AddCommand(shareCmd)
+}
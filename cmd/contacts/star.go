@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/arjungandhi/contacts"
+	"github.com/spf13/cobra"
+)
+
+var starCmd = &cobra.Command{
+	Use:   "star <contact>",
+	Short: "mark a contact as a favorite, listed first in completions",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setStarred(args[0], true)
+	},
+}
+
+var unstarCmd = &cobra.Command{
+	Use:   "unstar <contact>",
+	Short: "remove a contact from favorites",
+	Args:  cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return contactCompletions(toComplete), contactCompDirective
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setStarred(args[0], false)
+	},
+}
+
+func setStarred(query string, starred bool) error {
+	cm, err := getManager()
+	if err != nil {
+		return err
+	}
+	card, err := cm.ResolveContact(query)
+	if err != nil {
+		return err
+	}
+	contacts.SetStarred(card, starred)
+	if err := cm.WriteContact(card); err != nil {
+		return err
+	}
+	if starred {
+		infof("Starred %s.\n", contacts.CardFullName(card))
+	} else {
+		infof("Unstarred %s.\n", contacts.CardFullName(card))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(starCmd, unstarCmd)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/charmbracelet/huh"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	verifyAccept bool
+	verifyRepull bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "detect contact files modified or corrupted outside the tool",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cm, err := getManager()
+		if err != nil {
+			return err
+		}
+		issues, err := cm.Verify()
+		if err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			infof("All contacts verified.\n")
+			return nil
+		}
+
+		interactive := !verifyAccept && !verifyRepull && term.IsTerminal(int(os.Stdin.Fd()))
+		var repull []string
+		unresolved := 0
+		for _, issue := range issues {
+			fmt.Fprintf(os.Stderr, "%s: %s", issue.UID, issue.Status)
+			if issue.Err != nil {
+				fmt.Fprintf(os.Stderr, " (%v)", issue.Err)
+			}
+			fmt.Fprintln(os.Stderr)
+
+			action := "skip"
+			switch {
+			case verifyRepull && issue.Status != contacts.VerifyUntracked:
+				action = "repull"
+			case verifyAccept && issue.Status != contacts.VerifyCorrupt:
+				action = "accept"
+			case interactive:
+				options := []huh.Option[string]{huh.NewOption("Re-pull from provider", "repull")}
+				if issue.Status != contacts.VerifyCorrupt {
+					options = append(options, huh.NewOption("Accept as local change", "accept"))
+				}
+				if issue.Status == contacts.VerifyModified {
+					options = append(options, huh.NewOption("Merge with provider version field by field", "merge"))
+				}
+				options = append(options, huh.NewOption("Skip", "skip"))
+				if err := huh.NewSelect[string]().
+					Title(fmt.Sprintf("%s is %s", issue.UID, issue.Status)).
+					Options(options...).
+					Value(&action).
+					Run(); err != nil {
+					return err
+				}
+			}
+
+			switch action {
+			case "accept":
+				if err := cm.AcceptContact(issue.UID); err != nil {
+					return err
+				}
+			case "repull":
+				repull = append(repull, issue.UID)
+			case "merge":
+				if err := mergeWithRemote(cm, issue.UID); err != nil {
+					return err
+				}
+			default:
+				unresolved++
+			}
+		}
+		if len(repull) > 0 {
+			if err := cm.RepullContacts(repull...); err != nil {
+				return err
+			}
+		}
+		if unresolved > 0 {
+			return fmt.Errorf("%d contacts failed verification", unresolved)
+		}
+		infof("All issues resolved.\n")
+		return nil
+	},
+}
+
+// mergeWithRemote resolves an external edit by merging the local file with
+// the provider's version field by field and saving the result to both.
+func mergeWithRemote(cm *contacts.ContactManager, uid string) error {
+	local, err := cm.GetContact(uid)
+	if err != nil {
+		return err
+	}
+	remote, err := cm.FetchRemoteContact(uid)
+	if err != nil {
+		return err
+	}
+	if remote == nil {
+		return fmt.Errorf("%w: %s is not at the provider", contacts.ErrNotFound, uid)
+	}
+	merged, err := chooseFields(local, remote, "local", "provider")
+	if err != nil {
+		return err
+	}
+	return cm.WriteContact(merged)
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyAccept, "accept", false, "accept all external edits as local changes")
+	verifyCmd.Flags().BoolVar(&verifyRepull, "repull", false, "re-pull all modified or corrupt contacts from the provider")
+
+	rootCmd.AddCommand(verifyCmd)
+}
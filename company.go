@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Department lists the people in one department of a company.
+type Department struct {
+	// Name is the department, from the ORG units after the company name
+	// joined with " / ". It is empty for people with no department.
+	Name     string
+	Contacts []vcard.Card
+}
+
+// AtCompany matches individuals whose ORG names company, ignoring case.
+func AtCompany(company string) Filter {
+	company = strings.TrimSpace(company)
+	return func(card vcard.Card) bool {
+		if card.Kind() != vcard.KindIndividual {
+			return false
+		}
+		for _, f := range card[vcard.FieldOrganization] {
+			org, _, _ := strings.Cut(f.Value, ";")
+			if strings.EqualFold(strings.TrimSpace(org), company) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// CardDepartment returns the department of card at company: the units
+// following the company name in its matching ORG, joined with " / ".
+func CardDepartment(card vcard.Card, company string) string {
+	for _, f := range card[vcard.FieldOrganization] {
+		parts := strings.Split(f.Value, ";")
+		if !strings.EqualFold(strings.TrimSpace(parts[0]), company) {
+			continue
+		}
+		var units []string
+		for _, p := range parts[1:] {
+			if p = strings.TrimSpace(p); p != "" {
+				units = append(units, p)
+			}
+		}
+		return strings.Join(units, " / ")
+	}
+	return ""
+}
+
+// CompanyDirectory lists the people at company (see AtCompany) by
+// department, sorted by department name with the unnamed department last.
+// Within a department people are sorted by title, untitled last, and then
+// name.
+func CompanyDirectory(cards []vcard.Card, company string) []Department {
+	company = strings.TrimSpace(company)
+	byName := map[string]*Department{}
+	var out []*Department
+	for _, card := range FilterCards(cards, AtCompany(company)) {
+		name := CardDepartment(card, company)
+		key := strings.ToLower(name)
+		dept, ok := byName[key]
+		if !ok {
+			dept = &Department{Name: name}
+			byName[key] = dept
+			out = append(out, dept)
+		}
+		dept.Contacts = append(dept.Contacts, card)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Name, out[j].Name
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		return strings.ToLower(a) < strings.ToLower(b)
+	})
+	depts := make([]Department, len(out))
+	for i, d := range out {
+		sort.SliceStable(d.Contacts, func(i, j int) bool {
+			ti := strings.ToLower(d.Contacts[i].Value(vcard.FieldTitle))
+			tj := strings.ToLower(d.Contacts[j].Value(vcard.FieldTitle))
+			if (ti == "") != (tj == "") {
+				return tj == ""
+			}
+			if ti != tj {
+				return ti < tj
+			}
+			return strings.ToLower(CardFullName(d.Contacts[i])) < strings.ToLower(CardFullName(d.Contacts[j]))
+		})
+		depts[i] = *d
+	}
+	return depts
+}
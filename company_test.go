@@ -0,0 +1,50 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestCompanyDirectory(t *testing.T) {
+	person := func(name, org, title string) vcard.Card {
+		card := NewCard(name)
+		card.SetValue(vcard.FieldOrganization, org)
+		if title != "" {
+			card.SetValue(vcard.FieldTitle, title)
+		}
+		return card
+	}
+	cards := []vcard.Card{
+		person("Zed", "Acme;Engineering;Platform", "Engineer"),
+		person("Amy", "ACME;Engineering;Platform", "Director"),
+		person("Bea", "acme", ""),
+		person("Cal", "Acme;Sales", "Account Executive"),
+		person("Dee", "Acme Labs;Research", "Scientist"),
+		NewOrgCard("Acme"),
+	}
+
+	depts := CompanyDirectory(cards, " acme ")
+	if len(depts) != 3 {
+		t.Fatalf("departments = %+v, want 3", depts)
+	}
+	want := []struct {
+		name   string
+		people []string
+	}{
+		{"Engineering / Platform", []string{"Amy", "Zed"}},
+		{"Sales", []string{"Cal"}},
+		{"", []string{"Bea"}},
+	}
+	for i, w := range want {
+		if depts[i].Name != w.name || len(depts[i].Contacts) != len(w.people) {
+			t.Errorf("departments[%d] = %q with %d people, want %q with %d", i, depts[i].Name, len(depts[i].Contacts), w.name, len(w.people))
+			continue
+		}
+		for j, name := range w.people {
+			if got := CardFullName(depts[i].Contacts[j]); got != name {
+				t.Errorf("departments[%d][%d] = %s, want %s", i, j, got, name)
+			}
+		}
+	}
+}
@@ -1,12 +1,120 @@
 package contacts
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
+// Providers that can be selected in Config.Provider.
+const (
+	ProviderGoogle    = "google"
+	ProviderMicrosoft = "microsoft"
+	ProviderExchange  = "exchange"
+	ProviderCardDAV   = "carddav"
+	ProviderNextcloud = "nextcloud"
+	ProviderICloud    = "icloud"
+	ProviderLDAP      = "ldap"
+	ProviderMacOS     = "macos"
+	ProviderProton    = "proton"
+	ProviderLocal     = "local"
+)
+
+// Config holds the data directory plus settings loaded from config.json in
+// that directory.
 type Config struct {
-	Dir string
+	Dir string `json:"-"`
+
+	// Provider is the remote contact backend set up by `contacts init`:
+	// ProviderGoogle (the default), ProviderMicrosoft, ProviderExchange for
+	// on-premises Exchange, ProviderCardDAV, ProviderNextcloud,
+	// ProviderICloud, ProviderLDAP for a read-only directory, ProviderMacOS
+	// for the Mac's Contacts app, ProviderProton, or ProviderLocal for no
+	// remote at all.
+	Provider string `json:"provider,omitempty"`
+
+	// SMTP configures outgoing mail for `contacts remind --email`.
+	SMTP SMTPConfig `json:"smtp,omitzero"`
+	// StaleAfterDays is how long a contact can go unmodified before the
+	// reminder digest lists it as stale. Zero uses the default of 365.
+	StaleAfterDays int `json:"stale_after_days,omitempty"`
+	// FileMode and DirMode are octal permissions (e.g. "0600") for the
+	// store's files and directories. Empty uses DefaultFileMode and
+	// DefaultDirMode.
+	FileMode string `json:"file_mode,omitempty"`
+	DirMode  string `json:"dir_mode,omitempty"`
+	// OAuthSuccessPage is an html/template file shown in the browser after
+	// authorization, replacing the built-in page.
+	OAuthSuccessPage string `json:"oauth_success_page,omitempty"`
+	// OAuthAutoClose makes the success page close its browser tab.
+	OAuthAutoClose bool `json:"oauth_auto_close,omitempty"`
+	// DecodeMode is "strict" to reject malformed contact files or
+	// "lenient" (the default) to repair them when read.
+	DecodeMode string `json:"decode_mode,omitempty"`
+	// Locale is a language tag such as "de-DE" that sets how dates are
+	// displayed and how names are sorted. Empty uses US English.
+	Locale string `json:"locale,omitempty"`
+	// Policy lists fields contacts must have. add, edit and import warn
+	// about or refuse contacts that break it, and lint reports them.
+	Policy FieldPolicy `json:"policy,omitzero"`
+	// MergeStrategy resolves sync conflicts by merging the local and
+	// remote versions: "newest-wins", "remote-wins" or "union". Empty
+	// keeps the local version and reports the conflict.
+	MergeStrategy string `json:"merge_strategy,omitempty"`
+	// FieldOwners names the source that wins each field, such as
+	// {"phone": "google", "title": "ldap"}, when linked contacts are
+	// shown merged and when sync conflicts are merged. Sources are
+	// provider names or "local".
+	FieldOwners map[string]string `json:"field_owners,omitempty"`
+	// UIDScheme is how locally created contacts get their UID: UIDRandom
+	// (the default) or UIDStable.
+	UIDScheme string `json:"uid_scheme,omitempty"`
+	// Templates pre-fill new contacts, selected with `contacts add
+	// --template <name>`.
+	Templates map[string]ContactTemplate `json:"templates,omitempty"`
+	// Routing tags contacts pulled in by sync or adds them to local groups
+	// when they match a rule.
+	Routing []RoutingRule `json:"routing,omitempty"`
+	// Photos sets how photos attached from files or uploaded to the
+	// provider are scaled and encoded.
+	Photos PhotoOptions `json:"photos,omitzero"`
+	// Serve configures `contacts serve`.
+	Serve ServeConfig `json:"serve,omitzero"`
+	// Webhooks are notified when contacts are created, updated or deleted
+	// and when a sync completes.
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+}
+
+// SMTPConfig holds the mail server settings used to send digests.
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// ServeConfig holds the HTTP server's authentication and TLS settings.
+// Flags given to `contacts serve` are used in addition.
+type ServeConfig struct {
+	// Tokens are accepted as bearer tokens for the API.
+	Tokens []string `json:"tokens,omitempty"`
+	// TLSCert and TLSKey are PEM files; with both set the server speaks
+	// HTTPS.
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
+	// ClientCA is a PEM bundle of CAs; when set, clients must present a
+	// certificate signed by one of them (mutual TLS).
+	ClientCA string `json:"client_ca,omitempty"`
+	// RateLimit is how many requests a minute each client may make. 0
+	// keeps the serve command's default and a negative value disables
+	// the limit.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For header identifies the client.
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
 }
 
 func NewConfig() *Config {
@@ -26,5 +134,123 @@ func defaultDir() string {
 }
 
 func (c *Config) EnsureDir() error {
-	return os.MkdirAll(c.Dir, 0755)
+	_, dirMode, err := c.Permissions()
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(c.Dir, dirMode)
+}
+
+// Permissions returns the configured file and directory modes.
+func (c *Config) Permissions() (fileMode, dirMode os.FileMode, err error) {
+	if fileMode, err = parseMode(c.FileMode, DefaultFileMode); err != nil {
+		return 0, 0, err
+	}
+	if dirMode, err = parseMode(c.DirMode, DefaultDirMode); err != nil {
+		return 0, 0, err
+	}
+	return fileMode, dirMode, nil
+}
+
+// ManagerOptions returns the ContactManager options implied by the config.
+func (c *Config) ManagerOptions() ([]ManagerOption, error) {
+	fileMode, dirMode, err := c.Permissions()
+	if err != nil {
+		return nil, err
+	}
+	opts := []ManagerOption{WithPermissions(fileMode, dirMode)}
+	if len(c.Webhooks) > 0 {
+		opts = append(opts, WithWebhooks(c.Webhooks...))
+	}
+	if c.MergeStrategy != "" {
+		s, err := ParseStrategy(c.MergeStrategy)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithMergeStrategy(s))
+	}
+	if len(c.FieldOwners) > 0 {
+		owners, err := ParseFieldOwners(c.FieldOwners)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithFieldOwners(owners))
+	}
+	if len(c.Routing) > 0 {
+		routes := make([]Route, 0, len(c.Routing))
+		for _, rule := range c.Routing {
+			route, err := rule.Compile()
+			if err != nil {
+				return nil, err
+			}
+			routes = append(routes, route)
+		}
+		opts = append(opts, WithRouting(routes...))
+	}
+	stable, err := parseUIDScheme(c.UIDScheme)
+	if err != nil {
+		return nil, err
+	}
+	if stable {
+		opts = append(opts, WithStableUIDs())
+	}
+	switch c.DecodeMode {
+	case "", "lenient":
+	case "strict":
+		opts = append(opts, WithDecodeMode(DecodeStrict))
+	default:
+		return nil, fmt.Errorf("invalid decode_mode %q: expected strict or lenient", c.DecodeMode)
+	}
+	return opts, nil
+}
+
+// Template returns the contact template with the given name.
+func (c *Config) Template(name string) (ContactTemplate, error) {
+	t, ok := c.Templates[name]
+	if !ok {
+		return ContactTemplate{}, fmt.Errorf("no template named %q in %s", name, c.Path())
+	}
+	return t, nil
+}
+
+// Path returns the location of the config file.
+func (c *Config) Path() string {
+	return filepath.Join(c.Dir, "config.json")
+}
+
+// Load reads settings from the config file. A missing file is not an error.
+func (c *Config) Load() error {
+	data, err := os.ReadFile(c.Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", c.Path(), err)
+	}
+	if err := c.Policy.Validate(); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", c.Path(), err)
+	}
+	if err := c.Photos.Validate(); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", c.Path(), err)
+	}
+	return nil
+}
+
+// Save writes the settings to the config file.
+func (c *Config) Save() error {
+	fileMode, _, err := c.Permissions()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(c.Path(), append(data, '\n'), fileMode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
 }
@@ -3,6 +3,7 @@ package contacts
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +39,53 @@ func TestConfig_EnsureDir(t *testing.T) {
 		t.Fatal("not a directory")
 	}
 }
+
+func TestConfig_Load(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{Dir: dir}
+
+	// Missing file is fine
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `{"smtp": {"host": "smtp.example.com", "port": 587, "from": "me@example.com", "to": ["me@example.com"]}, "stale_after_days": 90}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dir != dir {
+		t.Errorf("Dir changed to %s", cfg.Dir)
+	}
+	if cfg.SMTP.Host != "smtp.example.com" || cfg.SMTP.Port != 587 {
+		t.Errorf("SMTP: got %+v", cfg.SMTP)
+	}
+	if cfg.StaleAfterDays != 90 {
+		t.Errorf("StaleAfterDays: got %d", cfg.StaleAfterDays)
+	}
+}
+
+func TestConfig_Save(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{Dir: dir, Provider: ProviderLocal, StaleAfterDays: 30}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(cfg.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "smtp") {
+		t.Errorf("empty SMTP settings written: %s", data)
+	}
+
+	loaded := &Config{Dir: dir}
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Provider != ProviderLocal || loaded.StaleAfterDays != 30 {
+		t.Errorf("round trip lost settings: %+v", loaded)
+	}
+}
@@ -3,10 +3,12 @@ package contacts
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/emersion/go-vcard"
@@ -16,25 +18,95 @@ import (
 // ContactProvider abstracts a remote contact backend (e.g. Google).
 type ContactProvider interface {
 	FetchContacts() ([]vcard.Card, error)
+	// WriteContact updates the contact named by the card's ProviderID, or
+	// creates one if the card has none. When creating a contact the
+	// provider should set FieldProviderID to the ID it assigned, and may
+	// set the card's UID to it too; the local copy is then moved to that
+	// UID.
 	WriteContact(vcard.Card) error
-	DeleteContact(uid string) error
+	// DeleteContact deletes the contact with the given provider ID.
+	DeleteContact(id string) error
+}
+
+// FieldProviderID holds the provider's ID for a contact it has stored.
+// Whether a contact exists at the provider is decided by this field alone,
+// never by the shape of its UID.
+const FieldProviderID = "X-PROVIDER-ID"
+
+// ProviderID returns the provider's ID for a card, or "" if the contact
+// exists only locally.
+func ProviderID(card vcard.Card) string {
+	return card.Value(FieldProviderID)
+}
+
+// IncrementalProvider is a ContactProvider that can return only what
+// changed since the last sync. SyncContacts uses it when available, so a
+// sync with no remote changes writes nothing.
+type IncrementalProvider interface {
+	ContactProvider
+	// FetchChanges returns cards created or updated remotely and the UIDs
+	// of cards deleted remotely since the last committed sync.
+	FetchChanges() (changed []vcard.Card, deleted []string, err error)
+	// CommitSync records that the last FetchChanges was applied locally.
+	CommitSync() error
 }
 
 // ContactManager handles local storage and provider syncing.
 type ContactManager struct {
 	provider    ContactProvider
+	dir         string
 	storagePath string
+	actor       string
+	fileMode    os.FileMode
+	dirMode     os.FileMode
+	decodeMode  DecodeMode
+	webhooks    []Webhook
+	webhookErr  func(error)
+	mergeWith   Strategy
+	stableUIDs  bool
+	routes      []Route
+	idMaps      IDMapStore
+	fieldOwners FieldOwners
+	// mu serializes changes to the store; see lockStore.
+	mu sync.Mutex
 }
 
-func NewContactManager(provider ContactProvider, dir string) (*ContactManager, error) {
-	contactsDir := filepath.Join(dir, "people")
-	if err := os.MkdirAll(contactsDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create contacts directory: %w", err)
+// ManagerOption configures optional ContactManager behaviour.
+type ManagerOption func(*ContactManager)
+
+// WithActor sets who the audit log credits for changes made through the
+// manager (ActorCLI by default). Changes pulled by SyncContacts are always
+// recorded as ActorSync.
+func WithActor(actor string) ManagerOption {
+	return func(cm *ContactManager) {
+		cm.actor = actor
 	}
-	return &ContactManager{
+}
+
+func NewContactManager(provider ContactProvider, dir string, opts ...ManagerOption) (*ContactManager, error) {
+	cm := &ContactManager{
 		provider:    provider,
-		storagePath: contactsDir,
-	}, nil
+		dir:         dir,
+		storagePath: filepath.Join(dir, "people"),
+		actor:       ActorCLI,
+		fileMode:    DefaultFileMode,
+		dirMode:     DefaultDirMode,
+	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	if err := os.MkdirAll(cm.storagePath, cm.dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create contacts directory: %w", err)
+	}
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := cm.migrate(); err != nil {
+		return nil, err
+	}
+	return cm, nil
 }
 
 // --- Helper functions for vcard.Card ---
@@ -52,12 +124,16 @@ func CardFullName(card vcard.Card) string {
 	return card.Value(vcard.FieldFormattedName)
 }
 
-// PrimaryPhone returns the first mobile/cell phone, or the first phone if none.
+// PrimaryPhone returns the preferred phone (see IsPreferred), else the
+// first mobile/cell phone, or the first phone if none.
 func PrimaryPhone(card vcard.Card) string {
 	fields := card[vcard.FieldTelephone]
 	if len(fields) == 0 {
 		return ""
 	}
+	if f := preferredField(card, vcard.FieldTelephone); f != nil {
+		return f.Value
+	}
 	for _, f := range fields {
 		t := strings.ToLower(f.Params.Get(vcard.ParamType))
 		if t == "cell" || t == "mobile" {
@@ -67,12 +143,16 @@ func PrimaryPhone(card vcard.Card) string {
 	return fields[0].Value
 }
 
-// PrimaryEmail returns the first email address.
+// PrimaryEmail returns the preferred email address (see IsPreferred), or
+// the first.
 func PrimaryEmail(card vcard.Card) string {
 	fields := card[vcard.FieldEmail]
 	if len(fields) == 0 {
 		return ""
 	}
+	if f := preferredField(card, vcard.FieldEmail); f != nil {
+		return f.Value
+	}
 	return fields[0].Value
 }
 
@@ -85,8 +165,14 @@ func NewCard(fullName string) vcard.Card {
 	return card
 }
 
-// FormatCard returns a human-readable summary of a vcard.Card.
+// FormatCard returns a human-readable summary of a vcard.Card with dates
+// in DefaultLocale.
 func FormatCard(card vcard.Card) string {
+	return FormatCardLocale(card, DefaultLocale)
+}
+
+// FormatCardLocale is FormatCard with dates rendered for loc.
+func FormatCardLocale(card vcard.Card, loc Locale) string {
 	var b strings.Builder
 
 	// Name header
@@ -102,6 +188,11 @@ func FormatCard(card vcard.Card) string {
 		b.WriteString(fmt.Sprintf("  Nickname:  %s\n", nicks[0].Value))
 	}
 
+	// Former names
+	if former := FormerNames(card); len(former) > 0 {
+		b.WriteString(fmt.Sprintf("  Formerly:  %s\n", strings.Join(former, ", ")))
+	}
+
 	// Organization + Title
 	if org := card.Value(vcard.FieldOrganization); org != "" {
 		display := strings.ReplaceAll(org, ";", ", ")
@@ -131,24 +222,53 @@ func FormatCard(card vcard.Card) string {
 	// Addresses
 	for _, f := range card[vcard.FieldAddress] {
 		label := formatTypeLabel(f, "address")
-		addr := formatAddress(f.Value)
+		addr := FormatAddress(f.Value)
 		if addr != "" {
 			b.WriteString(fmt.Sprintf("  Address:   %s (%s)\n", addr, label))
 		}
 	}
 
+	// Time zone, with the contact's current local time and whether it is a
+	// good time to call
+	if zone, derived, ok := ContactTimeZone(card); ok {
+		now := time.Now().In(zone)
+		_, hint := CallHint(now)
+		if derived {
+			b.WriteString(fmt.Sprintf("  Time zone: %s (from address; now %s %s, %s)\n", zone, loc.FormatWeekday(now), now.Format("15:04"), hint))
+		} else {
+			b.WriteString(fmt.Sprintf("  Time zone: %s (now %s %s, %s)\n", card.Value(vcard.FieldTimezone), loc.FormatWeekday(now), now.Format("15:04"), hint))
+		}
+	} else if tz := card.Value(vcard.FieldTimezone); tz != "" {
+		b.WriteString(fmt.Sprintf("  Time zone: %s\n", tz))
+	}
+
+	// Geo position
+	if lat, lon, ok := CardGeo(card); ok {
+		b.WriteString(fmt.Sprintf("  Geo:       %g, %g\n", lat, lon))
+	}
+
 	// Birthday
 	if bday := card.Value(vcard.FieldBirthday); bday != "" {
-		b.WriteString(fmt.Sprintf("  Birthday:  %s\n", formatDate(bday)))
+		b.WriteString(fmt.Sprintf("  Birthday:  %s\n", formatDate(bday, loc)))
 	}
 
 	// Anniversary
 	if ann := card.Value(vcard.FieldAnniversary); ann != "" {
-		b.WriteString(fmt.Sprintf("  Anniv:     %s\n", formatDate(ann)))
+		b.WriteString(fmt.Sprintf("  Anniv:     %s\n", formatDate(ann, loc)))
+	}
+
+	// Social profiles, with the URLs they came from left out below
+	profiles := map[string]bool{}
+	for _, p := range SocialProfiles(card) {
+		profiles[socialKey(p.URL)] = true
+		b.WriteString(fmt.Sprintf("  %s: %s%s (%s)\n", p.Service.Name, strings.Repeat(" ", 9-len(p.Service.Name)), p.URL, p.User))
 	}
 
 	// URLs
 	for _, f := range card[vcard.FieldURL] {
+		if profiles[socialKey(f.Value)] {
+			continue
+		}
 		label := formatTypeLabel(f, "url")
 		b.WriteString(fmt.Sprintf("  URL:       %s (%s)\n", f.Value, label))
 	}
@@ -164,9 +284,12 @@ func FormatCard(card vcard.Card) string {
 		b.WriteString(fmt.Sprintf("  Related:   %s (%s)\n", f.Value, label))
 	}
 
-	// Gender
+	// Gender and pronouns
 	if g := card.Value(vcard.FieldGender); g != "" {
-		b.WriteString(fmt.Sprintf("  Gender:    %s\n", g))
+		b.WriteString(fmt.Sprintf("  Gender:    %s\n", FormatGender(g)))
+	}
+	if p := card.Value(FieldPronouns); p != "" {
+		b.WriteString(fmt.Sprintf("  Pronouns:  %s\n", p))
 	}
 
 	// Notes
@@ -190,6 +313,23 @@ func FormatCard(card vcard.Card) string {
 		}
 	}
 
+	// Groups
+	if groups := CardGroupLabels(card); len(groups) > 0 {
+		b.WriteString(fmt.Sprintf("  Groups:    %s\n", strings.Join(groups, ", ")))
+	}
+
+	// Flags from Google's system groups
+	var flags []string
+	if IsStarred(card) {
+		flags = append(flags, "starred")
+	}
+	if IsBlocked(card) {
+		flags = append(flags, "blocked")
+	}
+	if len(flags) > 0 {
+		b.WriteString(fmt.Sprintf("  Flags:     %s\n", strings.Join(flags, ", ")))
+	}
+
 	// UID footer
 	if uid := CardUID(card); uid != "" {
 		b.WriteString(fmt.Sprintf("  UID:       %s\n", uid))
@@ -245,7 +385,7 @@ func CardToMap(card vcard.Card) map[string]any {
 	if addrs := card[vcard.FieldAddress]; len(addrs) > 0 {
 		var list []map[string]string
 		for _, f := range addrs {
-			addr := formatAddress(f.Value)
+			addr := FormatAddress(f.Value)
 			if addr == "" {
 				continue
 			}
@@ -261,10 +401,10 @@ func CardToMap(card vcard.Card) map[string]any {
 	}
 
 	if bday := card.Value(vcard.FieldBirthday); bday != "" {
-		m["birthday"] = formatDate(bday)
+		m["birthday"] = formatDate(bday, DefaultLocale)
 	}
 	if ann := card.Value(vcard.FieldAnniversary); ann != "" {
-		m["anniversary"] = formatDate(ann)
+		m["anniversary"] = formatDate(ann, DefaultLocale)
 	}
 
 	if urls := card[vcard.FieldURL]; len(urls) > 0 {
@@ -279,6 +419,14 @@ func CardToMap(card vcard.Card) map[string]any {
 		m["urls"] = list
 	}
 
+	if profiles := SocialProfiles(card); len(profiles) > 0 {
+		var list []map[string]string
+		for _, p := range profiles {
+			list = append(list, map[string]string{"service": p.Service.Type, "user": p.User, "value": p.URL})
+		}
+		m["social_profiles"] = list
+	}
+
 	if ims := card[vcard.FieldIMPP]; len(ims) > 0 {
 		var list []string
 		for _, f := range ims {
@@ -299,9 +447,23 @@ func CardToMap(card vcard.Card) map[string]any {
 		m["related"] = list
 	}
 
+	if tz := card.Value(vcard.FieldTimezone); tz != "" {
+		m["timezone"] = tz
+	}
+
+	if lat, lon, ok := CardGeo(card); ok {
+		m["geo"] = map[string]float64{"lat": lat, "lon": lon}
+	}
+
 	if g := card.Value(vcard.FieldGender); g != "" {
 		m["gender"] = g
 	}
+	if p := card.Value(FieldPronouns); p != "" {
+		m["pronouns"] = p
+	}
+	if former := FormerNames(card); len(former) > 0 {
+		m["former_names"] = former
+	}
 
 	if notes := card[vcard.FieldNote]; len(notes) > 0 {
 		var list []string
@@ -311,6 +473,16 @@ func CardToMap(card vcard.Card) map[string]any {
 		m["notes"] = list
 	}
 
+	if groups := CardGroupLabels(card); len(groups) > 0 {
+		m["groups"] = groups
+	}
+	if IsStarred(card) {
+		m["starred"] = true
+	}
+	if IsBlocked(card) {
+		m["blocked"] = true
+	}
+
 	xFields := []struct {
 		key   string
 		label string
@@ -342,13 +514,18 @@ func FormatCardJSON(card vcard.Card) (string, error) {
 	return string(data), nil
 }
 
-// FormatCardsJSON returns a JSON array representation of multiple vcard.Cards.
-func FormatCardsJSON(cards []vcard.Card) (string, error) {
-	var list []map[string]any
+// CardsToMaps converts cards with CardToMap, for JSON output.
+func CardsToMaps(cards []vcard.Card) []map[string]any {
+	list := make([]map[string]any, 0, len(cards))
 	for _, card := range cards {
 		list = append(list, CardToMap(card))
 	}
-	data, err := json.MarshalIndent(list, "", "  ")
+	return list
+}
+
+// FormatCardsJSON returns a JSON array representation of multiple vcard.Cards.
+func FormatCardsJSON(cards []vcard.Card) (string, error) {
+	data, err := json.MarshalIndent(CardsToMaps(cards), "", "  ")
 	if err != nil {
 		return "", err
 	}
@@ -362,7 +539,9 @@ func formatTypeLabel(f *vcard.Field, fallback string) string {
 	return fallback
 }
 
-func formatAddress(adrValue string) string {
+// FormatAddress renders an ADR value on one line: street, city, region,
+// postal code and country, separated by commas.
+func FormatAddress(adrValue string) string {
 	// ADR: PO Box;Extended;Street;City;Region;PostalCode;Country
 	parts := strings.Split(adrValue, ";")
 	var pieces []string
@@ -389,20 +568,20 @@ func formatAddress(adrValue string) string {
 	return strings.Join(pieces, ", ")
 }
 
-func formatDate(s string) string {
+func formatDate(s string, loc Locale) string {
 	// Try YYYYMMDD
 	s = strings.ReplaceAll(s, "-", "")
 	if len(s) == 8 {
 		t, err := time.Parse("20060102", s)
 		if err == nil {
-			return t.Format("Jan 2, 2006")
+			return loc.FormatDate(t)
 		}
 	}
 	// Try --MMDD (no year)
 	if len(s) == 4 {
 		t, err := time.Parse("0102", s)
 		if err == nil {
-			return t.Format("Jan 2")
+			return loc.FormatMonthDay(t)
 		}
 	}
 	return s
@@ -418,19 +597,31 @@ func EncodeCard(card vcard.Card) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// DecodeCard deserializes VCF bytes into a vcard.Card.
+// DecodeCard deserializes VCF bytes into a vcard.Card, repairing what it
+// can (see DecodeCardStrict for a validating decoder).
 func DecodeCard(data []byte) (vcard.Card, error) {
 	dec := vcard.NewDecoder(bytes.NewReader(data))
 	card, err := dec.Decode()
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode vcard: %w", err)
 	}
+	repairCard(card)
 	return card, nil
 }
 
 // --- ContactManager methods ---
 
+// CheckStore returns an error if the store's contact directory cannot be
+// read, for health checks.
+func (cm *ContactManager) CheckStore() error {
+	_, err := os.Stat(cm.storagePath)
+	return err
+}
+
 func (cm *ContactManager) GetContact(uid string) (vcard.Card, error) {
+	if !uidInStore(uid) {
+		return nil, nil
+	}
 	filePath := filepath.Join(cm.storagePath, uid+".vcf")
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -439,14 +630,36 @@ func (cm *ContactManager) GetContact(uid string) (vcard.Card, error) {
 		}
 		return nil, fmt.Errorf("failed to read contact file: %w", err)
 	}
-	card, err := DecodeCard(data)
+	card, err := cm.decodeCard(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse contact file: %w", err)
 	}
 	return card, nil
 }
 
-// FindContactByName searches contacts by name (case-insensitive exact match).
+// FindByEmail returns the contacts with an email address equal to address,
+// ignoring case.
+func (cm *ContactManager) FindByEmail(address string) ([]vcard.Card, error) {
+	cards, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	address = strings.TrimSpace(address)
+	var matches []vcard.Card
+	for _, card := range cards {
+		for _, f := range card[vcard.FieldEmail] {
+			if strings.EqualFold(strings.TrimSpace(f.Value), address) {
+				matches = append(matches, card)
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// FindContactByName searches contacts by name (case-insensitive exact
+// match). Current names are matched first, then former names (see
+// FormerNames), so someone still known by a previous name is found.
 func (cm *ContactManager) FindContactByName(name string) (vcard.Card, error) {
 	cards, err := cm.ListContacts()
 	if err != nil {
@@ -457,10 +670,16 @@ func (cm *ContactManager) FindContactByName(name string) (vcard.Card, error) {
 			return card, nil
 		}
 	}
+	for _, card := range cards {
+		if hasFormerName(card, name) {
+			return card, nil
+		}
+	}
 	return nil, nil
 }
 
-// ResolveContact looks up a contact by UID first, then falls back to name match.
+// ResolveContact looks up a contact by UID first, then falls back to name
+// match. It returns ErrNotFound if neither matches.
 func (cm *ContactManager) ResolveContact(query string) (vcard.Card, error) {
 	card, err := cm.GetContact(query)
 	if err != nil {
@@ -469,58 +688,176 @@ func (cm *ContactManager) ResolveContact(query string) (vcard.Card, error) {
 	if card != nil {
 		return card, nil
 	}
-	return cm.FindContactByName(query)
+	card, err = cm.FindContactByName(query)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, query)
+	}
+	return card, nil
 }
 
+// listWorkers bounds how many contact files ListContacts reads at once.
+const listWorkers = 16
+
+// ListContacts reads and decodes every contact file, in directory order.
+// Files are loaded concurrently, which matters most on slow disks.
 func (cm *ContactManager) ListContacts() ([]vcard.Card, error) {
+	names, err := cm.contactFileNames()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	cards := make([]vcard.Card, len(names))
+	errs := make([]error, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(listWorkers, len(names)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				cards[i], errs[i] = cm.loadContactFile(names[i])
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cards, nil
+}
+
+// contactFileNames returns the names of the contact files, in directory
+// order.
+func (cm *ContactManager) contactFileNames() ([]string, error) {
 	entries, err := os.ReadDir(cm.storagePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read contacts directory: %w", err)
 	}
-	var cards []vcard.Card
+	names := make([]string, 0, len(entries))
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".vcf") {
 			continue
 		}
-		filePath := filepath.Join(cm.storagePath, entry.Name())
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read contact file %s: %w", entry.Name(), err)
-		}
-		card, err := DecodeCard(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse contact file %s: %w", entry.Name(), err)
-		}
-		cards = append(cards, card)
+		names = append(names, entry.Name())
 	}
-	return cards, nil
+	return names, nil
+}
+
+func (cm *ContactManager) loadContactFile(name string) (vcard.Card, error) {
+	data, err := os.ReadFile(filepath.Join(cm.storagePath, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contact file %s: %w", name, err)
+	}
+	card, err := cm.decodeCard(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse contact file %s: %w", name, err)
+	}
+	return card, nil
 }
 
 func (cm *ContactManager) WriteContact(card vcard.Card) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return cm.writeContact(card)
+}
+
+// writeContact is WriteContact for callers holding the store lock.
+func (cm *ContactManager) writeContact(card vcard.Card) error {
+	if err := cm.checkWritable(card); err != nil {
+		return err
+	}
 	if CardUID(card) == "" {
 		card.SetValue(vcard.FieldUID, uuid.New().String())
 	}
+	normalizeUID(card)
+	if err := ValidateCard(card); err != nil {
+		return err
+	}
+	if cm.stableUIDs {
+		if err := cm.assignStableUID(card); err != nil {
+			return err
+		}
+	}
 	card.SetValue(vcard.FieldRevision, time.Now().UTC().Format("20060102T150405Z"))
+	old, _ := cm.GetContact(CardUID(card))
+	restampEdits(old, card)
 
-	data, err := EncodeCard(card)
+	index, err := cm.loadIndex()
 	if err != nil {
-		return fmt.Errorf("failed to marshal contact: %w", err)
+		return err
 	}
-	filePath := filepath.Join(cm.storagePath, CardUID(card)+".vcf")
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write contact file: %w", err)
+	if err := cm.writeCardFile(card, index, cm.actor); err != nil {
+		return err
 	}
-	if cm.provider != nil {
+	if err := cm.saveIndex(index); err != nil {
+		return err
+	}
+	if cm.provider != nil && !cm.readOnly() && card.Kind() != vcard.KindGroup {
+		uid := CardUID(card)
 		if err := cm.provider.WriteContact(card); err != nil {
 			return fmt.Errorf("failed to write contact to provider: %w", err)
 		}
+		return cm.adoptProviderID(card, uid)
 	}
 	return nil
 }
 
+// adoptProviderID stores what a provider's WriteContact changed on a card
+// that had UID uid: the provider ID of a new contact, which defaults to
+// its UID, any UID the provider assigned and the provider's own fields,
+// such as the etag of the version it now has.
+func (cm *ContactManager) adoptProviderID(card vcard.Card, uid string) error {
+	if ProviderID(card) == "" {
+		card.SetValue(FieldProviderID, CardUID(card))
+	}
+	name := cm.providerName()
+	if err := cm.updateIDMap(func(ids IDMap) { ids.Set(name, ProviderID(card), uid) }); err != nil {
+		return err
+	}
+	if CardUID(card) != uid {
+		return cm.moveContact(card, uid)
+	}
+	data, err := EncodeCard(card)
+	if err != nil {
+		return fmt.Errorf("failed to marshal contact: %w", err)
+	}
+	if stored, err := os.ReadFile(filepath.Join(cm.storagePath, uid+".vcf")); err == nil && bytes.Equal(stored, data) {
+		return nil
+	}
+	index, err := cm.loadIndex()
+	if err != nil {
+		return err
+	}
+	if err := cm.writeCardFile(card, index, cm.actor); err != nil {
+		return err
+	}
+	return cm.saveIndex(index)
+}
+
 func (cm *ContactManager) WriteContacts(cards []vcard.Card) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	for _, card := range cards {
-		if err := cm.WriteContact(card); err != nil {
+		if err := cm.writeContact(card); err != nil {
 			return err
 		}
 	}
@@ -528,50 +865,466 @@ func (cm *ContactManager) WriteContacts(cards []vcard.Card) error {
 }
 
 func (cm *ContactManager) DeleteContact(uid string) error {
-	isProviderContact := !strings.Contains(uid, "-")
-	if isProviderContact && cm.provider != nil {
-		if err := cm.provider.DeleteContact(uid); err != nil {
-			return fmt.Errorf("failed to delete contact from provider: %w", err)
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return cm.deleteContact(uid)
+}
+
+// ContentETag is a strong ETag for data: its hash, quoted.
+func ContentETag(data []byte) string {
+	return `"` + hashContent(data) + `"`
+}
+
+// CardETag is a strong ETag for the stored version of a card: the hash of
+// its encoding, which changes with every write since REV does.
+func CardETag(card vcard.Card) (string, error) {
+	data, err := EncodeCard(card)
+	if err != nil {
+		return "", err
+	}
+	return ContentETag(data), nil
+}
+
+// ETagMatches reports whether an If-Match or If-None-Match header value
+// lists etag or is "*".
+func ETagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Preconditions make a write conditional on the stored version of a
+// contact, as HTTP's If-Match and If-None-Match do. Each is a list of
+// ETags or "*"; empty conditions always hold.
+type Preconditions struct {
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// check fails with ErrPreconditionFailed unless the stored version of the
+// contact, nil if there is none, meets the conditions.
+func (c Preconditions) check(existing vcard.Card) error {
+	var etag string
+	if existing != nil {
+		var err error
+		if etag, err = CardETag(existing); err != nil {
+			return err
+		}
+	}
+	if c.IfMatch != "" && (etag == "" || !ETagMatches(c.IfMatch, etag)) ||
+		c.IfNoneMatch != "" && etag != "" && ETagMatches(c.IfNoneMatch, etag) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// WriteContactIf is WriteContact for a contact whose stored version must
+// meet cond. The check and the write happen under the store lock, so no
+// other write can come between them. It reports whether the contact is
+// new.
+func (cm *ContactManager) WriteContactIf(card vcard.Card, cond Preconditions) (created bool, err error) {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	existing, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		return false, err
+	}
+	if err := cond.check(existing); err != nil {
+		return false, err
+	}
+	return existing == nil, cm.writeContact(card)
+}
+
+// DeleteContactIf is DeleteContact for a contact whose stored version
+// must meet cond, checked under the store lock like WriteContactIf.
+func (cm *ContactManager) DeleteContactIf(uid string, cond Preconditions) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	existing, err := cm.GetContact(uid)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, uid)
+	}
+	if err := cond.check(existing); err != nil {
+		return err
+	}
+	return cm.deleteContact(uid)
+}
+
+// deleteContact is DeleteContact for callers holding the store lock.
+func (cm *ContactManager) deleteContact(uid string) error {
+	if !uidInStore(uid) {
+		return fmt.Errorf("%w: %s", ErrNotFound, uid)
+	}
+	card, _ := cm.GetContact(uid)
+	if cm.provider != nil {
+		ids, err := cm.IDMap()
+		if err != nil {
+			return err
+		}
+		id, ok := ids.ID(cm.providerName(), uid)
+		if !ok {
+			id = ProviderID(card)
+		}
+		if id != "" && cm.readOnly() {
+			return fmt.Errorf("%w: %s comes from %s", ErrReadOnly, CardFullName(card), cm.providerName())
+		}
+		if id != "" {
+			if err := cm.provider.DeleteContact(id); err != nil {
+				return fmt.Errorf("failed to delete contact from provider: %w", err)
+			}
+			ids.Unmap(cm.providerName(), uid)
+			if err := cm.saveIDMap(ids); err != nil {
+				return err
+			}
 		}
 	}
 	filePath := filepath.Join(cm.storagePath, uid+".vcf")
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("contact not found: %s", uid)
+			return fmt.Errorf("%w: %s", ErrNotFound, uid)
 		}
 		return fmt.Errorf("failed to delete contact: %w", err)
 	}
+	if err := cm.removeFromIndex(uid); err != nil {
+		return err
+	}
+	cm.invalidateNames()
+	entry := AuditEntry{Actor: cm.actor, Action: "delete", UID: uid, Name: CardFullName(card)}
+	if err := cm.appendAudit(entry); err != nil {
+		return err
+	}
+	cm.notifyContact(entry, nil)
 	return nil
 }
 
-func (cm *ContactManager) SyncContacts() error {
-	remoteContacts, err := cm.provider.FetchContacts()
+// SyncResult summarizes what a sync did to the local store.
+type SyncResult struct {
+	// Created and Updated count contacts written because they were new or
+	// differed from the stored copy.
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	// Deleted counts contacts removed because the provider deleted them.
+	Deleted int `json:"deleted"`
+	// Skipped counts fetched contacts that matched the stored copy.
+	Skipped int `json:"skipped"`
+	// Invalid explains why fetched contacts that failed ValidateCard were
+	// not stored.
+	Invalid []string `json:"invalid,omitempty"`
+	// Conflicts lists contacts whose files were edited outside the tool
+	// and also changed at the provider. They are left as they are for
+	// `contacts verify` to resolve.
+	Conflicts []string `json:"conflicts,omitempty"`
+	// Duration is how long the sync took.
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON renders Duration as a string such as "1.5s".
+func (r SyncResult) MarshalJSON() ([]byte, error) {
+	type plain SyncResult
+	return json.Marshal(struct {
+		plain
+		Duration string `json:"duration"`
+	}{plain(r), r.Duration.String()})
+}
+
+// SyncContacts pulls contacts from the provider into the local store.
+func (cm *ContactManager) SyncContacts() (result SyncResult, err error) {
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+	if cm.provider == nil {
+		return result, ErrNotInitialized
+	}
+	// Local changes still waiting for the provider must land before
+	// fetching, or the fetch would overwrite them. The store is locked
+	// only once the provider has answered, to apply what it sent.
+	if _, err := cm.PushPending(); err != nil {
+		return result, fmt.Errorf("failed to push pending changes: %w", err)
+	}
+	remoteContacts, deleted, err := cm.fetchChanges()
 	if err != nil {
-		return fmt.Errorf("failed to fetch remote contacts: %w", err)
+		return result, err
 	}
-	for _, card := range remoteContacts {
-		if err := cm.writeContactLocal(card); err != nil {
-			return fmt.Errorf("failed to write local contact: %w", err)
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+	if err := cm.mapRemote(remoteContacts, deleted); err != nil {
+		return result, err
+	}
+	if err := cm.applySync(remoteContacts, deleted, &result); err != nil {
+		return result, err
+	}
+	if incremental, ok := cm.provider.(IncrementalProvider); ok {
+		if err := incremental.CommitSync(); err != nil {
+			return result, err
+		}
+	}
+	cm.notify(WebhookPayload{Event: EventSyncCompleted, Actor: ActorSync, Count: result.Created + result.Updated + result.Deleted})
+	return result, nil
+}
+
+// fetchRemote fetches the contacts changed at the provider since the last
+// sync, or all of them if the provider does not support incremental sync,
+// under their local UIDs.
+func (cm *ContactManager) fetchRemote() (changed []vcard.Card, deleted []string, err error) {
+	if changed, deleted, err = cm.fetchChanges(); err != nil {
+		return nil, nil, err
+	}
+	if err := cm.mapRemote(changed, deleted); err != nil {
+		return nil, nil, err
+	}
+	return changed, deleted, nil
+}
+
+// fetchChanges is fetchRemote without mapping provider IDs to local UIDs.
+func (cm *ContactManager) fetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	if incremental, ok := cm.provider.(IncrementalProvider); ok {
+		changed, deleted, err = incremental.FetchChanges()
+	} else {
+		changed, err = cm.provider.FetchContacts()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch remote contacts: %w", err)
+	}
+	return changed, deleted, nil
+}
+
+// mapRemote gives fetched cards and deletions the local UIDs the ID map
+// records for them, and routes and tags the cards.
+func (cm *ContactManager) mapRemote(changed []vcard.Card, deleted []string) error {
+	// Cards the ID map knows are stored under their mapped local UID.
+	ids, err := cm.IDMap()
+	if err != nil {
+		return err
+	}
+	name := cm.providerName()
+	for _, card := range changed {
+		if ProviderID(card) == "" {
+			card.SetValue(FieldProviderID, CardUID(card))
+		}
+		if uid, ok := ids.Lookup(name, ProviderID(card)); ok {
+			card.SetValue(vcard.FieldUID, uid)
+		}
+		cm.routeTags(card)
+		DetectSocialProfiles(card)
+	}
+	for i, id := range deleted {
+		if uid, ok := ids.Lookup(name, id); ok {
+			deleted[i] = uid
 		}
 	}
 	return nil
 }
 
-func (cm *ContactManager) writeContactLocal(card vcard.Card) error {
+// applySync stores fetched cards and removes deleted ones, counting what it
+// did in result.
+func (cm *ContactManager) applySync(remoteContacts []vcard.Card, deleted []string, result *SyncResult) error {
+	if len(remoteContacts) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	index, err := cm.loadIndex()
+	if err != nil {
+		return err
+	}
+	// A failure doesn't stop the round: the index is still saved, so the
+	// files already written stay tracked.
+	var errs []error
+	for _, card := range remoteContacts {
+		outcome, err := cm.syncContactLocal(card, index)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to write local contact %s: %w", CardUID(card), err))
+			continue
+		}
+		switch outcome {
+		case syncCreated:
+			result.Created++
+		case syncUpdated:
+			result.Updated++
+		case syncSkipped:
+			result.Skipped++
+		case syncConflict:
+			result.Conflicts = append(result.Conflicts, CardUID(card))
+		case syncInvalid:
+			result.Invalid = append(result.Invalid, ValidateCard(card).Error())
+		}
+	}
+	if err := cm.routeGroups(remoteContacts, index); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cm.syncGroupLabels(index); err != nil {
+		errs = append(errs, err)
+	}
+	kept := map[string]bool{}
+	for _, uid := range deleted {
+		removed, err := cm.deleteContactLocal(uid, index)
+		if err != nil {
+			errs = append(errs, err)
+			kept[uid] = true
+			continue
+		}
+		if removed {
+			result.Deleted++
+		}
+	}
+	if err := cm.saveIndex(index); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	name := cm.providerName()
+	if err := cm.updateIDMap(func(ids IDMap) {
+		for _, card := range remoteContacts {
+			if _, stored := index[CardUID(card)]; stored && ProviderID(card) != "" {
+				ids.Set(name, ProviderID(card), CardUID(card))
+			}
+		}
+		for _, uid := range deleted {
+			if !kept[uid] {
+				ids.Unmap(name, uid)
+			}
+		}
+	}); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	if _, err := cm.refreshNamesCache(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// syncOutcome is what syncContactLocal did with a fetched card.
+type syncOutcome int
+
+const (
+	syncSkipped syncOutcome = iota
+	syncCreated
+	syncUpdated
+	syncConflict
+	syncInvalid
+)
+
+// syncContactLocal stores a fetched card. If its file was edited outside
+// the tool since the last write, the two versions are merged with the
+// manager's merge strategy, or without one the edit is kept and the card
+// is reported as a conflict. A read-only provider's version always wins.
+func (cm *ContactManager) syncContactLocal(card vcard.Card, index map[string]indexEntry) (syncOutcome, error) {
+	if CardUID(card) != "" && ValidateCard(card) != nil {
+		return syncInvalid, nil
+	}
+	uid := CardUID(card)
+	if uid != "" {
+		data, err := os.ReadFile(filepath.Join(cm.storagePath, uid+".vcf"))
+		entry, tracked := index[uid]
+		if err == nil && tracked && entry.Hash != hashContent(data) {
+			local, err := DecodeCard(data)
+			if (err == nil && sameSyncedContent(local, card)) || cm.readOnly() {
+				return cm.writeContactLocal(card, index)
+			}
+			if err != nil || cm.mergeWith == "" {
+				return syncConflict, nil
+			}
+			return cm.mergeConflict(local, card, index)
+		}
+	}
+	return cm.writeContactLocal(card, index)
+}
+
+// deleteContactLocal removes a contact deleted at the provider. A contact
+// that is already gone locally is ignored and removed is false.
+func (cm *ContactManager) deleteContactLocal(uid string, index map[string]indexEntry) (removed bool, err error) {
+	card, err := cm.GetContact(uid)
+	if err != nil || card == nil {
+		delete(index, uid)
+		return false, nil
+	}
+	if err := os.Remove(filepath.Join(cm.storagePath, uid+".vcf")); err != nil {
+		return false, fmt.Errorf("failed to delete contact: %w", err)
+	}
+	delete(index, uid)
+	cm.invalidateNames()
+	entry := AuditEntry{Actor: ActorSync, Action: "delete", UID: uid, Name: CardFullName(card)}
+	if err := cm.appendAudit(entry); err != nil {
+		return true, err
+	}
+	cm.notifyContact(entry, nil)
+	return true, nil
+}
+
+// writeContactLocal stores a card fetched from the provider. If the stored
+// copy already has the same content, ignoring X-LAST-SYNCED, the file is
+// left untouched and syncSkipped is returned.
+func (cm *ContactManager) writeContactLocal(card vcard.Card, index map[string]indexEntry) (syncOutcome, error) {
 	if CardUID(card) == "" {
 		card.SetValue(vcard.FieldUID, uuid.New().String())
 	}
+	outcome := syncCreated
+	filePath := filepath.Join(cm.storagePath, CardUID(card)+".vcf")
+	if data, err := os.ReadFile(filePath); err == nil {
+		outcome = syncUpdated
+		if old, err := DecodeCard(data); err == nil && sameSyncedContent(old, card) {
+			index[CardUID(card)] = indexEntry{Hash: hashContent(data)}
+			return syncSkipped, nil
+		}
+	}
 	card.Set("X-LAST-SYNCED", &vcard.Field{
 		Value: time.Now().UTC().Format("20060102T150405Z"),
 	})
+	return outcome, cm.writeCardFile(card, index, ActorSync)
+}
 
+// sameSyncedContent reports whether two cards encode identically apart
+// from their X-LAST-SYNCED timestamps.
+func sameSyncedContent(a, b vcard.Card) bool {
+	strip := func(card vcard.Card) []byte {
+		c := make(vcard.Card, len(card))
+		for k, v := range card {
+			if k != "X-LAST-SYNCED" {
+				c[k] = v
+			}
+		}
+		data, err := EncodeCard(c)
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+	ea, eb := strip(a), strip(b)
+	return ea != nil && bytes.Equal(ea, eb)
+}
+
+// writeCardFile encodes a card to its file, records the content hash in
+// index and logs the change for actor. Callers are responsible for saving
+// the index.
+func (cm *ContactManager) writeCardFile(card vcard.Card, index map[string]indexEntry, actor string) error {
+	if err := ValidateCard(card); err != nil {
+		return err
+	}
+	// An unreadable previous version is logged as a create.
+	old, _ := cm.GetContact(CardUID(card))
 	data, err := EncodeCard(card)
 	if err != nil {
 		return fmt.Errorf("failed to marshal contact: %w", err)
 	}
 	filePath := filepath.Join(cm.storagePath, CardUID(card)+".vcf")
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := writeFileAtomic(filePath, data, cm.fileMode); err != nil {
 		return fmt.Errorf("failed to write contact file: %w", err)
 	}
-	return nil
+	index[CardUID(card)] = indexEntry{Hash: hashContent(data)}
+	cm.invalidateNames()
+	return cm.auditWrite(actor, old, card)
 }
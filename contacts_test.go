@@ -1,10 +1,13 @@
 package contacts
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/emersion/go-vcard"
 )
@@ -31,6 +34,10 @@ func TestPrimaryPhone(t *testing.T) {
 			{Value: "555-1111", Params: vcard.Params{vcard.ParamType: []string{"work"}}},
 			{Value: "555-2222", Params: vcard.Params{vcard.ParamType: []string{"home"}}},
 		}, "555-1111"},
+		{"prefers PREF over cell", []*vcard.Field{
+			{Value: "555-1234", Params: vcard.Params{vcard.ParamType: []string{"cell"}}},
+			{Value: "555-4321", Params: vcard.Params{vcard.ParamType: []string{"work"}, vcard.ParamPreferred: []string{"1"}}},
+		}, "555-4321"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,6 +67,14 @@ func TestPrimaryEmail(t *testing.T) {
 			{Value: "first@b.com", Params: vcard.Params{vcard.ParamType: []string{"work"}}},
 			{Value: "second@b.com", Params: vcard.Params{vcard.ParamType: []string{"home"}}},
 		}, "first@b.com"},
+		{"prefers lowest PREF", []*vcard.Field{
+			{Value: "first@b.com", Params: vcard.Params{vcard.ParamPreferred: []string{"2"}}},
+			{Value: "second@b.com", Params: vcard.Params{vcard.ParamPreferred: []string{"1"}}},
+		}, "second@b.com"},
+		{"TYPE=pref", []*vcard.Field{
+			{Value: "first@b.com"},
+			{Value: "second@b.com", Params: vcard.Params{vcard.ParamType: []string{"home", "pref"}}},
+		}, "second@b.com"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -209,7 +224,7 @@ func TestContactManager_SyncContacts(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := cm.SyncContacts(); err != nil {
+	if _, err := cm.SyncContacts(); err != nil {
 		t.Fatal(err)
 	}
 	cards, err := cm.ListContacts()
@@ -337,3 +352,201 @@ func TestEncodeDecodeRoundTrip(t *testing.T) {
 		t.Errorf("note: got %q, want %q", decoded.Value(vcard.FieldNote), "A note")
 	}
 }
+
+func TestContactManager_ListContactsOrder(t *testing.T) {
+	dir := t.TempDir()
+	cm, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 100
+	for i := 0; i < n; i++ {
+		card := NewCard(fmt.Sprintf("Contact %03d", i))
+		card.SetValue(vcard.FieldUID, fmt.Sprintf("uid-%03d", i))
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cards, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != n {
+		t.Fatalf("got %d contacts, want %d", len(cards), n)
+	}
+	for i, card := range cards {
+		if want := fmt.Sprintf("uid-%03d", i); CardUID(card) != want {
+			t.Fatalf("cards[%d] = %s, want %s", i, CardUID(card), want)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "people", "bad.vcf"), []byte("not a vcard"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.ListContacts(); err == nil {
+		t.Error("expected error for unparseable contact file")
+	}
+}
+
+type incrementalMockProvider struct {
+	mockProvider
+	changed   []vcard.Card
+	deleted   []string
+	committed int
+}
+
+func (m *incrementalMockProvider) FetchChanges() ([]vcard.Card, []string, error) {
+	return m.changed, m.deleted, nil
+}
+
+func (m *incrementalMockProvider) CommitSync() error {
+	m.committed++
+	return nil
+}
+
+func TestContactManager_SyncContactsIncremental(t *testing.T) {
+	dir := t.TempDir()
+	provider := &incrementalMockProvider{}
+	cm, err := NewContactManager(provider, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keep := NewCard("Keep Me")
+	gone := NewCard("Gone Soon")
+	provider.changed = []vcard.Card{keep, gone}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	keepPath := filepath.Join(cm.storagePath, CardUID(keep)+".vcf")
+	before, err := os.Stat(keepPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider.changed = nil
+	provider.deleted = []string{CardUID(gone), "never-synced"}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	if provider.committed != 2 {
+		t.Errorf("CommitSync called %d times, want 2", provider.committed)
+	}
+	if card, _ := cm.GetContact(CardUID(gone)); card != nil {
+		t.Error("remotely deleted contact still stored locally")
+	}
+	after, err := os.Stat(keepPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.ModTime().Equal(before.ModTime()) {
+		t.Error("unchanged contact was rewritten")
+	}
+
+	// A sync with no changes writes nothing.
+	provider.deleted = nil
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("got %d contacts, want 1", len(list))
+	}
+}
+
+func TestContactManager_SyncContactsSkipsUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	same := NewCard("Same Person")
+	edited := NewCard("Edited Person")
+	provider := &mockProvider{contacts: []vcard.Card{same, edited}}
+	cm, err := NewContactManager(provider, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 2 || result.Skipped != 0 {
+		t.Errorf("first sync = %+v, want 2 created", result)
+	}
+
+	samePath := filepath.Join(cm.storagePath, CardUID(same)+".vcf")
+	before, err := os.ReadFile(samePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited.SetValue(vcard.FieldNote, "met at the conference")
+	result, err = cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 || result.Skipped != 1 {
+		t.Errorf("second sync = %+v, want 1 updated, 1 skipped", result)
+	}
+	after, err := os.ReadFile(samePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Error("unchanged contact was rewritten")
+	}
+	if issues, err := cm.Verify(); err != nil || len(issues) != 0 {
+		t.Errorf("Verify() = %v, %v; want no issues", issues, err)
+	}
+}
+
+func TestContactManager_SyncContactsConflict(t *testing.T) {
+	dir := t.TempDir()
+	card := NewCard("Ada Lovelace")
+	provider := &mockProvider{contacts: []vcard.Card{card}}
+	cm, err := NewContactManager(provider, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(cm.storagePath, CardUID(card)+".vcf")
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldUID, CardUID(card))
+	local.SetValue(vcard.FieldNote, "edited by hand")
+	data, err := EncodeCard(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue(vcard.FieldTitle, "Analyst")
+
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0] != CardUID(card) {
+		t.Errorf("Conflicts = %v, want [%s]", result.Conflicts, CardUID(card))
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(data) {
+		t.Error("sync overwrote a locally edited contact")
+	}
+}
+
+func TestSyncResult_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(SyncResult{Created: 1, Skipped: 2, Duration: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"created":1,"updated":0,"deleted":0,"skipped":2,"duration":"1.5s"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,145 @@
+package contacts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// DecodeMode selects how strictly contact files are validated when read.
+type DecodeMode int
+
+const (
+	// DecodeLenient accepts malformed cards and repairs what it can.
+	DecodeLenient DecodeMode = iota
+	// DecodeStrict rejects cards that fail DecodeCardStrict's checks.
+	DecodeStrict
+)
+
+// WithDecodeMode sets how the manager decodes contact files
+// (DecodeLenient by default).
+func WithDecodeMode(mode DecodeMode) ManagerOption {
+	return func(cm *ContactManager) {
+		cm.decodeMode = mode
+	}
+}
+
+func (cm *ContactManager) decodeCard(data []byte) (vcard.Card, error) {
+	if cm.decodeMode == DecodeStrict {
+		return DecodeCardStrict(data)
+	}
+	return DecodeCard(data)
+}
+
+// dateFields are the properties validated and repaired as dates.
+var dateFields = []string{vcard.FieldBirthday, vcard.FieldAnniversary, FieldEvent}
+
+// DecodeCardStrict deserializes VCF bytes into a vcard.Card, rejecting
+// cards with no VERSION or FN, unparseable dates, or invalid backslash
+// escapes. Unlike DecodeCard it never modifies the card.
+func DecodeCardStrict(data []byte) (vcard.Card, error) {
+	if err := checkEscapes(data); err != nil {
+		return nil, fmt.Errorf("invalid vcard: %w", err)
+	}
+	card, err := vcard.NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vcard: %w", err)
+	}
+	if card.Value(vcard.FieldVersion) == "" {
+		return nil, fmt.Errorf("invalid vcard: missing VERSION")
+	}
+	if strings.TrimSpace(card.Value(vcard.FieldFormattedName)) == "" {
+		return nil, fmt.Errorf("invalid vcard: missing FN")
+	}
+	for _, name := range dateFields {
+		for _, f := range card[name] {
+			if _, _, _, ok := parseVCardDate(f.Value); !ok {
+				return nil, fmt.Errorf("invalid vcard: %s %q is not a valid date", name, f.Value)
+			}
+		}
+	}
+	return card, nil
+}
+
+// checkEscapes reports the first property value containing a backslash
+// escape other than \\, \,, \; or \n.
+func checkEscapes(data []byte) error {
+	// Unfold continuation lines before splitting into properties.
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\n ", "")
+	text = strings.ReplaceAll(text, "\n\t", "")
+	for _, line := range strings.Split(text, "\n") {
+		value, ok := propertyValue(line)
+		if !ok {
+			continue
+		}
+		for i := 0; i < len(value); i++ {
+			if value[i] != '\\' {
+				continue
+			}
+			if i+1 == len(value) || !strings.ContainsRune(`\,;nN`, rune(value[i+1])) {
+				return fmt.Errorf("bad escape in %q", line)
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// propertyValue returns the part of a content line after the first colon
+// that isn't inside a quoted parameter value.
+func propertyValue(line string) (string, bool) {
+	quoted := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				return line[i+1:], true
+			}
+		}
+	}
+	return "", false
+}
+
+// repairCard fixes problems DecodeCard can recover from: a missing VERSION,
+// a missing FN (derived from N or ORG), and dates with stray whitespace.
+func repairCard(card vcard.Card) {
+	if card.Value(vcard.FieldVersion) == "" {
+		card.SetValue(vcard.FieldVersion, "4.0")
+	}
+	if strings.TrimSpace(card.Value(vcard.FieldFormattedName)) == "" {
+		if fn := nameFromCard(card); fn != "" {
+			card.SetValue(vcard.FieldFormattedName, fn)
+		}
+	}
+	for _, name := range dateFields {
+		for _, f := range card[name] {
+			f.Value = strings.TrimSpace(f.Value)
+		}
+	}
+}
+
+// nameFromCard builds a display name from the N or ORG property.
+func nameFromCard(card vcard.Card) string {
+	if n := card.Name(); n != nil {
+		parts := []string{n.HonorificPrefix, n.GivenName, n.AdditionalName, n.FamilyName, n.HonorificSuffix}
+		var nonEmpty []string
+		for _, p := range parts {
+			if p = strings.TrimSpace(p); p != "" {
+				nonEmpty = append(nonEmpty, p)
+			}
+		}
+		if len(nonEmpty) > 0 {
+			return strings.Join(nonEmpty, " ")
+		}
+	}
+	org := card.Value(vcard.FieldOrganization)
+	if i := strings.IndexByte(org, ';'); i >= 0 {
+		org = org[:i]
+	}
+	return strings.TrimSpace(org)
+}
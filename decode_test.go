@@ -0,0 +1,130 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func vcf(lines ...string) []byte {
+	return []byte("BEGIN:VCARD\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCARD\r\n")
+}
+
+func TestDecodeCardStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"valid", vcf("VERSION:4.0", "UID:a", "FN:Jane Doe", "BDAY:19900115", "NOTE:one\\, two\\nthree"), ""},
+		{"no-year birthday", vcf("VERSION:4.0", "FN:Jane", "BDAY:--0115"), ""},
+		{"missing version", vcf("FN:Jane"), "missing VERSION"},
+		{"missing FN", vcf("VERSION:4.0", "N:Doe;Jane;;;"), "missing FN"},
+		{"invalid date", vcf("VERSION:4.0", "FN:Jane", "BDAY:sometime in may"), "not a valid date"},
+		{"invalid anniversary", vcf("VERSION:4.0", "FN:Jane", "ANNIVERSARY:20201340"), "not a valid date"},
+		{"bad escape", vcf("VERSION:4.0", "FN:Jane", "NOTE:C:\\temp"), "bad escape"},
+		{"quoted colon in param", vcf("VERSION:4.0", `FN;X-A="a:b":Jane`), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCardStrict(tt.data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeCard_Repairs(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		wantFN string
+	}{
+		{"FN from N", vcf("N:Doe;Jane;;;"), "Jane Doe"},
+		{"FN from ORG", vcf("ORG:Acme Corp;Sales"), "Acme Corp"},
+		{"existing FN kept", vcf("VERSION:3.0", "FN:J. Doe", "N:Doe;Jane;;;"), "J. Doe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card, err := DecodeCard(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := card.Value(vcard.FieldFormattedName); got != tt.wantFN {
+				t.Errorf("FN = %q, want %q", got, tt.wantFN)
+			}
+			if card.Value(vcard.FieldVersion) == "" {
+				t.Error("VERSION not set")
+			}
+		})
+	}
+
+	card, err := DecodeCard(vcf("VERSION:4.0", "FN:Jane", "BDAY: 19900115 ", "NOTE:C:\\temp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := card.Value(vcard.FieldBirthday); got != "19900115" {
+		t.Errorf("BDAY = %q, want trimmed", got)
+	}
+}
+
+func TestContactManager_DecodeMode(t *testing.T) {
+	dir := t.TempDir()
+	people := filepath.Join(dir, "people")
+	if err := os.MkdirAll(people, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(people, "x.vcf"), vcf("UID:x", "N:Doe;Jane;;;"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	lenient, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card, err := lenient.GetContact("x")
+	if err != nil {
+		t.Fatalf("lenient: %v", err)
+	}
+	if CardFullName(card) != "Jane Doe" {
+		t.Errorf("lenient FN = %q, want Jane Doe", CardFullName(card))
+	}
+
+	strict, err := NewContactManager(nil, dir, WithDecodeMode(DecodeStrict))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strict.GetContact("x"); err == nil {
+		t.Error("strict: expected error for card without VERSION or FN")
+	}
+	issues, err := strict.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Status != VerifyCorrupt {
+		t.Errorf("strict verify = %+v, want one corrupt issue", issues)
+	}
+}
+
+func TestConfig_DecodeMode(t *testing.T) {
+	for _, mode := range []string{"", "lenient", "strict"} {
+		cfg := &Config{DecodeMode: mode}
+		if _, err := cfg.ManagerOptions(); err != nil {
+			t.Errorf("%q: %v", mode, err)
+		}
+	}
+	cfg := &Config{DecodeMode: "picky"}
+	if _, err := cfg.ManagerOptions(); err == nil {
+		t.Error("expected error for unknown decode mode")
+	}
+}
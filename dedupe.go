@@ -0,0 +1,86 @@
+package contacts
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FindDuplicates groups cards that share an email address (case-insensitive)
+// or a phone number (compared with PhonesMatch). Only groups of two or more
+// cards are returned, each sorted by name.
+func FindDuplicates(cards []vcard.Card) [][]vcard.Card {
+	parent := make([]int, len(cards))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		if ri, rj := find(i), find(j); ri != rj {
+			parent[ri] = rj
+		}
+	}
+
+	byEmail := map[string]int{}
+	phones := make([][]string, len(cards))
+	for i, card := range cards {
+		for _, f := range card[vcard.FieldEmail] {
+			email := strings.ToLower(strings.TrimSpace(f.Value))
+			if email == "" {
+				continue
+			}
+			if j, ok := byEmail[email]; ok {
+				union(i, j)
+			} else {
+				byEmail[email] = i
+			}
+		}
+		for _, f := range card[vcard.FieldTelephone] {
+			phones[i] = append(phones[i], f.Value)
+		}
+	}
+	for i := range cards {
+		for j := i + 1; j < len(cards); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+			if anyPhonesMatch(phones[i], phones[j]) {
+				union(i, j)
+			}
+		}
+	}
+
+	groups := map[int][]vcard.Card{}
+	for i, card := range cards {
+		root := find(i)
+		groups[root] = append(groups[root], card)
+	}
+	var out [][]vcard.Card
+	for _, g := range groups {
+		if len(g) < 2 {
+			continue
+		}
+		sort.Slice(g, func(i, j int) bool { return CardFullName(g[i]) < CardFullName(g[j]) })
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool { return CardFullName(out[i][0]) < CardFullName(out[j][0]) })
+	return out
+}
+
+func anyPhonesMatch(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if PhonesMatch(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	card := func(name, email, phone string) vcard.Card {
+		c := NewCard(name)
+		if email != "" {
+			c.Add(vcard.FieldEmail, &vcard.Field{Value: email})
+		}
+		if phone != "" {
+			c.Add(vcard.FieldTelephone, &vcard.Field{Value: phone})
+		}
+		return c
+	}
+	cards := []vcard.Card{
+		card("Ann A", "ann@example.com", ""),
+		card("Ann B", "ANN@example.com", "+1 (555) 123-4567"),
+		card("Ann C", "", "5551234567"),
+		card("Bob", "bob@example.com", "555-000-1111"),
+		card("Cat", "cat@example.com", ""),
+	}
+	groups := FindDuplicates(cards)
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+	var names []string
+	for _, c := range groups[0] {
+		names = append(names, CardFullName(c))
+	}
+	if len(names) != 3 || names[0] != "Ann A" || names[2] != "Ann C" {
+		t.Errorf("group = %v, want [Ann A Ann B Ann C]", names)
+	}
+}
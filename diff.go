@@ -0,0 +1,73 @@
+package contacts
+
+import (
+	"sort"
+
+	"github.com/emersion/go-vcard"
+)
+
+// ChangeKind says how a field differs between two versions of a card.
+type ChangeKind string
+
+const (
+	FieldAdded   ChangeKind = "added"
+	FieldRemoved ChangeKind = "removed"
+	FieldChanged ChangeKind = "changed"
+)
+
+// FieldChange is a field that differs between two versions of a card,
+// with its values before and after. Before is empty for an added field
+// and After for a removed one.
+type FieldChange struct {
+	Field         string
+	Kind          ChangeKind
+	Before, After []*vcard.Field
+}
+
+// String summarizes the change as "+FIELD" (added), "-FIELD" (removed) or
+// "~FIELD" (changed), as the audit log and sync plans record it.
+func (c FieldChange) String() string {
+	switch c.Kind {
+	case FieldAdded:
+		return "+" + c.Field
+	case FieldRemoved:
+		return "-" + c.Field
+	}
+	return "~" + c.Field
+}
+
+// DiffCards lists the fields that differ from a to b, sorted by name.
+// Either card may be nil, for a contact created or deleted. Values and
+// parameters are compared, except ParamUpdated stamps; bookkeeping fields
+// that change on every write (REV, X-LAST-SYNCED, X-PROVIDER-ID) are not
+// reported.
+func DiffCards(a, b vcard.Card) []FieldChange {
+	names := map[string]bool{}
+	for k := range a {
+		names[k] = true
+	}
+	for k := range b {
+		names[k] = true
+	}
+	var out []FieldChange
+	for k := range names {
+		if auditIgnoredFields[k] {
+			continue
+		}
+		before, after := fieldValues(a[k]), fieldValues(b[k])
+		change := FieldChange{Field: k, Before: a[k], After: b[k]}
+		switch {
+		case len(before) == 0 && len(after) > 0:
+			change.Kind = FieldAdded
+		case len(before) > 0 && len(after) == 0:
+			change.Kind = FieldRemoved
+		case before != after:
+			change.Kind = FieldChanged
+		default:
+			continue
+		}
+		out = append(out, change)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
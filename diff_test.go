@@ -0,0 +1,56 @@
+package contacts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestDiffCards(t *testing.T) {
+	old := NewCard("Alice")
+	old.SetValue(vcard.FieldNote, "old note")
+	old.SetValue(vcard.FieldTitle, "Engineer")
+	old.Add(vcard.FieldEmail, &vcard.Field{Value: "alice@example.com", Params: vcard.Params{vcard.ParamType: {"home"}}})
+
+	new := NewCard("Alice")
+	new.SetValue(vcard.FieldUID, CardUID(old))
+	new.SetValue(vcard.FieldNote, "new note")
+	new.Add(vcard.FieldEmail, &vcard.Field{Value: "alice@example.com", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	new.SetValue(vcard.FieldTelephone, "555-0100")
+	new.SetValue(vcard.FieldRevision, "20240101T000000Z")
+	MarkUpdated(new.Get(vcard.FieldFormattedName), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	got := DiffCards(old, new)
+	want := []struct {
+		field string
+		kind  ChangeKind
+		str   string
+	}{
+		{vcard.FieldEmail, FieldChanged, "~EMAIL"},
+		{vcard.FieldNote, FieldChanged, "~NOTE"},
+		{vcard.FieldTelephone, FieldAdded, "+TEL"},
+		{vcard.FieldTitle, FieldRemoved, "-TITLE"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %d changes", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Field != w.field || got[i].Kind != w.kind || got[i].String() != w.str {
+			t.Errorf("change %d = %s %s, want %s", i, got[i].Kind, got[i].Field, w.str)
+		}
+	}
+	if note := got[1]; note.Before[0].Value != "old note" || note.After[0].Value != "new note" {
+		t.Errorf("NOTE before %v, after %v", note.Before, note.After)
+	}
+	if added := got[2]; len(added.Before) != 0 || added.After[0].Value != "555-0100" {
+		t.Errorf("TEL before %v, after %v", added.Before, added.After)
+	}
+
+	if changes := DiffCards(nil, old); len(changes) != len(old) {
+		t.Errorf("DiffCards(nil, card) = %v, want every field added", changes)
+	}
+	if changes := DiffCards(old, old); len(changes) != 0 {
+		t.Errorf("DiffCards(card, card) = %v, want none", changes)
+	}
+}
@@ -0,0 +1,30 @@
+package contacts
+
+import "errors"
+
+// Errors returned by ContactManager and providers. They are wrapped with
+// context, so test for them with errors.Is.
+var (
+	// ErrNotFound means the requested contact does not exist.
+	ErrNotFound = errors.New("contact not found")
+	// ErrNotInitialized means the provider has no credentials or has not
+	// been initialized.
+	ErrNotInitialized = errors.New("provider not initialized")
+	// ErrAuthExpired means the provider rejected the stored credentials and
+	// the user must re-authorize.
+	ErrAuthExpired = errors.New("authorization expired")
+	// ErrConflict means the contact was changed at the provider since it
+	// was last synced.
+	ErrConflict = errors.New("contact changed at provider")
+	// ErrPreconditionFailed means the contact is not at the version the
+	// caller expected.
+	ErrPreconditionFailed = errors.New("contact has changed")
+	// ErrInvalidCard means a card failed ValidateCard.
+	ErrInvalidCard = errors.New("invalid contact")
+	// ErrProviderUnavailable means the provider could not be reached or
+	// failed to handle the request.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+	// ErrReadOnly means the contact comes from a read-only provider and
+	// can't be changed or deleted.
+	ErrReadOnly = errors.New("contact is read-only")
+)
@@ -0,0 +1,22 @@
+package contacts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContactManager_ErrNotFound(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.ResolveContact("nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResolveContact: got %v, want ErrNotFound", err)
+	}
+	if err := cm.DeleteContact("nobody"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteContact: got %v, want ErrNotFound", err)
+	}
+	if _, err := cm.SyncContacts(); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("SyncContacts: got %v, want ErrNotInitialized", err)
+	}
+}
@@ -0,0 +1,100 @@
+package contacts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldEvent holds a custom dated event, such as the day you first met. The
+// event's kind is the field's TYPE parameter and its value is a vCard date
+// (YYYYMMDD, or --MMDD without a year).
+const FieldEvent = "X-EVENT"
+
+// Event is a custom dated event on a contact.
+type Event struct {
+	Kind string `json:"kind"`
+	Date string `json:"date"`
+}
+
+// CardEvents returns the card's custom events sorted by kind. Events with
+// no TYPE have kind "event".
+func CardEvents(card vcard.Card) []Event {
+	var out []Event
+	for _, f := range card[FieldEvent] {
+		kind := "event"
+		if types := f.Params.Types(); len(types) > 0 {
+			kind = strings.ToLower(types[0])
+		}
+		out = append(out, Event{Kind: kind, Date: f.Value})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Kind < out[j].Kind })
+	return out
+}
+
+// ParseEventDate converts YYYY-MM-DD, --MM-DD or a vCard date to the vCard
+// form stored in X-EVENT.
+func ParseEventDate(s string) (string, error) {
+	year, month, day, ok := parseVCardDate(s)
+	if !ok {
+		return "", fmt.Errorf("invalid date %q: expected YYYY-MM-DD or --MM-DD", s)
+	}
+	if year == 0 {
+		return fmt.Sprintf("--%02d%02d", month, day), nil
+	}
+	return fmt.Sprintf("%04d%02d%02d", year, month, day), nil
+}
+
+// SetEvent sets the date of the card's event of the given kind, replacing
+// any earlier date for that kind. The caller saves card.
+func SetEvent(card vcard.Card, kind, date string) error {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" || strings.ContainsAny(kind, ",;:=\"") {
+		return fmt.Errorf("invalid event type %q", kind)
+	}
+	value, err := ParseEventDate(date)
+	if err != nil {
+		return err
+	}
+	RemoveEvent(card, kind)
+	card.Add(FieldEvent, &vcard.Field{
+		Value:  value,
+		Params: vcard.Params{vcard.ParamType: {kind}},
+	})
+	return nil
+}
+
+// RemoveEvent removes the card's events of the given kind and reports
+// whether there were any. The caller saves card.
+func RemoveEvent(card vcard.Card, kind string) bool {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	var kept []*vcard.Field
+	for _, f := range card[FieldEvent] {
+		if types := f.Params.Types(); len(types) > 0 && strings.EqualFold(types[0], kind) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	removed := len(kept) != len(card[FieldEvent])
+	if len(kept) == 0 {
+		delete(card, FieldEvent)
+	} else {
+		card[FieldEvent] = kept
+	}
+	return removed
+}
+
+// cardOccasions lists the recurring dates on a card: its birthday,
+// anniversary and custom events, by kind.
+func cardOccasions(card vcard.Card) []Event {
+	var out []Event
+	if v := card.Value(vcard.FieldBirthday); v != "" {
+		out = append(out, Event{Kind: "birthday", Date: v})
+	}
+	if v := card.Value(vcard.FieldAnniversary); v != "" {
+		out = append(out, Event{Kind: "anniversary", Date: v})
+	}
+	return append(out, CardEvents(card)...)
+}
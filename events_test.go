@@ -0,0 +1,66 @@
+package contacts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestSetEvent(t *testing.T) {
+	card := NewCard("Alice")
+	if err := SetEvent(card, "Met", "2019-03-10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetEvent(card, "graduated", "--06-01"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetEvent(card, "met", "2019-03-11"); err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{{Kind: "graduated", Date: "--0601"}, {Kind: "met", Date: "20190311"}}
+	got := CardEvents(card)
+	if len(got) != len(want) {
+		t.Fatalf("CardEvents() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	for _, tt := range []struct{ kind, date string }{
+		{"met", "March 10"},
+		{"met", "2019-02-30"},
+		{"", "2019-03-10"},
+		{"a;b", "2019-03-10"},
+	} {
+		if err := SetEvent(card, tt.kind, tt.date); err == nil {
+			t.Errorf("SetEvent(%q, %q) succeeded, want error", tt.kind, tt.date)
+		}
+	}
+
+	if !RemoveEvent(card, "MET") || RemoveEvent(card, "met") {
+		t.Error("RemoveEvent should remove met once")
+	}
+	RemoveEvent(card, "graduated")
+	if _, ok := card[FieldEvent]; ok {
+		t.Error("X-EVENT left behind after removing every event")
+	}
+}
+
+func TestUpcomingOccasions_Events(t *testing.T) {
+	card := NewCard("Alice")
+	card.SetValue(vcard.FieldBirthday, "19900615")
+	if err := SetEvent(card, "met", "2019-06-12"); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2024, time.June, 10, 0, 0, 0, 0, time.UTC)
+	got := UpcomingOccasions([]vcard.Card{card}, from, 7)
+	if len(got) != 2 {
+		t.Fatalf("got %d occasions, want 2", len(got))
+	}
+	if got[0].Kind != "met" || got[0].DaysUntil != 2 || got[0].Years() != 5 {
+		t.Errorf("first = %s in %d days (%d years), want met in 2 days (5 years)", got[0].Kind, got[0].DaysUntil, got[0].Years())
+	}
+}
@@ -0,0 +1,250 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// csvHeader lists the columns written by WriteCSV.
+var csvHeader = []string{"uid", "name", "email", "phone", "organization", "title", "address", "birthday", "note", "groups"}
+
+// WriteCSV writes cards as CSV with one row per contact. Multi-valued
+// fields are joined with "; ".
+func WriteCSV(w io.Writer, cards []vcard.Card) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	for _, card := range cards {
+		var addrs []string
+		for _, f := range card[vcard.FieldAddress] {
+			if a := FormatAddress(f.Value); a != "" {
+				addrs = append(addrs, a)
+			}
+		}
+		var bday string
+		if v := card.Value(vcard.FieldBirthday); v != "" {
+			bday = formatDate(v, DefaultLocale)
+		}
+		row := []string{
+			CardUID(card),
+			CardFullName(card),
+			joinValues(card[vcard.FieldEmail]),
+			joinValues(card[vcard.FieldTelephone]),
+			strings.TrimRight(strings.ReplaceAll(card.Value(vcard.FieldOrganization), ";", ", "), ", "),
+			card.Value(vcard.FieldTitle),
+			strings.Join(addrs, "; "),
+			bday,
+			joinValues(card[vcard.FieldNote]),
+			strings.Join(CardGroupLabels(card), "; "),
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write csv row for %s: %w", CardUID(card), err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// googleLabelSep separates several values in one Google CSV cell.
+const googleLabelSep = " ::: "
+
+// googleColumns are the repeated column groups of a Google contacts CSV,
+// with the vCard field each is filled from and the columns after
+// "<Group> N - ".
+var googleColumns = []struct {
+	group, field string
+	columns      []string
+}{
+	{"E-mail", vcard.FieldEmail, []string{"Label", "Value"}},
+	{"Phone", vcard.FieldTelephone, []string{"Label", "Value"}},
+	{"Address", vcard.FieldAddress, []string{"Label", "Formatted", "Street", "City", "PO Box", "Region", "Postal Code", "Country", "Extended Address"}},
+	{"Website", vcard.FieldURL, []string{"Label", "Value"}},
+}
+
+// WriteGoogleCSV writes cards in the CSV layout Google Contacts exports and
+// imports, so the file can be imported into a Google account. Each contact
+// gets as many numbered E-mail, Phone, Address and Website columns as the
+// contact with the most values needs. Labels come from group memberships
+// and CATEGORIES, and every contact is labelled "* myContacts" as in
+// Google's own exports.
+// Group cards are left out; Google has no equivalent.
+func WriteGoogleCSV(w io.Writer, cards []vcard.Card) error {
+	cards = FilterCards(cards, func(card vcard.Card) bool { return card.Kind() != vcard.KindGroup })
+	counts := make([]int, len(googleColumns))
+	for _, card := range cards {
+		for i, g := range googleColumns {
+			counts[i] = max(counts[i], len(card[g.field]))
+		}
+	}
+	header := []string{
+		"First Name", "Middle Name", "Last Name",
+		"Phonetic First Name", "Phonetic Middle Name", "Phonetic Last Name",
+		"Name Prefix", "Name Suffix", "Nickname", "File As",
+		"Organization Name", "Organization Title", "Organization Department",
+		"Birthday", "Notes", "Photo", "Labels",
+	}
+	for i, g := range googleColumns {
+		for n := 1; n <= counts[i]; n++ {
+			for _, col := range g.columns {
+				header = append(header, fmt.Sprintf("%s %d - %s", g.group, n, col))
+			}
+		}
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	for _, card := range cards {
+		name := structuredParts(card.Value(vcard.FieldName), 5)
+		if name[0] == "" && name[1] == "" {
+			name[1] = CardFullName(card)
+		}
+		org := structuredParts(card.Value(vcard.FieldOrganization), 2)
+		var photo string
+		if v := card.Value(vcard.FieldPhoto); strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+			photo = v
+		}
+		labels := append([]string{"* myContacts"}, CardGroupLabels(card)...)
+		var nicknames []string
+		for _, f := range card[vcard.FieldNickname] {
+			nicknames = append(nicknames, f.Value)
+		}
+		var notes []string
+		for _, f := range card[vcard.FieldNote] {
+			notes = append(notes, f.Value)
+		}
+		row := []string{
+			name[1], name[2], name[0],
+			"", "", "",
+			name[3], name[4], strings.Join(nicknames, googleLabelSep), "",
+			org[0], card.Value(vcard.FieldTitle), org[1],
+			googleDate(card.Value(vcard.FieldBirthday)), strings.Join(notes, "\n"), photo,
+			strings.Join(labels, googleLabelSep),
+		}
+		for i, g := range googleColumns {
+			fields := card[g.field]
+			for n := 0; n < counts[i]; n++ {
+				if n >= len(fields) {
+					row = append(row, make([]string, len(g.columns))...)
+					continue
+				}
+				f := fields[n]
+				label := googleLabel(f)
+				if g.field != vcard.FieldAddress {
+					row = append(row, label, f.Value)
+					continue
+				}
+				adr := structuredParts(f.Value, 7)
+				row = append(row, label, FormatAddress(f.Value), adr[2], adr[3], adr[0], adr[4], adr[5], adr[6], adr[1])
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write csv row for %s: %w", CardUID(card), err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// structuredParts splits a structured vCard value such as N or ADR into
+// exactly n components.
+func structuredParts(value string, n int) []string {
+	parts := strings.SplitN(value, ";", n)
+	for len(parts) < n {
+		parts = append(parts, "")
+	}
+	return parts
+}
+
+// googleLabel turns a field's TYPE into the label Google Contacts uses,
+// such as "Mobile" for TYPE=cell. PREF and INTERNET are not labels.
+func googleLabel(f *vcard.Field) string {
+	for _, t := range f.Params.Types() {
+		switch t = strings.ToLower(t); t {
+		case "", "pref", "internet", "voice", "text":
+		case "cell":
+			return "Mobile"
+		case "fax":
+			return "Work Fax"
+		default:
+			return strings.ToUpper(t[:1]) + t[1:]
+		}
+	}
+	return ""
+}
+
+// googleDate formats a vCard date as Google Contacts does: YYYY-MM-DD, or
+// --MM-DD without a year.
+func googleDate(s string) string {
+	year, month, day, ok := parseVCardDate(s)
+	if !ok {
+		return ""
+	}
+	if year == 0 {
+		return fmt.Sprintf("--%02d-%02d", month, day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", year, month, day)
+}
+
+// WriteVCF writes cards as a concatenated VCF stream.
+func WriteVCF(w io.Writer, cards []vcard.Card) error {
+	for _, card := range cards {
+		data, err := EncodeCard(card)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write vcard: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteICS writes the birthdays, anniversaries and custom events of cards
+// as yearly all-day events in an iCalendar file. Dates without a year start
+// in 2000, a leap year, so that Feb 29 is kept.
+func WriteICS(w io.Writer, cards []vcard.Card) error {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//arjungandhi//contacts//EN\r\n")
+	for _, card := range cards {
+		name := CardFullName(card)
+		for _, event := range cardOccasions(card) {
+			year, month, day, ok := parseVCardDate(event.Date)
+			if !ok {
+				continue
+			}
+			if year == 0 {
+				year = 2000
+			}
+			summary := fmt.Sprintf("%s's %s", name, event.Kind)
+			if event.Kind != "birthday" && event.Kind != "anniversary" {
+				summary = fmt.Sprintf("%s: %s", name, event.Kind)
+			}
+			fmt.Fprintf(&b, "BEGIN:VEVENT\r\nUID:%s-%s@contacts\r\nDTSTART;VALUE=DATE:%04d%02d%02d\r\nRRULE:FREQ=YEARLY\r\nSUMMARY:%s\r\nTRANSP:TRANSPARENT\r\nEND:VEVENT\r\n",
+				CardUID(card), event.Kind, year, month, day, icsEscape(summary))
+		}
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+	return nil
+}
+
+// icsEscape escapes an iCalendar TEXT value.
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+func joinValues(fields []*vcard.Field) string {
+	values := make([]string, 0, len(fields))
+	for _, f := range fields {
+		values = append(values, f.Value)
+	}
+	return strings.Join(values, "; ")
+}
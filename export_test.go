@@ -0,0 +1,144 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestWriteCSV(t *testing.T) {
+	card := NewCard("Alice Smith")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "alice@example.com"})
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "alice@work.com"})
+	card.SetValue(vcard.FieldOrganization, "Acme Inc;Engineering")
+	card.Add(vcard.FieldAddress, &vcard.Field{Value: ";;123 Main St;Springfield;IL;62701;US"})
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []vcard.Card{card}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected header + 1 row, got %d rows", len(rows))
+	}
+	row := rows[1]
+	if row[1] != "Alice Smith" {
+		t.Errorf("name: got %q", row[1])
+	}
+	if row[2] != "alice@example.com; alice@work.com" {
+		t.Errorf("email: got %q", row[2])
+	}
+	if row[4] != "Acme Inc, Engineering" {
+		t.Errorf("organization: got %q", row[4])
+	}
+	if row[6] != "123 Main St, Springfield, IL, 62701, US" {
+		t.Errorf("address: got %q", row[6])
+	}
+}
+
+func TestWriteVCF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteVCF(&buf, []vcard.Card{NewCard("One"), NewCard("Two")}); err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(buf.Bytes(), []byte("BEGIN:VCARD")); n != 2 {
+		t.Errorf("expected 2 cards, got %d", n)
+	}
+}
+
+func TestWriteICS(t *testing.T) {
+	card := NewCard("Alice Smith")
+	card.SetValue(vcard.FieldUID, "alice")
+	card.SetValue(vcard.FieldBirthday, "--0229")
+	if err := SetEvent(card, "met", "2019-03-10"); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, []vcard.Card{card, NewCard("No Dates")}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:alice-birthday@contacts\r\nDTSTART;VALUE=DATE:20000229\r\n",
+		"SUMMARY:Alice Smith's birthday\r\n",
+		"DTSTART;VALUE=DATE:20190310\r\nRRULE:FREQ=YEARLY\r\nSUMMARY:Alice Smith: met\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("calendar missing %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "BEGIN:VEVENT"); n != 2 {
+		t.Errorf("got %d events, want 2", n)
+	}
+}
+
+func TestWriteGoogleCSV(t *testing.T) {
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldName, "Lovelace;Ada;;Countess;")
+	ada.SetValue(vcard.FieldOrganization, "Analytical Engines;Research")
+	ada.SetValue(vcard.FieldBirthday, "18151210")
+	ada.SetValue(vcard.FieldCategories, "Friends,Science")
+	ada.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@example.com", Params: vcard.Params{vcard.ParamType: {"home", "pref"}}})
+	ada.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@work.example", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	ada.Add(vcard.FieldTelephone, &vcard.Field{Value: "+44 20 7946 0000", Params: vcard.Params{vcard.ParamType: {"cell"}}})
+	ada.Add(vcard.FieldAddress, &vcard.Field{Value: ";;12 St James's Sq;London;;SW1Y 4JH;UK"})
+	bob := NewCard("Bob")
+	bob.SetValue(vcard.FieldBirthday, "--0704")
+
+	var buf bytes.Buffer
+	if err := WriteGoogleCSV(&buf, []vcard.Card{ada, bob, NewGroupCard("Friends")}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 contacts", len(rows))
+	}
+	get := func(row int, column string) string {
+		for i, name := range rows[0] {
+			if name == column {
+				return rows[row][i]
+			}
+		}
+		t.Fatalf("no column %q in %v", column, rows[0])
+		return ""
+	}
+	for column, want := range map[string]string{
+		"First Name":              "Ada",
+		"Last Name":               "Lovelace",
+		"Name Prefix":             "Countess",
+		"Organization Name":       "Analytical Engines",
+		"Organization Department": "Research",
+		"Birthday":                "1815-12-10",
+		"Labels":                  "* myContacts ::: Friends ::: Science",
+		"E-mail 1 - Label":        "Home",
+		"E-mail 1 - Value":        "ada@example.com",
+		"E-mail 2 - Label":        "Work",
+		"Phone 1 - Label":         "Mobile",
+		"Address 1 - City":        "London",
+		"Address 1 - Postal Code": "SW1Y 4JH",
+	} {
+		if got := get(1, column); got != want {
+			t.Errorf("%s = %q, want %q", column, got, want)
+		}
+	}
+	if got := get(2, "First Name"); got != "Bob" {
+		t.Errorf("First Name without N = %q, want the full name", got)
+	}
+	if got := get(2, "Birthday"); got != "--07-04" {
+		t.Errorf("Birthday without year = %q, want --07-04", got)
+	}
+	if got := get(2, "E-mail 2 - Value"); got != "" {
+		t.Errorf("unused column = %q, want empty", got)
+	}
+}
@@ -0,0 +1,130 @@
+package contacts
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldStarred marks a contact as a favorite.
+const FieldStarred = "X-STARRED"
+
+// FieldBlocked marks a contact as blocked, as Google's blocked group does.
+const FieldBlocked = "X-BLOCKED"
+
+// Google's system contact groups that map to local flags.
+const (
+	googleStarred = "contactGroups/starred"
+	googleBlocked = "contactGroups/blocked"
+)
+
+// starredBonus puts favorites ahead of any contact ranked by use alone.
+const starredBonus = 1000
+
+// IsStarred reports whether a contact is a favorite: starred with
+// SetStarred or in Google's starred group.
+func IsStarred(card vcard.Card) bool {
+	if strings.EqualFold(card.Value(FieldStarred), "true") {
+		return true
+	}
+	for _, f := range card["X-GOOGLE-GROUP-MEMBERSHIP"] {
+		if f.Value == googleStarred {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlocked reports whether a contact is blocked: flagged with FieldBlocked
+// or in Google's blocked group.
+func IsBlocked(card vcard.Card) bool {
+	if strings.EqualFold(card.Value(FieldBlocked), "true") {
+		return true
+	}
+	for _, f := range card["X-GOOGLE-GROUP-MEMBERSHIP"] {
+		if f.Value == googleBlocked {
+			return true
+		}
+	}
+	return false
+}
+
+// SetStarred stars or unstars a contact. The caller saves card.
+func SetStarred(card vcard.Card, starred bool) {
+	if starred {
+		card.SetValue(FieldStarred, "true")
+		return
+	}
+	delete(card, FieldStarred)
+	var kept []*vcard.Field
+	for _, f := range card["X-GOOGLE-GROUP-MEMBERSHIP"] {
+		if f.Value != googleStarred {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == 0 {
+		delete(card, "X-GOOGLE-GROUP-MEMBERSHIP")
+	} else {
+		card["X-GOOGLE-GROUP-MEMBERSHIP"] = kept
+	}
+}
+
+// SortByRelevance orders cards with favorites first, then by how often and
+// how recently they were viewed, edited or interacted with (see
+// RecordAccess and RecordInteractions). Ties keep their order.
+func (cm *ContactManager) SortByRelevance(cards []vcard.Card) error {
+	score, err := cm.relevanceScorer()
+	if err != nil {
+		return err
+	}
+	scores := make(map[string]float64, len(cards))
+	for _, card := range cards {
+		s := score(CardUID(card))
+		if IsStarred(card) {
+			s += starredBonus
+		}
+		scores[CardUID(card)] = s
+	}
+	sort.SliceStable(cards, func(i, j int) bool { return scores[CardUID(cards[i])] > scores[CardUID(cards[j])] })
+	return nil
+}
+
+// SortNamesByRelevance orders names the same way SortByRelevance orders
+// cards, using the names cache's starred flag.
+func (cm *ContactManager) SortNamesByRelevance(names []NameEntry) error {
+	score, err := cm.relevanceScorer()
+	if err != nil {
+		return err
+	}
+	scoreOf := func(n NameEntry) float64 {
+		s := score(n.UID)
+		if n.Starred {
+			s += starredBonus
+		}
+		return s
+	}
+	sort.SliceStable(names, func(i, j int) bool { return scoreOf(names[i]) > scoreOf(names[j]) })
+	return nil
+}
+
+// relevanceScorer adds up a contact's access score and its interactions,
+// each counting for less the longer ago it was.
+func (cm *ContactManager) relevanceScorer() (func(uid string) float64, error) {
+	access, err := cm.recencyScorer()
+	if err != nil {
+		return nil, err
+	}
+	interactions, err := cm.Interactions("")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	touched := map[string]float64{}
+	for _, in := range interactions {
+		touched[in.UID] += math.Pow(0.5, float64(now.Sub(in.Time))/float64(recencyHalfLife))
+	}
+	return func(uid string) float64 { return access(uid) + touched[uid] }, nil
+}
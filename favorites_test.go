@@ -0,0 +1,106 @@
+package contacts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestStarred(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	if IsStarred(card) {
+		t.Fatal("new card is starred")
+	}
+	SetStarred(card, true)
+	if !IsStarred(card) {
+		t.Error("SetStarred(true) did not star")
+	}
+	SetStarred(card, false)
+	if IsStarred(card) {
+		t.Error("SetStarred(false) did not unstar")
+	}
+
+	google := NewCard("Alan Turing")
+	google.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/myContacts"})
+	google.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/starred"})
+	if !IsStarred(google) {
+		t.Error("card in Google's starred group is not starred")
+	}
+	SetStarred(google, false)
+	if IsStarred(google) || len(google["X-GOOGLE-GROUP-MEMBERSHIP"]) != 1 {
+		t.Errorf("unstarring left memberships %v", google["X-GOOGLE-GROUP-MEMBERSHIP"])
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	if IsBlocked(card) {
+		t.Fatal("new card is blocked")
+	}
+	card.SetValue(FieldBlocked, "true")
+	if !IsBlocked(card) {
+		t.Error("FieldBlocked card is not blocked")
+	}
+	if !InGroup("blocked")(card) {
+		t.Error("blocked card is not in the blocked group")
+	}
+
+	google := NewCard("Alan Turing")
+	google.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/blocked"})
+	if !IsBlocked(google) {
+		t.Error("card in Google's blocked group is not blocked")
+	}
+}
+
+func TestContactManager_SortByRelevance(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cards []vcard.Card
+	for _, name := range []string{"Idle", "Viewed", "Called", "Favorite"} {
+		card := NewCard(name)
+		card.SetValue(vcard.FieldUID, name)
+		if name == "Favorite" {
+			SetStarred(card, true)
+		}
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+		cards = append(cards, card)
+	}
+	if err := cm.RecordAccess("Viewed"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := cm.RecordInteractions(
+		Interaction{Time: now, UID: "Called", Kind: InteractionCall},
+		Interaction{Time: now.Add(-time.Hour), UID: "Called", Kind: InteractionCall},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Favorite", "Called", "Viewed", "Idle"}
+	if err := cm.SortByRelevance(cards); err != nil {
+		t.Fatal(err)
+	}
+	for i, card := range cards {
+		if CardUID(card) != want[i] {
+			t.Fatalf("SortByRelevance order wrong at %d: got %s, want %v", i, CardUID(card), want)
+		}
+	}
+
+	names, err := cm.ContactNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.SortNamesByRelevance(names); err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range names {
+		if n.UID != want[i] {
+			t.Fatalf("SortNamesByRelevance order wrong at %d: got %s, want %v", i, n.UID, want)
+		}
+	}
+}
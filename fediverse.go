@@ -0,0 +1,235 @@
+package contacts
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// fediverseClient fetches WebFinger documents, actors and avatars.
+var fediverseClient = &http.Client{Timeout: 10 * time.Second}
+
+// webfingerScheme is the scheme WebFinger lookups use; tests serve plain
+// HTTP.
+var webfingerScheme = "https"
+
+// FediverseProfile is what a fediverse handle resolves to.
+type FediverseProfile struct {
+	// Handle is "user@host".
+	Handle     string `json:"handle"`
+	ProfileURL string `json:"profile_url,omitempty"`
+	AvatarURL  string `json:"avatar_url,omitempty"`
+}
+
+// ParseFediverseHandle splits a handle written as "@user@host",
+// "user@host" or "acct:user@host" into its user and host.
+func ParseFediverseHandle(s string) (user, host string, ok bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "acct:")
+	s = strings.TrimPrefix(s, "@")
+	user, host, ok = strings.Cut(s, "@")
+	if !ok || user == "" || host == "" || strings.ContainsAny(user+host, "@/ ") {
+		return "", "", false
+	}
+	return user, strings.ToLower(host), true
+}
+
+// FediverseHandles returns the card's fediverse handles, as "user@host":
+// those of its Mastodon profiles and of X-SOCIALPROFILE fields written as
+// a handle.
+func FediverseHandles(card vcard.Card) []string {
+	var handles []string
+	add := func(h string) {
+		if user, host, ok := ParseFediverseHandle(h); ok {
+			h = user + "@" + host
+			for _, have := range handles {
+				if strings.EqualFold(have, h) {
+					return
+				}
+			}
+			handles = append(handles, h)
+		}
+	}
+	for _, p := range SocialProfiles(card) {
+		if p.Service.Type == "mastodon" {
+			add(p.User)
+		}
+	}
+	for _, f := range card[FieldSocialProfile] {
+		if typ := f.Params.Get(vcard.ParamType); typ == "" || strings.EqualFold(typ, "mastodon") {
+			add(f.Value)
+		}
+	}
+	return handles
+}
+
+// ResolveFediverse looks up handle ("user@host") with WebFinger (RFC 7033)
+// and returns the profile page and avatar of the account it names. The
+// avatar comes from the WebFinger document if it links one, and otherwise
+// from the account's ActivityPub actor.
+func ResolveFediverse(handle string) (*FediverseProfile, error) {
+	user, host, ok := ParseFediverseHandle(handle)
+	if !ok {
+		return nil, fmt.Errorf("invalid fediverse handle %q", handle)
+	}
+	profile := &FediverseProfile{Handle: user + "@" + host}
+	query := url.Values{"resource": {"acct:" + profile.Handle}}
+	var finger struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Type string `json:"type"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	target := webfingerScheme + "://" + host + "/.well-known/webfinger?" + query.Encode()
+	if err := getFediverseJSON(target, "application/jrd+json", &finger); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", profile.Handle, err)
+	}
+	var actor string
+	for _, l := range finger.Links {
+		switch {
+		case l.Rel == "http://webfinger.net/rel/profile-page" && profile.ProfileURL == "":
+			profile.ProfileURL = l.Href
+		case l.Rel == "http://webfinger.net/rel/avatar" && profile.AvatarURL == "":
+			profile.AvatarURL = l.Href
+		case l.Rel == "self" && (strings.Contains(l.Type, "activity+json") || strings.Contains(l.Type, "ld+json")):
+			actor = l.Href
+		}
+	}
+	if actor != "" && (profile.AvatarURL == "" || profile.ProfileURL == "") {
+		var doc struct {
+			URL  json.RawMessage `json:"url"`
+			Icon json.RawMessage `json:"icon"`
+		}
+		if err := getFediverseJSON(actor, "application/activity+json", &doc); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s's profile: %w", profile.Handle, err)
+		}
+		if profile.ProfileURL == "" {
+			profile.ProfileURL = activityURL(doc.URL)
+		}
+		if profile.AvatarURL == "" {
+			profile.AvatarURL = activityURL(doc.Icon)
+		}
+	}
+	if profile.ProfileURL == "" && profile.AvatarURL == "" {
+		return nil, fmt.Errorf("%w: %s has no profile page or avatar", ErrNotFound, profile.Handle)
+	}
+	return profile, nil
+}
+
+// activityURL returns the URL an ActivityStreams property refers to, which
+// may be written as a string, a Link or Image object, or a list of those.
+func activityURL(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj struct {
+		URL  json.RawMessage `json:"url"`
+		Href string          `json:"href"`
+	}
+	if json.Unmarshal(raw, &obj) == nil {
+		if obj.Href != "" {
+			return obj.Href
+		}
+		if obj.URL != nil {
+			return activityURL(obj.URL)
+		}
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) == nil && len(list) > 0 {
+		return activityURL(list[0])
+	}
+	return ""
+}
+
+func getFediverseJSON(target, accept string, v any) error {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := fediverseClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// FillFromFediverse resolves the card's first fediverse handle and fills
+// in what the card lacks: the avatar as its PHOTO, prepared with opts, if
+// it has no photo, and the profile page as a URL. It returns the resolved
+// profile and whether the card changed.
+func FillFromFediverse(card vcard.Card, opts PhotoOptions) (*FediverseProfile, bool, error) {
+	handles := FediverseHandles(card)
+	if len(handles) == 0 {
+		return nil, false, fmt.Errorf("%w: %s has no fediverse handle", ErrNotFound, CardFullName(card))
+	}
+	profile, err := ResolveFediverse(handles[0])
+	if err != nil {
+		return nil, false, err
+	}
+	changed := false
+	if profile.ProfileURL != "" {
+		known := false
+		for _, name := range []string{vcard.FieldURL, FieldSocialProfile} {
+			for _, f := range card[name] {
+				known = known || socialKey(f.Value) == socialKey(profile.ProfileURL)
+			}
+		}
+		if !known {
+			card.AddValue(vcard.FieldURL, profile.ProfileURL)
+			DetectSocialProfiles(card)
+			changed = true
+		}
+	}
+	if profile.AvatarURL != "" && card.Value(vcard.FieldPhoto) == "" {
+		photo, err := avatarPhoto(profile.AvatarURL, opts)
+		if err != nil {
+			return profile, changed, fmt.Errorf("failed to fetch %s's avatar: %w", profile.Handle, err)
+		}
+		card.SetValue(vcard.FieldPhoto, photo)
+		changed = true
+	}
+	return profile, changed, nil
+}
+
+// avatarPhoto downloads an avatar and returns it as a PHOTO data: URI.
+func avatarPhoto(avatarURL string, opts PhotoOptions) (string, error) {
+	resp, err := fediverseClient.Get(avatarURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPhotoBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxPhotoBytes {
+		return "", fmt.Errorf("avatar is larger than %d bytes", maxPhotoBytes)
+	}
+	data, mime, err := PreparePhoto(data, opts)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
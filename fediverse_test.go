@@ -0,0 +1,133 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseFediverseHandle(t *testing.T) {
+	tests := []struct {
+		in, user, host string
+	}{
+		{"@ada@Mastodon.Social", "ada", "mastodon.social"},
+		{"ada@mastodon.social", "ada", "mastodon.social"},
+		{"acct:ada@mastodon.social", "ada", "mastodon.social"},
+		{"@ada", "", ""},
+		{"https://mastodon.social/@ada", "", ""},
+	}
+	for _, tt := range tests {
+		user, host, ok := ParseFediverseHandle(tt.in)
+		if ok != (tt.user != "") || user != tt.user || host != tt.host {
+			t.Errorf("ParseFediverseHandle(%q) = %q, %q, %v", tt.in, user, host, ok)
+		}
+	}
+}
+
+func TestFediverseHandles(t *testing.T) {
+	card := NewCard("Ada")
+	card.AddValue(vcard.FieldURL, "https://mastodon.social/@ada")
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "@ada@hachyderm.io"})
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "ada@example.com", Params: vcard.Params{vcard.ParamType: {"github"}}})
+	DetectSocialProfiles(card)
+	got := FediverseHandles(card)
+	if strings.Join(got, " ") != "ada@mastodon.social ada@hachyderm.io" {
+		t.Errorf("FediverseHandles = %v", got)
+	}
+}
+
+// fakeFediverse serves WebFinger, an ActivityPub actor and an avatar for
+// the account "ada".
+func fakeFediverse(t *testing.T) *httptest.Server {
+	t.Helper()
+	var avatar bytes.Buffer
+	png.Encode(&avatar, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/webfinger":
+			if r.URL.Query().Get("resource") != "acct:ada@"+r.Host {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"links": []map[string]string{
+				{"rel": "http://webfinger.net/rel/profile-page", "href": srv.URL + "/@ada"},
+				{"rel": "self", "type": "application/activity+json", "href": srv.URL + "/users/ada"},
+			}})
+		case "/users/ada":
+			if r.Header.Get("Accept") != "application/activity+json" {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"url":  srv.URL + "/@ada",
+				"icon": map[string]string{"type": "Image", "url": srv.URL + "/avatar.png"},
+			})
+		case "/avatar.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(avatar.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	old := webfingerScheme
+	webfingerScheme = "http"
+	t.Cleanup(func() { webfingerScheme = old })
+	return srv
+}
+
+func TestResolveFediverse(t *testing.T) {
+	srv := fakeFediverse(t)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	profile, err := ResolveFediverse("@ada@" + host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ProfileURL != srv.URL+"/@ada" || profile.AvatarURL != srv.URL+"/avatar.png" {
+		t.Errorf("ResolveFediverse = %+v", profile)
+	}
+	if _, err := ResolveFediverse("bob@" + host); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown account: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestFillFromFediverse(t *testing.T) {
+	srv := fakeFediverse(t)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	card := NewCard("Ada")
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "@ada@" + host})
+	_, changed, err := FillFromFediverse(card, PhotoOptions{Format: "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected the card to change")
+	}
+	if !strings.HasPrefix(card.Value(vcard.FieldPhoto), "data:image/png;base64,") {
+		t.Errorf("PHOTO = %.40q, want a data URI", card.Value(vcard.FieldPhoto))
+	}
+	if card.Value(vcard.FieldURL) != srv.URL+"/@ada" {
+		t.Errorf("URL = %q", card.Value(vcard.FieldURL))
+	}
+
+	// A second run finds nothing new, and an existing photo is kept.
+	card.SetValue(vcard.FieldPhoto, "https://example.com/ada.jpg")
+	if _, changed, err := FillFromFediverse(card, PhotoOptions{}); err != nil || changed {
+		t.Errorf("second run: changed=%v err=%v", changed, err)
+	}
+
+	if _, _, err := FillFromFediverse(NewCard("Nobody"), PhotoOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("no handle: got %v, want ErrNotFound", err)
+	}
+}
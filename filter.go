@@ -0,0 +1,218 @@
+package contacts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Filter reports whether a card belongs in a result set.
+type Filter func(vcard.Card) bool
+
+// FilterCards returns the cards that match every filter.
+func FilterCards(cards []vcard.Card, filters ...Filter) []vcard.Card {
+	if len(filters) == 0 {
+		return cards
+	}
+	match := AllOf(filters...)
+	var out []vcard.Card
+	for _, card := range cards {
+		if match(card) {
+			out = append(out, card)
+		}
+	}
+	return out
+}
+
+// AllOf returns a filter matching the cards that match every filter.
+func AllOf(filters ...Filter) Filter {
+	return func(card vcard.Card) bool {
+		for _, f := range filters {
+			if !f(card) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// fieldAliases maps friendly filter keys to vCard property names.
+var fieldAliases = map[string]string{
+	"name":         vcard.FieldFormattedName,
+	"nickname":     vcard.FieldNickname,
+	"phone":        vcard.FieldTelephone,
+	"tel":          vcard.FieldTelephone,
+	"email":        vcard.FieldEmail,
+	"org":          vcard.FieldOrganization,
+	"organization": vcard.FieldOrganization,
+	"title":        vcard.FieldTitle,
+	"address":      vcard.FieldAddress,
+	"adr":          vcard.FieldAddress,
+	"birthday":     vcard.FieldBirthday,
+	"bday":         vcard.FieldBirthday,
+	"anniversary":  vcard.FieldAnniversary,
+	"url":          vcard.FieldURL,
+	"note":         vcard.FieldNote,
+	"gender":       vcard.FieldGender,
+	"pronouns":     FieldPronouns,
+	"former":       FieldFormerName,
+	"uid":          vcard.FieldUID,
+	"im":           vcard.FieldIMPP,
+	"related":      vcard.FieldRelated,
+	"categories":   vcard.FieldCategories,
+	"kind":         vcard.FieldKind,
+	"photo":        vcard.FieldPhoto,
+	"social":       FieldSocialProfile,
+}
+
+// resolveFieldKey turns a filter key into a vCard property name. Unknown
+// keys are treated as raw property names, so "x-google-skill" works too.
+func resolveFieldKey(key string) string {
+	if f, ok := fieldAliases[strings.ToLower(key)]; ok {
+		return f
+	}
+	return strings.ToUpper(key)
+}
+
+// fieldCandidates returns the strings a field value can match against: the
+// raw value plus each of its structured (;-separated) components.
+func fieldCandidates(value string) []string {
+	candidates := []string{value}
+	if strings.Contains(value, ";") {
+		for _, part := range strings.Split(value, ";") {
+			if part != "" {
+				candidates = append(candidates, part)
+			}
+		}
+	}
+	return candidates
+}
+
+// ParseWhere parses a filter expression of the form:
+//
+//	key=value   some value of the field equals value (case-insensitive)
+//	key!=value  no value of the field equals value
+//	key~value   some value of the field contains value (case-insensitive)
+//	key         the field is present
+//	!key        the field is absent
+//
+// Keys are friendly names (name, email, phone, org, ...) or raw vCard
+// property names.
+func ParseWhere(expr string) (Filter, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+
+	var key, op, value string
+	switch {
+	case strings.Contains(expr, "!="):
+		key, value, _ = strings.Cut(expr, "!=")
+		op = "!="
+	case strings.Contains(expr, "="):
+		key, value, _ = strings.Cut(expr, "=")
+		op = "="
+	case strings.Contains(expr, "~"):
+		key, value, _ = strings.Cut(expr, "~")
+		op = "~"
+	case strings.HasPrefix(expr, "!"):
+		key = expr[1:]
+		op = "absent"
+	default:
+		key = expr
+		op = "present"
+	}
+	key = strings.TrimSpace(key)
+	value = strings.ToLower(strings.TrimSpace(value))
+	if key == "" {
+		return nil, fmt.Errorf("invalid filter expression %q: missing field", expr)
+	}
+	field := resolveFieldKey(key)
+
+	matchAny := func(card vcard.Card, match func(string) bool) bool {
+		for _, f := range card[field] {
+			for _, c := range fieldCandidates(f.Value) {
+				if match(strings.ToLower(c)) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch op {
+	case "=":
+		return func(card vcard.Card) bool {
+			return matchAny(card, func(s string) bool { return s == value })
+		}, nil
+	case "!=":
+		return func(card vcard.Card) bool {
+			return !matchAny(card, func(s string) bool { return s == value })
+		}, nil
+	case "~":
+		return func(card vcard.Card) bool {
+			return matchAny(card, func(s string) bool { return strings.Contains(s, value) })
+		}, nil
+	case "absent":
+		return func(card vcard.Card) bool { return len(card[field]) == 0 }, nil
+	default:
+		return func(card vcard.Card) bool { return len(card[field]) > 0 }, nil
+	}
+}
+
+// ParamGroupLabel holds a Google group's display name on an
+// X-GOOGLE-GROUP-MEMBERSHIP field, whose value is the group's resource
+// name.
+const ParamGroupLabel = "X-LABEL"
+
+// CardGroups returns the groups a card belongs to: Google contact group
+// memberships and vCard CATEGORIES. Starred and blocked contacts are in
+// Google's starred and blocked groups even when only flagged locally.
+func CardGroups(card vcard.Card) []string {
+	var groups []string
+	for _, f := range card["X-GOOGLE-GROUP-MEMBERSHIP"] {
+		groups = append(groups, f.Value)
+	}
+	for _, system := range []struct {
+		group string
+		field string
+	}{{googleStarred, FieldStarred}, {googleBlocked, FieldBlocked}} {
+		if strings.EqualFold(card.Value(system.field), "true") && !slices.Contains(groups, system.group) {
+			groups = append(groups, system.group)
+		}
+	}
+	for _, f := range card[vcard.FieldCategories] {
+		for _, c := range strings.Split(f.Value, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				groups = append(groups, c)
+			}
+		}
+	}
+	return groups
+}
+
+// InGroup matches cards that belong to the named group. Google group
+// resource names match either in full ("contactGroups/family"), by their
+// trailing ID ("family") or by the group's display name ("Family").
+func InGroup(name string) Filter {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return func(card vcard.Card) bool {
+		for _, g := range CardGroups(card) {
+			g = strings.ToLower(g)
+			if g == name {
+				return true
+			}
+			if i := strings.LastIndex(g, "/"); i >= 0 && g[i+1:] == name {
+				return true
+			}
+		}
+		for _, label := range CardGroupLabels(card) {
+			if strings.EqualFold(label, name) {
+				return true
+			}
+		}
+		return false
+	}
+}
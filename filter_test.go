@@ -0,0 +1,66 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseWhere(t *testing.T) {
+	card := NewCard("Alice Smith")
+	card.SetValue(vcard.FieldOrganization, "Acme Inc;Engineering")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "alice@example.com"})
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"name=alice smith", true},
+		{"name=bob", false},
+		{"org=Acme Inc", true},
+		{"org=engineering", true},
+		{"org!=Acme Inc", false},
+		{"email~@example.com", true},
+		{"email~@gmail.com", false},
+		{"email", true},
+		{"!email", false},
+		{"phone", false},
+		{"!phone", true},
+		{"x-google-skill", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseWhere(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f(card); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseWhere(""); err == nil {
+		t.Error("expected error for empty expression")
+	}
+	if _, err := ParseWhere("=foo"); err == nil {
+		t.Error("expected error for missing field")
+	}
+}
+
+func TestInGroup(t *testing.T) {
+	family := NewCard("Mom")
+	family.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/family"})
+	tagged := NewCard("Tagged")
+	tagged.SetValue(vcard.FieldCategories, "Friends, Family")
+	other := NewCard("Coworker")
+	other.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/myContacts"})
+
+	got := FilterCards([]vcard.Card{family, tagged, other}, InGroup("Family"))
+	if len(got) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(got))
+	}
+	if CardFullName(got[0]) != "Mom" || CardFullName(got[1]) != "Tagged" {
+		t.Errorf("unexpected matches: %q, %q", CardFullName(got[0]), CardFullName(got[1]))
+	}
+}
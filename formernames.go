@@ -0,0 +1,65 @@
+package contacts
+
+import (
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldFormerName holds a name a contact went by before, such as a maiden
+// name, as a full name. TYPE=maiden marks a maiden name.
+const FieldFormerName = "X-FORMER-NAME"
+
+// FormerNames returns the names a contact went by before: its
+// X-FORMER-NAME values and any N entries after the first, which vCard
+// allows for additional names.
+func FormerNames(card vcard.Card) []string {
+	var names []string
+	for _, f := range card[FieldFormerName] {
+		if v := strings.TrimSpace(f.Value); v != "" {
+			names = append(names, v)
+		}
+	}
+	if ns := card[vcard.FieldName]; len(ns) > 1 {
+		for _, f := range ns[1:] {
+			if v := formatStructuredName(f.Value); v != "" {
+				names = append(names, v)
+			}
+		}
+	}
+	return names
+}
+
+// AddFormerName records name as one the contact went by before; maiden
+// marks it as a maiden name. The caller saves card.
+func AddFormerName(card vcard.Card, name string, maiden bool) {
+	f := &vcard.Field{Value: strings.TrimSpace(name), Params: vcard.Params{}}
+	if maiden {
+		f.Params.Set(vcard.ParamType, "maiden")
+	}
+	card.Add(FieldFormerName, f)
+}
+
+// formatStructuredName renders an N value as "Prefix Given Additional
+// Family Suffix".
+func formatStructuredName(value string) string {
+	parts := structuredParts(value, 5)
+	var words []string
+	for _, i := range []int{3, 1, 2, 0, 4} {
+		if p := strings.TrimSpace(parts[i]); p != "" {
+			words = append(words, p)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// hasFormerName reports whether name is one of card's former names,
+// case-insensitively.
+func hasFormerName(card vcard.Card, name string) bool {
+	for _, former := range FormerNames(card) {
+		if strings.EqualFold(former, name) {
+			return true
+		}
+	}
+	return false
+}
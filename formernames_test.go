@@ -0,0 +1,57 @@
+package contacts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestFormerNames(t *testing.T) {
+	card := NewCard("Ada King")
+	AddFormerName(card, "Ada Byron", true)
+	card.Add(vcard.FieldName, &vcard.Field{Value: "King;Ada;;;"})
+	card.Add(vcard.FieldName, &vcard.Field{Value: "Byron;Augusta;Ada;;"})
+	got := strings.Join(FormerNames(card), ",")
+	if want := "Ada Byron,Augusta Ada Byron"; got != want {
+		t.Errorf("FormerNames = %s, want %s", got, want)
+	}
+	if card[FieldFormerName][0].Params.Get(vcard.ParamType) != "maiden" {
+		t.Error("maiden name not marked TYPE=maiden")
+	}
+	if !strings.Contains(FormatCard(card), "Formerly:  Ada Byron, Augusta Ada Byron") {
+		t.Errorf("FormatCard does not show former names:\n%s", FormatCard(card))
+	}
+}
+
+func TestContactManager_ResolveContactFormerName(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada King")
+	AddFormerName(ada, "Ada Byron", true)
+	// Someone currently named like Ada's former name wins over her.
+	other := NewCard("Grace Hopper")
+	AddFormerName(other, "Ada King", false)
+	for _, card := range []vcard.Card{ada, other} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := cm.ResolveContact("ada byron")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CardUID(got) != CardUID(ada) {
+		t.Errorf("resolved %q, want Ada King", CardFullName(got))
+	}
+	if got, err = cm.ResolveContact("Ada King"); err != nil || CardUID(got) != CardUID(ada) {
+		t.Errorf("current name resolved to %v, %v; want Ada King", CardFullName(got), err)
+	}
+	if _, err := cm.ResolveContact("Ada Lovelace"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown name: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,54 @@
+package contacts
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/emersion/go-vcard"
+)
+
+// GrepLine is one field value that matched a Grep pattern.
+type GrepLine struct {
+	Field string
+	Value string
+	// Matches are the [start, end) byte offsets of each match in Value.
+	Matches [][]int
+}
+
+// GrepMatch is a contact with at least one matching field value.
+type GrepMatch struct {
+	Card  vcard.Card
+	Lines []GrepLine
+}
+
+// Grep searches the raw values of every field, including NOTEs and X-
+// extensions, for re. If fields is non-empty only those fields (by vCard
+// name or alias such as "note") are searched. Lines are ordered by field name.
+func Grep(cards []vcard.Card, re *regexp.Regexp, fields ...string) []GrepMatch {
+	only := map[string]bool{}
+	for _, f := range fields {
+		only[resolveFieldKey(f)] = true
+	}
+	var out []GrepMatch
+	for _, card := range cards {
+		keys := make([]string, 0, len(card))
+		for key := range card {
+			if len(only) == 0 || only[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		var lines []GrepLine
+		for _, key := range keys {
+			for _, f := range card[key] {
+				if m := re.FindAllStringIndex(f.Value, -1); m != nil {
+					lines = append(lines, GrepLine{Field: key, Value: f.Value, Matches: m})
+				}
+			}
+		}
+		if len(lines) > 0 {
+			out = append(out, GrepMatch{Card: card, Lines: lines})
+		}
+	}
+	return out
+}
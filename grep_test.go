@@ -0,0 +1,36 @@
+package contacts
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestGrep(t *testing.T) {
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldNote, "Met at the Analytical Society; loves engines")
+	ada.SetValue("X-TWITTER", "@ada_engines")
+	alan := NewCard("Alan Turing")
+	alan.SetValue(vcard.FieldNote, "codebreaker")
+	cards := []vcard.Card{ada, alan}
+
+	got := Grep(cards, regexp.MustCompile(`(?i)engines?`))
+	if len(got) != 1 || CardUID(got[0].Card) != CardUID(ada) {
+		t.Fatalf("Grep matched %d contacts, want Ada only", len(got))
+	}
+	lines := got[0].Lines
+	if len(lines) != 2 || lines[0].Field != vcard.FieldNote || lines[1].Field != "X-TWITTER" {
+		t.Fatalf("lines = %+v, want NOTE then X-TWITTER", lines)
+	}
+	if m := lines[0].Matches; len(m) != 1 || lines[0].Value[m[0][0]:m[0][1]] != "engines" {
+		t.Errorf("NOTE matches = %v", m)
+	}
+
+	if got := Grep(cards, regexp.MustCompile(`engines`), "note"); len(got) != 1 || len(got[0].Lines) != 1 {
+		t.Errorf("Grep restricted to note = %+v", got)
+	}
+	if got := Grep(cards, regexp.MustCompile(`^Alan`), "email"); len(got) != 0 {
+		t.Errorf("Grep on email matched %d contacts", len(got))
+	}
+}
@@ -0,0 +1,118 @@
+package contacts
+
+import (
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// NewGroupCard creates a KIND:group card. Groups are local to the store:
+// WriteContact does not push them to the provider.
+func NewGroupCard(name string) vcard.Card {
+	card := NewCard(name)
+	card.SetKind(vcard.KindGroup)
+	return card
+}
+
+// GroupMemberUIDs returns the UIDs in a group card's MEMBER fields.
+func GroupMemberUIDs(group vcard.Card) []string {
+	var uids []string
+	for _, f := range group[vcard.FieldMember] {
+		uids = append(uids, uidFromURI(f.Value))
+	}
+	return uids
+}
+
+// AddMember adds member to group. It reports false if member was already in
+// the group. The caller saves group.
+func AddMember(group, member vcard.Card) bool {
+	uid := CardUID(member)
+	for _, u := range GroupMemberUIDs(group) {
+		if u == uid {
+			return false
+		}
+	}
+	group.Add(vcard.FieldMember, &vcard.Field{Value: cardURI(member)})
+	return true
+}
+
+// RemoveMember removes the contact with uid from group. It reports false if
+// it was not a member. The caller saves group.
+func RemoveMember(group vcard.Card, uid string) bool {
+	fields := group[vcard.FieldMember]
+	for i, f := range fields {
+		if uidFromURI(f.Value) == uid {
+			fields = append(fields[:i], fields[i+1:]...)
+			if len(fields) == 0 {
+				delete(group, vcard.FieldMember)
+			} else {
+				group[vcard.FieldMember] = fields
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// FindGroup returns the KIND:group card named name (case-insensitively), or
+// nil if there is none.
+func (cm *ContactManager) FindGroup(name string) (vcard.Card, error) {
+	return cm.findKind(vcard.KindGroup, name)
+}
+
+// Groups returns the store's KIND:group cards.
+func (cm *ContactManager) Groups() ([]vcard.Card, error) {
+	list, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	return FilterCards(list, OfKind(vcard.KindGroup)), nil
+}
+
+// GroupMembers expands a group card into its member cards. Members that no
+// longer exist are skipped.
+func (cm *ContactManager) GroupMembers(group vcard.Card) ([]vcard.Card, error) {
+	var out []vcard.Card
+	for _, uid := range GroupMemberUIDs(group) {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			return nil, err
+		}
+		if card != nil {
+			out = append(out, card)
+		}
+	}
+	return out, nil
+}
+
+// GroupFilter matches the members of the local group named name as well as
+// cards in a provider group or category of that name (see InGroup).
+func (cm *ContactManager) GroupFilter(name string) (Filter, error) {
+	inGroup := InGroup(name)
+	group, err := cm.FindGroup(name)
+	if err != nil || group == nil {
+		return inGroup, err
+	}
+	members := map[string]bool{}
+	for _, uid := range GroupMemberUIDs(group) {
+		members[uid] = true
+	}
+	return func(card vcard.Card) bool {
+		return members[CardUID(card)] || inGroup(card)
+	}, nil
+}
+
+// findKind returns the card of the given kind named name
+// (case-insensitively), or nil if there is none.
+func (cm *ContactManager) findKind(kind vcard.Kind, name string) (vcard.Card, error) {
+	list, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	for _, card := range FilterCards(list, OfKind(kind)) {
+		if strings.EqualFold(CardFullName(card), name) {
+			return card, nil
+		}
+	}
+	return nil, nil
+}
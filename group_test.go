@@ -0,0 +1,107 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+type recordingProvider struct {
+	mockProvider
+	written []string
+}
+
+func (p *recordingProvider) WriteContact(c vcard.Card) error {
+	p.written = append(p.written, CardUID(c))
+	return nil
+}
+
+func TestAddRemoveMember(t *testing.T) {
+	group := NewGroupCard("Book Club")
+	ada := NewCard("Ada Lovelace")
+	alan := NewCard("Alan Turing")
+
+	if !AddMember(group, ada) || !AddMember(group, alan) {
+		t.Fatal("AddMember reported an existing member")
+	}
+	if AddMember(group, ada) {
+		t.Error("AddMember added a duplicate")
+	}
+	if got := group.Values(vcard.FieldMember); len(got) != 2 || got[0] != "urn:uuid:"+CardUID(ada) {
+		t.Errorf("MEMBER = %v", got)
+	}
+	if !RemoveMember(group, CardUID(ada)) {
+		t.Error("RemoveMember did not find a member")
+	}
+	if RemoveMember(group, CardUID(ada)) {
+		t.Error("RemoveMember removed a non-member")
+	}
+	if uids := GroupMemberUIDs(group); len(uids) != 1 || uids[0] != CardUID(alan) {
+		t.Errorf("GroupMemberUIDs = %v, want [%s]", uids, CardUID(alan))
+	}
+	RemoveMember(group, CardUID(alan))
+	if _, ok := group[vcard.FieldMember]; ok {
+		t.Error("empty MEMBER property left behind")
+	}
+}
+
+func TestContactManager_Groups(t *testing.T) {
+	provider := &recordingProvider{}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada Lovelace")
+	alan := NewCard("Alan Turing")
+	grace := NewCard("Grace Hopper")
+	grace.SetValue(vcard.FieldCategories, "book club")
+	group := NewGroupCard("Book Club")
+	AddMember(group, ada)
+	AddMember(group, NewCard("Deleted Person"))
+	for _, card := range []vcard.Card{ada, alan, grace, group} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, uid := range provider.written {
+		if uid == CardUID(group) {
+			t.Error("group card was pushed to the provider")
+		}
+	}
+
+	found, err := cm.FindGroup("book club")
+	if err != nil || found == nil {
+		t.Fatalf("FindGroup = %v, %v", found, err)
+	}
+	members, err := cm.GroupMembers(found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || CardUID(members[0]) != CardUID(ada) {
+		t.Errorf("GroupMembers = %d cards, want only Ada", len(members))
+	}
+
+	filter, err := cm.GroupFilter("Book Club")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for _, card := range FilterCards(list, filter) {
+		got[CardFullName(card)] = true
+	}
+	if len(got) != 2 || !got["Ada Lovelace"] || !got["Grace Hopper"] {
+		t.Errorf("GroupFilter matched %v, want Ada and Grace", got)
+	}
+
+	groups, err := cm.Groups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 {
+		t.Errorf("Groups() = %d cards, want 1", len(groups))
+	}
+}
@@ -0,0 +1,130 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// GroupNamer is implemented by providers whose contacts carry group
+// memberships as resource names, such as "contactGroups/3bb2a8b0f0d1", and
+// that can look up the groups' display names.
+type GroupNamer interface {
+	GroupNames() (map[string]string, error)
+}
+
+func (cm *ContactManager) groupLabelsPath() string {
+	return filepath.Join(cm.dir, "groups.json")
+}
+
+// GroupLabels returns the display names of the provider's contact groups by
+// resource name, as fetched by the last sync.
+func (cm *ContactManager) GroupLabels() (map[string]string, error) {
+	labels := map[string]string{}
+	data, err := os.ReadFile(cm.groupLabelsPath())
+	if os.IsNotExist(err) {
+		return labels, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read group labels: %w", err)
+	}
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse group labels: %w", err)
+	}
+	return labels, nil
+}
+
+// syncGroupLabels fetches the provider's group names and, when they differ
+// from the last sync's, saves them and relabels the stored memberships, so
+// a renamed group shows its new name on contacts that did not change.
+func (cm *ContactManager) syncGroupLabels(index map[string]indexEntry) error {
+	namer, ok := cm.provider.(GroupNamer)
+	if !ok {
+		return nil
+	}
+	labels, err := namer.GroupNames()
+	if err != nil {
+		return fmt.Errorf("failed to fetch group names: %w", err)
+	}
+	old, err := cm.GroupLabels()
+	if err != nil {
+		return err
+	}
+	if maps.Equal(old, labels) {
+		return nil
+	}
+	data, err := json.MarshalIndent(labels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal group labels: %w", err)
+	}
+	if err := writeFileAtomic(cm.groupLabelsPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write group labels: %w", err)
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		return err
+	}
+	for _, card := range list {
+		if LabelGroups(card, labels) {
+			if err := cm.writeCardFile(card, index, ActorSync); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LabelGroups sets ParamGroupLabel on card's group memberships from labels,
+// keyed by resource name. It reports whether any label changed.
+func LabelGroups(card vcard.Card, labels map[string]string) bool {
+	changed := false
+	for _, f := range card["X-GOOGLE-GROUP-MEMBERSHIP"] {
+		label, ok := labels[f.Value]
+		if !ok || label == "" || f.Params.Get(ParamGroupLabel) == label {
+			continue
+		}
+		if f.Params == nil {
+			f.Params = vcard.Params{}
+		}
+		f.Params.Set(ParamGroupLabel, label)
+		changed = true
+	}
+	return changed
+}
+
+// GroupLabel returns a display name for a group membership: its
+// ParamGroupLabel, or else the trailing ID of its resource name.
+func GroupLabel(f *vcard.Field) string {
+	if label := f.Params.Get(ParamGroupLabel); label != "" {
+		return label
+	}
+	if i := strings.LastIndex(f.Value, "/"); i >= 0 {
+		return f.Value[i+1:]
+	}
+	return f.Value
+}
+
+// CardGroupLabels returns the display names of the groups a card belongs
+// to: its labelled memberships and CATEGORIES.
+func CardGroupLabels(card vcard.Card) []string {
+	var labels []string
+	for _, f := range card["X-GOOGLE-GROUP-MEMBERSHIP"] {
+		if f.Value == googleStarred || f.Value == googleBlocked {
+			continue
+		}
+		labels = append(labels, GroupLabel(f))
+	}
+	for _, f := range card[vcard.FieldCategories] {
+		for _, c := range strings.Split(f.Value, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				labels = append(labels, c)
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,77 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+// groupProvider is a mockProvider that names its contact groups.
+type groupProvider struct {
+	mockProvider
+	names map[string]string
+}
+
+func (p *groupProvider) GroupNames() (map[string]string, error) { return p.names, nil }
+
+func TestCardGroupLabels(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/3bb2a8b0f0d1"})
+	card.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/friends"})
+	card.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/starred"})
+	card.SetValue(vcard.FieldCategories, "Chess, Poetry")
+
+	if !LabelGroups(card, map[string]string{"contactGroups/3bb2a8b0f0d1": "Family"}) {
+		t.Error("LabelGroups reported no change")
+	}
+	if LabelGroups(card, map[string]string{"contactGroups/3bb2a8b0f0d1": "Family"}) {
+		t.Error("LabelGroups reported a change for an unchanged label")
+	}
+	got := strings.Join(CardGroupLabels(card), ",")
+	if want := "Family,friends,Chess,Poetry"; got != want {
+		t.Errorf("CardGroupLabels = %s, want %s", got, want)
+	}
+	if !InGroup("family")(card) || !InGroup("3bb2a8b0f0d1")(card) {
+		t.Error("InGroup does not match the group by label and ID")
+	}
+	if !strings.Contains(FormatCard(card), "Groups:    Family, friends, Chess, Poetry") {
+		t.Errorf("FormatCard does not list groups:\n%s", FormatCard(card))
+	}
+}
+
+func TestContactManager_SyncGroupLabels(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/3bb2a8b0f0d1"})
+	provider := &groupProvider{
+		mockProvider: mockProvider{contacts: []vcard.Card{card}},
+		names:        map[string]string{"contactGroups/3bb2a8b0f0d1": "Family"},
+	}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := cm.GroupLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["contactGroups/3bb2a8b0f0d1"] != "Family" {
+		t.Errorf("GroupLabels = %v", labels)
+	}
+
+	// Renaming the group relabels the stored copy.
+	provider.names = map[string]string{"contactGroups/3bb2a8b0f0d1": "Relatives"}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels := CardGroupLabels(got); len(labels) != 1 || labels[0] != "Relatives" {
+		t.Errorf("labels after rename = %v, want [Relatives]", labels)
+	}
+}
@@ -0,0 +1,362 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Household is a set of people who live together and are written to as
+// one, such as on a holiday card envelope.
+type Household struct {
+	// Name is how the household is addressed. Households recorded without
+	// one are named after their members; see HouseholdName.
+	Name    string   `json:"name,omitempty"`
+	Members []string `json:"members"`
+	// Detected marks a household found by DetectHouseholds rather than
+	// recorded with AddToHousehold.
+	Detected bool `json:"detected,omitempty"`
+}
+
+// householdRelations are the RELATED types that put two people in one
+// household.
+var householdRelations = []string{"spouse", "sweetheart", "co-resident"}
+
+func (cm *ContactManager) householdsPath() string {
+	return filepath.Join(cm.dir, "households.json")
+}
+
+// Households returns the households recorded with AddToHousehold.
+func (cm *ContactManager) Households() ([]Household, error) {
+	var households []Household
+	data, err := os.ReadFile(cm.householdsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read households: %w", err)
+	}
+	if err := json.Unmarshal(data, &households); err != nil {
+		return nil, fmt.Errorf("failed to parse households: %w", err)
+	}
+	return households, nil
+}
+
+func (cm *ContactManager) saveHouseholds(households []Household) error {
+	if len(households) == 0 {
+		if err := os.Remove(cm.householdsPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove households: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(households, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal households: %w", err)
+	}
+	if err := writeFileAtomic(cm.householdsPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write households: %w", err)
+	}
+	return nil
+}
+
+// AddToHousehold records that the contacts with the given UIDs live
+// together. They join the household named name (case-insensitively), or
+// the household the first of them is already in when name is empty, and
+// leave any other recorded household. A household is created if needed.
+func (cm *ContactManager) AddToHousehold(name string, uids ...string) error {
+	if len(uids) == 0 {
+		return fmt.Errorf("a household needs at least one contact")
+	}
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, uid := range uids {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			return err
+		}
+		if card == nil {
+			return fmt.Errorf("%w: %s", ErrNotFound, uid)
+		}
+	}
+	households, err := cm.Households()
+	if err != nil {
+		return err
+	}
+	target := -1
+	for i, h := range households {
+		if (name != "" && strings.EqualFold(h.Name, name)) || (name == "" && slices.Contains(h.Members, uids[0])) {
+			target = i
+			break
+		}
+	}
+	if target < 0 {
+		households = append(households, Household{Name: name})
+		target = len(households) - 1
+	}
+	for i := range households {
+		if i != target {
+			households[i].Members = slices.DeleteFunc(households[i].Members, func(uid string) bool { return slices.Contains(uids, uid) })
+		}
+	}
+	for _, uid := range uids {
+		if !slices.Contains(households[target].Members, uid) {
+			households[target].Members = append(households[target].Members, uid)
+		}
+	}
+	return cm.saveHouseholds(slices.DeleteFunc(households, func(h Household) bool { return len(h.Members) == 0 }))
+}
+
+// RemoveFromHousehold removes a contact from its recorded household. An
+// emptied household is dropped.
+func (cm *ContactManager) RemoveFromHousehold(uid string) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	households, err := cm.Households()
+	if err != nil {
+		return err
+	}
+	found := false
+	for i, h := range households {
+		if j := slices.Index(h.Members, uid); j >= 0 {
+			households[i].Members = slices.Delete(h.Members, j, j+1)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s is not in a household", ErrNotFound, uid)
+	}
+	return cm.saveHouseholds(slices.DeleteFunc(households, func(h Household) bool { return len(h.Members) == 0 }))
+}
+
+// AllHouseholds returns the recorded households followed by those
+// DetectHouseholds finds among the cards not in one. Unnamed households
+// are named after their members in cards.
+func (cm *ContactManager) AllHouseholds(cards []vcard.Card) ([]Household, error) {
+	recorded, err := cm.Households()
+	if err != nil {
+		return nil, err
+	}
+	byUID := make(map[string]vcard.Card, len(cards))
+	for _, card := range cards {
+		byUID[CardUID(card)] = card
+	}
+	var out []Household
+	placed := map[string]bool{}
+	for _, h := range recorded {
+		var members []vcard.Card
+		for _, uid := range h.Members {
+			placed[uid] = true
+			if card, ok := byUID[uid]; ok {
+				members = append(members, card)
+			}
+		}
+		if h.Name == "" {
+			h.Name = HouseholdName(members)
+		}
+		out = append(out, h)
+	}
+	var rest []vcard.Card
+	for _, card := range cards {
+		if !placed[CardUID(card)] {
+			rest = append(rest, card)
+		}
+	}
+	return append(out, DetectHouseholds(rest)...), nil
+}
+
+// DetectHouseholds groups individuals who share a mailing address (see
+// MailingList) or are related as spouses, sweethearts or co-residents.
+// Only groups of two or more are returned, sorted by name.
+func DetectHouseholds(cards []vcard.Card) []Household {
+	parent := map[string]string{}
+	var find func(uid string) string
+	find = func(uid string) string {
+		if p, ok := parent[uid]; ok && p != uid {
+			parent[uid] = find(p)
+			return parent[uid]
+		}
+		return uid
+	}
+	union := func(a, b string) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	byUID := map[string]vcard.Card{}
+	byAddress := map[string]string{}
+	for _, card := range cards {
+		if card.Kind() != vcard.KindIndividual {
+			continue
+		}
+		uid := CardUID(card)
+		byUID[uid] = card
+		parent[uid] = uid
+	}
+	for uid, card := range byUID {
+		if f := mailingField(card); f != nil {
+			key := addressKey(f.Value)
+			if other, ok := byAddress[key]; ok {
+				union(other, uid)
+			} else {
+				byAddress[key] = uid
+			}
+		}
+		for _, f := range card[vcard.FieldRelated] {
+			related := uidFromURI(f.Value)
+			if _, ok := byUID[related]; !ok {
+				continue
+			}
+			if slices.ContainsFunc(householdRelations, func(t string) bool { return f.Params.HasType(t) }) {
+				union(uid, related)
+			}
+		}
+	}
+
+	groups := map[string][]vcard.Card{}
+	for _, card := range cards {
+		if _, ok := byUID[CardUID(card)]; ok {
+			root := find(CardUID(card))
+			groups[root] = append(groups[root], card)
+		}
+	}
+	var out []Household
+	for _, members := range groups {
+		if len(members) < 2 {
+			continue
+		}
+		h := Household{Name: HouseholdName(members), Detected: true}
+		for _, card := range members {
+			h.Members = append(h.Members, CardUID(card))
+		}
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// addressKey normalizes an ADR value so that the same address written
+// with different case, spacing or punctuation compares equal.
+func addressKey(value string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// HouseholdName names a household after its members: "The Smith Family"
+// when they share a family name, else their names joined, as in "Ada
+// Lovelace & Charles Babbage".
+func HouseholdName(members []vcard.Card) string {
+	var names []string
+	family := ""
+	shared := true
+	for _, card := range members {
+		names = append(names, CardFullName(card))
+		f := familyName(card)
+		if f == "" || (family != "" && !strings.EqualFold(f, family)) {
+			shared = false
+		}
+		if family == "" {
+			family = f
+		}
+	}
+	switch {
+	case len(names) == 0:
+		return ""
+	case len(names) > 1 && shared:
+		return "The " + family + " Family"
+	case len(names) == 1:
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " & " + names[len(names)-1]
+}
+
+// familyName returns the family name from N, or else the last word of FN.
+func familyName(card vcard.Card) string {
+	if n := strings.TrimSpace(structuredParts(card.Value(vcard.FieldName), 5)[0]); n != "" {
+		return n
+	}
+	if words := strings.Fields(CardFullName(card)); len(words) > 1 {
+		return words[len(words)-1]
+	}
+	return ""
+}
+
+// MailingListByHousehold is MailingList with one entry per household:
+// members of a household, when two or more of them are in cards, share an
+// entry addressed to the household's name, with the first complete
+// address among them.
+func MailingListByHousehold(cards []vcard.Card, households []Household) []MailingEntry {
+	present := map[string]bool{}
+	for _, card := range cards {
+		present[CardUID(card)] = true
+	}
+	household := map[string]int{}
+	for i, h := range households {
+		count := 0
+		for _, uid := range h.Members {
+			if present[uid] {
+				count++
+			}
+		}
+		if count < 2 {
+			continue
+		}
+		for _, uid := range h.Members {
+			household[uid] = i
+		}
+	}
+
+	var out []MailingEntry
+	pos := map[int]int{}
+	for _, entry := range MailingList(cards) {
+		h, ok := household[CardUID(entry.Card)]
+		if !ok {
+			out = append(out, entry)
+			continue
+		}
+		entry.Address.Name = households[h].Name
+		if i, seen := pos[h]; seen {
+			if len(out[i].Problems) > 0 && len(entry.Problems) == 0 {
+				out[i] = entry
+			}
+			continue
+		}
+		pos[h] = len(out)
+		out = append(out, entry)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Address.Name < out[j].Address.Name })
+	return out
+}
+
+// renameHousehold points household membership of oldUID at newUID.
+func (cm *ContactManager) renameHousehold(oldUID, newUID string) error {
+	households, err := cm.Households()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, h := range households {
+		if i := slices.Index(h.Members, oldUID); i >= 0 {
+			h.Members[i] = newUID
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return cm.saveHouseholds(households)
+}
@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestDetectHouseholds(t *testing.T) {
+	john := NewCard("John Smith")
+	john.SetValue(vcard.FieldAddress, ";;10 Elm St;Springfield;IL;62701;USA")
+	jane := NewCard("Jane Smith")
+	jane.SetValue(vcard.FieldAddress, ";;10 elm st.;Springfield;IL;62701;USA")
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldAddress, ";;1 Work St;London;;EC1;UK")
+	charles := NewCard("Charles Babbage")
+	charles.Add(vcard.FieldRelated, &vcard.Field{Value: cardURI(ada), Params: vcard.Params{vcard.ParamType: {"spouse"}}})
+	bob := NewCard("Bob")
+	bob.SetValue(vcard.FieldAddress, ";;5 Main St;Springfield;IL;;")
+
+	households := DetectHouseholds([]vcard.Card{john, jane, ada, charles, bob})
+	if len(households) != 2 {
+		t.Fatalf("households = %+v, want 2", households)
+	}
+	if h := households[0]; h.Name != "Ada Lovelace & Charles Babbage" || len(h.Members) != 2 || !h.Detected {
+		t.Errorf("households[0] = %+v", h)
+	}
+	if h := households[1]; h.Name != "The Smith Family" || len(h.Members) != 2 {
+		t.Errorf("households[1] = %+v", h)
+	}
+}
+
+func TestContactManager_Households(t *testing.T) {
+	cm, err := NewContactManager(&mockProvider{}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	john := NewCard("John Smith")
+	john.SetValue(vcard.FieldAddress, ";;10 Elm St;Springfield;IL;62701;USA")
+	jane := NewCard("Jane Doe")
+	sam := NewCard("Sam Smith")
+	sam.SetValue(vcard.FieldAddress, ";;10 Elm St;Springfield;IL;62701;USA")
+	for _, card := range []vcard.Card{john, jane, sam} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := cm.AddToHousehold("The Smith-Doe Family", CardUID(john), CardUID(jane)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.AddToHousehold("", "missing"); err == nil {
+		t.Error("AddToHousehold accepted an unknown contact")
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	households, err := cm.AllHouseholds(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// John's recorded household wins over the address he shares with Sam.
+	if len(households) != 1 || households[0].Name != "The Smith-Doe Family" || households[0].Detected {
+		t.Fatalf("households = %+v", households)
+	}
+
+	entries := MailingListByHousehold(list, households)
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want 2", entries)
+	}
+	if a := entries[0].Address; a.Name != "Sam Smith" {
+		t.Errorf("entries[0] = %+v", a)
+	}
+	if e := entries[1]; e.Address.Name != "The Smith-Doe Family" || e.Address.Street != "10 Elm St" || len(e.Problems) != 0 {
+		t.Errorf("entries[1] = %+v, want the household at John's address", e)
+	}
+
+	if err := cm.RenameUID(CardUID(jane), "jane-doe"); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := cm.Households()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 1 || recorded[0].Members[1] != "jane-doe" {
+		t.Errorf("households after rename = %+v", recorded)
+	}
+
+	if err := cm.RemoveFromHousehold(CardUID(john)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.RemoveFromHousehold(CardUID(john)); err == nil {
+		t.Error("RemoveFromHousehold succeeded for a contact in no household")
+	}
+}
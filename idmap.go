@@ -0,0 +1,170 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// IDMap maps each provider's IDs for contacts to local UIDs, so the same
+// contact synced from several providers can be kept as one local card. It
+// is keyed by provider name and then provider ID.
+type IDMap map[string]map[string]string
+
+// Lookup returns the local UID a provider's ID is mapped to.
+func (m IDMap) Lookup(provider, id string) (string, bool) {
+	uid, ok := m[provider][id]
+	return uid, ok
+}
+
+// ID returns a provider's ID for the local contact uid.
+func (m IDMap) ID(provider, uid string) (string, bool) {
+	for id, mapped := range m[provider] {
+		if mapped == uid {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// IDs returns the ID of the local contact uid at each provider that has
+// it, keyed by provider name.
+func (m IDMap) IDs(uid string) map[string]string {
+	out := map[string]string{}
+	for provider := range m {
+		if id, ok := m.ID(provider, uid); ok {
+			out[provider] = id
+		}
+	}
+	return out
+}
+
+// Set maps a provider's ID to the local contact uid, replacing any other
+// ID the provider had for it.
+func (m IDMap) Set(provider, id, uid string) {
+	m.Unmap(provider, uid)
+	if m[provider] == nil {
+		m[provider] = map[string]string{}
+	}
+	m[provider][id] = uid
+}
+
+// Unmap forgets a provider's ID for the local contact uid.
+func (m IDMap) Unmap(provider, uid string) {
+	for id, mapped := range m[provider] {
+		if mapped == uid {
+			delete(m[provider], id)
+		}
+	}
+	if len(m[provider]) == 0 {
+		delete(m, provider)
+	}
+}
+
+// RenameUID points every mapping of oldUID at newUID.
+func (m IDMap) RenameUID(oldUID, newUID string) {
+	for _, ids := range m {
+		for id, uid := range ids {
+			if uid == oldUID {
+				ids[id] = newUID
+			}
+		}
+	}
+}
+
+// Providers returns the names of the providers with mappings, sorted.
+func (m IDMap) Providers() []string {
+	out := make([]string, 0, len(m))
+	for provider := range m {
+		out = append(out, provider)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IDMapStore loads and saves the manager's IDMap. The default stores it as
+// idmap.json in the data directory; WithIDMapStore replaces it, e.g. to
+// share one table between machines.
+type IDMapStore interface {
+	LoadIDMap() (IDMap, error)
+	SaveIDMap(IDMap) error
+}
+
+// WithIDMapStore keeps the ID map in store instead of idmap.json.
+func WithIDMapStore(store IDMapStore) ManagerOption {
+	return func(cm *ContactManager) { cm.idMaps = store }
+}
+
+// NamedProvider is a ContactProvider with a name, used to key its entries
+// in the ID map. Providers without one are mapped under "default".
+type NamedProvider interface {
+	ContactProvider
+	Name() string
+}
+
+func (cm *ContactManager) providerName() string {
+	if named, ok := cm.provider.(NamedProvider); ok {
+		return named.Name()
+	}
+	return "default"
+}
+
+// IDMap returns the table mapping provider IDs to local UIDs.
+func (cm *ContactManager) IDMap() (IDMap, error) {
+	if cm.idMaps != nil {
+		return cm.idMaps.LoadIDMap()
+	}
+	return fileIDMap{cm}.LoadIDMap()
+}
+
+func (cm *ContactManager) saveIDMap(m IDMap) error {
+	if cm.idMaps != nil {
+		return cm.idMaps.SaveIDMap(m)
+	}
+	return fileIDMap{cm}.SaveIDMap(m)
+}
+
+// updateIDMap loads the ID map, applies change and saves it.
+func (cm *ContactManager) updateIDMap(change func(IDMap)) error {
+	m, err := cm.IDMap()
+	if err != nil {
+		return err
+	}
+	change(m)
+	return cm.saveIDMap(m)
+}
+
+// fileIDMap is the default IDMapStore, idmap.json in the data directory.
+type fileIDMap struct{ cm *ContactManager }
+
+func (f fileIDMap) path() string {
+	return filepath.Join(f.cm.dir, "idmap.json")
+}
+
+func (f fileIDMap) LoadIDMap() (IDMap, error) {
+	m := IDMap{}
+	data, err := os.ReadFile(f.path())
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ID map: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse ID map: %w", err)
+	}
+	return m, nil
+}
+
+func (f fileIDMap) SaveIDMap(m IDMap) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ID map: %w", err)
+	}
+	if err := writeFileAtomic(f.path(), data, f.cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write ID map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestIDMap(t *testing.T) {
+	m := IDMap{}
+	m.Set("google", "c1", "ada")
+	m.Set("carddav", "ada.vcf", "ada")
+	m.Set("google", "c2", "ada")
+	if _, ok := m.Lookup("google", "c1"); ok {
+		t.Error("Set kept the provider's old ID for the contact")
+	}
+	if uid, ok := m.Lookup("google", "c2"); !ok || uid != "ada" {
+		t.Errorf("Lookup(google, c2) = %q, %v", uid, ok)
+	}
+	if ids := m.IDs("ada"); len(ids) != 2 || ids["carddav"] != "ada.vcf" {
+		t.Errorf("IDs(ada) = %v", ids)
+	}
+
+	m.RenameUID("ada", "lovelace")
+	if id, ok := m.ID("google", "lovelace"); !ok || id != "c2" {
+		t.Errorf("ID(google, lovelace) after rename = %q, %v", id, ok)
+	}
+	m.Unmap("google", "lovelace")
+	if got := m.Providers(); len(got) != 1 || got[0] != "carddav" {
+		t.Errorf("Providers() after Unmap = %v, want [carddav]", got)
+	}
+}
+
+// namedProvider is an incremental provider with a name.
+type namedProvider struct {
+	deletingProvider
+}
+
+func (p *namedProvider) Name() string { return "test" }
+
+// memoryIDMaps is an IDMapStore kept in memory.
+type memoryIDMaps struct{ m IDMap }
+
+func (s *memoryIDMaps) LoadIDMap() (IDMap, error) {
+	m := IDMap{}
+	for provider, ids := range s.m {
+		for id, uid := range ids {
+			m.Set(provider, id, uid)
+		}
+	}
+	return m, nil
+}
+
+func (s *memoryIDMaps) SaveIDMap(m IDMap) error {
+	s.m = m
+	return nil
+}
+
+func TestContactManager_SyncUsesIDMap(t *testing.T) {
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldUID, "ada")
+	remote := NewCard("Ada King")
+	remote.SetValue(vcard.FieldUID, "remote-1")
+	other := NewCard("Charles Babbage")
+	other.SetValue(vcard.FieldUID, "remote-2")
+
+	dir := t.TempDir()
+	offline, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.WriteContact(local); err != nil {
+		t.Fatal(err)
+	}
+	provider := &namedProvider{}
+	store := &memoryIDMaps{m: IDMap{"test": {"remote-1": "ada"}}}
+	cm, err := NewContactManager(provider, dir, WithIDMapStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider.contacts = []vcard.Card{remote, other}
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 1 || result.Updated != 1 {
+		t.Errorf("result = %+v, want the mapped card updated and the other created", result)
+	}
+	if got, _ := cm.GetContact("ada"); CardFullName(got) != "Ada King" {
+		t.Errorf("ada = %q, want the remote update", CardFullName(got))
+	}
+	if got, _ := cm.GetContact("remote-1"); got != nil {
+		t.Error("the mapped card was also stored under its provider UID")
+	}
+	if uid, ok := store.m.Lookup("test", "remote-2"); !ok || uid != "remote-2" {
+		t.Errorf("new contact mapping = %q, %v", uid, ok)
+	}
+
+	provider.contacts, provider.deleted = nil, []string{"remote-1"}
+	if result, err = cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cm.GetContact("ada"); got != nil || result.Deleted != 1 {
+		t.Errorf("remote delete of remote-1 left ada (result %+v)", result)
+	}
+	if _, ok := store.m.Lookup("test", "remote-1"); ok {
+		t.Error("mapping of a deleted contact was kept")
+	}
+}
@@ -0,0 +1,161 @@
+package contacts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+// PhotoOptions controls how PreparePhoto converts images.
+type PhotoOptions struct {
+	// MaxSize is the largest width or height in pixels. Zero uses
+	// DefaultPhotoSize.
+	MaxSize int `json:"max_size,omitempty"`
+	// Format is "jpeg" (the default) or "png", which keeps transparency.
+	Format string `json:"format,omitempty"`
+}
+
+// Validate checks the options' values.
+func (o PhotoOptions) Validate() error {
+	if o.MaxSize < 0 {
+		return fmt.Errorf("invalid photo max_size %d", o.MaxSize)
+	}
+	switch o.Format {
+	case "", "jpeg", "png":
+		return nil
+	}
+	return fmt.Errorf("invalid photo format %q: expected jpeg or png", o.Format)
+}
+
+// PreparePhoto decodes an image in any format DecodeImage supports, scales
+// it down to fit within opts.MaxSize and encodes it in opts.Format. It
+// returns the encoded image and its MIME type.
+func PreparePhoto(data []byte, opts PhotoOptions) ([]byte, string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, "", err
+	}
+	img, err := DecodeImage(data)
+	if err != nil {
+		return nil, "", err
+	}
+	size := opts.MaxSize
+	if size == 0 {
+		size = DefaultPhotoSize
+	}
+	img = FitImage(img, size)
+	var buf bytes.Buffer
+	if opts.Format == "png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode photo: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode photo: %w", err)
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// ErrUnsupportedImage is returned for images DecodeImage cannot read.
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+// DecodeImage decodes a JPEG, PNG or GIF image. WebP and HEIC images, such
+// as photos from Android and iPhones, are converted with an external tool
+// if one is installed: ImageMagick, dwebp (libwebp), heif-convert
+// (libheif) or sips (macOS).
+func DecodeImage(data []byte) (image.Image, error) {
+	format := imageFormat(data)
+	switch format {
+	case "jpeg", "png", "gif":
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode photo: %w", err)
+		}
+		return img, nil
+	case "webp", "heic":
+		return convertImage(data, format)
+	}
+	return nil, ErrUnsupportedImage
+}
+
+// imageFormat identifies an image format from its leading bytes.
+func imageFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return "gif"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		switch string(data[8:12]) {
+		case "heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1":
+			return "heic"
+		}
+	}
+	return ""
+}
+
+// imageConverter is an external command that converts an image file to
+// PNG.
+type imageConverter struct {
+	name    string
+	formats []string
+	args    func(in, out string) []string
+}
+
+// imageConverters are tried in order for formats the standard library
+// cannot decode.
+var imageConverters = []imageConverter{
+	{"magick", []string{"webp", "heic"}, func(in, out string) []string { return []string{in, out} }},
+	{"convert", []string{"webp", "heic"}, func(in, out string) []string { return []string{in, out} }},
+	{"dwebp", []string{"webp"}, func(in, out string) []string { return []string{in, "-png", "-o", out} }},
+	{"heif-convert", []string{"heic"}, func(in, out string) []string { return []string{in, out} }},
+	{"sips", []string{"webp", "heic"}, func(in, out string) []string { return []string{"-s", "format", "png", in, "--out", out} }},
+}
+
+// convertImage decodes a format the standard library cannot read by
+// converting it to PNG with the first installed imageConverter.
+func convertImage(data []byte, format string) (image.Image, error) {
+	dir, err := os.MkdirTemp("", "contacts-photo-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s photo: %w", format, err)
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "in."+format), filepath.Join(dir, "out.png")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to convert %s photo: %w", format, err)
+	}
+	for _, c := range imageConverters {
+		if !slices.Contains(c.formats, format) {
+			continue
+		}
+		path, err := exec.LookPath(c.name)
+		if err != nil {
+			continue
+		}
+		if output, err := exec.Command(path, c.args(in, out)...).CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to convert %s photo with %s: %w: %s", format, c.name, err, bytes.TrimSpace(output))
+		}
+		converted, err := os.ReadFile(out)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s photo with %s: %w", format, c.name, err)
+		}
+		img, err := png.Decode(bytes.NewReader(converted))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s photo converted by %s: %w", format, c.name, err)
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("%w: %s photos need ImageMagick, dwebp, heif-convert or sips to be installed", ErrUnsupportedImage, format)
+}
@@ -0,0 +1,78 @@
+package contacts
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPreparePhoto(t *testing.T) {
+	tests := []struct {
+		opts         PhotoOptions
+		mime, format string
+		wantW, wantH int
+	}{
+		{PhotoOptions{}, "image/jpeg", "jpeg", DefaultPhotoSize, DefaultPhotoSize / 2},
+		{PhotoOptions{MaxSize: 100, Format: "png"}, "image/png", "png", 100, 50},
+	}
+	for _, tt := range tests {
+		data, mime, err := PreparePhoto(testPNG(t, 1000, 500), tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil || mime != tt.mime || format != tt.format || cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+			t.Errorf("PreparePhoto(%+v) = %dx%d %s (%s), %v; want %dx%d %s", tt.opts, cfg.Width, cfg.Height, format, mime, err, tt.wantW, tt.wantH, tt.format)
+		}
+	}
+	if _, _, err := PreparePhoto(testPNG(t, 10, 10), PhotoOptions{Format: "bmp"}); err == nil {
+		t.Error("PreparePhoto with format bmp succeeded, want error")
+	}
+	if _, _, err := PreparePhoto([]byte("not an image"), PhotoOptions{}); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("PreparePhoto(garbage) = %v, want ErrUnsupportedImage", err)
+	}
+}
+
+func TestImageFormat(t *testing.T) {
+	tests := map[string]string{
+		"\xff\xd8\xff\xe0rest":         "jpeg",
+		"GIF89a":                       "gif",
+		"RIFF\x00\x00\x00\x00WEBPVP8 ": "webp",
+		"\x00\x00\x00\x18ftypheic\x00": "heic",
+		"\x00\x00\x00\x18ftypisom\x00": "",
+		"plain text":                   "",
+	}
+	for data, want := range tests {
+		if got := imageFormat([]byte(data)); got != want {
+			t.Errorf("imageFormat(%q) = %q, want %q", data, got, want)
+		}
+	}
+}
+
+func TestDecodeImage_Converter(t *testing.T) {
+	// Stand in for a real converter with cp, which "converts" any input by
+	// copying a prepared PNG to the output path.
+	png := filepath.Join(t.TempDir(), "converted.png")
+	if err := os.WriteFile(png, testPNG(t, 20, 10), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8 ")
+
+	saved := imageConverters
+	defer func() { imageConverters = saved }()
+	imageConverters = nil
+	if _, err := DecodeImage(webp); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("DecodeImage(webp) without converters = %v, want ErrUnsupportedImage", err)
+	}
+	imageConverters = []imageConverter{{"cp", []string{"webp"}, func(in, out string) []string { return []string{png, out} }}}
+	img, err := DecodeImage(webp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 10 {
+		t.Errorf("converted image is %dx%d, want 20x10", b.Dx(), b.Dy())
+	}
+}
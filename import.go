@@ -0,0 +1,243 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/emersion/go-vcard"
+	"github.com/google/uuid"
+)
+
+// ImportAction is what an import does with one incoming contact.
+type ImportAction string
+
+const (
+	ImportCreate ImportAction = "create"
+	// ImportMerge updates a stored contact with the incoming values.
+	ImportMerge ImportAction = "merge"
+	ImportSkip  ImportAction = "skip"
+)
+
+// ImportEntry is one incoming contact in an import preview or report.
+type ImportEntry struct {
+	UID    string       `json:"uid"`
+	Name   string       `json:"name"`
+	Action ImportAction `json:"action"`
+	// Reason says why a contact is skipped.
+	Reason string `json:"reason,omitempty"`
+	// Fields summarizes the changes a merge makes, as in PlannedChange.
+	Fields []string `json:"fields,omitempty"`
+	// Similar lists stored or earlier imported contacts a created contact
+	// may duplicate. They are left untouched; review them with dedupe.
+	Similar []ImportMatch `json:"similar,omitempty"`
+}
+
+// ImportMatch is a contact an imported one resembles, and why.
+type ImportMatch struct {
+	UID     string   `json:"uid"`
+	Name    string   `json:"name"`
+	Reasons []string `json:"reasons"`
+}
+
+// ImportFailure is an input record an importer could not parse.
+type ImportFailure struct {
+	// Record is the 1-based position of the card or row in the input.
+	Record int    `json:"record"`
+	Error  string `json:"error"`
+}
+
+// ImportReport says what an import did, or would do, with each incoming
+// contact, so a large import can be audited and its failures retried.
+type ImportReport struct {
+	Entries []ImportEntry   `json:"entries"`
+	Failed  []ImportFailure `json:"failed,omitempty"`
+}
+
+// Count returns the number of entries with the given action.
+func (r ImportReport) Count(action ImportAction) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// Ambiguous returns the created entries that resemble other contacts.
+func (r ImportReport) Ambiguous() []ImportEntry {
+	var out []ImportEntry
+	for _, e := range r.Entries {
+		if len(e.Similar) > 0 {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Importer imports contacts one at a time, so large inputs can be streamed
+// and checkpointed instead of held in memory. It keeps the contacts already
+// stored or imported to report possible duplicates.
+type Importer struct {
+	cm       *ContactManager
+	dryRun   bool
+	existing []vcard.Card
+}
+
+// NewImporter starts an import. With dryRun, Add only reports what it
+// would do.
+func (cm *ContactManager) NewImporter(dryRun bool) (*Importer, error) {
+	existing, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	return &Importer{cm: cm, dryRun: dryRun, existing: existing}, nil
+}
+
+// Add imports card and returns what happened to it: a card whose UID is
+// not stored is created, one that differs from the stored copy is merged
+// into it, and one that matches it or fails ValidateCard is skipped.
+// Created cards list the contacts they resemble.
+func (im *Importer) Add(card vcard.Card) (ImportEntry, error) {
+	entry := ImportEntry{UID: CardUID(card), Name: CardFullName(card)}
+	DetectSocialProfiles(card)
+	// Check a copy prepared the way WriteContact prepares it.
+	prepared := Merge(card, vcard.Card{}, StrategyUnion)
+	if entry.UID == "" {
+		prepared.SetValue(vcard.FieldUID, uuid.New().String())
+	}
+	normalizeUID(prepared)
+	if err := ValidateCard(prepared); err != nil {
+		entry.Action, entry.Reason = ImportSkip, err.Error()
+		return entry, nil
+	}
+	stored, err := im.cm.GetContact(CardUID(prepared))
+	if err != nil {
+		return entry, err
+	}
+	switch {
+	case stored == nil:
+		entry.Action = ImportCreate
+		for _, s := range FindSimilar(prepared, im.existing) {
+			entry.Similar = append(entry.Similar, ImportMatch{UID: CardUID(s.Card), Name: CardFullName(s.Card), Reasons: s.Reasons})
+		}
+		im.existing = append(im.existing, prepared)
+	default:
+		entry.Fields = changedFields(stored, prepared)
+		entry.Action = ImportMerge
+		if len(entry.Fields) == 0 {
+			entry.Action, entry.Reason = ImportSkip, "unchanged"
+		}
+	}
+	if im.dryRun || entry.Action == ImportSkip {
+		return entry, nil
+	}
+	card.SetValue(vcard.FieldUID, CardUID(prepared))
+	if err := im.cm.WriteContact(card); err != nil {
+		return entry, err
+	}
+	entry.UID = CardUID(card)
+	return entry, nil
+}
+
+// PlanImport reports what Import would do with cards without writing
+// anything.
+func (cm *ContactManager) PlanImport(cards []vcard.Card) ([]ImportEntry, error) {
+	return cm.importCards(cards, true)
+}
+
+// Import writes cards like WriteContacts and reports what happened to
+// each. Unlike WriteContacts it skips invalid and unchanged cards instead
+// of failing or rewriting them; see Importer.Add.
+func (cm *ContactManager) Import(cards []vcard.Card) (ImportReport, error) {
+	entries, err := cm.importCards(cards, false)
+	return ImportReport{Entries: entries}, err
+}
+
+func (cm *ContactManager) importCards(cards []vcard.Card, dryRun bool) ([]ImportEntry, error) {
+	im, err := cm.NewImporter(dryRun)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]ImportEntry, 0, len(cards))
+	for _, card := range cards {
+		entry, err := im.Add(card)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ImportCheckpoint records how far an import of a file got, so that an
+// interrupted import can be resumed rather than started over.
+type ImportCheckpoint struct {
+	Source  string    `json:"source"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	// Records counts the input records already handled.
+	Records int          `json:"records"`
+	Report  ImportReport `json:"report"`
+}
+
+// NewImportCheckpoint starts a checkpoint for importing the file at path.
+func NewImportCheckpoint(path string) (ImportCheckpoint, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ImportCheckpoint{}, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return ImportCheckpoint{}, err
+	}
+	return ImportCheckpoint{Source: abs, Size: info.Size(), ModTime: info.ModTime().UTC()}, nil
+}
+
+// SameSource reports whether c and other are for the same, unchanged file.
+func (c ImportCheckpoint) SameSource(other ImportCheckpoint) bool {
+	return c.Source == other.Source && c.Size == other.Size && c.ModTime.Equal(other.ModTime)
+}
+
+func (cm *ContactManager) importCheckpointPath() string {
+	return filepath.Join(cm.dir, "import.json")
+}
+
+// ImportCheckpoint returns the checkpoint of an unfinished import, or nil.
+func (cm *ContactManager) ImportCheckpoint() (*ImportCheckpoint, error) {
+	data, err := os.ReadFile(cm.importCheckpointPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import checkpoint: %w", err)
+	}
+	var c ImportCheckpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse import checkpoint: %w", err)
+	}
+	return &c, nil
+}
+
+// SaveImportCheckpoint records the progress of an import.
+func (cm *ContactManager) SaveImportCheckpoint(c ImportCheckpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal import checkpoint: %w", err)
+	}
+	if err := writeFileAtomic(cm.importCheckpointPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write import checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ClearImportCheckpoint removes the checkpoint of a finished import.
+func (cm *ContactManager) ClearImportCheckpoint() error {
+	if err := os.Remove(cm.importCheckpointPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove import checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_PlanImport(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	same := NewCard("Ada Lovelace")
+	changed := NewCard("Alan Turing")
+	for _, card := range []vcard.Card{same, changed} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changed = Merge(changed, vcard.Card{}, StrategyUnion)
+	changed.SetValue(vcard.FieldEmail, "alan@example.com")
+	fresh := NewCard("Grace Hopper")
+	fresh.SetValue(vcard.FieldUID, "")
+	invalid := NewCard("")
+
+	entries, err := cm.PlanImport([]vcard.Card{same, changed, fresh, invalid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportAction{ImportSkip, ImportMerge, ImportCreate, ImportSkip}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Action != want[i] {
+			t.Errorf("entry %d (%s) action = %s, want %s", i, e.Name, e.Action, want[i])
+		}
+	}
+	if entries[0].Reason != "unchanged" || entries[3].Reason == "" {
+		t.Errorf("skip reasons = %q, %q", entries[0].Reason, entries[3].Reason)
+	}
+	if len(entries[1].Fields) != 1 || entries[1].Fields[0] != "+EMAIL" {
+		t.Errorf("merge fields = %v, want [+EMAIL]", entries[1].Fields)
+	}
+	if fresh.Value(vcard.FieldUID) != "" {
+		t.Error("PlanImport modified its input")
+	}
+	if list, _ := cm.ListContacts(); len(list) != 2 {
+		t.Errorf("store has %d contacts after planning, want 2", len(list))
+	}
+}
+
+func TestContactManager_Import(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldEmail, "ada@example.com")
+	if err := cm.WriteContact(ada); err != nil {
+		t.Fatal(err)
+	}
+	dup := NewCard("Ada King")
+	dup.SetValue(vcard.FieldEmail, "ada@example.com")
+	alan := NewCard("Alan Turing")
+
+	report, err := cm.Import([]vcard.Card{dup, alan, NewCard("")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, s := report.Count(ImportCreate), report.Count(ImportSkip); c != 2 || s != 1 {
+		t.Errorf("created %d, skipped %d; want 2 and 1", c, s)
+	}
+	ambiguous := report.Ambiguous()
+	if len(ambiguous) != 1 || ambiguous[0].Name != "Ada King" || ambiguous[0].Similar[0].UID != CardUID(ada) {
+		t.Errorf("ambiguous = %+v, want Ada King resembling the stored Ada", ambiguous)
+	}
+	if list, _ := cm.ListContacts(); len(list) != 3 {
+		t.Errorf("store has %d contacts, want 3", len(list))
+	}
+	if stored, _ := cm.GetContact(CardUID(ada)); CardFullName(stored) != "Ada Lovelace" {
+		t.Error("the similar stored contact was changed")
+	}
+}
+
+func TestContactManager_ImportCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	cm, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, err := cm.ImportCheckpoint(); err != nil || c != nil {
+		t.Fatalf("ImportCheckpoint() = %v, %v; want nil, nil", c, err)
+	}
+	path := filepath.Join(dir, "export.vcf")
+	if err := os.WriteFile(path, []byte("BEGIN:VCARD\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewImportCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Records = 200
+	c.Report.Entries = []ImportEntry{{UID: "a", Name: "Ada", Action: ImportCreate}}
+	if err := cm.SaveImportCheckpoint(c); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := cm.ImportCheckpoint()
+	if err != nil || saved == nil {
+		t.Fatalf("ImportCheckpoint() = %v, %v", saved, err)
+	}
+	if !saved.SameSource(c) || saved.Records != 200 || len(saved.Report.Entries) != 1 {
+		t.Errorf("saved checkpoint = %+v, want %+v", saved, c)
+	}
+	if err := os.WriteFile(path, []byte("BEGIN:VCARD\r\nFN:changed\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := NewImportCheckpoint(path); saved.SameSource(changed) {
+		t.Error("SameSource matched a file that changed")
+	}
+
+	if err := cm.ClearImportCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := cm.ImportCheckpoint(); c != nil {
+		t.Error("checkpoint still present after ClearImportCheckpoint")
+	}
+}
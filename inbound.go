@@ -0,0 +1,104 @@
+package contacts
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// InboundTag is added to every contact received with ReceiveInbound, so
+// new arrivals can be found and triaged with --tag inbound.
+const InboundTag = "inbound"
+
+// InboundContact is a contact pushed by another service, such as a
+// "contact me" form on a website.
+type InboundContact struct {
+	Name         string `json:"name"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	// Message is recorded as a message interaction, not stored on the
+	// card.
+	Message string   `json:"message,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// Card converts the submission to a new vCard. A name or an email address
+// is required; without a name, the email address is used.
+func (in InboundContact) Card() (vcard.Card, error) {
+	name := strings.TrimSpace(in.Name)
+	email := strings.TrimSpace(in.Email)
+	if name == "" {
+		name = email
+	}
+	if name == "" {
+		return nil, errors.New("a name or email address is required")
+	}
+	card := NewCard(name)
+	if email != "" {
+		card.SetValue(vcard.FieldEmail, email)
+	}
+	if phone := strings.TrimSpace(in.Phone); phone != "" {
+		card.SetValue(vcard.FieldTelephone, phone)
+	}
+	if org := strings.TrimSpace(in.Organization); org != "" {
+		card.SetValue(vcard.FieldOrganization, org)
+	}
+	var tags []string
+	for _, tag := range in.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	addCategories(card, tags...)
+	return card, nil
+}
+
+// InboundResult reports what ReceiveInbound did with a contact.
+type InboundResult struct {
+	// Card is the stored contact.
+	Card vcard.Card
+	// Merged is true if the contact was merged into an existing one
+	// rather than created.
+	Merged bool
+}
+
+// ReceiveInbound stores a contact pushed from outside. If an existing
+// contact shares an email address or phone number with it, the two are
+// merged (keeping the existing contact's values and adding new addresses,
+// numbers and tags) instead of creating a duplicate; a similar name alone
+// is not enough. The contact is tagged InboundTag, and message, if not
+// empty, is recorded as a message interaction.
+func (cm *ContactManager) ReceiveInbound(card vcard.Card, message string) (InboundResult, error) {
+	var result InboundResult
+	addCategories(card, InboundTag)
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+	list, err := cm.ListContacts()
+	if err != nil {
+		return result, err
+	}
+	for _, existing := range list {
+		if sharedEmail(card, existing) != "" || sharedPhone(card, existing) != "" {
+			card = Merge(existing, card, StrategyUnion)
+			result.Merged = true
+			break
+		}
+	}
+	if err := cm.writeContact(card); err != nil {
+		return result, err
+	}
+	if card, err = cm.GetContact(CardUID(card)); err != nil {
+		return result, err
+	}
+	result.Card = card
+	if message = strings.TrimSpace(message); message != "" {
+		err = cm.RecordInteractions(Interaction{Time: time.Now().UTC(), UID: CardUID(card), Kind: InteractionMessage, Note: message})
+	}
+	return result, err
+}
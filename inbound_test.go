@@ -0,0 +1,68 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestInboundContact_Card(t *testing.T) {
+	card, err := InboundContact{Email: " ada@example.com ", Tags: []string{"website", " "}}.Card()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CardFullName(card) != "ada@example.com" || card.Value(vcard.FieldEmail) != "ada@example.com" {
+		t.Errorf("card = %v, want the email as name", card)
+	}
+	if got := strings.Join(card.Categories(), ","); got != "website" {
+		t.Errorf("categories = %q, want website", got)
+	}
+	if _, err := (InboundContact{Phone: "555"}).Card(); err == nil {
+		t.Error("Card() without name or email succeeded")
+	}
+}
+
+func TestContactManager_ReceiveInbound(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldEmail, "ada@example.com")
+	ada.SetValue(vcard.FieldTitle, "Mathematician")
+	if err := cm.WriteContact(ada); err != nil {
+		t.Fatal(err)
+	}
+
+	in, _ := InboundContact{Name: "A. Lovelace", Email: "ADA@example.com", Phone: "+44 20 7946 0000"}.Card()
+	result, err := cm.ReceiveInbound(in, "please call me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Merged || CardUID(result.Card) != CardUID(ada) {
+		t.Fatalf("result = %+v, want merged into %s", result, CardUID(ada))
+	}
+	if CardFullName(result.Card) != "Ada Lovelace" || result.Card.Value(vcard.FieldTitle) != "Mathematician" {
+		t.Error("merge replaced the existing contact's values")
+	}
+	if result.Card.Value(vcard.FieldTelephone) == "" {
+		t.Error("merge did not add the new phone number")
+	}
+	if !containsFold(result.Card.Categories(), InboundTag) {
+		t.Errorf("categories = %v, want %s", result.Card.Categories(), InboundTag)
+	}
+	interactions, err := cm.Interactions(CardUID(ada))
+	if err != nil || len(interactions) != 1 || interactions[0].Note != "please call me" {
+		t.Errorf("interactions = %v, %v", interactions, err)
+	}
+
+	// A similar name alone does not merge.
+	in, _ = InboundContact{Name: "Ada Lovelace"}.Card()
+	if result, err := cm.ReceiveInbound(in, ""); err != nil || result.Merged {
+		t.Errorf("ReceiveInbound(same name) = %+v, %v; want a new contact", result, err)
+	}
+	if list, _ := cm.ListContacts(); len(list) != 2 {
+		t.Errorf("got %d contacts, want 2", len(list))
+	}
+}
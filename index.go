@@ -0,0 +1,183 @@
+package contacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// indexEntry records what the tool last wrote for a contact file.
+type indexEntry struct {
+	Hash string `json:"hash"`
+}
+
+// VerifyStatus describes how a contact file differs from the index.
+type VerifyStatus string
+
+const (
+	// VerifyModified means the file changed since the tool last wrote it.
+	VerifyModified VerifyStatus = "modified"
+	// VerifyCorrupt means the file no longer parses as a vCard.
+	VerifyCorrupt VerifyStatus = "corrupt"
+	// VerifyMissing means the file was removed outside the tool.
+	VerifyMissing VerifyStatus = "missing"
+	// VerifyUntracked means the file has no index entry, e.g. it was
+	// created by hand or before the index existed.
+	VerifyUntracked VerifyStatus = "untracked"
+)
+
+// VerifyIssue is a contact file whose contents don't match the index.
+type VerifyIssue struct {
+	UID    string
+	Status VerifyStatus
+	Err    error
+}
+
+func hashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (cm *ContactManager) indexPath() string {
+	return filepath.Join(cm.dir, "index.json")
+}
+
+func (cm *ContactManager) loadIndex() (map[string]indexEntry, error) {
+	index := map[string]indexEntry{}
+	data, err := os.ReadFile(cm.indexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+	return index, nil
+}
+
+func (cm *ContactManager) saveIndex(index map[string]indexEntry) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	if err := writeFileAtomic(cm.indexPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	return nil
+}
+
+// removeFromIndex drops the index entry for a deleted contact.
+func (cm *ContactManager) removeFromIndex(uid string) error {
+	index, err := cm.loadIndex()
+	if err != nil {
+		return err
+	}
+	delete(index, uid)
+	return cm.saveIndex(index)
+}
+
+// Verify compares every contact file against the hash recorded when the
+// tool last wrote it, reporting files edited or corrupted outside the tool.
+func (cm *ContactManager) Verify() ([]VerifyIssue, error) {
+	index, err := cm.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(cm.storagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contacts directory: %w", err)
+	}
+
+	var issues []VerifyIssue
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".vcf") {
+			continue
+		}
+		uid := strings.TrimSuffix(entry.Name(), ".vcf")
+		seen[uid] = true
+		data, err := os.ReadFile(filepath.Join(cm.storagePath, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read contact file %s: %w", entry.Name(), err)
+		}
+		if _, err := cm.decodeCard(data); err != nil {
+			issues = append(issues, VerifyIssue{UID: uid, Status: VerifyCorrupt, Err: err})
+			continue
+		}
+		recorded, ok := index[uid]
+		switch {
+		case !ok:
+			issues = append(issues, VerifyIssue{UID: uid, Status: VerifyUntracked})
+		case recorded.Hash != hashContent(data):
+			issues = append(issues, VerifyIssue{UID: uid, Status: VerifyModified})
+		}
+	}
+	for uid := range index {
+		if !seen[uid] {
+			issues = append(issues, VerifyIssue{UID: uid, Status: VerifyMissing})
+		}
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].UID < issues[j].UID })
+	return issues, nil
+}
+
+// AcceptContact treats an externally edited contact file as a local change:
+// the card is rewritten through WriteContact, which records its new hash and
+// pushes it to the provider. A missing file is dropped from the index.
+func (cm *ContactManager) AcceptContact(uid string) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	card, err := cm.GetContact(uid)
+	if err != nil {
+		return err
+	}
+	if card == nil {
+		return cm.removeFromIndex(uid)
+	}
+	return cm.writeContact(card)
+}
+
+// RepullContacts replaces the local copies of the given contacts with the
+// provider's version, discarding any external edits.
+func (cm *ContactManager) RepullContacts(uids ...string) error {
+	if cm.provider == nil {
+		return ErrNotInitialized
+	}
+	remote, err := cm.provider.FetchContacts()
+	if err != nil {
+		return fmt.Errorf("failed to fetch remote contacts: %w", err)
+	}
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	byUID := make(map[string]int, len(remote))
+	for i, card := range remote {
+		byUID[CardUID(card)] = i
+	}
+	index, err := cm.loadIndex()
+	if err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		i, ok := byUID[uid]
+		if !ok {
+			return fmt.Errorf("%w at provider: %s", ErrNotFound, uid)
+		}
+		if _, err := cm.writeContactLocal(remote[i], index); err != nil {
+			return fmt.Errorf("failed to write local contact: %w", err)
+		}
+	}
+	return cm.saveIndex(index)
+}
@@ -0,0 +1,113 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_Verify(t *testing.T) {
+	dir := t.TempDir()
+	cm, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uid := range []string{"clean", "edited", "broken", "removed"} {
+		card := NewCard(uid)
+		card.SetValue(vcard.FieldUID, uid)
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	people := filepath.Join(dir, "people")
+	edited, err := cm.GetContact("edited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited.SetValue(vcard.FieldNote, "edited by hand")
+	data, err := EncodeCard(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(people, "edited.vcf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(people, "broken.vcf"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(people, "removed.vcf")); err != nil {
+		t.Fatal(err)
+	}
+	stray := NewCard("Stray")
+	stray.SetValue(vcard.FieldUID, "stray")
+	data, _ = EncodeCard(stray)
+	if err := os.WriteFile(filepath.Join(people, "stray.vcf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := cm.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]VerifyStatus{
+		"broken":  VerifyCorrupt,
+		"edited":  VerifyModified,
+		"removed": VerifyMissing,
+		"stray":   VerifyUntracked,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %+v", len(want), issues)
+	}
+	for _, issue := range issues {
+		if want[issue.UID] != issue.Status {
+			t.Errorf("%s: got %s, want %s", issue.UID, issue.Status, want[issue.UID])
+		}
+	}
+
+	// Accepting clears the issues
+	for _, uid := range []string{"edited", "removed", "stray"} {
+		if err := cm.AcceptContact(uid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	issues, err = cm.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].UID != "broken" {
+		t.Errorf("expected only the corrupt card left, got %+v", issues)
+	}
+}
+
+func TestContactManager_RepullContacts(t *testing.T) {
+	dir := t.TempDir()
+	remote := NewCard("Remote Name")
+	remote.SetValue(vcard.FieldUID, "r1")
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{remote}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "people", "r1.vcf"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.RepullContacts("r1"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cm.GetContact("r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CardFullName(got) != "Remote Name" {
+		t.Errorf("got %q, want %q", CardFullName(got), "Remote Name")
+	}
+	issues, err := cm.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no issues after repull, got %+v", issues)
+	}
+}
@@ -0,0 +1,168 @@
+package contacts
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Interaction kinds recorded by `contacts touch` and log importers.
+const (
+	InteractionCall    = "call"
+	InteractionMessage = "message"
+	InteractionEmail   = "email"
+	InteractionMeeting = "meeting"
+	InteractionOther   = "other"
+)
+
+// Interaction is one recorded contact with a person: a call, message,
+// meeting and so on.
+type Interaction struct {
+	Time time.Time `json:"time"`
+	UID  string    `json:"uid"`
+	Kind string    `json:"kind"`
+	Note string    `json:"note,omitempty"`
+}
+
+func (cm *ContactManager) interactionsPath() string {
+	return filepath.Join(cm.dir, "interactions.log")
+}
+
+// RecordInteractions appends interactions to the interaction log.
+func (cm *ContactManager) RecordInteractions(interactions ...Interaction) error {
+	if len(interactions) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(cm.interactionsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, cm.fileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open interaction log: %w", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, in := range interactions {
+		if in.Time.IsZero() {
+			in.Time = time.Now()
+		}
+		if in.Kind == "" {
+			in.Kind = InteractionOther
+		}
+		in.Time = in.Time.UTC()
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal interaction: %w", err)
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write interaction log: %w", err)
+	}
+	return nil
+}
+
+// Interactions returns the logged interactions, oldest first. If uid is
+// non-empty only that contact's interactions are returned.
+func (cm *ContactManager) Interactions(uid string) ([]Interaction, error) {
+	f, err := os.Open(cm.interactionsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open interaction log: %w", err)
+	}
+	defer f.Close()
+
+	var out []Interaction
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var in Interaction
+		if err := json.Unmarshal(line, &in); err != nil {
+			return nil, fmt.Errorf("failed to parse interaction log: %w", err)
+		}
+		if uid == "" || in.UID == uid {
+			out = append(out, in)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read interaction log: %w", err)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out, nil
+}
+
+// LastInteractions returns when each contact was last interacted with.
+func (cm *ContactManager) LastInteractions() (map[string]time.Time, error) {
+	all, err := cm.Interactions("")
+	if err != nil {
+		return nil, err
+	}
+	last := map[string]time.Time{}
+	for _, in := range all {
+		if in.Time.After(last[in.UID]) {
+			last[in.UID] = in.Time
+		}
+	}
+	return last, nil
+}
+
+// RecordEmailSent logs an email interaction at the given time with every
+// contact having one of the recipients' addresses. Recipients may be
+// written as in a To header ("Ada <ada@example.com>, bob@example.com").
+// It returns the contacts recorded and the addresses matching none.
+func (cm *ContactManager) RecordEmailSent(at time.Time, recipients ...string) (matched []vcard.Card, unmatched []string, err error) {
+	var interactions []Interaction
+	seen := map[string]bool{}
+	for _, r := range recipients {
+		for _, address := range parseRecipients(r) {
+			cards, err := cm.FindByEmail(address)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(cards) == 0 {
+				unmatched = append(unmatched, address)
+			}
+			for _, card := range cards {
+				if seen[CardUID(card)] {
+					continue
+				}
+				seen[CardUID(card)] = true
+				matched = append(matched, card)
+				interactions = append(interactions, Interaction{Time: at, UID: CardUID(card), Kind: InteractionEmail})
+			}
+		}
+	}
+	return matched, unmatched, cm.RecordInteractions(interactions...)
+}
+
+// parseRecipients extracts the addresses from a header-style address list,
+// falling back to splitting on commas for lists net/mail rejects.
+func parseRecipients(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	if list, err := mail.ParseAddressList(s); err == nil {
+		out := make([]string, len(list))
+		for i, a := range list {
+			out[i] = a.Address
+		}
+		return out
+	}
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.Trim(strings.TrimSpace(part), "<>"); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
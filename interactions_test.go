@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_Interactions(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cm.Interactions(""); err != nil || got != nil {
+		t.Fatalf("Interactions() on empty log = %v, %v", got, err)
+	}
+	jan := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 10, 12, 0, 0, 0, time.UTC)
+	if err := cm.RecordInteractions(
+		Interaction{Time: feb, UID: "ada", Kind: InteractionCall},
+		Interaction{Time: jan, UID: "ada", Kind: InteractionEmail},
+		Interaction{Time: jan, UID: "alan"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cm.Interactions("ada")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[0].Time.Equal(jan) || got[1].Kind != InteractionCall {
+		t.Errorf("Interactions(ada) = %+v", got)
+	}
+	all, _ := cm.Interactions("")
+	if len(all) != 3 || all[2].Kind != InteractionCall {
+		t.Errorf("Interactions() = %+v", all)
+	}
+	if alan, _ := cm.Interactions("alan"); len(alan) != 1 || alan[0].Kind != InteractionOther {
+		t.Errorf("interaction without a kind = %+v, want kind other", alan)
+	}
+
+	last, err := cm.LastInteractions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last["ada"].Equal(feb) || !last["alan"].Equal(jan) {
+		t.Errorf("LastInteractions() = %v", last)
+	}
+}
+
+func TestContactManager_RecordEmailSent(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldEmail, "ada@example.com")
+	ada.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@work.example"})
+	if err := cm.WriteContact(ada); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	matched, unmatched, err := cm.RecordEmailSent(at, "Ada <ADA@example.com>, stranger@example.com", "ada@work.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched) != 1 || CardUID(matched[0]) != CardUID(ada) {
+		t.Errorf("matched = %v, want Ada once", matched)
+	}
+	if len(unmatched) != 1 || unmatched[0] != "stranger@example.com" {
+		t.Errorf("unmatched = %v", unmatched)
+	}
+	got, err := cm.Interactions(CardUID(ada))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Kind != InteractionEmail || !got[0].Time.Equal(at) {
+		t.Errorf("interactions = %+v", got)
+	}
+}
+
+func TestParseRecipients(t *testing.T) {
+	tests := map[string][]string{
+		"":                nil,
+		"ada@example.com": {"ada@example.com"},
+		`"Lovelace, Ada" <ada@example.com>, b@example.com`: {"ada@example.com", "b@example.com"},
+		"<ada@example.com>, not an address@":               {"ada@example.com", "not an address@"},
+	}
+	for in, want := range tests {
+		if got := parseRecipients(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseRecipients(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// RelatedOrganization is the RELATED type linking a person to the KIND:org
+// card of the organization they belong to.
+const RelatedOrganization = "x-organization"
+
+// ParseKind validates a KIND name given on the command line.
+func ParseKind(s string) (vcard.Kind, error) {
+	switch k := vcard.Kind(strings.ToLower(s)); k {
+	case vcard.KindIndividual, vcard.KindGroup, vcard.KindOrganization, vcard.KindLocation:
+		return k, nil
+	}
+	return "", fmt.Errorf("invalid kind %q: expected individual, org, group or location", s)
+}
+
+// OfKind matches cards of the given kind.
+func OfKind(kind vcard.Kind) Filter {
+	return func(card vcard.Card) bool { return card.Kind() == kind }
+}
+
+// NewOrgCard creates a KIND:org card for an organization.
+func NewOrgCard(name string) vcard.Card {
+	card := NewCard(name)
+	card.SetKind(vcard.KindOrganization)
+	card.SetValue(vcard.FieldOrganization, name)
+	return card
+}
+
+// cardURI returns the URI other cards use to refer to card.
+func cardURI(card vcard.Card) string {
+	return "urn:uuid:" + CardUID(card)
+}
+
+// uidFromURI returns the UID in a urn:uuid: reference.
+func uidFromURI(uri string) string {
+	return strings.TrimPrefix(uri, "urn:uuid:")
+}
+
+// LinkToOrg links person to org with a RELATED field and sets the person's
+// ORG to the organization's name if it has none. The caller saves person.
+func LinkToOrg(person, org vcard.Card) error {
+	if org.Kind() != vcard.KindOrganization {
+		return fmt.Errorf("%s is not an organization card", CardFullName(org))
+	}
+	uri := cardURI(org)
+	for _, f := range person[vcard.FieldRelated] {
+		if f.Value == uri {
+			return nil
+		}
+	}
+	person.Add(vcard.FieldRelated, &vcard.Field{
+		Value:  uri,
+		Params: vcard.Params{vcard.ParamType: []string{RelatedOrganization}},
+	})
+	if person.Value(vcard.FieldOrganization) == "" {
+		person.SetValue(vcard.FieldOrganization, CardFullName(org))
+	}
+	return nil
+}
+
+// FindOrg returns the KIND:org card named name (case-insensitively), or nil
+// if there is none.
+func (cm *ContactManager) FindOrg(name string) (vcard.Card, error) {
+	return cm.findKind(vcard.KindOrganization, name)
+}
+
+// OrgMembers returns the contacts linked to org with LinkToOrg.
+func (cm *ContactManager) OrgMembers(org vcard.Card) ([]vcard.Card, error) {
+	list, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	uid := CardUID(org)
+	var out []vcard.Card
+	for _, card := range list {
+		for _, f := range card[vcard.FieldRelated] {
+			if f.Params.HasType(RelatedOrganization) && uidFromURI(f.Value) == uid {
+				out = append(out, card)
+				break
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		in   string
+		want vcard.Kind
+		ok   bool
+	}{
+		{"org", vcard.KindOrganization, true},
+		{"GROUP", vcard.KindGroup, true},
+		{"individual", vcard.KindIndividual, true},
+		{"company", "", false},
+	}
+	for _, tt := range tests {
+		got, err := ParseKind(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseKind(%q) = %q, %v; want %q, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLinkToOrg(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	org := NewOrgCard("Acme Inc")
+	person := NewCard("Wile E. Coyote")
+	other := NewCard("Road Runner")
+	for _, card := range []vcard.Card{org, person, other} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := cm.FindOrg("acme inc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == nil || CardUID(found) != CardUID(org) {
+		t.Fatalf("FindOrg = %v, want %s", found, CardUID(org))
+	}
+	if err := LinkToOrg(person, org); err != nil {
+		t.Fatal(err)
+	}
+	// Linking twice is a no-op.
+	if err := LinkToOrg(person, org); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkToOrg(org, person); err == nil {
+		t.Error("linking to an individual succeeded, want error")
+	}
+	if err := cm.WriteContact(person); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := cm.GetContact(CardUID(person))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored[vcard.FieldRelated]) != 1 {
+		t.Errorf("got %d RELATED fields, want 1", len(stored[vcard.FieldRelated]))
+	}
+	if got := stored.Value(vcard.FieldOrganization); got != "Acme Inc" {
+		t.Errorf("ORG = %q, want Acme Inc", got)
+	}
+
+	members, err := cm.OrgMembers(org)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || CardUID(members[0]) != CardUID(person) {
+		t.Errorf("OrgMembers = %d cards, want only %s", len(members), CardFullName(person))
+	}
+
+	list, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orgs := FilterCards(list, OfKind(vcard.KindOrganization)); len(orgs) != 1 {
+		t.Errorf("OfKind(org) matched %d cards, want 1", len(orgs))
+	}
+}
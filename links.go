@@ -0,0 +1,217 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldLinked lists, on a card returned by MergeLinked, the UIDs of the
+// linked copies it was merged from besides its own.
+const FieldLinked = "X-LINKED-UID"
+
+// Links are sets of contacts known to be the same person, typically the
+// copies of one person pulled in from different providers. Each set is
+// stored separately and synced separately; MergeLinked combines them for
+// display. The first UID of a set is its primary copy.
+type Links [][]string
+
+// Set returns the link set containing uid, or nil.
+func (l Links) Set(uid string) []string {
+	for _, set := range l {
+		if slices.Contains(set, uid) {
+			return set
+		}
+	}
+	return nil
+}
+
+func (cm *ContactManager) linksPath() string {
+	return filepath.Join(cm.dir, "links.json")
+}
+
+// Links returns the stored link sets.
+func (cm *ContactManager) Links() (Links, error) {
+	var links Links
+	data, err := os.ReadFile(cm.linksPath())
+	if os.IsNotExist(err) {
+		return links, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("failed to parse links: %w", err)
+	}
+	return links, nil
+}
+
+func (cm *ContactManager) saveLinks(links Links) error {
+	if len(links) == 0 {
+		if err := os.Remove(cm.linksPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove links: %w", err)
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal links: %w", err)
+	}
+	if err := writeFileAtomic(cm.linksPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write links: %w", err)
+	}
+	return nil
+}
+
+// Link records that the contacts with the given UIDs are the same person.
+// Sets they already belong to are joined, keeping the first set's
+// primary; otherwise the first UID becomes the primary of a new set.
+func (cm *ContactManager) Link(uids ...string) error {
+	if len(uids) < 2 {
+		return fmt.Errorf("linking needs at least two contacts")
+	}
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, uid := range uids {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			return err
+		}
+		if card == nil {
+			return fmt.Errorf("%w: %s", ErrNotFound, uid)
+		}
+	}
+	links, err := cm.Links()
+	if err != nil {
+		return err
+	}
+	var joined []string
+	var kept Links
+	for _, set := range links {
+		if slices.ContainsFunc(uids, func(uid string) bool { return slices.Contains(set, uid) }) {
+			joined = append(joined, set...)
+		} else {
+			kept = append(kept, set)
+		}
+	}
+	for _, uid := range uids {
+		if !slices.Contains(joined, uid) {
+			joined = append(joined, uid)
+		}
+	}
+	return cm.saveLinks(append(kept, joined))
+}
+
+// Unlink removes a contact from its link set, so it is shown on its own
+// again. A set left with one contact is dropped.
+func (cm *ContactManager) Unlink(uid string) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	links, err := cm.Links()
+	if err != nil {
+		return err
+	}
+	var kept Links
+	found := false
+	for _, set := range links {
+		if i := slices.Index(set, uid); i >= 0 {
+			found = true
+			set = slices.Delete(set, i, i+1)
+		}
+		if len(set) > 1 {
+			kept = append(kept, set)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s is not linked", ErrNotFound, uid)
+	}
+	return cm.saveLinks(kept)
+}
+
+// renameLink points links to oldUID at newUID.
+func (cm *ContactManager) renameLink(oldUID, newUID string) error {
+	links, err := cm.Links()
+	if err != nil || links.Set(oldUID) == nil {
+		return err
+	}
+	for _, set := range links {
+		if i := slices.Index(set, oldUID); i >= 0 {
+			set[i] = newUID
+		}
+	}
+	return cm.saveLinks(links)
+}
+
+// MergeLinked replaces each linked set among cards with one merged card,
+// at the position of the set's first card. The merged card has the UID of
+// the primary copy present, its values, and the values only the other
+// copies have (combining multi-valued fields such as EMAIL), and lists the
+// other copies' UIDs in FieldLinked. Fields with an owner (see
+// WithFieldOwners) are taken from the owning source's copy. It is a view:
+// store changes in the copies themselves.
+func (cm *ContactManager) MergeLinked(cards []vcard.Card) ([]vcard.Card, error) {
+	links, err := cm.Links()
+	if err != nil || len(links) == 0 {
+		return cards, err
+	}
+	var ids IDMap
+	if len(cm.fieldOwners) > 0 {
+		if ids, err = cm.IDMap(); err != nil {
+			return nil, err
+		}
+	}
+	byUID := map[string]vcard.Card{}
+	for _, card := range cards {
+		byUID[CardUID(card)] = card
+	}
+	out := make([]vcard.Card, 0, len(cards))
+	done := map[string]bool{}
+	for _, card := range cards {
+		uid := CardUID(card)
+		if done[uid] {
+			continue
+		}
+		set := links.Set(uid)
+		if set == nil {
+			out = append(out, card)
+			continue
+		}
+		var merged vcard.Card
+		var others []string
+		sources := map[string]vcard.Card{}
+		for _, member := range set {
+			linked, ok := byUID[member]
+			if !ok {
+				continue
+			}
+			done[member] = true
+			for _, source := range cardSources(ids, member) {
+				if _, ok := sources[source]; !ok {
+					sources[source] = linked
+				}
+			}
+			if merged == nil {
+				merged = Merge(linked, vcard.Card{}, StrategyUnion)
+				continue
+			}
+			merged = Merge(merged, linked, StrategyUnion)
+			others = append(others, member)
+		}
+		cm.fieldOwners.Apply(merged, sources)
+		for _, other := range others {
+			merged.Add(FieldLinked, &vcard.Field{Value: other})
+		}
+		out = append(out, merged)
+	}
+	return out, nil
+}
@@ -0,0 +1,99 @@
+package contacts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_Link(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	google := NewCard("Ada Lovelace")
+	google.SetValue(vcard.FieldEmail, "ada@example.com")
+	google.SetValue(vcard.FieldTitle, "Mathematician")
+	carddav := NewCard("Ada King")
+	carddav.SetValue(vcard.FieldEmail, "ada@analytical.example")
+	carddav.SetValue(vcard.FieldOrganization, "Analytical Engines")
+	work := NewCard("A. Lovelace")
+	charles := NewCard("Charles Babbage")
+	if err := cm.WriteContacts([]vcard.Card{google, carddav, work, charles}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.Link(CardUID(google), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Link(missing) = %v, want ErrNotFound", err)
+	}
+	if err := cm.Link(CardUID(google), CardUID(carddav)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.Link(CardUID(work), CardUID(carddav)); err != nil {
+		t.Fatal(err)
+	}
+	links, err := cm.Links()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || len(links[0]) != 3 || links[0][0] != CardUID(google) {
+		t.Fatalf("links = %v, want one set of three led by %s", links, CardUID(google))
+	}
+
+	list := []vcard.Card{charles, carddav, google, work}
+	merged, err := cm.MergeLinked(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 || CardUID(merged[0]) != CardUID(charles) {
+		t.Fatalf("MergeLinked returned %d cards, want Charles and one merged Ada", len(merged))
+	}
+	ada := merged[1]
+	if CardUID(ada) != CardUID(google) || CardFullName(ada) != "Ada Lovelace" {
+		t.Errorf("merged card = %s %q, want the primary's UID and name", CardUID(ada), CardFullName(ada))
+	}
+	if len(ada[vcard.FieldEmail]) != 2 || ada.Value(vcard.FieldOrganization) != "Analytical Engines" {
+		t.Error("merged card lacks values only the other copies have")
+	}
+	if n := len(ada[FieldLinked]); n != 2 {
+		t.Errorf("merged card lists %d linked UIDs, want 2", n)
+	}
+	if len(google[FieldLinked]) != 0 {
+		t.Error("MergeLinked modified its input")
+	}
+
+	if err := cm.Unlink(CardUID(work)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.Unlink(CardUID(carddav)); err != nil {
+		t.Fatal(err)
+	}
+	if links, _ := cm.Links(); len(links) != 0 {
+		t.Errorf("links after unlinking = %v, want none", links)
+	}
+	if err := cm.Unlink(CardUID(google)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Unlink(unlinked) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestContactManager_RenameKeepsLinks(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := NewCard("Ada Lovelace"), NewCard("Ada King")
+	if err := cm.WriteContacts([]vcard.Card{a, b}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.Link(CardUID(a), CardUID(b)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.RenameUID(CardUID(b), "ada-king"); err != nil {
+		t.Fatal(err)
+	}
+	links, _ := cm.Links()
+	if set := links.Set("ada-king"); len(set) != 2 {
+		t.Errorf("links after rename = %v", links)
+	}
+}
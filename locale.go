@@ -0,0 +1,107 @@
+package contacts
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Locale controls how dates are displayed. Its Tag is a BCP 47 language
+// tag such as "en-US" or "de-DE", which callers can also use to collate
+// names.
+type Locale struct {
+	Tag string
+
+	// Go time layouts for a full date, a date without a year and a date
+	// with its weekday.
+	date, monthDay, weekday string
+}
+
+// DefaultLocale is US English, used when no locale is configured.
+var DefaultLocale = Locale{Tag: "en-US", date: "Jan 2, 2006", monthDay: "Jan 2", weekday: "Mon Jan 2"}
+
+// localeLayouts maps a lowercase language tag, or just its language, to
+// date layouts. Layouts outside English avoid month and weekday names,
+// which Go only renders in English.
+var localeLayouts = map[string][3]string{
+	"en":    {"Jan 2, 2006", "Jan 2", "Mon Jan 2"},
+	"en-gb": {"2 Jan 2006", "2 Jan", "Mon 2 Jan"},
+	"en-au": {"2 Jan 2006", "2 Jan", "Mon 2 Jan"},
+	"en-ie": {"2 Jan 2006", "2 Jan", "Mon 2 Jan"},
+	"en-in": {"2 Jan 2006", "2 Jan", "Mon 2 Jan"},
+	"en-nz": {"2 Jan 2006", "2 Jan", "Mon 2 Jan"},
+	"en-ca": {"2006-01-02", "01-02", "Mon 2006-01-02"},
+	"de":    {"02.01.2006", "02.01.", "02.01."},
+	"cs":    {"02.01.2006", "02.01.", "02.01."},
+	"da":    {"02.01.2006", "02.01.", "02.01."},
+	"fi":    {"02.01.2006", "02.01.", "02.01."},
+	"nb":    {"02.01.2006", "02.01.", "02.01."},
+	"pl":    {"02.01.2006", "02.01.", "02.01."},
+	"ru":    {"02.01.2006", "02.01.", "02.01."},
+	"tr":    {"02.01.2006", "02.01.", "02.01."},
+	"uk":    {"02.01.2006", "02.01.", "02.01."},
+	"fr":    {"02/01/2006", "02/01", "02/01"},
+	"es":    {"02/01/2006", "02/01", "02/01"},
+	"it":    {"02/01/2006", "02/01", "02/01"},
+	"pt":    {"02/01/2006", "02/01", "02/01"},
+	"el":    {"02/01/2006", "02/01", "02/01"},
+	"nl":    {"02-01-2006", "02-01", "02-01"},
+	"sv":    {"2006-01-02", "01-02", "01-02"},
+	"lt":    {"2006-01-02", "01-02", "01-02"},
+	"hu":    {"2006.01.02.", "01.02.", "01.02."},
+	"ja":    {"2006/01/02", "01/02", "01/02"},
+	"zh":    {"2006/01/02", "01/02", "01/02"},
+	"ko":    {"2006.01.02", "01.02", "01.02"},
+}
+
+var localeTagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// ParseLocale returns the Locale for a language tag. POSIX names such as
+// "de_DE.UTF-8" are accepted too. Languages without their own date layouts
+// use ISO dates (2006-01-02).
+func ParseLocale(tag string) (Locale, error) {
+	if tag == "" {
+		return DefaultLocale, nil
+	}
+	tag, _, _ = strings.Cut(tag, ".")
+	tag = strings.ReplaceAll(tag, "_", "-")
+	if !localeTagPattern.MatchString(tag) {
+		return Locale{}, fmt.Errorf("invalid locale %q: expected a language tag like en-US or de-DE", tag)
+	}
+	key := strings.ToLower(tag)
+	layouts, ok := localeLayouts[key]
+	if !ok {
+		lang, _, _ := strings.Cut(key, "-")
+		layouts, ok = localeLayouts[lang]
+	}
+	if !ok {
+		layouts = [3]string{"2006-01-02", "01-02", "01-02"}
+	}
+	return Locale{Tag: tag, date: layouts[0], monthDay: layouts[1], weekday: layouts[2]}, nil
+}
+
+// FormatDate renders a full date.
+func (l Locale) FormatDate(t time.Time) string {
+	return t.Format(l.layout(l.date, DefaultLocale.date))
+}
+
+// FormatMonthDay renders a date without its year, as used for birthdays
+// whose year is unknown.
+func (l Locale) FormatMonthDay(t time.Time) string {
+	return t.Format(l.layout(l.monthDay, DefaultLocale.monthDay))
+}
+
+// FormatWeekday renders a date in the coming year with its weekday where
+// the locale shows one.
+func (l Locale) FormatWeekday(t time.Time) string {
+	return t.Format(l.layout(l.weekday, DefaultLocale.weekday))
+}
+
+// layout returns layout, or fallback for a zero Locale.
+func (l Locale) layout(layout, fallback string) string {
+	if layout == "" {
+		return fallback
+	}
+	return layout
+}
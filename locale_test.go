@@ -0,0 +1,64 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseLocale(t *testing.T) {
+	date := time.Date(2024, time.March, 7, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		tag      string
+		date     string
+		monthDay string
+	}{
+		{"", "Mar 7, 2024", "Mar 7"},
+		{"en-US", "Mar 7, 2024", "Mar 7"},
+		{"en-GB", "7 Mar 2024", "7 Mar"},
+		{"de-DE", "07.03.2024", "07.03."},
+		{"de_AT.UTF-8", "07.03.2024", "07.03."},
+		{"fr", "07/03/2024", "07/03"},
+		{"sv-SE", "2024-03-07", "03-07"},
+		{"eo", "2024-03-07", "03-07"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			loc, err := ParseLocale(tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := loc.FormatDate(date); got != tt.date {
+				t.Errorf("FormatDate = %q, want %q", got, tt.date)
+			}
+			if got := loc.FormatMonthDay(date); got != tt.monthDay {
+				t.Errorf("FormatMonthDay = %q, want %q", got, tt.monthDay)
+			}
+		})
+	}
+}
+
+func TestParseLocale_Invalid(t *testing.T) {
+	for _, tag := range []string{"english", "de DE", "1234"} {
+		if _, err := ParseLocale(tag); err == nil {
+			t.Errorf("ParseLocale(%q) succeeded, want error", tag)
+		}
+	}
+}
+
+func TestFormatCardLocale(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldBirthday, "18151210")
+	loc, err := ParseLocale("de-DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := FormatCardLocale(card, loc); !strings.Contains(out, "10.12.1815") {
+		t.Errorf("birthday not localized:\n%s", out)
+	}
+	if out := FormatCard(card); !strings.Contains(out, "Dec 10, 1815") {
+		t.Errorf("default birthday format changed:\n%s", out)
+	}
+}
@@ -0,0 +1,114 @@
+package contacts
+
+import (
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// countryNames maps ISO 3166 alpha-2 codes (and a few common alternates)
+// to the spellings that appear in address books, so --country US matches
+// "United States" and "USA".
+var countryNames = map[string][]string{
+	"us": {"united states", "united states of america", "usa", "u.s.", "u.s.a."},
+	"gb": {"united kingdom", "uk", "great britain", "england", "scotland", "wales"},
+	"ca": {"canada"},
+	"au": {"australia"},
+	"nz": {"new zealand"},
+	"ie": {"ireland"},
+	"de": {"germany", "deutschland"},
+	"fr": {"france"},
+	"es": {"spain", "españa"},
+	"it": {"italy", "italia"},
+	"nl": {"netherlands", "the netherlands", "holland"},
+	"be": {"belgium"},
+	"ch": {"switzerland", "schweiz", "suisse"},
+	"at": {"austria", "österreich"},
+	"se": {"sweden", "sverige"},
+	"no": {"norway", "norge"},
+	"dk": {"denmark", "danmark"},
+	"fi": {"finland", "suomi"},
+	"pl": {"poland", "polska"},
+	"pt": {"portugal"},
+	"in": {"india"},
+	"cn": {"china"},
+	"jp": {"japan"},
+	"kr": {"south korea", "korea"},
+	"sg": {"singapore"},
+	"br": {"brazil", "brasil"},
+	"mx": {"mexico", "méxico"},
+	"ar": {"argentina"},
+	"za": {"south africa"},
+	"il": {"israel"},
+	"ae": {"united arab emirates", "uae"},
+}
+
+// countryVariants returns every spelling that should match country.
+func countryVariants(country string) []string {
+	country = strings.ToLower(strings.TrimSpace(country))
+	variants := []string{country}
+	for code, names := range countryNames {
+		match := code == country
+		for _, n := range names {
+			if n == country {
+				match = true
+			}
+		}
+		if match {
+			variants = append(variants, code)
+			variants = append(variants, names...)
+		}
+	}
+	return variants
+}
+
+// InCity matches cards with an address in city (the ADR locality,
+// case-insensitively) or whose Google location mentions it.
+func InCity(city string) Filter {
+	city = strings.ToLower(strings.TrimSpace(city))
+	return func(card vcard.Card) bool {
+		for _, addr := range card.Addresses() {
+			if strings.ToLower(strings.TrimSpace(addr.Locality)) == city {
+				return true
+			}
+		}
+		return locationMentions(card, []string{city})
+	}
+}
+
+// InCountry matches cards with an address in country, given as a name or
+// ISO code, or whose Google location mentions it.
+func InCountry(country string) Filter {
+	variants := countryVariants(country)
+	return func(card vcard.Card) bool {
+		for _, addr := range card.Addresses() {
+			c := strings.ToLower(strings.TrimSpace(addr.Country))
+			for _, v := range variants {
+				if c == v {
+					return true
+				}
+			}
+		}
+		return locationMentions(card, variants)
+	}
+}
+
+// locationMentions reports whether any X-GOOGLE-LOCATION value contains one
+// of the given names as a whole word. Two-letter codes are skipped, since
+// free-text locations rarely use them and they match too much.
+func locationMentions(card vcard.Card, names []string) bool {
+	for _, f := range card["X-GOOGLE-LOCATION"] {
+		words := strings.FieldsFunc(strings.ToLower(f.Value), func(r rune) bool {
+			return r == ',' || r == ';' || r == '/' || r == '(' || r == ')'
+		})
+		for _, w := range words {
+			w = strings.TrimSpace(w)
+			for _, n := range names {
+				if len(n) > 2 && (w == n || strings.HasPrefix(w, n+" ") || strings.HasSuffix(w, " "+n) || strings.Contains(w, " "+n+" ")) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func locationCard(city, country, googleLocation string) vcard.Card {
+	card := NewCard("Someone")
+	if city != "" || country != "" {
+		card.AddAddress(&vcard.Address{Locality: city, Country: country})
+	}
+	if googleLocation != "" {
+		card.Add("X-GOOGLE-LOCATION", &vcard.Field{Value: googleLocation})
+	}
+	return card
+}
+
+func TestInCity(t *testing.T) {
+	tests := []struct {
+		name string
+		card vcard.Card
+		city string
+		want bool
+	}{
+		{"address match", locationCard("Berlin", "Germany", ""), "berlin", true},
+		{"address mismatch", locationCard("Munich", "Germany", ""), "Berlin", false},
+		{"google location", locationCard("", "", "Berlin office, Mitte"), "Berlin", true},
+		{"google location partial word", locationCard("", "", "Berlingen"), "Berlin", false},
+		{"no location", locationCard("", "", ""), "Berlin", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InCity(tt.city)(tt.card); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInCountry(t *testing.T) {
+	tests := []struct {
+		name    string
+		card    vcard.Card
+		country string
+		want    bool
+	}{
+		{"code matches name", locationCard("Springfield", "United States", ""), "US", true},
+		{"name matches code", locationCard("Springfield", "US", ""), "usa", true},
+		{"exact name", locationCard("Berlin", "Germany", ""), "germany", true},
+		{"unknown country exact", locationCard("Reykjavik", "Iceland", ""), "Iceland", true},
+		{"mismatch", locationCard("Berlin", "Germany", ""), "FR", false},
+		{"google location", locationCard("", "", "Remote, Germany"), "DE", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InCountry(tt.country)(tt.card); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
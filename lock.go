@@ -0,0 +1,58 @@
+package contacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockStore serializes changes to the store: between goroutines sharing
+// the manager, such as the handlers of 'contacts serve', with the
+// manager's mutex, and between processes, such as the CLI and a sync
+// daemon, with an advisory lock on store.lock in the data directory. It
+// returns the function that releases both.
+func (cm *ContactManager) lockStore() (unlock func(), err error) {
+	cm.mu.Lock()
+	f, err := os.OpenFile(filepath.Join(cm.dir, "store.lock"), os.O_CREATE|os.O_RDWR, cm.fileMode)
+	if err != nil {
+		cm.mu.Unlock()
+		return nil, fmt.Errorf("failed to open store lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		cm.mu.Unlock()
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		cm.mu.Unlock()
+	}, nil
+}
+
+// writeFileAtomic replaces the file at path with data, writing it to a
+// temporary file in the same directory first and renaming that over path,
+// so readers and crashes see either the old contents or the new.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package contacts
+
+import "os"
+
+// lockFile is a no-op on systems without file locks; the manager's mutex
+// still serializes changes within a process.
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,123 @@
+package contacts
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_ConcurrentWrites(t *testing.T) {
+	// Two managers on one directory stand in for the server and the CLI.
+	dir := t.TempDir()
+	var managers []*ContactManager
+	for range 2 {
+		cm, err := NewContactManager(&mockProvider{}, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		managers = append(managers, cm)
+	}
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- managers[i%2].WriteContact(NewCard(fmt.Sprintf("Person %d", i)))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cm := managers[0]
+	if list, err := cm.ListContacts(); err != nil || len(list) != n {
+		t.Fatalf("got %d contacts (%v), want %d", len(list), err, n)
+	}
+	if issues, err := cm.Verify(); err != nil || len(issues) > 0 {
+		t.Errorf("Verify = %v, %v; want no issues", issues, err)
+	}
+	ids, err := cm.IDMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(ids[cm.providerName()]); got != n {
+		t.Errorf("ID map has %d contacts, want %d", got, n)
+	}
+}
+
+// lockProbeProvider checks, whenever it is called, that another manager on
+// the same directory can lock the store.
+type lockProbeProvider struct {
+	mockProvider
+	other *ContactManager
+	calls int
+	// blocked counts the calls made while the store was locked.
+	blocked int
+}
+
+func (p *lockProbeProvider) probe() {
+	if p.other == nil {
+		return
+	}
+	p.calls++
+	done := make(chan struct{})
+	go func() {
+		if unlock, err := p.other.lockStore(); err == nil {
+			unlock()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		p.blocked++
+	}
+}
+
+func (p *lockProbeProvider) FetchContacts() ([]vcard.Card, error) {
+	p.probe()
+	return p.mockProvider.FetchContacts()
+}
+
+func (p *lockProbeProvider) WriteContact(c vcard.Card) error {
+	p.probe()
+	return p.mockProvider.WriteContact(c)
+}
+
+func TestContactManager_SyncUnlockedWhileCallingProvider(t *testing.T) {
+	dir := t.TempDir()
+	provider := &lockProbeProvider{mockProvider: mockProvider{contacts: []vcard.Card{NewCard("Charles Babbage")}}}
+	cm, err := NewContactManager(provider, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ada := NewCard("Ada Lovelace")
+	if err := cm.WriteContact(ada); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.queuePushes(pendingPush{UID: CardUID(ada)}); err != nil {
+		t.Fatal(err)
+	}
+	if provider.other, err = NewContactManager(&mockProvider{}, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	if provider.calls != 2 || provider.blocked != 0 {
+		t.Errorf("store was locked during %d of %d provider calls, want 0 of 2", provider.blocked, provider.calls)
+	}
+	if queue, err := cm.loadPending(); err != nil || len(queue) != 0 {
+		t.Errorf("pending = %v, %v; want the push taken off the queue", queue, err)
+	}
+}
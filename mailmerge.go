@@ -0,0 +1,160 @@
+package contacts
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// MailingAddress is a postal address ready to print on an envelope.
+type MailingAddress struct {
+	Name       string `json:"name"`
+	POBox      string `json:"po_box,omitempty"`
+	Extended   string `json:"extended,omitempty"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country,omitempty"`
+}
+
+// MailingEntry is one contact in a mail merge, with the problems that keep
+// its address from being printed.
+type MailingEntry struct {
+	Card     vcard.Card
+	Address  MailingAddress
+	Problems []string
+}
+
+// MailingList picks a postal address for each card: the one marked PREF,
+// else a home address, else the first. Entries missing an address, street,
+// city or postal code list what is missing in Problems. The list is sorted
+// by name.
+func MailingList(cards []vcard.Card) []MailingEntry {
+	out := make([]MailingEntry, 0, len(cards))
+	for _, card := range cards {
+		entry := MailingEntry{Card: card, Address: MailingAddress{Name: CardFullName(card)}}
+		f := mailingField(card)
+		if f == nil {
+			entry.Problems = []string{"no address"}
+			out = append(out, entry)
+			continue
+		}
+		parts := strings.Split(f.Value, ";")
+		for len(parts) < 7 {
+			parts = append(parts, "")
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		entry.Address.POBox, entry.Address.Extended, entry.Address.Street = parts[0], parts[1], parts[2]
+		entry.Address.City, entry.Address.Region = parts[3], parts[4]
+		entry.Address.PostalCode, entry.Address.Country = parts[5], parts[6]
+		if entry.Address.Street == "" && entry.Address.POBox == "" {
+			entry.Problems = append(entry.Problems, "missing street")
+		}
+		if entry.Address.City == "" {
+			entry.Problems = append(entry.Problems, "missing city")
+		}
+		if entry.Address.PostalCode == "" {
+			entry.Problems = append(entry.Problems, "missing postal code")
+		}
+		out = append(out, entry)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Address.Name < out[j].Address.Name })
+	return out
+}
+
+// mailingField returns the ADR field MailingList uses, or nil.
+func mailingField(card vcard.Card) *vcard.Field {
+	var home *vcard.Field
+	for _, f := range card[vcard.FieldAddress] {
+		if strings.Trim(f.Value, " ;") == "" {
+			continue
+		}
+		if IsPreferred(f) {
+			return f
+		}
+		if home == nil && f.Params.HasType(vcard.TypeHome) {
+			home = f
+		}
+	}
+	if home != nil {
+		return home
+	}
+	for _, f := range card[vcard.FieldAddress] {
+		if strings.Trim(f.Value, " ;") != "" {
+			return f
+		}
+	}
+	return nil
+}
+
+// mailMergeHeader lists the columns written by WriteMailMergeCSV.
+var mailMergeHeader = []string{"name", "po_box", "extended", "street", "city", "region", "postal_code", "country"}
+
+// WriteMailMergeCSV writes addresses as CSV for a word processor's mail
+// merge, one row per address.
+func WriteMailMergeCSV(w io.Writer, addrs []MailingAddress) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(mailMergeHeader); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+	for _, a := range addrs {
+		if err := cw.Write([]string{a.Name, a.POBox, a.Extended, a.Street, a.City, a.Region, a.PostalCode, a.Country}); err != nil {
+			return fmt.Errorf("failed to write csv row for %s: %w", a.Name, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteLabels writes addresses as plain-text label blocks separated by
+// blank lines.
+func WriteLabels(w io.Writer, addrs []MailingAddress) error {
+	var b strings.Builder
+	for i, a := range addrs {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, line := range a.Lines() {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	return nil
+}
+
+// Lines returns the address as it is printed on a label.
+func (a MailingAddress) Lines() []string {
+	lines := []string{a.Name}
+	for _, s := range []string{a.Extended, a.Street} {
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+	if a.POBox != "" {
+		lines = append(lines, "PO Box "+strings.TrimPrefix(a.POBox, "PO Box "))
+	}
+	last := a.City
+	if a.Region != "" {
+		last += ", " + a.Region
+	}
+	if a.PostalCode != "" {
+		last += " " + a.PostalCode
+	}
+	if last = strings.TrimSpace(last); last != "" {
+		lines = append(lines, last)
+	}
+	if a.Country != "" {
+		lines = append(lines, a.Country)
+	}
+	return lines
+}
@@ -0,0 +1,49 @@
+package contacts
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestMailingList(t *testing.T) {
+	ada := NewCard("Ada Lovelace")
+	ada.Add(vcard.FieldAddress, &vcard.Field{Value: ";;1 Work St;London;;EC1;UK", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	ada.Add(vcard.FieldAddress, &vcard.Field{Value: ";Flat 2;10 Home Rd;London;;NW1;UK", Params: vcard.Params{vcard.ParamType: {"home"}}})
+	bob := NewCard("Bob")
+	bob.SetValue(vcard.FieldAddress, ";;5 Main St;Springfield;IL;;")
+	carol := NewCard("Carol")
+
+	entries := MailingList([]vcard.Card{carol, bob, ada})
+	if len(entries) != 3 || entries[0].Address.Name != "Ada Lovelace" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if a := entries[0].Address; a.Street != "10 Home Rd" || len(entries[0].Problems) != 0 {
+		t.Errorf("Ada: got %+v %v, want the home address with no problems", a, entries[0].Problems)
+	}
+	if got := strings.Join(entries[1].Problems, ","); got != "missing postal code" {
+		t.Errorf("Bob problems = %q", got)
+	}
+	if got := strings.Join(entries[2].Problems, ","); got != "no address" {
+		t.Errorf("Carol problems = %q", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteLabels(&buf, []MailingAddress{entries[0].Address, {Name: "Dan", Street: "1 A St", City: "Boston", Region: "MA", PostalCode: "02101"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "Ada Lovelace\nFlat 2\n10 Home Rd\nLondon NW1\nUK\n\nDan\n1 A St\nBoston, MA 02101\n"
+	if buf.String() != want {
+		t.Errorf("labels =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteMailMergeCSV(&buf, []MailingAddress{entries[0].Address}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || lines[1] != "Ada Lovelace,,Flat 2,10 Home Rd,London,,NW1,UK" {
+		t.Errorf("csv = %q", buf.String())
+	}
+}
@@ -0,0 +1,345 @@
+package contacts
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Strategy decides whose values Merge keeps for a field both cards set
+// differently.
+type Strategy string
+
+const (
+	// StrategyNewestWins keeps the values updated last: by the fields'
+	// ParamUpdated stamps where both cards have them, otherwise by the
+	// cards' REV.
+	StrategyNewestWins Strategy = "newest-wins"
+	// StrategyRemoteWins keeps the values of the second (remote) card.
+	StrategyRemoteWins Strategy = "remote-wins"
+	// StrategyUnion combines the values of multi-valued fields such as
+	// EMAIL and TEL, and keeps the first card's single values.
+	StrategyUnion Strategy = "union"
+)
+
+// ParseStrategy validates a strategy name from the config or command line.
+func ParseStrategy(s string) (Strategy, error) {
+	switch st := Strategy(s); st {
+	case StrategyNewestWins, StrategyRemoteWins, StrategyUnion:
+		return st, nil
+	}
+	return "", fmt.Errorf("invalid merge strategy %q: expected newest-wins, remote-wins or union", s)
+}
+
+// WithMergeStrategy makes SyncContacts resolve conflicts, where a contact
+// file was edited outside the tool and also changed at the provider, by
+// merging the two versions with s. The merged card is saved locally and
+// pushed to the provider. Without it conflicts keep the local file.
+func WithMergeStrategy(s Strategy) ManagerOption {
+	return func(cm *ContactManager) { cm.mergeWith = s }
+}
+
+// ParamUpdated stamps a field with when its source last updated it, as
+// reported by providers that track this, such as Google. Fields without
+// it count as updated at the card's REV, and local writes restamp the
+// fields they change with their REV.
+const ParamUpdated = "X-UPDATED"
+
+// MarkUpdated stamps f with update time t.
+func MarkUpdated(f *vcard.Field, t time.Time) {
+	if f.Params == nil {
+		f.Params = make(vcard.Params)
+	}
+	f.Params.Set(ParamUpdated, t.UTC().Format("20060102T150405Z"))
+}
+
+// FieldUpdated returns the latest ParamUpdated stamp of fields. It reports
+// false unless every field has a valid stamp, since an unstamped value was
+// added after the rest were fetched.
+func FieldUpdated(fields []*vcard.Field) (time.Time, bool) {
+	var latest time.Time
+	for _, f := range fields {
+		t, err := time.Parse("20060102T150405Z", f.Params.Get(ParamUpdated))
+		if err != nil {
+			return time.Time{}, false
+		}
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, len(fields) > 0
+}
+
+// restampEdits stamps the fields of each key whose values differ from old
+// with the card's REV. Stamps fetched from a provider stay on a field
+// when it is edited locally, and would otherwise make the edit look older
+// than a remote value it replaced. Keys without stamps are left alone.
+func restampEdits(old, card vcard.Card) {
+	rev, ok := CardRevision(card)
+	if !ok {
+		return
+	}
+	for key, fields := range card {
+		if fieldValues(fields) == fieldValues(old[key]) || !slices.ContainsFunc(fields, hasUpdated) {
+			continue
+		}
+		for _, f := range fields {
+			MarkUpdated(f, rev)
+		}
+	}
+}
+
+func hasUpdated(f *vcard.Field) bool {
+	return f.Params.Get(ParamUpdated) != ""
+}
+
+// mergeConflict merges a locally edited card with its remote version,
+// pushes the result to the provider if it differs from the remote version
+// and stores it.
+func (cm *ContactManager) mergeConflict(local, remote vcard.Card, index map[string]indexEntry) (syncOutcome, error) {
+	merged := Merge(local, remote, cm.mergeWith)
+	cm.fieldOwners.Apply(merged, map[string]vcard.Card{SourceLocal: local, cm.providerName(): remote})
+	merged.SetValue(vcard.FieldUID, CardUID(remote))
+	// The merged card is pushed first so that what the provider changes
+	// on it, such as its etag, is stored with it.
+	if !sameSyncedContent(merged, remote) {
+		if err := cm.provider.WriteContact(merged); err != nil {
+			return syncUpdated, fmt.Errorf("failed to write merged contact to provider: %w", err)
+		}
+	}
+	if _, err := cm.writeContactLocal(merged, index); err != nil {
+		return syncUpdated, err
+	}
+	return syncUpdated, nil
+}
+
+// multiValuedFields are combined by StrategyUnion.
+var multiValuedFields = map[string]bool{
+	vcard.FieldEmail:      true,
+	vcard.FieldTelephone:  true,
+	vcard.FieldURL:        true,
+	vcard.FieldNote:       true,
+	vcard.FieldAddress:    true,
+	vcard.FieldIMPP:       true,
+	vcard.FieldRelated:    true,
+	vcard.FieldMember:     true,
+	vcard.FieldCategories: true,
+	vcard.FieldNickname:   true,
+}
+
+// Merge combines two versions of a contact into a new card; a is the local
+// or primary card and b the remote or secondary one. Fields only one card
+// has are kept. For fields both have, s decides the result; newest-wins
+// decides field by field where both cards stamp the field with
+// ParamUpdated, and by REV otherwise. The merged card
+// keeps a's UID and provider ID and the later of the two REVs.
+func Merge(a, b vcard.Card, s Strategy) vcard.Card {
+	winner := a
+	switch s {
+	case StrategyRemoteWins:
+		winner = b
+	case StrategyNewestWins:
+		revA, okA := CardRevision(a)
+		revB, okB := CardRevision(b)
+		if !okA || (okB && !revB.Before(revA)) {
+			winner = b
+		}
+	}
+
+	out := make(vcard.Card, len(a)+len(b))
+	for key := range a {
+		out[key] = copyFields(a[key])
+	}
+	for key, fields := range b {
+		switch {
+		case key == vcard.FieldUID || key == vcard.FieldVersion || key == FieldProviderID:
+			if len(out[key]) == 0 {
+				out[key] = copyFields(fields)
+			}
+		case key == vcard.FieldRevision:
+			if winner := newerRevision(a, b); winner != nil {
+				out[key] = copyFields(winner[key])
+			}
+		case len(out[key]) == 0:
+			out[key] = copyFields(fields)
+		case s == StrategyUnion && multiValuedFields[key]:
+			for _, f := range fields {
+				if !hasFieldValue(out[key], f.Value, key) {
+					out[key] = append(out[key], copyField(f))
+				}
+			}
+		case s == StrategyNewestWins:
+			out[key] = copyFields(newerField(a, b, key, winner)[key])
+		default:
+			out[key] = copyFields(winner[key])
+		}
+	}
+	return out
+}
+
+// newerField returns whichever card's ParamUpdated stamps show it updated
+// key later, or fallback if either lacks stamps or they are equal.
+func newerField(a, b vcard.Card, key string, fallback vcard.Card) vcard.Card {
+	updatedA, okA := FieldUpdated(a[key])
+	updatedB, okB := FieldUpdated(b[key])
+	switch {
+	case !okA || !okB:
+		return fallback
+	case updatedA.After(updatedB):
+		return a
+	case updatedB.After(updatedA):
+		return b
+	}
+	return fallback
+}
+
+// newerRevision returns whichever card has the later REV, or nil if
+// neither has one.
+func newerRevision(a, b vcard.Card) vcard.Card {
+	revA, okA := CardRevision(a)
+	revB, okB := CardRevision(b)
+	switch {
+	case okA && (!okB || revA.After(revB)):
+		return a
+	case okB:
+		return b
+	}
+	return nil
+}
+
+func copyFields(fields []*vcard.Field) []*vcard.Field {
+	if fields == nil {
+		return nil
+	}
+	out := make([]*vcard.Field, len(fields))
+	for i, f := range fields {
+		out[i] = copyField(f)
+	}
+	return out
+}
+
+func copyField(f *vcard.Field) *vcard.Field {
+	c := *f
+	if f.Params != nil {
+		c.Params = make(vcard.Params, len(f.Params))
+		for k, v := range f.Params {
+			c.Params[k] = append([]string(nil), v...)
+		}
+	}
+	return &c
+}
+
+// ScoreSimilarity estimates how likely two cards describe the same person,
+// from 0 (nothing in common) to 1. A shared email address or phone number
+// is strong evidence; matching names and organizations add to it.
+func ScoreSimilarity(a, b vcard.Card) float64 {
+	var signals []float64
+	if sharedEmail(a, b) != "" {
+		signals = append(signals, 0.9)
+	}
+	if sharedPhone(a, b) != "" {
+		signals = append(signals, 0.8)
+	}
+	nameA, nameB := nameKey(CardFullName(a)), nameKey(CardFullName(b))
+	switch {
+	case nameA != "" && nameA == nameB:
+		signals = append(signals, 0.6)
+	case namesSimilar(nameA, nameB):
+		signals = append(signals, 0.4)
+	}
+	if org := a.Value(vcard.FieldOrganization); org != "" && strings.EqualFold(org, b.Value(vcard.FieldOrganization)) {
+		signals = append(signals, 0.2)
+	}
+	// Combine independent signals: each removes part of the remaining
+	// doubt.
+	doubt := 1.0
+	for _, s := range signals {
+		doubt *= 1 - s
+	}
+	return 1 - doubt
+}
+
+// Choice picks which side's values MergeChoices keeps for a field.
+type Choice int
+
+const (
+	// ChooseLeft keeps the first card's values.
+	ChooseLeft Choice = iota
+	// ChooseRight keeps the second card's values.
+	ChooseRight
+	// ChooseBoth keeps the values of both cards, without duplicates.
+	ChooseBoth
+)
+
+// FieldConflict is a field two versions of a contact both set, to
+// different values.
+type FieldConflict struct {
+	Field       string
+	Left, Right []*vcard.Field
+	// Combinable reports whether ChooseBoth makes sense for the field,
+	// i.e. whether a card may have several of it.
+	Combinable bool
+}
+
+// FieldConflicts lists the fields a and b set differently, sorted by name.
+// Bookkeeping fields (UID, VERSION, REV, X-LAST-SYNCED, X-PROVIDER-ID) are
+// not reported.
+func FieldConflicts(a, b vcard.Card) []FieldConflict {
+	var out []FieldConflict
+	for key, left := range a {
+		right := b[key]
+		if key == vcard.FieldUID || key == vcard.FieldVersion || auditIgnoredFields[key] ||
+			len(left) == 0 || len(right) == 0 || fieldValues(left) == fieldValues(right) {
+			continue
+		}
+		out = append(out, FieldConflict{Field: key, Left: left, Right: right, Combinable: multiValuedFields[key]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
+
+// MergeChoices combines two versions of a contact field by field: fields
+// only one card has are kept, and for each of FieldConflicts(a, b) the
+// choice for that field decides, defaulting to ChooseLeft. The merged card
+// keeps a's UID and provider ID and the later of the two REVs.
+func MergeChoices(a, b vcard.Card, choices map[string]Choice) vcard.Card {
+	out := Merge(a, b, StrategyNewestWins)
+	for _, c := range FieldConflicts(a, b) {
+		switch choices[c.Field] {
+		case ChooseRight:
+			out[c.Field] = copyFields(c.Right)
+		case ChooseBoth:
+			out[c.Field] = copyFields(c.Left)
+			for _, f := range c.Right {
+				if !hasFieldValue(out[c.Field], f.Value, c.Field) {
+					out[c.Field] = append(out[c.Field], copyField(f))
+				}
+			}
+		default:
+			out[c.Field] = copyFields(c.Left)
+		}
+	}
+	return out
+}
+
+// FetchRemoteContact fetches the provider's current version of the contact
+// with uid, or nil if the provider does not have it.
+func (cm *ContactManager) FetchRemoteContact(uid string) (vcard.Card, error) {
+	if cm.provider == nil {
+		return nil, ErrNotInitialized
+	}
+	cards, err := cm.provider.FetchContacts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+	}
+	for _, card := range cards {
+		if CardUID(card) == uid {
+			return card, nil
+		}
+	}
+	return nil, nil
+}
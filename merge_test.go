@@ -0,0 +1,371 @@
+package contacts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseStrategy(t *testing.T) {
+	for _, s := range []string{"newest-wins", "remote-wins", "union"} {
+		if got, err := ParseStrategy(s); err != nil || string(got) != s {
+			t.Errorf("ParseStrategy(%q) = %q, %v", s, got, err)
+		}
+	}
+	if _, err := ParseStrategy("local-wins"); err == nil {
+		t.Error("ParseStrategy(local-wins) succeeded, want error")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldTitle, "Mathematician")
+	local.SetValue(vcard.FieldEmail, "ada@example.com")
+	local.SetValue(vcard.FieldRevision, "20240102T000000Z")
+	local.SetValue(vcard.FieldNote, "local note")
+
+	remote := NewCard("Ada Lovelace")
+	remote.SetValue(vcard.FieldTitle, "Analyst")
+	remote.SetValue(vcard.FieldEmail, "ada@analytical.example")
+	remote.SetValue(vcard.FieldRevision, "20240101T000000Z")
+	remote.SetValue(vcard.FieldOrganization, "Analytical Engines")
+
+	tests := []struct {
+		strategy Strategy
+		title    string
+		emails   int
+	}{
+		{StrategyNewestWins, "Mathematician", 1},
+		{StrategyRemoteWins, "Analyst", 1},
+		{StrategyUnion, "Mathematician", 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			got := Merge(local, remote, tt.strategy)
+			if CardUID(got) != CardUID(local) {
+				t.Errorf("UID = %q, want local %q", CardUID(got), CardUID(local))
+			}
+			if title := got.Value(vcard.FieldTitle); title != tt.title {
+				t.Errorf("TITLE = %q, want %q", title, tt.title)
+			}
+			if n := len(got[vcard.FieldEmail]); n != tt.emails {
+				t.Errorf("got %d emails, want %d", n, tt.emails)
+			}
+			if got.Value(vcard.FieldOrganization) != "Analytical Engines" || got.Value(vcard.FieldNote) != "local note" {
+				t.Error("fields set on only one side were not kept")
+			}
+			if rev := got.Value(vcard.FieldRevision); rev != "20240102T000000Z" {
+				t.Errorf("REV = %q, want the later one", rev)
+			}
+		})
+	}
+
+	Merge(local, remote, StrategyUnion)[vcard.FieldEmail][0].Value = "changed"
+	if local.Value(vcard.FieldEmail) != "ada@example.com" {
+		t.Error("Merge result shares fields with its input")
+	}
+}
+
+func TestMerge_NewestWinsByField(t *testing.T) {
+	stamped := func(card vcard.Card, field, value string, updated time.Time) {
+		f := &vcard.Field{Value: value}
+		MarkUpdated(f, updated)
+		card.Set(field, f)
+	}
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldRevision, "20240102T000000Z")
+	stamped(local, vcard.FieldTitle, "Mathematician", older)
+	stamped(local, vcard.FieldNote, "local note", newer)
+	local.SetValue(vcard.FieldOrganization, "Local Co")
+
+	remote := NewCard("Ada Lovelace")
+	remote.SetValue(vcard.FieldRevision, "20240101T000000Z")
+	stamped(remote, vcard.FieldTitle, "Analyst", newer)
+	stamped(remote, vcard.FieldNote, "remote note", older)
+	stamped(remote, vcard.FieldOrganization, "Remote Co", newer)
+
+	got := Merge(local, remote, StrategyNewestWins)
+	if title := got.Value(vcard.FieldTitle); title != "Analyst" {
+		t.Errorf("TITLE = %q, want the later stamped remote value", title)
+	}
+	if note := got.Value(vcard.FieldNote); note != "local note" {
+		t.Errorf("NOTE = %q, want the later stamped local value", note)
+	}
+	// An unstamped side falls back to REV.
+	if org := got.Value(vcard.FieldOrganization); org != "Local Co" {
+		t.Errorf("ORG = %q, want the value from the later REV", org)
+	}
+	if updated, ok := FieldUpdated(got[vcard.FieldTitle]); !ok || !updated.Equal(newer) {
+		t.Errorf("FieldUpdated(TITLE) = %v, %v, want %v", updated, ok, newer)
+	}
+}
+
+func TestContactManager_WriteContactRestampsEdits(t *testing.T) {
+	fetched := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	remote := NewCard("Ada Lovelace")
+	for field, value := range map[string]string{vcard.FieldTitle: "Mathematician", vcard.FieldNote: "first programmer"} {
+		f := &vcard.Field{Value: value}
+		MarkUpdated(f, fetched)
+		remote.Set(field, f)
+	}
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{remote}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Editing the value keeps the stamp it was fetched with, as when the
+	// file is edited in an editor.
+	local, err := cm.GetContact(CardUID(remote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local[vcard.FieldTitle][0].Value = "Analyst"
+	if err := cm.WriteContact(local); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := cm.GetContact(CardUID(remote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated, ok := FieldUpdated(stored[vcard.FieldNote]); !ok || !updated.Equal(fetched) {
+		t.Errorf("FieldUpdated(NOTE) = %v, %v, want the unedited field's stamp kept", updated, ok)
+	}
+
+	// The remote value changed after it was fetched but before the edit.
+	remote[vcard.FieldTitle][0].Value = "Countess"
+	MarkUpdated(remote[vcard.FieldTitle][0], fetched.AddDate(0, 6, 0))
+	if got := Merge(stored, remote, StrategyNewestWins).Value(vcard.FieldTitle); got != "Analyst" {
+		t.Errorf("TITLE = %q, want the later local edit", got)
+	}
+}
+
+func TestScoreSimilarity(t *testing.T) {
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldEmail, "ada@example.com")
+	ada.SetValue(vcard.FieldOrganization, "Analytical Engines")
+
+	sameEmail := NewCard("A. Lovelace")
+	sameEmail.SetValue(vcard.FieldEmail, "ADA@example.com")
+	sameName := NewCard("Lovelace Ada")
+	typo := NewCard("Ada Lovelase")
+	stranger := NewCard("Alan Turing")
+
+	scores := []float64{
+		ScoreSimilarity(ada, sameEmail),
+		ScoreSimilarity(ada, sameName),
+		ScoreSimilarity(ada, typo),
+		ScoreSimilarity(ada, stranger),
+	}
+	for i := 1; i < len(scores); i++ {
+		if scores[i-1] <= scores[i] {
+			t.Errorf("scores not decreasing: %v", scores)
+		}
+	}
+	if scores[3] != 0 {
+		t.Errorf("ScoreSimilarity(stranger) = %v, want 0", scores[3])
+	}
+	if s := ScoreSimilarity(ada, ada); s <= 0.9 || s > 1 {
+		t.Errorf("ScoreSimilarity(self) = %v, want close to 1", s)
+	}
+}
+
+func TestContactManager_SyncContactsMergeStrategy(t *testing.T) {
+	dir := t.TempDir()
+	card := NewCard("Ada Lovelace")
+	provider := &recordingProvider{mockProvider: mockProvider{contacts: []vcard.Card{card}}}
+	cm, err := NewContactManager(provider, dir, WithMergeStrategy(StrategyUnion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(cm.storagePath, CardUID(card)+".vcf")
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldUID, CardUID(card))
+	local.SetValue(vcard.FieldNote, "edited by hand")
+	data, err := EncodeCard(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue(vcard.FieldTitle, "Analyst")
+
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Conflicts) != 0 || result.Updated != 1 {
+		t.Errorf("result = %+v, want one update and no conflicts", result)
+	}
+	got, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Value(vcard.FieldNote) != "edited by hand" || got.Value(vcard.FieldTitle) != "Analyst" {
+		t.Errorf("merged card lost a side: NOTE=%q TITLE=%q", got.Value(vcard.FieldNote), got.Value(vcard.FieldTitle))
+	}
+	if len(provider.written) != 1 || provider.written[0] != CardUID(card) {
+		t.Errorf("provider writes = %v, want the merged card", provider.written)
+	}
+}
+
+func TestContactManager_SyncMergeStoresPushedCard(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	provider := &etagProvider{mockProvider: mockProvider{contacts: []vcard.Card{card}}}
+	cm, err := NewContactManager(provider, t.TempDir(), WithMergeStrategy(StrategyUnion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	editByHand(t, cm, CardUID(card), vcard.FieldNote, "edited by hand")
+	card.SetValue(vcard.FieldTitle, "Analyst")
+
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag := got.Value("X-ETAG"); etag != "etag-1" {
+		t.Errorf("stored etag = %q, want the one the provider set on the merged card", etag)
+	}
+	if issues, err := cm.Verify(); err != nil || len(issues) != 0 {
+		t.Errorf("Verify = %v, %v", issues, err)
+	}
+}
+
+func TestContactManager_SyncErrorKeepsIndex(t *testing.T) {
+	ada, alan := NewCard("Ada Lovelace"), NewCard("Alan Turing")
+	provider := &flakyProvider{recordingProvider: recordingProvider{mockProvider: mockProvider{contacts: []vcard.Card{ada}}}}
+	cm, err := NewContactManager(provider, t.TempDir(), WithMergeStrategy(StrategyUnion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	editByHand(t, cm, CardUID(ada), vcard.FieldNote, "edited by hand")
+	ada.SetValue(vcard.FieldTitle, "Analyst")
+	provider.contacts = []vcard.Card{alan, ada}
+
+	// Pushing the merged card fails, after Alan's card was written.
+	provider.down = true
+	if _, err := cm.SyncContacts(); !errors.Is(err, ErrProviderUnavailable) {
+		t.Fatalf("SyncContacts = %v, want ErrProviderUnavailable", err)
+	}
+	if got, _ := cm.GetContact(CardUID(alan)); got == nil {
+		t.Fatal("contact written before the failure is missing")
+	}
+	// Ada's file still holds the unmerged edit.
+	issues, err := cm.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		if issue.UID != CardUID(ada) {
+			t.Errorf("Verify reports %s %v, want the written contact tracked", issue.UID, issue.Status)
+		}
+	}
+}
+
+// editByHand sets field on a stored contact by rewriting its file, as an
+// editor outside the tool would.
+func editByHand(t *testing.T, cm *ContactManager, uid, field, value string) {
+	t.Helper()
+	card, err := cm.GetContact(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue(field, value)
+	data, err := EncodeCard(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cm.storagePath, uid+".vcf"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeChoices(t *testing.T) {
+	left := NewCard("Ada Lovelace")
+	left.SetValue(vcard.FieldTitle, "Mathematician")
+	left.SetValue(vcard.FieldEmail, "ada@example.com")
+	left.SetValue(vcard.FieldNote, "met at the Royal Society")
+	right := NewCard("Ada King")
+	right.SetValue(vcard.FieldTitle, "Analyst")
+	right.SetValue(vcard.FieldEmail, "ada@analytical.example")
+	right.SetValue(vcard.FieldOrganization, "Analytical Engines")
+	right.SetValue(vcard.FieldUID, "remote-uid")
+
+	conflicts := FieldConflicts(left, right)
+	var fields []string
+	for _, c := range conflicts {
+		fields = append(fields, c.Field)
+	}
+	if got, want := strings.Join(fields, ","), "EMAIL,FN,TITLE"; got != want {
+		t.Fatalf("FieldConflicts fields = %s, want %s", got, want)
+	}
+	if !conflicts[0].Combinable || conflicts[2].Combinable {
+		t.Error("EMAIL should be combinable and TITLE not")
+	}
+
+	got := MergeChoices(left, right, map[string]Choice{
+		vcard.FieldEmail: ChooseBoth,
+		vcard.FieldTitle: ChooseRight,
+	})
+	if CardUID(got) != CardUID(left) {
+		t.Errorf("UID = %q, want %q", CardUID(got), CardUID(left))
+	}
+	if CardFullName(got) != "Ada Lovelace" {
+		t.Errorf("FN = %q, want the left value by default", CardFullName(got))
+	}
+	if got.Value(vcard.FieldTitle) != "Analyst" {
+		t.Errorf("TITLE = %q, want Analyst", got.Value(vcard.FieldTitle))
+	}
+	if n := len(got[vcard.FieldEmail]); n != 2 {
+		t.Errorf("got %d emails, want 2", n)
+	}
+	if got.Value(vcard.FieldNote) == "" || got.Value(vcard.FieldOrganization) == "" {
+		t.Error("fields set on only one side were not kept")
+	}
+}
+
+func TestContactManager_FetchRemoteContact(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{card}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := cm.FetchRemoteContact(CardUID(card)); err != nil || CardFullName(got) != "Ada Lovelace" {
+		t.Errorf("FetchRemoteContact() = %v, %v", got, err)
+	}
+	if got, err := cm.FetchRemoteContact("missing"); err != nil || got != nil {
+		t.Errorf("FetchRemoteContact(missing) = %v, %v; want nil, nil", got, err)
+	}
+
+	local, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := local.FetchRemoteContact("x"); !errors.Is(err, ErrNotInitialized) {
+		t.Errorf("FetchRemoteContact without provider = %v, want ErrNotInitialized", err)
+	}
+}
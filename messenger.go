@@ -0,0 +1,168 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// MessengerContact is a person from a messaging app's contact export.
+type MessengerContact struct {
+	Name  string
+	Phone string
+}
+
+// ParseTelegramContacts reads the result.json written by Telegram Desktop's
+// "Export Telegram data" with contacts selected.
+func ParseTelegramContacts(r io.Reader) ([]MessengerContact, error) {
+	var export struct {
+		Contacts struct {
+			List []struct {
+				FirstName   string `json:"first_name"`
+				LastName    string `json:"last_name"`
+				PhoneNumber string `json:"phone_number"`
+			} `json:"list"`
+		} `json:"contacts"`
+	}
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse telegram export: %w", err)
+	}
+	var out []MessengerContact
+	for _, c := range export.Contacts.List {
+		name := strings.TrimSpace(c.FirstName + " " + c.LastName)
+		if name == "" && c.PhoneNumber == "" {
+			continue
+		}
+		out = append(out, MessengerContact{Name: name, Phone: c.PhoneNumber})
+	}
+	return out, nil
+}
+
+// ParseSignalContacts reads the JSON printed by
+// `signal-cli --output=json listContacts`.
+func ParseSignalContacts(r io.Reader) ([]MessengerContact, error) {
+	var export []struct {
+		Number     string `json:"number"`
+		Name       string `json:"name"`
+		GivenName  string `json:"givenName"`
+		FamilyName string `json:"familyName"`
+		Profile    *struct {
+			GivenName  string `json:"givenName"`
+			FamilyName string `json:"familyName"`
+		} `json:"profile"`
+	}
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("failed to parse signal export: %w", err)
+	}
+	var out []MessengerContact
+	for _, c := range export {
+		if c.Number == "" {
+			// Contacts known only by username or UUID can't be matched.
+			continue
+		}
+		name := strings.TrimSpace(c.Name)
+		if name == "" {
+			name = strings.TrimSpace(c.GivenName + " " + c.FamilyName)
+		}
+		if name == "" && c.Profile != nil {
+			name = strings.TrimSpace(c.Profile.GivenName + " " + c.Profile.FamilyName)
+		}
+		out = append(out, MessengerContact{Name: name, Phone: c.Number})
+	}
+	return out, nil
+}
+
+// MergeResult describes how messenger contacts were merged into an address
+// book.
+type MergeResult struct {
+	// Updated are existing cards that gained a phone number or name.
+	Updated []vcard.Card
+	// Created are new cards for people not already in the address book.
+	Created []vcard.Card
+	// Unchanged counts people already present with nothing to add.
+	Unchanged int
+}
+
+// MergeMessengerContacts matches incoming contacts against existing cards,
+// first by phone number (see PhonesMatch) and then by name. A matched card
+// gains the phone number if it was matched by name, and a name if it had
+// none; unmatched people become new cards. Existing cards are modified in
+// place.
+func MergeMessengerContacts(existing []vcard.Card, incoming []MessengerContact) MergeResult {
+	var result MergeResult
+	updated := map[string]bool{}
+	for _, mc := range incoming {
+		card := matchByPhone(existing, mc.Phone)
+		byName := false
+		if card == nil && mc.Name != "" {
+			card = matchByName(existing, mc.Name)
+			byName = card != nil
+		}
+		if card == nil {
+			card = NewCard(mc.Name)
+			if mc.Name == "" {
+				card.SetValue(vcard.FieldFormattedName, mc.Phone)
+			}
+			if mc.Phone != "" {
+				card.Add(vcard.FieldTelephone, &vcard.Field{Value: mc.Phone, Params: vcard.Params{vcard.ParamType: {"cell"}}})
+			}
+			existing = append(existing, card)
+			result.Created = append(result.Created, card)
+			continue
+		}
+
+		changed := false
+		if byName && mc.Phone != "" {
+			card.Add(vcard.FieldTelephone, &vcard.Field{Value: mc.Phone, Params: vcard.Params{vcard.ParamType: {"cell"}}})
+			changed = true
+		}
+		if mc.Name != "" && strings.TrimSpace(card.Value(vcard.FieldFormattedName)) == "" {
+			card.SetValue(vcard.FieldFormattedName, mc.Name)
+			changed = true
+		}
+		uid := CardUID(card)
+		switch {
+		case changed && !updated[uid] && !isCreated(result.Created, card):
+			updated[uid] = true
+			result.Updated = append(result.Updated, card)
+		case !changed:
+			result.Unchanged++
+		}
+	}
+	return result
+}
+
+func matchByPhone(cards []vcard.Card, phone string) vcard.Card {
+	if phone == "" {
+		return nil
+	}
+	for _, card := range cards {
+		for _, f := range card[vcard.FieldTelephone] {
+			if PhonesMatch(f.Value, phone) {
+				return card
+			}
+		}
+	}
+	return nil
+}
+
+func matchByName(cards []vcard.Card, name string) vcard.Card {
+	for _, card := range cards {
+		if strings.EqualFold(strings.TrimSpace(card.Value(vcard.FieldFormattedName)), name) {
+			return card
+		}
+	}
+	return nil
+}
+
+func isCreated(created []vcard.Card, card vcard.Card) bool {
+	for _, c := range created {
+		if CardUID(c) == CardUID(card) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseTelegramContacts(t *testing.T) {
+	data := `{"about": "...", "contacts": {"about": "...", "list": [
+		{"first_name": "Ann", "last_name": "Lee", "phone_number": "+1 555 123 4567", "date": "2023-01-01T00:00:00"},
+		{"first_name": "", "last_name": "", "phone_number": "", "date": "2023-01-01T00:00:00"}
+	]}}`
+	got, err := ParseTelegramContacts(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Name != "Ann Lee" || got[0].Phone != "+1 555 123 4567" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseSignalContacts(t *testing.T) {
+	data := `[
+		{"number": "+15551234567", "name": "Ann Lee", "givenName": "", "familyName": ""},
+		{"number": "+15559876543", "name": "", "profile": {"givenName": "Bob", "familyName": "Roe"}},
+		{"number": null, "uuid": "abc", "name": "Username only"}
+	]`
+	got, err := ParseSignalContacts(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MessengerContact{{"Ann Lee", "+15551234567"}, {"Bob Roe", "+15559876543"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMergeMessengerContacts(t *testing.T) {
+	ann := NewCard("Ann Lee")
+	ann.Add(vcard.FieldTelephone, &vcard.Field{Value: "(555) 123-4567"})
+	bob := NewCard("Bob Roe")
+	existing := []vcard.Card{ann, bob}
+
+	result := MergeMessengerContacts(existing, []MessengerContact{
+		{Name: "Annie", Phone: "+1 555 123 4567"}, // same number, different name
+		{Name: "bob roe", Phone: "+1 555 987 6543"},
+		{Name: "Cat", Phone: "+44 7700 900123"},
+		{Name: "Cat Again", Phone: "07700 900123"},
+	})
+
+	if result.Unchanged != 2 {
+		t.Errorf("Unchanged = %d, want 2", result.Unchanged)
+	}
+	if len(result.Updated) != 1 || CardUID(result.Updated[0]) != CardUID(bob) {
+		t.Fatalf("Updated = %v, want Bob", names(result.Updated))
+	}
+	if len(bob[vcard.FieldTelephone]) != 1 {
+		t.Errorf("Bob should gain a phone number")
+	}
+	if len(result.Created) != 1 || CardFullName(result.Created[0]) != "Cat" {
+		t.Errorf("Created = %v, want [Cat]", names(result.Created))
+	}
+	if CardFullName(ann) != "Ann Lee" {
+		t.Errorf("existing name overwritten: %q", CardFullName(ann))
+	}
+}
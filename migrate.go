@@ -0,0 +1,160 @@
+package contacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// storeVersion is the layout version of the data directory, recorded in
+// its store.version file. Version 2 marks provider contacts with
+// FieldProviderID instead of telling them apart by the absence of a dash
+// in their UID. Version 3 records every provider contact in the ID map,
+// so contacts keep their UIDs when a provider changes how it derives them.
+const storeVersion = 3
+
+func (cm *ContactManager) storeVersionPath() string {
+	return filepath.Join(cm.dir, "store.version")
+}
+
+// migrate upgrades a data directory written by an older version. It runs
+// once; afterwards only store.version is read.
+func (cm *ContactManager) migrate() error {
+	version := 1
+	if data, err := os.ReadFile(cm.storeVersionPath()); err == nil {
+		if version, err = strconv.Atoi(strings.TrimSpace(string(data))); err != nil {
+			return fmt.Errorf("invalid store version %q", data)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read store version: %w", err)
+	}
+	if version > storeVersion {
+		return fmt.Errorf("the data directory was written by a newer version (store version %d)", version)
+	}
+	if version < 2 {
+		if err := cm.migrateProviderIDs(); err != nil {
+			return err
+		}
+	}
+	target := storeVersion
+	if version < 3 {
+		// The ID map is keyed by the provider's name, so without a
+		// provider the step waits for a manager that has one.
+		if cm.provider == nil {
+			target = 2
+		} else if err := cm.migrateIDMap(); err != nil {
+			return err
+		}
+	}
+	if version == target {
+		return nil
+	}
+	if err := writeFileAtomic(cm.storeVersionPath(), []byte(strconv.Itoa(target)+"\n"), cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write store version: %w", err)
+	}
+	return nil
+}
+
+// migrateProviderIDs sets FieldProviderID on contacts the provider knows
+// about: those with a UID without a dash (the old rule) that were synced
+// or written to the provider. Contacts whose files were edited outside the
+// tool keep their index entry, so 'contacts verify' still reports them.
+func (cm *ContactManager) migrateProviderIDs() error {
+	entries, err := os.ReadDir(cm.storagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read contacts directory: %w", err)
+	}
+	index, err := cm.loadIndex()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, entry := range entries {
+		uid, ok := strings.CutSuffix(entry.Name(), ".vcf")
+		if !ok || entry.IsDir() || strings.Contains(uid, "-") {
+			continue
+		}
+		path := filepath.Join(cm.storagePath, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read contact file %s: %w", entry.Name(), err)
+		}
+		card, err := DecodeCard(data)
+		if err != nil || ProviderID(card) != "" || card.Kind() == vcard.KindGroup ||
+			card.Value("X-LAST-SYNCED") == "" && card.Value("X-GOOGLE-ETAG") == "" {
+			continue
+		}
+		card.SetValue(FieldProviderID, uid)
+		updated, err := EncodeCard(card)
+		if err != nil {
+			return fmt.Errorf("failed to marshal contact: %w", err)
+		}
+		if err := writeFileAtomic(path, updated, cm.fileMode); err != nil {
+			return fmt.Errorf("failed to write contact file: %w", err)
+		}
+		if e, tracked := index[uid]; tracked && e.Hash == hashContent(data) {
+			index[uid] = indexEntry{Hash: hashContent(updated)}
+			changed = true
+		}
+	}
+	if changed {
+		if err := cm.saveIndex(index); err != nil {
+			return err
+		}
+	}
+
+	queue, err := cm.loadPending()
+	if err != nil || len(queue) == 0 {
+		return err
+	}
+	for i, p := range queue {
+		if p.Delete && p.ProviderID == "" && !strings.Contains(p.UID, "-") {
+			queue[i].ProviderID = p.UID
+		}
+	}
+	return cm.savePending(queue)
+}
+
+// migrateIDMap maps the provider ID of each stored provider contact to
+// its UID. Sync stores cards the ID map knows under their mapped UID, so
+// contacts keep the UIDs they were stored under after a provider starts
+// deriving UIDs differently, instead of being stored again under new ones.
+func (cm *ContactManager) migrateIDMap() error {
+	entries, err := os.ReadDir(cm.storagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read contacts directory: %w", err)
+	}
+	ids, err := cm.IDMap()
+	if err != nil {
+		return err
+	}
+	name := cm.providerName()
+	changed := false
+	for _, entry := range entries {
+		uid, ok := strings.CutSuffix(entry.Name(), ".vcf")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cm.storagePath, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read contact file %s: %w", entry.Name(), err)
+		}
+		card, err := DecodeCard(data)
+		if err != nil || ProviderID(card) == "" || card.Kind() == vcard.KindGroup {
+			continue
+		}
+		if _, mapped := ids.Lookup(name, ProviderID(card)); mapped {
+			continue
+		}
+		ids.Set(name, ProviderID(card), uid)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return cm.saveIDMap(ids)
+}
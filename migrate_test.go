@@ -0,0 +1,179 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+// deleteRecordingProvider records the IDs it is asked to delete.
+type deleteRecordingProvider struct {
+	mockProvider
+	deleted []string
+}
+
+func (p *deleteRecordingProvider) DeleteContact(id string) error {
+	p.deleted = append(p.deleted, id)
+	return nil
+}
+
+func TestContactManager_ProviderIDRouting(t *testing.T) {
+	provider := &deleteRecordingProvider{}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A local contact with a dashless UID was once mistaken for a
+	// provider contact.
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldUID, "imported42")
+	if err := cm.WriteContact(local); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := cm.GetContact("imported42")
+	if ProviderID(stored) != "imported42" {
+		t.Errorf("provider ID after writing to the provider = %q", ProviderID(stored))
+	}
+
+	offline, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.WriteContact(local); err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.DeleteContact("imported42"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.DeleteContact("imported42"); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.deleted) != 1 || provider.deleted[0] != "imported42" {
+		t.Errorf("provider deletes = %v, want imported42", provider.deleted)
+	}
+
+	// A contact that never reached the provider is only deleted locally,
+	// whatever its UID.
+	group := NewGroupCard("Book Club")
+	group.SetValue(vcard.FieldUID, "bookclub")
+	if err := cm.WriteContact(group); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.DeleteContact("bookclub"); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.deleted) != 1 {
+		t.Errorf("provider deletes = %v, want no delete for a local-only group", provider.deleted)
+	}
+}
+
+func TestContactManager_MigrateProviderIDs(t *testing.T) {
+	dir := t.TempDir()
+	people := filepath.Join(dir, "people")
+	if err := os.MkdirAll(people, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(uid string, fields map[string]string) {
+		card := NewCard(uid)
+		card.SetValue(vcard.FieldUID, uid)
+		for k, v := range fields {
+			card.SetValue(k, v)
+		}
+		data, err := EncodeCard(card)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(people, uid+".vcf"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("c123", map[string]string{"X-LAST-SYNCED": "20240101T000000Z"})
+	write("c456", map[string]string{"X-GOOGLE-ETAG": "etag"})
+	write("imported42", nil)
+	write("0b8e3ad4-5d7e-4b0c-9d55-3f7c2b1a9e10", map[string]string{"X-LAST-SYNCED": "20240101T000000Z"})
+
+	cm, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for uid, want := range map[string]string{
+		"c123":                                 "c123",
+		"c456":                                 "c456",
+		"imported42":                           "",
+		"0b8e3ad4-5d7e-4b0c-9d55-3f7c2b1a9e10": "",
+	} {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ProviderID(card); got != want {
+			t.Errorf("ProviderID(%s) = %q, want %q", uid, got, want)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "store.version")); err != nil || string(data) != "2\n" {
+		t.Errorf("store.version = %q, %v", data, err)
+	}
+
+	// The migration runs once.
+	write("c789", map[string]string{"X-LAST-SYNCED": "20240101T000000Z"})
+	if _, err := NewContactManager(nil, dir); err != nil {
+		t.Fatal(err)
+	}
+	if card, _ := cm.GetContact("c789"); ProviderID(card) != "" {
+		t.Error("migration ran again")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "store.version"), []byte("99\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewContactManager(nil, dir); err == nil {
+		t.Error("NewContactManager accepted a store from a newer version")
+	}
+}
+
+func TestContactManager_MigrateIDMap(t *testing.T) {
+	dir := t.TempDir()
+	offline, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stored when the provider made UIDs from its IDs by replacing
+	// characters that can't go in a file name.
+	old := NewCard("Ada Lovelace")
+	old.SetValue(vcard.FieldUID, "AAMk-item_1=")
+	old.SetValue(FieldProviderID, "AAMk+item/1=")
+	if err := offline.WriteContact(old); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "store.version")); err != nil || string(data) != "2\n" {
+		t.Errorf("store.version without a provider = %q, %v", data, err)
+	}
+
+	remote := NewCard("Ada King")
+	remote.SetValue(vcard.FieldUID, "9f86d081884c7d659a2feaa0c55ad015")
+	remote.SetValue(FieldProviderID, "AAMk+item/1=")
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{remote}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "store.version")); err != nil || string(data) != "3\n" {
+		t.Errorf("store.version = %q, %v", data, err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	cards, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || CardUID(cards[0]) != "AAMk-item_1=" {
+		t.Fatalf("%d contacts after sync, want one under the old UID", len(cards))
+	}
+	if got := CardFullName(cards[0]); got != "Ada King" {
+		t.Errorf("FN = %q, want the synced name", got)
+	}
+}
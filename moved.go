@@ -0,0 +1,68 @@
+package contacts
+
+import (
+	"sort"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Move is a change of a contact's address found in the audit log.
+type Move struct {
+	Time time.Time `json:"time"`
+	UID  string    `json:"uid"`
+	Name string    `json:"name"`
+	// From are the addresses before the change, formatted for display.
+	From []string `json:"from"`
+	// To are the contact's current addresses.
+	To []string `json:"to"`
+}
+
+// Moved returns the contacts whose address changed at or after since,
+// whether edited locally or by sync, most recent first. A contact that
+// moved more than once is listed once, with the addresses it had before
+// the earliest of those changes.
+func (cm *ContactManager) Moved(since time.Time) ([]Move, error) {
+	entries, err := cm.AuditLog("", since)
+	if err != nil {
+		return nil, err
+	}
+	moves := map[string]*Move{}
+	for _, e := range entries {
+		if e.Action != "update" || len(e.PreviousAddresses) == 0 {
+			continue
+		}
+		if m, ok := moves[e.UID]; ok {
+			m.Time = e.Time
+			continue
+		}
+		var from []string
+		for _, adr := range e.PreviousAddresses {
+			if a := FormatAddress(adr); a != "" {
+				from = append(from, a)
+			}
+		}
+		moves[e.UID] = &Move{Time: e.Time, UID: e.UID, Name: e.Name, From: from}
+	}
+
+	out := make([]Move, 0, len(moves))
+	for uid, m := range moves {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			return nil, err
+		}
+		if card == nil {
+			continue
+		}
+		m.Name = CardFullName(card)
+		m.To = []string{}
+		for _, f := range card[vcard.FieldAddress] {
+			if a := FormatAddress(f.Value); a != "" {
+				m.To = append(m.To, a)
+			}
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package contacts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_Moved(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldAddress, ";;1 Old Rd;London;;;UK")
+	stayed := NewCard("Charles Babbage")
+	stayed.SetValue(vcard.FieldAddress, ";;2 Same St;London;;;UK")
+	provider := &mockProvider{contacts: []vcard.Card{card, stayed}}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue(vcard.FieldAddress, ";;3 New Rd;Oxford;;;UK")
+	stayed.SetValue(vcard.FieldNote, "no move")
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	moves, err := cm.Moved(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 1 {
+		t.Fatalf("got %d moves, want 1: %+v", len(moves), moves)
+	}
+	m := moves[0]
+	if m.UID != CardUID(card) || len(m.From) != 1 || m.From[0] != "1 Old Rd, London, UK" || len(m.To) != 1 || m.To[0] != "3 New Rd, Oxford, UK" {
+		t.Errorf("move = %+v", m)
+	}
+
+	if moves, err := cm.Moved(time.Now().Add(time.Hour)); err != nil || len(moves) != 0 {
+		t.Errorf("Moved(future) = %v, %v; want none", moves, err)
+	}
+}
@@ -0,0 +1,103 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NameEntry is a contact's UID and display name, as kept in the names cache.
+type NameEntry struct {
+	UID     string `json:"uid"`
+	Name    string `json:"name"`
+	Starred bool   `json:"starred,omitempty"`
+}
+
+func (cm *ContactManager) namesPath() string {
+	return filepath.Join(cm.dir, "names.json")
+}
+
+// ContactNames returns the UID and name of every contact without decoding
+// the contact files, using a cache that is dropped whenever the manager
+// writes a contact and rebuilt if contact files change behind its back.
+func (cm *ContactManager) ContactNames() ([]NameEntry, error) {
+	fresh, err := cm.namesCacheFresh()
+	if err != nil {
+		return nil, err
+	}
+	if fresh {
+		if data, err := os.ReadFile(cm.namesPath()); err == nil {
+			var names []NameEntry
+			if err := json.Unmarshal(data, &names); err == nil {
+				return names, nil
+			}
+		}
+	}
+	return cm.refreshNamesCache()
+}
+
+// namesCacheFresh reports whether the names cache is newer than the
+// contacts directory (so no files were added or removed) and every contact
+// file in it.
+func (cm *ContactManager) namesCacheFresh() (bool, error) {
+	info, err := os.Stat(cm.namesPath())
+	if err != nil {
+		return false, nil
+	}
+	cached := info.ModTime()
+
+	dir, err := os.Stat(cm.storagePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read contacts directory: %w", err)
+	}
+	if !dir.ModTime().Before(cached) {
+		return false, nil
+	}
+	entries, err := os.ReadDir(cm.storagePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read contacts directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".vcf") {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil || !fi.ModTime().Before(cached) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// invalidateNames drops the names cache so the next ContactNames call
+// rebuilds it.
+func (cm *ContactManager) invalidateNames() {
+	os.Remove(cm.namesPath())
+}
+
+// refreshNamesCache rebuilds the names cache from the contact files.
+func (cm *ContactManager) refreshNamesCache() ([]NameEntry, error) {
+	// Stamp the cache with the time the scan started, so files written
+	// during the scan make it stale.
+	start := time.Now()
+	cards, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]NameEntry, 0, len(cards))
+	for _, card := range cards {
+		names = append(names, NameEntry{UID: CardUID(card), Name: CardFullName(card), Starred: IsStarred(card)})
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal names cache: %w", err)
+	}
+	if err := writeFileAtomic(cm.namesPath(), data, cm.fileMode); err != nil {
+		return nil, fmt.Errorf("failed to write names cache: %w", err)
+	}
+	os.Chtimes(cm.namesPath(), start, start)
+	return names, nil
+}
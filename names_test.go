@@ -0,0 +1,62 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContactManager_ContactNames(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann := NewCard("Ann")
+	if err := cm.WriteContact(ann); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := cm.ContactNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0].Name != "Ann" || names[0].UID != CardUID(ann) {
+		t.Fatalf("ContactNames = %+v, want Ann", names)
+	}
+	if _, err := os.Stat(cm.namesPath()); err != nil {
+		t.Fatalf("cache not written: %v", err)
+	}
+
+	// Age the cache so the next write is unambiguously newer.
+	past := time.Now().Add(-time.Minute)
+	os.Chtimes(cm.namesPath(), past, past)
+	os.Chtimes(cm.storagePath, past.Add(-time.Minute), past.Add(-time.Minute))
+	os.Chtimes(filepath.Join(cm.storagePath, CardUID(ann)+".vcf"), past.Add(-time.Minute), past.Add(-time.Minute))
+	if fresh, _ := cm.namesCacheFresh(); !fresh {
+		t.Fatal("cache should be fresh")
+	}
+
+	ann.SetValue("FN", "Ann Renamed")
+	if err := cm.WriteContact(ann); err != nil {
+		t.Fatal(err)
+	}
+	names, err = cm.ContactNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0].Name != "Ann Renamed" {
+		t.Errorf("after rename: %+v", names)
+	}
+
+	if err := cm.DeleteContact(CardUID(ann)); err != nil {
+		t.Fatal(err)
+	}
+	names, err = cm.ContactNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 {
+		t.Errorf("after delete: %+v", names)
+	}
+}
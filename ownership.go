@@ -0,0 +1,67 @@
+package contacts
+
+import (
+	"fmt"
+
+	"github.com/emersion/go-vcard"
+)
+
+// SourceLocal names the local store in FieldOwners: contacts created or
+// edited here rather than pulled in from a provider.
+const SourceLocal = "local"
+
+// FieldOwners says which source wins a field when copies of a contact from
+// different sources are merged, keyed by vCard property name. A source is
+// a provider name, such as "google", or SourceLocal. The same rules decide
+// MergeLinked views and sync conflicts resolved with a merge strategy.
+type FieldOwners map[string]string
+
+// ParseFieldOwners builds FieldOwners from config rules, whose keys may be
+// aliases such as "phone" or "title" as well as property names.
+func ParseFieldOwners(rules map[string]string) (FieldOwners, error) {
+	owners := make(FieldOwners, len(rules))
+	for key, source := range rules {
+		field := resolveFieldKey(key)
+		switch {
+		case source == "":
+			return nil, fmt.Errorf("invalid field owner for %s: empty source", key)
+		case field == vcard.FieldUID || field == vcard.FieldVersion || field == FieldProviderID:
+			return nil, fmt.Errorf("invalid field owner for %s: the field identifies the contact", key)
+		}
+		owners[field] = source
+	}
+	return owners, nil
+}
+
+// WithFieldOwners makes merges of linked contacts and of sync conflicts
+// take each owned field from its owner's copy.
+func WithFieldOwners(owners FieldOwners) ManagerOption {
+	return func(cm *ContactManager) { cm.fieldOwners = owners }
+}
+
+// Apply replaces each owned field of merged with the values of the owner's
+// copy in sources, keyed by source name. A field is left as merged when its
+// owner has no copy in sources or the owner's copy does not set it.
+func (o FieldOwners) Apply(merged vcard.Card, sources map[string]vcard.Card) {
+	for field, source := range o {
+		card, ok := sources[source]
+		if !ok || len(card[field]) == 0 {
+			continue
+		}
+		merged[field] = copyFields(card[field])
+	}
+}
+
+// cardSources returns the sources a stored copy came from: the providers
+// the ID map has it under, or SourceLocal if none.
+func cardSources(ids IDMap, uid string) []string {
+	providers := ids.IDs(uid)
+	if len(providers) == 0 {
+		return []string{SourceLocal}
+	}
+	out := make([]string, 0, len(providers))
+	for provider := range providers {
+		out = append(out, provider)
+	}
+	return out
+}
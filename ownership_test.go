@@ -0,0 +1,111 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseFieldOwners(t *testing.T) {
+	owners, err := ParseFieldOwners(map[string]string{"phone": "google", "title": "ldap"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owners[vcard.FieldTelephone] != "google" || owners[vcard.FieldTitle] != "ldap" {
+		t.Errorf("owners = %v", owners)
+	}
+	for _, rules := range []map[string]string{{"uid": "google"}, {"email": ""}} {
+		if _, err := ParseFieldOwners(rules); err == nil {
+			t.Errorf("ParseFieldOwners(%v) succeeded, want error", rules)
+		}
+	}
+}
+
+func TestContactManager_MergeLinkedFieldOwners(t *testing.T) {
+	dir := t.TempDir()
+	ids := &memoryIDMaps{m: IDMap{}}
+	owners := FieldOwners{vcard.FieldTelephone: "google", vcard.FieldTitle: SourceLocal}
+	cm, err := NewContactManager(nil, dir, WithIDMapStore(ids), WithFieldOwners(owners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := NewCard("Ada Lovelace")
+	local.SetValue(vcard.FieldTelephone, "+44 20 7946 0000")
+	local.SetValue(vcard.FieldTitle, "Mathematician")
+	remote := NewCard("Ada King")
+	remote.SetValue(vcard.FieldTelephone, "+44 20 7946 0999")
+	remote.SetValue(vcard.FieldTitle, "Analyst")
+	for _, card := range []vcard.Card{local, remote} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids.m.Set("google", "people/1", CardUID(remote))
+	if err := cm.Link(CardUID(local), CardUID(remote)); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := cm.MergeLinked([]vcard.Card{local, remote})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 {
+		t.Fatalf("got %d cards, want 1", len(merged))
+	}
+	got := merged[0]
+	if tels := got[vcard.FieldTelephone]; len(tels) != 1 || tels[0].Value != "+44 20 7946 0999" {
+		t.Errorf("TEL = %v, want only google's number", got.Values(vcard.FieldTelephone))
+	}
+	if got.Value(vcard.FieldTitle) != "Mathematician" {
+		t.Errorf("TITLE = %q, want the local title", got.Value(vcard.FieldTitle))
+	}
+	if CardFullName(got) != "Ada Lovelace" {
+		t.Errorf("FN = %q, want the primary copy's name", CardFullName(got))
+	}
+}
+
+func TestContactManager_SyncConflictFieldOwners(t *testing.T) {
+	dir := t.TempDir()
+	card := NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldTitle, "Analyst")
+	provider := &recordingProvider{mockProvider: mockProvider{contacts: []vcard.Card{card}}}
+	owners := FieldOwners{vcard.FieldTitle: "default"}
+	cm, err := NewContactManager(provider, dir, WithMergeStrategy(StrategyUnion), WithFieldOwners(owners))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local.SetValue(vcard.FieldTitle, "Mathematician")
+	local.SetValue(vcard.FieldNote, "edited by hand")
+	data, err := EncodeCard(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cm.storagePath, CardUID(local)+".vcf"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue(vcard.FieldTitle, "Programmer")
+
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Value(vcard.FieldTitle) != "Programmer" {
+		t.Errorf("TITLE = %q, want the provider's title", got.Value(vcard.FieldTitle))
+	}
+	if got.Value(vcard.FieldNote) != "edited by hand" {
+		t.Errorf("NOTE = %q, want the local note kept", got.Value(vcard.FieldNote))
+	}
+}
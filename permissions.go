@@ -0,0 +1,102 @@
+package contacts
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// Default permissions for the contact store. Contact data is personal, so
+// nothing is readable by other users unless configured otherwise.
+const (
+	DefaultFileMode os.FileMode = 0600
+	DefaultDirMode  os.FileMode = 0700
+)
+
+// WithPermissions sets the modes used for files and directories the manager
+// creates.
+func WithPermissions(fileMode, dirMode os.FileMode) ManagerOption {
+	return func(cm *ContactManager) {
+		cm.fileMode = fileMode
+		cm.dirMode = dirMode
+	}
+}
+
+// parseMode parses an octal permission string such as "0600".
+func parseMode(s string, fallback os.FileMode) (os.FileMode, error) {
+	if s == "" {
+		return fallback, nil
+	}
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0777 {
+		return 0, fmt.Errorf("invalid permission %q: expected octal like 0600", s)
+	}
+	return os.FileMode(n), nil
+}
+
+// PermissionIssue is a path in the store that grants more access than the
+// configured mode allows.
+type PermissionIssue struct {
+	Path string
+	Mode os.FileMode
+	Want os.FileMode
+}
+
+// CheckPermissions walks the store at root and reports files and
+// directories whose permission bits exceed fileMode or dirMode. It always
+// returns no issues on Windows, where Unix permission bits don't apply.
+func CheckPermissions(root string, fileMode, dirMode os.FileMode) ([]PermissionIssue, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+	var issues []PermissionIssue
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		want := fileMode
+		if d.IsDir() {
+			want = dirMode
+		}
+		if mode := info.Mode().Perm(); mode&^want != 0 {
+			issues = append(issues, PermissionIssue{Path: path, Mode: mode, Want: want})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+	return issues, nil
+}
+
+// FixPermissions resets each path to its wanted mode.
+func FixPermissions(issues []PermissionIssue) error {
+	for _, issue := range issues {
+		if err := os.Chmod(issue.Path, issue.Want); err != nil {
+			return fmt.Errorf("failed to fix permissions on %s: %w", issue.Path, err)
+		}
+	}
+	return nil
+}
+
+// StoreExposed reports whether the store directory or its people directory
+// is accessible to group or other users.
+func StoreExposed(dir string) bool {
+	if runtime.GOOS == "windows" {
+		return false
+	}
+	for _, path := range []string{dir, filepath.Join(dir, "people")} {
+		info, err := os.Stat(path)
+		if err == nil && info.Mode().Perm()&0077 != 0 {
+			return true
+		}
+	}
+	return false
+}
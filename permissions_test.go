@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestContactManager_DefaultPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions")
+	}
+	dir := filepath.Join(t.TempDir(), "store")
+	cm, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.WriteContact(NewCard("Private")); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := CheckPermissions(dir, DefaultFileMode, DefaultDirMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no permission issues, got %+v", issues)
+	}
+	if StoreExposed(dir) {
+		t.Error("store should not be exposed")
+	}
+}
+
+func TestCheckAndFixPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions")
+	}
+	dir := t.TempDir()
+	people := filepath.Join(dir, "people")
+	if err := os.MkdirAll(people, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(people, "a.vcf")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !StoreExposed(dir) {
+		t.Error("store should be exposed")
+	}
+
+	issues, err := CheckPermissions(dir, DefaultFileMode, DefaultDirMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	if err := FixPermissions(issues); err != nil {
+		t.Fatal(err)
+	}
+	issues, err = CheckPermissions(dir, DefaultFileMode, DefaultDirMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected issues fixed, got %+v", issues)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode: got %o, want 600", info.Mode().Perm())
+	}
+}
+
+func TestConfig_Permissions(t *testing.T) {
+	cfg := &Config{FileMode: "0640", DirMode: "750"}
+	fileMode, dirMode, err := cfg.Permissions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileMode != 0640 || dirMode != 0750 {
+		t.Errorf("got %o/%o, want 640/750", fileMode, dirMode)
+	}
+	cfg = &Config{FileMode: "rw-------"}
+	if _, _, err := cfg.Permissions(); err == nil {
+		t.Error("expected error for non-octal mode")
+	}
+}
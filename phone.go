@@ -0,0 +1,60 @@
+package contacts
+
+import (
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// minPhoneMatchDigits is the shortest number suffix matching accepts, so
+// short extensions or partial numbers don't match everything.
+const minPhoneMatchDigits = 7
+
+// NormalizePhone reduces a phone number to its digits, dropping an
+// international "00" or "+" prefix and a national trunk "0", so
+// "+1 (555) 123-4567" becomes "15551234567" and "07700 900123" becomes
+// "7700900123".
+func NormalizePhone(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	digits = strings.TrimPrefix(digits, "00")
+	return strings.TrimPrefix(digits, "0")
+}
+
+// PhonesMatch reports whether two phone numbers are the same number written
+// differently. Numbers match if their normalized digits are equal or one
+// is the other with a country code prepended.
+func PhonesMatch(a, b string) bool {
+	a, b = NormalizePhone(a), NormalizePhone(b)
+	if len(a) < minPhoneMatchDigits || len(b) < minPhoneMatchDigits {
+		return a != "" && a == b
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	// A country code is 1-3 digits.
+	return strings.HasSuffix(a, b) && len(a)-len(b) <= 3
+}
+
+// FindByPhone returns the contacts with a phone number matching number.
+func (cm *ContactManager) FindByPhone(number string) ([]vcard.Card, error) {
+	cards, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	var matches []vcard.Card
+	for _, card := range cards {
+		for _, f := range card[vcard.FieldTelephone] {
+			if PhonesMatch(f.Value, number) {
+				matches = append(matches, card)
+				break
+			}
+		}
+	}
+	return matches, nil
+}
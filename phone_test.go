@@ -0,0 +1,66 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"+1 (555) 123-4567", "15551234567"},
+		{"555.123.4567", "5551234567"},
+		{"0044 7700 900123", "447700900123"},
+		{"07700 900123", "7700900123"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizePhone(tt.in); got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPhonesMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"+1 (555) 123-4567", "5551234567", true},
+		{"555-123-4567", "(555) 123 4567", true},
+		{"+44 7700 900123", "07700 900123", true},
+		{"5551234567", "5551234568", false},
+		{"4567", "+1 555 123 4567", false},
+		{"123", "123", true},
+		{"", "", false},
+		{"+49 30 5551234567", "5551234567", false},
+	}
+	for _, tt := range tests {
+		if got := PhonesMatch(tt.a, tt.b); got != tt.want {
+			t.Errorf("PhonesMatch(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestContactManager_FindByPhone(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Jane Doe")
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+1 (555) 123-4567"})
+	other := NewCard("John Roe")
+	other.Add(vcard.FieldTelephone, &vcard.Field{Value: "555-987-6543"})
+	if err := cm.WriteContacts([]vcard.Card{card, other}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cm.FindByPhone("5551234567")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || CardFullName(got[0]) != "Jane Doe" {
+		t.Errorf("FindByPhone returned %d cards, want Jane Doe", len(got))
+	}
+}
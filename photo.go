@@ -0,0 +1,192 @@
+package contacts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// maxPhotoBytes caps the size of a downloaded photo.
+const maxPhotoBytes = 10 << 20
+
+// photoClient fetches photo URLs. PHOTO values come from synced, inbound
+// and API-written cards, so it only connects to public addresses: a card
+// must not make the server request its own admin ports or a cloud
+// metadata service.
+var photoClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: publicAddressOnly}).DialContext,
+	},
+}
+
+// publicAddressOnly is a net.Dialer Control function refusing loopback,
+// private, link-local and unspecified addresses. It runs after the name
+// is resolved, for every address tried, including after redirects.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to fetch a photo from %s: not a public address", ip)
+	}
+	return nil
+}
+
+// FetchPhoto returns the bytes of the card's PHOTO: downloaded from its
+// URL or decoded from a data: URI. It returns nil if the card has no photo.
+func FetchPhoto(card vcard.Card) ([]byte, error) {
+	value := strings.TrimSpace(card.Value(vcard.FieldPhoto))
+	if value == "" {
+		return nil, nil
+	}
+	if rest, ok := strings.CutPrefix(value, "data:"); ok {
+		_, encoded, ok := strings.Cut(rest, ";base64,")
+		if !ok {
+			return nil, fmt.Errorf("unsupported photo data URI")
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode photo: %w", err)
+		}
+		return data, nil
+	}
+	if !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+		return nil, fmt.Errorf("unsupported photo URL %q", value)
+	}
+	resp, err := photoClient.Get(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch photo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch photo: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPhotoBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch photo: %w", err)
+	}
+	if len(data) > maxPhotoBytes {
+		return nil, fmt.Errorf("photo is larger than %d bytes", maxPhotoBytes)
+	}
+	return data, nil
+}
+
+func (cm *ContactManager) photoCachePath(card vcard.Card) string {
+	sum := sha256.Sum256([]byte(card.Value(vcard.FieldPhoto)))
+	return filepath.Join(cm.dir, "photos", hex.EncodeToString(sum[:16]))
+}
+
+// CachedPhoto is FetchPhoto with a read-through cache in the data
+// directory, keyed by the PHOTO value so a changed photo is fetched again.
+func (cm *ContactManager) CachedPhoto(card vcard.Card) ([]byte, error) {
+	if card.Value(vcard.FieldPhoto) == "" {
+		return nil, nil
+	}
+	path := cm.photoCachePath(card)
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+	data, err := FetchPhoto(card)
+	if err != nil || data == nil {
+		return data, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), cm.dirMode); err != nil {
+		return nil, fmt.Errorf("failed to create photo cache: %w", err)
+	}
+	if err := os.WriteFile(path, data, cm.fileMode); err != nil {
+		return nil, fmt.Errorf("failed to write photo cache: %w", err)
+	}
+	return data, nil
+}
+
+// DefaultPhotoSize is the largest width or height, in pixels, of a photo
+// prepared by PreparePhoto unless PhotoOptions say otherwise.
+const DefaultPhotoSize = 720
+
+// PhotoFromFile reads an image file and returns it as a PHOTO value: a
+// data: URI of the image prepared with PreparePhoto, so that it is stored
+// with the contact.
+func PhotoFromFile(path string, opts PhotoOptions) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read photo: %w", err)
+	}
+	data, mime, err := PreparePhoto(data, opts)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mime + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ResizeJPEG scales an image down to fit within size×size pixels, keeping
+// its aspect ratio, and encodes it as JPEG. Smaller images are not
+// enlarged.
+func ResizeJPEG(data []byte, size int) ([]byte, error) {
+	img, err := DecodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, FitImage(img, size), &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("failed to encode photo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FitImage scales img down to fit within size×size, averaging the source
+// pixels that fall in each destination pixel. Smaller images are returned
+// as they are.
+func FitImage(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if size <= 0 || (w <= size && h <= size) {
+		return img
+	}
+	dw, dh := size, size
+	if w > h {
+		dh = max(1, h*size/w)
+	} else {
+		dw = max(1, w*size/h)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+(y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+(x+1)*w/dw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
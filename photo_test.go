@@ -0,0 +1,146 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+// testPNG returns a w×h PNG.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFetchPhoto_DataURI(t *testing.T) {
+	data := testPNG(t, 4, 4)
+	card := NewCard("Ada")
+	if got, err := FetchPhoto(card); err != nil || got != nil {
+		t.Errorf("FetchPhoto(no photo) = %v, %v", got, err)
+	}
+	card.SetValue(vcard.FieldPhoto, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(data))
+	if got, err := FetchPhoto(card); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("FetchPhoto(data URI) = %d bytes, %v", len(got), err)
+	}
+	card.SetValue(vcard.FieldPhoto, "file:///etc/passwd")
+	if _, err := FetchPhoto(card); err == nil {
+		t.Error("FetchPhoto(file URL) succeeded, want error")
+	}
+}
+
+func TestContactManager_CachedPhoto(t *testing.T) {
+	data := testPNG(t, 4, 4)
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Write(data)
+	}))
+	defer ts.Close()
+
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ada")
+	// Cards can't point the photo fetcher at local or internal addresses.
+	for _, photo := range []string{ts.URL + "/ada.png", "http://169.254.169.254/latest/meta-data/", "http://[::1]:8080/admin", "http://10.0.0.1/"} {
+		card.SetValue(vcard.FieldPhoto, photo)
+		if _, err := cm.CachedPhoto(card); err == nil || !strings.Contains(err.Error(), "not a public address") {
+			t.Errorf("CachedPhoto(%s) = %v, want a refusal", photo, err)
+		}
+	}
+	if hits != 0 {
+		t.Fatalf("server on a loopback address fetched %d times", hits)
+	}
+
+	// The test server is local, so fetch with its own client.
+	defer func(c *http.Client) { photoClient = c }(photoClient)
+	photoClient = ts.Client()
+	card.SetValue(vcard.FieldPhoto, ts.URL+"/ada.png")
+	for i := 0; i < 2; i++ {
+		if got, err := cm.CachedPhoto(card); err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("CachedPhoto() = %d bytes, %v", len(got), err)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("photo fetched %d times, want 1", hits)
+	}
+	card.SetValue(vcard.FieldPhoto, ts.URL+"/ada2.png")
+	if _, err := cm.CachedPhoto(card); err != nil || hits != 2 {
+		t.Errorf("changed photo not fetched again: hits %d, err %v", hits, err)
+	}
+}
+
+func TestResizeJPEG(t *testing.T) {
+	tests := []struct {
+		w, h, size   int
+		wantW, wantH int
+	}{
+		{200, 100, 50, 50, 25},
+		{100, 200, 50, 25, 50},
+		{30, 20, 50, 30, 20},
+	}
+	for _, tt := range tests {
+		out, err := ResizeJPEG(testPNG(t, tt.w, tt.h), tt.size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(out))
+		if err != nil || format != "jpeg" {
+			t.Fatalf("decode resized: %v, %s", err, format)
+		}
+		if cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+			t.Errorf("%dx%d fit to %d = %dx%d, want %dx%d", tt.w, tt.h, tt.size, cfg.Width, cfg.Height, tt.wantW, tt.wantH)
+		}
+	}
+	if _, err := ResizeJPEG([]byte("not an image"), 10); err == nil {
+		t.Error("ResizeJPEG(garbage) succeeded, want error")
+	}
+}
+
+func TestPhotoFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "face.png")
+	if err := os.WriteFile(path, testPNG(t, 1440, 900), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	photo, err := PhotoFromFile(path, PhotoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldPhoto, photo)
+	if err := ValidateCard(card); err != nil {
+		t.Errorf("card with attached photo is invalid: %v", err)
+	}
+	data, err := FetchPhoto(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != "jpeg" || cfg.Width != DefaultPhotoSize || cfg.Height != 450 {
+		t.Errorf("attached photo = %dx%d %s, %v; want a %dx450 jpeg", cfg.Width, cfg.Height, format, err, DefaultPhotoSize)
+	}
+	if _, err := PhotoFromFile(filepath.Join(t.TempDir(), "missing.png"), PhotoOptions{}); err == nil {
+		t.Error("PhotoFromFile(missing) succeeded, want error")
+	}
+}
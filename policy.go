@@ -0,0 +1,90 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Modes for FieldPolicy.Mode.
+const (
+	PolicyWarn   = "warn"
+	PolicyRefuse = "refuse"
+)
+
+// FieldPolicy lists fields every contact must have. Each entry of Required
+// is a set of alternatives, any one of which satisfies it, so
+// [["email", "phone"], ["name"]] means "an email or a phone, and a name".
+// Field names are the friendly names accepted by ParseWhere or raw vCard
+// property names.
+type FieldPolicy struct {
+	Required [][]string `json:"required,omitempty"`
+	// Mode is PolicyWarn (the default) to report violations when adding,
+	// editing or importing contacts, or PolicyRefuse to reject them.
+	Mode string `json:"mode,omitempty"`
+}
+
+// PolicyViolation is a contact that doesn't meet a FieldPolicy.
+type PolicyViolation struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+	// Missing describes each unmet requirement, e.g. "email or phone".
+	Missing []string `json:"missing"`
+}
+
+func (v PolicyViolation) Error() string {
+	return fmt.Sprintf("%s is missing %s", v.Name, strings.Join(v.Missing, ", "))
+}
+
+// Validate checks the policy's mode.
+func (p FieldPolicy) Validate() error {
+	switch p.Mode {
+	case "", PolicyWarn, PolicyRefuse:
+		return nil
+	}
+	return fmt.Errorf("invalid policy mode %q: expected warn or refuse", p.Mode)
+}
+
+// Refuses reports whether violations should be rejected rather than warned
+// about.
+func (p FieldPolicy) Refuses() bool {
+	return p.Mode == PolicyRefuse
+}
+
+// Check returns the violation for card, or nil if it meets the policy.
+func (p FieldPolicy) Check(card vcard.Card) *PolicyViolation {
+	var missing []string
+	for _, alternatives := range p.Required {
+		if len(alternatives) == 0 || hasAnyField(card, alternatives) {
+			continue
+		}
+		missing = append(missing, strings.Join(alternatives, " or "))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return &PolicyViolation{UID: CardUID(card), Name: CardFullName(card), Missing: missing}
+}
+
+// Lint returns the violations among cards.
+func (p FieldPolicy) Lint(cards []vcard.Card) []PolicyViolation {
+	var out []PolicyViolation
+	for _, card := range cards {
+		if v := p.Check(card); v != nil {
+			out = append(out, *v)
+		}
+	}
+	return out
+}
+
+func hasAnyField(card vcard.Card, keys []string) bool {
+	for _, key := range keys {
+		for _, f := range card[resolveFieldKey(key)] {
+			if strings.Trim(f.Value, "; ") != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestFieldPolicy_Check(t *testing.T) {
+	policy := FieldPolicy{Required: [][]string{{"email", "phone"}, {"org"}}}
+	withEmail := NewCard("Ada Lovelace")
+	withEmail.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@example.com"})
+	withEmail.SetValue(vcard.FieldOrganization, "Analytical Engines")
+	withPhone := NewCard("Alan Turing")
+	withPhone.Add(vcard.FieldTelephone, &vcard.Field{Value: "+44 20 7946 0000"})
+	bare := NewCard("Grace Hopper")
+	bare.SetValue(vcard.FieldOrganization, ";")
+
+	if v := policy.Check(withEmail); v != nil {
+		t.Errorf("Check(withEmail) = %v, want nil", v)
+	}
+	v := policy.Check(withPhone)
+	if v == nil || len(v.Missing) != 1 || v.Missing[0] != "org" {
+		t.Errorf("Check(withPhone) = %+v, want missing org", v)
+	}
+	v = policy.Check(bare)
+	if v == nil || len(v.Missing) != 2 || v.Missing[0] != "email or phone" {
+		t.Errorf("Check(bare) = %+v, want missing email or phone and org", v)
+	}
+	if got := v.Error(); got != "Grace Hopper is missing email or phone, org" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := policy.Lint([]vcard.Card{withEmail, withPhone, bare}); len(got) != 2 {
+		t.Errorf("Lint found %d violations, want 2", len(got))
+	}
+}
+
+func TestConfig_LoadInvalidPolicy(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"policy": {"required": [["email"]], "mode": "block"}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Dir: dir}
+	if err := cfg.Load(); err == nil {
+		t.Error("Load accepted an invalid policy mode")
+	}
+}
@@ -0,0 +1,67 @@
+package contacts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// IsPreferred reports whether f is marked as preferred among the fields of
+// its property: with a PREF parameter (vCard 4.0), or with TYPE=pref as
+// vCard 3.0 and Apple Contacts write it.
+func IsPreferred(f *vcard.Field) bool {
+	return f.Params.Get(vcard.ParamPreferred) != "" || f.Params.HasType("pref")
+}
+
+// preferredField returns the field of the given property with the lowest
+// PREF, or nil if none is marked preferred.
+func preferredField(card vcard.Card, name string) *vcard.Field {
+	if !slices.ContainsFunc(card[name], IsPreferred) {
+		return nil
+	}
+	return card.Preferred(name)
+}
+
+// SetPreferred marks the field of the given property whose value is value
+// with PREF=1 and clears the preference of the others. Emails match
+// regardless of case and phone numbers regardless of formatting.
+func SetPreferred(card vcard.Card, name, value string) error {
+	fields := card[name]
+	match := slices.IndexFunc(fields, func(f *vcard.Field) bool { return f.Value == value })
+	if match < 0 {
+		match = slices.IndexFunc(fields, func(f *vcard.Field) bool {
+			switch name {
+			case vcard.FieldEmail:
+				return strings.EqualFold(strings.TrimSpace(f.Value), strings.TrimSpace(value))
+			case vcard.FieldTelephone:
+				return PhonesMatch(f.Value, value)
+			}
+			return false
+		})
+	}
+	if match < 0 {
+		return fmt.Errorf("%w: %s has no %s %q", ErrNotFound, CardFullName(card), strings.ToLower(name), value)
+	}
+	for i, f := range fields {
+		if f.Params == nil {
+			f.Params = vcard.Params{}
+		}
+		delete(f.Params, vcard.ParamPreferred)
+		if f.Params.HasType("pref") {
+			f.Params[vcard.ParamType] = slices.DeleteFunc(f.Params[vcard.ParamType], isPrefType)
+			if len(f.Params[vcard.ParamType]) == 0 {
+				delete(f.Params, vcard.ParamType)
+			}
+		}
+		if i == match {
+			f.Params.Set(vcard.ParamPreferred, "1")
+		}
+	}
+	return nil
+}
+
+func isPrefType(t string) bool {
+	return strings.EqualFold(t, "pref")
+}
@@ -0,0 +1,43 @@
+package contacts
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestSetPreferred(t *testing.T) {
+	card := NewCard("Sam Rivera")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "sam@home.example", Params: vcard.Params{vcard.ParamType: {"HOME", "pref"}}})
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "Sam@Work.example"})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+1 (555) 010-0000", Params: vcard.Params{vcard.ParamPreferred: {"1"}}})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+1 (555) 010-0001"})
+
+	if PrimaryEmail(card) != "sam@home.example" {
+		t.Fatalf("PrimaryEmail before = %q", PrimaryEmail(card))
+	}
+	if err := SetPreferred(card, vcard.FieldEmail, "sam@work.example"); err != nil {
+		t.Fatal(err)
+	}
+	if got := PrimaryEmail(card); got != "Sam@Work.example" {
+		t.Errorf("PrimaryEmail = %q, want the work address", got)
+	}
+	if home := card[vcard.FieldEmail][0]; IsPreferred(home) || !home.Params.HasType("home") {
+		t.Errorf("old preference not cleared: %v", home.Params)
+	}
+
+	if err := SetPreferred(card, vcard.FieldTelephone, "15550100001"); err != nil {
+		t.Fatal(err)
+	}
+	if got := PrimaryPhone(card); got != "+1 (555) 010-0001" {
+		t.Errorf("PrimaryPhone = %q", got)
+	}
+	if card[vcard.FieldTelephone][1].Params.Get(vcard.ParamPreferred) != "1" {
+		t.Errorf("PREF = %v, want 1", card[vcard.FieldTelephone][1].Params)
+	}
+
+	if err := SetPreferred(card, vcard.FieldEmail, "nobody@example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown value: got %v, want ErrNotFound", err)
+	}
+}
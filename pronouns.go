@@ -0,0 +1,53 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldPronouns holds the pronouns a contact goes by, such as "she/her"
+// (RFC 9554).
+const FieldPronouns = "PRONOUNS"
+
+// genderSexes names the sex component of a vCard GENDER value.
+var genderSexes = map[string]string{
+	"M": "male",
+	"F": "female",
+	"O": "other",
+	"N": "none",
+	"U": "unknown",
+}
+
+// FormatGender renders a GENDER value for reading. The free-form identity
+// is preferred when there is one ("O;non-binary" reads "non-binary"),
+// otherwise the sex code is spelled out. Values that are not vCard GENDER
+// values, as stored by older syncs, are returned as they are.
+func FormatGender(value string) string {
+	sex, identity, _ := strings.Cut(value, ";")
+	if identity = strings.TrimSpace(identity); identity != "" {
+		return identity
+	}
+	if name, ok := genderSexes[strings.ToUpper(strings.TrimSpace(sex))]; ok {
+		return name
+	}
+	return value
+}
+
+// SetField replaces every value of field, a vCard name or alias such as
+// "pronouns", with value, or removes the field when value is empty. Fields
+// that identify the contact cannot be set. The caller saves card.
+func SetField(card vcard.Card, field, value string) error {
+	key := resolveFieldKey(field)
+	switch key {
+	case "", vcard.FieldUID, vcard.FieldVersion, FieldProviderID:
+		return fmt.Errorf("cannot set %s: the field identifies the contact", field)
+	}
+	if value == "" {
+		delete(card, key)
+		return nil
+	}
+	card.SetValue(key, value)
+	return nil
+}
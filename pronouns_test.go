@@ -0,0 +1,53 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestFormatGender(t *testing.T) {
+	tests := map[string]string{
+		"M":             "male",
+		"f":             "female",
+		"O;non-binary":  "non-binary",
+		"U":             "unknown",
+		"male":          "male",
+		"genderqueer":   "genderqueer",
+		"F;trans woman": "trans woman",
+	}
+	for in, want := range tests {
+		if got := FormatGender(in); got != want {
+			t.Errorf("FormatGender(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetField(t *testing.T) {
+	card := NewCard("Sam Rivera")
+	if err := SetField(card, "pronouns", "they/them"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetField(card, "gender", "O;non-binary"); err != nil {
+		t.Fatal(err)
+	}
+	out := FormatCard(card)
+	for _, want := range []string{"Pronouns:  they/them", "Gender:    non-binary"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatCard missing %q:\n%s", want, out)
+		}
+	}
+	if err := SetField(card, "pronouns", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := card[FieldPronouns]; ok {
+		t.Error("empty value did not remove PRONOUNS")
+	}
+	if err := SetField(card, "uid", "other"); err == nil {
+		t.Error("setting UID succeeded, want error")
+	}
+	if CardUID(card) == "other" || card.Value(vcard.FieldFormattedName) != "Sam Rivera" {
+		t.Error("card changed by a rejected SetField")
+	}
+}
@@ -0,0 +1,468 @@
+// Package carddav implements a contacts.ContactProvider backed by a
+// CardDAV server (RFC 6352), such as Radicale, Baïkal, Nextcloud, iCloud
+// or mailbox.org.
+package carddav
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+var _ contacts.IncrementalProvider = (*Provider)(nil)
+
+// FieldETag holds the server's ETag for the stored copy of a contact, sent
+// with updates so that edits made elsewhere are not overwritten.
+const FieldETag = "X-CARDDAV-ETAG"
+
+// FieldUID holds the UID of the contact's vCard on the server, which may
+// be a URN or a braced GUID that can't name a file. The local UID is
+// derived from the contact's resource name instead, like other
+// providers', and this one is put back on upload.
+const FieldUID = "X-CARDDAV-UID"
+
+// Credentials are the server account and address book stored in
+// carddav_creds.json.
+type Credentials struct {
+	// URL is the server, or an address book on it. Servers are searched
+	// for address books with Discover.
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// AddressBook is the URL of the address book collection to sync.
+	AddressBook string `json:"address_book,omitempty"`
+}
+
+// AddressBook is an address book collection found by Discover.
+type AddressBook struct {
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// Provider syncs contacts with one address book on a CardDAV server.
+type Provider struct {
+	// name is returned by Name and prefixes the provider's files:
+	// contacts.ProviderCardDAV, or contacts.ProviderNextcloud or
+	// contacts.ProviderICloud for those servers, whose quirks are handled
+	// too (see nextcloud.go and icloud.go).
+	name          string
+	client        *http.Client
+	creds         providerutil.CredentialsFile[Credentials]
+	syncToken     string
+	syncTokenPath string
+	// pendingSyncToken is saved by CommitSync.
+	pendingSyncToken string
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	return newProvider(dir, contacts.ProviderCardDAV)
+}
+
+func newProvider(dir, name string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		name:          name,
+		client:        &http.Client{Timeout: time.Minute},
+		creds:         providerutil.NewCredentialsFile[Credentials](filepath.Join(dir, name+"_creds.json")),
+		syncTokenPath: filepath.Join(dir, name+"_sync_token.txt"),
+	}, nil
+}
+
+func (p *Provider) SaveCredentials(creds *Credentials) error {
+	return p.creds.Save(creds)
+}
+
+// LoadCredentials returns a copy of the stored credentials. The file is
+// read on the first call only; later calls return what was last loaded or
+// saved.
+func (p *Provider) LoadCredentials() (*Credentials, error) {
+	return p.creds.Load()
+}
+
+func (p *Provider) Initialize() error {
+	creds, err := p.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	if creds.AddressBook == "" {
+		return fmt.Errorf("%w: no address book selected: please run init first", contacts.ErrNotInitialized)
+	}
+	if data, err := os.ReadFile(p.syncTokenPath); err == nil {
+		p.syncToken = string(data)
+	}
+	return nil
+}
+
+// SelectAddressBook sets the address book to sync. Changing it forgets the
+// sync token, so the next sync fetches the new address book in full.
+func (p *Provider) SelectAddressBook(bookURL string) error {
+	creds, err := p.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	bookURL = asCollection(bookURL)
+	if creds.AddressBook != bookURL {
+		p.syncToken = ""
+		if err := os.Remove(p.syncTokenPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove sync token: %w", err)
+		}
+	}
+	creds.AddressBook = bookURL
+	return p.SaveCredentials(creds)
+}
+
+func (p *Provider) SaveSyncToken(token string) error {
+	p.syncToken = token
+	return os.WriteFile(p.syncTokenPath, []byte(token), 0600)
+}
+
+// Name keys the server's contacts in the manager's ID map.
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Discover lists the address books the stored credentials can reach. The
+// URL may be an address book itself, any URL that reports the user's
+// principal, or just the server, which is searched through its
+// /.well-known/carddav URL (RFC 6764).
+func (p *Provider) Discover() ([]AddressBook, error) {
+	creds, err := p.LoadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	start := creds.URL
+	if !strings.Contains(start, "://") {
+		start = "https://" + start
+	}
+	ms, err := p.multistatus("PROPFIND", start, "0", propfindPrincipal)
+	var principal string
+	if err == nil && len(ms.Responses) > 0 {
+		found := ms.Responses[0].found()
+		if found.isAddressBook() {
+			return []AddressBook{{URL: asCollection(ms.Responses[0].Href), Name: found.DisplayName}}, nil
+		}
+		if found.CurrentUserPrincipal != nil {
+			principal = resolveOn(ms.Responses[0].Href, found.CurrentUserPrincipal.Href)
+		}
+	}
+	if principal == "" {
+		if principal, err = p.wellKnownPrincipal(start); err != nil {
+			return nil, err
+		}
+	}
+
+	ms, err = p.multistatus("PROPFIND", principal, "0", propfindHomeSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find address books of %s: %w", principal, err)
+	}
+	var home string
+	for _, r := range ms.Responses {
+		if found := r.found(); found.AddressbookHomeSet != nil {
+			home = resolveOn(r.Href, found.AddressbookHomeSet.Href)
+		}
+	}
+	if home == "" {
+		return nil, fmt.Errorf("%w: %s has no addressbook-home-set", contacts.ErrNotFound, principal)
+	}
+
+	ms, err = p.multistatus("PROPFIND", asCollection(home), "1", propfindCollections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list address books in %s: %w", home, err)
+	}
+	var books []AddressBook
+	for _, r := range ms.Responses {
+		if found := r.found(); found.isAddressBook() {
+			name := found.DisplayName
+			if name == "" {
+				name = path.Base(strings.TrimSuffix(r.Href, "/"))
+			}
+			books = append(books, AddressBook{URL: asCollection(r.Href), Name: name})
+		}
+	}
+	if len(books) == 0 {
+		return nil, fmt.Errorf("%w: no address books in %s", contacts.ErrNotFound, home)
+	}
+	return books, nil
+}
+
+// wellKnownPrincipal finds the user's principal through the server's
+// /.well-known/carddav URL, which usually redirects to the CardDAV root.
+func (p *Provider) wellKnownPrincipal(server string) (string, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL %q: %w", server, err)
+	}
+	target := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/.well-known/carddav"}).String()
+	// Redirects are followed by hand: net/http would turn the PROPFIND
+	// into a GET.
+	noRedirect := *p.client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	for range 5 {
+		resp, err := p.request(&noRedirect, "PROPFIND", target, "0", propfindPrincipal, nil)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+		loc := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || loc == "" {
+			break
+		}
+		target = resolveOn(target, loc)
+	}
+	ms, err := p.multistatus("PROPFIND", target, "0", propfindPrincipal)
+	if err != nil {
+		return "", fmt.Errorf("no CardDAV service found at %s: %w", server, err)
+	}
+	for _, r := range ms.Responses {
+		if found := r.found(); found.CurrentUserPrincipal != nil {
+			return resolveOn(r.Href, found.CurrentUserPrincipal.Href), nil
+		}
+	}
+	return "", fmt.Errorf("%w: no CardDAV principal found at %s", contacts.ErrNotFound, server)
+}
+
+// FetchContacts returns every contact in the address book.
+func (p *Provider) FetchContacts() ([]vcard.Card, error) {
+	if p.creds.Cached() == nil || p.creds.Cached().AddressBook == "" {
+		return nil, contacts.ErrNotInitialized
+	}
+	ms, err := p.multistatus("PROPFIND", p.creds.Cached().AddressBook, "1", propfindETags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	var hrefs []string
+	for _, r := range ms.Responses {
+		if !r.found().isCollection() && statusOK(r.Status) {
+			hrefs = append(hrefs, r.Href)
+		}
+	}
+	return p.multiget(hrefs)
+}
+
+// FetchChanges returns the contacts changed or deleted in the address book
+// since the last committed sync, using a sync-collection report (RFC
+// 6578). Without a sync token, or if the server has expired it, every
+// contact is returned as changed. Servers without sync-collection support
+// get a full fetch every time.
+func (p *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	if p.creds.Cached() == nil || p.creds.Cached().AddressBook == "" {
+		return nil, nil, contacts.ErrNotInitialized
+	}
+	hrefs, gone, token, err := p.syncCollection(p.syncToken)
+	if errors.Is(err, errSyncTokenInvalid) {
+		hrefs, gone, token, err = p.syncCollection("")
+	}
+	if errors.Is(err, errSyncUnsupported) {
+		changed, err = p.FetchContacts()
+		return changed, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if changed, err = p.multiget(hrefs); err != nil {
+		return nil, nil, err
+	}
+	for _, href := range gone {
+		deleted = append(deleted, resourceID(href))
+	}
+	p.pendingSyncToken = token
+	return changed, deleted, nil
+}
+
+// CommitSync saves the sync token from the last FetchChanges, once its
+// changes have been stored locally.
+func (p *Provider) CommitSync() error {
+	if p.pendingSyncToken == "" {
+		return nil
+	}
+	if err := p.SaveSyncToken(p.pendingSyncToken); err != nil {
+		return fmt.Errorf("failed to save sync token: %w", err)
+	}
+	p.pendingSyncToken = ""
+	return nil
+}
+
+// syncCollection returns the hrefs of contacts changed and removed since
+// token, and the token to pass next time.
+func (p *Provider) syncCollection(token string) (changed, removed []string, next string, err error) {
+	ms, err := p.multistatus("REPORT", p.creds.Cached().AddressBook, "", fmt.Sprintf(syncCollection, html.EscapeString(token)))
+	if err != nil {
+		var he *httpError
+		if errors.As(err, &he) {
+			switch {
+			case token != "" && strings.Contains(he.Body, "valid-sync-token"):
+				return nil, nil, "", errSyncTokenInvalid
+			case he.Status == http.StatusBadRequest, he.Status == http.StatusForbidden, he.Status == http.StatusNotImplemented,
+				he.Status == http.StatusMethodNotAllowed, he.Status == http.StatusUnsupportedMediaType:
+				return nil, nil, "", errSyncUnsupported
+			}
+		}
+		return nil, nil, "", fmt.Errorf("failed to fetch changes: %w", err)
+	}
+	for _, r := range ms.Responses {
+		switch {
+		case strings.Contains(r.Status, " 404"):
+			removed = append(removed, r.Href)
+		case !r.found().isCollection() && asCollection(r.Href) != p.creds.Cached().AddressBook:
+			changed = append(changed, r.Href)
+		}
+	}
+	return changed, removed, ms.SyncToken, nil
+}
+
+// multiget fetches the contacts at hrefs with an addressbook-multiget
+// report.
+func (p *Provider) multiget(hrefs []string) ([]vcard.Card, error) {
+	if len(hrefs) == 0 {
+		return nil, nil
+	}
+	var b strings.Builder
+	for _, href := range hrefs {
+		u, err := url.Parse(href)
+		if err != nil {
+			return nil, fmt.Errorf("invalid contact URL %q: %w", href, err)
+		}
+		b.WriteString("<d:href>" + html.EscapeString(u.EscapedPath()) + "</d:href>")
+	}
+	ms, err := p.multistatus("REPORT", p.creds.Cached().AddressBook, "", fmt.Sprintf(addressbookMultiget, b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+	}
+	var cards []vcard.Card
+	for _, r := range ms.Responses {
+		found := r.found()
+		if found.AddressData == "" {
+			continue
+		}
+		card, err := contacts.DecodeCard([]byte(found.AddressData))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse contact %s: %w", r.Href, err)
+		}
+		id := resourceID(r.Href)
+		if uid := contacts.CardUID(card); uid != "" {
+			card.SetValue(FieldUID, uid)
+		}
+		card.SetValue(vcard.FieldUID, providerutil.LocalUID(id))
+		card.SetValue(contacts.FieldProviderID, id)
+		if found.ETag != "" {
+			card.SetValue(FieldETag, found.ETag)
+		}
+		switch p.name {
+		case contacts.ProviderNextcloud:
+			p.fromNextcloud(card)
+		case contacts.ProviderICloud:
+			p.inlinePhotos(card)
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// WriteContact creates or updates the contact's resource in the address
+// book. Updates are conditional on the ETag last synced, so a contact
+// changed on the server since fails with contacts.ErrConflict.
+func (p *Provider) WriteContact(card vcard.Card) error {
+	if p.creds.Cached() == nil || p.creds.Cached().AddressBook == "" {
+		return contacts.ErrNotInitialized
+	}
+	id := contacts.ProviderID(card)
+	header := http.Header{"Content-Type": {"text/vcard; charset=utf-8"}}
+	if id == "" {
+		id = contacts.CardUID(card) + ".vcf"
+		header.Set("If-None-Match", "*")
+	} else if etag := card.Value(FieldETag); etag != "" {
+		header.Set("If-Match", etag)
+	}
+	encode := contacts.EncodeCard
+	if p.name == contacts.ProviderNextcloud {
+		encode = encodeNextcloud
+	}
+	data, err := encode(serverCard(card))
+	if err != nil {
+		return err
+	}
+	target := p.creds.Cached().AddressBook + url.PathEscape(id)
+	resp, err := p.request(p.client, "PUT", target, "", string(data), header)
+	if err != nil {
+		return fmt.Errorf("failed to update contact %s: %w", contacts.CardFullName(card), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to update contact %s (status %d): %s", contacts.CardFullName(card), resp.StatusCode, string(body)))
+	}
+	card.SetValue(contacts.FieldProviderID, id)
+	// Without an ETag in the response the next sync brings the new one.
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		card.SetValue(FieldETag, etag)
+	} else {
+		delete(card, FieldETag)
+	}
+	return nil
+}
+
+func (p *Provider) DeleteContact(id string) error {
+	if p.creds.Cached() == nil || p.creds.Cached().AddressBook == "" {
+		return contacts.ErrNotInitialized
+	}
+	resp, err := p.request(p.client, "DELETE", p.creds.Cached().AddressBook+url.PathEscape(id), "", "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete contact %s: %w", id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to delete contact %s (status %d): %s", id, resp.StatusCode, string(body)))
+	}
+	return nil
+}
+
+// serverCard returns a copy of card without the fields that only make
+// sense in the local store, and with the server's UID if it has one.
+func serverCard(card vcard.Card) vcard.Card {
+	out := make(vcard.Card, len(card))
+	for k, v := range card {
+		switch k {
+		case contacts.FieldProviderID, FieldETag, FieldUID, "X-LAST-SYNCED":
+			continue
+		}
+		out[k] = v
+	}
+	if uid := card.Value(FieldUID); uid != "" {
+		out.SetValue(vcard.FieldUID, uid)
+	}
+	return out
+}
+
+// resourceID returns the name of a contact's resource in its address
+// book, which is its provider ID.
+func resourceID(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return path.Base(href)
+	}
+	return path.Base(u.Path)
+}
+
+// resolveOn resolves href relative to the absolute URL base.
+func resolveOn(base, href string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return href
+	}
+	return resolve(u, href)
+}
@@ -0,0 +1,338 @@
+package carddav
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+const bookPath = "/dav/books/ada/contacts/"
+
+// photoPath serves a photo that needs the account's credentials.
+const photoPath = "/dav/photos/ada.png"
+
+// fakeServer is a minimal CardDAV server holding one address book.
+type fakeServer struct {
+	mu      sync.Mutex
+	version int
+	cards   map[string]string // resource name -> vCard
+	etags   map[string]string
+	changed map[string]int // resource name -> version of last change
+	deleted map[string]int
+	// noSync makes sync-collection reports fail as unsupported.
+	noSync bool
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{cards: map[string]string{}, etags: map[string]string{}, changed: map[string]int{}, deleted: map[string]int{}}
+}
+
+func (s *fakeServer) put(name, data string) string {
+	s.version++
+	s.cards[name] = data
+	s.etags[name] = fmt.Sprintf(`"v%d"`, s.version)
+	s.changed[name] = s.version
+	delete(s.deleted, name)
+	return s.etags[name]
+}
+
+func (s *fakeServer) remove(name string) {
+	s.version++
+	delete(s.cards, name)
+	delete(s.etags, name)
+	delete(s.changed, name)
+	s.deleted[name] = s.version
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "ada" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	multistatus := func(inner string, extra ...string) {
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:card="urn:ietf:params:xml:ns:carddav">%s%s</d:multistatus>`, inner, strings.Join(extra, ""))
+	}
+	ok := func(href, props string) string {
+		return fmt.Sprintf(`<d:response><d:href>%s</d:href><d:propstat><d:prop>%s</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`, href, props)
+	}
+	name := path.Base(r.URL.Path)
+
+	switch {
+	case r.Method == "PROPFIND" && r.URL.Path == "/.well-known/carddav":
+		http.Redirect(w, r, "/dav/", http.StatusMovedPermanently)
+	case r.Method == "PROPFIND" && r.URL.Path == "/dav/":
+		multistatus(ok("/dav/", `<d:resourcetype><d:collection/></d:resourcetype><d:current-user-principal><d:href>/dav/principals/ada/</d:href></d:current-user-principal>`))
+	case r.Method == "PROPFIND" && r.URL.Path == "/dav/principals/ada/":
+		multistatus(ok("/dav/principals/ada/", `<card:addressbook-home-set><d:href>/dav/books/ada/</d:href></card:addressbook-home-set>`))
+	case r.Method == "PROPFIND" && r.URL.Path == "/dav/books/ada/":
+		multistatus(ok("/dav/books/ada/", `<d:resourcetype><d:collection/></d:resourcetype>`),
+			ok(bookPath, `<d:resourcetype><d:collection/><card:addressbook/></d:resourcetype><d:displayname>Contacts</d:displayname>`),
+			ok("/dav/books/ada/archive/", `<d:resourcetype><d:collection/><card:addressbook/></d:resourcetype>`))
+	case r.Method == "PROPFIND" && r.URL.Path == bookPath:
+		out := []string{ok(bookPath, `<d:resourcetype><d:collection/><card:addressbook/></d:resourcetype>`)}
+		for name, etag := range s.etags {
+			out = append(out, ok(bookPath+name, `<d:resourcetype/><d:getetag>`+etag+`</d:getetag>`))
+		}
+		multistatus(strings.Join(out, ""))
+	case r.Method == "REPORT" && strings.Contains(string(body), "sync-collection"):
+		if s.noSync {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		since := 0
+		if m := regexp.MustCompile(`<d:sync-token>tok-(\d+)</d:sync-token>`).FindStringSubmatch(string(body)); m != nil {
+			fmt.Sscan(m[1], &since)
+		} else if strings.Contains(string(body), "<d:sync-token>bogus") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<d:error xmlns:d="DAV:"><d:valid-sync-token/></d:error>`)
+			return
+		}
+		var out []string
+		for name, v := range s.changed {
+			if v > since {
+				out = append(out, ok(bookPath+name, `<d:getetag>`+s.etags[name]+`</d:getetag>`))
+			}
+		}
+		for name, v := range s.deleted {
+			if v > since && since > 0 {
+				out = append(out, fmt.Sprintf(`<d:response><d:href>%s</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>`, bookPath+name))
+			}
+		}
+		sort.Strings(out)
+		multistatus(strings.Join(out, ""), fmt.Sprintf(`<d:sync-token>tok-%d</d:sync-token>`, s.version))
+	case r.Method == "REPORT" && strings.Contains(string(body), "addressbook-multiget"):
+		var out []string
+		for _, m := range regexp.MustCompile(`<d:href>([^<]+)</d:href>`).FindAllStringSubmatch(string(body), -1) {
+			name := path.Base(m[1])
+			if data, found := s.cards[name]; found {
+				out = append(out, ok(m[1], `<d:getetag>`+s.etags[name]+`</d:getetag><card:address-data>`+data+`</card:address-data>`))
+			}
+		}
+		multistatus(strings.Join(out, ""))
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, bookPath):
+		_, exists := s.cards[name]
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && match != s.etags[name] {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", s.put(name, string(body)))
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "GET" && r.URL.Path == photoPath:
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, bookPath):
+		if _, exists := s.cards[name]; !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.remove(name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func vcf(uid, name string) string {
+	return "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:" + uid + "\r\nFN:" + name + "\r\nEND:VCARD\r\n"
+}
+
+func newTestProvider(t *testing.T, creds Credentials) *Provider {
+	t.Helper()
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SaveCredentials(&creds); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProvider_Discover(t *testing.T) {
+	srv := httptest.NewServer(newFakeServer())
+	defer srv.Close()
+
+	// The server URL is resolved through /.well-known/carddav.
+	p := newTestProvider(t, Credentials{URL: srv.URL, Username: "ada", Password: "secret"})
+	books, err := p.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || books[0].URL != srv.URL+bookPath || books[0].Name != "Contacts" || books[1].Name != "archive" {
+		t.Errorf("Discover = %+v", books)
+	}
+
+	// An address book URL is used as it is.
+	p = newTestProvider(t, Credentials{URL: srv.URL + bookPath, Username: "ada", Password: "secret"})
+	if books, err = p.Discover(); err != nil || len(books) != 1 || books[0].URL != srv.URL+bookPath {
+		t.Errorf("Discover(address book) = %+v, %v", books, err)
+	}
+
+	p = newTestProvider(t, Credentials{URL: srv.URL, Username: "ada", Password: "wrong"})
+	if _, err := p.Discover(); !errors.Is(err, contacts.ErrAuthExpired) {
+		t.Errorf("Discover with a wrong password = %v, want ErrAuthExpired", err)
+	}
+}
+
+func TestProvider_Sync(t *testing.T) {
+	fake := newFakeServer()
+	fake.put("ada.vcf", vcf("ada", "Ada Lovelace"))
+	fake.put("bob.vcf", vcf("bob", "Bob"))
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	p := newTestProvider(t, Credentials{URL: srv.URL, Username: "ada", Password: "secret"})
+	if err := p.SelectAddressBook(srv.URL + bookPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := p.FetchContacts()
+	if err != nil || len(all) != 2 {
+		t.Fatalf("FetchContacts = %d cards, %v", len(all), err)
+	}
+
+	changed, deleted, err := p.FetchChanges()
+	if err != nil || len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("initial FetchChanges = %d changed, %v deleted, %v", len(changed), deleted, err)
+	}
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _, _ = p.FetchChanges(); len(changed) != 0 {
+		t.Errorf("FetchChanges with nothing changed = %d cards", len(changed))
+	}
+
+	fake.mu.Lock()
+	fake.put("bob.vcf", vcf("bob", "Robert"))
+	fake.remove("ada.vcf")
+	fake.mu.Unlock()
+	changed, deleted, err = p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || contacts.CardFullName(changed[0]) != "Robert" || contacts.ProviderID(changed[0]) != "bob.vcf" {
+		t.Errorf("changed = %v", changed)
+	}
+	if len(deleted) != 1 || deleted[0] != "ada.vcf" {
+		t.Errorf("deleted = %v, want [ada.vcf]", deleted)
+	}
+
+	// An expired token falls back to a full listing.
+	p.syncToken = "bogus"
+	if changed, _, err = p.FetchChanges(); err != nil || len(changed) != 1 {
+		t.Errorf("FetchChanges with an expired token = %d cards, %v", len(changed), err)
+	}
+
+	// Servers without sync-collection get a full fetch.
+	fake.noSync = true
+	if changed, _, err = p.FetchChanges(); err != nil || len(changed) != 1 {
+		t.Errorf("FetchChanges without sync-collection = %d cards, %v", len(changed), err)
+	}
+}
+
+func TestProvider_SyncServerUIDs(t *testing.T) {
+	for _, uid := range []string{"urn:uuid:0b8e3ad4-5d7e-4b0c-9d55-3f7c2b1a9e10", "{7A1C9F2E-4B3D-4E8A-9C6F-1D2E3F4A5B6C}"} {
+		t.Run(uid, func(t *testing.T) {
+			fake := newFakeServer()
+			fake.put("ada.vcf", vcf(uid, "Ada Lovelace"))
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+			p := newTestProvider(t, Credentials{URL: srv.URL, Username: "ada", Password: "secret", AddressBook: srv.URL + bookPath})
+			if err := p.Initialize(); err != nil {
+				t.Fatal(err)
+			}
+			cm, err := contacts.NewContactManager(p, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cm.SyncContacts(); err != nil {
+				t.Fatal(err)
+			}
+			cards, err := cm.ListContacts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cards) != 1 {
+				t.Fatalf("stored %d contacts, want the one with UID %s", len(cards), uid)
+			}
+			card := cards[0]
+			if card.Value(FieldUID) != uid {
+				t.Errorf("%s = %q, want the server's UID", FieldUID, card.Value(FieldUID))
+			}
+
+			// Writing the contact back keeps the server's UID.
+			card.SetValue(vcard.FieldFormattedName, "Ada King")
+			if err := cm.WriteContact(card); err != nil {
+				t.Fatal(err)
+			}
+			stored := fake.cards["ada.vcf"]
+			if !strings.Contains(stored, "UID:"+uid+"\r\n") || strings.Contains(stored, FieldUID) || !strings.Contains(stored, "FN:Ada King") {
+				t.Errorf("stored card =\n%s", stored)
+			}
+		})
+	}
+}
+
+func TestProvider_WriteAndDelete(t *testing.T) {
+	fake := newFakeServer()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	p := newTestProvider(t, Credentials{URL: srv.URL, Username: "ada", Password: "secret", AddressBook: srv.URL + bookPath})
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	card := contacts.NewCard("Carol")
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	id := contacts.ProviderID(card)
+	if id != contacts.CardUID(card)+".vcf" || card.Value(FieldETag) == "" {
+		t.Fatalf("created card has provider ID %q and ETag %q", id, card.Value(FieldETag))
+	}
+	if stored := fake.cards[id]; !strings.Contains(stored, "FN:Carol") || strings.Contains(stored, contacts.FieldProviderID) {
+		t.Errorf("stored card =\n%s", stored)
+	}
+
+	card.SetValue(vcard.FieldFormattedName, "Carol Shaw")
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+
+	// An edit made on the server since the last sync is not overwritten.
+	fake.mu.Lock()
+	fake.put(id, vcf(contacts.CardUID(card), "Carol S."))
+	fake.mu.Unlock()
+	if err := p.WriteContact(card); !errors.Is(err, contacts.ErrConflict) {
+		t.Errorf("WriteContact over a server edit = %v, want ErrConflict", err)
+	}
+
+	if err := p.DeleteContact(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.DeleteContact(id); !errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("second DeleteContact = %v, want ErrNotFound", err)
+	}
+}
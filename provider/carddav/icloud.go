@@ -0,0 +1,73 @@
+package carddav
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// iCloudServer is where iCloud serves CardDAV. It only reports the
+// account's principal, which lives on a per-account host such as
+// p42-contacts.icloud.com.
+var iCloudServer = "https://contacts.icloud.com"
+
+// iCloudDefaultBook is the name of the single address book iCloud keeps
+// for every account.
+const iCloudDefaultBook = "card"
+
+// ICloudAppPasswordURL is where app-specific passwords for an Apple ID are
+// created, under Sign-In and Security.
+const ICloudAppPasswordURL = "https://appleid.apple.com/account/manage"
+
+// appPasswordPattern matches an app-specific password as Apple shows it.
+var appPasswordPattern = regexp.MustCompile(`^[a-z]{4}-[a-z]{4}-[a-z]{4}-[a-z]{4}$`)
+
+// NewICloudProvider returns a provider for the contacts of an Apple ID. It
+// keeps its own credentials and sync token, so it can be set up next to a
+// generic CardDAV account.
+func NewICloudProvider(dir string) (*Provider, error) {
+	return newProvider(dir, contacts.ProviderICloud)
+}
+
+// NormalizeICloudAppPassword returns an app-specific password as iCloud
+// expects it, in lower case and without surrounding space, or an error if
+// it does not look like one. The password of the Apple ID itself is
+// rejected by iCloud's CardDAV server, so catching it early saves a
+// confusing authorization error.
+func NormalizeICloudAppPassword(password string) (string, error) {
+	password = strings.ToLower(strings.TrimSpace(password))
+	if !appPasswordPattern.MatchString(password) {
+		return "", fmt.Errorf("not an app-specific password: expected the form xxxx-xxxx-xxxx-xxxx")
+	}
+	return password, nil
+}
+
+// SetupICloud stores the Apple ID and app-specific password, finds the
+// account's principal and selects its address book.
+func (p *Provider) SetupICloud(appleID, appPassword string) (AddressBook, error) {
+	creds := &Credentials{URL: iCloudServer, Username: strings.TrimSpace(appleID), Password: strings.TrimSpace(appPassword)}
+	if err := p.SaveCredentials(creds); err != nil {
+		return AddressBook{}, err
+	}
+	books, err := p.Discover()
+	if errors.Is(err, contacts.ErrAuthExpired) {
+		return AddressBook{}, fmt.Errorf("iCloud rejected the sign-in; check the Apple ID and use an app-specific password, not the account password: %w", err)
+	}
+	if err != nil {
+		return AddressBook{}, fmt.Errorf("failed to find address books: %w", err)
+	}
+	book := books[0]
+	for _, b := range books {
+		if path := strings.TrimSuffix(b.URL, "/"); strings.HasSuffix(path, "/"+iCloudDefaultBook) {
+			book = b
+			break
+		}
+	}
+	if err := p.SelectAddressBook(book.URL); err != nil {
+		return AddressBook{}, err
+	}
+	return book, nil
+}
@@ -0,0 +1,93 @@
+package carddav
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+func TestNormalizeICloudAppPassword(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"abcd-efgh-ijkl-mnop", "abcd-efgh-ijkl-mnop"},
+		{" ABCD-efgh-IJKL-mnop\n", "abcd-efgh-ijkl-mnop"},
+		{"hunter2", ""},
+		{"abcdefghijklmnop", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeICloudAppPassword(tt.in)
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("NormalizeICloudAppPassword(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+// fakeICloud serves a CardDAV account the way iCloud does: the well-known
+// server only reports the principal, which lives on another host.
+func fakeICloud(t *testing.T, fake *fakeServer) (partition *httptest.Server) {
+	t.Helper()
+	partition = httptest.NewServer(fake)
+	t.Cleanup(partition.Close)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "ada" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != "PROPFIND" || r.URL.Path != "/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:"><d:response><d:href>/</d:href><d:propstat><d:prop><d:current-user-principal><d:href>%s/dav/principals/ada/</d:href></d:current-user-principal></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response></d:multistatus>`, partition.URL)
+	}))
+	t.Cleanup(gateway.Close)
+	old := iCloudServer
+	iCloudServer = gateway.URL
+	t.Cleanup(func() { iCloudServer = old })
+	return partition
+}
+
+func TestProvider_SetupICloud(t *testing.T) {
+	fake := newFakeServer()
+	fake.put("ada.vcf", "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:ada\r\nFN:Ada\r\nPHOTO;ENCODING=b;TYPE=JPEG:/9j/4A==\r\nEND:VCARD\r\n")
+	partition := fakeICloud(t, fake)
+
+	dir := t.TempDir()
+	p, err := NewICloudProvider(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SetupICloud("ada", "wrong"); !errors.Is(err, contacts.ErrAuthExpired) || !strings.Contains(err.Error(), "app-specific password") {
+		t.Errorf("SetupICloud with a wrong password = %v", err)
+	}
+	book, err := p.SetupICloud("ada", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.URL != partition.URL+bookPath {
+		t.Errorf("selected %q, want the book on the account's host", book.URL)
+	}
+	if p.Name() != contacts.ProviderICloud {
+		t.Errorf("Name() = %q", p.Name())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "icloud_creds.json")); err != nil {
+		t.Errorf("credentials not saved apart from CardDAV's: %v", err)
+	}
+
+	cards, err := p.FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].Value(vcard.FieldPhoto) != "data:image/jpeg;base64,/9j/4A==" {
+		t.Errorf("photo not inlined: %v", cards)
+	}
+}
@@ -0,0 +1,212 @@
+package carddav
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+// nextcloudDAVPath is where Nextcloud serves CardDAV, below the server
+// root.
+const nextcloudDAVPath = "/remote.php/dav"
+
+// nextcloudDefaultBook is the name of the address book Nextcloud creates
+// for every user.
+const nextcloudDefaultBook = "contacts"
+
+// maxPhotoBytes bounds the photos inlined by fromNextcloud.
+const maxPhotoBytes = 5 << 20
+
+// NewNextcloudProvider returns a provider for a Nextcloud server. It keeps
+// its own credentials and sync token, so it can be set up next to a
+// generic CardDAV account.
+func NewNextcloudProvider(dir string) (*Provider, error) {
+	return newProvider(dir, contacts.ProviderNextcloud)
+}
+
+// SetupNextcloud stores a Nextcloud account and selects its default
+// address book, or the first one found if the user has deleted it. The
+// server may be given with or without its scheme and with any path below
+// its root, such as a link copied from the web interface.
+func (p *Provider) SetupNextcloud(server, username, appPassword string) (AddressBook, error) {
+	root, err := nextcloudRoot(server)
+	if err != nil {
+		return AddressBook{}, err
+	}
+	creds := &Credentials{URL: root + nextcloudDAVPath, Username: username, Password: appPassword}
+	if err := p.SaveCredentials(creds); err != nil {
+		return AddressBook{}, err
+	}
+	books, err := p.Discover()
+	if err != nil {
+		return AddressBook{}, fmt.Errorf("failed to find address books: %w", err)
+	}
+	book := books[0]
+	for _, b := range books {
+		if path := strings.TrimSuffix(b.URL, "/"); strings.HasSuffix(path, "/"+nextcloudDefaultBook) {
+			book = b
+			break
+		}
+	}
+	if err := p.SelectAddressBook(book.URL); err != nil {
+		return AddressBook{}, err
+	}
+	return book, nil
+}
+
+// nextcloudRoot returns the root of the Nextcloud server at rawURL,
+// dropping the path of any page or endpoint below it.
+func nextcloudRoot(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q", rawURL)
+	}
+	for _, marker := range []string{"/index.php", "/remote.php", "/apps/"} {
+		if i := strings.Index(u.Path, marker); i >= 0 {
+			u.Path = u.Path[:i]
+		}
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawQuery, u.Fragment = "", ""
+	return u.String(), nil
+}
+
+// fromNextcloud adapts a card fetched from Nextcloud to the local store:
+// its photos are inlined, and several CATEGORIES, as left by other
+// clients, are merged into one.
+func (p *Provider) fromNextcloud(card vcard.Card) {
+	p.inlinePhotos(card)
+	if len(card[vcard.FieldCategories]) > 1 {
+		var groups []string
+		for _, f := range card[vcard.FieldCategories] {
+			for _, c := range strings.Split(f.Value, ",") {
+				if c = strings.TrimSpace(c); c != "" {
+					groups = append(groups, c)
+				}
+			}
+		}
+		card.SetCategories(nextcloudCategories(groups))
+	}
+}
+
+// inlinePhotos turns the photos of a card from a server that keeps vCard
+// 3.0, such as Nextcloud or iCloud, into data: URIs. They arrive
+// base64-encoded with ENCODING=b, or as links back to the server that need
+// the account's credentials.
+func (p *Provider) inlinePhotos(card vcard.Card) {
+	for _, f := range card[vcard.FieldPhoto] {
+		encoding := strings.ToLower(f.Params.Get("ENCODING"))
+		switch {
+		case encoding == "b" || encoding == "base64":
+			typ := strings.ToLower(f.Params.Get(vcard.ParamType))
+			if typ == "" {
+				typ = "jpeg"
+			}
+			if !strings.Contains(typ, "/") {
+				typ = "image/" + typ
+			}
+			f.Value = "data:" + typ + ";base64," + f.Value
+		case p.onServer(f.Value):
+			if data, err := p.inlinePhoto(f.Value); err == nil {
+				f.Value = data
+			}
+		}
+		if strings.HasPrefix(f.Value, "data:") {
+			delete(f.Params, "ENCODING")
+			delete(f.Params, vcard.ParamType)
+			delete(f.Params, vcard.ParamValue)
+		}
+	}
+}
+
+// encodeNextcloud encodes a card to be written to Nextcloud, whose
+// Contacts app only knows groups as CATEGORIES and only reads the first of
+// them. Labelled group memberships from another provider, such as a
+// contact migrated from Google, become categories too. The categories are
+// written as a list: the vCard encoder escapes their commas, which would
+// make them a single category.
+func encodeNextcloud(card vcard.Card) ([]byte, error) {
+	categories := nextcloudCategories(contacts.CardGroupLabels(card))
+	delete(card, "X-GOOGLE-GROUP-MEMBERSHIP")
+	delete(card, vcard.FieldCategories)
+	if len(categories) > 0 {
+		card.SetCategories(categories)
+	}
+	data, err := contacts.EncodeCard(card)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\r\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, vcard.FieldCategories+":") {
+			lines[i] = strings.ReplaceAll(line, `\,`, ",")
+		}
+	}
+	return []byte(strings.Join(lines, "\r\n")), nil
+}
+
+// nextcloudCategories returns groups without duplicates, keeping the
+// first spelling of each, and without Google's resource names, which mean
+// nothing to Nextcloud.
+func nextcloudCategories(groups []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, g := range groups {
+		key := strings.ToLower(g)
+		if seen[key] || strings.HasPrefix(g, "contactGroups/") {
+			continue
+		}
+		seen[key] = true
+		out = append(out, g)
+	}
+	return out
+}
+
+// onServer reports whether value is a URL on the provider's server.
+func (p *Provider) onServer(value string) bool {
+	if !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+		return false
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	book, err := url.Parse(p.creds.Cached().AddressBook)
+	return err == nil && u.Host == book.Host
+}
+
+// inlinePhoto downloads a photo from the server with the account's
+// credentials and returns it as a data: URI.
+func (p *Provider) inlinePhoto(target string) (string, error) {
+	resp, err := p.request(p.client, "GET", target, "", "", nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch photo: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPhotoBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch photo: %w", err)
+	}
+	if len(data) > maxPhotoBytes {
+		return "", fmt.Errorf("photo is larger than %d bytes", maxPhotoBytes)
+	}
+	typ, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(typ, "image/") {
+		typ = http.DetectContentType(data)
+	}
+	return "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,108 @@
+package carddav
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+func TestNextcloudRoot(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"cloud.example.com", "https://cloud.example.com"},
+		{"https://cloud.example.com/", "https://cloud.example.com"},
+		{"https://example.com/nextcloud/index.php/apps/contacts/All", "https://example.com/nextcloud"},
+		{"https://cloud.example.com/remote.php/dav/addressbooks/users/ada/contacts/", "https://cloud.example.com"},
+		{"http://localhost:8080/apps/files/?dir=/", "http://localhost:8080"},
+	}
+	for _, tt := range tests {
+		got, err := nextcloudRoot(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("nextcloudRoot(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := nextcloudRoot("https://"); err == nil {
+		t.Error("expected an error for a URL without a host")
+	}
+}
+
+func TestProvider_SetupNextcloud(t *testing.T) {
+	srv := httptest.NewServer(newFakeServer())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	p, err := NewNextcloudProvider(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book, err := p.SetupNextcloud(srv.URL+"/index.php/apps/contacts/All", "ada", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book.URL != srv.URL+bookPath {
+		t.Errorf("selected %q, want the default address book", book.URL)
+	}
+	if p.Name() != contacts.ProviderNextcloud {
+		t.Errorf("Name() = %q", p.Name())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nextcloud_creds.json")); err != nil {
+		t.Errorf("credentials not saved apart from CardDAV's: %v", err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvider_NextcloudQuirks(t *testing.T) {
+	fake := newFakeServer()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	fake.put("ada.vcf", "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:ada\r\nFN:Ada\r\nPHOTO;ENCODING=b;TYPE=JPEG:/9j/4A==\r\nCATEGORIES:Family\r\nCATEGORIES:Friends,family\r\nEND:VCARD\r\n")
+	fake.put("bob.vcf", "BEGIN:VCARD\r\nVERSION:3.0\r\nUID:bob\r\nFN:Bob\r\nPHOTO;VALUE=uri:"+srv.URL+photoPath+"\r\nEND:VCARD\r\n")
+
+	p, err := NewNextcloudProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.SetupNextcloud(srv.URL, "ada", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	cards, err := p.FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]vcard.Card{}
+	for _, card := range cards {
+		byID[contacts.ProviderID(card)] = card
+	}
+	ada := byID["ada.vcf"]
+	if got := ada.Get(vcard.FieldPhoto); got == nil || got.Value != "data:image/jpeg;base64,/9j/4A==" || len(got.Params) != 0 {
+		t.Errorf("base64 photo = %+v, want a data URI", got)
+	}
+	if got := ada[vcard.FieldCategories]; len(got) != 1 || got[0].Value != "Family,Friends" {
+		t.Errorf("categories = %+v, want one merged field", got)
+	}
+	if got := byID["bob.vcf"].Value(vcard.FieldPhoto); got != "data:image/png;base64,cG5n" {
+		t.Errorf("server photo = %q, want it inlined", got)
+	}
+
+	card := contacts.NewCard("Carol")
+	card.AddValue(vcard.FieldCategories, "Work")
+	card.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/abc", Params: vcard.Params{contacts.ParamGroupLabel: {"Book Club"}}})
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	stored := fake.cards[contacts.ProviderID(card)]
+	if !strings.Contains(stored, "CATEGORIES:Book Club,Work") || strings.Contains(stored, "X-GOOGLE-GROUP-MEMBERSHIP") {
+		t.Errorf("stored card:\n%s\nwant groups as one CATEGORIES", stored)
+	}
+	if card.Get("X-GOOGLE-GROUP-MEMBERSHIP") == nil {
+		t.Error("the local card lost its group membership")
+	}
+}
@@ -0,0 +1,212 @@
+package carddav
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+)
+
+// WebDAV (RFC 4918), CardDAV (RFC 6352) and sync-collection (RFC 6578)
+// request bodies.
+const (
+	propfindPrincipal = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:current-user-principal/></d:prop></d:propfind>`
+
+	propfindHomeSet = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav"><d:prop><c:addressbook-home-set/></d:prop></d:propfind>`
+
+	propfindCollections = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:displayname/></d:prop></d:propfind>`
+
+	propfindETags = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getetag/></d:prop></d:propfind>`
+
+	syncCollection = `<?xml version="1.0" encoding="utf-8"?>
+<d:sync-collection xmlns:d="DAV:"><d:sync-token>%s</d:sync-token><d:sync-level>1</d:sync-level><d:prop><d:getetag/></d:prop></d:sync-collection>`
+
+	addressbookMultiget = `<?xml version="1.0" encoding="utf-8"?>
+<c:addressbook-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:carddav"><d:prop><d:getetag/><c:address-data/></d:prop>%s</c:addressbook-multiget>`
+)
+
+type multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []response `xml:"DAV: response"`
+	SyncToken string     `xml:"DAV: sync-token"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Status    string     `xml:"DAV: status"`
+	Propstats []propstat `xml:"DAV: propstat"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type prop struct {
+	ResourceType         *resourceType `xml:"DAV: resourcetype"`
+	DisplayName          string        `xml:"DAV: displayname"`
+	ETag                 string        `xml:"DAV: getetag"`
+	CurrentUserPrincipal *hrefProp     `xml:"DAV: current-user-principal"`
+	AddressbookHomeSet   *hrefProp     `xml:"urn:ietf:params:xml:ns:carddav addressbook-home-set"`
+	AddressData          string        `xml:"urn:ietf:params:xml:ns:carddav address-data"`
+}
+
+type resourceType struct {
+	Collection  *struct{} `xml:"DAV: collection"`
+	AddressBook *struct{} `xml:"urn:ietf:params:xml:ns:carddav addressbook"`
+}
+
+type hrefProp struct {
+	Href string `xml:"DAV: href"`
+}
+
+// found returns the properties the server returned with status 200.
+func (r response) found() prop {
+	var out prop
+	for _, ps := range r.Propstats {
+		if !statusOK(ps.Status) {
+			continue
+		}
+		if ps.Prop.ResourceType != nil {
+			out.ResourceType = ps.Prop.ResourceType
+		}
+		if ps.Prop.DisplayName != "" {
+			out.DisplayName = ps.Prop.DisplayName
+		}
+		if ps.Prop.ETag != "" {
+			out.ETag = ps.Prop.ETag
+		}
+		if ps.Prop.CurrentUserPrincipal != nil {
+			out.CurrentUserPrincipal = ps.Prop.CurrentUserPrincipal
+		}
+		if ps.Prop.AddressbookHomeSet != nil {
+			out.AddressbookHomeSet = ps.Prop.AddressbookHomeSet
+		}
+		if ps.Prop.AddressData != "" {
+			out.AddressData = ps.Prop.AddressData
+		}
+	}
+	return out
+}
+
+// isAddressBook reports whether the resource is an address book
+// collection.
+func (p prop) isAddressBook() bool {
+	return p.ResourceType != nil && p.ResourceType.AddressBook != nil
+}
+
+// isCollection reports whether the resource is any collection.
+func (p prop) isCollection() bool {
+	return p.ResourceType != nil && (p.ResourceType.Collection != nil || p.ResourceType.AddressBook != nil)
+}
+
+// statusOK reports whether a DAV:status line such as "HTTP/1.1 200 OK"
+// is a success. A missing status counts as success.
+func statusOK(status string) bool {
+	fields := strings.Fields(status)
+	return len(fields) < 2 || strings.HasPrefix(fields[1], "2")
+}
+
+// errSyncTokenInvalid is returned by syncCollection when the server no
+// longer accepts the sync token and a full sync is needed.
+var errSyncTokenInvalid = errors.New("sync token invalid")
+
+// errSyncUnsupported is returned by syncCollection when the server does
+// not support sync-collection reports.
+var errSyncUnsupported = errors.New("sync-collection not supported")
+
+// request sends a WebDAV request with the provider's credentials. depth
+// is omitted when empty.
+func (p *Provider) request(client *http.Client, method, target, depth, body string, header http.Header) (*http.Response, error) {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, target, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request for %s: %w", method, target, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", `application/xml; charset=utf-8`)
+	}
+	if depth != "" {
+		req.Header.Set("Depth", depth)
+	}
+	req.SetBasicAuth(p.creds.Cached().Username, p.creds.Cached().Password)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s %s: %w", contacts.ErrProviderUnavailable, method, target, err)
+	}
+	return resp, nil
+}
+
+// multistatus sends a PROPFIND or REPORT and parses its 207 response.
+// Hrefs in the result are resolved against target.
+func (p *Provider) multistatus(method, target, depth, body string) (*multistatus, error) {
+	resp, err := p.request(p.client, method, target, depth, body, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read %s response: %w", contacts.ErrProviderUnavailable, method, err)
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, errors.Join(providerutil.StatusError(resp.StatusCode), &httpError{Method: method, URL: target, Status: resp.StatusCode, Body: string(data)})
+	}
+	var ms multistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response from %s: %w", method, target, err)
+	}
+	base, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range ms.Responses {
+		ms.Responses[i].Href = resolve(base, r.Href)
+	}
+	return &ms, nil
+}
+
+// httpError is an unexpected HTTP status from the server.
+type httpError struct {
+	Method string
+	URL    string
+	Status int
+	Body   string
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%s %s failed (status %d): %s", e.Method, e.URL, e.Status, strings.TrimSpace(e.Body))
+}
+
+// resolve returns href as an absolute URL relative to base.
+func resolve(base *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// asCollection returns u with a trailing slash.
+func asCollection(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
@@ -0,0 +1,517 @@
+package ews
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+// ewsContact is the part of an EWS Contact item that is synced.
+type ewsContact struct {
+	ItemID            itemID            `xml:"ItemId"`
+	Body              string            `xml:"Body"`
+	Categories        []string          `xml:"Categories>String"`
+	DisplayName       string            `xml:"DisplayName"`
+	GivenName         string            `xml:"GivenName"`
+	MiddleName        string            `xml:"MiddleName"`
+	Nickname          string            `xml:"Nickname"`
+	Title             string            `xml:"CompleteName>Title"`
+	CompanyName       string            `xml:"CompanyName"`
+	EmailAddresses    []dictEntry       `xml:"EmailAddresses>Entry"`
+	PhysicalAddresses []physicalAddress `xml:"PhysicalAddresses>Entry"`
+	PhoneNumbers      []dictEntry       `xml:"PhoneNumbers>Entry"`
+	Birthday          string            `xml:"Birthday"`
+	BusinessHomePage  string            `xml:"BusinessHomePage"`
+	Children          []string          `xml:"Children>String"`
+	Department        string            `xml:"Department"`
+	Generation        string            `xml:"Generation"`
+	ImAddresses       []dictEntry       `xml:"ImAddresses>Entry"`
+	JobTitle          string            `xml:"JobTitle"`
+	SpouseName        string            `xml:"SpouseName"`
+	Surname           string            `xml:"Surname"`
+}
+
+type dictEntry struct {
+	Key string `xml:"Key,attr"`
+	// RoutingType is "EX" for email addresses of people in the same
+	// organization, whose value is then an Exchange address rather than
+	// an SMTP one.
+	RoutingType string `xml:"RoutingType,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type physicalAddress struct {
+	Key             string `xml:"Key,attr"`
+	Street          string `xml:"Street"`
+	City            string `xml:"City"`
+	State           string `xml:"State"`
+	CountryOrRegion string `xml:"CountryOrRegion"`
+	PostalCode      string `xml:"PostalCode"`
+}
+
+// addressKeys pairs each EWS physical address with its ADR TYPE.
+var addressKeys = []struct{ key, typ string }{
+	{"Business", "work"},
+	{"Home", "home"},
+	{"Other", "other"},
+}
+
+// addressFields are the components of a physical address in schema
+// order, with their position in an ADR value.
+var addressFields = []struct {
+	name string
+	adr  int
+}{
+	{"Street", 2},
+	{"City", 3},
+	{"State", 4},
+	{"CountryOrRegion", 6},
+	{"PostalCode", 5},
+}
+
+// phoneKeys are the EWS phone numbers with the TEL types they map to.
+// Keys without types are read as plain numbers and written to
+// OtherTelephone.
+var phoneKeys = []struct {
+	key   string
+	types []string
+}{
+	{"MobilePhone", []string{"cell"}},
+	{"BusinessPhone", []string{"work"}},
+	{"BusinessPhone2", []string{"work"}},
+	{"HomePhone", []string{"home"}},
+	{"HomePhone2", []string{"home"}},
+	{"BusinessFax", []string{"work", "fax"}},
+	{"HomeFax", []string{"home", "fax"}},
+	{"OtherFax", []string{"fax"}},
+	{"Pager", []string{"pager"}},
+	{"TtyTddPhone", []string{"textphone"}},
+	{"OtherTelephone", nil},
+	{"PrimaryPhone", nil},
+	{"AssistantPhone", nil},
+	{"Callback", nil},
+	{"CarPhone", nil},
+	{"CompanyMainPhone", nil},
+	{"Isdn", nil},
+	{"RadioPhone", nil},
+	{"Telex", nil},
+}
+
+// maxEmails and maxIMs are how many email and IM addresses an Exchange
+// contact holds.
+const (
+	maxEmails = 3
+	maxIMs    = 3
+)
+
+// --- Conversion: EWS → vcard.Card ---
+
+func convertEWSToCard(c ewsContact) vcard.Card {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+	card.SetValue(vcard.FieldUID, providerutil.LocalUID(c.ItemID.ID))
+	card.SetValue(contacts.FieldProviderID, c.ItemID.ID)
+	if c.ItemID.ChangeKey != "" {
+		card.SetValue(FieldChangeKey, c.ItemID.ChangeKey)
+	}
+
+	// Names → FN, N
+	if c.DisplayName != "" {
+		card.SetValue(vcard.FieldFormattedName, c.DisplayName)
+	}
+	if c.Surname != "" || c.GivenName != "" || c.MiddleName != "" || c.Title != "" || c.Generation != "" {
+		card.SetValue(vcard.FieldName, c.Surname+";"+c.GivenName+";"+c.MiddleName+";"+c.Title+";"+c.Generation)
+	}
+	if c.Nickname != "" {
+		card.SetValue(vcard.FieldNickname, c.Nickname)
+	}
+
+	// EmailAddresses → EMAIL, in key order
+	emails := append([]dictEntry{}, c.EmailAddresses...)
+	sort.SliceStable(emails, func(i, j int) bool { return emails[i].Key < emails[j].Key })
+	for _, e := range emails {
+		if v := strings.TrimSpace(e.Value); v != "" && !strings.EqualFold(e.RoutingType, "EX") {
+			card.Add(vcard.FieldEmail, &vcard.Field{Value: v, Params: vcard.Params{}})
+		}
+	}
+
+	// PhoneNumbers → TEL
+	for _, e := range c.PhoneNumbers {
+		v := strings.TrimSpace(e.Value)
+		if v == "" {
+			continue
+		}
+		field := &vcard.Field{Value: v, Params: vcard.Params{}}
+		for _, k := range phoneKeys {
+			if k.key == e.Key && k.types != nil {
+				field.Params[vcard.ParamType] = append([]string{}, k.types...)
+			}
+		}
+		card.Add(vcard.FieldTelephone, field)
+	}
+
+	// Company → ORG, TITLE
+	if c.CompanyName != "" || c.Department != "" {
+		org := c.CompanyName
+		if c.Department != "" {
+			org += ";" + c.Department
+		}
+		card.SetValue(vcard.FieldOrganization, org)
+	}
+	if c.JobTitle != "" {
+		card.SetValue(vcard.FieldTitle, c.JobTitle)
+	}
+
+	// PhysicalAddresses → ADR
+	for _, k := range addressKeys {
+		for _, a := range c.PhysicalAddresses {
+			if a.Key != k.key || a == (physicalAddress{Key: a.Key}) {
+				continue
+			}
+			// ADR: PO Box;Extended;Street;City;Region;PostalCode;Country
+			card.Add(vcard.FieldAddress, &vcard.Field{
+				Value:  ";;" + a.Street + ";" + a.City + ";" + a.State + ";" + a.PostalCode + ";" + a.CountryOrRegion,
+				Params: vcard.Params{vcard.ParamType: {k.typ}},
+			})
+		}
+	}
+
+	// Birthday → BDAY
+	if bday := parseEWSDate(c.Birthday); !bday.IsZero() {
+		if bday.Year() == providerutil.NoYear {
+			card.SetValue(vcard.FieldBirthday, bday.Format("--0102"))
+		} else {
+			card.SetValue(vcard.FieldBirthday, bday.Format("20060102"))
+		}
+	}
+
+	// Body → NOTE
+	if note := strings.TrimRight(strings.ReplaceAll(c.Body, "\r\n", "\n"), "\n"); note != "" {
+		card.SetValue(vcard.FieldNote, note)
+	}
+
+	// BusinessHomePage → URL
+	if c.BusinessHomePage != "" {
+		card.Add(vcard.FieldURL, &vcard.Field{Value: c.BusinessHomePage, Params: vcard.Params{vcard.ParamType: {"work"}}})
+	}
+
+	// ImAddresses → IMPP
+	for _, im := range c.ImAddresses {
+		if v := strings.TrimSpace(im.Value); v != "" {
+			card.Add(vcard.FieldIMPP, &vcard.Field{Value: v})
+		}
+	}
+
+	// SpouseName, Children → RELATED
+	if c.SpouseName != "" {
+		card.Add(vcard.FieldRelated, &vcard.Field{Value: c.SpouseName, Params: vcard.Params{vcard.ParamType: {"spouse"}}})
+	}
+	for _, child := range c.Children {
+		if child != "" {
+			card.Add(vcard.FieldRelated, &vcard.Field{Value: child, Params: vcard.Params{vcard.ParamType: {"child"}}})
+		}
+	}
+
+	// Categories → CATEGORIES
+	if len(c.Categories) > 0 {
+		card.SetValue(vcard.FieldCategories, strings.Join(c.Categories, ","))
+	}
+
+	// Ensure FN is set (vCard requires it)
+	if contacts.CardFullName(card) == "" {
+		name := strings.Join(strings.Fields(c.GivenName+" "+c.MiddleName+" "+c.Surname), " ")
+		if name == "" && len(card[vcard.FieldEmail]) > 0 {
+			name = card.Value(vcard.FieldEmail)
+		}
+		if name == "" {
+			name = c.CompanyName
+		}
+		if name == "" {
+			name = providerutil.LocalUID(c.ItemID.ID)
+		}
+		card.SetValue(vcard.FieldFormattedName, name)
+	}
+
+	return card
+}
+
+// parseEWSDate parses an xs:dateTime birthday. Exchange stores birthdays
+// as midnight in the time zone of whoever entered them, so the time is
+// rounded to the nearest UTC day.
+func parseEWSDate(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	t = t.UTC().Add(12 * time.Hour)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// --- Conversion: vcard.Card → EWS ---
+
+// property is one EWS contact property, which an update sets or deletes
+// on its own.
+type property struct {
+	// uri is the property's FieldURI, such as "contacts:GivenName".
+	uri string
+	// index is the dictionary key of an indexed property, such as
+	// "EmailAddress1"; elem is then the dictionary.
+	index string
+	elem  string
+	// field is the component of a physical address entry.
+	field string
+	attrs string
+	// value is the escaped XML content, empty to delete the property.
+	value string
+}
+
+// contactProperties returns every synced property of card, in the order
+// the EWS schema wants them, empty where the card has no value so that an
+// update clears what was removed locally.
+func contactProperties(card vcard.Card) []property {
+	var props []property
+	simple := func(uri, elem, value string) {
+		props = append(props, property{uri: uri, elem: elem, value: escape(value)})
+	}
+	list := func(uri, elem string, values []string) {
+		var b strings.Builder
+		for _, v := range values {
+			b.WriteString("<t:String>" + escape(v) + "</t:String>")
+		}
+		props = append(props, property{uri: uri, elem: elem, value: b.String()})
+	}
+
+	// NOTE → Body
+	var notes []string
+	for _, f := range card[vcard.FieldNote] {
+		notes = append(notes, f.Value)
+	}
+	props = append(props, property{uri: "item:Body", elem: "Body", attrs: ` BodyType="Text"`, value: escape(strings.Join(notes, "\n"))})
+
+	// CATEGORIES → Categories
+	var categories []string
+	for _, f := range card[vcard.FieldCategories] {
+		for _, c := range strings.Split(f.Value, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				categories = append(categories, c)
+			}
+		}
+	}
+	list("item:Categories", "Categories", categories)
+
+	// FN, N → names
+	name := strings.Split(card.Value(vcard.FieldName), ";")
+	for len(name) < 5 {
+		name = append(name, "")
+	}
+	simple("contacts:DisplayName", "DisplayName", contacts.CardFullName(card))
+	simple("contacts:GivenName", "GivenName", name[1])
+	simple("contacts:MiddleName", "MiddleName", name[2])
+	simple("contacts:Nickname", "Nickname", card.Value(vcard.FieldNickname))
+
+	// ORG → CompanyName
+	org := strings.SplitN(card.Value(vcard.FieldOrganization), ";", 2)
+	simple("contacts:CompanyName", "CompanyName", org[0])
+
+	// EMAIL → EmailAddresses
+	for i := range maxEmails {
+		p := property{uri: "contacts:EmailAddress", index: "EmailAddress" + string(rune('1'+i)), elem: "EmailAddresses"}
+		if i < len(card[vcard.FieldEmail]) {
+			p.value = escape(card[vcard.FieldEmail][i].Value)
+		}
+		props = append(props, p)
+	}
+
+	// ADR → PhysicalAddresses. An address without a known TYPE takes the
+	// first of home and other still free.
+	addresses := map[string][]string{}
+	var untyped [][]string
+	for _, f := range card[vcard.FieldAddress] {
+		parts := strings.Split(f.Value, ";")
+		for len(parts) < 7 {
+			parts = append(parts, "")
+		}
+		// The PO box and extended address are joined to the street.
+		var street []string
+		for _, s := range parts[:3] {
+			if s = strings.TrimSpace(s); s != "" {
+				street = append(street, s)
+			}
+		}
+		parts[2] = strings.Join(street, "\n")
+		typed := false
+		for _, k := range addressKeys {
+			if f.Params.HasType(k.typ) && addresses[k.key] == nil {
+				addresses[k.key], typed = parts, true
+				break
+			}
+		}
+		if !typed {
+			untyped = append(untyped, parts)
+		}
+	}
+	for _, parts := range untyped {
+		for _, key := range []string{"Home", "Other"} {
+			if addresses[key] == nil {
+				addresses[key] = parts
+				break
+			}
+		}
+	}
+	for _, k := range addressKeys {
+		for _, f := range addressFields {
+			p := property{uri: "contacts:PhysicalAddress:" + f.name, index: k.key, elem: "PhysicalAddresses", field: f.name}
+			if parts := addresses[k.key]; parts != nil {
+				p.value = escape(parts[f.adr])
+			}
+			props = append(props, p)
+		}
+	}
+
+	// TEL → PhoneNumbers. Numbers without a free slot of their type go
+	// to OtherTelephone, or are dropped once that is taken.
+	phones := map[string]string{}
+	for _, f := range card[vcard.FieldTelephone] {
+		for _, key := range phoneSlots(f.Params) {
+			if phones[key] == "" {
+				phones[key] = f.Value
+				break
+			}
+		}
+	}
+	for _, k := range phoneKeys {
+		props = append(props, property{uri: "contacts:PhoneNumber", index: k.key, elem: "PhoneNumbers", value: escape(phones[k.key])})
+	}
+
+	// BDAY → Birthday
+	bday := ""
+	if t := providerutil.ParseBirthday(card.Value(vcard.FieldBirthday)); !t.IsZero() {
+		bday = t.Format(time.RFC3339)
+	}
+	simple("contacts:Birthday", "Birthday", bday)
+
+	// URL → BusinessHomePage
+	simple("contacts:BusinessHomePage", "BusinessHomePage", card.Value(vcard.FieldURL))
+
+	// RELATED → Children, SpouseName
+	spouse := ""
+	var children []string
+	for _, f := range card[vcard.FieldRelated] {
+		switch {
+		case spouse == "" && f.Params.HasType("spouse"):
+			spouse = f.Value
+		case f.Params.HasType("child"):
+			children = append(children, f.Value)
+		}
+	}
+	list("contacts:Children", "Children", children)
+
+	// ORG → Department
+	department := ""
+	if len(org) > 1 {
+		department = org[1]
+	}
+	simple("contacts:Department", "Department", department)
+	simple("contacts:Generation", "Generation", name[4])
+
+	// IMPP → ImAddresses
+	for i := range maxIMs {
+		p := property{uri: "contacts:ImAddress", index: "ImAddress" + string(rune('1'+i)), elem: "ImAddresses"}
+		if i < len(card[vcard.FieldIMPP]) {
+			p.value = escape(card[vcard.FieldIMPP][i].Value)
+		}
+		props = append(props, p)
+	}
+
+	// TITLE → JobTitle
+	simple("contacts:JobTitle", "JobTitle", card.Value(vcard.FieldTitle))
+	simple("contacts:SpouseName", "SpouseName", spouse)
+	simple("contacts:Surname", "Surname", name[0])
+	return props
+}
+
+// phoneSlots returns the EWS phone numbers a TEL of the given type can be
+// stored in, in order of preference.
+func phoneSlots(params vcard.Params) []string {
+	var slots []string
+	switch {
+	case params.HasType("fax") && params.HasType("work"):
+		slots = []string{"BusinessFax"}
+	case params.HasType("fax") && params.HasType("home"):
+		slots = []string{"HomeFax"}
+	case params.HasType("fax"):
+		slots = []string{"OtherFax"}
+	case params.HasType("pager"):
+		slots = []string{"Pager"}
+	case params.HasType("textphone"):
+		slots = []string{"TtyTddPhone"}
+	case params.HasType("cell"), params.HasType("mobile"):
+		slots = []string{"MobilePhone"}
+	case params.HasType("work"):
+		slots = []string{"BusinessPhone", "BusinessPhone2"}
+	case params.HasType("home"):
+		slots = []string{"HomePhone", "HomePhone2"}
+	}
+	return append(slots, "OtherTelephone")
+}
+
+// renderContact returns a t:Contact element with the properties that have
+// values, grouping dictionary entries and the components of an address.
+func renderContact(props []property) string {
+	var b strings.Builder
+	b.WriteString("<t:Contact>")
+	for i := 0; i < len(props); {
+		p := props[i]
+		if p.index == "" {
+			if p.value != "" {
+				b.WriteString("<t:" + p.elem + p.attrs + ">" + p.value + "</t:" + p.elem + ">")
+			}
+			i++
+			continue
+		}
+		var entries strings.Builder
+		for i < len(props) && props[i].elem == p.elem {
+			key := props[i].index
+			var entry strings.Builder
+			for ; i < len(props) && props[i].elem == p.elem && props[i].index == key; i++ {
+				switch q := props[i]; {
+				case q.value == "":
+				case q.field != "":
+					entry.WriteString("<t:" + q.field + ">" + q.value + "</t:" + q.field + ">")
+				default:
+					entry.WriteString(q.value)
+				}
+			}
+			if entry.Len() > 0 {
+				entries.WriteString(`<t:Entry Key="` + key + `">` + entry.String() + "</t:Entry>")
+			}
+		}
+		if entries.Len() > 0 {
+			b.WriteString("<t:" + p.elem + ">" + entries.String() + "</t:" + p.elem + ">")
+		}
+	}
+	b.WriteString("</t:Contact>")
+	return b.String()
+}
+
+// renderUpdates returns the item changes that set every property with a
+// value and delete the rest.
+func renderUpdates(props []property) string {
+	var b strings.Builder
+	for _, p := range props {
+		uri := `<t:FieldURI FieldURI="` + p.uri + `"/>`
+		if p.index != "" {
+			uri = `<t:IndexedFieldURI FieldURI="` + p.uri + `" FieldIndex="` + p.index + `"/>`
+		}
+		if p.value == "" {
+			b.WriteString("<t:DeleteItemField>" + uri + "</t:DeleteItemField>")
+		} else {
+			b.WriteString("<t:SetItemField>" + uri + renderContact([]property{p}) + "</t:SetItemField>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,34 @@
+package ews
+
+import (
+	"net/http"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+)
+
+// statusError maps an HTTP status from Exchange to one of the sentinel
+// errors, or nil if the status has no specific meaning. Exchange reports
+// missing items with a response code (see responseCodeError), so a 404
+// means a wrong server URL rather than a missing contact.
+func statusError(status int) error {
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return providerutil.StatusError(status)
+}
+
+// responseCodeError maps an EWS response code to one of the sentinel
+// errors, or nil if the code has no specific meaning.
+func responseCodeError(code string) error {
+	switch code {
+	case "ErrorItemNotFound":
+		return contacts.ErrNotFound
+	case "ErrorIrresolvableConflict", "ErrorStaleObject":
+		return contacts.ErrConflict
+	case "ErrorServerBusy", "ErrorTimeoutExpired", "ErrorMailboxStoreUnavailable",
+		"ErrorMailboxMoveInProgress", "ErrorInternalServerTransientError":
+		return contacts.ErrProviderUnavailable
+	}
+	return nil
+}
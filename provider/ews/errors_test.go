@@ -0,0 +1,61 @@
+package ews
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+)
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, nil},
+		{http.StatusUnauthorized, contacts.ErrAuthExpired},
+		{http.StatusTooManyRequests, contacts.ErrProviderUnavailable},
+		{http.StatusServiceUnavailable, contacts.ErrProviderUnavailable},
+		{http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			if got := statusError(tt.status); got != tt.want {
+				t.Errorf("statusError(%d) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCodeError(t *testing.T) {
+	tests := []struct {
+		code string
+		want error
+	}{
+		{"ErrorItemNotFound", contacts.ErrNotFound},
+		{"ErrorIrresolvableConflict", contacts.ErrConflict},
+		{"ErrorServerBusy", contacts.ErrProviderUnavailable},
+		{"ErrorSchemaValidation", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := responseCodeError(tt.code); got != tt.want {
+				t.Errorf("responseCodeError(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvider_ErrNotInitialized(t *testing.T) {
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("Initialize: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := p.FetchContacts(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("FetchContacts: got %v, want ErrNotInitialized", err)
+	}
+}
@@ -0,0 +1,352 @@
+// Package ews implements a contacts.ContactProvider backed by Exchange Web
+// Services, for on-premises Exchange servers that can't be reached through
+// Microsoft Graph.
+//
+// Contacts are listed with SyncFolderItems, which also reports what changed
+// since the last sync, and read with GetItem. FindPeople isn't used: it
+// returns personas, which merge contacts and lack the item IDs needed to
+// update and delete them.
+package ews
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+var _ contacts.IncrementalProvider = (*Provider)(nil)
+
+// FieldChangeKey holds the change key of the stored copy of a contact,
+// sent with updates so that edits made in Outlook since are not
+// overwritten.
+const FieldChangeKey = "X-EWS-CHANGEKEY"
+
+// maxChangesReturned is how many changes SyncFolderItems returns at a
+// time; 512 is the most Exchange allows.
+var maxChangesReturned = 512
+
+// getItemBatch is how many contacts GetItem fetches at a time.
+const getItemBatch = 100
+
+// Credentials are the Exchange account stored in exchange_creds.json.
+type Credentials struct {
+	// URL is the EWS endpoint, usually
+	// https://mail.example.com/EWS/Exchange.asmx.
+	URL string `json:"url"`
+	// Username is "DOMAIN\user" or "user@example.com".
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Provider syncs contacts with the Contacts folder of an Exchange mailbox.
+type Provider struct {
+	client *http.Client
+	creds  providerutil.CredentialsFile[Credentials]
+	// authScheme is "NTLM" or "Negotiate" once the server has turned down
+	// Basic authentication, and empty before.
+	authScheme    string
+	syncState     string
+	syncStatePath string
+	// pendingSyncState is saved by CommitSync.
+	pendingSyncState string
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		client:        &http.Client{Timeout: time.Minute, Transport: newTransport()},
+		creds:         providerutil.NewCredentialsFile[Credentials](filepath.Join(dir, "exchange_creds.json")),
+		syncStatePath: filepath.Join(dir, "exchange_sync_state.txt"),
+	}, nil
+}
+
+// newTransport returns a transport that keeps to one HTTP/1.1 connection,
+// as NTLM authenticates connections rather than requests and doesn't work
+// over HTTP/2.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.ForceAttemptHTTP2 = false
+	t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	t.MaxConnsPerHost = 1
+	return t
+}
+
+func (p *Provider) SaveCredentials(creds *Credentials) error {
+	return p.creds.Save(creds)
+}
+
+// LoadCredentials returns a copy of the stored credentials. The file is
+// read on the first call only; later calls return what was last loaded or
+// saved.
+func (p *Provider) LoadCredentials() (*Credentials, error) {
+	return p.creds.Load()
+}
+
+func (p *Provider) Initialize() error {
+	creds, err := p.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	if creds.URL == "" {
+		return fmt.Errorf("%w: no server configured: please run init first", contacts.ErrNotInitialized)
+	}
+	if data, err := os.ReadFile(p.syncStatePath); err == nil {
+		p.syncState = string(data)
+	}
+	return nil
+}
+
+// Setup stores the account and checks that it can read the mailbox's
+// Contacts folder, returning how many items the folder holds. server may
+// be the EWS endpoint or just the server's host name. Switching to
+// another account forgets the sync state, so the next sync fetches every
+// contact.
+func (p *Provider) Setup(server, username, password string) (int, error) {
+	endpoint, err := endpointURL(server)
+	if err != nil {
+		return 0, err
+	}
+	if old, _ := p.LoadCredentials(); old != nil && (old.URL != endpoint || !strings.EqualFold(old.Username, username)) {
+		p.syncState = ""
+		if err := os.Remove(p.syncStatePath); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to remove sync state: %w", err)
+		}
+	}
+	if err := p.SaveCredentials(&Credentials{URL: endpoint, Username: username, Password: password}); err != nil {
+		return 0, err
+	}
+	p.authScheme = ""
+	msgs, err := p.call(getFolderRequest)
+	if err == nil && len(msgs) != 1 {
+		err = fmt.Errorf("unexpected GetFolder response with %d messages", len(msgs))
+	}
+	if err == nil {
+		err = msgs[0].err()
+	}
+	if errors.Is(err, contacts.ErrAuthExpired) {
+		return 0, fmt.Errorf("Exchange rejected the sign-in; check the user name (DOMAIN\\user or user@example.com) and password: %w", err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to open the Contacts folder: %w", err)
+	}
+	return msgs[0].Folders.TotalCount, nil
+}
+
+// endpointURL returns the EWS endpoint for server, adding the scheme and
+// the usual path if they are missing.
+func endpointURL(server string) (string, error) {
+	server = strings.TrimSpace(server)
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("invalid server URL %q", server)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/EWS/Exchange.asmx"
+	}
+	return u.String(), nil
+}
+
+func (p *Provider) SaveSyncState(state string) error {
+	p.syncState = state
+	return os.WriteFile(p.syncStatePath, []byte(state), 0600)
+}
+
+// Name keys Exchange's contacts in the manager's ID map.
+func (p *Provider) Name() string {
+	return contacts.ProviderExchange
+}
+
+// FetchContacts returns every contact in the Contacts folder.
+func (p *Provider) FetchContacts() ([]vcard.Card, error) {
+	ids, _, _, err := p.syncFolderItems("")
+	if err != nil {
+		return nil, err
+	}
+	return p.getContacts(ids)
+}
+
+// FetchChanges returns the contacts changed or deleted in the Contacts
+// folder since the last committed sync. Without a sync state, or if
+// Exchange no longer accepts it, every contact is returned as changed.
+func (p *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	ids, deleted, state, err := p.syncFolderItems(p.syncState)
+	if errors.Is(err, errSyncStateInvalid) {
+		ids, deleted, state, err = p.syncFolderItems("")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if changed, err = p.getContacts(ids); err != nil {
+		return nil, nil, err
+	}
+	p.pendingSyncState = state
+	return changed, deleted, nil
+}
+
+// CommitSync saves the sync state from the last FetchChanges, once its
+// changes have been stored locally.
+func (p *Provider) CommitSync() error {
+	if p.pendingSyncState == "" {
+		return nil
+	}
+	if err := p.SaveSyncState(p.pendingSyncState); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	p.pendingSyncState = ""
+	return nil
+}
+
+// errSyncStateInvalid is returned by syncFolderItems when Exchange no
+// longer accepts the sync state and a full sync is needed.
+var errSyncStateInvalid = errors.New("sync state invalid")
+
+// syncFolderItems returns the IDs of contacts created or changed and of
+// items deleted since state, and the state to pass next time.
+func (p *Provider) syncFolderItems(state string) (changed []string, deleted []string, next string, err error) {
+	alive := map[string]bool{}
+	for {
+		var stateXML string
+		if state != "" {
+			stateXML = "<m:SyncState>" + escape(state) + "</m:SyncState>"
+		}
+		msgs, err := p.call(fmt.Sprintf(syncFolderItemsRequest, stateXML, maxChangesReturned))
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to fetch changes: %w", err)
+		}
+		if len(msgs) != 1 {
+			return nil, nil, "", fmt.Errorf("unexpected SyncFolderItems response with %d messages", len(msgs))
+		}
+		m := msgs[0]
+		if m.ResponseCode == "ErrorInvalidSyncStateData" && state != "" {
+			return nil, nil, "", errSyncStateInvalid
+		}
+		if err := m.err(); err != nil {
+			return nil, nil, "", fmt.Errorf("failed to fetch changes: %w", err)
+		}
+		for _, c := range append(m.Changes.Create, m.Changes.Update...) {
+			// Other items, such as distribution lists, are skipped.
+			if c.Contact == nil {
+				continue
+			}
+			if id := c.Contact.ItemID.ID; !alive[id] {
+				alive[id] = true
+				changed = append(changed, id)
+			}
+		}
+		for _, c := range m.Changes.Delete {
+			delete(alive, c.ItemID.ID)
+			deleted = append(deleted, c.ItemID.ID)
+		}
+		state = m.SyncState
+		if m.IncludesLastItemInRange {
+			break
+		}
+	}
+	var live []string
+	for _, id := range changed {
+		if alive[id] {
+			live = append(live, id)
+		}
+	}
+	return live, deleted, state, nil
+}
+
+// getContacts reads the contacts with the given item IDs. Contacts
+// deleted in the meantime are skipped.
+func (p *Provider) getContacts(ids []string) ([]vcard.Card, error) {
+	var cards []vcard.Card
+	for start := 0; start < len(ids); start += getItemBatch {
+		var b strings.Builder
+		for _, id := range ids[start:min(start+getItemBatch, len(ids))] {
+			b.WriteString(itemID{ID: id}.xml())
+		}
+		msgs, err := p.call(fmt.Sprintf(getItemRequest, b.String()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+		}
+		for _, m := range msgs {
+			if err := m.err(); errors.Is(err, contacts.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+			}
+			for _, c := range m.Items.Contacts {
+				cards = append(cards, convertEWSToCard(c))
+			}
+		}
+	}
+	return cards, nil
+}
+
+// WriteContact creates the contact in the Contacts folder or updates it.
+// Updates are conditional on the change key last synced, so a contact
+// changed in Outlook since fails with contacts.ErrConflict.
+func (p *Provider) WriteContact(card vcard.Card) error {
+	props := contactProperties(card)
+	id := contacts.ProviderID(card)
+	var op string
+	if id == "" {
+		op = fmt.Sprintf(createItemRequest, renderContact(props))
+	} else {
+		conflict := "AlwaysOverwrite"
+		changeKey := card.Value(FieldChangeKey)
+		if changeKey != "" {
+			conflict = "NeverOverwrite"
+		}
+		op = fmt.Sprintf(updateItemRequest, conflict, itemID{ID: id, ChangeKey: changeKey}.xml(), renderUpdates(props))
+	}
+	msgs, err := p.call(op)
+	if err == nil && len(msgs) != 1 {
+		err = fmt.Errorf("unexpected response with %d messages", len(msgs))
+	}
+	if err == nil {
+		err = msgs[0].err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update contact %s: %w", contacts.CardFullName(card), err)
+	}
+	if len(msgs[0].Items.Contacts) == 0 {
+		return nil
+	}
+	saved := msgs[0].Items.Contacts[0].ItemID
+	if id == "" {
+		// Adopt the ID Exchange assigned so the next sync recognizes the
+		// contact instead of duplicating it.
+		card.SetValue(vcard.FieldUID, providerutil.LocalUID(saved.ID))
+		card.SetValue(contacts.FieldProviderID, saved.ID)
+	}
+	if saved.ChangeKey != "" {
+		card.SetValue(FieldChangeKey, saved.ChangeKey)
+	}
+	return nil
+}
+
+// DeleteContact moves the contact to the mailbox's Deleted Items.
+func (p *Provider) DeleteContact(id string) error {
+	msgs, err := p.call(fmt.Sprintf(deleteItemRequest, itemID{ID: id}.xml()))
+	if err == nil && len(msgs) != 1 {
+		err = fmt.Errorf("unexpected response with %d messages", len(msgs))
+	}
+	if err == nil {
+		err = msgs[0].err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete contact %s: %w", id, err)
+	}
+	return nil
+}
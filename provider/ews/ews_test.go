@@ -0,0 +1,498 @@
+package ews
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+// fakeExchange is a minimal EWS server holding one Contacts folder.
+type fakeExchange struct {
+	mu      sync.Mutex
+	version int
+	nextID  int
+	items   map[string]string // item ID -> XML inside t:Contact
+	kinds   map[string]string // item ID -> element, "Contact" unless set
+	keys    map[string]string // item ID -> change key
+	created map[string]int    // item ID -> version created
+	changed map[string]int    // item ID -> version of last change
+	deleted map[string]int
+	// updates is the body of the last UpdateItem.
+	updates string
+	// ntlm makes the server turn down Basic authentication.
+	ntlm bool
+	// challenged counts NTLM challenges sent.
+	challenged int
+}
+
+func newFakeExchange(t *testing.T) (*fakeExchange, *httptest.Server) {
+	f := &fakeExchange{items: map[string]string{}, kinds: map[string]string{}, keys: map[string]string{},
+		created: map[string]int{}, changed: map[string]int{}, deleted: map[string]int{}}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+// put stores an item, returning its ID.
+func (f *fakeExchange) put(id, inner string) string {
+	f.version++
+	if id == "" {
+		f.nextID++
+		// Real item IDs are base64, with '/' and '+'.
+		id = fmt.Sprintf("AAMk+item/%d=", f.nextID)
+		f.created[id] = f.version
+	}
+	f.items[id] = inner
+	f.keys[id] = fmt.Sprintf("CQ%d", f.version)
+	f.changed[id] = f.version
+	return id
+}
+
+func (f *fakeExchange) remove(id string) {
+	f.version++
+	delete(f.items, id)
+	delete(f.changed, id)
+	f.deleted[id] = f.version
+}
+
+// authorized checks the request's credentials, answering it if they are
+// missing or wrong.
+func (f *fakeExchange) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if !f.ntlm {
+		if user, pass, ok := r.BasicAuth(); ok && user == `CORP\ada` && pass == "secret" {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="mail.example.com"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "NTLM ")
+	msg, _ := base64.StdEncoding.DecodeString(token)
+	if len(msg) < 12 {
+		w.Header().Add("WWW-Authenticate", "Negotiate")
+		w.Header().Add("WWW-Authenticate", "NTLM")
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+	switch binary.LittleEndian.Uint32(msg[8:]) {
+	case 1:
+		f.challenged++
+		targetInfo := []byte{2, 0, 8, 0, 'C', 0, 'O', 0, 'R', 0, 'P', 0, 0, 0, 0, 0}
+		ch := make([]byte, 48)
+		copy(ch, ntlmSignature)
+		binary.LittleEndian.PutUint32(ch[8:], 2)
+		binary.LittleEndian.PutUint32(ch[20:], ntlmFlags)
+		copy(ch[24:], "serverch")
+		binary.LittleEndian.PutUint16(ch[40:], uint16(len(targetInfo)))
+		binary.LittleEndian.PutUint32(ch[44:], 48)
+		ch = append(ch, targetInfo...)
+		w.Header().Set("WWW-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString(ch))
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	case 3:
+		field := func(i int) []byte {
+			pos := 12 + 8*i
+			n, off := int(binary.LittleEndian.Uint16(msg[pos:])), int(binary.LittleEndian.Uint32(msg[pos+4:]))
+			return msg[off : off+n]
+		}
+		nt := field(1)
+		var serverChallenge, clientChallenge [8]byte
+		copy(serverChallenge[:], "serverch")
+		copy(clientChallenge[:], nt[32:40])
+		want := ntlmv2Response(ntowfv2("ada", "CORP", "secret"), serverChallenge, clientChallenge, nt[24:32], nt[44:len(nt)-4])
+		if bytes.Equal(nt, want) {
+			return true
+		}
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+	return false
+}
+
+func (f *fakeExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	if !f.authorized(w, r) {
+		return
+	}
+	req := string(body)
+	respond := func(op string, messages ...string) {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+			`<m:%sResponse xmlns:m="http://schemas.microsoft.com/exchange/services/2006/messages" xmlns:t="http://schemas.microsoft.com/exchange/services/2006/types">`+
+			`<m:ResponseMessages>%s</m:ResponseMessages></m:%sResponse></s:Body></s:Envelope>`, op, strings.Join(messages, ""), op)
+	}
+	success := func(op, inner string) string {
+		return fmt.Sprintf(`<m:%sResponseMessage ResponseClass="Success"><m:ResponseCode>NoError</m:ResponseCode>%s</m:%sResponseMessage>`, op, inner, op)
+	}
+	failure := func(op, code string) string {
+		return fmt.Sprintf(`<m:%sResponseMessage ResponseClass="Error"><m:MessageText>%s</m:MessageText><m:ResponseCode>%s</m:ResponseCode></m:%sResponseMessage>`, op, code, code, op)
+	}
+	item := func(id string) string {
+		kind := f.kinds[id]
+		if kind == "" {
+			kind = "Contact"
+		}
+		return fmt.Sprintf(`<t:%s><t:ItemId Id="%s" ChangeKey="%s"/>%s</t:%s>`, kind, escape(id), f.keys[id], f.items[id], kind)
+	}
+	ids := func() []string {
+		var out []string
+		for _, m := range regexp.MustCompile(`<t:ItemId Id="([^"]+)"`).FindAllStringSubmatch(req, -1) {
+			out = append(out, unescape(m[1]))
+		}
+		return out
+	}
+
+	switch {
+	case strings.Contains(req, "<m:GetFolder>"):
+		respond("GetFolder", success("GetFolder", fmt.Sprintf(`<m:Folders><t:ContactsFolder><t:DisplayName>Contacts</t:DisplayName><t:TotalCount>%d</t:TotalCount></t:ContactsFolder></m:Folders>`, len(f.items))))
+	case strings.Contains(req, "<m:SyncFolderItems>"):
+		since := 0
+		if m := regexp.MustCompile(`<m:SyncState>([^<]*)</m:SyncState>`).FindStringSubmatch(req); m != nil {
+			if _, err := fmt.Sscanf(m[1], "s%d", &since); err != nil {
+				respond("SyncFolderItems", failure("SyncFolderItems", "ErrorInvalidSyncStateData"))
+				return
+			}
+		}
+		var max int
+		fmt.Sscanf(regexp.MustCompile(`<m:MaxChangesReturned>(\d+)`).FindStringSubmatch(req)[1], "%d", &max)
+		type change struct {
+			version int
+			xml     string
+		}
+		var changes []change
+		for id, v := range f.changed {
+			if v > since {
+				kind := "Update"
+				if f.created[id] > since {
+					kind = "Create"
+				}
+				changes = append(changes, change{v, "<t:" + kind + ">" + item(id) + "</t:" + kind + ">"})
+			}
+		}
+		for id, v := range f.deleted {
+			if v > since && since > 0 {
+				changes = append(changes, change{v, `<t:Delete><t:ItemId Id="` + escape(id) + `"/></t:Delete>`})
+			}
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].version < changes[j].version })
+		last, state := true, f.version
+		if len(changes) > max {
+			changes, last, state = changes[:max], false, changes[max-1].version
+		}
+		var out strings.Builder
+		for _, c := range changes {
+			out.WriteString(c.xml)
+		}
+		respond("SyncFolderItems", success("SyncFolderItems", fmt.Sprintf(`<m:SyncState>s%d</m:SyncState><m:IncludesLastItemInRange>%t</m:IncludesLastItemInRange><m:Changes>%s</m:Changes>`, state, last, out.String())))
+	case strings.Contains(req, "<m:GetItem>"):
+		var out []string
+		for _, id := range ids() {
+			if _, ok := f.items[id]; !ok {
+				out = append(out, failure("GetItem", "ErrorItemNotFound"))
+				continue
+			}
+			out = append(out, success("GetItem", "<m:Items>"+item(id)+"</m:Items>"))
+		}
+		respond("GetItem", out...)
+	case strings.Contains(req, "<m:CreateItem>"):
+		inner := regexp.MustCompile(`<t:Contact>(.*)</t:Contact>`).FindStringSubmatch(req)[1]
+		var check ewsContact
+		if err := xml.Unmarshal([]byte(`<t:Contact xmlns:t="types">`+inner+`</t:Contact>`), &check); err != nil {
+			respond("CreateItem", failure("CreateItem", "ErrorSchemaValidation"))
+			return
+		}
+		id := f.put("", inner)
+		respond("CreateItem", success("CreateItem", fmt.Sprintf(`<m:Items><t:Contact><t:ItemId Id="%s" ChangeKey="%s"/></t:Contact></m:Items>`, escape(id), f.keys[id])))
+	case strings.Contains(req, "<m:UpdateItem "):
+		id := ids()[0]
+		key := regexp.MustCompile(`ChangeKey="([^"]*)"`).FindStringSubmatch(req)
+		switch {
+		case f.items[id] == "":
+			respond("UpdateItem", failure("UpdateItem", "ErrorItemNotFound"))
+			return
+		case key != nil && key[1] != f.keys[id]:
+			respond("UpdateItem", failure("UpdateItem", "ErrorIrresolvableConflict"))
+			return
+		}
+		f.updates = req
+		f.put(id, f.items[id])
+		respond("UpdateItem", success("UpdateItem", fmt.Sprintf(`<m:Items><t:Contact><t:ItemId Id="%s" ChangeKey="%s"/></t:Contact></m:Items>`, escape(id), f.keys[id])))
+	case strings.Contains(req, "<m:DeleteItem "):
+		id := ids()[0]
+		if f.items[id] == "" {
+			respond("DeleteItem", failure("DeleteItem", "ErrorItemNotFound"))
+			return
+		}
+		f.remove(id)
+		respond("DeleteItem", success("DeleteItem", ""))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><faultcode>s:Client</faultcode><faultstring>unsupported</faultstring></s:Fault></s:Body></s:Envelope>`)
+	}
+}
+
+func unescape(s string) string {
+	var out string
+	xml.Unmarshal([]byte("<v>"+s+"</v>"), &out)
+	return out
+}
+
+const ada = `<t:Body BodyType="Text">First programmer&#xD;
+Notes on the engine&#xD;
+</t:Body><t:Categories><t:String>Friends</t:String><t:String>Science</t:String></t:Categories>` +
+	`<t:DisplayName>Ada Lovelace</t:DisplayName><t:GivenName>Ada</t:GivenName><t:Nickname>Ada</t:Nickname>` +
+	`<t:CompleteName><t:Title>Countess</t:Title></t:CompleteName><t:CompanyName>Analytical Engines</t:CompanyName>` +
+	`<t:EmailAddresses><t:Entry Key="EmailAddress2" RoutingType="SMTP">ada@work.example</t:Entry>` +
+	`<t:Entry Key="EmailAddress1" RoutingType="SMTP">ada@example.com</t:Entry>` +
+	`<t:Entry Key="EmailAddress3" RoutingType="EX">/o=Example/ou=Exchange Administrative Group/cn=Recipients/cn=ada</t:Entry></t:EmailAddresses>` +
+	`<t:PhysicalAddresses><t:Entry Key="Home"><t:Street>12 St James's Sq</t:Street><t:City>London</t:City><t:PostalCode>SW1Y</t:PostalCode></t:Entry><t:Entry Key="Other"/></t:PhysicalAddresses>` +
+	`<t:PhoneNumbers><t:Entry Key="MobilePhone">+1 555 0100</t:Entry><t:Entry Key="BusinessFax">+1 555 0200</t:Entry><t:Entry Key="CarPhone">+1 555 0300</t:Entry><t:Entry Key="HomePhone"/></t:PhoneNumbers>` +
+	`<t:Birthday>1815-12-09T23:00:00Z</t:Birthday><t:Children><t:String>Byron</t:String></t:Children>` +
+	`<t:Department>Research</t:Department><t:JobTitle>Mathematician</t:JobTitle><t:SpouseName>William King</t:SpouseName><t:Surname>Lovelace</t:Surname>`
+
+func newTestProvider(t *testing.T, srv *httptest.Server) *Provider {
+	t.Helper()
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Setup(srv.URL, `CORP\ada`, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProvider_Sync(t *testing.T) {
+	f, srv := newFakeExchange(t)
+	adaID := f.put("", ada)
+	graceID := f.put("", `<t:DisplayName>Grace Hopper</t:DisplayName>`)
+	listID := f.put("", `<t:DisplayName>Team</t:DisplayName>`)
+	f.kinds[listID] = "DistributionList"
+	old := maxChangesReturned
+	maxChangesReturned = 2
+	t.Cleanup(func() { maxChangesReturned = old })
+
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := p.Setup(srv.URL, `CORP\ada`, "secret")
+	if err != nil || count != 3 {
+		t.Fatalf("Setup = %d, %v", count, err)
+	}
+	if p.creds.Cached().URL != srv.URL+"/EWS/Exchange.asmx" {
+		t.Errorf("URL = %q, want the EWS endpoint", p.creds.Cached().URL)
+	}
+
+	changed, deleted, err := p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("first sync: %d changed, %v deleted; want both contacts", len(changed), deleted)
+	}
+	card := changed[0]
+	if contacts.ProviderID(card) != adaID || contacts.CardUID(card) != providerutil.LocalUID("AAMk+item/1=") {
+		t.Errorf("provider ID = %q, UID = %q", contacts.ProviderID(card), contacts.CardUID(card))
+	}
+	for field, want := range map[string]string{
+		vcard.FieldFormattedName: "Ada Lovelace",
+		vcard.FieldName:          "Lovelace;Ada;;Countess;",
+		vcard.FieldNickname:      "Ada",
+		vcard.FieldOrganization:  "Analytical Engines;Research",
+		vcard.FieldTitle:         "Mathematician",
+		vcard.FieldBirthday:      "18151210",
+		vcard.FieldNote:          "First programmer\nNotes on the engine",
+		vcard.FieldCategories:    "Friends,Science",
+		vcard.FieldAddress:       ";;12 St James's Sq;London;;SW1Y;",
+		FieldChangeKey:           "CQ1",
+	} {
+		if got := card.Value(field); got != want {
+			t.Errorf("%s = %q, want %q", field, got, want)
+		}
+	}
+	if emails := card.Values(vcard.FieldEmail); len(emails) != 2 || emails[0] != "ada@example.com" {
+		t.Errorf("EMAIL = %v, want the SMTP addresses in key order", emails)
+	}
+	tels := card[vcard.FieldTelephone]
+	if len(tels) != 3 || !tels[0].Params.HasType("cell") || !tels[1].Params.HasType("fax") || len(tels[2].Params.Types()) != 0 {
+		t.Errorf("TEL = %+v", tels)
+	}
+	if n := len(card[vcard.FieldRelated]); n != 2 {
+		t.Errorf("got %d RELATED, want spouse and child", n)
+	}
+
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	f.put(graceID, `<t:DisplayName>Grace Brewster Hopper</t:DisplayName>`)
+	f.remove(adaID)
+	changed, deleted, err = p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || contacts.CardFullName(changed[0]) != "Grace Brewster Hopper" {
+		t.Errorf("changed = %v, want Grace renamed", changed)
+	}
+	if len(deleted) != 1 || deleted[0] != adaID {
+		t.Errorf("deleted = %v, want Ada", deleted)
+	}
+
+	// An expired sync state starts over.
+	p.syncState = "expired"
+	changed, _, err = p.FetchChanges()
+	if err != nil || len(changed) != 1 {
+		t.Errorf("after an expired sync state: %d changed, %v", len(changed), err)
+	}
+}
+
+func TestProvider_WriteContact(t *testing.T) {
+	f, srv := newFakeExchange(t)
+	p := newTestProvider(t, srv)
+
+	card := vcard.Card{}
+	card.SetValue(vcard.FieldUID, "local-1")
+	card.SetValue(vcard.FieldFormattedName, "Ada Lovelace")
+	card.SetValue(vcard.FieldName, "Lovelace;Ada;;;")
+	card.SetValue(vcard.FieldOrganization, "Analytical Engines")
+	card.SetValue(vcard.FieldNote, "Likes <engines> & poetry")
+	card.SetValue(vcard.FieldBirthday, "--1210")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@example.com"})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+1 555 0100", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+1 555 0101", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+1 555 0102", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	card.Add(vcard.FieldAddress, &vcard.Field{Value: ";Flat 2;12 St James's Sq;London;;SW1Y;UK"})
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	id := contacts.ProviderID(card)
+	if id == "" || contacts.CardUID(card) != providerutil.LocalUID(id) || card.Value(FieldChangeKey) == "" {
+		t.Fatalf("created contact: provider ID %q, UID %q, change key %q", id, contacts.CardUID(card), card.Value(FieldChangeKey))
+	}
+
+	cards, err := p.FetchContacts()
+	if err != nil || len(cards) != 1 {
+		t.Fatalf("FetchContacts = %d cards, %v", len(cards), err)
+	}
+	got := cards[0]
+	for field, want := range map[string]string{
+		vcard.FieldFormattedName: "Ada Lovelace",
+		vcard.FieldOrganization:  "Analytical Engines",
+		vcard.FieldNote:          "Likes <engines> & poetry",
+		vcard.FieldBirthday:      "--1210",
+		vcard.FieldEmail:         "ada@example.com",
+		vcard.FieldAddress:       ";;Flat 2\n12 St James's Sq;London;;SW1Y;UK",
+	} {
+		if v := got.Value(field); v != want {
+			t.Errorf("%s = %q, want %q", field, v, want)
+		}
+	}
+	if adr := got.Get(vcard.FieldAddress); adr == nil || !adr.Params.HasType("home") {
+		t.Errorf("untyped address = %+v, want it stored as home", adr)
+	}
+	if tels := got.Values(vcard.FieldTelephone); len(tels) != 3 {
+		t.Errorf("TEL = %v, want the third work number kept as another number", tels)
+	}
+
+	stale := card.Value(FieldChangeKey)
+	card.SetValue(vcard.FieldName, "Lovelace;Augusta Ada;;;")
+	delete(card, vcard.FieldNote)
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<t:SetItemField><t:FieldURI FieldURI="contacts:GivenName"/><t:Contact><t:GivenName>Augusta Ada</t:GivenName></t:Contact></t:SetItemField>`,
+		`<t:DeleteItemField><t:FieldURI FieldURI="item:Body"/></t:DeleteItemField>`,
+		`<t:SetItemField><t:IndexedFieldURI FieldURI="contacts:PhysicalAddress:City" FieldIndex="Home"/><t:Contact><t:PhysicalAddresses><t:Entry Key="Home"><t:City>London</t:City></t:Entry></t:PhysicalAddresses></t:Contact></t:SetItemField>`,
+		`<t:DeleteItemField><t:IndexedFieldURI FieldURI="contacts:EmailAddress" FieldIndex="EmailAddress2"/></t:DeleteItemField>`,
+		`ConflictResolution="NeverOverwrite"`,
+	} {
+		if !strings.Contains(f.updates, want) {
+			t.Errorf("update is missing %s", want)
+		}
+	}
+	if card.Value(FieldChangeKey) == stale {
+		t.Error("change key not updated")
+	}
+
+	card.SetValue(FieldChangeKey, stale)
+	if err := p.WriteContact(card); !errors.Is(err, contacts.ErrConflict) {
+		t.Errorf("update with a stale change key = %v, want ErrConflict", err)
+	}
+
+	if err := p.DeleteContact(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.DeleteContact(id); !errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("second delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProvider_NTLM(t *testing.T) {
+	f, srv := newFakeExchange(t)
+	f.ntlm = true
+	f.put("", `<t:DisplayName>Grace Hopper</t:DisplayName>`)
+	p := newTestProvider(t, srv)
+	if p.authScheme != "NTLM" {
+		t.Errorf("auth scheme = %q, want NTLM", p.authScheme)
+	}
+	cards, err := p.FetchContacts()
+	if err != nil || len(cards) != 1 {
+		t.Fatalf("FetchContacts = %d cards, %v", len(cards), err)
+	}
+	if f.challenged < 2 {
+		t.Errorf("sent %d challenges, want one per request", f.challenged)
+	}
+
+	p.creds.Cached().Password = "wrong"
+	if _, err := p.FetchContacts(); !errors.Is(err, contacts.ErrAuthExpired) {
+		t.Errorf("wrong password = %v, want ErrAuthExpired", err)
+	}
+}
+
+func TestProvider_WrongPassword(t *testing.T) {
+	_, srv := newFakeExchange(t)
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Setup(srv.URL, `CORP\ada`, "wrong"); !errors.Is(err, contacts.ErrAuthExpired) || !strings.Contains(err.Error(), "DOMAIN\\user") {
+		t.Errorf("Setup = %v, want ErrAuthExpired with a hint", err)
+	}
+}
+
+func TestEndpointURL(t *testing.T) {
+	tests := map[string]string{
+		"mail.example.com":                                      "https://mail.example.com/EWS/Exchange.asmx",
+		"https://mail.example.com/":                             "https://mail.example.com/EWS/Exchange.asmx",
+		"https://mail.example.com/ews/exchange.asmx":            "https://mail.example.com/ews/exchange.asmx",
+		" http://exchange.corp.example:8080/EWS/Exchange.asmx ": "http://exchange.corp.example:8080/EWS/Exchange.asmx",
+	}
+	for in, want := range tests {
+		if got, err := endpointURL(in); err != nil || got != want {
+			t.Errorf("endpointURL(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := endpointURL("https://"); err == nil {
+		t.Error("expected an error for a URL without a host")
+	}
+}
@@ -0,0 +1,214 @@
+package ews
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// NTLM (MS-NLMP) is how on-premises Exchange usually authenticates when
+// Basic authentication is turned off. Only NTLMv2 responses are sent.
+
+// NTLM negotiate flags.
+const (
+	ntlmNegotiateUnicode        = 0x00000001
+	ntlmRequestTarget           = 0x00000004
+	ntlmNegotiateNTLM           = 0x00000200
+	ntlmNegotiateAlwaysSign     = 0x00008000
+	ntlmExtendedSessionSecurity = 0x00080000
+	ntlmNegotiateTargetInfo     = 0x00800000
+	ntlmNegotiate128            = 0x20000000
+	ntlmNegotiate56             = 0x80000000
+
+	ntlmFlags = ntlmNegotiateUnicode | ntlmRequestTarget | ntlmNegotiateNTLM | ntlmNegotiateAlwaysSign |
+		ntlmExtendedSessionSecurity | ntlmNegotiateTargetInfo | ntlmNegotiate128 | ntlmNegotiate56
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// avTimestamp is the AV_PAIR of a challenge's target info holding the
+// server's time.
+const avTimestamp = 7
+
+// ntlmNegotiate returns the NEGOTIATE_MESSAGE that starts a handshake.
+func ntlmNegotiate() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmFlags)
+	return msg
+}
+
+// ntlmChallenge is the server's CHALLENGE_MESSAGE.
+type ntlmChallenge struct {
+	flags           uint32
+	serverChallenge [8]byte
+	targetInfo      []byte
+}
+
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 48 || !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("malformed NTLM challenge")
+	}
+	ch := &ntlmChallenge{flags: binary.LittleEndian.Uint32(msg[20:])}
+	copy(ch.serverChallenge[:], msg[24:32])
+	n, off := int(binary.LittleEndian.Uint16(msg[40:])), int(binary.LittleEndian.Uint32(msg[44:]))
+	if off+n > len(msg) {
+		return nil, errors.New("malformed NTLM challenge")
+	}
+	ch.targetInfo = msg[off : off+n]
+	return ch, nil
+}
+
+// timestamp returns the server time from the target info, if it has one.
+func (ch *ntlmChallenge) timestamp() ([]byte, bool) {
+	info := ch.targetInfo
+	for len(info) >= 4 {
+		id, n := binary.LittleEndian.Uint16(info), int(binary.LittleEndian.Uint16(info[2:]))
+		if len(info) < 4+n || id == 0 {
+			break
+		}
+		if id == avTimestamp && n == 8 {
+			return info[4:12], true
+		}
+		info = info[4+n:]
+	}
+	return nil, false
+}
+
+// ntlmAuthenticate returns the AUTHENTICATE_MESSAGE answering ch for the
+// given account. username may be "DOMAIN\user" or "user@domain".
+func ntlmAuthenticate(ch *ntlmChallenge, username, password string) []byte {
+	user, domain := username, ""
+	if d, u, ok := strings.Cut(username, `\`); ok {
+		user, domain = u, d
+	}
+	var clientChallenge [8]byte
+	rand.Read(clientChallenge[:])
+	stamp, fromServer := ch.timestamp()
+	if !fromServer {
+		stamp = fileTime(time.Now())
+	}
+	key := ntowfv2(user, domain, password)
+	nt := ntlmv2Response(key, ch.serverChallenge, clientChallenge, stamp, ch.targetInfo)
+	// With a server timestamp the LMv2 response must be zeros.
+	lm := make([]byte, 24)
+	if !fromServer {
+		mac := hmac.New(md5.New, key)
+		mac.Write(ch.serverChallenge[:])
+		mac.Write(clientChallenge[:])
+		lm = append(mac.Sum(nil), clientChallenge[:]...)
+	}
+
+	payload := [][]byte{lm, nt, utf16le(domain), utf16le(user), nil, nil}
+	msg := make([]byte, 64)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := len(msg)
+	for i, field := range payload {
+		pos := 12 + 8*i
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(len(field)))
+		binary.LittleEndian.PutUint16(msg[pos+2:], uint16(len(field)))
+		binary.LittleEndian.PutUint32(msg[pos+4:], uint32(offset))
+		offset += len(field)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], ntlmFlags&ch.flags|ntlmNegotiateUnicode)
+	for _, field := range payload {
+		msg = append(msg, field...)
+	}
+	return msg
+}
+
+// ntowfv2 is the NTLMv2 response key for an account.
+func ntowfv2(user, domain, password string) []byte {
+	hash := md4Sum(utf16le(password))
+	mac := hmac.New(md5.New, hash[:])
+	mac.Write(utf16le(strings.ToUpper(user) + domain))
+	return mac.Sum(nil)
+}
+
+// ntlmv2Response computes the NtChallengeResponse: the NTProofStr followed
+// by the client blob it covers.
+func ntlmv2Response(key []byte, serverChallenge, clientChallenge [8]byte, timestamp, targetInfo []byte) []byte {
+	var blob []byte
+	blob = append(blob, 1, 1, 0, 0, 0, 0, 0, 0)
+	blob = append(blob, timestamp...)
+	blob = append(blob, clientChallenge[:]...)
+	blob = append(blob, 0, 0, 0, 0)
+	blob = append(blob, targetInfo...)
+	blob = append(blob, 0, 0, 0, 0)
+	mac := hmac.New(md5.New, key)
+	mac.Write(serverChallenge[:])
+	mac.Write(blob)
+	return append(mac.Sum(nil), blob...)
+}
+
+// fileTime encodes t as a Windows FILETIME: 100ns intervals since 1601.
+func fileTime(t time.Time) []byte {
+	const epochDelta = 116444736000000000
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint64(out, uint64(t.UnixNano()/100+epochDelta))
+	return out
+}
+
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(out[2*i:], u)
+	}
+	return out
+}
+
+// md4Sum is MD4 (RFC 1320), which the NT password hash is built on and
+// the standard library doesn't provide.
+func md4Sum(data []byte) [16]byte {
+	msg := append([]byte{}, data...)
+	msg = append(msg, 0x80)
+	for len(msg)%64 != 56 {
+		msg = append(msg, 0)
+	}
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(len(data))*8)
+
+	s := [4]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476}
+	rounds := []struct {
+		f      func(x, y, z uint32) uint32
+		k      uint32
+		order  [16]int
+		shifts [4]int
+	}{
+		{func(x, y, z uint32) uint32 { return x&y | ^x&z }, 0,
+			[16]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, [4]int{3, 7, 11, 19}},
+		{func(x, y, z uint32) uint32 { return x&y | x&z | y&z }, 0x5a827999,
+			[16]int{0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15}, [4]int{3, 5, 9, 13}},
+		{func(x, y, z uint32) uint32 { return x ^ y ^ z }, 0x6ed9eba1,
+			[16]int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}, [4]int{3, 9, 11, 15}},
+	}
+	for len(msg) > 0 {
+		var x [16]uint32
+		for i := range x {
+			x[i] = binary.LittleEndian.Uint32(msg[4*i:])
+		}
+		a, b, c, d := s[0], s[1], s[2], s[3]
+		for _, r := range rounds {
+			for i, j := range r.order {
+				a = bits.RotateLeft32(a+r.f(b, c, d)+x[j]+r.k, r.shifts[i%4])
+				a, b, c, d = d, a, b, c
+			}
+		}
+		s[0], s[1], s[2], s[3] = s[0]+a, s[1]+b, s[2]+c, s[3]+d
+		msg = msg[64:]
+	}
+	var out [16]byte
+	for i, v := range s {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return out
+}
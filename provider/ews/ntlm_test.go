@@ -0,0 +1,72 @@
+package ews
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestMD4(t *testing.T) {
+	// RFC 1320 appendix A.5.
+	tests := map[string]string{
+		"":    "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"abc": "a448017aaf21d8525fc10ae87aa6729d",
+		"12345678901234567890123456789012345678901234567890123456789012345678901234567890": "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for in, want := range tests {
+		if got := md4Sum([]byte(in)); hex.EncodeToString(got[:]) != want {
+			t.Errorf("md4(%q) = %x, want %s", in, got, want)
+		}
+	}
+}
+
+func TestNTLMv2Response(t *testing.T) {
+	// MS-NLMP section 4.2.4.
+	key := ntowfv2("User", "Domain", "Password")
+	if got := hex.EncodeToString(key); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Fatalf("NTOWFv2 = %s", got)
+	}
+	serverChallenge := [8]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+	clientChallenge := [8]byte{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+	targetInfo, _ := hex.DecodeString("02000c0044006f006d00610069006e0001000c0053006500720076006500720000000000")
+	resp := ntlmv2Response(key, serverChallenge, clientChallenge, make([]byte, 8), targetInfo)
+	if got := hex.EncodeToString(resp[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("NTProofStr = %s", got)
+	}
+}
+
+func TestNTLMAuthenticate(t *testing.T) {
+	challenge, _ := hex.DecodeString("4e544c4d53535000020000000c000c003800000033828ae20123456789abcdef00000000000000002400240044000000" +
+		"060070170000000f530065007200760065007200" +
+		"02000c0044006f006d00610069006e0001000c0053006500720076006500720000000000")
+	ch, err := parseNTLMChallenge(challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(ch.serverChallenge[:]) != "0123456789abcdef" || len(ch.targetInfo) != 36 {
+		t.Fatalf("challenge = %+v", ch)
+	}
+	if _, ok := ch.timestamp(); ok {
+		t.Error("found a timestamp the target info doesn't have")
+	}
+
+	msg := ntlmAuthenticate(ch, `Domain\User`, "Password")
+	field := func(i int) []byte {
+		pos := 12 + 8*i
+		n, off := int(msg[pos])|int(msg[pos+1])<<8, int(msg[pos+4])|int(msg[pos+5])<<8
+		return msg[off : off+n]
+	}
+	if string(field(2)) != string(utf16le("Domain")) || string(field(3)) != string(utf16le("User")) {
+		t.Errorf("domain = %q, user = %q", field(2), field(3))
+	}
+	// The NT response must verify against the blob it carries.
+	nt := field(1)
+	var clientChallenge [8]byte
+	copy(clientChallenge[:], nt[32:40])
+	want := ntlmv2Response(ntowfv2("User", "Domain", "Password"), ch.serverChallenge, clientChallenge, nt[24:32], ch.targetInfo)
+	if hex.EncodeToString(nt) != hex.EncodeToString(want) {
+		t.Errorf("NT response doesn't verify")
+	}
+	if lm := field(0); len(lm) != 24 || hex.EncodeToString(lm[16:]) != hex.EncodeToString(clientChallenge[:]) {
+		t.Errorf("LM response = %x", lm)
+	}
+}
@@ -0,0 +1,227 @@
+package ews
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// soapEnvelope wraps an EWS operation. Exchange 2010 SP2 is the oldest
+// version with everything the provider uses that is still supported.
+const soapEnvelope = `<?xml version="1.0" encoding="utf-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:t="http://schemas.microsoft.com/exchange/services/2006/types" xmlns:m="http://schemas.microsoft.com/exchange/services/2006/messages"><soap:Header><t:RequestServerVersion Version="Exchange2010_SP2"/></soap:Header><soap:Body>%s</soap:Body></soap:Envelope>`
+
+// EWS operations. The %s verbs take already escaped XML.
+const (
+	getFolderRequest = `<m:GetFolder><m:FolderShape><t:BaseShape>Default</t:BaseShape></m:FolderShape><m:FolderIds><t:DistinguishedFolderId Id="contacts"/></m:FolderIds></m:GetFolder>`
+
+	syncFolderItemsRequest = `<m:SyncFolderItems><m:ItemShape><t:BaseShape>IdOnly</t:BaseShape></m:ItemShape><m:SyncFolderId><t:DistinguishedFolderId Id="contacts"/></m:SyncFolderId>%s<m:MaxChangesReturned>%d</m:MaxChangesReturned></m:SyncFolderItems>`
+
+	getItemRequest = `<m:GetItem><m:ItemShape><t:BaseShape>AllProperties</t:BaseShape><t:BodyType>Text</t:BodyType></m:ItemShape><m:ItemIds>%s</m:ItemIds></m:GetItem>`
+
+	createItemRequest = `<m:CreateItem><m:SavedItemFolderId><t:DistinguishedFolderId Id="contacts"/></m:SavedItemFolderId><m:Items>%s</m:Items></m:CreateItem>`
+
+	// Updates with a change key fail rather than overwrite changes made
+	// in Outlook since the last sync.
+	updateItemRequest = `<m:UpdateItem MessageDisposition="SaveOnly" ConflictResolution="%s"><m:ItemChanges><t:ItemChange>%s<t:Updates>%s</t:Updates></t:ItemChange></m:ItemChanges></m:UpdateItem>`
+
+	deleteItemRequest = `<m:DeleteItem DeleteType="MoveToDeletedItems"><m:ItemIds>%s</m:ItemIds></m:DeleteItem>`
+)
+
+type envelope struct {
+	Body struct {
+		Fault    *soapFault `xml:"Fault"`
+		Response struct {
+			Messages struct {
+				Items []responseMessage `xml:",any"`
+			} `xml:"ResponseMessages"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+type soapFault struct {
+	String string `xml:"faultstring"`
+	// ResponseCode is set for EWS errors reported as faults, such as
+	// ErrorServerBusy when the client is throttled.
+	ResponseCode string `xml:"detail>ResponseCode"`
+}
+
+// responseMessage is the result of one item of an operation.
+type responseMessage struct {
+	ResponseClass string `xml:"ResponseClass,attr"`
+	ResponseCode  string `xml:"ResponseCode"`
+	MessageText   string `xml:"MessageText"`
+
+	// GetFolder
+	Folders struct {
+		TotalCount int `xml:"ContactsFolder>TotalCount"`
+	} `xml:"Folders"`
+
+	// SyncFolderItems
+	SyncState               string      `xml:"SyncState"`
+	IncludesLastItemInRange bool        `xml:"IncludesLastItemInRange"`
+	Changes                 syncChanges `xml:"Changes"`
+
+	// GetItem, CreateItem and UpdateItem
+	Items struct {
+		Contacts []ewsContact `xml:"Contact"`
+	} `xml:"Items"`
+}
+
+// err returns the error the message reports, or nil on success. Warnings
+// count as success.
+func (m responseMessage) err() error {
+	if m.ResponseClass != "Error" {
+		return nil
+	}
+	return errors.Join(responseCodeError(m.ResponseCode), fmt.Errorf("%s: %s", m.ResponseCode, strings.TrimSpace(m.MessageText)))
+}
+
+type syncChanges struct {
+	Create []syncChange `xml:"Create"`
+	Update []syncChange `xml:"Update"`
+	Delete []syncChange `xml:"Delete"`
+}
+
+// syncChange is a changed item of any kind, of which only contacts have a
+// Contact, or a deleted item, which has only an ItemId.
+type syncChange struct {
+	Contact *struct {
+		ItemID itemID `xml:"ItemId"`
+	} `xml:"Contact"`
+	ItemID itemID `xml:"ItemId"`
+}
+
+type itemID struct {
+	ID        string `xml:"Id,attr"`
+	ChangeKey string `xml:"ChangeKey,attr"`
+}
+
+// xml returns the t:ItemId element for id, with its change key if known.
+func (id itemID) xml() string {
+	if id.ChangeKey == "" {
+		return `<t:ItemId Id="` + escape(id.ID) + `"/>`
+	}
+	return `<t:ItemId Id="` + escape(id.ID) + `" ChangeKey="` + escape(id.ChangeKey) + `"/>`
+}
+
+// escape escapes s for use in XML text and attributes.
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// call sends an EWS operation and returns its response messages. Errors
+// in individual messages are left to the caller.
+func (p *Provider) call(operation string) ([]responseMessage, error) {
+	if p.creds.Cached() == nil || p.creds.Cached().URL == "" {
+		return nil, contacts.ErrNotInitialized
+	}
+	resp, err := p.post(fmt.Sprintf(soapEnvelope, operation))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read EWS response: %w", contacts.ErrProviderUnavailable, err)
+	}
+	var env envelope
+	parseErr := xml.Unmarshal(data, &env)
+	if parseErr == nil && env.Body.Fault != nil {
+		fault := env.Body.Fault
+		return nil, errors.Join(responseCodeError(fault.ResponseCode), statusError(resp.StatusCode),
+			fmt.Errorf("EWS request failed: %s", strings.TrimSpace(fault.String)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(statusError(resp.StatusCode), fmt.Errorf("EWS request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data))))
+	}
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to parse EWS response: %w", parseErr)
+	}
+	return env.Body.Response.Messages.Items, nil
+}
+
+// post sends a SOAP request with Basic authentication or, once the
+// server has asked for it, NTLM.
+func (p *Provider) post(body string) (*http.Response, error) {
+	if p.authScheme == "" {
+		resp, err := p.send(body, func(req *http.Request) { req.SetBasicAuth(p.creds.Cached().Username, p.creds.Cached().Password) })
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+		scheme := ntlmScheme(resp.Header)
+		if scheme == "" {
+			return resp, nil
+		}
+		drain(resp)
+		p.authScheme = scheme
+	}
+
+	// NTLM authenticates the connection, so the handshake's requests must
+	// share one; see newTransport.
+	negotiate := p.authScheme + " " + base64.StdEncoding.EncodeToString(ntlmNegotiate())
+	resp, err := p.send(body, func(req *http.Request) { req.Header.Set("Authorization", negotiate) })
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	var challenge []byte
+	for _, h := range resp.Header.Values("WWW-Authenticate") {
+		if scheme, token, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, p.authScheme) {
+			challenge, _ = base64.StdEncoding.DecodeString(strings.TrimSpace(token))
+		}
+	}
+	ch, err := parseNTLMChallenge(challenge)
+	if err != nil {
+		// The server rejected the negotiation outright.
+		return resp, nil
+	}
+	drain(resp)
+	authenticate := p.authScheme + " " + base64.StdEncoding.EncodeToString(ntlmAuthenticate(ch, p.creds.Cached().Username, p.creds.Cached().Password))
+	return p.send(body, func(req *http.Request) { req.Header.Set("Authorization", authenticate) })
+}
+
+func (p *Provider) send(body string, auth func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequest("POST", p.creds.Cached().URL, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create EWS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	auth(req)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to reach %s: %w", contacts.ErrProviderUnavailable, p.creds.Cached().URL, err)
+	}
+	return resp, nil
+}
+
+// ntlmScheme returns the scheme to send NTLM messages with if the server
+// offers NTLM, directly or through Negotiate, or "" if it doesn't.
+func ntlmScheme(header http.Header) string {
+	offered := map[string]bool{}
+	for _, h := range header.Values("WWW-Authenticate") {
+		scheme, _, _ := strings.Cut(h, " ")
+		offered[strings.ToLower(scheme)] = true
+	}
+	switch {
+	case offered["ntlm"]:
+		return "NTLM"
+	case offered["negotiate"]:
+		return "Negotiate"
+	}
+	return ""
+}
+
+// drain reads and closes resp's body so its connection can be reused.
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
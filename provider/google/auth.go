@@ -0,0 +1,194 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"golang.org/x/oauth2"
+)
+
+// Google OAuth endpoints used for token lifecycle management.
+var (
+	googleTokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+	googleRevokeURL    = "https://oauth2.googleapis.com/revoke"
+)
+
+// TokenInfo describes the current access token as reported by Google.
+type TokenInfo struct {
+	Email  string
+	Scopes []string
+	Expiry time.Time
+}
+
+// refreshToken exchanges the refresh token for a new access token if the
+// current one has expired. A new token is persisted by the token source.
+func (g *Provider) refreshToken(ctx context.Context) error {
+	if _, err := g.tokenSource().Token(); err != nil {
+		return fmt.Errorf("%w: failed to refresh token: %w", tokenError(err), err)
+	}
+	return nil
+}
+
+// setToken replaces the current token and the token source built on it.
+func (g *Provider) setToken(token *oauth2.Token) {
+	g.tokenMu.Lock()
+	defer g.tokenMu.Unlock()
+	g.token = token
+	g.ts = nil
+}
+
+// tokenSource returns the token source shared by every request of this
+// provider, so an expired token is refreshed once rather than per client,
+// and each new token is persisted as it rotates.
+func (g *Provider) tokenSource() oauth2.TokenSource {
+	g.tokenMu.Lock()
+	defer g.tokenMu.Unlock()
+	if g.ts == nil {
+		src := g.config.TokenSource(context.Background(), g.token)
+		g.ts = oauth2.ReuseTokenSource(g.token, &persistingTokenSource{g: g, src: src})
+	}
+	return g.ts
+}
+
+// persistingTokenSource saves each token its source issues.
+type persistingTokenSource struct {
+	g   *Provider
+	src oauth2.TokenSource
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.g.tokenMu.Lock()
+	s.g.token = token
+	s.g.tokenMu.Unlock()
+	if err := s.g.persistToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// persistToken stores token in the credentials file, unless the file
+// already holds it.
+func (g *Provider) persistToken(token *oauth2.Token) error {
+	creds, err := g.LoadCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+	refresh := token.RefreshToken
+	if refresh == "" {
+		refresh = creds.RefreshToken
+	}
+	if creds.AccessToken == token.AccessToken && creds.RefreshToken == refresh && creds.Expiry.Equal(token.Expiry) {
+		return nil
+	}
+	creds.RefreshToken = refresh
+	creds.AccessToken = token.AccessToken
+	creds.Expiry = token.Expiry
+	if err := g.SaveCredentials(creds); err != nil {
+		return fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	return nil
+}
+
+// RefreshAccessToken forces a new access token to be issued, regardless of
+// whether the current one has expired.
+func (g *Provider) RefreshAccessToken() (time.Time, error) {
+	if g.config == nil || g.token == nil {
+		return time.Time{}, contacts.ErrNotInitialized
+	}
+	expired := *g.token
+	expired.Expiry = time.Now().Add(-time.Hour)
+	g.setToken(&expired)
+	if err := g.refreshToken(context.Background()); err != nil {
+		return time.Time{}, err
+	}
+	return g.token.Expiry, nil
+}
+
+// TokenStatus refreshes the access token if needed and asks Google which
+// account and scopes it grants and when it expires.
+func (g *Provider) TokenStatus() (*TokenInfo, error) {
+	ctx := context.Background()
+	if g.config == nil || g.token == nil {
+		return nil, contacts.ErrNotInitialized
+	}
+	if err := g.refreshToken(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := http.Get(googleTokenInfoURL + "?" + url.Values{"access_token": {g.token.AccessToken}}.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to fetch token info: %w", contacts.ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token info request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	var result struct {
+		Email     string `json:"email"`
+		Scope     string `json:"scope"`
+		ExpiresIn string `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode token info: %w", err)
+	}
+	info := &TokenInfo{
+		Email:  result.Email,
+		Scopes: strings.Fields(result.Scope),
+		Expiry: g.token.Expiry,
+	}
+	if secs, err := strconv.Atoi(result.ExpiresIn); err == nil {
+		info.Expiry = time.Now().Add(time.Duration(secs) * time.Second)
+	}
+	return info, nil
+}
+
+// Revoke invalidates the refresh token at Google and removes the stored
+// tokens and sync token. The OAuth client ID and secret are kept so the
+// user can re-authorize without re-entering them.
+func (g *Provider) Revoke() error {
+	creds, err := g.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	token := creds.RefreshToken
+	if token == "" {
+		token = creds.AccessToken
+	}
+	if token != "" {
+		resp, err := http.PostForm(googleRevokeURL, url.Values{"token": {token}})
+		if err != nil {
+			return fmt.Errorf("%w: failed to revoke token: %w", contacts.ErrProviderUnavailable, err)
+		}
+		defer resp.Body.Close()
+		// 400 means the token was already invalid, which is the goal.
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("token revocation failed with status %d: %s", resp.StatusCode, string(body))
+		}
+	}
+	creds.RefreshToken = ""
+	creds.AccessToken = ""
+	creds.Expiry = time.Time{}
+	if err := g.SaveCredentials(creds); err != nil {
+		return err
+	}
+	g.setToken(nil)
+	g.syncToken = ""
+	if err := os.Remove(g.syncTokenPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sync token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestProvider_Revoke(t *testing.T) {
+	var revoked string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		revoked = r.Form.Get("token")
+	}))
+	defer srv.Close()
+	oldURL := googleRevokeURL
+	googleRevokeURL = srv.URL
+	defer func() { googleRevokeURL = oldURL }()
+
+	g, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SaveCredentials(&Credentials{ClientID: "id", ClientSecret: "secret", RefreshToken: "refresh", AccessToken: "access"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.SaveSyncToken("sync"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Revoke(); err != nil {
+		t.Fatal(err)
+	}
+	if revoked != "refresh" {
+		t.Errorf("revoked %q, want refresh token", revoked)
+	}
+	creds, err := g.LoadCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.RefreshToken != "" || creds.AccessToken != "" {
+		t.Errorf("tokens not wiped: %+v", creds)
+	}
+	if creds.ClientID != "id" {
+		t.Errorf("client ID should be kept, got %q", creds.ClientID)
+	}
+	if _, err := os.Stat(g.syncTokenPath); !os.IsNotExist(err) {
+		t.Error("sync token not removed")
+	}
+}
+
+func TestProvider_TokenStatusUninitialized(t *testing.T) {
+	g, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.TokenStatus(); err == nil {
+		t.Error("expected error without credentials")
+	}
+	if _, err := g.RefreshAccessToken(); err == nil {
+		t.Error("expected error without credentials")
+	}
+}
+
+func TestProvider_RefreshTokenPersistsOnRotation(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	newProvider := func() *Provider {
+		g, err := NewProvider(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		g.config.Endpoint.TokenURL = srv.URL
+		return g
+	}
+	seed, err := NewProvider(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := seed.SaveCredentials(&Credentials{ClientID: "id", ClientSecret: "secret", RefreshToken: "refresh", AccessToken: "stale"}); err != nil {
+		t.Fatal(err)
+	}
+
+	g := newProvider()
+	for i := 0; i < 3; i++ {
+		if err := g.refreshToken(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("token endpoint called %d times, want 1", requests)
+	}
+	info, err := os.Stat(g.creds.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := info.ModTime()
+
+	// A second process reuses the saved token until it expires.
+	g = newProvider()
+	if err := g.refreshToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 1 {
+		t.Errorf("token endpoint called %d times, want the saved token reused", requests)
+	}
+	creds, err := g.LoadCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessToken != "fresh" || creds.RefreshToken != "refresh" || creds.Expiry.IsZero() {
+		t.Errorf("credentials = %+v, want the rotated token and its expiry", creds)
+	}
+	if info, err := os.Stat(g.creds.Path()); err != nil || !info.ModTime().Equal(written) {
+		t.Errorf("credentials rewritten without a new token")
+	}
+}
@@ -0,0 +1,1568 @@
+// Package google implements a contacts.ContactProvider backed by the Google
+// People API.
+package google
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+	"golang.org/x/oauth2"
+	googleoauth "golang.org/x/oauth2/google"
+)
+
+var _ contacts.IncrementalProvider = (*Provider)(nil)
+
+//go:embed assets/logo.svg
+var logoSVG string
+
+// allPersonFields lists every personField the People API supports.
+const allPersonFields = "addresses,ageRanges,biographies,birthdays,calendarUrls,clientData,coverPhotos,emailAddresses,events,externalIds,genders,imClients,interests,locales,locations,memberships,metadata,miscKeywords,names,nicknames,occupations,organizations,phoneNumbers,photos,relations,sipAddresses,skills,urls,userDefined"
+
+// Built-in OAuth client, injected at build time so end users can authorize
+// without creating their own Google Cloud project:
+//
+//	go build -ldflags "-X github.com/arjungandhi/contacts/provider/google.defaultClientID=... -X github.com/arjungandhi/contacts/provider/google.defaultClientSecret=..."
+var (
+	defaultClientID     string
+	defaultClientSecret string
+)
+
+// DefaultCredentials returns the OAuth client built into the binary,
+// or nil if it was built without one.
+func DefaultCredentials() *Credentials {
+	if defaultClientID == "" {
+		return nil
+	}
+	return &Credentials{
+		ClientID:     defaultClientID,
+		ClientSecret: defaultClientSecret,
+	}
+}
+
+// peopleAPIBase is the base URL of the People API.
+var peopleAPIBase = "https://people.googleapis.com/v1"
+
+// Credentials are the OAuth client and tokens stored in credentials.json.
+type Credentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	// Expiry is when AccessToken expires, so a later run can reuse it
+	// instead of refreshing.
+	Expiry time.Time `json:"expiry,omitzero"`
+	Email  string    `json:"email,omitempty"`
+}
+
+// Provider syncs contacts with Google Contacts.
+type Provider struct {
+	config *oauth2.Config
+	token  *oauth2.Token
+	creds  providerutil.CredentialsFile[Credentials]
+	// groups caches group display names; see GroupNames.
+	groups map[string]string
+	// ts is the shared token source; see tokenSource.
+	ts            oauth2.TokenSource
+	tokenMu       sync.Mutex
+	syncToken     string
+	syncTokenPath string
+	// pendingSyncToken is saved by CommitSync.
+	pendingSyncToken string
+	successPage      string
+	autoClose        bool
+	// photos is how photos are converted for upload; see SetPhotoOptions.
+	photos contacts.PhotoOptions
+}
+
+// defaultSuccessPage is shown in the browser once authorization completes.
+const defaultSuccessPage = `<html><head><title>Authorization Successful</title>
+<style>.logo-container{width:200px;height:200px;margin:0 auto 30px;}.logo-container svg{width:100%;height:100%;}</style>
+</head><body style="font-family:sans-serif;text-align:center;padding:50px;">
+<div class="logo-container">{{.Logo}}</div>
+<h1 style="color:#4CAF50;">Authorization Successful!</h1>
+<p>You can close this window and return to the terminal.</p>
+{{if .AutoClose}}<script>setTimeout(function(){window.close();}, 1500);</script>{{end}}
+</body></html>`
+
+// successPageData is passed to the success page template.
+type successPageData struct {
+	Logo      template.HTML
+	AutoClose bool
+}
+
+// SetSuccessPage overrides the page shown after authorization with the
+// html/template at templatePath (empty keeps the default). The template
+// receives .Logo and .AutoClose; when autoClose is set the default page
+// closes its own tab.
+func (g *Provider) SetSuccessPage(templatePath string, autoClose bool) {
+	g.successPage = templatePath
+	g.autoClose = autoClose
+}
+
+// SetPhotoOptions sets how photos attached locally are converted before
+// they are uploaded, normally the configuration's photos settings.
+func (g *Provider) SetPhotoOptions(opts contacts.PhotoOptions) {
+	g.photos = opts
+}
+
+func (g *Provider) successTemplate() (*template.Template, error) {
+	if g.successPage == "" {
+		return template.New("success").Parse(defaultSuccessPage)
+	}
+	tmpl, err := template.ParseFiles(g.successPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load success page template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func generatePKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	h := sha256.New()
+	h.Write([]byte(verifier))
+	challenge = base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+	return verifier, challenge, nil
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		creds:         providerutil.NewCredentialsFile[Credentials](filepath.Join(dir, "google_creds.json")),
+		syncTokenPath: filepath.Join(dir, "google_sync_token.txt"),
+	}, nil
+}
+
+func (g *Provider) SaveCredentials(creds *Credentials) error {
+	return g.creds.Save(creds)
+}
+
+// LoadCredentials returns a copy of the stored credentials. The file is
+// read on the first call only; later calls return what was last loaded or
+// saved.
+func (g *Provider) LoadCredentials() (*Credentials, error) {
+	return g.creds.Load()
+}
+
+func (g *Provider) Initialize() error {
+	creds, err := g.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	g.config = &oauth2.Config{
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		Endpoint:     googleoauth.Endpoint,
+		RedirectURL:  "http://localhost:8080/callback",
+		Scopes: []string{
+			"https://www.googleapis.com/auth/contacts",
+			"https://www.googleapis.com/auth/userinfo.email",
+		},
+	}
+	if creds.RefreshToken != "" {
+		expiry := creds.Expiry
+		if expiry.IsZero() {
+			expiry = time.Now().Add(-time.Hour)
+		}
+		g.setToken(&oauth2.Token{
+			RefreshToken: creds.RefreshToken,
+			AccessToken:  creds.AccessToken,
+			Expiry:       expiry,
+		})
+	}
+	if data, err := os.ReadFile(g.syncTokenPath); err == nil {
+		g.syncToken = string(data)
+	}
+	return nil
+}
+
+func (g *Provider) AuthorizeWithPKCE(ctx context.Context) (authURL string, errChan <-chan error, err error) {
+	if g.config == nil {
+		return "", nil, contacts.ErrNotInitialized
+	}
+	successPage, err := g.successTemplate()
+	if err != nil {
+		return "", nil, err
+	}
+	verifier, challenge, err := generatePKCE()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate PKCE: %w", err)
+	}
+	stateBytes := make([]byte, 16)
+	rand.Read(stateBytes)
+	state := base64.RawURLEncoding.EncodeToString(stateBytes)
+
+	authURL = g.config.AuthCodeURL(state,
+		oauth2.AccessTypeOffline,
+		oauth2.ApprovalForce,
+		oauth2.SetAuthURLParam("code_challenge", challenge),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+
+	resultCh := make(chan error, 1)
+	mux := http.NewServeMux()
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: mux,
+	}
+
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+			errDesc := r.URL.Query().Get("error_description")
+			http.Error(w, "Authorization failed", http.StatusBadRequest)
+			resultCh <- fmt.Errorf("authorization failed: %s - %s", errMsg, errDesc)
+			return
+		}
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "No authorization code received", http.StatusBadRequest)
+			resultCh <- fmt.Errorf("no authorization code in callback")
+			return
+		}
+		returnedState := r.URL.Query().Get("state")
+		if returnedState != state {
+			http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+			resultCh <- fmt.Errorf("state mismatch: CSRF attack detected")
+			return
+		}
+		token, err := g.config.Exchange(ctx, code,
+			oauth2.SetAuthURLParam("code_verifier", verifier),
+		)
+		if err != nil {
+			http.Error(w, "Token exchange failed", http.StatusInternalServerError)
+			resultCh <- fmt.Errorf("failed to exchange code: %w", err)
+			return
+		}
+		g.setToken(token)
+		if err := g.persistToken(token); err != nil {
+			http.Error(w, "Failed to save credentials", http.StatusInternalServerError)
+			resultCh <- fmt.Errorf("failed to save credentials: %w", err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		successPage.Execute(w, successPageData{Logo: template.HTML(logoSVG), AutoClose: g.autoClose})
+		resultCh <- nil
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			server.Shutdown(context.Background())
+		}()
+	})
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			resultCh <- fmt.Errorf("server error: %w", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+		select {
+		case resultCh <- ctx.Err():
+		default:
+		}
+	}()
+
+	return authURL, resultCh, nil
+}
+
+func (g *Provider) SaveSyncToken(token string) error {
+	g.syncToken = token
+	return os.WriteFile(g.syncTokenPath, []byte(token), 0600)
+}
+
+func (g *Provider) GetSyncToken() string {
+	return g.syncToken
+}
+
+// --- People API response structures ---
+
+type peopleAPIPerson struct {
+	ResourceName   string                   `json:"resourceName"`
+	ETag           string                   `json:"etag"`
+	Names          []peopleAPIName          `json:"names"`
+	Nicknames      []peopleAPINickname      `json:"nicknames"`
+	PhoneNumbers   []peopleAPIPhoneNumber   `json:"phoneNumbers"`
+	EmailAddresses []peopleAPIEmailAddress  `json:"emailAddresses"`
+	Addresses      []peopleAPIAddress       `json:"addresses"`
+	Organizations  []peopleAPIOrganization  `json:"organizations"`
+	Birthdays      []peopleAPIBirthday      `json:"birthdays"`
+	Photos         []peopleAPIPhoto         `json:"photos"`
+	Biographies    []peopleAPIBiography     `json:"biographies"`
+	URLs           []peopleAPIURL           `json:"urls"`
+	Events         []peopleAPIEvent         `json:"events"`
+	Genders        []peopleAPIGender        `json:"genders"`
+	ImClients      []peopleAPIImClient      `json:"imClients"`
+	Relations      []peopleAPIRelation      `json:"relations"`
+	CalendarURLs   []peopleAPICalendarURL   `json:"calendarUrls"`
+	SipAddresses   []peopleAPISipAddress    `json:"sipAddresses"`
+	Locales        []peopleAPILocale        `json:"locales"`
+	Interests      []peopleAPIInterest      `json:"interests"`
+	Skills         []peopleAPISkill         `json:"skills"`
+	Occupations    []peopleAPIOccupation    `json:"occupations"`
+	Locations      []peopleAPILocation      `json:"locations"`
+	Memberships    []peopleAPIMembership    `json:"memberships"`
+	UserDefined    []peopleAPIUserDefined   `json:"userDefined"`
+	ClientData     []peopleAPIClientData    `json:"clientData"`
+	ExternalIds    []peopleAPIExternalId    `json:"externalIds"`
+	MiscKeywords   []peopleAPIMiscKeyword   `json:"miscKeywords"`
+	CoverPhotos    []peopleAPICoverPhoto    `json:"coverPhotos"`
+	AgeRanges      []peopleAPIAgeRange      `json:"ageRanges"`
+	Metadata       *peopleAPIPersonMetadata `json:"metadata"`
+}
+
+type peopleAPIName struct {
+	DisplayName          string                  `json:"displayName"`
+	FamilyName           string                  `json:"familyName"`
+	GivenName            string                  `json:"givenName"`
+	MiddleName           string                  `json:"middleName"`
+	HonorificPrefix      string                  `json:"honorificPrefix"`
+	HonorificSuffix      string                  `json:"honorificSuffix"`
+	DisplayNameLastFirst string                  `json:"displayNameLastFirst"`
+	Metadata             *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPINickname struct {
+	Value    string                  `json:"value"`
+	Type     string                  `json:"type"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIPhoneNumber struct {
+	Value    string                  `json:"value"`
+	Type     string                  `json:"type"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIEmailAddress struct {
+	Value    string                  `json:"value"`
+	Type     string                  `json:"type"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+// peopleAPIFieldMetadata is the metadata of one of a person's fields.
+// Primary maps to a vCard PREF=1, so the field Google shows first stays
+// first after a round trip. The update time of the field's source maps to
+// contacts.ParamUpdated, for per-field newest-wins merges.
+type peopleAPIFieldMetadata struct {
+	Primary bool `json:"primary"`
+	Source  *struct {
+		UpdateTime string `json:"updateTime"`
+	} `json:"source,omitempty"`
+}
+
+// markPrimary sets PREF=1 on f if md marks its People API field primary.
+func markPrimary(f *vcard.Field, md *peopleAPIFieldMetadata) {
+	if md != nil && md.Primary {
+		f.Params.Set(vcard.ParamPreferred, "1")
+	}
+}
+
+// markUpdated stamps f with the update time of the source md's field came
+// from, if known.
+func markUpdated(f *vcard.Field, md *peopleAPIFieldMetadata) {
+	if f == nil || md == nil || md.Source == nil {
+		return
+	}
+	if t, err := time.Parse(time.RFC3339Nano, md.Source.UpdateTime); err == nil {
+		contacts.MarkUpdated(f, t)
+	}
+}
+
+type peopleAPIAddress struct {
+	StreetAddress   string                  `json:"streetAddress"`
+	ExtendedAddress string                  `json:"extendedAddress"`
+	City            string                  `json:"city"`
+	Region          string                  `json:"region"`
+	PostalCode      string                  `json:"postalCode"`
+	Country         string                  `json:"country"`
+	PostOfficeBox   string                  `json:"poBox"`
+	Type            string                  `json:"type"`
+	Metadata        *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIOrganization struct {
+	Name       string                  `json:"name"`
+	Title      string                  `json:"title"`
+	Department string                  `json:"department"`
+	Metadata   *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIBirthday struct {
+	Date struct {
+		Year  int `json:"year"`
+		Month int `json:"month"`
+		Day   int `json:"day"`
+	} `json:"date"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIPhoto struct {
+	URL string `json:"url"`
+}
+
+type peopleAPIBiography struct {
+	Value    string                  `json:"value"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIURL struct {
+	Value    string                  `json:"value"`
+	Type     string                  `json:"type"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPIEvent struct {
+	Date struct {
+		Year  int `json:"year"`
+		Month int `json:"month"`
+		Day   int `json:"day"`
+	} `json:"date"`
+	Type string `json:"type"`
+}
+
+type peopleAPIGender struct {
+	Value       string `json:"value"`
+	AddressMeAs string `json:"addressMeAs,omitempty"`
+}
+
+// googleGenders maps the People API's predefined gender values to vCard
+// GENDER sex codes. Other values are custom genders.
+var googleGenders = map[string]string{
+	"male":        "M",
+	"female":      "F",
+	"unspecified": "U",
+}
+
+type peopleAPIImClient struct {
+	Username string `json:"username"`
+	Protocol string `json:"protocol"`
+	Type     string `json:"type"`
+}
+
+type peopleAPIRelation struct {
+	Person   string                  `json:"person"`
+	Type     string                  `json:"type"`
+	Metadata *peopleAPIFieldMetadata `json:"metadata"`
+}
+
+type peopleAPICalendarURL struct {
+	URL  string `json:"url"`
+	Type string `json:"type"`
+}
+
+type peopleAPISipAddress struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+type peopleAPILocale struct {
+	Value string `json:"value"`
+}
+
+type peopleAPIInterest struct {
+	Value string `json:"value"`
+}
+
+type peopleAPISkill struct {
+	Value string `json:"value"`
+}
+
+type peopleAPIOccupation struct {
+	Value string `json:"value"`
+}
+
+type peopleAPILocation struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// System contact groups with a meaning of their own.
+const (
+	groupMyContacts = "contactGroups/myContacts"
+	groupStarred    = "contactGroups/starred"
+	groupBlocked    = "contactGroups/blocked"
+)
+
+type peopleAPIMembership struct {
+	ContactGroupMembership *struct {
+		ContactGroupResourceName string `json:"contactGroupResourceName"`
+	} `json:"contactGroupMembership"`
+}
+
+type peopleAPIUserDefined struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type peopleAPIClientData struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type peopleAPIExternalId struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+type peopleAPIMiscKeyword struct {
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+type peopleAPICoverPhoto struct {
+	URL string `json:"url"`
+}
+
+type peopleAPIAgeRange struct {
+	AgeRange string `json:"ageRange"`
+}
+
+type peopleAPIPersonMetadata struct {
+	Deleted bool `json:"deleted"`
+	Sources []struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"sources"`
+}
+
+// --- Conversion: People API → vcard.Card ---
+
+func convertPeopleAPIToCard(person peopleAPIPerson) vcard.Card {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+
+	// UID from resourceName
+	uid := person.ResourceName
+	if strings.Contains(uid, "/") {
+		parts := strings.Split(uid, "/")
+		uid = parts[len(parts)-1]
+	}
+	card.SetValue(vcard.FieldUID, uid)
+	card.SetValue(contacts.FieldProviderID, uid)
+
+	// ETag
+	if person.ETag != "" {
+		card.SetValue("X-GOOGLE-ETAG", person.ETag)
+	}
+
+	// Names → FN, N
+	if len(person.Names) > 0 {
+		name := person.Names[0]
+		if name.DisplayName != "" {
+			card.SetValue(vcard.FieldFormattedName, name.DisplayName)
+		}
+		// N: family;given;middle;prefix;suffix
+		nField := &vcard.Field{
+			Value: name.FamilyName + ";" + name.GivenName + ";" + name.MiddleName + ";" + name.HonorificPrefix + ";" + name.HonorificSuffix,
+		}
+		card[vcard.FieldName] = []*vcard.Field{nField}
+		markUpdated(nField, name.Metadata)
+		markUpdated(card.Get(vcard.FieldFormattedName), name.Metadata)
+	}
+
+	// Nicknames → NICKNAME, except maiden names → X-FORMER-NAME
+	for _, nick := range person.Nicknames {
+		if nick.Type == "MAIDEN_NAME" {
+			contacts.AddFormerName(card, nick.Value, true)
+			continue
+		}
+		f := &vcard.Field{Value: nick.Value}
+		markUpdated(f, nick.Metadata)
+		card.Add(vcard.FieldNickname, f)
+	}
+
+	// PhoneNumbers → TEL
+	for _, phone := range person.PhoneNumbers {
+		f := &vcard.Field{
+			Value:  phone.Value,
+			Params: vcard.Params{},
+		}
+		if phone.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(phone.Type)}
+		}
+		markPrimary(f, phone.Metadata)
+		markUpdated(f, phone.Metadata)
+		card.Add(vcard.FieldTelephone, f)
+	}
+
+	// EmailAddresses → EMAIL
+	for _, email := range person.EmailAddresses {
+		f := &vcard.Field{
+			Value:  email.Value,
+			Params: vcard.Params{},
+		}
+		if email.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(email.Type)}
+		}
+		markPrimary(f, email.Metadata)
+		markUpdated(f, email.Metadata)
+		card.Add(vcard.FieldEmail, f)
+	}
+
+	// Addresses → ADR
+	for _, addr := range person.Addresses {
+		// ADR: PO Box;Extended;Street;City;Region;PostalCode;Country
+		adrValue := addr.PostOfficeBox + ";" + addr.ExtendedAddress + ";" + addr.StreetAddress + ";" + addr.City + ";" + addr.Region + ";" + addr.PostalCode + ";" + addr.Country
+		f := &vcard.Field{
+			Value:  adrValue,
+			Params: vcard.Params{},
+		}
+		if addr.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(addr.Type)}
+		}
+		markPrimary(f, addr.Metadata)
+		markUpdated(f, addr.Metadata)
+		card.Add(vcard.FieldAddress, f)
+	}
+
+	// Organizations → ORG, TITLE, from the primary organization
+	if len(person.Organizations) > 0 {
+		org := person.Organizations[0]
+		for _, o := range person.Organizations {
+			if o.Metadata != nil && o.Metadata.Primary {
+				org = o
+				break
+			}
+		}
+		orgParts := org.Name
+		if org.Department != "" {
+			orgParts += ";" + org.Department
+		}
+		card.SetValue(vcard.FieldOrganization, orgParts)
+		markUpdated(card.Get(vcard.FieldOrganization), org.Metadata)
+		if org.Title != "" {
+			card.SetValue(vcard.FieldTitle, org.Title)
+			markUpdated(card.Get(vcard.FieldTitle), org.Metadata)
+		}
+	}
+
+	// Birthdays → BDAY
+	if len(person.Birthdays) > 0 {
+		bday := person.Birthdays[0]
+		if bday.Date.Year > 0 && bday.Date.Month > 0 && bday.Date.Day > 0 {
+			card.SetValue(vcard.FieldBirthday, fmt.Sprintf("%04d%02d%02d", bday.Date.Year, bday.Date.Month, bday.Date.Day))
+		} else if bday.Date.Month > 0 && bday.Date.Day > 0 {
+			card.SetValue(vcard.FieldBirthday, fmt.Sprintf("--%02d%02d", bday.Date.Month, bday.Date.Day))
+		}
+		markUpdated(card.Get(vcard.FieldBirthday), bday.Metadata)
+	}
+
+	// Photos → PHOTO
+	for _, photo := range person.Photos {
+		card.Add(vcard.FieldPhoto, &vcard.Field{Value: photo.URL})
+	}
+
+	// Biographies → NOTE
+	for _, bio := range person.Biographies {
+		f := &vcard.Field{Value: bio.Value}
+		markUpdated(f, bio.Metadata)
+		card.Add(vcard.FieldNote, f)
+	}
+
+	// URLs → URL
+	for _, u := range person.URLs {
+		f := &vcard.Field{
+			Value:  u.Value,
+			Params: vcard.Params{},
+		}
+		if u.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(u.Type)}
+		}
+		markPrimary(f, u.Metadata)
+		markUpdated(f, u.Metadata)
+		card.Add(vcard.FieldURL, f)
+	}
+
+	// Events → ANNIVERSARY or X-GOOGLE-EVENT
+	for _, event := range person.Events {
+		dateStr := ""
+		if event.Date.Year > 0 {
+			dateStr = fmt.Sprintf("%04d%02d%02d", event.Date.Year, event.Date.Month, event.Date.Day)
+		} else if event.Date.Month > 0 && event.Date.Day > 0 {
+			dateStr = fmt.Sprintf("--%02d%02d", event.Date.Month, event.Date.Day)
+		}
+		if dateStr == "" {
+			continue
+		}
+		if strings.ToLower(event.Type) == "anniversary" {
+			card.SetValue(vcard.FieldAnniversary, dateStr)
+		} else {
+			f := &vcard.Field{
+				Value:  dateStr,
+				Params: vcard.Params{},
+			}
+			if event.Type != "" {
+				f.Params[vcard.ParamType] = []string{event.Type}
+			}
+			card.Add("X-GOOGLE-EVENT", f)
+		}
+	}
+
+	// Genders → GENDER, with custom genders as the identity text, and
+	// addressMeAs → PRONOUNS
+	for _, gender := range person.Genders {
+		if sex, ok := googleGenders[strings.ToLower(gender.Value)]; ok {
+			card.SetValue(vcard.FieldGender, sex)
+		} else if gender.Value != "" {
+			card.SetValue(vcard.FieldGender, "O;"+gender.Value)
+		}
+		if gender.AddressMeAs != "" {
+			card.SetValue(contacts.FieldPronouns, gender.AddressMeAs)
+		}
+	}
+
+	// ImClients → IMPP
+	for _, im := range person.ImClients {
+		protocol := strings.ToLower(im.Protocol)
+		uri := protocol + ":" + im.Username
+		f := &vcard.Field{
+			Value:  uri,
+			Params: vcard.Params{},
+		}
+		if im.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(im.Type)}
+		}
+		card.Add(vcard.FieldIMPP, f)
+	}
+
+	// Relations → RELATED
+	for _, rel := range person.Relations {
+		f := &vcard.Field{
+			Value:  rel.Person,
+			Params: vcard.Params{},
+		}
+		if rel.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(rel.Type)}
+		}
+		markPrimary(f, rel.Metadata)
+		markUpdated(f, rel.Metadata)
+		card.Add(vcard.FieldRelated, f)
+	}
+
+	// CalendarURLs → CALURI
+	for _, cal := range person.CalendarURLs {
+		f := &vcard.Field{
+			Value:  cal.URL,
+			Params: vcard.Params{},
+		}
+		if cal.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(cal.Type)}
+		}
+		card.Add(vcard.FieldCalendarURI, f)
+	}
+
+	// SipAddresses → IMPP with sip: prefix
+	for _, sip := range person.SipAddresses {
+		f := &vcard.Field{
+			Value:  "sip:" + sip.Value,
+			Params: vcard.Params{},
+		}
+		if sip.Type != "" {
+			f.Params[vcard.ParamType] = []string{strings.ToLower(sip.Type)}
+		}
+		card.Add(vcard.FieldIMPP, f)
+	}
+
+	// Locales → LANG
+	for _, locale := range person.Locales {
+		card.Add(vcard.FieldLanguage, &vcard.Field{Value: locale.Value})
+	}
+
+	// --- X-GOOGLE-* extensions ---
+
+	// Interests
+	for _, interest := range person.Interests {
+		card.Add("X-GOOGLE-INTEREST", &vcard.Field{Value: interest.Value})
+	}
+
+	// Skills
+	for _, skill := range person.Skills {
+		card.Add("X-GOOGLE-SKILL", &vcard.Field{Value: skill.Value})
+	}
+
+	// Occupations
+	for _, occ := range person.Occupations {
+		card.Add("X-GOOGLE-OCCUPATION", &vcard.Field{Value: occ.Value})
+	}
+
+	// Locations. A location that names a time zone or a position also
+	// fills TZ or GEO unless client data already set them.
+	for _, loc := range person.Locations {
+		if _, err := contacts.ParseTimeZone(loc.Value); err == nil && strings.Contains(loc.Value, "/") {
+			card.SetValue(vcard.FieldTimezone, loc.Value)
+		} else if lat, lon, err := contacts.ParseGeo(loc.Value); err == nil {
+			card.SetValue(vcard.FieldGeolocation, contacts.FormatGeo(lat, lon))
+		}
+		f := &vcard.Field{
+			Value:  loc.Value,
+			Params: vcard.Params{},
+		}
+		if loc.Type != "" {
+			f.Params[vcard.ParamType] = []string{loc.Type}
+		}
+		card.Add("X-GOOGLE-LOCATION", f)
+	}
+
+	// Memberships: the starred and blocked system groups become flags, and
+	// myContacts, which every synced contact is in, is left out.
+	for _, mem := range person.Memberships {
+		if mem.ContactGroupMembership == nil {
+			continue
+		}
+		switch group := mem.ContactGroupMembership.ContactGroupResourceName; group {
+		case groupStarred:
+			card.SetValue(contacts.FieldStarred, "true")
+		case groupBlocked:
+			card.SetValue(contacts.FieldBlocked, "true")
+		case groupMyContacts:
+		default:
+			card.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: group})
+		}
+	}
+
+	// UserDefined, except that a "pronouns" field fills PRONOUNS when the
+	// gender did not
+	for _, ud := range person.UserDefined {
+		if strings.EqualFold(strings.TrimSpace(ud.Key), "pronouns") && card.Value(contacts.FieldPronouns) == "" {
+			card.SetValue(contacts.FieldPronouns, ud.Value)
+			continue
+		}
+		card.Add("X-GOOGLE-CUSTOM-"+strings.ToUpper(strings.ReplaceAll(ud.Key, " ", "-")), &vcard.Field{Value: ud.Value})
+	}
+
+	// ClientData. TZ and GEO have no People API field and are kept here;
+	// other entries keep their original key so they can be written back.
+	for _, cd := range person.ClientData {
+		switch cd.Key {
+		case clientDataTZ:
+			card.SetValue(vcard.FieldTimezone, cd.Value)
+		case clientDataGeo:
+			card.SetValue(vcard.FieldGeolocation, cd.Value)
+		case clientDataKind:
+			card.SetValue(vcard.FieldKind, cd.Value)
+		default:
+			card.Add("X-GOOGLE-CLIENT-"+strings.ToUpper(strings.ReplaceAll(cd.Key, " ", "-")), &vcard.Field{
+				Value:  cd.Value,
+				Params: vcard.Params{paramClientKey: []string{cd.Key}},
+			})
+		}
+	}
+
+	// ExternalIds
+	for _, eid := range person.ExternalIds {
+		f := &vcard.Field{
+			Value:  eid.Value,
+			Params: vcard.Params{},
+		}
+		if eid.Type != "" {
+			f.Params[vcard.ParamType] = []string{eid.Type}
+		}
+		card.Add("X-GOOGLE-EXTERNAL-ID", f)
+	}
+
+	// MiscKeywords
+	for _, kw := range person.MiscKeywords {
+		f := &vcard.Field{
+			Value:  kw.Value,
+			Params: vcard.Params{},
+		}
+		if kw.Type != "" {
+			f.Params[vcard.ParamType] = []string{kw.Type}
+		}
+		card.Add("X-GOOGLE-KEYWORD", f)
+	}
+
+	// CoverPhotos
+	for _, cp := range person.CoverPhotos {
+		card.Add("X-GOOGLE-COVER-PHOTO", &vcard.Field{Value: cp.URL})
+	}
+
+	// AgeRanges
+	for _, ar := range person.AgeRanges {
+		card.Add("X-GOOGLE-AGE-RANGE", &vcard.Field{Value: ar.AgeRange})
+	}
+
+	// Metadata sources
+	if person.Metadata != nil {
+		for _, src := range person.Metadata.Sources {
+			card.Add("X-GOOGLE-SOURCE", &vcard.Field{
+				Value:  src.ID,
+				Params: vcard.Params{vcard.ParamType: []string{src.Type}},
+			})
+		}
+	}
+
+	// Ensure FN is set (vCard requires it)
+	if contacts.CardFullName(card) == "" {
+		card.SetValue(vcard.FieldFormattedName, uid)
+	}
+
+	if fields := fetchedPersonFields(person); len(fields) > 0 {
+		card.SetValue(fieldPersonFields, strings.Join(fields, ","))
+	}
+
+	return card
+}
+
+// fieldPersonFields lists the updatable person fields a contact had when
+// it was fetched, so an update can clear the ones removed locally.
+const fieldPersonFields = "X-GOOGLE-FIELDS"
+
+// updatablePersonFields are the person fields WriteContact sends, in the
+// order of an updatePersonFields mask.
+var updatablePersonFields = []string{
+	"names", "phoneNumbers", "emailAddresses", "addresses", "organizations", "birthdays",
+	"biographies", "urls", "relations", "clientData", "genders",
+}
+
+// fetchedPersonFields returns the updatable fields person sets.
+func fetchedPersonFields(person peopleAPIPerson) []string {
+	set := map[string]bool{
+		"names":          len(person.Names) > 0,
+		"phoneNumbers":   len(person.PhoneNumbers) > 0,
+		"emailAddresses": len(person.EmailAddresses) > 0,
+		"addresses":      len(person.Addresses) > 0,
+		"organizations":  len(person.Organizations) > 0,
+		"birthdays":      len(person.Birthdays) > 0,
+		"biographies":    len(person.Biographies) > 0,
+		"urls":           len(person.URLs) > 0,
+		"relations":      len(person.Relations) > 0,
+		"clientData":     len(person.ClientData) > 0,
+		"genders":        len(person.Genders) > 0,
+	}
+	var out []string
+	for _, field := range updatablePersonFields {
+		if set[field] {
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+// updatePersonFields returns the update mask for sending personData, the
+// converted card: the fields it sets, plus those the contact had at Google
+// when fetched, which the card no longer sets and so are cleared. Fields
+// the card never carried are left alone rather than wiped.
+func updatePersonFields(card vcard.Card, personData map[string]interface{}) string {
+	fetched := map[string]bool{}
+	for _, field := range strings.Split(card.Value(fieldPersonFields), ",") {
+		fetched[field] = true
+	}
+	var mask []string
+	for _, field := range updatablePersonFields {
+		if _, ok := personData[field]; ok || fetched[field] {
+			mask = append(mask, field)
+		}
+	}
+	return strings.Join(mask, ",")
+}
+
+// --- Conversion: vcard.Card → People API ---
+
+func convertCardToPeopleAPI(card vcard.Card) map[string]interface{} {
+	person := make(map[string]interface{})
+
+	// N → names
+	fn := contacts.CardFullName(card)
+	nFields := card[vcard.FieldName]
+	if len(nFields) > 0 {
+		parts := strings.SplitN(nFields[0].Value, ";", 5)
+		nameMap := map[string]interface{}{}
+		if len(parts) > 0 {
+			nameMap["familyName"] = parts[0]
+		}
+		if len(parts) > 1 {
+			nameMap["givenName"] = parts[1]
+		}
+		if len(parts) > 2 {
+			nameMap["middleName"] = parts[2]
+		}
+		if len(parts) > 3 {
+			nameMap["honorificPrefix"] = parts[3]
+		}
+		if len(parts) > 4 {
+			nameMap["honorificSuffix"] = parts[4]
+		}
+		person["names"] = []map[string]interface{}{nameMap}
+	} else if fn != "" {
+		person["names"] = []map[string]interface{}{{"displayName": fn}}
+	}
+
+	// TEL → phoneNumbers
+	if tels := card[vcard.FieldTelephone]; len(tels) > 0 {
+		phones := make([]map[string]interface{}, len(tels))
+		for i, f := range tels {
+			phones[i] = withPrimary(card, vcard.FieldTelephone, f, map[string]interface{}{"value": f.Value, "type": peopleAPIType(f)})
+		}
+		person["phoneNumbers"] = phones
+	}
+
+	// EMAIL → emailAddresses
+	if emails := card[vcard.FieldEmail]; len(emails) > 0 {
+		addrs := make([]map[string]interface{}, len(emails))
+		for i, f := range emails {
+			addrs[i] = withPrimary(card, vcard.FieldEmail, f, map[string]interface{}{"value": f.Value, "type": peopleAPIType(f)})
+		}
+		person["emailAddresses"] = addrs
+	}
+
+	// ADR → addresses
+	if adrs := card[vcard.FieldAddress]; len(adrs) > 0 {
+		addresses := make([]map[string]interface{}, len(adrs))
+		for i, f := range adrs {
+			parts := strings.SplitN(f.Value, ";", 7)
+			m := withPrimary(card, vcard.FieldAddress, f, map[string]interface{}{"type": peopleAPIType(f)})
+			if len(parts) > 0 {
+				m["poBox"] = parts[0]
+			}
+			if len(parts) > 1 {
+				m["extendedAddress"] = parts[1]
+			}
+			if len(parts) > 2 {
+				m["streetAddress"] = parts[2]
+			}
+			if len(parts) > 3 {
+				m["city"] = parts[3]
+			}
+			if len(parts) > 4 {
+				m["region"] = parts[4]
+			}
+			if len(parts) > 5 {
+				m["postalCode"] = parts[5]
+			}
+			if len(parts) > 6 {
+				m["country"] = parts[6]
+			}
+			addresses[i] = m
+		}
+		person["addresses"] = addresses
+	}
+
+	// ORG, TITLE → organizations
+	orgVal := card.Value(vcard.FieldOrganization)
+	titleVal := card.Value(vcard.FieldTitle)
+	if orgVal != "" || titleVal != "" {
+		orgMap := map[string]interface{}{}
+		if orgVal != "" {
+			parts := strings.SplitN(orgVal, ";", 2)
+			orgMap["name"] = parts[0]
+			if len(parts) > 1 {
+				orgMap["department"] = parts[1]
+			}
+		}
+		if titleVal != "" {
+			orgMap["title"] = titleVal
+		}
+		person["organizations"] = []map[string]interface{}{orgMap}
+	}
+
+	// BDAY → birthdays
+	if bday := card.Value(vcard.FieldBirthday); bday != "" {
+		dateMap := parseDateValue(bday)
+		if dateMap != nil {
+			person["birthdays"] = []map[string]interface{}{{"date": dateMap}}
+		}
+	}
+
+	// NOTE → biographies
+	if notes := card[vcard.FieldNote]; len(notes) > 0 {
+		bios := make([]map[string]interface{}, len(notes))
+		for i, f := range notes {
+			bios[i] = map[string]interface{}{"value": f.Value}
+		}
+		person["biographies"] = bios
+	}
+
+	// URL → urls
+	if urls := card[vcard.FieldURL]; len(urls) > 0 {
+		us := make([]map[string]interface{}, len(urls))
+		for i, f := range urls {
+			us[i] = withPrimary(card, vcard.FieldURL, f, map[string]interface{}{"value": f.Value, "type": peopleAPIType(f)})
+		}
+		person["urls"] = us
+	}
+
+	// RELATED → relations
+	if related := card[vcard.FieldRelated]; len(related) > 0 {
+		rels := make([]map[string]interface{}, len(related))
+		for i, f := range related {
+			rels[i] = withPrimary(card, vcard.FieldRelated, f, map[string]interface{}{"person": f.Value, "type": peopleAPIType(f)})
+		}
+		person["relations"] = rels
+	}
+
+	// TZ, GEO, KIND and X-GOOGLE-CLIENT-* → clientData
+	var clientData []map[string]interface{}
+	if tz := card.Value(vcard.FieldTimezone); tz != "" {
+		clientData = append(clientData, map[string]interface{}{"key": clientDataTZ, "value": tz})
+	}
+	if geo := card.Value(vcard.FieldGeolocation); geo != "" {
+		clientData = append(clientData, map[string]interface{}{"key": clientDataGeo, "value": geo})
+	}
+	if kind := card.Value(vcard.FieldKind); kind != "" {
+		clientData = append(clientData, map[string]interface{}{"key": clientDataKind, "value": kind})
+	}
+	for name, fields := range card {
+		if !strings.HasPrefix(name, "X-GOOGLE-CLIENT-") {
+			continue
+		}
+		for _, f := range fields {
+			key := f.Params.Get(paramClientKey)
+			if key == "" {
+				key = strings.ToLower(strings.TrimPrefix(name, "X-GOOGLE-CLIENT-"))
+			}
+			clientData = append(clientData, map[string]interface{}{"key": key, "value": f.Value})
+		}
+	}
+	if len(clientData) > 0 {
+		person["clientData"] = clientData
+	}
+
+	// GENDER and PRONOUNS → genders
+	if gender := genderToPeopleAPI(card); gender != nil {
+		person["genders"] = []map[string]interface{}{gender}
+	}
+
+	return person
+}
+
+// peopleAPIType returns the People API type of a field: its first TYPE
+// other than "pref", which is carried as metadata instead.
+func peopleAPIType(f *vcard.Field) string {
+	for _, t := range f.Params[vcard.ParamType] {
+		if !strings.EqualFold(t, "pref") {
+			return t
+		}
+	}
+	return ""
+}
+
+// withPrimary marks m, the People API form of f, as primary if f is the
+// preferred field of the given property, and returns it.
+func withPrimary(card vcard.Card, name string, f *vcard.Field, m map[string]interface{}) map[string]interface{} {
+	if contacts.IsPreferred(f) && card.Preferred(name) == f {
+		m["metadata"] = map[string]interface{}{"primary": true}
+	}
+	return m
+}
+
+// genderToPeopleAPI converts GENDER and PRONOUNS to a People API gender,
+// or returns nil if the card has neither.
+func genderToPeopleAPI(card vcard.Card) map[string]interface{} {
+	value := card.Value(vcard.FieldGender)
+	pronouns := card.Value(contacts.FieldPronouns)
+	if value == "" && pronouns == "" {
+		return nil
+	}
+	sex, identity, _ := strings.Cut(value, ";")
+	gender := map[string]interface{}{"value": "unspecified"}
+	switch {
+	case strings.TrimSpace(identity) != "":
+		gender["value"] = strings.TrimSpace(identity)
+	case strings.EqualFold(sex, "M"):
+		gender["value"] = "male"
+	case strings.EqualFold(sex, "F"):
+		gender["value"] = "female"
+	case sex != "" && len(sex) > 1:
+		// Stored by syncs before GENDER was mapped to sex codes.
+		gender["value"] = sex
+	}
+	if pronouns != "" {
+		gender["addressMeAs"] = pronouns
+	}
+	return gender
+}
+
+// Client data keys holding vCard fields the People API has no field for.
+const (
+	clientDataTZ   = "vcard.tz"
+	clientDataGeo  = "vcard.geo"
+	clientDataKind = "vcard.kind"
+)
+
+// paramClientKey records the original key of an X-GOOGLE-CLIENT-* field.
+const paramClientKey = "X-KEY"
+
+// parseDateValue parses YYYYMMDD or --MMDD vCard date format.
+func parseDateValue(s string) map[string]int {
+	s = strings.ReplaceAll(s, "-", "")
+	if len(s) == 8 {
+		// YYYYMMDD
+		t, err := time.Parse("20060102", s)
+		if err != nil {
+			return nil
+		}
+		return map[string]int{"year": t.Year(), "month": int(t.Month()), "day": t.Day()}
+	}
+	if len(s) == 4 {
+		// MMDD (after stripping --)
+		t, err := time.Parse("0102", s)
+		if err != nil {
+			return nil
+		}
+		return map[string]int{"year": 0, "month": int(t.Month()), "day": t.Day()}
+	}
+	return nil
+}
+
+// --- Provider methods ---
+
+func (g *Provider) FetchContacts() ([]vcard.Card, error) {
+	persons, _, err := g.listConnections("")
+	if err != nil {
+		return nil, err
+	}
+	var allCards []vcard.Card
+	for _, person := range persons {
+		allCards = append(allCards, convertPeopleAPIToCard(person))
+	}
+	if err := g.labelGroups(allCards); err != nil {
+		return nil, err
+	}
+	return allCards, nil
+}
+
+// FetchChanges returns the contacts changed or deleted at Google since the
+// last committed sync. Without a sync token, or if Google has expired it,
+// every contact is returned as changed.
+func (g *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	persons, nextToken, err := g.listConnections(g.syncToken)
+	if errors.Is(err, errSyncTokenExpired) {
+		persons, nextToken, err = g.listConnections("")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, person := range persons {
+		if person.Metadata != nil && person.Metadata.Deleted {
+			deleted = append(deleted, strings.TrimPrefix(person.ResourceName, "people/"))
+			continue
+		}
+		changed = append(changed, convertPeopleAPIToCard(person))
+	}
+	if err := g.labelGroups(changed); err != nil {
+		return nil, nil, err
+	}
+	g.pendingSyncToken = nextToken
+	return changed, deleted, nil
+}
+
+// CommitSync saves the sync token from the last FetchChanges, once its
+// changes have been stored locally.
+func (g *Provider) CommitSync() error {
+	if g.pendingSyncToken == "" {
+		return nil
+	}
+	if err := g.SaveSyncToken(g.pendingSyncToken); err != nil {
+		return fmt.Errorf("failed to save sync token: %w", err)
+	}
+	g.pendingSyncToken = ""
+	return nil
+}
+
+// errSyncTokenExpired is returned by listConnections when Google no longer
+// accepts the sync token and a full sync is needed.
+var errSyncTokenExpired = errors.New("sync token expired")
+
+// listConnections pages through the user's contacts. With a sync token
+// only contacts changed since that token are returned. A new sync token
+// is always requested and returned.
+func (g *Provider) listConnections(syncToken string) ([]peopleAPIPerson, string, error) {
+	ctx := context.Background()
+	if g.config == nil || g.token == nil {
+		return nil, "", contacts.ErrNotInitialized
+	}
+	if err := g.refreshToken(ctx); err != nil {
+		return nil, "", err
+	}
+	httpClient := oauth2.NewClient(ctx, g.tokenSource())
+
+	var persons []peopleAPIPerson
+	var nextSyncToken string
+	pageToken := ""
+	for {
+		params := url.Values{
+			"personFields":     []string{allPersonFields},
+			"pageSize":         []string{"1000"},
+			"sources":          []string{"READ_SOURCE_TYPE_CONTACT"},
+			"requestSyncToken": []string{"true"},
+		}
+		if syncToken != "" {
+			params.Set("syncToken", syncToken)
+		}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+		apiURL := peopleAPIBase + "/people/me/connections?" + params.Encode()
+		resp, err := httpClient.Get(apiURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: failed to fetch contacts: %w", contacts.ErrProviderUnavailable, err)
+		}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusGone && syncToken != "" {
+			return nil, "", errSyncTokenExpired
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("People API request failed with status %d: %s", resp.StatusCode, string(bodyBytes)))
+		}
+		var result struct {
+			Connections   []peopleAPIPerson `json:"connections"`
+			NextPageToken string            `json:"nextPageToken"`
+			NextSyncToken string            `json:"nextSyncToken"`
+		}
+		if err := json.Unmarshal(bodyBytes, &result); err != nil {
+			return nil, "", fmt.Errorf("failed to decode People API response: %w", err)
+		}
+		persons = append(persons, result.Connections...)
+		if result.NextSyncToken != "" {
+			nextSyncToken = result.NextSyncToken
+		}
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+	return persons, nextSyncToken, nil
+}
+
+// labelGroups sets each group membership's ParamGroupLabel to the group's
+// display name.
+func (g *Provider) labelGroups(cards []vcard.Card) error {
+	for _, card := range cards {
+		if len(card["X-GOOGLE-GROUP-MEMBERSHIP"]) == 0 {
+			continue
+		}
+		names, err := g.GroupNames()
+		if err != nil {
+			return err
+		}
+		contacts.LabelGroups(card, names)
+	}
+	return nil
+}
+
+// GroupNames returns the display names of the user's contact groups by
+// resource name. They are fetched on first use and kept for the life of
+// the provider.
+func (g *Provider) GroupNames() (map[string]string, error) {
+	if g.groups != nil {
+		return g.groups, nil
+	}
+	httpClient := oauth2.NewClient(context.Background(), g.tokenSource())
+	names := map[string]string{}
+	pageToken := ""
+	for {
+		params := url.Values{
+			"pageSize":    []string{"1000"},
+			"groupFields": []string{"name,groupType"},
+		}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+		resp, err := httpClient.Get(peopleAPIBase + "/contactGroups?" + params.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to fetch contact groups: %w", contacts.ErrProviderUnavailable, err)
+		}
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("contact groups request failed with status %d: %s", resp.StatusCode, string(bodyBytes)))
+		}
+		var result struct {
+			ContactGroups []struct {
+				ResourceName  string `json:"resourceName"`
+				FormattedName string `json:"formattedName"`
+			} `json:"contactGroups"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(bodyBytes, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode contact groups: %w", err)
+		}
+		for _, cg := range result.ContactGroups {
+			names[cg.ResourceName] = cg.FormattedName
+		}
+		if result.NextPageToken == "" {
+			break
+		}
+		pageToken = result.NextPageToken
+	}
+	g.groups = names
+	return names, nil
+}
+
+func (g *Provider) WriteContact(card vcard.Card) error {
+	ctx := context.Background()
+	if g.config == nil || g.token == nil {
+		return contacts.ErrNotInitialized
+	}
+	httpClient := oauth2.NewClient(ctx, g.tokenSource())
+	personData := convertCardToPeopleAPI(card)
+	var req *http.Request
+	var apiURL string
+	var err error
+
+	id := contacts.ProviderID(card)
+	isExistingGoogleContact := id != ""
+	if isExistingGoogleContact {
+		resourceName := fmt.Sprintf("people/%s", id)
+		apiURL = fmt.Sprintf("%s/%s:updateContact", peopleAPIBase, resourceName)
+		params := url.Values{}
+		params.Set("updatePersonFields", updatePersonFields(card, personData))
+		apiURL += "?" + params.Encode()
+
+		// Include etag for update
+		if etag := card.Value("X-GOOGLE-ETAG"); etag != "" {
+			personData["etag"] = etag
+		}
+		body, _ := json.Marshal(personData)
+		req, err = http.NewRequest("PATCH", apiURL, strings.NewReader(string(body)))
+	} else {
+		apiURL = peopleAPIBase + "/people:createContact"
+		body, _ := json.Marshal(personData)
+		req, err = http.NewRequest("POST", apiURL, strings.NewReader(string(body)))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create request for contact %s: %w", contacts.CardFullName(card), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update contact %s: %w", contacts.ErrProviderUnavailable, contacts.CardFullName(card), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to update contact %s (status %d): %s", contacts.CardFullName(card), resp.StatusCode, string(body)))
+	}
+	var written peopleAPIPerson
+	if err := json.NewDecoder(resp.Body).Decode(&written); err == nil && written.ResourceName != "" {
+		promoted := convertPeopleAPIToCard(written)
+		if !isExistingGoogleContact {
+			// Adopt the resource name Google assigned so the next sync
+			// recognizes the contact instead of duplicating it.
+			card.SetValue(vcard.FieldUID, contacts.CardUID(promoted))
+			card.SetValue(contacts.FieldProviderID, contacts.ProviderID(promoted))
+		}
+		if etag := promoted.Value("X-GOOGLE-ETAG"); etag != "" {
+			card.SetValue("X-GOOGLE-ETAG", etag)
+		}
+		// The next update must clear the fields the contact has now, not
+		// only those it had when last fetched.
+		if fields := promoted.Value(fieldPersonFields); fields != "" {
+			card.SetValue(fieldPersonFields, fields)
+		} else {
+			delete(card, fieldPersonFields)
+		}
+	}
+	if strings.HasPrefix(card.Value(vcard.FieldPhoto), "data:") {
+		if err := g.uploadPhoto(httpClient, card); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadPhoto sets the Google contact's photo to the card's PHOTO, a
+// photo attached locally as a data: URI. The card takes the URL Google
+// serves the photo from in its place, so it isn't uploaded again by the
+// next write, and the contact's new etag.
+func (g *Provider) uploadPhoto(httpClient *http.Client, card vcard.Card) error {
+	id := contacts.ProviderID(card)
+	if id == "" {
+		return nil
+	}
+	data, err := contacts.FetchPhoto(card)
+	if err != nil {
+		return err
+	}
+	// Google accepts JPEG and PNG photos.
+	if data, _, err = contacts.PreparePhoto(data, g.photos); err != nil {
+		return err
+	}
+	body, _ := json.Marshal(map[string]string{
+		"photoBytes":   base64.StdEncoding.EncodeToString(data),
+		"personFields": "photos",
+	})
+	apiURL := fmt.Sprintf("%s/people/%s:updateContactPhoto", peopleAPIBase, id)
+	req, err := http.NewRequest("PATCH", apiURL, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create photo request for contact %s: %w", contacts.CardFullName(card), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to upload photo of %s: %w", contacts.ErrProviderUnavailable, contacts.CardFullName(card), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to upload photo of %s (status %d): %s", contacts.CardFullName(card), resp.StatusCode, string(body)))
+	}
+	var result struct {
+		Person peopleAPIPerson `json:"person"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode photo response for %s: %w", contacts.CardFullName(card), err)
+	}
+	updated := convertPeopleAPIToCard(result.Person)
+	if photo := updated.Value(vcard.FieldPhoto); photo != "" {
+		card.SetValue(vcard.FieldPhoto, photo)
+	}
+	if etag := updated.Value("X-GOOGLE-ETAG"); etag != "" {
+		card.SetValue("X-GOOGLE-ETAG", etag)
+	}
+	return nil
+}
+
+// Name keys Google's contacts in the manager's ID map.
+func (g *Provider) Name() string {
+	return contacts.ProviderGoogle
+}
+
+func (g *Provider) DeleteContact(id string) error {
+	ctx := context.Background()
+	if g.config == nil || g.token == nil {
+		return contacts.ErrNotInitialized
+	}
+	httpClient := oauth2.NewClient(ctx, g.tokenSource())
+	resourceName := fmt.Sprintf("people/%s", id)
+	apiURL := fmt.Sprintf("%s/%s:deleteContact", peopleAPIBase, resourceName)
+	req, err := http.NewRequest("DELETE", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create delete request for contact %s: %w", id, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to delete contact %s: %w", contacts.ErrProviderUnavailable, id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to delete contact %s (status %d): %s", id, resp.StatusCode, string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,879 @@
+package google
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+func TestConvertPeopleAPIToCard(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/c123456",
+		ETag:         "etag123",
+		Names: []peopleAPIName{
+			{DisplayName: "John Doe", GivenName: "John", FamilyName: "Doe", MiddleName: "M", HonorificPrefix: "Dr", HonorificSuffix: "Jr"},
+		},
+		Nicknames: []peopleAPINickname{
+			{Value: "Johnny"},
+		},
+		PhoneNumbers: []peopleAPIPhoneNumber{
+			{Value: "555-1234", Type: "mobile"},
+			{Value: "555-5678", Type: "work"},
+		},
+		EmailAddresses: []peopleAPIEmailAddress{
+			{Value: "john@example.com", Type: "home"},
+		},
+		Addresses: []peopleAPIAddress{
+			{StreetAddress: "123 Main St", City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US", Type: "home"},
+		},
+		Organizations: []peopleAPIOrganization{
+			{Name: "Acme Inc", Title: "Engineer", Department: "R&D"},
+		},
+		Birthdays: []peopleAPIBirthday{
+			{Date: struct {
+				Year  int `json:"year"`
+				Month int `json:"month"`
+				Day   int `json:"day"`
+			}{Year: 1990, Month: 6, Day: 15}},
+		},
+		Photos: []peopleAPIPhoto{
+			{URL: "https://photo.example.com/john.jpg"},
+		},
+		Biographies: []peopleAPIBiography{
+			{Value: "Some notes about John"},
+		},
+		URLs: []peopleAPIURL{
+			{Value: "https://johndoe.com", Type: "blog"},
+		},
+		Events: []peopleAPIEvent{
+			{Date: struct {
+				Year  int `json:"year"`
+				Month int `json:"month"`
+				Day   int `json:"day"`
+			}{Year: 2020, Month: 1, Day: 1}, Type: "anniversary"},
+			{Date: struct {
+				Year  int `json:"year"`
+				Month int `json:"month"`
+				Day   int `json:"day"`
+			}{Year: 2015, Month: 3, Day: 10}, Type: "other"},
+		},
+		Genders: []peopleAPIGender{
+			{Value: "male"},
+		},
+		ImClients: []peopleAPIImClient{
+			{Username: "johndoe", Protocol: "xmpp", Type: "home"},
+		},
+		Relations: []peopleAPIRelation{
+			{Person: "Jane Doe", Type: "spouse"},
+		},
+		CalendarURLs: []peopleAPICalendarURL{
+			{URL: "https://calendar.example.com/john", Type: "home"},
+		},
+		SipAddresses: []peopleAPISipAddress{
+			{Value: "john@sip.example.com", Type: "work"},
+		},
+		Locales: []peopleAPILocale{
+			{Value: "en-US"},
+		},
+		Interests: []peopleAPIInterest{
+			{Value: "Coding"},
+		},
+		Skills: []peopleAPISkill{
+			{Value: "Go"},
+		},
+		Occupations: []peopleAPIOccupation{
+			{Value: "Software Developer"},
+		},
+		Locations: []peopleAPILocation{
+			{Value: "Building A", Type: "desk"},
+		},
+		Memberships: []peopleAPIMembership{
+			{ContactGroupMembership: &struct {
+				ContactGroupResourceName string `json:"contactGroupResourceName"`
+			}{ContactGroupResourceName: "contactGroups/friends"}},
+		},
+		UserDefined: []peopleAPIUserDefined{
+			{Key: "Shirt Size", Value: "L"},
+		},
+		ClientData: []peopleAPIClientData{
+			{Key: "app-id", Value: "12345"},
+		},
+		ExternalIds: []peopleAPIExternalId{
+			{Value: "EMP001", Type: "organization"},
+		},
+		MiscKeywords: []peopleAPIMiscKeyword{
+			{Value: "VIP", Type: "outlook"},
+		},
+		CoverPhotos: []peopleAPICoverPhoto{
+			{URL: "https://cover.example.com/john.jpg"},
+		},
+		AgeRanges: []peopleAPIAgeRange{
+			{AgeRange: "TWENTY_ONE_OR_OLDER"},
+		},
+		Metadata: &peopleAPIPersonMetadata{
+			Sources: []struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+			}{
+				{Type: "CONTACT", ID: "abc123"},
+			},
+		},
+	}
+
+	card := convertPeopleAPIToCard(person)
+
+	// Basic fields
+	if contacts.CardUID(card) != "c123456" {
+		t.Errorf("UID: got %q, want %q", contacts.CardUID(card), "c123456")
+	}
+	if card.Value("X-GOOGLE-ETAG") != "etag123" {
+		t.Errorf("ETag: got %q, want %q", card.Value("X-GOOGLE-ETAG"), "etag123")
+	}
+	if contacts.CardFullName(card) != "John Doe" {
+		t.Errorf("FN: got %q, want %q", contacts.CardFullName(card), "John Doe")
+	}
+
+	// N field
+	nFields := card[vcard.FieldName]
+	if len(nFields) == 0 {
+		t.Fatal("N field missing")
+	}
+	nParts := strings.SplitN(nFields[0].Value, ";", 5)
+	if nParts[0] != "Doe" {
+		t.Errorf("N family: got %q, want %q", nParts[0], "Doe")
+	}
+	if nParts[1] != "John" {
+		t.Errorf("N given: got %q, want %q", nParts[1], "John")
+	}
+
+	// Nickname
+	if nickFields := card[vcard.FieldNickname]; len(nickFields) == 0 || nickFields[0].Value != "Johnny" {
+		t.Error("NICKNAME missing or wrong")
+	}
+
+	// Phone
+	if tels := card[vcard.FieldTelephone]; len(tels) != 2 {
+		t.Fatalf("TEL: got %d, want 2", len(tels))
+	} else if tels[0].Value != "555-1234" {
+		t.Errorf("TEL[0]: got %q, want %q", tels[0].Value, "555-1234")
+	}
+
+	// Email
+	if emails := card[vcard.FieldEmail]; len(emails) != 1 {
+		t.Fatalf("EMAIL: got %d, want 1", len(emails))
+	}
+
+	// Address
+	if adrs := card[vcard.FieldAddress]; len(adrs) != 1 {
+		t.Fatalf("ADR: got %d, want 1", len(adrs))
+	} else if !strings.Contains(adrs[0].Value, "Springfield") {
+		t.Errorf("ADR missing city, got %q", adrs[0].Value)
+	}
+
+	// Org + Title
+	org := card.Value(vcard.FieldOrganization)
+	if !strings.Contains(org, "Acme Inc") {
+		t.Errorf("ORG: got %q", org)
+	}
+	if card.Value(vcard.FieldTitle) != "Engineer" {
+		t.Errorf("TITLE: got %q", card.Value(vcard.FieldTitle))
+	}
+
+	// Birthday
+	if card.Value(vcard.FieldBirthday) != "19900615" {
+		t.Errorf("BDAY: got %q, want %q", card.Value(vcard.FieldBirthday), "19900615")
+	}
+
+	// Photo
+	if photos := card[vcard.FieldPhoto]; len(photos) == 0 || photos[0].Value != "https://photo.example.com/john.jpg" {
+		t.Error("PHOTO missing or wrong")
+	}
+
+	// Note
+	if card.Value(vcard.FieldNote) != "Some notes about John" {
+		t.Errorf("NOTE: got %q", card.Value(vcard.FieldNote))
+	}
+
+	// URL
+	if urls := card[vcard.FieldURL]; len(urls) == 0 || urls[0].Value != "https://johndoe.com" {
+		t.Error("URL missing or wrong")
+	}
+
+	// Anniversary (from events)
+	if card.Value(vcard.FieldAnniversary) != "20200101" {
+		t.Errorf("ANNIVERSARY: got %q, want %q", card.Value(vcard.FieldAnniversary), "20200101")
+	}
+
+	// Non-anniversary event → X-GOOGLE-EVENT
+	if events := card["X-GOOGLE-EVENT"]; len(events) == 0 {
+		t.Error("X-GOOGLE-EVENT missing")
+	} else if events[0].Value != "20150310" {
+		t.Errorf("X-GOOGLE-EVENT: got %q, want %q", events[0].Value, "20150310")
+	}
+
+	// Gender
+	if card.Value(vcard.FieldGender) != "M" {
+		t.Errorf("GENDER: got %q", card.Value(vcard.FieldGender))
+	}
+
+	// IMPP (from imClients)
+	imppFields := card[vcard.FieldIMPP]
+	if len(imppFields) < 1 {
+		t.Fatal("IMPP missing")
+	}
+	if imppFields[0].Value != "xmpp:johndoe" {
+		t.Errorf("IMPP[0]: got %q, want %q", imppFields[0].Value, "xmpp:johndoe")
+	}
+
+	// IMPP (from sipAddresses — should be second IMPP)
+	foundSip := false
+	for _, f := range imppFields {
+		if f.Value == "sip:john@sip.example.com" {
+			foundSip = true
+		}
+	}
+	if !foundSip {
+		t.Error("SIP address not found in IMPP fields")
+	}
+
+	// RELATED
+	if rels := card[vcard.FieldRelated]; len(rels) == 0 || rels[0].Value != "Jane Doe" {
+		t.Error("RELATED missing or wrong")
+	}
+
+	// CALURI
+	if cals := card[vcard.FieldCalendarURI]; len(cals) == 0 || cals[0].Value != "https://calendar.example.com/john" {
+		t.Error("CALURI missing or wrong")
+	}
+
+	// LANG
+	if langs := card[vcard.FieldLanguage]; len(langs) == 0 || langs[0].Value != "en-US" {
+		t.Error("LANG missing or wrong")
+	}
+
+	// X-GOOGLE-* extensions
+	if v := card["X-GOOGLE-INTEREST"]; len(v) == 0 || v[0].Value != "Coding" {
+		t.Error("X-GOOGLE-INTEREST missing or wrong")
+	}
+	if v := card["X-GOOGLE-SKILL"]; len(v) == 0 || v[0].Value != "Go" {
+		t.Error("X-GOOGLE-SKILL missing or wrong")
+	}
+	if v := card["X-GOOGLE-OCCUPATION"]; len(v) == 0 || v[0].Value != "Software Developer" {
+		t.Error("X-GOOGLE-OCCUPATION missing or wrong")
+	}
+	if v := card["X-GOOGLE-LOCATION"]; len(v) == 0 || v[0].Value != "Building A" {
+		t.Error("X-GOOGLE-LOCATION missing or wrong")
+	}
+	if v := card["X-GOOGLE-GROUP-MEMBERSHIP"]; len(v) == 0 || v[0].Value != "contactGroups/friends" {
+		t.Error("X-GOOGLE-GROUP-MEMBERSHIP missing or wrong")
+	}
+	if v := card["X-GOOGLE-CUSTOM-SHIRT-SIZE"]; len(v) == 0 || v[0].Value != "L" {
+		t.Error("X-GOOGLE-CUSTOM-SHIRT-SIZE missing or wrong")
+	}
+	if v := card["X-GOOGLE-CLIENT-APP-ID"]; len(v) == 0 || v[0].Value != "12345" {
+		t.Error("X-GOOGLE-CLIENT-APP-ID missing or wrong")
+	}
+	if v := card["X-GOOGLE-EXTERNAL-ID"]; len(v) == 0 || v[0].Value != "EMP001" {
+		t.Error("X-GOOGLE-EXTERNAL-ID missing or wrong")
+	}
+	if v := card["X-GOOGLE-KEYWORD"]; len(v) == 0 || v[0].Value != "VIP" {
+		t.Error("X-GOOGLE-KEYWORD missing or wrong")
+	}
+	if v := card["X-GOOGLE-COVER-PHOTO"]; len(v) == 0 || v[0].Value != "https://cover.example.com/john.jpg" {
+		t.Error("X-GOOGLE-COVER-PHOTO missing or wrong")
+	}
+	if v := card["X-GOOGLE-AGE-RANGE"]; len(v) == 0 || v[0].Value != "TWENTY_ONE_OR_OLDER" {
+		t.Error("X-GOOGLE-AGE-RANGE missing or wrong")
+	}
+	if v := card["X-GOOGLE-SOURCE"]; len(v) == 0 || v[0].Value != "abc123" {
+		t.Error("X-GOOGLE-SOURCE missing or wrong")
+	}
+}
+
+func TestConvertCardToPeopleAPI(t *testing.T) {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+	card.SetValue(vcard.FieldFormattedName, "Jane Smith")
+	card[vcard.FieldName] = []*vcard.Field{
+		{Value: "Smith;Jane;;;"},
+	}
+	card.Add(vcard.FieldTelephone, &vcard.Field{
+		Value:  "555-9999",
+		Params: vcard.Params{vcard.ParamType: []string{"mobile"}},
+	})
+	card.Add(vcard.FieldEmail, &vcard.Field{
+		Value:  "jane@example.com",
+		Params: vcard.Params{vcard.ParamType: []string{"work"}},
+	})
+	card.SetValue(vcard.FieldOrganization, "Corp")
+	card.SetValue(vcard.FieldTitle, "CEO")
+	card.SetValue(vcard.FieldBirthday, "19900615")
+	card.Add(vcard.FieldNote, &vcard.Field{Value: "test note"})
+	card.Add(vcard.FieldURL, &vcard.Field{
+		Value:  "https://jane.dev",
+		Params: vcard.Params{vcard.ParamType: []string{"blog"}},
+	})
+
+	result := convertCardToPeopleAPI(card)
+
+	if result["names"] == nil {
+		t.Fatal("names missing")
+	}
+	if result["phoneNumbers"] == nil {
+		t.Fatal("phoneNumbers missing")
+	}
+	if result["emailAddresses"] == nil {
+		t.Fatal("emailAddresses missing")
+	}
+	if result["organizations"] == nil {
+		t.Fatal("organizations missing")
+	}
+	if result["birthdays"] == nil {
+		t.Fatal("birthdays missing")
+	}
+	if result["biographies"] == nil {
+		t.Fatal("biographies missing")
+	}
+	if result["urls"] == nil {
+		t.Fatal("urls missing")
+	}
+}
+
+func TestConvertPeopleAPIToCard_EmptyPerson(t *testing.T) {
+	person := peopleAPIPerson{ResourceName: "people/empty"}
+	card := convertPeopleAPIToCard(person)
+	if contacts.CardUID(card) != "empty" {
+		t.Errorf("UID: got %q, want %q", contacts.CardUID(card), "empty")
+	}
+	// FN should default to UID when no name present
+	if contacts.CardFullName(card) != "empty" {
+		t.Errorf("FN should default to UID, got %q", contacts.CardFullName(card))
+	}
+}
+
+func TestConvertPeopleAPIToCard_SystemGroups(t *testing.T) {
+	person := peopleAPIPerson{ResourceName: "people/ada"}
+	for _, group := range []string{groupMyContacts, groupStarred, groupBlocked, "contactGroups/3bb2a8b0f0d1"} {
+		m := peopleAPIMembership{}
+		m.ContactGroupMembership = &struct {
+			ContactGroupResourceName string `json:"contactGroupResourceName"`
+		}{group}
+		person.Memberships = append(person.Memberships, m)
+	}
+	card := convertPeopleAPIToCard(person)
+	if !contacts.IsStarred(card) || card.Value(contacts.FieldStarred) != "true" {
+		t.Error("starred group not mapped to X-STARRED")
+	}
+	if !contacts.IsBlocked(card) || card.Value(contacts.FieldBlocked) != "true" {
+		t.Error("blocked group not mapped to X-BLOCKED")
+	}
+	groups := card["X-GOOGLE-GROUP-MEMBERSHIP"]
+	if len(groups) != 1 || groups[0].Value != "contactGroups/3bb2a8b0f0d1" {
+		t.Fatalf("memberships = %v, want only the user group", card.Values("X-GOOGLE-GROUP-MEMBERSHIP"))
+	}
+
+	g := &Provider{groups: map[string]string{"contactGroups/3bb2a8b0f0d1": "Family"}}
+	if err := g.labelGroups([]vcard.Card{card}); err != nil {
+		t.Fatal(err)
+	}
+	if got := groups[0].Params.Get(contacts.ParamGroupLabel); got != "Family" {
+		t.Errorf("group label = %q, want Family", got)
+	}
+}
+
+func TestConvertPeopleAPI_GenderAndPronouns(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/sam",
+		Genders:      []peopleAPIGender{{Value: "non-binary", AddressMeAs: "they/them"}},
+	}
+	card := convertPeopleAPIToCard(person)
+	if got := card.Value(vcard.FieldGender); got != "O;non-binary" {
+		t.Errorf("GENDER = %q, want O;non-binary", got)
+	}
+	if got := card.Value(contacts.FieldPronouns); got != "they/them" {
+		t.Errorf("PRONOUNS = %q, want they/them", got)
+	}
+	genders, _ := convertCardToPeopleAPI(card)["genders"].([]map[string]interface{})
+	if len(genders) != 1 || genders[0]["value"] != "non-binary" || genders[0]["addressMeAs"] != "they/them" {
+		t.Errorf("genders = %v", genders)
+	}
+
+	person = peopleAPIPerson{
+		ResourceName: "people/ada",
+		Genders:      []peopleAPIGender{{Value: "female"}},
+		UserDefined:  []peopleAPIUserDefined{{Key: "Pronouns", Value: "she/her"}},
+	}
+	card = convertPeopleAPIToCard(person)
+	if card.Value(vcard.FieldGender) != "F" || card.Value(contacts.FieldPronouns) != "she/her" {
+		t.Errorf("GENDER = %q, PRONOUNS = %q", card.Value(vcard.FieldGender), card.Value(contacts.FieldPronouns))
+	}
+	if _, ok := card["X-GOOGLE-CUSTOM-PRONOUNS"]; ok {
+		t.Error("pronouns user field also kept as X-GOOGLE-CUSTOM-PRONOUNS")
+	}
+}
+
+func TestConvertPeopleAPIToCard_MaidenName(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/ada",
+		Nicknames:    []peopleAPINickname{{Value: "Ada Byron", Type: "MAIDEN_NAME"}, {Value: "Countess"}},
+	}
+	card := convertPeopleAPIToCard(person)
+	if got := contacts.FormerNames(card); len(got) != 1 || got[0] != "Ada Byron" {
+		t.Errorf("FormerNames = %v, want [Ada Byron]", got)
+	}
+	if nicks := card.Values(vcard.FieldNickname); len(nicks) != 1 || nicks[0] != "Countess" {
+		t.Errorf("NICKNAME = %v, want [Countess]", nicks)
+	}
+}
+
+func TestConvertPeopleAPIRoundTrip(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/rt123",
+		Names: []peopleAPIName{
+			{DisplayName: "Round Trip", GivenName: "Round", FamilyName: "Trip"},
+		},
+		PhoneNumbers: []peopleAPIPhoneNumber{
+			{Value: "555-0000", Type: "mobile"},
+		},
+		EmailAddresses: []peopleAPIEmailAddress{
+			{Value: "rt@example.com", Type: "work"},
+		},
+		Organizations: []peopleAPIOrganization{
+			{Name: "RT Corp", Title: "Dev", Department: "Eng"},
+		},
+		Birthdays: []peopleAPIBirthday{
+			{Date: struct {
+				Year  int `json:"year"`
+				Month int `json:"month"`
+				Day   int `json:"day"`
+			}{Year: 1985, Month: 12, Day: 25}},
+		},
+		Biographies: []peopleAPIBiography{
+			{Value: "round trip test"},
+		},
+	}
+
+	card := convertPeopleAPIToCard(person)
+	result := convertCardToPeopleAPI(card)
+
+	// Verify key fields survive round trip
+	if result["names"] == nil {
+		t.Fatal("names lost in round trip")
+	}
+	if result["phoneNumbers"] == nil {
+		t.Fatal("phoneNumbers lost in round trip")
+	}
+	if result["emailAddresses"] == nil {
+		t.Fatal("emailAddresses lost in round trip")
+	}
+	if result["organizations"] == nil {
+		t.Fatal("organizations lost in round trip")
+	}
+	if result["birthdays"] == nil {
+		t.Fatal("birthdays lost in round trip")
+	}
+	if result["biographies"] == nil {
+		t.Fatal("biographies lost in round trip")
+	}
+}
+
+func TestConvertPeopleAPI_Primary(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/p1",
+		PhoneNumbers: []peopleAPIPhoneNumber{
+			{Value: "555-0000", Type: "mobile"},
+			{Value: "555-1111", Type: "work", Metadata: &peopleAPIFieldMetadata{Primary: true}},
+		},
+		EmailAddresses: []peopleAPIEmailAddress{
+			{Value: "home@example.com", Type: "home"},
+			{Value: "work@example.com", Type: "work", Metadata: &peopleAPIFieldMetadata{Primary: true}},
+		},
+	}
+	card := convertPeopleAPIToCard(person)
+	if got := contacts.PrimaryPhone(card); got != "555-1111" {
+		t.Errorf("PrimaryPhone = %q, want the primary number", got)
+	}
+	if got := contacts.PrimaryEmail(card); got != "work@example.com" {
+		t.Errorf("PrimaryEmail = %q, want the primary address", got)
+	}
+
+	// TYPE=pref, as vCard 3.0 writes it, is sent as metadata, not as a type.
+	card[vcard.FieldEmail][0].Params = vcard.Params{vcard.ParamType: {"home", "pref"}}
+	delete(card[vcard.FieldEmail][1].Params, vcard.ParamPreferred)
+	result := convertCardToPeopleAPI(card)
+	phones := result["phoneNumbers"].([]map[string]interface{})
+	if phones[0]["metadata"] != nil || phones[1]["metadata"] == nil {
+		t.Errorf("phoneNumbers = %v, want the second primary", phones)
+	}
+	emails := result["emailAddresses"].([]map[string]interface{})
+	if emails[0]["metadata"] == nil || emails[0]["type"] != "home" || emails[1]["metadata"] != nil {
+		t.Errorf("emailAddresses = %v, want the first primary", emails)
+	}
+}
+
+func TestConvertPeopleAPI_PrimaryRoundTrip(t *testing.T) {
+	var person peopleAPIPerson
+	err := json.Unmarshal([]byte(`{
+		"resourceName": "people/p2",
+		"addresses": [{"city": "Paris"}, {"city": "Lyon", "metadata": {"primary": true}}],
+		"urls": [{"value": "https://a.example", "metadata": {"primary": true}}, {"value": "https://b.example"}],
+		"relations": [{"person": "Ann"}, {"person": "Bo", "type": "spouse", "metadata": {"primary": true}}],
+		"organizations": [{"name": "Old Co"}, {"name": "New Co", "title": "CTO", "metadata": {"primary": true}}]
+	}`), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := convertPeopleAPIToCard(person)
+	if card.Value(vcard.FieldOrganization) != "New Co" || card.Value(vcard.FieldTitle) != "CTO" {
+		t.Errorf("ORG = %q, TITLE = %q, want the primary organization", card.Value(vcard.FieldOrganization), card.Value(vcard.FieldTitle))
+	}
+
+	result := convertCardToPeopleAPI(card)
+	for key, want := range map[string]int{"addresses": 1, "urls": 0, "relations": 1} {
+		for i, m := range result[key].([]map[string]interface{}) {
+			if primary := m["metadata"] != nil; primary != (i == want) {
+				t.Errorf("%s[%d] primary = %v", key, i, primary)
+			}
+		}
+	}
+}
+
+func TestConvertPeopleAPI_UpdateTimes(t *testing.T) {
+	var person peopleAPIPerson
+	err := json.Unmarshal([]byte(`{
+		"resourceName": "people/p3",
+		"names": [{"displayName": "Ada", "givenName": "Ada", "metadata": {"source": {"type": "CONTACT", "updateTime": "2024-03-01T10:00:00.123456Z"}}}],
+		"emailAddresses": [
+			{"value": "ada@example.com", "metadata": {"source": {"type": "CONTACT", "updateTime": "2024-03-01T10:00:00Z"}}},
+			{"value": "ada@work.example", "metadata": {"source": {"type": "PROFILE", "updateTime": "2024-02-01T09:30:00Z"}}}
+		],
+		"biographies": [{"value": "Notes"}]
+	}`), &person)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := convertPeopleAPIToCard(person)
+	for _, tt := range []struct {
+		field *vcard.Field
+		want  string
+	}{
+		{card.Get(vcard.FieldFormattedName), "20240301T100000Z"},
+		{card.Get(vcard.FieldName), "20240301T100000Z"},
+		{card[vcard.FieldEmail][0], "20240301T100000Z"},
+		{card[vcard.FieldEmail][1], "20240201T093000Z"},
+		{card.Get(vcard.FieldNote), ""},
+	} {
+		if got := tt.field.Params.Get(contacts.ParamUpdated); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.field.Value, contacts.ParamUpdated, got, tt.want)
+		}
+	}
+	if _, ok := convertCardToPeopleAPI(card)["emailAddresses"].([]map[string]interface{})[0]["metadata"]; ok {
+		t.Error("update time sent back to the People API")
+	}
+}
+
+func TestUpdatePersonFields(t *testing.T) {
+	card := convertPeopleAPIToCard(peopleAPIPerson{
+		ResourceName:   "people/p4",
+		Names:          []peopleAPIName{{DisplayName: "Ada"}},
+		PhoneNumbers:   []peopleAPIPhoneNumber{{Value: "555-0000"}},
+		EmailAddresses: []peopleAPIEmailAddress{{Value: "ada@example.com"}},
+	})
+	if got := card.Value(fieldPersonFields); got != "names,phoneNumbers,emailAddresses" {
+		t.Errorf("%s = %q", fieldPersonFields, got)
+	}
+
+	// A phone removed locally is cleared, a new note is sent, and fields
+	// the contact never had are left alone.
+	delete(card, vcard.FieldTelephone)
+	card.SetValue(vcard.FieldNote, "met at the conference")
+	if got := updatePersonFields(card, convertCardToPeopleAPI(card)); got != "names,phoneNumbers,emailAddresses,biographies" {
+		t.Errorf("updatePersonFields = %q", got)
+	}
+
+	// A card never fetched from Google only updates what it sets.
+	other := contacts.NewCard("Bo")
+	if got := updatePersonFields(other, convertCardToPeopleAPI(other)); got != "names" {
+		t.Errorf("updatePersonFields = %q, want names", got)
+	}
+}
+
+func TestConvertPeopleAPI_TimeZoneAndGeo(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/tz1",
+		Names:        []peopleAPIName{{DisplayName: "Tz Person"}},
+		ClientData: []peopleAPIClientData{
+			{Key: clientDataTZ, Value: "Europe/Berlin"},
+			{Key: clientDataGeo, Value: "geo:52.52,13.405"},
+			{Key: "app id", Value: "42"},
+		},
+	}
+	card := convertPeopleAPIToCard(person)
+	if got := card.Value(vcard.FieldTimezone); got != "Europe/Berlin" {
+		t.Errorf("TZ = %q, want Europe/Berlin", got)
+	}
+	if got := card.Value(vcard.FieldGeolocation); got != "geo:52.52,13.405" {
+		t.Errorf("GEO = %q, want geo:52.52,13.405", got)
+	}
+
+	data := convertCardToPeopleAPI(card)["clientData"].([]map[string]interface{})
+	got := map[string]interface{}{}
+	for _, cd := range data {
+		got[cd["key"].(string)] = cd["value"]
+	}
+	want := map[string]interface{}{clientDataTZ: "Europe/Berlin", clientDataGeo: "geo:52.52,13.405", "app id": "42"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("clientData[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestConvertPeopleAPI_OrgKindRoundTrip(t *testing.T) {
+	card := contacts.NewOrgCard("Acme Inc")
+	person := contacts.NewCard("Wile E. Coyote")
+	person.Add(vcard.FieldRelated, &vcard.Field{
+		Value:  "urn:uuid:" + contacts.CardUID(card),
+		Params: vcard.Params{vcard.ParamType: []string{contacts.RelatedOrganization}},
+	})
+
+	data, err := json.Marshal(convertCardToPeopleAPI(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p peopleAPIPerson
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	if got := convertPeopleAPIToCard(p).Kind(); got != vcard.KindOrganization {
+		t.Errorf("KIND = %q, want org", got)
+	}
+
+	data, err = json.Marshal(convertCardToPeopleAPI(person))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = peopleAPIPerson{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	related := convertPeopleAPIToCard(p)[vcard.FieldRelated]
+	if len(related) != 1 || !related[0].Params.HasType(contacts.RelatedOrganization) {
+		t.Errorf("RELATED = %+v, want an x-organization link", related)
+	}
+}
+
+func TestConvertPeopleAPIToCard_LocationTimeZone(t *testing.T) {
+	person := peopleAPIPerson{
+		ResourceName: "people/loc1",
+		Locations: []peopleAPILocation{
+			{Value: "America/New_York", Type: "desk"},
+			{Value: "40.7128,-74.006"},
+		},
+	}
+	card := convertPeopleAPIToCard(person)
+	if got := card.Value(vcard.FieldTimezone); got != "America/New_York" {
+		t.Errorf("TZ = %q, want America/New_York", got)
+	}
+	if got := card.Value(vcard.FieldGeolocation); got != "geo:40.7128,-74.006" {
+		t.Errorf("GEO = %q, want geo:40.7128,-74.006", got)
+	}
+}
+
+func TestGeneratePKCE(t *testing.T) {
+	verifier, challenge, err := generatePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifier == "" {
+		t.Error("verifier is empty")
+	}
+	if challenge == "" {
+		t.Error("challenge is empty")
+	}
+	if verifier == challenge {
+		t.Error("verifier and challenge should differ")
+	}
+}
+
+func TestDefaultCredentials(t *testing.T) {
+	if DefaultCredentials() != nil {
+		t.Fatal("expected no built-in client by default")
+	}
+	defaultClientID, defaultClientSecret = "id.apps.googleusercontent.com", "secret"
+	defer func() { defaultClientID, defaultClientSecret = "", "" }()
+	creds := DefaultCredentials()
+	if creds == nil || creds.ClientID != "id.apps.googleusercontent.com" || creds.ClientSecret != "secret" {
+		t.Errorf("got %+v", creds)
+	}
+}
+
+func TestSuccessTemplate(t *testing.T) {
+	g := &Provider{}
+	g.SetSuccessPage("", true)
+	tmpl, err := g.successTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, successPageData{Logo: "<svg></svg>", AutoClose: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<svg></svg>") || !strings.Contains(buf.String(), "window.close()") {
+		t.Errorf("default page missing logo or close script:\n%s", buf.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(path, []byte(`<p>done{{if .AutoClose}} closing{{end}}</p>`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	g.SetSuccessPage(path, false)
+	tmpl, err = g.successTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := tmpl.Execute(&buf, successPageData{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "<p>done</p>" {
+		t.Errorf("custom page: got %q", buf.String())
+	}
+
+	g.SetSuccessPage(filepath.Join(t.TempDir(), "missing.html"), false)
+	if _, err := g.successTemplate(); err == nil {
+		t.Error("expected error for missing template")
+	}
+}
+
+// newTestProvider returns a signed-in provider whose People API requests
+// go to handler.
+func newTestProvider(t *testing.T, handler http.HandlerFunc) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	oldBase := peopleAPIBase
+	peopleAPIBase = srv.URL
+	t.Cleanup(func() { peopleAPIBase = oldBase })
+
+	g, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := &Credentials{ClientID: "id", ClientSecret: "secret", RefreshToken: "refresh", AccessToken: "access", Expiry: time.Now().Add(time.Hour)}
+	if err := g.SaveCredentials(creds); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestProvider_WriteContactPhoto(t *testing.T) {
+	var uploads [][]byte
+	g := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":updateContactPhoto"):
+			var req struct {
+				PhotoBytes []byte `json:"photoBytes"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			uploads = append(uploads, req.PhotoBytes)
+			fmt.Fprint(w, `{"person":{"resourceName":"people/c1","etag":"etag-photo","photos":[{"url":"https://lh3.googleusercontent.com/c1"}]}}`)
+		case strings.HasSuffix(r.URL.Path, ":updateContact"):
+			fmt.Fprint(w, `{"resourceName":"people/c1","etag":"etag-update"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	g.SetPhotoOptions(contacts.PhotoOptions{Format: "png", MaxSize: 8})
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 32, 32)))
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(contacts.FieldProviderID, "c1")
+	card.SetValue(vcard.FieldPhoto, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if err := g.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads) != 1 {
+		t.Fatalf("got %d photo uploads, want 1", len(uploads))
+	}
+	img, format, err := image.Decode(bytes.NewReader(uploads[0]))
+	if err != nil || format != "png" || img.Bounds().Dx() != 8 {
+		t.Errorf("uploaded a %s photo %v (%v), want an 8px PNG", format, img.Bounds(), err)
+	}
+	if got := card.Value(vcard.FieldPhoto); got != "https://lh3.googleusercontent.com/c1" {
+		t.Errorf("PHOTO = %.40q, want Google's URL", got)
+	}
+	if got := card.Value("X-GOOGLE-ETAG"); got != "etag-photo" {
+		t.Errorf("etag = %q, want etag-photo", got)
+	}
+
+	if err := g.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads) != 1 {
+		t.Error("the photo was uploaded again")
+	}
+}
+
+func TestProvider_WriteContactRefreshesFields(t *testing.T) {
+	var masks, etags []string
+	g := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ":updateContact") {
+			http.NotFound(w, r)
+			return
+		}
+		var person map[string]any
+		json.NewDecoder(r.Body).Decode(&person)
+		masks = append(masks, r.URL.Query().Get("updatePersonFields"))
+		etag, _ := person["etag"].(string)
+		etags = append(etags, etag)
+		// Google answers with the contact as updated.
+		person["resourceName"] = "people/c1"
+		person["etag"] = fmt.Sprintf("etag-%d", len(etags)+1)
+		json.NewEncoder(w).Encode(person)
+	})
+
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(contacts.FieldProviderID, "c1")
+	card.SetValue("X-GOOGLE-ETAG", "etag-1")
+	card.SetValue(fieldPersonFields, "names")
+
+	// Add a phone number and push it, then remove it before the next sync.
+	card.SetValue(vcard.FieldTelephone, "+1 555 0100")
+	if err := g.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if got := card.Value(fieldPersonFields); got != "names,phoneNumbers" {
+		t.Errorf("%s = %q after adding a phone number", fieldPersonFields, got)
+	}
+	delete(card, vcard.FieldTelephone)
+	if err := g.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if len(masks) != 2 || !strings.Contains(masks[1], "phoneNumbers") {
+		t.Errorf("update masks = %q, want the removed phoneNumbers cleared", masks)
+	}
+	if len(etags) != 2 || etags[1] != "etag-2" {
+		t.Errorf("sent etags = %q, want the one from the first update", etags)
+	}
+	if got := card.Value(fieldPersonFields); got != "names" {
+		t.Errorf("%s = %q after removing the phone number", fieldPersonFields, got)
+	}
+}
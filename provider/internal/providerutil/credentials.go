@@ -0,0 +1,77 @@
+package providerutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// CredentialsFile is a provider's credentials file, holding a T as JSON
+// readable only by the user. The file is read once per process and
+// cached.
+type CredentialsFile[T any] struct {
+	path   string
+	cached *T
+}
+
+// NewCredentialsFile returns the credentials file at path.
+func NewCredentialsFile[T any](path string) CredentialsFile[T] {
+	return CredentialsFile[T]{path: path}
+}
+
+// Path is where the file is stored.
+func (f *CredentialsFile[T]) Path() string {
+	return f.path
+}
+
+// Save writes creds to the file and caches a copy.
+func (f *CredentialsFile[T]) Save(creds *T) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	if err := os.WriteFile(f.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	cached := *creds
+	f.cached = &cached
+	return nil
+}
+
+// Load returns a copy of the stored credentials. The file is read on the
+// first call only; later calls return what was last loaded or saved. A
+// missing file is reported as contacts.ErrNotInitialized.
+func (f *CredentialsFile[T]) Load() (*T, error) {
+	if f.cached != nil {
+		creds := *f.cached
+		return &creds, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: credentials file not found at %s: please run init first", contacts.ErrNotInitialized, f.path)
+		}
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var creds T
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	cached := creds
+	f.cached = &cached
+	return &creds, nil
+}
+
+// Cached returns the credentials last loaded or saved, or nil before
+// either. Changes to them are not written to the file.
+func (f *CredentialsFile[T]) Cached() *T {
+	return f.cached
+}
+
+// SetCached replaces the cached credentials without writing the file, for
+// state kept in memory until it is complete enough to save.
+func (f *CredentialsFile[T]) SetCached(creds *T) {
+	f.cached = creds
+}
@@ -0,0 +1,45 @@
+package providerutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+)
+
+type testCredentials struct {
+	Token string `json:"token"`
+}
+
+func TestCredentialsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test_creds.json")
+	f := NewCredentialsFile[testCredentials](path)
+	if _, err := f.Load(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("Load without a file = %v, want ErrNotInitialized", err)
+	}
+	if f.Cached() != nil {
+		t.Error("Cached before a Load or Save is not nil")
+	}
+	if err := f.Save(&testCredentials{Token: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("credentials file mode = %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	// A fresh file reads what was saved; later loads come from the cache.
+	g := NewCredentialsFile[testCredentials](path)
+	creds, err := g.Load()
+	if err != nil || creds.Token != "secret" {
+		t.Fatalf("Load = %+v, %v", creds, err)
+	}
+	creds.Token = "changed"
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if creds, err := g.Load(); err != nil || creds.Token != "secret" {
+		t.Errorf("cached Load = %+v, %v; want the saved token, unchanged by the caller", creds, err)
+	}
+}
@@ -0,0 +1,58 @@
+// Package providerutil holds what the contact providers have in common:
+// how they store credentials, derive local UIDs from their IDs, map HTTP
+// statuses to the contacts package's errors and read birthdays.
+package providerutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// LocalUID derives a contact's UID from a provider's ID for it. IDs are
+// often base64, which tells letters' case apart and may contain '/', so
+// they are hashed rather than used as file names.
+func LocalUID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:16])
+}
+
+// StatusError maps an HTTP status from a provider's API to one of the
+// sentinel errors, or nil if the status has no specific meaning.
+func StatusError(status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return contacts.ErrNotFound
+	case status == http.StatusUnauthorized:
+		return contacts.ErrAuthExpired
+	case status == http.StatusConflict, status == http.StatusPreconditionFailed:
+		return contacts.ErrConflict
+	case status == http.StatusTooManyRequests, status >= 500:
+		return contacts.ErrProviderUnavailable
+	}
+	return nil
+}
+
+// NoYear is the year Outlook stores on birthdays entered without one.
+const NoYear = 1604
+
+// ParseBirthday parses a BDAY of YYYYMMDD, YYYY-MM-DD or --MMDD. A
+// birthday without a year gets NoYear.
+func ParseBirthday(s string) time.Time {
+	if strings.HasPrefix(s, "--") {
+		t, err := time.Parse("0102", strings.ReplaceAll(s[2:], "-", ""))
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Date(NoYear, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	t, err := time.Parse("20060102", strings.ReplaceAll(s, "-", ""))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
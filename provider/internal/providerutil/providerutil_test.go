@@ -0,0 +1,64 @@
+package providerutil
+
+import (
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+)
+
+func TestLocalUID(t *testing.T) {
+	a, b := LocalUID("aBc/+=="), LocalUID("AbC/+==")
+	if a == b {
+		t.Error("IDs differing in case got the same UID")
+	}
+	if _, err := hex.DecodeString(a); err != nil || len(a) != 32 {
+		t.Errorf("LocalUID = %q, want 32 hex digits", a)
+	}
+	if LocalUID("aBc/+==") != a {
+		t.Error("LocalUID is not stable")
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, contacts.ErrNotFound},
+		{http.StatusUnauthorized, contacts.ErrAuthExpired},
+		{http.StatusConflict, contacts.ErrConflict},
+		{http.StatusPreconditionFailed, contacts.ErrConflict},
+		{http.StatusTooManyRequests, contacts.ErrProviderUnavailable},
+		{http.StatusServiceUnavailable, contacts.ErrProviderUnavailable},
+		{http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			if got := StatusError(tt.status); got != tt.want {
+				t.Errorf("StatusError(%d) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBirthday(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"19800704", time.Date(1980, 7, 4, 0, 0, 0, 0, time.UTC)},
+		{"1980-07-04", time.Date(1980, 7, 4, 0, 0, 0, 0, time.UTC)},
+		{"--0704", time.Date(NoYear, 7, 4, 0, 0, 0, 0, time.UTC)},
+		{"--07-04", time.Date(NoYear, 7, 4, 0, 0, 0, 0, time.UTC)},
+		{"July 4th", time.Time{}},
+		{"", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := ParseBirthday(tt.in); !got.Equal(tt.want) {
+			t.Errorf("ParseBirthday(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
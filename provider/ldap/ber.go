@@ -0,0 +1,194 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The subset of BER (X.690) that LDAP uses: definite lengths and tag
+// numbers below 31, so every tag fits in one byte.
+
+// Tag classes and the constructed bit, combined with a tag number into the
+// identifier byte.
+const (
+	classUniversal   = 0x00
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+)
+
+// Universal tags.
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = constructed | 0x10
+	tagSet         = constructed | 0x11
+)
+
+// maxMessageSize bounds a message read from the server. Entries with large
+// photos are a few hundred kilobytes; anything this big is an error.
+const maxMessageSize = 32 << 20
+
+// element is a decoded BER element.
+type element struct {
+	tag      byte
+	data     []byte
+	children []*element
+}
+
+// child returns the i-th child, or an empty element if there is none, so
+// optional trailing fields read as zero values.
+func (e *element) child(i int) *element {
+	if i < len(e.children) {
+		return e.children[i]
+	}
+	return &element{}
+}
+
+func (e *element) str() string {
+	return string(e.data)
+}
+
+// int decodes an INTEGER or ENUMERATED.
+func (e *element) int() int {
+	n := 0
+	for i, b := range e.data {
+		if i == 0 && b&0x80 != 0 {
+			n = -1
+		}
+		n = n<<8 | int(b)
+	}
+	return n
+}
+
+// encode returns the element with the given tag and contents. The
+// contents of a constructed element are its children, already encoded.
+func encode(tag byte, contents ...[]byte) []byte {
+	n := 0
+	for _, c := range contents {
+		n += len(c)
+	}
+	out := append([]byte{tag}, encodeLength(n)...)
+	for _, c := range contents {
+		out = append(out, c...)
+	}
+	return out
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for ; n > 0; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+func encodeString(tag byte, s string) []byte {
+	return encode(tag, []byte(s))
+}
+
+func encodeInt(tag byte, n int) []byte {
+	b := []byte{byte(n)}
+	for n >>= 8; n != 0 && n != -1; n >>= 8 {
+		b = append([]byte{byte(n)}, b...)
+	}
+	// Keep the sign bit right: 128 needs a leading zero byte.
+	if n == 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	} else if n == -1 && b[0]&0x80 == 0 {
+		b = append([]byte{0xff}, b...)
+	}
+	return encode(tag, b)
+}
+
+func encodeBool(v bool) []byte {
+	if v {
+		return encode(tagBoolean, []byte{0xff})
+	}
+	return encode(tagBoolean, []byte{0})
+}
+
+// readElement reads one element from r.
+func readElement(r *bufio.Reader) (*element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	n := int(first)
+	if first&0x80 != 0 {
+		size := int(first &^ 0x80)
+		if size == 0 || size > 4 {
+			return nil, fmt.Errorf("invalid BER length")
+		}
+		n = 0
+		for range size {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			n = n<<8 | int(b)
+		}
+	}
+	if n > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return decode(tag, data)
+}
+
+// decode builds the element with the given tag and contents, decoding the
+// children of a constructed element.
+func decode(tag byte, data []byte) (*element, error) {
+	e := &element{tag: tag, data: data}
+	if tag&constructed == 0 {
+		return e, nil
+	}
+	for rest := data; len(rest) > 0; {
+		if len(rest) < 2 {
+			return nil, fmt.Errorf("truncated BER element")
+		}
+		childTag, n, header := rest[0], int(rest[1]), 2
+		if rest[1]&0x80 != 0 {
+			size := int(rest[1] &^ 0x80)
+			if size == 0 || size > 4 || len(rest) < 2+size {
+				return nil, fmt.Errorf("invalid BER length")
+			}
+			n = 0
+			for _, b := range rest[2 : 2+size] {
+				n = n<<8 | int(b)
+			}
+			header += size
+		}
+		if n < 0 || len(rest) < header+n {
+			return nil, fmt.Errorf("truncated BER element")
+		}
+		child, err := decode(childTag, rest[header:header+n])
+		if err != nil {
+			return nil, err
+		}
+		e.children = append(e.children, child)
+		rest = rest[header+n:]
+	}
+	return e, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
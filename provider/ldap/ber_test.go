@@ -0,0 +1,56 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestEncodeInt(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "020100"},
+		{3, "020103"},
+		{127, "02017f"},
+		{128, "02020080"},
+		{256, "02020100"},
+		{-1, "0201ff"},
+		{-129, "0202ff7f"},
+	}
+	for _, tt := range tests {
+		got := encodeInt(tagInteger, tt.n)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("encodeInt(%d) = %x, want %s", tt.n, got, tt.want)
+		}
+		e, err := readElement(bufio.NewReader(bytes.NewReader(got)))
+		if err != nil || e.int() != tt.n {
+			t.Errorf("decoding %x = %v, %v; want %d", got, e, err, tt.n)
+		}
+	}
+}
+
+func TestReadElement(t *testing.T) {
+	long := strings.Repeat("x", 200)
+	msg := encode(tagSequence, encodeInt(tagInteger, 7), encodeString(tagOctetString, long), encode(tagSet))
+	if !bytes.HasPrefix(msg, []byte{tagSequence, 0x81}) {
+		t.Fatalf("long length not encoded in long form: %x", msg[:4])
+	}
+	e, err := readElement(bufio.NewReader(bytes.NewReader(msg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.children) != 3 || e.child(0).int() != 7 || e.child(1).str() != long || e.child(2).tag != tagSet {
+		t.Errorf("decoded %+v", e)
+	}
+	if e.child(5).str() != "" {
+		t.Error("missing child should read as empty")
+	}
+
+	if _, err := readElement(bufio.NewReader(bytes.NewReader(msg[:20]))); err == nil {
+		t.Error("expected an error for a truncated message")
+	}
+}
@@ -0,0 +1,306 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// Protocol operations (RFC 4511 section 4.2 onwards).
+const (
+	opBindRequest      = classApplication | constructed | 0
+	opBindResponse     = classApplication | constructed | 1
+	opUnbindRequest    = classApplication | 2
+	opSearchRequest    = classApplication | constructed | 3
+	opSearchEntry      = classApplication | constructed | 4
+	opSearchDone       = classApplication | constructed | 5
+	opSearchReference  = classApplication | constructed | 19
+	opExtendedRequest  = classApplication | constructed | 23
+	opExtendedResponse = classApplication | constructed | 24
+
+	tagControls = classContext | constructed | 0
+)
+
+const (
+	oidStartTLS = "1.3.6.1.4.1.1466.20037"
+	// oidPaging is the simple paged results control (RFC 2696), which
+	// Active Directory needs to return more than 1000 entries.
+	oidPaging = "1.2.840.113556.1.4.319"
+)
+
+// Search scopes and alias dereferencing.
+const (
+	scopeSubtree = 2
+	derefNever   = 0
+)
+
+// pageSize is how many entries a search asks for at a time.
+var pageSize = 500
+
+// timeout bounds connecting and each operation.
+var timeout = 30 * time.Second
+
+// conn is a connection to an LDAP server, which runs one operation at a
+// time.
+type conn struct {
+	nc    net.Conn
+	r     *bufio.Reader
+	msgID int
+}
+
+// entry is a search result. Attribute names are lower case, as LDAP
+// compares them without case.
+type entry struct {
+	DN    string
+	Attrs map[string][][]byte
+}
+
+// dial connects to rawURL, an ldap:// or ldaps:// URL, upgrading an
+// ldap:// connection with StartTLS if startTLS is set.
+func dial(rawURL string, startTLS bool, config *tls.Config) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q", rawURL)
+	}
+	host := u.Host
+	dialer := &net.Dialer{Timeout: timeout}
+	var nc net.Conn
+	switch strings.ToLower(u.Scheme) {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		nc, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		nc, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig(config, u.Hostname()))
+	default:
+		return nil, fmt.Errorf("invalid server URL %q: expected ldap:// or ldaps://", rawURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", contacts.ErrProviderUnavailable, err)
+	}
+	c := &conn{nc: nc, r: bufio.NewReader(nc)}
+	if startTLS && strings.EqualFold(u.Scheme, "ldap") {
+		if err := c.startTLS(tlsConfig(config, u.Hostname())); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func tlsConfig(config *tls.Config, host string) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config = config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	return config
+}
+
+// send writes a request and returns its message ID.
+func (c *conn) send(op []byte, controls ...[]byte) (int, error) {
+	c.msgID++
+	parts := [][]byte{encodeInt(tagInteger, c.msgID), op}
+	if len(controls) > 0 {
+		parts = append(parts, encode(tagControls, controls...))
+	}
+	c.nc.SetDeadline(time.Now().Add(timeout))
+	if _, err := c.nc.Write(encode(tagSequence, parts...)); err != nil {
+		return 0, fmt.Errorf("%w: %w", contacts.ErrProviderUnavailable, err)
+	}
+	return c.msgID, nil
+}
+
+// receive reads the next response to message id. It returns the protocol
+// operation and the message's controls, if any.
+func (c *conn) receive(id int) (op, controls *element, err error) {
+	for {
+		c.nc.SetDeadline(time.Now().Add(timeout))
+		msg, err := readElement(c.r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: failed to read response: %w", contacts.ErrProviderUnavailable, err)
+		}
+		if msg.tag != tagSequence || len(msg.children) < 2 {
+			return nil, nil, fmt.Errorf("malformed LDAP message")
+		}
+		switch got := msg.child(0).int(); {
+		case got == 0:
+			// An unsolicited notification, which in practice means the
+			// server is about to close the connection.
+			return nil, nil, errors.Join(contacts.ErrProviderUnavailable, resultOf("connection", msg.child(1)))
+		case got != id:
+			continue
+		}
+		return msg.child(1), msg.child(2), nil
+	}
+}
+
+// resultOf returns the error an LDAPResult reports, or nil on success.
+func resultOf(op string, result *element) error {
+	code := result.child(0).int()
+	if code == resultSuccess {
+		return nil
+	}
+	return errors.Join(resultCodeError(code), &resultError{Op: op, Code: code, Message: result.child(2).str()})
+}
+
+// resultError is an LDAP operation that failed.
+type resultError struct {
+	Op      string
+	Code    int
+	Message string
+}
+
+func (e *resultError) Error() string {
+	return fmt.Sprintf("LDAP %s failed (result %d): %s", e.Op, e.Code, strings.TrimSpace(e.Message))
+}
+
+func (c *conn) startTLS(config *tls.Config) error {
+	id, err := c.send(encode(opExtendedRequest, encodeString(classContext|0, oidStartTLS)))
+	if err != nil {
+		return err
+	}
+	resp, _, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if resp.tag != opExtendedResponse {
+		return fmt.Errorf("unexpected response to StartTLS")
+	}
+	if err := resultOf("StartTLS", resp); err != nil {
+		return err
+	}
+	tc := tls.Client(c.nc, config)
+	tc.SetDeadline(time.Now().Add(timeout))
+	if err := tc.Handshake(); err != nil {
+		return fmt.Errorf("failed to start TLS: %w", err)
+	}
+	c.nc, c.r = tc, bufio.NewReader(tc)
+	return nil
+}
+
+// bind authenticates with a simple bind; an empty dn and password bind
+// anonymously.
+func (c *conn) bind(dn, password string) error {
+	id, err := c.send(encode(opBindRequest,
+		encodeInt(tagInteger, 3),
+		encodeString(tagOctetString, dn),
+		encodeString(classContext|0, password)))
+	if err != nil {
+		return err
+	}
+	resp, _, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if resp.tag != opBindResponse {
+		return fmt.Errorf("unexpected response to bind")
+	}
+	return resultOf("bind", resp)
+}
+
+// search returns the entries below base that match filter, with the given
+// attributes, fetching them a page at a time.
+func (c *conn) search(base, filter string, attrs []string) ([]entry, error) {
+	compiled, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	attrList := make([][]byte, len(attrs))
+	for i, a := range attrs {
+		attrList[i] = encodeString(tagOctetString, a)
+	}
+	req := encode(opSearchRequest,
+		encodeString(tagOctetString, base),
+		encodeInt(tagEnumerated, scopeSubtree),
+		encodeInt(tagEnumerated, derefNever),
+		encodeInt(tagInteger, 0),
+		encodeInt(tagInteger, 0),
+		encodeBool(false),
+		compiled,
+		encode(tagSequence, attrList...))
+
+	var entries []entry
+	var cookie string
+	for {
+		paging := encode(tagSequence, encodeInt(tagInteger, pageSize), encodeString(tagOctetString, cookie))
+		id, err := c.send(req, encode(tagSequence, encodeString(tagOctetString, oidPaging), encodeString(tagOctetString, string(paging))))
+		if err != nil {
+			return nil, err
+		}
+		cookie = ""
+		for done := false; !done; {
+			resp, controls, err := c.receive(id)
+			if err != nil {
+				return nil, err
+			}
+			switch resp.tag {
+			case opSearchEntry:
+				entries = append(entries, parseEntry(resp))
+			case opSearchReference:
+				// Referrals to other servers are not followed.
+			case opSearchDone:
+				if err := resultOf("search", resp); err != nil {
+					return nil, err
+				}
+				cookie = pagingCookie(controls)
+				done = true
+			default:
+				return nil, fmt.Errorf("unexpected response to search")
+			}
+		}
+		if cookie == "" {
+			return entries, nil
+		}
+	}
+}
+
+func parseEntry(e *element) entry {
+	out := entry{DN: e.child(0).str(), Attrs: map[string][][]byte{}}
+	for _, attr := range e.child(1).children {
+		name := strings.ToLower(attr.child(0).str())
+		for _, v := range attr.child(1).children {
+			out.Attrs[name] = append(out.Attrs[name], v.data)
+		}
+	}
+	return out
+}
+
+// pagingCookie returns the cookie of the paged results control in
+// controls, which is empty once the last page has been sent.
+func pagingCookie(controls *element) string {
+	for _, ctrl := range controls.children {
+		if ctrl.child(0).str() != oidPaging {
+			continue
+		}
+		value := ctrl.children[len(ctrl.children)-1]
+		if value.tag != tagOctetString {
+			return ""
+		}
+		v, err := decode(tagSequence, value.data)
+		if err != nil || len(v.children) != 1 || v.children[0].tag != tagSequence {
+			return ""
+		}
+		return v.children[0].child(1).str()
+	}
+	return ""
+}
+
+// close unbinds and closes the connection.
+func (c *conn) close() error {
+	c.send(encode(opUnbindRequest))
+	return c.nc.Close()
+}
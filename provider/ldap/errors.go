@@ -0,0 +1,27 @@
+package ldap
+
+import "github.com/arjungandhi/contacts"
+
+// LDAP result codes (RFC 4511 appendix A) with a meaning of their own.
+const (
+	resultSuccess            = 0
+	resultNoSuchObject       = 32
+	resultInvalidCredentials = 49
+	resultInsufficientAccess = 50
+	resultBusy               = 51
+	resultUnavailable        = 52
+)
+
+// resultCodeError maps an LDAP result code to one of the sentinel errors,
+// or nil if the code has no specific meaning.
+func resultCodeError(code int) error {
+	switch code {
+	case resultNoSuchObject:
+		return contacts.ErrNotFound
+	case resultInvalidCredentials, resultInsufficientAccess:
+		return contacts.ErrAuthExpired
+	case resultBusy, resultUnavailable:
+		return contacts.ErrProviderUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package ldap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+)
+
+func TestResultCodeError(t *testing.T) {
+	tests := []struct {
+		code int
+		want error
+	}{
+		{resultNoSuchObject, contacts.ErrNotFound},
+		{resultInvalidCredentials, contacts.ErrAuthExpired},
+		{resultInsufficientAccess, contacts.ErrAuthExpired},
+		{resultBusy, contacts.ErrProviderUnavailable},
+		{resultUnavailable, contacts.ErrProviderUnavailable},
+		{1, nil},
+	}
+	for _, tt := range tests {
+		if got := resultCodeError(tt.code); got != tt.want {
+			t.Errorf("resultCodeError(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestProvider_ErrNotInitialized(t *testing.T) {
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("Initialize: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := p.FetchContacts(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("FetchContacts: got %v, want ErrNotInitialized", err)
+	}
+}
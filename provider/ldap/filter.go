@@ -0,0 +1,163 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Filter choices (RFC 4511 section 4.5.1).
+const (
+	filterAnd       = classContext | constructed | 0
+	filterOr        = classContext | constructed | 1
+	filterNot       = classContext | constructed | 2
+	filterEquality  = classContext | constructed | 3
+	filterSubstring = classContext | constructed | 4
+	filterGreater   = classContext | constructed | 5
+	filterLess      = classContext | constructed | 6
+	filterPresent   = classContext | 7
+	filterApprox    = classContext | constructed | 8
+
+	substringInitial = classContext | 0
+	substringAny     = classContext | 1
+	substringFinal   = classContext | 2
+)
+
+// compileFilter encodes a filter written in the string form of RFC 4515,
+// such as "(&(objectClass=person)(mail=*))".
+func compileFilter(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		s = "(" + s + ")"
+	}
+	out, rest, err := parseFilter(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", s, err)
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("invalid filter %q: unexpected %q", s, rest)
+	}
+	return out, nil
+}
+
+// parseFilter parses the parenthesized filter at the start of s and
+// returns its encoding and what follows it.
+func parseFilter(s string) ([]byte, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, s, fmt.Errorf("expected '(' at %q", s)
+	}
+	s = s[1:]
+	var out []byte
+	switch {
+	case strings.HasPrefix(s, "&"), strings.HasPrefix(s, "|"):
+		tag := byte(filterAnd)
+		if s[0] == '|' {
+			tag = filterOr
+		}
+		s = s[1:]
+		var parts [][]byte
+		for strings.HasPrefix(s, "(") {
+			part, rest, err := parseFilter(s)
+			if err != nil {
+				return nil, s, err
+			}
+			parts, s = append(parts, part), rest
+		}
+		out = encode(tag, parts...)
+	case strings.HasPrefix(s, "!"):
+		part, rest, err := parseFilter(s[1:])
+		if err != nil {
+			return nil, s, err
+		}
+		out, s = encode(filterNot, part), rest
+	default:
+		end := strings.IndexByte(s, ')')
+		if end < 0 {
+			return nil, s, fmt.Errorf("missing ')'")
+		}
+		item, err := parseItem(s[:end])
+		if err != nil {
+			return nil, s, err
+		}
+		out, s = item, s[end:]
+	}
+	if !strings.HasPrefix(s, ")") {
+		return nil, s, fmt.Errorf("missing ')'")
+	}
+	return out, s[1:], nil
+}
+
+// parseItem encodes a simple filter such as "mail=*@example.com".
+func parseItem(s string) ([]byte, error) {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return nil, fmt.Errorf("expected attribute=value in %q", s)
+	}
+	attr, value := s[:i], s[i+1:]
+	tag := byte(filterEquality)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreater, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLess, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApprox, attr[:len(attr)-1]
+	}
+	if attr == "" {
+		return nil, fmt.Errorf("missing attribute in %q", s)
+	}
+	if tag != filterEquality || !strings.Contains(value, "*") {
+		v, err := unescapeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return encode(tag, encodeString(tagOctetString, attr), encodeString(tagOctetString, v)), nil
+	}
+	if value == "*" {
+		return encodeString(filterPresent, attr), nil
+	}
+	pieces := strings.Split(value, "*")
+	var subs [][]byte
+	for i, p := range pieces {
+		if p == "" {
+			continue
+		}
+		v, err := unescapeValue(p)
+		if err != nil {
+			return nil, err
+		}
+		tag := byte(substringAny)
+		switch i {
+		case 0:
+			tag = substringInitial
+		case len(pieces) - 1:
+			tag = substringFinal
+		}
+		subs = append(subs, encodeString(tag, v))
+	}
+	return encode(filterSubstring, encodeString(tagOctetString, attr), encode(tagSequence, subs...)), nil
+}
+
+// unescapeValue decodes the \XX escapes of a filter value.
+func unescapeValue(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		b.Write(c)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,38 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestCompileFilter(t *testing.T) {
+	tests := []struct {
+		filter, want string
+	}{
+		{"(cn=Ada)", "a3090402636e0403416461"},
+		{"cn=Ada", "a3090402636e0403416461"},
+		{"(mail=*)", "87046d61696c"},
+		{"(!(cn=Ada))", "a20ba3090402636e0403416461"},
+		{"(&(cn=A*)(sn=*ce))", "a017a4090402636e30038001" + "41" + "a40a0402736e300482026365"},
+		{"(cn=a\\2ab)", "a3090402636e0403612a62"},
+		{"(age>=30)", "a50904036167650402" + "3330"},
+	}
+	for _, tt := range tests {
+		got, err := compileFilter(tt.filter)
+		if err != nil {
+			t.Errorf("compileFilter(%q): %v", tt.filter, err)
+			continue
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("compileFilter(%q) = %x, want %s", tt.filter, got, tt.want)
+		}
+	}
+	if _, err := compileFilter(DefaultFilter); err != nil {
+		t.Errorf("DefaultFilter: %v", err)
+	}
+	for _, bad := range []string{"(cn=Ada", "(&(cn=a)", "(=x)", "(cn=\\zz)", "(cn=a)(sn=b)"} {
+		if _, err := compileFilter(bad); err == nil {
+			t.Errorf("compileFilter(%q): expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+// Package ldap implements a read-only contacts.ContactProvider backed by an
+// LDAP directory, such as OpenLDAP or Active Directory, so a corporate
+// address book can be synced into the local store.
+package ldap
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+	"github.com/google/uuid"
+)
+
+var (
+	_ contacts.IncrementalProvider = (*Provider)(nil)
+	_ contacts.ReadOnlyProvider    = (*Provider)(nil)
+)
+
+// FieldDN holds the distinguished name of the directory entry a contact
+// was read from.
+const FieldDN = "X-LDAP-DN"
+
+// DefaultFilter selects people in OpenLDAP-style directories and user
+// accounts, but not computers, in Active Directory.
+const DefaultFilter = "(|(objectClass=inetOrgPerson)(&(objectCategory=person)(objectClass=user)))"
+
+// DefaultAttributes maps each contact field to the LDAP attributes it is
+// read from, in order of preference. Credentials.Attributes overrides
+// single fields.
+var DefaultAttributes = map[string][]string{
+	"uid":         {"entryUUID", "objectGUID"},
+	"name":        {"displayName", "cn"},
+	"given":       {"givenName"},
+	"family":      {"sn"},
+	"email":       {"mail"},
+	"work_phone":  {"telephoneNumber"},
+	"mobile":      {"mobile"},
+	"home_phone":  {"homePhone"},
+	"org":         {"o", "company"},
+	"department":  {"department", "ou"},
+	"title":       {"title"},
+	"street":      {"street", "streetAddress"},
+	"city":        {"l"},
+	"region":      {"st"},
+	"postal_code": {"postalCode"},
+	"country":     {"co", "c"},
+	"url":         {"labeledURI", "wWWHomePage"},
+	"note":        {"description"},
+	"photo":       {"jpegPhoto", "thumbnailPhoto"},
+}
+
+// Credentials are the directory connection stored in ldap_creds.json.
+type Credentials struct {
+	// URL is the server, as ldap://host[:port] or ldaps://host[:port].
+	URL string `json:"url"`
+	// StartTLS upgrades an ldap:// connection to TLS before binding.
+	StartTLS bool `json:"start_tls,omitempty"`
+	// CAFile is a PEM file of certificates to trust instead of the
+	// system's, for directories with an internal CA.
+	CAFile string `json:"ca_file,omitempty"`
+	// BindDN and Password are used for a simple bind; leaving both empty
+	// binds anonymously. Active Directory also accepts "user@domain" as
+	// the bind DN.
+	BindDN   string `json:"bind_dn,omitempty"`
+	Password string `json:"password,omitempty"`
+	// BaseDN is where the search starts, e.g. "ou=people,dc=example,dc=com".
+	BaseDN string `json:"base_dn"`
+	// Filter selects the entries to sync; DefaultFilter if empty.
+	Filter string `json:"filter,omitempty"`
+	// Attributes overrides DefaultAttributes for the fields it names. An
+	// empty list leaves a field out.
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// Provider syncs the people in an LDAP directory. The directory is never
+// written to.
+type Provider struct {
+	creds providerutil.CredentialsFile[Credentials]
+	// state maps the ID of each entry last synced to a hash of its
+	// attributes, so FetchChanges can tell what changed.
+	state     map[string]string
+	statePath string
+	// pendingState is saved by CommitSync.
+	pendingState map[string]string
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		creds:     providerutil.NewCredentialsFile[Credentials](filepath.Join(dir, "ldap_creds.json")),
+		statePath: filepath.Join(dir, "ldap_state.json"),
+	}, nil
+}
+
+func (p *Provider) SaveCredentials(creds *Credentials) error {
+	return p.creds.Save(creds)
+}
+
+// LoadCredentials returns a copy of the stored credentials. The file is
+// read on the first call only; later calls return what was last loaded or
+// saved.
+func (p *Provider) LoadCredentials() (*Credentials, error) {
+	return p.creds.Load()
+}
+
+func (p *Provider) Initialize() error {
+	creds, err := p.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	if creds.URL == "" || creds.BaseDN == "" {
+		return fmt.Errorf("%w: no server or base DN configured: please run init first", contacts.ErrNotInitialized)
+	}
+	p.state = nil
+	if data, err := os.ReadFile(p.statePath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			return fmt.Errorf("failed to parse sync state: %w", err)
+		}
+	}
+	return nil
+}
+
+// Name keys the directory's contacts in the manager's ID map.
+func (p *Provider) Name() string {
+	return contacts.ProviderLDAP
+}
+
+// ReadOnly reports that the directory can't be written to, so the manager
+// keeps its contacts from being edited.
+func (p *Provider) ReadOnly() bool {
+	return true
+}
+
+// ParseAttributes parses attribute overrides written as space-separated
+// field=attribute pairs, with several attributes separated by commas and
+// an empty value to leave a field out:
+//
+//	email=mail,proxyAddresses title=jobTitle photo=
+func ParseAttributes(s string) (map[string][]string, error) {
+	out := map[string][]string{}
+	for _, pair := range strings.Fields(s) {
+		field, attrs, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid attribute mapping %q: expected field=attribute", pair)
+		}
+		if _, known := DefaultAttributes[field]; !known {
+			return nil, fmt.Errorf("unknown contact field %q in attribute mapping", field)
+		}
+		out[field] = []string{}
+		for _, a := range strings.Split(attrs, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				out[field] = append(out[field], a)
+			}
+		}
+	}
+	return out, nil
+}
+
+// attributes returns the effective mapping of contact fields to LDAP
+// attributes.
+func (p *Provider) attributes() map[string][]string {
+	out := make(map[string][]string, len(DefaultAttributes))
+	for field, attrs := range DefaultAttributes {
+		out[field] = attrs
+	}
+	for field, attrs := range p.creds.Cached().Attributes {
+		out[field] = attrs
+	}
+	return out
+}
+
+// search connects, binds and returns the directory's entries.
+func (p *Provider) search() ([]entry, error) {
+	creds := p.creds.Cached()
+	var config *tls.Config
+	if creds.CAFile != "" {
+		pem, err := os.ReadFile(creds.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", creds.CAFile)
+		}
+		config = &tls.Config{RootCAs: pool}
+	}
+	c, err := dial(creds.URL, creds.StartTLS, config)
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+	if err := c.bind(creds.BindDN, creds.Password); err != nil {
+		return nil, fmt.Errorf("failed to bind as %q: %w", creds.BindDN, err)
+	}
+	filter := creds.Filter
+	if filter == "" {
+		filter = DefaultFilter
+	}
+	var attrs []string
+	for _, list := range p.attributes() {
+		for _, a := range list {
+			if !slices.Contains(attrs, a) {
+				attrs = append(attrs, a)
+			}
+		}
+	}
+	sort.Strings(attrs)
+	entries, err := c.search(creds.BaseDN, filter, attrs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", creds.BaseDN, err)
+	}
+	return entries, nil
+}
+
+// FetchContacts returns every entry the filter selects.
+func (p *Provider) FetchContacts() ([]vcard.Card, error) {
+	if p.creds.Cached() == nil {
+		return nil, contacts.ErrNotInitialized
+	}
+	entries, err := p.search()
+	if err != nil {
+		return nil, err
+	}
+	attrs := p.attributes()
+	cards := make([]vcard.Card, len(entries))
+	for i, e := range entries {
+		cards[i] = convertEntryToCard(e, attrs)
+	}
+	return cards, nil
+}
+
+// FetchChanges returns the entries added or changed since the last
+// committed sync and the IDs of those removed. LDAP has no portable change
+// log, so the whole directory is searched and compared with the hashes
+// saved by the last sync.
+func (p *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	if p.creds.Cached() == nil {
+		return nil, nil, contacts.ErrNotInitialized
+	}
+	entries, err := p.search()
+	if err != nil {
+		return nil, nil, err
+	}
+	attrs := p.attributes()
+	next := make(map[string]string, len(entries))
+	for _, e := range entries {
+		card := convertEntryToCard(e, attrs)
+		id, hash := contacts.ProviderID(card), hashEntry(e)
+		next[id] = hash
+		if p.state[id] != hash {
+			changed = append(changed, card)
+		}
+	}
+	for id := range p.state {
+		if _, ok := next[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Strings(deleted)
+	p.pendingState = next
+	return changed, deleted, nil
+}
+
+// CommitSync saves the entry hashes from the last FetchChanges, once its
+// changes have been stored locally.
+func (p *Provider) CommitSync() error {
+	if p.pendingState == nil {
+		return nil
+	}
+	data, err := json.Marshal(p.pendingState)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.statePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	p.state, p.pendingState = p.pendingState, nil
+	return nil
+}
+
+// WriteContact fails: the directory is read-only.
+func (p *Provider) WriteContact(card vcard.Card) error {
+	return fmt.Errorf("%w: %s is in the LDAP directory", contacts.ErrReadOnly, contacts.CardFullName(card))
+}
+
+// DeleteContact fails: the directory is read-only.
+func (p *Provider) DeleteContact(id string) error {
+	return fmt.Errorf("%w: %s is in the LDAP directory", contacts.ErrReadOnly, id)
+}
+
+// hashEntry returns a hash of an entry's DN and attributes.
+func hashEntry(e entry) string {
+	h := sha256.New()
+	h.Write([]byte(e.DN))
+	names := make([]string, 0, len(e.Attrs))
+	for name := range e.Attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "\x00%s", name)
+		for _, v := range e.Attrs[name] {
+			fmt.Fprintf(h, "\x00%d:%s", len(v), v)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// --- Conversion: LDAP entry → vcard.Card ---
+
+func convertEntryToCard(e entry, attrs map[string][]string) vcard.Card {
+	values := func(field string) [][]byte {
+		for _, a := range attrs[field] {
+			if v := e.Attrs[strings.ToLower(a)]; len(v) > 0 {
+				return v
+			}
+		}
+		return nil
+	}
+	first := func(field string) string {
+		if v := values(field); len(v) > 0 {
+			return strings.TrimSpace(string(v[0]))
+		}
+		return ""
+	}
+
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+	uid := entryUID(e.DN, values("uid"))
+	card.SetValue(vcard.FieldUID, uid)
+	card.SetValue(contacts.FieldProviderID, uid)
+	card.SetValue(FieldDN, e.DN)
+
+	given, family := first("given"), first("family")
+	if given != "" || family != "" {
+		card.SetValue(vcard.FieldName, family+";"+given+";;;")
+	}
+	fn := first("name")
+	if fn == "" {
+		fn = strings.TrimSpace(given + " " + family)
+	}
+	for _, v := range values("email") {
+		card.AddValue(vcard.FieldEmail, string(v))
+	}
+	if fn == "" {
+		fn = card.Value(vcard.FieldEmail)
+	}
+	if fn == "" {
+		fn = e.DN
+	}
+	card.SetValue(vcard.FieldFormattedName, fn)
+
+	for _, tel := range []struct{ field, typ string }{
+		{"work_phone", vcard.TypeWork},
+		{"mobile", vcard.TypeCell},
+		{"home_phone", vcard.TypeHome},
+	} {
+		for _, v := range values(tel.field) {
+			card.Add(vcard.FieldTelephone, &vcard.Field{Value: string(v), Params: vcard.Params{vcard.ParamType: {tel.typ}}})
+		}
+	}
+
+	if org, dept := first("org"), first("department"); org != "" || dept != "" {
+		card.SetValue(vcard.FieldOrganization, strings.TrimSuffix(org+";"+dept, ";"))
+	}
+	if title := first("title"); title != "" {
+		card.SetValue(vcard.FieldTitle, title)
+	}
+	adr := []string{"", "", first("street"), first("city"), first("region"), first("postal_code"), first("country")}
+	if strings.Join(adr, "") != "" {
+		card.Add(vcard.FieldAddress, &vcard.Field{Value: strings.Join(adr, ";"), Params: vcard.Params{vcard.ParamType: {vcard.TypeWork}}})
+	}
+	for _, v := range values("url") {
+		// labeledURI values are "URI label".
+		u, _, _ := strings.Cut(strings.TrimSpace(string(v)), " ")
+		card.AddValue(vcard.FieldURL, u)
+	}
+	if note := first("note"); note != "" {
+		card.SetValue(vcard.FieldNote, note)
+	}
+	if photo := values("photo"); len(photo) > 0 && len(photo[0]) > 0 {
+		card.SetValue(vcard.FieldPhoto, "data:"+http.DetectContentType(photo[0])+";base64,"+base64.StdEncoding.EncodeToString(photo[0]))
+	}
+	return card
+}
+
+// entryUID returns a stable UID for an entry: its entryUUID, its
+// objectGUID (Active Directory's binary GUID) as a UUID, or else a UUID
+// derived from its DN.
+func entryUID(dn string, ids [][]byte) string {
+	for _, id := range ids {
+		if u, err := uuid.ParseBytes(id); err == nil {
+			return u.String()
+		}
+		if u, err := uuid.FromBytes(id); err == nil {
+			return u.String()
+		}
+	}
+	return uuid.NewSHA1(uuid.NameSpaceX500, []byte(strings.ToLower(dn))).String()
+}
@@ -0,0 +1,286 @@
+package ldap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+const baseDN = "ou=people,dc=example,dc=com"
+
+// fakeDirectory is a minimal LDAP server holding a list of entries under
+// baseDN, which it returns a page at a time.
+type fakeDirectory struct {
+	mu      sync.Mutex
+	entries []entry
+	// filter is the last search filter received.
+	filter []byte
+}
+
+func (d *fakeDirectory) set(entries ...entry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = entries
+}
+
+func newFakeDirectory(t *testing.T) (*fakeDirectory, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	d := &fakeDirectory{}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(c)
+		}
+	}()
+	old := pageSize
+	pageSize = 1
+	t.Cleanup(func() { pageSize = old })
+	return d, "ldap://" + l.Addr().String()
+}
+
+func (d *fakeDirectory) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	reply := func(id int, op []byte, controls ...[]byte) {
+		parts := [][]byte{encodeInt(tagInteger, id), op}
+		if len(controls) > 0 {
+			parts = append(parts, encode(tagControls, controls...))
+		}
+		c.Write(encode(tagSequence, parts...))
+	}
+	result := func(tag byte, code int) []byte {
+		return encode(tag, encodeInt(tagEnumerated, code), encodeString(tagOctetString, ""), encodeString(tagOctetString, "fake"))
+	}
+	for {
+		msg, err := readElement(r)
+		if err != nil {
+			return
+		}
+		id, op := msg.child(0).int(), msg.child(1)
+		switch op.tag {
+		case opBindRequest:
+			code := resultSuccess
+			if op.child(1).str() != "cn=reader,dc=example,dc=com" || op.child(2).str() != "secret" {
+				code = resultInvalidCredentials
+			}
+			reply(id, result(opBindResponse, code))
+		case opSearchRequest:
+			d.mu.Lock()
+			filter := op.child(6)
+			d.filter = encode(filter.tag, filter.data)
+			if op.child(0).str() != baseDN {
+				d.mu.Unlock()
+				reply(id, result(opSearchDone, resultNoSuchObject))
+				continue
+			}
+			start := 0
+			for _, ctrl := range msg.child(2).children {
+				paging, _ := decode(tagSequence, ctrl.child(1).data)
+				start, _ = strconv.Atoi(paging.child(0).child(1).str())
+			}
+			end := min(start+pageSize, len(d.entries))
+			for _, e := range d.entries[start:end] {
+				var attrs [][]byte
+				for name, values := range e.Attrs {
+					var vals [][]byte
+					for _, v := range values {
+						vals = append(vals, encode(tagOctetString, v))
+					}
+					attrs = append(attrs, encode(tagSequence, encodeString(tagOctetString, name), encode(tagSet, vals...)))
+				}
+				reply(id, encode(opSearchEntry, encodeString(tagOctetString, e.DN), encode(tagSequence, attrs...)))
+			}
+			cookie := ""
+			if end < len(d.entries) {
+				cookie = strconv.Itoa(end)
+			}
+			d.mu.Unlock()
+			paging := encode(tagSequence, encodeInt(tagInteger, 0), encodeString(tagOctetString, cookie))
+			reply(id, result(opSearchDone, resultSuccess),
+				encode(tagSequence, encodeString(tagOctetString, oidPaging), encodeString(tagOctetString, string(paging))))
+		case opUnbindRequest:
+			return
+		}
+	}
+}
+
+func attrs(kv ...string) map[string][][]byte {
+	m := map[string][][]byte{}
+	for i := 0; i < len(kv); i += 2 {
+		m[strings.ToLower(kv[i])] = append(m[strings.ToLower(kv[i])], []byte(kv[i+1]))
+	}
+	return m
+}
+
+var (
+	ada = entry{DN: "uid=ada," + baseDN, Attrs: attrs(
+		"entryUUID", "6f1c8a9e-2b1d-4c3e-9f00-0a1b2c3d4e5f",
+		"cn", "Ada Lovelace", "givenName", "Ada", "sn", "Lovelace",
+		"mail", "ada@example.com", "mail", "ada.lovelace@example.com",
+		"telephoneNumber", "+1 555 0100", "mobile", "+1 555 0101",
+		"o", "Analytical Engines", "ou", "Research", "title", "Engineer",
+		"street", "1 Engine Way", "l", "London", "postalCode", "N1",
+		"labeledURI", "https://ada.example Homepage",
+	)}
+	grace = entry{DN: "CN=Grace Hopper,OU=Staff,DC=example,DC=com", Attrs: map[string][][]byte{
+		"objectguid":     {{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f, 0x10}},
+		"displayname":    {[]byte("Grace Hopper")},
+		"company":        {[]byte("Navy")},
+		"thumbnailphoto": {{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F'}},
+	}}
+)
+
+func newTestProvider(t *testing.T, url, password string) *Provider {
+	t.Helper()
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SaveCredentials(&Credentials{URL: url, BindDN: "cn=reader,dc=example,dc=com", Password: password, BaseDN: baseDN}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProvider_Sync(t *testing.T) {
+	dir, url := newFakeDirectory(t)
+	dir.set(ada, grace)
+	p := newTestProvider(t, url, "secret")
+
+	changed, deleted, err := p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("first sync: %d changed, %v deleted; want every entry", len(changed), deleted)
+	}
+	if want, _ := compileFilter(DefaultFilter); !bytes.Equal(dir.filter, want) {
+		t.Errorf("searched with %x, want the default filter", dir.filter)
+	}
+
+	a := changed[0]
+	if contacts.CardUID(a) != "6f1c8a9e-2b1d-4c3e-9f00-0a1b2c3d4e5f" || contacts.ProviderID(a) != contacts.CardUID(a) {
+		t.Errorf("UID = %q, provider ID = %q", contacts.CardUID(a), contacts.ProviderID(a))
+	}
+	for field, want := range map[string]string{
+		vcard.FieldFormattedName: "Ada Lovelace",
+		vcard.FieldName:          "Lovelace;Ada;;;",
+		vcard.FieldOrganization:  "Analytical Engines;Research",
+		vcard.FieldTitle:         "Engineer",
+		vcard.FieldAddress:       ";;1 Engine Way;London;;N1;",
+		vcard.FieldURL:           "https://ada.example",
+		FieldDN:                  ada.DN,
+	} {
+		if got := a.Value(field); got != want {
+			t.Errorf("%s = %q, want %q", field, got, want)
+		}
+	}
+	if emails := a.Values(vcard.FieldEmail); len(emails) != 2 {
+		t.Errorf("EMAIL = %v, want both addresses", emails)
+	}
+	if tels := a[vcard.FieldTelephone]; len(tels) != 2 || !tels[0].Params.HasType(vcard.TypeWork) || !tels[1].Params.HasType(vcard.TypeCell) {
+		t.Errorf("TEL = %v", a.Values(vcard.FieldTelephone))
+	}
+
+	g := changed[1]
+	if contacts.CardUID(g) != "01020304-0506-0708-090a-0b0c0d0e0f10" {
+		t.Errorf("objectGUID UID = %q", contacts.CardUID(g))
+	}
+	if g.Value(vcard.FieldOrganization) != "Navy" || !strings.HasPrefix(g.Value(vcard.FieldPhoto), "data:image/jpeg;base64,") {
+		t.Errorf("ORG = %q, PHOTO = %.30q", g.Value(vcard.FieldOrganization), g.Value(vcard.FieldPhoto))
+	}
+
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	changed, deleted, err = p.FetchChanges()
+	if err != nil || len(changed) != 0 || len(deleted) != 0 {
+		t.Errorf("unchanged directory: %d changed, %v deleted, %v", len(changed), deleted, err)
+	}
+
+	// The state survives a restart.
+	p.CommitSync()
+	p.state = nil
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	moved := grace
+	moved.Attrs = map[string][][]byte{"objectguid": grace.Attrs["objectguid"], "displayname": {[]byte("Grace Hopper")}, "title": {[]byte("Rear Admiral")}}
+	dir.set(moved)
+	changed, deleted, err = p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || changed[0].Value(vcard.FieldTitle) != "Rear Admiral" {
+		t.Errorf("changed = %v, want Grace's new title", changed)
+	}
+	if len(deleted) != 1 || deleted[0] != contacts.CardUID(a) {
+		t.Errorf("deleted = %v, want Ada", deleted)
+	}
+
+	if err := p.WriteContact(a); !errors.Is(err, contacts.ErrReadOnly) {
+		t.Errorf("WriteContact = %v, want ErrReadOnly", err)
+	}
+	if err := p.DeleteContact(contacts.ProviderID(a)); !errors.Is(err, contacts.ErrReadOnly) {
+		t.Errorf("DeleteContact = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestProvider_WrongPassword(t *testing.T) {
+	_, url := newFakeDirectory(t)
+	p := newTestProvider(t, url, "wrong")
+	if _, err := p.FetchContacts(); !errors.Is(err, contacts.ErrAuthExpired) {
+		t.Errorf("FetchContacts = %v, want ErrAuthExpired", err)
+	}
+}
+
+func TestProvider_Attributes(t *testing.T) {
+	overrides, err := ParseAttributes("title=jobTitle,title photo=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseAttributes("shoe_size=shoeSize"); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+
+	dir, url := newFakeDirectory(t)
+	withJob := grace
+	withJob.Attrs = map[string][][]byte{"displayname": {[]byte("Grace Hopper")}, "jobtitle": {[]byte("Admiral")}, "thumbnailphoto": grace.Attrs["thumbnailphoto"]}
+	dir.set(withJob)
+	p := newTestProvider(t, url, "secret")
+	creds, _ := p.LoadCredentials()
+	creds.Attributes = overrides
+	if err := p.SaveCredentials(creds); err != nil {
+		t.Fatal(err)
+	}
+	cards, err := p.FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].Value(vcard.FieldTitle) != "Admiral" || cards[0].Value(vcard.FieldPhoto) != "" {
+		t.Errorf("cards = %v, want the mapped title and no photo", cards)
+	}
+	// Without an ID attribute the UID is derived from the DN.
+	if uid := contacts.CardUID(cards[0]); uid != entryUID(strings.ToUpper(grace.DN), nil) {
+		t.Errorf("UID = %q, want one derived from the DN", uid)
+	}
+}
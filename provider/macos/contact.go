@@ -0,0 +1,183 @@
+package macos
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+// contact holds the Contacts properties writeScript sets, named as in the
+// Contacts scripting dictionary.
+type contact struct {
+	FirstName    string `json:"firstName,omitempty"`
+	MiddleName   string `json:"middleName,omitempty"`
+	LastName     string `json:"lastName,omitempty"`
+	Title        string `json:"title,omitempty"`
+	Suffix       string `json:"suffix,omitempty"`
+	Nickname     string `json:"nickname,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Department   string `json:"department,omitempty"`
+	JobTitle     string `json:"jobTitle,omitempty"`
+	Note         string `json:"note,omitempty"`
+	// BirthDate is YYYY-MM-DD, with year noYear if the year is unknown.
+	BirthDate string    `json:"birthDate,omitempty"`
+	Emails    []labeled `json:"emails"`
+	Phones    []labeled `json:"phones"`
+	URLs      []labeled `json:"urls"`
+	Addresses []address `json:"addresses"`
+}
+
+type labeled struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type address struct {
+	Label   string `json:"label"`
+	Street  string `json:"street,omitempty"`
+	City    string `json:"city,omitempty"`
+	State   string `json:"state,omitempty"`
+	Zip     string `json:"zip,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// noYear is the year Contacts stores birthdays without one under.
+const noYear = "1604"
+
+// Contacts' built-in labels, which it shows localized.
+const (
+	labelHome     = "_$!<Home>!$_"
+	labelWork     = "_$!<Work>!$_"
+	labelOther    = "_$!<Other>!$_"
+	labelMobile   = "_$!<Mobile>!$_"
+	labelHomeFax  = "_$!<HomeFAX>!$_"
+	labelWorkFax  = "_$!<WorkFAX>!$_"
+	labelPager    = "_$!<Pager>!$_"
+	labelHomePage = "_$!<HomePage>!$_"
+)
+
+func convertCardToContact(card vcard.Card) *contact {
+	c := &contact{
+		Nickname: card.Value(vcard.FieldNickname),
+		JobTitle: card.Value(vcard.FieldTitle),
+		Note:     card.Value(vcard.FieldNote),
+	}
+
+	// N: family;given;middle;prefix;suffix
+	if n := card.Value(vcard.FieldName); strings.Trim(n, "; ") != "" {
+		parts := strings.SplitN(n, ";", 5)
+		parts = append(parts, make([]string, 5-len(parts))...)
+		c.LastName, c.FirstName, c.MiddleName, c.Title, c.Suffix = parts[0], parts[1], parts[2], parts[3], parts[4]
+	} else {
+		c.FirstName = contacts.CardFullName(card)
+	}
+	if org := card.Value(vcard.FieldOrganization); org != "" {
+		c.Organization, c.Department, _ = strings.Cut(org, ";")
+	}
+
+	if bday := strings.ReplaceAll(card.Value(vcard.FieldBirthday), "-", ""); len(bday) == 4 {
+		c.BirthDate = noYear + "-" + bday[:2] + "-" + bday[2:]
+	} else if len(bday) >= 8 {
+		c.BirthDate = bday[:4] + "-" + bday[4:6] + "-" + bday[6:8]
+	}
+
+	for _, f := range preferredFirst(card[vcard.FieldEmail]) {
+		c.Emails = append(c.Emails, labeled{Label: label(card, f, labelOther), Value: f.Value})
+	}
+	for _, f := range preferredFirst(card[vcard.FieldTelephone]) {
+		c.Phones = append(c.Phones, labeled{Label: phoneLabel(card, f), Value: f.Value})
+	}
+	for _, f := range preferredFirst(card[vcard.FieldURL]) {
+		l := label(card, f, labelHomePage)
+		if l == labelHome {
+			l = labelHomePage
+		}
+		c.URLs = append(c.URLs, labeled{Label: l, Value: f.Value})
+	}
+	for _, f := range preferredFirst(card[vcard.FieldAddress]) {
+		// ADR: PO Box;Extended;Street;City;Region;PostalCode;Country
+		parts := strings.SplitN(f.Value, ";", 7)
+		parts = append(parts, make([]string, 7-len(parts))...)
+		var street []string
+		for _, s := range parts[:3] {
+			if s != "" {
+				street = append(street, s)
+			}
+		}
+		c.Addresses = append(c.Addresses, address{
+			Label:   label(card, f, labelHome),
+			Street:  strings.Join(street, "\n"),
+			City:    parts[3],
+			State:   parts[4],
+			Zip:     parts[5],
+			Country: parts[6],
+		})
+	}
+	return c
+}
+
+// preferredFirst returns fields with the preferred ones first, as the
+// first value is the one Contacts treats as primary.
+func preferredFirst(fields []*vcard.Field) []*vcard.Field {
+	out := slices.Clone(fields)
+	slices.SortStableFunc(out, func(a, b *vcard.Field) int {
+		switch pa, pb := contacts.IsPreferred(a), contacts.IsPreferred(b); {
+		case pa && !pb:
+			return -1
+		case pb && !pa:
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// label returns the Contacts label for f: the custom label Contacts
+// exported with it, if any, or the built-in label for its TYPE, or def.
+func label(card vcard.Card, f *vcard.Field, def string) string {
+	if custom := customLabel(card, f); custom != "" {
+		return custom
+	}
+	switch {
+	case f.Params.HasType(vcard.TypeHome):
+		return labelHome
+	case f.Params.HasType(vcard.TypeWork):
+		return labelWork
+	case f.Params.HasType("other"):
+		return labelOther
+	}
+	return def
+}
+
+func phoneLabel(card vcard.Card, f *vcard.Field) string {
+	if custom := customLabel(card, f); custom != "" {
+		return custom
+	}
+	switch {
+	case f.Params.HasType(vcard.TypeFax) && f.Params.HasType(vcard.TypeHome):
+		return labelHomeFax
+	case f.Params.HasType(vcard.TypeFax):
+		return labelWorkFax
+	case f.Params.HasType(vcard.TypeCell) || f.Params.HasType("iphone"):
+		return labelMobile
+	case f.Params.HasType(vcard.TypePager):
+		return labelPager
+	}
+	return label(card, f, labelOther)
+}
+
+// customLabel returns the X-ABLabel Contacts exports in the same property
+// group as f for a label of the user's own, such as "item1.X-ABLabel".
+func customLabel(card vcard.Card, f *vcard.Field) string {
+	if f.Group == "" {
+		return ""
+	}
+	for _, l := range card["X-ABLABEL"] {
+		if strings.EqualFold(l.Group, f.Group) {
+			return l.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package macos
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+)
+
+// errorCode matches the Apple event error number osascript ends its error
+// messages with, as in "Error: Can't get object. (-1728)".
+var errorCode = regexp.MustCompile(`\((-?\d+)\)\s*$`)
+
+// codeError maps an Apple event error number to the matching
+// contacts error, or nil for errors without one.
+func codeError(code string) error {
+	switch code {
+	case "-1743": // errAEEventNotPermitted: access to Contacts denied
+		return contacts.ErrAuthExpired
+	case "-1728", "-1719": // no such object, invalid index
+		return contacts.ErrNotFound
+	case "-1712", "-600": // timed out, Contacts not running
+		return contacts.ErrProviderUnavailable
+	}
+	return nil
+}
+
+// scriptError describes a failed bridge script from what osascript wrote
+// to stderr.
+func scriptError(stderr string, err error) error {
+	msg := strings.TrimSpace(stderr)
+	if msg == "" {
+		return fmt.Errorf("%w: failed to run osascript: %w", contacts.ErrProviderUnavailable, err)
+	}
+	var code string
+	if m := errorCode.FindStringSubmatch(msg); m != nil {
+		code = m[1]
+	}
+	if code == "-1743" {
+		msg += ": allow access to Contacts in System Settings > Privacy & Security > Automation"
+	}
+	return errors.Join(codeError(code), fmt.Errorf("Contacts script failed: %s", msg))
+}
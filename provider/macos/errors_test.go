@@ -0,0 +1,47 @@
+package macos
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+)
+
+func TestScriptError(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{"execution error: Error: Not authorized to send Apple events to Contacts. (-1743)", contacts.ErrAuthExpired},
+		{"execution error: Error: Can't get object. (-1728)", contacts.ErrNotFound},
+		{"execution error: Error: AppleEvent timed out. (-1712)", contacts.ErrProviderUnavailable},
+		{"", contacts.ErrProviderUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stderr, func(t *testing.T) {
+			if got := scriptError(tt.stderr, errors.New("exit status 1")); !errors.Is(got, tt.want) {
+				t.Errorf("scriptError(%q) = %v, want %v", tt.stderr, got, tt.want)
+			}
+		})
+	}
+	if err := scriptError("Error: Can't get object. (-1728)", nil); !strings.Contains(err.Error(), "Can't get object") {
+		t.Errorf("error %q lost the script's message", err)
+	}
+	if err := scriptError("execution error: syntax error (-2741)", nil); errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("unknown code mapped to %v", err)
+	}
+}
+
+func TestProvider_ErrNotInitialized(t *testing.T) {
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("Initialize: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := p.FetchContacts(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("FetchContacts: got %v, want ErrNotInitialized", err)
+	}
+}
@@ -0,0 +1,267 @@
+// Package macos implements a contacts.ContactProvider backed by the macOS
+// Contacts app, so a Mac's system address book can be mirrored into the
+// local store.
+//
+// Contacts has no documented API outside Objective-C and Swift, and its
+// AddressBook database is private, so the provider drives the app through
+// osascript and JavaScript for Automation. People are read as the vCards
+// Contacts exports; writes set the properties the scripting dictionary
+// offers (names, organization, note, birthday, emails, phones, URLs and
+// addresses) and leave the rest, such as photos and related names, as
+// they are in Contacts.
+package macos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+var _ contacts.IncrementalProvider = (*Provider)(nil)
+
+// Config is the provider setup stored in macos_config.json.
+type Config struct {
+	// Group limits syncing to the members of a Contacts group; contacts
+	// created locally are added to it. Empty syncs every contact.
+	Group string `json:"group,omitempty"`
+}
+
+// Provider syncs the people in the Contacts app of the Mac it runs on.
+type Provider struct {
+	configPath string
+	// config caches the config file, which is read once per process.
+	config *Config
+	// state maps the ID of each person last synced to their modification
+	// date, so FetchChanges can tell what changed.
+	state     map[string]string
+	statePath string
+	// pendingState is saved by CommitSync.
+	pendingState map[string]string
+	run          runner
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		configPath: filepath.Join(dir, "macos_config.json"),
+		statePath:  filepath.Join(dir, "macos_state.json"),
+		run:        osascript,
+	}, nil
+}
+
+func (p *Provider) SaveConfig(config *Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(p.configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	cached := *config
+	p.config = &cached
+	return nil
+}
+
+// LoadConfig returns a copy of the stored config. The file is read on the
+// first call only; later calls return what was last loaded or saved.
+func (p *Provider) LoadConfig() (*Config, error) {
+	if p.config != nil {
+		config := *p.config
+		return &config, nil
+	}
+	data, err := os.ReadFile(p.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: config file not found at %s: please run init first", contacts.ErrNotInitialized, p.configPath)
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	cached := config
+	p.config = &cached
+	return &config, nil
+}
+
+// Setup checks that Contacts can be read, which makes macOS ask the user
+// to allow access, and saves the config. It returns the number of
+// contacts to sync. Choosing a different group resets the sync state.
+func (p *Provider) Setup(group string) (int, error) {
+	var people []person
+	if err := p.call(listScript, request{Group: group}, &people); err != nil {
+		return 0, fmt.Errorf("failed to read Contacts: %w", err)
+	}
+	if old, err := p.LoadConfig(); err == nil && old.Group != group {
+		if err := os.Remove(p.statePath); err != nil && !os.IsNotExist(err) {
+			return 0, fmt.Errorf("failed to reset sync state: %w", err)
+		}
+		p.state = nil
+	}
+	if err := p.SaveConfig(&Config{Group: group}); err != nil {
+		return 0, err
+	}
+	return len(people), nil
+}
+
+func (p *Provider) Initialize() error {
+	if _, err := p.LoadConfig(); err != nil {
+		return err
+	}
+	p.state = nil
+	if data, err := os.ReadFile(p.statePath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			return fmt.Errorf("failed to parse sync state: %w", err)
+		}
+	}
+	return nil
+}
+
+// Name keys the Mac's contacts in the manager's ID map.
+func (p *Provider) Name() string {
+	return contacts.ProviderMacOS
+}
+
+// FetchContacts returns every person in Contacts, or in the configured
+// group.
+func (p *Provider) FetchContacts() ([]vcard.Card, error) {
+	if p.config == nil {
+		return nil, contacts.ErrNotInitialized
+	}
+	var people []person
+	if err := p.call(listScript, request{Group: p.config.Group, Cards: true}, &people); err != nil {
+		return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+	}
+	return convertPeople(people)
+}
+
+// FetchChanges returns the people added or changed since the last
+// committed sync and the IDs of those removed. Contacts keeps no change
+// log, so every person's modification date is compared with the dates
+// saved by the last sync and only the changed people are exported.
+func (p *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	if p.config == nil {
+		return nil, nil, contacts.ErrNotInitialized
+	}
+	var listed []person
+	if err := p.call(listScript, request{Group: p.config.Group}, &listed); err != nil {
+		return nil, nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	next := make(map[string]string, len(listed))
+	var ids []string
+	for _, person := range listed {
+		next[person.ID] = person.Modified
+		if person.Modified == "" || p.state[person.ID] != person.Modified {
+			ids = append(ids, person.ID)
+		}
+	}
+	if len(ids) > 0 {
+		var people []person
+		if err := p.call(getScript, request{IDs: ids}, &people); err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch contacts: %w", err)
+		}
+		if changed, err = convertPeople(people); err != nil {
+			return nil, nil, err
+		}
+		// People deleted between the two scripts are reported deleted.
+		fetched := make(map[string]bool, len(people))
+		for _, person := range people {
+			fetched[person.ID] = true
+		}
+		for _, id := range ids {
+			if !fetched[id] {
+				delete(next, id)
+			}
+		}
+	}
+	for id := range p.state {
+		if _, ok := next[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Strings(deleted)
+	p.pendingState = next
+	return changed, deleted, nil
+}
+
+// CommitSync saves the modification dates from the last FetchChanges,
+// once its changes have been stored locally.
+func (p *Provider) CommitSync() error {
+	if p.pendingState == nil {
+		return nil
+	}
+	data, err := json.Marshal(p.pendingState)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.statePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	p.state, p.pendingState = p.pendingState, nil
+	return nil
+}
+
+// WriteContact creates or updates the person in Contacts. A new person
+// gets the ID Contacts assigns, which the card adopts.
+func (p *Provider) WriteContact(card vcard.Card) error {
+	if p.config == nil {
+		return contacts.ErrNotInitialized
+	}
+	req := request{ID: contacts.ProviderID(card), Contact: convertCardToContact(card)}
+	if req.ID == "" {
+		req.Group = p.config.Group
+	}
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := p.call(writeScript, req, &result); err != nil {
+		return fmt.Errorf("failed to write contact %s: %w", contacts.CardFullName(card), err)
+	}
+	if req.ID == "" && result.ID != "" {
+		card.SetValue(vcard.FieldUID, localUID(result.ID))
+		card.SetValue(contacts.FieldProviderID, result.ID)
+	}
+	return nil
+}
+
+func (p *Provider) DeleteContact(id string) error {
+	if p.config == nil {
+		return contacts.ErrNotInitialized
+	}
+	var result struct{}
+	if err := p.call(deleteScript, request{ID: id}, &result); err != nil {
+		return fmt.Errorf("failed to delete contact %s: %w", id, err)
+	}
+	return nil
+}
+
+// localUID derives a contact's UID from its Contacts ID, a UUID followed
+// by ":ABPerson".
+func localUID(id string) string {
+	uid, _, _ := strings.Cut(id, ":")
+	return strings.ToLower(uid)
+}
+
+// convertPeople decodes the vCards Contacts exported.
+func convertPeople(people []person) ([]vcard.Card, error) {
+	cards := make([]vcard.Card, 0, len(people))
+	for _, person := range people {
+		card, err := contacts.DecodeCard([]byte(person.VCard))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse contact %s: %w", person.ID, err)
+		}
+		card.SetValue(vcard.FieldUID, localUID(person.ID))
+		card.SetValue(contacts.FieldProviderID, person.ID)
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
@@ -0,0 +1,229 @@
+package macos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+// fakeContacts stands in for the Contacts app, answering the bridge
+// scripts from an in-memory address book.
+type fakeContacts struct {
+	people  map[string]person
+	written []request
+	calls   map[string]int
+}
+
+func (f *fakeContacts) run(script string, arg []byte) ([]byte, error) {
+	var req request
+	if err := json.Unmarshal(arg, &req); err != nil {
+		return nil, err
+	}
+	f.calls[script]++
+	var out any
+	switch script {
+	case listScript:
+		list := []person{}
+		for _, p := range f.people {
+			if !req.Cards {
+				p.VCard = ""
+			}
+			list = append(list, p)
+		}
+		out = list
+	case getScript:
+		list := []person{}
+		for _, id := range req.IDs {
+			if p, ok := f.people[id]; ok {
+				list = append(list, p)
+			}
+		}
+		out = list
+	case writeScript:
+		f.written = append(f.written, req)
+		id := req.ID
+		if id == "" {
+			id = fmt.Sprintf("NEW-%d:ABPerson", len(f.written))
+		}
+		out = map[string]string{"id": id}
+	case deleteScript:
+		if _, ok := f.people[req.ID]; !ok {
+			return nil, scriptError("execution error: Error: Can't get object. (-1728)", nil)
+		}
+		delete(f.people, req.ID)
+		out = struct{}{}
+	}
+	return json.Marshal(out)
+}
+
+func vcardText(name string) string {
+	return "BEGIN:VCARD\r\nVERSION:3.0\r\nN:" + name + ";;;;\r\nFN:" + name + "\r\nEND:VCARD\r\n"
+}
+
+func newTestProvider(t *testing.T) (*Provider, *fakeContacts) {
+	t.Helper()
+	fake := &fakeContacts{
+		people: map[string]person{
+			"AAAA-1:ABPerson": {ID: "AAAA-1:ABPerson", Modified: "2024-01-01T00:00:00.000Z", VCard: vcardText("Lovelace")},
+			"BBBB-2:ABPerson": {ID: "BBBB-2:ABPerson", Modified: "2024-01-01T00:00:00.000Z", VCard: vcardText("Hopper")},
+		},
+		calls: map[string]int{},
+	}
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.run = fake.run
+	if n, err := p.Setup(""); err != nil || n != 2 {
+		t.Fatalf("Setup = %d, %v", n, err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return p, fake
+}
+
+func TestProvider_FetchContacts(t *testing.T) {
+	p, _ := newTestProvider(t)
+	cards, err := p.FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("got %d cards, want 2", len(cards))
+	}
+	for _, card := range cards {
+		id := contacts.ProviderID(card)
+		if want := localUID(id); contacts.CardUID(card) != want {
+			t.Errorf("UID = %q, want %q", contacts.CardUID(card), want)
+		}
+	}
+}
+
+func TestProvider_FetchChanges(t *testing.T) {
+	p, fake := newTestProvider(t)
+	changed, deleted, err := p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("first sync: %d changed, %v deleted", len(changed), deleted)
+	}
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	hopper := fake.people["BBBB-2:ABPerson"]
+	hopper.Modified = "2024-02-01T00:00:00.000Z"
+	hopper.VCard = vcardText("Hopper-Murray")
+	fake.people[hopper.ID] = hopper
+	delete(fake.people, "AAAA-1:ABPerson")
+	fake.calls = map[string]int{}
+
+	changed, deleted, err = p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || contacts.CardFullName(changed[0]) != "Hopper-Murray" {
+		t.Errorf("changed = %v, want the edited contact", changed)
+	}
+	if len(deleted) != 1 || deleted[0] != "AAAA-1:ABPerson" {
+		t.Errorf("deleted = %v", deleted)
+	}
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing changed: no vCards are exported.
+	fake.calls = map[string]int{}
+	if changed, deleted, err = p.FetchChanges(); err != nil || len(changed) != 0 || len(deleted) != 0 {
+		t.Errorf("unchanged sync: %d changed, %v deleted, %v", len(changed), deleted, err)
+	}
+	if fake.calls[getScript] != 0 {
+		t.Error("unchanged sync exported vCards")
+	}
+}
+
+func TestProvider_WriteContact(t *testing.T) {
+	p, fake := newTestProvider(t)
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldName, "Lovelace;Ada;;;")
+	card.SetValue(vcard.FieldOrganization, "Analytical Engines;Research")
+	card.SetValue(vcard.FieldBirthday, "--1210")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@home.example", Params: vcard.Params{vcard.ParamType: {"home"}}})
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@work.example", Params: vcard.Params{vcard.ParamType: {"work"}, vcard.ParamPreferred: {"1"}}})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "555-0100", Params: vcard.Params{vcard.ParamType: {"cell"}}})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "555-0101", Group: "item1"})
+	card.Add("X-ABLABEL", &vcard.Field{Value: "Lab", Group: "item1"})
+	card.Add(vcard.FieldAddress, &vcard.Field{Value: ";;12 St James's Square;London;;SW1Y 4JH;UK", Params: vcard.Params{vcard.ParamType: {"work"}}})
+
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if contacts.ProviderID(card) != "NEW-1:ABPerson" || contacts.CardUID(card) != "new-1" {
+		t.Errorf("card did not adopt the new person's ID: UID %q, ID %q", contacts.CardUID(card), contacts.ProviderID(card))
+	}
+	c := fake.written[0].Contact
+	if c.FirstName != "Ada" || c.LastName != "Lovelace" || c.Organization != "Analytical Engines" || c.Department != "Research" {
+		t.Errorf("names = %+v", c)
+	}
+	if c.BirthDate != "1604-12-10" {
+		t.Errorf("BirthDate = %q, want a year-less birthday", c.BirthDate)
+	}
+	if len(c.Emails) != 2 || c.Emails[0] != (labeled{labelWork, "ada@work.example"}) {
+		t.Errorf("Emails = %v, want the preferred address first", c.Emails)
+	}
+	if len(c.Phones) != 2 || c.Phones[0].Label != labelMobile || c.Phones[1].Label != "Lab" {
+		t.Errorf("Phones = %v", c.Phones)
+	}
+	if len(c.Addresses) != 1 || c.Addresses[0].Street != "12 St James's Square" || c.Addresses[0].Label != labelWork {
+		t.Errorf("Addresses = %v", c.Addresses)
+	}
+
+	// Updates name the person to change.
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if fake.written[1].ID != "NEW-1:ABPerson" {
+		t.Errorf("update request = %+v", fake.written[1])
+	}
+}
+
+func TestProvider_DeleteContact(t *testing.T) {
+	p, fake := newTestProvider(t)
+	if err := p.DeleteContact("AAAA-1:ABPerson"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.people["AAAA-1:ABPerson"]; ok {
+		t.Error("person not deleted")
+	}
+	if err := p.DeleteContact("AAAA-1:ABPerson"); !errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("deleting again: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestProvider_SetupGroupResetsState(t *testing.T) {
+	p, _ := newTestProvider(t)
+	if _, _, err := p.FetchChanges(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Setup("Family"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.state) != 0 {
+		t.Errorf("state = %v, want it reset for the new group", p.state)
+	}
+	if config, err := p.LoadConfig(); err != nil || config.Group != "Family" {
+		t.Errorf("config = %+v, %v", config, err)
+	}
+}
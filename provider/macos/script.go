@@ -0,0 +1,148 @@
+package macos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// The bridge scripts are JavaScript for Automation run by osascript. Each
+// takes a JSON request as its only argument and prints a JSON result.
+const (
+	// listScript returns the ID and modification date of every person,
+	// with their vCards if cards is set.
+	listScript = `function run(argv) {
+	const req = JSON.parse(argv[0]);
+	const app = Application("Contacts");
+	const people = req.group ? app.groups.byName(req.group).people : app.people;
+	const ids = people.id();
+	const modified = people.modificationDate();
+	const cards = req.cards ? people.vcard() : [];
+	return JSON.stringify(ids.map((id, i) => ({
+		id: id,
+		modified: modified[i] ? modified[i].toISOString() : "",
+		vcard: cards[i] || ""
+	})));
+}`
+
+	// getScript returns the people with the given IDs, skipping those
+	// deleted since they were listed.
+	getScript = `function run(argv) {
+	const req = JSON.parse(argv[0]);
+	const app = Application("Contacts");
+	const out = [];
+	for (const id of req.ids) {
+		let person;
+		try {
+			const p = app.people.byId(id);
+			const modified = p.modificationDate();
+			person = {id: id, modified: modified ? modified.toISOString() : "", vcard: p.vcard()};
+		} catch (e) {
+			continue;
+		}
+		out.push(person);
+	}
+	return JSON.stringify(out);
+}`
+
+	// writeScript creates a person, or updates the one with the given ID,
+	// and returns its ID. Multi-valued properties are replaced as a whole.
+	writeScript = `function run(argv) {
+	const req = JSON.parse(argv[0]);
+	const app = Application("Contacts");
+	const c = req.contact;
+	let p;
+	if (req.id) {
+		p = app.people.byId(req.id);
+		p.id();
+	} else {
+		p = app.Person();
+		app.people.push(p);
+		if (req.group) {
+			app.add(p, {to: app.groups.byName(req.group)});
+		}
+	}
+	for (const key of ["firstName", "middleName", "lastName", "title", "suffix", "nickname", "organization", "department", "jobTitle", "note"]) {
+		p[key] = c[key] || "";
+	}
+	if (c.birthDate) {
+		p.birthDate = new Date(c.birthDate + "T12:00:00");
+	}
+	const lists = {emails: app.Email, phones: app.Phone, urls: app.Url, addresses: app.Address};
+	for (const key in lists) {
+		const existing = p[key]();
+		for (let i = existing.length - 1; i >= 0; i--) {
+			app.delete(existing[i]);
+		}
+		for (const props of c[key] || []) {
+			p[key].push(lists[key](props));
+		}
+	}
+	app.save();
+	return JSON.stringify({id: p.id()});
+}`
+
+	deleteScript = `function run(argv) {
+	const req = JSON.parse(argv[0]);
+	const app = Application("Contacts");
+	app.delete(app.people.byId(req.id));
+	app.save();
+	return "{}";
+}`
+)
+
+// request is the argument of every bridge script; each reads the fields
+// it needs.
+type request struct {
+	Group   string   `json:"group,omitempty"`
+	Cards   bool     `json:"cards,omitempty"`
+	IDs     []string `json:"ids,omitempty"`
+	ID      string   `json:"id,omitempty"`
+	Contact *contact `json:"contact,omitempty"`
+}
+
+// person is a Contacts person as listScript and getScript return it.
+type person struct {
+	ID       string `json:"id"`
+	Modified string `json:"modified"`
+	VCard    string `json:"vcard"`
+}
+
+// runner runs a bridge script with a JSON argument and returns what it
+// printed. Tests replace osascript with a fake.
+type runner func(script string, arg []byte) ([]byte, error)
+
+// osascript runs script with the osascript tool, which asks the user to
+// allow access to Contacts the first time.
+func osascript(script string, arg []byte) ([]byte, error) {
+	if runtime.GOOS != "darwin" {
+		return nil, fmt.Errorf("%w: the macOS Contacts provider only runs on macOS", errors.ErrUnsupported)
+	}
+	cmd := exec.Command("osascript", "-l", "JavaScript", "-e", script, string(arg))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, scriptError(stderr.String(), err)
+	}
+	return out, nil
+}
+
+// call runs script with req and decodes its result into out.
+func (p *Provider) call(script string, req request, out any) error {
+	arg, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	data, err := p.run(script, arg)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse Contacts response: %w", err)
+	}
+	return nil
+}
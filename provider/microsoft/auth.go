@@ -0,0 +1,192 @@
+package microsoft
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"golang.org/x/oauth2"
+)
+
+// successPage is shown in the browser once authorization completes.
+const successPage = `<html><head><title>Authorization Successful</title></head>
+<body style="font-family:sans-serif;text-align:center;padding:50px;">
+<h1 style="color:#4CAF50;">Authorization Successful!</h1>
+<p>You can close this window and return to the terminal.</p>
+</body></html>`
+
+// AuthorizeWithPKCE starts the authorization code flow with PKCE. It
+// returns the URL to open in a browser and a channel that receives the
+// outcome once Microsoft redirects back to the local callback server.
+func (m *Provider) AuthorizeWithPKCE(ctx context.Context) (authURL string, errChan <-chan error, err error) {
+	if m.config == nil {
+		return "", nil, contacts.ErrNotInitialized
+	}
+	verifier := oauth2.GenerateVerifier()
+	stateBytes := make([]byte, 16)
+	rand.Read(stateBytes)
+	state := base64.RawURLEncoding.EncodeToString(stateBytes)
+	authURL = m.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+
+	resultCh := make(chan error, 1)
+	mux := http.NewServeMux()
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: mux,
+	}
+
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+			errDesc := r.URL.Query().Get("error_description")
+			http.Error(w, "Authorization failed", http.StatusBadRequest)
+			resultCh <- fmt.Errorf("authorization failed: %s - %s", errMsg, errDesc)
+			return
+		}
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "No authorization code received", http.StatusBadRequest)
+			resultCh <- fmt.Errorf("no authorization code in callback")
+			return
+		}
+		if r.URL.Query().Get("state") != state {
+			http.Error(w, "Invalid state parameter", http.StatusBadRequest)
+			resultCh <- fmt.Errorf("state mismatch: CSRF attack detected")
+			return
+		}
+		token, err := m.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+		if err != nil {
+			http.Error(w, "Token exchange failed", http.StatusInternalServerError)
+			resultCh <- fmt.Errorf("failed to exchange code: %w", err)
+			return
+		}
+		m.setToken(token)
+		if err := m.persistToken(token); err != nil {
+			http.Error(w, "Failed to save credentials", http.StatusInternalServerError)
+			resultCh <- fmt.Errorf("failed to save credentials: %w", err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, successPage)
+		resultCh <- nil
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			server.Shutdown(context.Background())
+		}()
+	})
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			resultCh <- fmt.Errorf("server error: %w", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+		select {
+		case resultCh <- ctx.Err():
+		default:
+		}
+	}()
+
+	return authURL, resultCh, nil
+}
+
+// AuthorizeWithDevice runs the device code flow, for machines without a
+// browser: prompt is called with the URL to visit and the code to enter
+// there, and AuthorizeWithDevice returns once the user has signed in.
+func (m *Provider) AuthorizeWithDevice(ctx context.Context, prompt func(verificationURI, userCode string)) error {
+	if m.config == nil {
+		return contacts.ErrNotInitialized
+	}
+	auth, err := m.config.DeviceAuth(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to start device authorization: %w", tokenError(err), err)
+	}
+	prompt(auth.VerificationURI, auth.UserCode)
+	token, err := m.config.DeviceAccessToken(ctx, auth)
+	if err != nil {
+		return fmt.Errorf("authorization failed: %w", err)
+	}
+	m.setToken(token)
+	if err := m.persistToken(token); err != nil {
+		return fmt.Errorf("failed to save credentials: %w", err)
+	}
+	return nil
+}
+
+// refreshToken exchanges the refresh token for a new access token if the
+// current one has expired. A new token is persisted by the token source.
+func (m *Provider) refreshToken() error {
+	if _, err := m.tokenSource().Token(); err != nil {
+		return fmt.Errorf("%w: failed to refresh token: %w", tokenError(err), err)
+	}
+	return nil
+}
+
+// setToken replaces the current token and the token source built on it.
+func (m *Provider) setToken(token *oauth2.Token) {
+	m.tokenMu.Lock()
+	defer m.tokenMu.Unlock()
+	m.token = token
+	m.ts = nil
+}
+
+// tokenSource returns the token source shared by every request of this
+// provider, so an expired token is refreshed once rather than per client,
+// and each new token is persisted as it rotates.
+func (m *Provider) tokenSource() oauth2.TokenSource {
+	m.tokenMu.Lock()
+	defer m.tokenMu.Unlock()
+	if m.ts == nil {
+		src := m.config.TokenSource(context.Background(), m.token)
+		m.ts = oauth2.ReuseTokenSource(m.token, &persistingTokenSource{m: m, src: src})
+	}
+	return m.ts
+}
+
+// persistingTokenSource saves each token its source issues.
+type persistingTokenSource struct {
+	m   *Provider
+	src oauth2.TokenSource
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.m.tokenMu.Lock()
+	s.m.token = token
+	s.m.tokenMu.Unlock()
+	if err := s.m.persistToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// persistToken stores token in the credentials file, unless the file
+// already holds it.
+func (m *Provider) persistToken(token *oauth2.Token) error {
+	creds, err := m.LoadCredentials()
+	if err != nil {
+		return fmt.Errorf("failed to load credentials: %w", err)
+	}
+	refresh := token.RefreshToken
+	if refresh == "" {
+		refresh = creds.RefreshToken
+	}
+	if creds.AccessToken == token.AccessToken && creds.RefreshToken == refresh && creds.Expiry.Equal(token.Expiry) {
+		return nil
+	}
+	creds.RefreshToken = refresh
+	creds.AccessToken = token.AccessToken
+	creds.Expiry = token.Expiry
+	if err := m.SaveCredentials(creds); err != nil {
+		return fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package microsoft
+
+import (
+	"errors"
+
+	"github.com/arjungandhi/contacts"
+	"golang.org/x/oauth2"
+)
+
+// tokenError classifies an error from an OAuth token request. A rejected
+// refresh token means authorization has expired; anything else means the
+// token endpoint couldn't be reached.
+func tokenError(err error) error {
+	var re *oauth2.RetrieveError
+	if errors.As(err, &re) && re.Response != nil && re.Response.StatusCode < 500 {
+		return contacts.ErrAuthExpired
+	}
+	return contacts.ErrProviderUnavailable
+}
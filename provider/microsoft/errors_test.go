@@ -0,0 +1,33 @@
+package microsoft
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenError(t *testing.T) {
+	rejected := &oauth2.RetrieveError{Response: &http.Response{StatusCode: http.StatusBadRequest}}
+	if got := tokenError(rejected); got != contacts.ErrAuthExpired {
+		t.Errorf("rejected refresh = %v, want ErrAuthExpired", got)
+	}
+	if got := tokenError(errors.New("dial tcp: connection refused")); got != contacts.ErrProviderUnavailable {
+		t.Errorf("network failure = %v, want ErrProviderUnavailable", got)
+	}
+}
+
+func TestProvider_ErrNotInitialized(t *testing.T) {
+	m, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Initialize(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("Initialize: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := m.FetchContacts(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("FetchContacts: got %v, want ErrNotInitialized", err)
+	}
+}
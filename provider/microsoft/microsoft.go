@@ -0,0 +1,670 @@
+// Package microsoft implements a contacts.ContactProvider backed by the
+// Microsoft Graph contacts API, for Outlook.com and Microsoft 365 accounts.
+package microsoft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+	"golang.org/x/oauth2"
+	msoauth "golang.org/x/oauth2/microsoft"
+)
+
+var _ contacts.IncrementalProvider = (*Provider)(nil)
+
+// graphURL is the Microsoft Graph API root.
+var graphURL = "https://graph.microsoft.com/v1.0"
+
+// Built-in OAuth client, injected at build time so end users can authorize
+// without registering their own Azure app:
+//
+//	go build -ldflags "-X github.com/arjungandhi/contacts/provider/microsoft.defaultClientID=..."
+var defaultClientID string
+
+// DefaultCredentials returns the OAuth client built into the binary,
+// or nil if it was built without one.
+func DefaultCredentials() *Credentials {
+	if defaultClientID == "" {
+		return nil
+	}
+	return &Credentials{ClientID: defaultClientID}
+}
+
+// Credentials are the OAuth client and tokens stored in
+// microsoft_creds.json.
+type Credentials struct {
+	ClientID string `json:"client_id"`
+	// ClientSecret is only needed for confidential clients; apps
+	// registered as public clients leave it empty.
+	ClientSecret string `json:"client_secret,omitempty"`
+	// Tenant is the Azure AD tenant to sign in to: "common" (the default)
+	// for any account, "consumers" for Outlook.com only, or an
+	// organization's tenant ID or domain.
+	Tenant       string `json:"tenant,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	// Expiry is when AccessToken expires, so a later run can reuse it
+	// instead of refreshing.
+	Expiry time.Time `json:"expiry,omitzero"`
+}
+
+// Provider syncs contacts with the default contacts folder of an Outlook
+// or Microsoft 365 mailbox.
+type Provider struct {
+	config *oauth2.Config
+	token  *oauth2.Token
+	creds  providerutil.CredentialsFile[Credentials]
+	// ts is the shared token source; see tokenSource.
+	ts        oauth2.TokenSource
+	tokenMu   sync.Mutex
+	deltaLink string
+	deltaPath string
+	// pendingDeltaLink is saved by CommitSync.
+	pendingDeltaLink string
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		creds:     providerutil.NewCredentialsFile[Credentials](filepath.Join(dir, "microsoft_creds.json")),
+		deltaPath: filepath.Join(dir, "microsoft_delta_link.txt"),
+	}, nil
+}
+
+func (m *Provider) SaveCredentials(creds *Credentials) error {
+	return m.creds.Save(creds)
+}
+
+// LoadCredentials returns a copy of the stored credentials. The file is
+// read on the first call only; later calls return what was last loaded or
+// saved.
+func (m *Provider) LoadCredentials() (*Credentials, error) {
+	return m.creds.Load()
+}
+
+func (m *Provider) Initialize() error {
+	creds, err := m.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	endpoint := msoauth.AzureADEndpoint(creds.Tenant)
+	// Public clients have no secret, which Azure AD only accepts in the
+	// request body.
+	endpoint.AuthStyle = oauth2.AuthStyleInParams
+	m.config = &oauth2.Config{
+		ClientID:     creds.ClientID,
+		ClientSecret: creds.ClientSecret,
+		Endpoint:     endpoint,
+		RedirectURL:  "http://localhost:8080/callback",
+		Scopes:       []string{"offline_access", "Contacts.ReadWrite", "User.Read"},
+	}
+	if creds.RefreshToken != "" {
+		expiry := creds.Expiry
+		if expiry.IsZero() {
+			expiry = time.Now().Add(-time.Hour)
+		}
+		m.setToken(&oauth2.Token{
+			RefreshToken: creds.RefreshToken,
+			AccessToken:  creds.AccessToken,
+			Expiry:       expiry,
+		})
+	}
+	if data, err := os.ReadFile(m.deltaPath); err == nil {
+		m.deltaLink = string(data)
+	}
+	return nil
+}
+
+func (m *Provider) SaveDeltaLink(link string) error {
+	m.deltaLink = link
+	return os.WriteFile(m.deltaPath, []byte(link), 0600)
+}
+
+// Name keys Microsoft's contacts in the manager's ID map.
+func (m *Provider) Name() string {
+	return contacts.ProviderMicrosoft
+}
+
+// --- Graph API structures ---
+
+type graphContact struct {
+	ID                   string        `json:"id"`
+	ETag                 string        `json:"@odata.etag,omitempty"`
+	Removed              *graphRemoved `json:"@removed,omitempty"`
+	DisplayName          string        `json:"displayName"`
+	GivenName            string        `json:"givenName"`
+	MiddleName           string        `json:"middleName"`
+	Surname              string        `json:"surname"`
+	Title                string        `json:"title"`
+	Generation           string        `json:"generation"`
+	NickName             string        `json:"nickName"`
+	EmailAddresses       []graphEmail  `json:"emailAddresses"`
+	MobilePhone          string        `json:"mobilePhone"`
+	BusinessPhones       []string      `json:"businessPhones"`
+	HomePhones           []string      `json:"homePhones"`
+	CompanyName          string        `json:"companyName"`
+	Department           string        `json:"department"`
+	JobTitle             string        `json:"jobTitle"`
+	BusinessAddress      *graphAddress `json:"businessAddress"`
+	HomeAddress          *graphAddress `json:"homeAddress"`
+	OtherAddress         *graphAddress `json:"otherAddress"`
+	Birthday             *time.Time    `json:"birthday"`
+	PersonalNotes        string        `json:"personalNotes"`
+	BusinessHomePage     string        `json:"businessHomePage"`
+	ImAddresses          []string      `json:"imAddresses"`
+	SpouseName           string        `json:"spouseName"`
+	Children             []string      `json:"children"`
+	Categories           []string      `json:"categories"`
+	LastModifiedDateTime *time.Time    `json:"lastModifiedDateTime,omitempty"`
+}
+
+type graphRemoved struct {
+	Reason string `json:"reason"`
+}
+
+type graphEmail struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+}
+
+type graphAddress struct {
+	Street          string `json:"street"`
+	City            string `json:"city"`
+	State           string `json:"state"`
+	PostalCode      string `json:"postalCode"`
+	CountryOrRegion string `json:"countryOrRegion"`
+}
+
+// graphAddressTypes pairs each Graph address with its ADR TYPE.
+var graphAddressTypes = []string{"work", "home", "other"}
+
+// graphMaxEmails is how many email addresses an Outlook contact holds.
+const graphMaxEmails = 3
+
+// FieldETag holds Graph's ETag for the stored copy of a contact, sent with
+// updates so that edits made in Outlook since are not overwritten.
+const FieldETag = "X-MICROSOFT-ETAG"
+
+// --- Conversion: Graph → vcard.Card ---
+
+func convertGraphToCard(c graphContact) vcard.Card {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldVersion, "4.0")
+	card.SetValue(vcard.FieldUID, providerutil.LocalUID(c.ID))
+	card.SetValue(contacts.FieldProviderID, c.ID)
+	if c.ETag != "" {
+		card.SetValue(FieldETag, c.ETag)
+	}
+
+	// Names → FN, N
+	if c.DisplayName != "" {
+		card.SetValue(vcard.FieldFormattedName, c.DisplayName)
+	}
+	if c.Surname != "" || c.GivenName != "" || c.MiddleName != "" || c.Title != "" || c.Generation != "" {
+		card.SetValue(vcard.FieldName, c.Surname+";"+c.GivenName+";"+c.MiddleName+";"+c.Title+";"+c.Generation)
+	}
+	if c.NickName != "" {
+		card.SetValue(vcard.FieldNickname, c.NickName)
+	}
+
+	// EmailAddresses → EMAIL
+	for _, email := range c.EmailAddresses {
+		if email.Address != "" {
+			card.Add(vcard.FieldEmail, &vcard.Field{Value: email.Address, Params: vcard.Params{}})
+		}
+	}
+
+	// Phones → TEL
+	addPhone := func(number, typ string) {
+		if number != "" {
+			card.Add(vcard.FieldTelephone, &vcard.Field{Value: number, Params: vcard.Params{vcard.ParamType: {typ}}})
+		}
+	}
+	addPhone(c.MobilePhone, "cell")
+	for _, p := range c.BusinessPhones {
+		addPhone(p, "work")
+	}
+	for _, p := range c.HomePhones {
+		addPhone(p, "home")
+	}
+
+	// Company → ORG, TITLE
+	if c.CompanyName != "" || c.Department != "" {
+		org := c.CompanyName
+		if c.Department != "" {
+			org += ";" + c.Department
+		}
+		card.SetValue(vcard.FieldOrganization, org)
+	}
+	if c.JobTitle != "" {
+		card.SetValue(vcard.FieldTitle, c.JobTitle)
+	}
+
+	// Addresses → ADR
+	for i, addr := range []*graphAddress{c.BusinessAddress, c.HomeAddress, c.OtherAddress} {
+		if addr == nil || *addr == (graphAddress{}) {
+			continue
+		}
+		// ADR: PO Box;Extended;Street;City;Region;PostalCode;Country
+		card.Add(vcard.FieldAddress, &vcard.Field{
+			Value:  ";;" + addr.Street + ";" + addr.City + ";" + addr.State + ";" + addr.PostalCode + ";" + addr.CountryOrRegion,
+			Params: vcard.Params{vcard.ParamType: {graphAddressTypes[i]}},
+		})
+	}
+
+	// Birthday → BDAY
+	if c.Birthday != nil && !c.Birthday.IsZero() {
+		bday := c.Birthday.UTC()
+		if bday.Year() == providerutil.NoYear {
+			card.SetValue(vcard.FieldBirthday, bday.Format("--0102"))
+		} else {
+			card.SetValue(vcard.FieldBirthday, bday.Format("20060102"))
+		}
+	}
+
+	// PersonalNotes → NOTE
+	if c.PersonalNotes != "" {
+		card.SetValue(vcard.FieldNote, c.PersonalNotes)
+	}
+
+	// BusinessHomePage → URL
+	if c.BusinessHomePage != "" {
+		card.Add(vcard.FieldURL, &vcard.Field{Value: c.BusinessHomePage, Params: vcard.Params{vcard.ParamType: {"work"}}})
+	}
+
+	// ImAddresses → IMPP
+	for _, im := range c.ImAddresses {
+		if im != "" {
+			card.Add(vcard.FieldIMPP, &vcard.Field{Value: im})
+		}
+	}
+
+	// SpouseName, Children → RELATED
+	if c.SpouseName != "" {
+		card.Add(vcard.FieldRelated, &vcard.Field{Value: c.SpouseName, Params: vcard.Params{vcard.ParamType: {"spouse"}}})
+	}
+	for _, child := range c.Children {
+		if child != "" {
+			card.Add(vcard.FieldRelated, &vcard.Field{Value: child, Params: vcard.Params{vcard.ParamType: {"child"}}})
+		}
+	}
+
+	// Categories → CATEGORIES
+	if len(c.Categories) > 0 {
+		card.SetValue(vcard.FieldCategories, strings.Join(c.Categories, ","))
+	}
+
+	// Ensure FN is set (vCard requires it)
+	if contacts.CardFullName(card) == "" {
+		name := strings.TrimSpace(strings.Join(strings.Fields(c.GivenName+" "+c.MiddleName+" "+c.Surname), " "))
+		if name == "" && len(c.EmailAddresses) > 0 {
+			name = c.EmailAddresses[0].Address
+		}
+		if name == "" {
+			name = c.CompanyName
+		}
+		if name == "" {
+			name = providerutil.LocalUID(c.ID)
+		}
+		card.SetValue(vcard.FieldFormattedName, name)
+	}
+
+	return card
+}
+
+// --- Conversion: vcard.Card → Graph ---
+
+// convertCardToGraph builds the body of a create or update request. Every
+// field Graph has is included, empty when the card has no value for it,
+// so that an update clears what was removed locally.
+func convertCardToGraph(card vcard.Card) map[string]interface{} {
+	contact := map[string]interface{}{"displayName": contacts.CardFullName(card)}
+
+	// N → names
+	name := strings.Split(card.Value(vcard.FieldName), ";")
+	for len(name) < 5 {
+		name = append(name, "")
+	}
+	contact["surname"] = name[0]
+	contact["givenName"] = name[1]
+	contact["middleName"] = name[2]
+	contact["title"] = name[3]
+	contact["generation"] = name[4]
+	contact["nickName"] = card.Value(vcard.FieldNickname)
+
+	// EMAIL → emailAddresses
+	emails := []map[string]interface{}{}
+	for _, f := range card[vcard.FieldEmail] {
+		if len(emails) == graphMaxEmails {
+			break
+		}
+		emails = append(emails, map[string]interface{}{"address": f.Value, "name": contacts.CardFullName(card)})
+	}
+	contact["emailAddresses"] = emails
+
+	// TEL → mobilePhone, businessPhones, homePhones. Numbers of other
+	// types, and cell numbers beyond the first, go to homePhones.
+	mobile := ""
+	business, home := []string{}, []string{}
+	for _, f := range card[vcard.FieldTelephone] {
+		switch {
+		case mobile == "" && (f.Params.HasType("cell") || f.Params.HasType("mobile")):
+			mobile = f.Value
+		case f.Params.HasType("work"):
+			business = append(business, f.Value)
+		default:
+			home = append(home, f.Value)
+		}
+	}
+	contact["mobilePhone"] = mobile
+	contact["businessPhones"] = business
+	contact["homePhones"] = home
+
+	// ORG, TITLE → companyName, department, jobTitle
+	org := strings.SplitN(card.Value(vcard.FieldOrganization), ";", 2)
+	contact["companyName"] = org[0]
+	if len(org) > 1 {
+		contact["department"] = org[1]
+	} else {
+		contact["department"] = ""
+	}
+	contact["jobTitle"] = card.Value(vcard.FieldTitle)
+
+	// ADR → businessAddress, homeAddress, otherAddress. An address
+	// without a known TYPE takes the first of home and other still free.
+	addresses := map[string]map[string]interface{}{}
+	var untyped []*vcard.Field
+	for _, f := range card[vcard.FieldAddress] {
+		typed := false
+		for _, typ := range graphAddressTypes {
+			if f.Params.HasType(typ) && addresses[typ] == nil {
+				addresses[typ] = graphAddressFromADR(f.Value)
+				typed = true
+				break
+			}
+		}
+		if !typed {
+			untyped = append(untyped, f)
+		}
+	}
+	for _, f := range untyped {
+		for _, typ := range []string{"home", "other"} {
+			if addresses[typ] == nil {
+				addresses[typ] = graphAddressFromADR(f.Value)
+				break
+			}
+		}
+	}
+	for i, key := range []string{"businessAddress", "homeAddress", "otherAddress"} {
+		if addr := addresses[graphAddressTypes[i]]; addr != nil {
+			contact[key] = addr
+		} else {
+			contact[key] = map[string]interface{}{}
+		}
+	}
+
+	// BDAY → birthday
+	contact["birthday"] = nil
+	if bday := providerutil.ParseBirthday(card.Value(vcard.FieldBirthday)); !bday.IsZero() {
+		contact["birthday"] = bday.Format(time.RFC3339)
+	}
+
+	// NOTE → personalNotes
+	var notes []string
+	for _, f := range card[vcard.FieldNote] {
+		notes = append(notes, f.Value)
+	}
+	contact["personalNotes"] = strings.Join(notes, "\n")
+
+	// URL → businessHomePage
+	contact["businessHomePage"] = card.Value(vcard.FieldURL)
+
+	// IMPP → imAddresses
+	ims := []string{}
+	for _, f := range card[vcard.FieldIMPP] {
+		ims = append(ims, f.Value)
+	}
+	contact["imAddresses"] = ims
+
+	// RELATED → spouseName, children
+	spouse := ""
+	children := []string{}
+	for _, f := range card[vcard.FieldRelated] {
+		switch {
+		case spouse == "" && f.Params.HasType("spouse"):
+			spouse = f.Value
+		case f.Params.HasType("child"):
+			children = append(children, f.Value)
+		}
+	}
+	contact["spouseName"] = spouse
+	contact["children"] = children
+
+	// CATEGORIES → categories
+	categories := []string{}
+	for _, f := range card[vcard.FieldCategories] {
+		for _, c := range strings.Split(f.Value, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				categories = append(categories, c)
+			}
+		}
+	}
+	contact["categories"] = categories
+
+	return contact
+}
+
+// graphAddressFromADR converts an ADR value to a Graph physicalAddress.
+// The PO box and extended address are joined to the street.
+func graphAddressFromADR(value string) map[string]interface{} {
+	parts := strings.Split(value, ";")
+	for len(parts) < 7 {
+		parts = append(parts, "")
+	}
+	var street []string
+	for _, p := range []string{parts[0], parts[1], parts[2]} {
+		if p = strings.TrimSpace(p); p != "" {
+			street = append(street, p)
+		}
+	}
+	return map[string]interface{}{
+		"street":          strings.Join(street, "\n"),
+		"city":            parts[3],
+		"state":           parts[4],
+		"postalCode":      parts[5],
+		"countryOrRegion": parts[6],
+	}
+}
+
+// --- Provider methods ---
+
+// client returns an HTTP client authorized with the current token,
+// refreshing it first if it has expired.
+func (m *Provider) client() (*http.Client, error) {
+	if m.config == nil || m.token == nil {
+		return nil, contacts.ErrNotInitialized
+	}
+	if err := m.refreshToken(); err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(context.Background(), m.tokenSource()), nil
+}
+
+func (m *Provider) FetchContacts() ([]vcard.Card, error) {
+	items, _, err := m.listContacts(graphURL + "/me/contacts?$top=500")
+	if err != nil {
+		return nil, err
+	}
+	var cards []vcard.Card
+	for _, c := range items {
+		cards = append(cards, convertGraphToCard(c))
+	}
+	return cards, nil
+}
+
+// FetchChanges returns the contacts changed or deleted in Outlook since
+// the last committed sync, using a delta query. Without a delta link, or
+// if Graph has expired it, every contact is returned as changed.
+func (m *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	start := m.deltaLink
+	if start == "" {
+		start = graphURL + "/me/contacts/delta"
+	}
+	items, next, err := m.listContacts(start)
+	if errors.Is(err, errDeltaExpired) {
+		items, next, err = m.listContacts(graphURL + "/me/contacts/delta")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range items {
+		if c.Removed != nil {
+			deleted = append(deleted, c.ID)
+			continue
+		}
+		changed = append(changed, convertGraphToCard(c))
+	}
+	m.pendingDeltaLink = next
+	return changed, deleted, nil
+}
+
+// CommitSync saves the delta link from the last FetchChanges, once its
+// changes have been stored locally.
+func (m *Provider) CommitSync() error {
+	if m.pendingDeltaLink == "" {
+		return nil
+	}
+	if err := m.SaveDeltaLink(m.pendingDeltaLink); err != nil {
+		return fmt.Errorf("failed to save delta link: %w", err)
+	}
+	m.pendingDeltaLink = ""
+	return nil
+}
+
+// errDeltaExpired is returned by listContacts when Graph no longer accepts
+// the delta link and a full sync is needed.
+var errDeltaExpired = errors.New("delta link expired")
+
+// listContacts pages through a contacts listing or delta query starting at
+// pageURL. For a delta query the delta link for the next sync is returned.
+func (m *Provider) listContacts(pageURL string) ([]graphContact, string, error) {
+	httpClient, err := m.client()
+	if err != nil {
+		return nil, "", err
+	}
+	var items []graphContact
+	for pageURL != "" {
+		resp, err := httpClient.Get(pageURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: failed to fetch contacts: %w", contacts.ErrProviderUnavailable, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusGone && strings.Contains(pageURL, "/delta") {
+			return nil, "", errDeltaExpired
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("Graph API request failed with status %d: %s", resp.StatusCode, string(body)))
+		}
+		var result struct {
+			Value     []graphContact `json:"value"`
+			NextLink  string         `json:"@odata.nextLink"`
+			DeltaLink string         `json:"@odata.deltaLink"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, "", fmt.Errorf("failed to decode Graph API response: %w", err)
+		}
+		items = append(items, result.Value...)
+		if result.DeltaLink != "" {
+			return items, result.DeltaLink, nil
+		}
+		pageURL = result.NextLink
+	}
+	return items, "", nil
+}
+
+func (m *Provider) WriteContact(card vcard.Card) error {
+	httpClient, err := m.client()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(convertCardToGraph(card))
+	if err != nil {
+		return fmt.Errorf("failed to encode contact %s: %w", contacts.CardFullName(card), err)
+	}
+	id := contacts.ProviderID(card)
+	var req *http.Request
+	if id != "" {
+		req, err = http.NewRequest("PATCH", graphURL+"/me/contacts/"+url.PathEscape(id), strings.NewReader(string(body)))
+		if err == nil {
+			if etag := card.Value(FieldETag); etag != "" {
+				req.Header.Set("If-Match", etag)
+			}
+		}
+	} else {
+		req, err = http.NewRequest("POST", graphURL+"/me/contacts", strings.NewReader(string(body)))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create request for contact %s: %w", contacts.CardFullName(card), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update contact %s: %w", contacts.ErrProviderUnavailable, contacts.CardFullName(card), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to update contact %s (status %d): %s", contacts.CardFullName(card), resp.StatusCode, string(body)))
+	}
+	var saved graphContact
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil || saved.ID == "" {
+		return nil
+	}
+	if id == "" {
+		// Adopt the ID Graph assigned so the next sync recognizes the
+		// contact instead of duplicating it.
+		card.SetValue(vcard.FieldUID, providerutil.LocalUID(saved.ID))
+		card.SetValue(contacts.FieldProviderID, saved.ID)
+	}
+	if saved.ETag != "" {
+		card.SetValue(FieldETag, saved.ETag)
+	}
+	return nil
+}
+
+func (m *Provider) DeleteContact(id string) error {
+	httpClient, err := m.client()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("DELETE", graphURL+"/me/contacts/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create delete request for contact %s: %w", id, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: failed to delete contact %s: %w", contacts.ErrProviderUnavailable, id, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return errors.Join(providerutil.StatusError(resp.StatusCode), fmt.Errorf("failed to delete contact %s (status %d): %s", id, resp.StatusCode, string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package microsoft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+	"golang.org/x/oauth2"
+)
+
+func TestConvertGraphToCard(t *testing.T) {
+	bday := time.Date(1990, 3, 15, 0, 0, 0, 0, time.UTC)
+	c := graphContact{
+		ID:             "AAMk+abc/def=",
+		ETag:           `W/"CQAAABYAAAA"`,
+		DisplayName:    "Ada Lovelace",
+		GivenName:      "Ada",
+		Surname:        "Lovelace",
+		Title:          "Countess",
+		NickName:       "Ada",
+		EmailAddresses: []graphEmail{{Name: "Ada", Address: "ada@example.com"}, {Address: "ada@work.example"}},
+		MobilePhone:    "+1 555 0100",
+		BusinessPhones: []string{"+1 555 0200"},
+		HomePhones:     []string{"+1 555 0300"},
+		CompanyName:    "Analytical Engines",
+		Department:     "Research",
+		JobTitle:       "Mathematician",
+		HomeAddress:    &graphAddress{Street: "12 St James's Sq", City: "London", PostalCode: "SW1Y", CountryOrRegion: "UK"},
+		Birthday:       &bday,
+		PersonalNotes:  "First programmer",
+		SpouseName:     "William King",
+		Children:       []string{"Byron", "Anne"},
+		Categories:     []string{"Friends", "Science"},
+	}
+	card := convertGraphToCard(c)
+
+	if got := card.Value(vcard.FieldUID); got != providerutil.LocalUID(c.ID) {
+		t.Errorf("UID = %q, want the ID hashed", got)
+	}
+	if got := contacts.ProviderID(card); got != c.ID {
+		t.Errorf("provider ID = %q, want %q", got, c.ID)
+	}
+	if got := card.Value(FieldETag); got != c.ETag {
+		t.Errorf("etag = %q, want %q", got, c.ETag)
+	}
+	checks := map[string]string{
+		vcard.FieldFormattedName: "Ada Lovelace",
+		vcard.FieldName:          "Lovelace;Ada;;Countess;",
+		vcard.FieldNickname:      "Ada",
+		vcard.FieldOrganization:  "Analytical Engines;Research",
+		vcard.FieldTitle:         "Mathematician",
+		vcard.FieldBirthday:      "19900315",
+		vcard.FieldNote:          "First programmer",
+		vcard.FieldCategories:    "Friends,Science",
+	}
+	for field, want := range checks {
+		if got := card.Value(field); got != want {
+			t.Errorf("%s = %q, want %q", field, got, want)
+		}
+	}
+	if n := len(card[vcard.FieldEmail]); n != 2 {
+		t.Errorf("got %d emails, want 2", n)
+	}
+	tels := card[vcard.FieldTelephone]
+	if len(tels) != 3 || !tels[0].Params.HasType("cell") || !tels[1].Params.HasType("work") || !tels[2].Params.HasType("home") {
+		t.Errorf("unexpected phones: %+v", tels)
+	}
+	adr := card.Get(vcard.FieldAddress)
+	if adr == nil || adr.Value != ";;12 St James's Sq;London;;SW1Y;UK" || !adr.Params.HasType("home") {
+		t.Errorf("unexpected address: %+v", adr)
+	}
+	if n := len(card[vcard.FieldRelated]); n != 3 {
+		t.Errorf("got %d related, want 3", n)
+	}
+}
+
+func TestConvertGraphToCard_Fallbacks(t *testing.T) {
+	bday := time.Date(providerutil.NoYear, 7, 4, 0, 0, 0, 0, time.UTC)
+	card := convertGraphToCard(graphContact{ID: "x", EmailAddresses: []graphEmail{{Address: "anon@example.com"}}, Birthday: &bday, HomeAddress: &graphAddress{}})
+	if got := contacts.CardFullName(card); got != "anon@example.com" {
+		t.Errorf("FN = %q, want email fallback", got)
+	}
+	if got := card.Value(vcard.FieldBirthday); got != "--0704" {
+		t.Errorf("BDAY = %q, want --0704", got)
+	}
+	if card.Get(vcard.FieldAddress) != nil {
+		t.Error("empty address should be skipped")
+	}
+}
+
+func TestConvertGraphRoundTrip(t *testing.T) {
+	bday := time.Date(1990, 3, 15, 0, 0, 0, 0, time.UTC)
+	orig := graphContact{
+		ID:               "id1",
+		DisplayName:      "Grace Hopper",
+		GivenName:        "Grace",
+		MiddleName:       "Brewster",
+		Surname:          "Hopper",
+		EmailAddresses:   []graphEmail{{Name: "Grace Hopper", Address: "grace@navy.example"}},
+		MobilePhone:      "555-0100",
+		BusinessPhones:   []string{"555-0200"},
+		HomePhones:       []string{},
+		CompanyName:      "US Navy",
+		JobTitle:         "Rear Admiral",
+		BusinessAddress:  &graphAddress{Street: "1 Navy Way", City: "Arlington", State: "VA", PostalCode: "22202", CountryOrRegion: "US"},
+		Birthday:         &bday,
+		PersonalNotes:    "COBOL",
+		BusinessHomePage: "https://navy.example",
+		ImAddresses:      []string{"grace@im.example"},
+		Children:         []string{},
+		Categories:       []string{"Heroes"},
+	}
+	body, err := json.Marshal(convertCardToGraph(convertGraphToCard(orig)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got graphContact
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	got.ID = orig.ID
+	if fmt.Sprintf("%+v", got.BusinessAddress) != fmt.Sprintf("%+v", orig.BusinessAddress) {
+		t.Errorf("businessAddress = %+v, want %+v", got.BusinessAddress, orig.BusinessAddress)
+	}
+	if got.Birthday == nil || !got.Birthday.Equal(bday) {
+		t.Errorf("birthday = %v, want %v", got.Birthday, bday)
+	}
+	got.BusinessAddress, orig.BusinessAddress = nil, nil
+	got.Birthday, orig.Birthday = nil, nil
+	got.HomeAddress, got.OtherAddress = nil, nil
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(orig)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("round trip mismatch:\n got %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+func TestConvertCardToGraph_ClearsFields(t *testing.T) {
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldFormattedName, "Solo")
+	for _, e := range []string{"a@x", "b@x", "c@x", "d@x"} {
+		card.AddValue(vcard.FieldEmail, e)
+	}
+	got := convertCardToGraph(card)
+	if emails := got["emailAddresses"].([]map[string]interface{}); len(emails) != graphMaxEmails {
+		t.Errorf("got %d emails, want %d", len(emails), graphMaxEmails)
+	}
+	for _, key := range []string{"jobTitle", "companyName", "personalNotes", "mobilePhone"} {
+		if v, ok := got[key]; !ok || v != "" {
+			t.Errorf("%s = %v, want empty string so updates clear it", key, v)
+		}
+	}
+	if v, ok := got["birthday"]; !ok || v != nil {
+		t.Errorf("birthday = %v, want null", v)
+	}
+}
+
+// fakeGraph serves /me/contacts and its delta query from a fixed set of
+// contacts.
+type fakeGraph struct {
+	contacts map[string]graphContact
+	// expireDelta makes the next request with a delta token fail with 410.
+	expireDelta bool
+	lastIfMatch string
+}
+
+func (f *fakeGraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	base := "http://" + r.Host
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/me/contacts/delta":
+		if r.URL.Query().Get("$deltatoken") != "" {
+			if f.expireDelta {
+				f.expireDelta = false
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"value":            []graphContact{{ID: "gone", Removed: &graphRemoved{Reason: "deleted"}}},
+				"@odata.deltaLink": base + "/me/contacts/delta?$deltatoken=2",
+			})
+			return
+		}
+		if r.URL.Query().Get("$skiptoken") == "" {
+			json.NewEncoder(w).Encode(map[string]any{
+				"value":           []graphContact{f.contacts["c1"]},
+				"@odata.nextLink": base + "/me/contacts/delta?$skiptoken=1",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"value":            []graphContact{f.contacts["c2"]},
+			"@odata.deltaLink": base + "/me/contacts/delta?$deltatoken=1",
+		})
+	case r.Method == "GET" && r.URL.Path == "/me/contacts":
+		var all []graphContact
+		for _, c := range f.contacts {
+			all = append(all, c)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"value": all})
+	case r.Method == "POST" && r.URL.Path == "/me/contacts":
+		var c graphContact
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &c)
+		c.ID, c.ETag = "new/id", `W/"1"`
+		f.contacts[c.ID] = c
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	case r.Method == "PATCH" && strings.HasPrefix(r.URL.Path, "/me/contacts/"):
+		f.lastIfMatch = r.Header.Get("If-Match")
+		if f.lastIfMatch == `W/"stale"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/me/contacts/")
+		c := f.contacts[id]
+		c.ETag = `W/"2"`
+		json.NewEncoder(w).Encode(c)
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/me/contacts/"):
+		id := strings.TrimPrefix(r.URL.Path, "/me/contacts/")
+		if _, ok := f.contacts[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.contacts, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// newTestProvider returns a provider talking to f with a token that
+// doesn't need refreshing.
+func newTestProvider(t *testing.T, f *fakeGraph) *Provider {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	old := graphURL
+	graphURL = srv.URL
+	t.Cleanup(func() { graphURL = old })
+
+	m, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SaveCredentials(&Credentials{ClientID: "id", RefreshToken: "refresh", AccessToken: "access", Expiry: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	m.config.Endpoint = oauth2.Endpoint{TokenURL: srv.URL + "/token"}
+	return m
+}
+
+func TestProvider_FetchChanges(t *testing.T) {
+	f := &fakeGraph{contacts: map[string]graphContact{
+		"c1": {ID: "c1", DisplayName: "One"},
+		"c2": {ID: "c2", DisplayName: "Two"},
+	}}
+	m := newTestProvider(t, f)
+
+	changed, deleted, err := m.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("first sync: got %d changed, %d deleted; want 2, 0", len(changed), len(deleted))
+	}
+	if m.deltaLink != "" {
+		t.Error("delta link saved before CommitSync")
+	}
+	if err := m.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(m.deltaLink, "$deltatoken=1") {
+		t.Errorf("delta link = %q", m.deltaLink)
+	}
+
+	changed, deleted, err = m.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 || len(deleted) != 1 || deleted[0] != "gone" {
+		t.Errorf("incremental sync: got %d changed, deleted %v", len(changed), deleted)
+	}
+
+	// An expired delta link falls back to a full delta query.
+	f.expireDelta = true
+	changed, _, err = m.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("after expiry: got %d changed, want 2", len(changed))
+	}
+}
+
+func TestProvider_FetchContacts(t *testing.T) {
+	f := &fakeGraph{contacts: map[string]graphContact{"c1": {ID: "c1", DisplayName: "One"}}}
+	m := newTestProvider(t, f)
+	cards, err := m.FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || contacts.CardFullName(cards[0]) != "One" {
+		t.Errorf("got %v", cards)
+	}
+}
+
+func TestProvider_WriteAndDelete(t *testing.T) {
+	f := &fakeGraph{contacts: map[string]graphContact{}}
+	m := newTestProvider(t, f)
+
+	card := make(vcard.Card)
+	card.SetValue(vcard.FieldUID, "local-uid")
+	card.SetValue(vcard.FieldFormattedName, "New Person")
+	if err := m.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if got := contacts.ProviderID(card); got != "new/id" {
+		t.Errorf("provider ID = %q, want new/id", got)
+	}
+	if got := card.Value(vcard.FieldUID); got != providerutil.LocalUID("new/id") {
+		t.Errorf("UID = %q, want new/id hashed", got)
+	}
+
+	if err := m.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if f.lastIfMatch != `W/"1"` {
+		t.Errorf("If-Match = %q, want the stored etag", f.lastIfMatch)
+	}
+	if got := card.Value(FieldETag); got != `W/"2"` {
+		t.Errorf("etag = %q, want updated", got)
+	}
+
+	card.SetValue(FieldETag, `W/"stale"`)
+	if err := m.WriteContact(card); !errors.Is(err, contacts.ErrConflict) {
+		t.Errorf("stale write: got %v, want ErrConflict", err)
+	}
+
+	if err := m.DeleteContact("new/id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.DeleteContact("new/id"); !errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("second delete: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,144 @@
+package proton
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+)
+
+// apiURL is the Proton API root.
+var apiURL = "https://mail.proton.me/api"
+
+// appVersion identifies the client to the API, which refuses requests
+// without one.
+const appVersion = "Other"
+
+// codeOK and codeMulti are the API's success codes, for single results
+// and for batches whose items carry codes of their own.
+const (
+	codeOK    = 1000
+	codeMulti = 1001
+)
+
+// apiError is an error response from the API.
+type apiError struct {
+	Status  int
+	Code    int    `json:"Code"`
+	Message string `json:"Error"`
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("Proton API request failed with status %d", e.Status)
+	}
+	return fmt.Sprintf("Proton API request failed with status %d: %s (code %d)", e.Status, e.Message, e.Code)
+}
+
+// Unwrap maps the error to the contacts sentinels.
+func (e *apiError) Unwrap() error {
+	if err := codeError(e.Code); err != nil {
+		return err
+	}
+	return providerutil.StatusError(e.Status)
+}
+
+// session is the login the API's requests are made in.
+type session struct {
+	UID          string
+	AccessToken  string
+	RefreshToken string
+}
+
+// call sends a JSON request to the API and decodes the response into out.
+// An expired access token is refreshed once and the request retried.
+func (p *Provider) call(method, path string, in, out any) error {
+	err := p.send(method, path, in, out)
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized || p.creds.Cached() == nil || p.creds.Cached().RefreshToken == "" {
+		return err
+	}
+	if err := p.refresh(); err != nil {
+		return err
+	}
+	return p.send(method, path, in, out)
+}
+
+// send makes one API request in the stored session, if any.
+func (p *Provider) send(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, apiURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-pm-appversion", appVersion)
+	req.Header.Set("Accept", "application/vnd.protonmail.v1+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.creds.Cached() != nil && p.creds.Cached().UID != "" {
+		req.Header.Set("x-pm-uid", p.creds.Cached().UID)
+		req.Header.Set("Authorization", "Bearer "+p.creds.Cached().AccessToken)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", contacts.ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read response: %w", contacts.ErrProviderUnavailable, err)
+	}
+	apiErr := &apiError{Status: resp.StatusCode}
+	_ = json.Unmarshal(data, apiErr)
+	if resp.StatusCode != http.StatusOK || apiErr.Code != codeOK && apiErr.Code != codeMulti {
+		if apiErr.Message == "" && len(data) > 0 && apiErr.Code == 0 {
+			apiErr.Message = string(data)
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode Proton API response: %w", err)
+	}
+	return nil
+}
+
+// refresh trades the refresh token for a new access token and saves both.
+func (p *Provider) refresh() error {
+	req := map[string]string{
+		"UID":          p.creds.Cached().UID,
+		"RefreshToken": p.creds.Cached().RefreshToken,
+		"ResponseType": "token",
+		"GrantType":    "refresh_token",
+		"RedirectURI":  "https://protonmail.ch",
+	}
+	var resp session
+	if err := p.send("POST", "/auth/v4/refresh", req, &resp); err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Status < 500 && apiErr.Status != http.StatusTooManyRequests {
+			return fmt.Errorf("%w: the Proton session has ended: please run init again: %w", contacts.ErrAuthExpired, err)
+		}
+		return fmt.Errorf("failed to refresh the Proton session: %w", err)
+	}
+	creds := *p.creds.Cached()
+	creds.AccessToken, creds.RefreshToken = resp.AccessToken, resp.RefreshToken
+	if resp.UID != "" {
+		creds.UID = resp.UID
+	}
+	return p.SaveCredentials(&creds)
+}
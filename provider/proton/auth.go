@@ -0,0 +1,239 @@
+package proton
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/arjungandhi/contacts"
+)
+
+// twoFactorTOTP is the bit of an account's 2FA status for an
+// authenticator app.
+const twoFactorTOTP = 1
+
+// passwordModeTwo marks accounts with a mailbox password apart from the
+// login password.
+const passwordModeTwo = 2
+
+type authInfo struct {
+	Version         int
+	Modulus         string
+	ServerEphemeral string
+	Salt            string
+	SRPSession      string
+}
+
+type authResponse struct {
+	session
+	ServerProof string
+	TwoFA       struct {
+		Enabled int
+	} `json:"2FA"`
+	PasswordMode int
+}
+
+// Login signs in to the account with SRP and saves the session. If the
+// account has two-factor authentication, twoFactorCode is called for a
+// code from the authenticator app. It reports whether the account has a
+// separate mailbox password, which Unlock needs instead of the login
+// password. Signing in as another user resets the sync state.
+func (p *Provider) Login(username, password string, twoFactorCode func() (string, error)) (twoPasswords bool, err error) {
+	p.creds.SetCached(nil)
+	var info authInfo
+	if err := p.send("POST", "/auth/v4/info", map[string]string{"Username": username}, &info); err != nil {
+		return false, fmt.Errorf("failed to start sign-in: %w", err)
+	}
+	modulus, err := readModulus(info.Modulus)
+	if err != nil {
+		return false, err
+	}
+	hashed, err := hashPassword(info.Version, password, info.Salt, modulus)
+	if err != nil {
+		return false, err
+	}
+	serverEphemeral, err := base64.StdEncoding.DecodeString(info.ServerEphemeral)
+	if err != nil {
+		return false, fmt.Errorf("invalid SRP server ephemeral: %w", err)
+	}
+	proofs, err := generateProofs(modulus, serverEphemeral, hashed)
+	if err != nil {
+		return false, err
+	}
+	var auth authResponse
+	err = p.send("POST", "/auth/v4", map[string]string{
+		"Username":        username,
+		"ClientEphemeral": base64.StdEncoding.EncodeToString(proofs.clientEphemeral),
+		"ClientProof":     base64.StdEncoding.EncodeToString(proofs.clientProof),
+		"SRPSession":      info.SRPSession,
+	}, &auth)
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Status == 422 {
+			return false, fmt.Errorf("%w: Proton rejected the sign-in; check the username and password: %w", contacts.ErrAuthExpired, err)
+		}
+		return false, fmt.Errorf("failed to sign in: %w", err)
+	}
+	serverProof, err := base64.StdEncoding.DecodeString(auth.ServerProof)
+	if err != nil || subtle.ConstantTimeCompare(serverProof, proofs.serverProof) != 1 {
+		return false, errors.New("the server's SRP proof is invalid: it may not be Proton")
+	}
+
+	if old, _ := p.LoadCredentials(); old == nil || old.Username != username {
+		p.state = nil
+		if err := os.Remove(p.statePath); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to reset sync state: %w", err)
+		}
+	}
+	// Keep the session in memory only until the 2FA step and Unlock are
+	// through, so a half-done login doesn't replace a working one.
+	p.creds.SetCached(&Credentials{Username: username, UID: auth.UID, AccessToken: auth.AccessToken, RefreshToken: auth.RefreshToken})
+	p.keyRing = nil
+	if auth.TwoFA.Enabled != 0 {
+		if auth.TwoFA.Enabled&twoFactorTOTP == 0 {
+			return false, errors.New("the account only offers security keys for two-factor authentication, which aren't supported")
+		}
+		code, err := twoFactorCode()
+		if err != nil {
+			return false, err
+		}
+		if err := p.send("POST", "/auth/v4/2fa", map[string]string{"TwoFactorCode": code}, nil); err != nil {
+			return false, fmt.Errorf("failed to verify the two-factor code: %w", err)
+		}
+	}
+	return auth.PasswordMode == passwordModeTwo, nil
+}
+
+type userKey struct {
+	ID         string
+	PrivateKey string
+	Primary    int
+}
+
+// userKeys returns the account's keys, primary first.
+func (p *Provider) userKeys() ([]userKey, error) {
+	var resp struct {
+		User struct {
+			Keys []userKey
+		}
+	}
+	if err := p.call("GET", "/core/v4/users", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch the account's keys: %w", err)
+	}
+	keys := resp.User.Keys
+	for i, key := range keys {
+		if key.Primary == 1 {
+			keys[0], keys[i] = keys[i], keys[0]
+		}
+	}
+	return keys, nil
+}
+
+// Unlock derives the passphrases of the account's keys from the mailbox
+// password, which is the login password unless Login reported a separate
+// one, and saves them with the session. The password itself isn't
+// stored. It returns the number of contacts in the account.
+func (p *Provider) Unlock(mailboxPassword string) (int, error) {
+	if p.creds.Cached() == nil {
+		return 0, fmt.Errorf("%w: not signed in", contacts.ErrNotInitialized)
+	}
+	keys, err := p.userKeys()
+	if err != nil {
+		return 0, err
+	}
+	var salts struct {
+		KeySalts []struct {
+			ID      string
+			KeySalt string
+		}
+	}
+	if err := p.call("GET", "/core/v4/keys/salts", nil, &salts); err != nil {
+		return 0, fmt.Errorf("failed to fetch key salts: %w", err)
+	}
+	passphrases := map[string]string{}
+	for _, salt := range salts.KeySalts {
+		if salt.KeySalt == "" {
+			continue
+		}
+		passphrase, err := keyPassphrase(mailboxPassword, salt.KeySalt)
+		if err != nil {
+			return 0, err
+		}
+		passphrases[salt.ID] = passphrase
+	}
+	p.creds.Cached().KeyPassphrases = passphrases
+	kr, err := unlockKeys(keys, passphrases)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.SaveCredentials(p.creds.Cached()); err != nil {
+		return 0, err
+	}
+	p.keyRing = kr
+	_, total, err := p.listPage(0, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	return total, nil
+}
+
+// unlockKeys returns a key ring of the keys that unlock with their
+// passphrases, primary first. The primary key has to unlock, as it signs
+// and encrypts the contacts written.
+func unlockKeys(keys []userKey, passphrases map[string]string) (*crypto.KeyRing, error) {
+	var kr *crypto.KeyRing
+	for i, k := range keys {
+		key, err := crypto.NewKeyFromArmored(k.PrivateKey)
+		if err == nil {
+			key, err = key.Unlock([]byte(passphrases[k.ID]))
+		}
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("%w: failed to unlock the account's key: check the mailbox password", contacts.ErrAuthExpired)
+			}
+			continue
+		}
+		if kr == nil {
+			if kr, err = crypto.NewKeyRing(key); err != nil {
+				return nil, err
+			}
+		} else if err := kr.AddKey(key); err != nil {
+			return nil, err
+		}
+	}
+	if kr == nil {
+		return nil, errors.New("the account has no keys")
+	}
+	return kr, nil
+}
+
+// keys returns the unlocked key ring, fetching the keys on first use.
+func (p *Provider) keys() (*crypto.KeyRing, error) {
+	if p.keyRing != nil {
+		return p.keyRing, nil
+	}
+	if p.creds.Cached() == nil {
+		return nil, contacts.ErrNotInitialized
+	}
+	keys, err := p.userKeys()
+	if err != nil {
+		return nil, err
+	}
+	if p.keyRing, err = unlockKeys(keys, p.creds.Cached().KeyPassphrases); err != nil {
+		return nil, err
+	}
+	return p.keyRing, nil
+}
+
+// signer returns the primary key alone, which new cards are signed with
+// and encrypted to.
+func (p *Provider) signer() (*crypto.KeyRing, error) {
+	kr, err := p.keys()
+	if err != nil {
+		return nil, err
+	}
+	return kr.FirstKey()
+}
@@ -0,0 +1,173 @@
+package proton
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+// Proton stores each contact as up to three vCards, told apart by type
+// bits: a clear one, one signed by the account's key and one encrypted
+// to it and signed. The servers can only read the first two, and do so
+// to offer the contact's addresses and groups when writing mail.
+const (
+	cardClear           = 0
+	cardEncrypted       = 1
+	cardSigned          = 2
+	cardEncryptedSigned = cardEncrypted | cardSigned
+)
+
+// FieldUID holds the UID of the contact's vCard at Proton. The local UID
+// is derived from the contact's ID instead, like other providers', and
+// this one is put back on upload.
+const FieldUID = "X-PROTON-UID"
+
+type protonCard struct {
+	Type      int
+	Data      string
+	Signature string
+}
+
+// signedFields go in the signed card, with the email settings (see
+// signedField); groups go in the clear card; everything else is
+// encrypted.
+var signedFields = map[string]bool{
+	vcard.FieldFormattedName: true,
+	vcard.FieldUID:           true,
+	vcard.FieldEmail:         true,
+}
+
+// signedField reports whether a field belongs in the signed card: the
+// name, UID and email addresses, and the keys and X-PM- settings Proton
+// groups with an address for sending it encrypted mail.
+func signedField(name string, f *vcard.Field, emailGroups map[string]bool) bool {
+	if signedFields[name] {
+		return true
+	}
+	return f.Group != "" && emailGroups[f.Group] && (name == vcard.FieldKey || strings.HasPrefix(name, "X-PM-"))
+}
+
+// localFields only make sense in the local store and aren't uploaded.
+var localFields = map[string]bool{
+	contacts.FieldProviderID: true,
+	FieldUID:                 true,
+	"X-LAST-SYNCED":          true,
+}
+
+// decodeCards verifies and decrypts a contact's cards and merges them
+// into one vCard.
+func decodeCards(id string, cards []protonCard, kr *crypto.KeyRing) (vcard.Card, error) {
+	out := make(vcard.Card)
+	for _, c := range cards {
+		data := c.Data
+		if c.Type&cardEncrypted != 0 {
+			msg, err := crypto.NewPGPMessageFromArmored(c.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read contact %s: %w", id, err)
+			}
+			plain, err := kr.Decrypt(msg, nil, 0)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt contact %s: %w", id, err)
+			}
+			data = string(plain.GetBinary())
+		}
+		if c.Type&cardSigned != 0 {
+			sig, err := crypto.NewPGPSignatureFromArmored(c.Signature)
+			if err == nil {
+				err = kr.VerifyDetached(crypto.NewPlainMessage([]byte(data)), sig, 0)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("contact %s has an invalid signature: %w", id, err)
+			}
+		}
+		part, err := contacts.DecodeCard([]byte(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse contact %s: %w", id, err)
+		}
+		for name, fields := range part {
+			if name != vcard.FieldVersion {
+				out[name] = append(out[name], fields...)
+			}
+		}
+	}
+	out.SetValue(vcard.FieldVersion, "4.0")
+	if uid := out.Value(vcard.FieldUID); uid != "" {
+		out.SetValue(FieldUID, uid)
+	}
+	out.SetValue(vcard.FieldUID, providerutil.LocalUID(id))
+	out.SetValue(contacts.FieldProviderID, id)
+	return out, nil
+}
+
+// encodeCards splits a card into the clear, signed and encrypted cards
+// Proton stores, signing and encrypting with kr's first key.
+func encodeCards(card vcard.Card, kr *crypto.KeyRing) ([]protonCard, error) {
+	plain, signed, encrypted := make(vcard.Card), make(vcard.Card), make(vcard.Card)
+	emailGroups := map[string]bool{}
+	for _, f := range card[vcard.FieldEmail] {
+		if f.Group != "" {
+			emailGroups[f.Group] = true
+		}
+	}
+	for name, fields := range card {
+		if localFields[name] || name == vcard.FieldVersion {
+			continue
+		}
+		for _, f := range fields {
+			switch {
+			case name == vcard.FieldCategories:
+				plain.Add(name, f)
+			case signedField(name, f, emailGroups):
+				signed.Add(name, f)
+			default:
+				encrypted.Add(name, f)
+			}
+		}
+	}
+	if uid := card.Value(FieldUID); uid != "" {
+		signed.SetValue(vcard.FieldUID, uid)
+	}
+	if signed.Value(vcard.FieldFormattedName) == "" {
+		signed.SetValue(vcard.FieldFormattedName, contacts.CardFullName(card))
+	}
+
+	var out []protonCard
+	for _, part := range []struct {
+		typ  int
+		card vcard.Card
+	}{{cardClear, plain}, {cardSigned, signed}, {cardEncryptedSigned, encrypted}} {
+		if len(part.card) == 0 && part.typ != cardSigned {
+			continue
+		}
+		part.card.SetValue(vcard.FieldVersion, "4.0")
+		data, err := contacts.EncodeCard(part.card)
+		if err != nil {
+			return nil, err
+		}
+		c := protonCard{Type: part.typ, Data: string(data)}
+		if part.typ&cardSigned != 0 {
+			sig, err := kr.SignDetached(crypto.NewPlainMessage(data))
+			if err == nil {
+				c.Signature, err = sig.GetArmored()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to sign contact: %w", err)
+			}
+		}
+		if part.typ&cardEncrypted != 0 {
+			msg, err := kr.Encrypt(crypto.NewPlainMessage(data), nil)
+			if err == nil {
+				c.Data, err = msg.GetArmored()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to encrypt contact: %w", err)
+			}
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
@@ -0,0 +1,17 @@
+package proton
+
+import (
+	"github.com/arjungandhi/contacts"
+)
+
+// codeError maps a Proton API error code to one of the sentinel errors,
+// or nil if the code has no specific meaning.
+func codeError(code int) error {
+	switch code {
+	case 2501: // the contact doesn't exist
+		return contacts.ErrNotFound
+	case 9001: // human verification required
+		return contacts.ErrAuthExpired
+	}
+	return nil
+}
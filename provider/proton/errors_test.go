@@ -0,0 +1,34 @@
+package proton
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+)
+
+func TestAPIError(t *testing.T) {
+	// Proton reports a missing contact as 422 with its own code.
+	err := error(&apiError{Status: http.StatusUnprocessableEntity, Code: 2501, Message: "Contact does not exist"})
+	if !errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("code 2501: got %v, want ErrNotFound", err)
+	}
+	err = &apiError{Status: http.StatusUnprocessableEntity, Code: 2000, Message: "Invalid input"}
+	if errors.Is(err, contacts.ErrNotFound) || errors.Is(err, contacts.ErrProviderUnavailable) {
+		t.Errorf("code 2000 mapped to a sentinel: %v", err)
+	}
+}
+
+func TestProvider_ErrNotInitialized(t *testing.T) {
+	p, err := NewProvider(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("Initialize: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := p.FetchContacts(); !errors.Is(err, contacts.ErrNotInitialized) {
+		t.Errorf("FetchContacts: got %v, want ErrNotInitialized", err)
+	}
+}
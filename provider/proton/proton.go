@@ -0,0 +1,326 @@
+// Package proton implements a contacts.ContactProvider backed by Proton
+// Contacts, the address book of a Proton Mail account.
+//
+// Proton keeps contacts end-to-end encrypted: each is stored as vCards
+// signed, and mostly encrypted, with the account's OpenPGP key (see
+// card.go). The provider signs in with the password, as Proton's own apps
+// do, and stores the session and the passphrases of the account's keys,
+// not the password; cards are decrypted and verified on fetch and signed
+// and encrypted again on write.
+package proton
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+var _ contacts.IncrementalProvider = (*Provider)(nil)
+
+// pageSize is how many contacts are listed or exported at a time.
+var pageSize = 100
+
+// Credentials are the session and key passphrases stored in
+// proton_creds.json.
+type Credentials struct {
+	Username     string `json:"username"`
+	UID          string `json:"uid"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// KeyPassphrases unlock the account's keys, by key ID. They are
+	// derived from the mailbox password, which can't be recovered from
+	// them.
+	KeyPassphrases map[string]string `json:"key_passphrases"`
+}
+
+// Provider syncs the contacts of a Proton account.
+type Provider struct {
+	client *http.Client
+	creds  providerutil.CredentialsFile[Credentials]
+	// keyRing holds the account's unlocked keys, primary first.
+	keyRing *crypto.KeyRing
+	// state maps the ID of each contact last synced to its modification
+	// time, so FetchChanges can tell what changed.
+	state     map[string]int64
+	statePath string
+	// pendingState is saved by CommitSync.
+	pendingState map[string]int64
+}
+
+func NewProvider(dir string) (*Provider, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	return &Provider{
+		client:    &http.Client{Timeout: time.Minute},
+		creds:     providerutil.NewCredentialsFile[Credentials](filepath.Join(dir, "proton_creds.json")),
+		statePath: filepath.Join(dir, "proton_state.json"),
+	}, nil
+}
+
+func (p *Provider) SaveCredentials(creds *Credentials) error {
+	return p.creds.Save(creds)
+}
+
+// LoadCredentials returns a copy of the stored credentials. The file is
+// read on the first call only; later calls return what was last loaded or
+// saved.
+func (p *Provider) LoadCredentials() (*Credentials, error) {
+	return p.creds.Load()
+}
+
+func (p *Provider) Initialize() error {
+	creds, err := p.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	if creds.UID == "" || len(creds.KeyPassphrases) == 0 {
+		return fmt.Errorf("%w: not signed in: please run init first", contacts.ErrNotInitialized)
+	}
+	p.state = nil
+	if data, err := os.ReadFile(p.statePath); err == nil {
+		if err := json.Unmarshal(data, &p.state); err != nil {
+			return fmt.Errorf("failed to parse sync state: %w", err)
+		}
+	}
+	return nil
+}
+
+// Name keys Proton's contacts in the manager's ID map.
+func (p *Provider) Name() string {
+	return contacts.ProviderProton
+}
+
+// contactMetadata is a contact as the API lists it, without its cards.
+type contactMetadata struct {
+	ID         string
+	ModifyTime int64
+}
+
+// listPage returns one page of the account's contacts and how many there
+// are in all.
+func (p *Provider) listPage(page, size int) ([]contactMetadata, int, error) {
+	var resp struct {
+		Contacts []contactMetadata
+		Total    int
+	}
+	q := url.Values{"Page": {strconv.Itoa(page)}, "PageSize": {strconv.Itoa(size)}}
+	if err := p.call("GET", "/contacts/v4/contacts?"+q.Encode(), nil, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp.Contacts, resp.Total, nil
+}
+
+// FetchContacts returns every contact in the account.
+func (p *Provider) FetchContacts() ([]vcard.Card, error) {
+	if p.creds.Cached() == nil {
+		return nil, contacts.ErrNotInitialized
+	}
+	kr, err := p.keys()
+	if err != nil {
+		return nil, err
+	}
+	var cards []vcard.Card
+	for page := 0; ; page++ {
+		var resp struct {
+			Contacts []struct {
+				ID    string
+				Cards []protonCard
+			}
+			Total int
+		}
+		q := url.Values{"Page": {strconv.Itoa(page)}, "PageSize": {strconv.Itoa(pageSize)}}
+		if err := p.call("GET", "/contacts/v4/contacts/export?"+q.Encode(), nil, &resp); err != nil {
+			return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+		}
+		for _, c := range resp.Contacts {
+			card, err := decodeCards(c.ID, c.Cards, kr)
+			if err != nil {
+				return nil, err
+			}
+			cards = append(cards, card)
+		}
+		if len(resp.Contacts) < pageSize || len(cards) >= resp.Total {
+			return cards, nil
+		}
+	}
+}
+
+// FetchChanges returns the contacts added or changed since the last
+// committed sync and the IDs of those removed. Proton keeps no change log
+// for contacts, so the list of contacts and their modification times is
+// compared with the times saved by the last sync and only changed
+// contacts are fetched.
+func (p *Provider) FetchChanges() (changed []vcard.Card, deleted []string, err error) {
+	if p.creds.Cached() == nil {
+		return nil, nil, contacts.ErrNotInitialized
+	}
+	next := map[string]int64{}
+	for page := 0; ; page++ {
+		list, total, err := p.listPage(page, pageSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list contacts: %w", err)
+		}
+		for _, c := range list {
+			next[c.ID] = c.ModifyTime
+		}
+		if len(list) < pageSize || len(next) >= total {
+			break
+		}
+	}
+	var ids []string
+	for id, modified := range next {
+		if t, ok := p.state[id]; !ok || t != modified {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > 0 {
+		kr, err := p.keys()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range ids {
+			card, err := p.getContact(id, kr)
+			// Contacts deleted since they were listed are reported deleted.
+			if errors.Is(err, contacts.ErrNotFound) {
+				delete(next, id)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			changed = append(changed, card)
+		}
+	}
+	for id := range p.state {
+		if _, ok := next[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Strings(deleted)
+	p.pendingState = next
+	return changed, deleted, nil
+}
+
+// CommitSync saves the modification times from the last FetchChanges,
+// once its changes have been stored locally.
+func (p *Provider) CommitSync() error {
+	if p.pendingState == nil {
+		return nil
+	}
+	data, err := json.Marshal(p.pendingState)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p.statePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+	p.state, p.pendingState = p.pendingState, nil
+	return nil
+}
+
+// getContact fetches and decodes one contact.
+func (p *Provider) getContact(id string, kr *crypto.KeyRing) (vcard.Card, error) {
+	var resp struct {
+		Contact struct {
+			ID    string
+			Cards []protonCard
+		}
+	}
+	if err := p.call("GET", "/contacts/v4/contacts/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to fetch contact %s: %w", id, err)
+	}
+	return decodeCards(id, resp.Contact.Cards, kr)
+}
+
+// WriteContact creates or updates the contact. A new contact gets the ID
+// Proton assigns, which the card adopts.
+func (p *Provider) WriteContact(card vcard.Card) error {
+	if p.creds.Cached() == nil {
+		return contacts.ErrNotInitialized
+	}
+	kr, err := p.signer()
+	if err != nil {
+		return err
+	}
+	cards, err := encodeCards(card, kr)
+	if err != nil {
+		return fmt.Errorf("failed to encode contact %s: %w", contacts.CardFullName(card), err)
+	}
+	id := contacts.ProviderID(card)
+	if id != "" {
+		req := map[string]any{"Cards": cards}
+		if err := p.call("PUT", "/contacts/v4/contacts/"+url.PathEscape(id), req, nil); err != nil {
+			return fmt.Errorf("failed to update contact %s: %w", contacts.CardFullName(card), err)
+		}
+		return nil
+	}
+
+	req := map[string]any{
+		"Contacts":  []map[string]any{{"Cards": cards}},
+		"Overwrite": 0,
+		"Labels":    0,
+	}
+	var resp struct {
+		Responses []struct {
+			Response struct {
+				apiError
+				Contact struct {
+					ID string
+				}
+			}
+		}
+	}
+	if err := p.call("POST", "/contacts/v4/contacts", req, &resp); err != nil {
+		return fmt.Errorf("failed to create contact %s: %w", contacts.CardFullName(card), err)
+	}
+	if len(resp.Responses) != 1 {
+		return fmt.Errorf("failed to create contact %s: unexpected response with %d results", contacts.CardFullName(card), len(resp.Responses))
+	}
+	result := resp.Responses[0].Response
+	if result.Code != codeOK {
+		result.Status = http.StatusUnprocessableEntity
+		return fmt.Errorf("failed to create contact %s: %w", contacts.CardFullName(card), &result.apiError)
+	}
+	// Adopt the ID Proton assigned so the next sync recognizes the contact
+	// instead of duplicating it.
+	if uid := card.Value(vcard.FieldUID); card.Value(FieldUID) == "" {
+		card.SetValue(FieldUID, uid)
+	}
+	card.SetValue(vcard.FieldUID, providerutil.LocalUID(result.Contact.ID))
+	card.SetValue(contacts.FieldProviderID, result.Contact.ID)
+	return nil
+}
+
+func (p *Provider) DeleteContact(id string) error {
+	if p.creds.Cached() == nil {
+		return contacts.ErrNotInitialized
+	}
+	var resp struct {
+		Responses []struct {
+			Response apiError
+		}
+	}
+	if err := p.call("PUT", "/contacts/v4/contacts/delete", map[string][]string{"IDs": {id}}, &resp); err != nil {
+		return fmt.Errorf("failed to delete contact %s: %w", id, err)
+	}
+	if len(resp.Responses) == 1 && resp.Responses[0].Response.Code != codeOK {
+		result := resp.Responses[0].Response
+		result.Status = http.StatusUnprocessableEntity
+		return fmt.Errorf("failed to delete contact %s: %w", id, &result)
+	}
+	return nil
+}
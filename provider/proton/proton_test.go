@@ -0,0 +1,439 @@
+package proton
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/arjungandhi/contacts"
+	"github.com/arjungandhi/contacts/provider/internal/providerutil"
+	"github.com/emersion/go-vcard"
+)
+
+const testPassword = "hunter2"
+
+type fakeContact struct {
+	cards    []protonCard
+	modified int64
+}
+
+// fakeProton stands in for the Proton API with one account.
+type fakeProton struct {
+	t        *testing.T
+	srp      *srpServer
+	modulus  string
+	kr       *crypto.KeyRing
+	key      string
+	keySalt  string
+	twoFA    bool
+	token    string
+	refresh  string
+	contacts map[string]*fakeContact
+	nextID   int
+	clock    int64
+}
+
+func newFakeProton(t *testing.T) *fakeProton {
+	t.Helper()
+	f := &fakeProton{
+		t:        t,
+		srp:      newSRPServer(t, testPassword),
+		keySalt:  "AAECAwQFBgcICQoLDA0ODw==",
+		contacts: map[string]*fakeContact{},
+	}
+	f.modulus = signedModulus(t, f.srp.modulus)
+	key, err := crypto.GenerateKey("Ada", "ada@proton.example", "x25519", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.kr, err = crypto.NewKeyRing(key); err != nil {
+		t.Fatal(err)
+	}
+	passphrase, err := keyPassphrase(testPassword, f.keySalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, err := key.Lock([]byte(passphrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.key, err = locked.Armor(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	old := apiURL
+	apiURL = server.URL
+	t.Cleanup(func() { apiURL = old })
+	return f
+}
+
+// add stores a contact as Proton's own apps would write it.
+func (f *fakeProton) add(id string, card vcard.Card) {
+	f.t.Helper()
+	cards, err := encodeCards(card, f.kr)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	f.clock++
+	f.contacts[id] = &fakeContact{cards: cards, modified: f.clock}
+}
+
+func (f *fakeProton) reply(w http.ResponseWriter, status int, body map[string]any) {
+	if _, ok := body["Code"]; !ok {
+		body["Code"] = codeOK
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (f *fakeProton) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req map[string]any
+	json.NewDecoder(r.Body).Decode(&req)
+	if r.Header.Get("x-pm-appversion") == "" {
+		f.reply(w, http.StatusBadRequest, map[string]any{"Code": 5001, "Error": "missing app version"})
+		return
+	}
+	path := r.URL.Path
+	switch {
+	case path == "/auth/v4/info":
+		f.reply(w, 200, map[string]any{"Version": 4, "Modulus": f.modulus, "Salt": f.srp.salt,
+			"ServerEphemeral": base64.StdEncoding.EncodeToString(f.srp.bigB), "SRPSession": "session"})
+		return
+	case path == "/auth/v4":
+		ephemeral, _ := base64.StdEncoding.DecodeString(req["ClientEphemeral"].(string))
+		proof, _ := base64.StdEncoding.DecodeString(req["ClientProof"].(string))
+		serverProof := f.srp.verify(ephemeral, proof)
+		if serverProof == nil {
+			f.reply(w, http.StatusUnprocessableEntity, map[string]any{"Code": 8002, "Error": "Incorrect login credentials"})
+			return
+		}
+		f.token, f.refresh = "token-1", "refresh-1"
+		twoFA := 0
+		if f.twoFA {
+			twoFA = twoFactorTOTP
+		}
+		f.reply(w, 200, map[string]any{"UID": "uid-1", "AccessToken": f.token, "RefreshToken": f.refresh,
+			"ServerProof": base64.StdEncoding.EncodeToString(serverProof), "2FA": map[string]int{"Enabled": twoFA}, "PasswordMode": 1})
+		return
+	case path == "/auth/v4/refresh":
+		if req["RefreshToken"] != f.refresh || r.Header.Get("x-pm-uid") != "uid-1" {
+			f.reply(w, http.StatusUnprocessableEntity, map[string]any{"Code": 10013, "Error": "Invalid refresh token"})
+			return
+		}
+		f.token, f.refresh = f.token+"'", f.refresh+"'"
+		f.reply(w, 200, map[string]any{"UID": "uid-1", "AccessToken": f.token, "RefreshToken": f.refresh})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+f.token || f.token == "" {
+		f.reply(w, http.StatusUnauthorized, map[string]any{"Code": 401, "Error": "Invalid access token"})
+		return
+	}
+
+	switch {
+	case path == "/auth/v4/2fa":
+		if req["TwoFactorCode"] != "123456" {
+			f.reply(w, http.StatusUnprocessableEntity, map[string]any{"Code": 8002, "Error": "Incorrect code"})
+			return
+		}
+		f.reply(w, 200, map[string]any{})
+	case path == "/core/v4/users":
+		f.reply(w, 200, map[string]any{"User": map[string]any{"Keys": []map[string]any{{"ID": "key-1", "PrivateKey": f.key, "Primary": 1}}}})
+	case path == "/core/v4/keys/salts":
+		f.reply(w, 200, map[string]any{"KeySalts": []map[string]string{{"ID": "key-1", "KeySalt": f.keySalt}}})
+	case path == "/contacts/v4/contacts" && r.Method == "GET", path == "/contacts/v4/contacts/export":
+		page, _ := strconv.Atoi(r.URL.Query().Get("Page"))
+		size, _ := strconv.Atoi(r.URL.Query().Get("PageSize"))
+		ids := f.ids()
+		var list []map[string]any
+		for _, id := range ids[min(page*size, len(ids)):min((page+1)*size, len(ids))] {
+			c := map[string]any{"ID": id, "ModifyTime": f.contacts[id].modified}
+			if strings.HasSuffix(path, "/export") {
+				c["Cards"] = f.contacts[id].cards
+			}
+			list = append(list, c)
+		}
+		f.reply(w, 200, map[string]any{"Contacts": list, "Total": len(ids)})
+	case path == "/contacts/v4/contacts" && r.Method == "POST":
+		var body struct {
+			Contacts []struct{ Cards []protonCard }
+		}
+		f.decode(req, &body)
+		var responses []map[string]any
+		for i, c := range body.Contacts {
+			f.nextID++
+			f.clock++
+			id := fmt.Sprintf("New%d==", f.nextID)
+			f.contacts[id] = &fakeContact{cards: c.Cards, modified: f.clock}
+			responses = append(responses, map[string]any{"Index": i, "Response": map[string]any{"Code": codeOK, "Contact": map[string]any{"ID": id}}})
+		}
+		f.reply(w, 200, map[string]any{"Code": codeMulti, "Responses": responses})
+	case path == "/contacts/v4/contacts/delete":
+		var body struct{ IDs []string }
+		f.decode(req, &body)
+		var responses []map[string]any
+		for _, id := range body.IDs {
+			code := codeOK
+			if _, ok := f.contacts[id]; !ok {
+				code = 2501
+			}
+			delete(f.contacts, id)
+			responses = append(responses, map[string]any{"ID": id, "Response": map[string]any{"Code": code}})
+		}
+		f.reply(w, 200, map[string]any{"Code": codeMulti, "Responses": responses})
+	case strings.HasPrefix(path, "/contacts/v4/contacts/"):
+		id := strings.TrimPrefix(path, "/contacts/v4/contacts/")
+		c, ok := f.contacts[id]
+		if !ok {
+			f.reply(w, http.StatusUnprocessableEntity, map[string]any{"Code": 2501, "Error": "Contact does not exist"})
+			return
+		}
+		if r.Method == "PUT" {
+			var body struct{ Cards []protonCard }
+			f.decode(req, &body)
+			f.clock++
+			c.cards, c.modified = body.Cards, f.clock
+		}
+		f.reply(w, 200, map[string]any{"Contact": map[string]any{"ID": id, "ModifyTime": c.modified, "Cards": c.cards}})
+	default:
+		f.reply(w, http.StatusNotFound, map[string]any{"Code": 404, "Error": "not found"})
+	}
+}
+
+func (f *fakeProton) decode(req map[string]any, v any) {
+	data, _ := json.Marshal(req)
+	if err := json.Unmarshal(data, v); err != nil {
+		f.t.Error(err)
+	}
+}
+
+func (f *fakeProton) ids() []string {
+	var ids []string
+	for id := range f.contacts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func newTestProvider(t *testing.T) (*Provider, *fakeProton) {
+	t.Helper()
+	fake := newFakeProton(t)
+	fake.add("Lovelace==", contacts.NewCard("Ada Lovelace"))
+	fake.add("Hopper==", contacts.NewCard("Grace Hopper"))
+	dir := t.TempDir()
+	p, err := NewProvider(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Login("ada", testPassword, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := p.Unlock(testPassword); err != nil || n != 2 {
+		t.Fatalf("Unlock = %d, %v", n, err)
+	}
+	// Start from the saved files, as a later run would.
+	if p, err = NewProvider(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	return p, fake
+}
+
+func TestProvider_Login(t *testing.T) {
+	fake := newFakeProton(t)
+	fake.twoFA = true
+	dir := t.TempDir()
+	p, err := NewProvider(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Login("ada", "wrong", nil); !errors.Is(err, contacts.ErrAuthExpired) {
+		t.Errorf("wrong password: got %v, want ErrAuthExpired", err)
+	}
+	asked := false
+	twoFactor := func() (string, error) {
+		asked = true
+		return "123456", nil
+	}
+	if twoPasswords, err := p.Login("ada", testPassword, twoFactor); err != nil || twoPasswords {
+		t.Fatalf("Login = %v, %v", twoPasswords, err)
+	}
+	if !asked {
+		t.Error("Login didn't ask for the two-factor code")
+	}
+	if _, err := p.Unlock("wrong"); !errors.Is(err, contacts.ErrAuthExpired) {
+		t.Errorf("wrong mailbox password: got %v, want ErrAuthExpired", err)
+	}
+	if _, err := p.Unlock(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(p.creds.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), testPassword) {
+		t.Error("the password was saved")
+	}
+}
+
+func TestProvider_FetchContacts(t *testing.T) {
+	p, _ := newTestProvider(t)
+	pageSize = 1
+	t.Cleanup(func() { pageSize = 100 })
+	cards, err := p.FetchContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 2 {
+		t.Fatalf("got %d cards, want 2", len(cards))
+	}
+	for _, card := range cards {
+		if want := providerutil.LocalUID(contacts.ProviderID(card)); contacts.CardUID(card) != want {
+			t.Errorf("UID = %q, want %q", contacts.CardUID(card), want)
+		}
+		if card.Value(FieldUID) == "" {
+			t.Error("Proton's UID of the contact was dropped")
+		}
+	}
+}
+
+func TestProvider_FetchChanges(t *testing.T) {
+	p, fake := newTestProvider(t)
+	changed, deleted, err := p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 2 || len(deleted) != 0 {
+		t.Fatalf("first sync: %d changed, %v deleted", len(changed), deleted)
+	}
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.add("Hopper==", contacts.NewCard("Grace Brewster Hopper"))
+	delete(fake.contacts, "Lovelace==")
+	changed, deleted, err = p.FetchChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 || contacts.CardFullName(changed[0]) != "Grace Brewster Hopper" {
+		t.Errorf("changed = %v, want the edited contact", changed)
+	}
+	if len(deleted) != 1 || deleted[0] != "Lovelace==" {
+		t.Errorf("deleted = %v", deleted)
+	}
+	if err := p.CommitSync(); err != nil {
+		t.Fatal(err)
+	}
+	if changed, deleted, err = p.FetchChanges(); err != nil || len(changed) != 0 || len(deleted) != 0 {
+		t.Errorf("unchanged sync: %d changed, %v deleted, %v", len(changed), deleted, err)
+	}
+}
+
+func TestProvider_WriteContact(t *testing.T) {
+	p, fake := newTestProvider(t)
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldNote, "Wrote the first program")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@example.com", Group: "item1"})
+	card.Add("X-PM-ENCRYPT", &vcard.Field{Value: "true", Group: "item1"})
+	card.Add(vcard.FieldCategories, &vcard.Field{Value: "Friends", Group: "item1"})
+	card.SetValue("X-LAST-SYNCED", "20240101T000000Z")
+	uid := contacts.CardUID(card)
+
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	id := contacts.ProviderID(card)
+	if id != "New1==" || contacts.CardUID(card) != providerutil.LocalUID(id) || card.Value(FieldUID) != uid {
+		t.Errorf("card did not adopt the new contact's ID: UID %q, ID %q", contacts.CardUID(card), id)
+	}
+	stored := fake.contacts[id].cards
+	if len(stored) != 3 {
+		t.Fatalf("stored %d cards, want clear, signed and encrypted", len(stored))
+	}
+	readable := stored[0].Data + stored[1].Data
+	for _, want := range []string{"CATEGORIES:Friends", "EMAIL:ada@example.com", "X-PM-ENCRYPT:true", "UID:" + uid} {
+		if !strings.Contains(readable, want) {
+			t.Errorf("%q isn't in the clear or signed card", want)
+		}
+	}
+	for _, secret := range []string{"Wrote the first program", "X-LAST-SYNCED", contacts.FieldProviderID} {
+		if strings.Contains(readable+stored[2].Data, secret) {
+			t.Errorf("%q was uploaded readable", secret)
+		}
+	}
+	got, err := decodeCards(id, stored, fake.kr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Value(vcard.FieldNote) != "Wrote the first program" {
+		t.Errorf("NOTE = %q after a round trip", got.Value(vcard.FieldNote))
+	}
+
+	// Updates replace the cards of the existing contact.
+	card.SetValue(vcard.FieldNote, "Analytical Engine")
+	if err := p.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.contacts) != 3 {
+		t.Errorf("update created a contact: %d contacts", len(fake.contacts))
+	}
+	if got, _ := decodeCards(id, fake.contacts[id].cards, fake.kr); got.Value(vcard.FieldNote) != "Analytical Engine" {
+		t.Errorf("NOTE = %q after the update", got.Value(vcard.FieldNote))
+	}
+}
+
+func TestProvider_DeleteContact(t *testing.T) {
+	p, fake := newTestProvider(t)
+	if err := p.DeleteContact("Lovelace=="); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.contacts["Lovelace=="]; ok {
+		t.Error("contact not deleted")
+	}
+	if err := p.DeleteContact("Lovelace=="); !errors.Is(err, contacts.ErrNotFound) {
+		t.Errorf("deleting again: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestProvider_RefreshesSession(t *testing.T) {
+	p, fake := newTestProvider(t)
+	// The access token expires; the refresh token gets a new one.
+	fake.token = "expired"
+	if _, err := p.FetchContacts(); err != nil {
+		t.Fatal(err)
+	}
+	if creds, _ := p.LoadCredentials(); creds.AccessToken != fake.token || creds.RefreshToken != fake.refresh {
+		t.Errorf("tokens = %q, %q, want the refreshed %q, %q", creds.AccessToken, creds.RefreshToken, fake.token, fake.refresh)
+	}
+
+	fake.token, fake.refresh = "expired", "revoked"
+	if _, _, err := p.FetchChanges(); !errors.Is(err, contacts.ErrAuthExpired) {
+		t.Errorf("revoked session: got %v, want ErrAuthExpired", err)
+	}
+}
+
+func TestProvider_InvalidSignature(t *testing.T) {
+	p, fake := newTestProvider(t)
+	c := fake.contacts["Hopper=="]
+	for i, card := range c.cards {
+		if card.Type == cardSigned {
+			c.cards[i].Data = strings.Replace(card.Data, "Grace Hopper", "Grace Murray", 1)
+		}
+	}
+	if _, err := p.FetchContacts(); err == nil || !strings.Contains(err.Error(), "invalid signature") {
+		t.Errorf("got %v, want an invalid signature error", err)
+	}
+}
@@ -0,0 +1,183 @@
+package proton
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"golang.org/x/crypto/blowfish"
+)
+
+// Proton signs in with SRP-6a over a 2048-bit group whose modulus the
+// server sends with each login, hashing with expandHash (four SHA-512s)
+// and reading numbers little-endian. The password is stretched with
+// bcrypt first, so neither it nor anything it can be recovered from
+// leaves the machine.
+
+// srpBits is the size of the SRP group.
+const srpBits = 2048
+
+// bcryptCost is the cost Proton uses for login and key passphrases.
+const bcryptCost = 10
+
+// bcryptEncoding is the base64 alphabet of bcrypt hashes.
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// bcryptHash returns the bcrypt hash of password with a 16-byte salt, as
+// "$2y$" followed by the cost, the encoded salt and the encoded hash.
+// golang.org/x/crypto/bcrypt always picks its own salt, so the algorithm
+// is run here on its Blowfish key schedule.
+func bcryptHash(password, salt []byte, cost int) []byte {
+	key := append(password[:len(password):len(password)], 0)
+	if len(key) > 72 {
+		key = key[:72]
+	}
+	c, err := blowfish.NewSaltedCipher(key, salt)
+	if err != nil {
+		panic(err) // only for empty keys, which the NUL rules out
+	}
+	for i := 0; i < 1<<cost; i++ {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(salt, c)
+	}
+	data := []byte("OrpheanBeholderScryDoubt")
+	for i := 0; i < len(data); i += 8 {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(data[i:i+8], data[i:i+8])
+		}
+	}
+	return fmt.Appendf(nil, "$2y$%02d$%s%s", cost, bcryptEncoding.EncodeToString(salt)[:22], bcryptEncoding.EncodeToString(data[:23]))
+}
+
+// keyPassphrase derives the passphrase of a key from the mailbox password
+// and the key's salt, base64 as the API returns it: the hash part of the
+// bcrypt hash.
+func keyPassphrase(password, keySalt string) (string, error) {
+	salt, err := base64.StdEncoding.DecodeString(keySalt)
+	if err != nil || len(salt) != 16 {
+		return "", fmt.Errorf("invalid key salt %q", keySalt)
+	}
+	hash := bcryptHash([]byte(password), salt, bcryptCost)
+	return string(hash[len(hash)-31:]), nil
+}
+
+// expandHash stretches SHA-512 to the 256 bytes of the SRP group.
+func expandHash(data []byte) []byte {
+	out := make([]byte, 0, 4*sha512.Size)
+	for i := byte(0); i < 4; i++ {
+		sum := sha512.Sum512(append(data[:len(data):len(data)], i))
+		out = append(out, sum[:]...)
+	}
+	return out
+}
+
+// hashPassword returns the SRP private value for password, for the auth
+// versions current accounts use (3 and 4). Older versions hashed
+// differently and are only left on accounts that haven't signed in for
+// years.
+func hashPassword(version int, password, salt string, modulus []byte) ([]byte, error) {
+	if version < 3 {
+		return nil, fmt.Errorf("the account uses password version %d: sign in on the Proton website once to upgrade it", version)
+	}
+	rawSalt, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid password salt: %w", err)
+	}
+	// The 10-byte salt is padded to bcrypt's 16 bytes with "proton".
+	hash := bcryptHash([]byte(password), append(rawSalt, "proton"...), bcryptCost)
+	return expandHash(append(hash, modulus...)), nil
+}
+
+// readModulus returns the SRP modulus from the PGP signed message the API
+// sends it in. The signature is by Proton's modulus key, which isn't
+// bundled; instead the modulus is checked to be a safe prime of the
+// expected size, which is what the protocol's security rests on.
+func readModulus(signed string) ([]byte, error) {
+	msg, err := crypto.NewClearTextMessageFromArmored(signed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SRP modulus: %w", err)
+	}
+	modulus, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(msg.GetBinary())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode SRP modulus: %w", err)
+	}
+	n := fromLE(modulus)
+	half := new(big.Int).Rsh(n, 1)
+	if len(modulus) != srpBits/8 || n.BitLen() != srpBits || !n.ProbablyPrime(10) || !half.ProbablyPrime(10) {
+		return nil, errors.New("SRP modulus is not a safe prime")
+	}
+	return modulus, nil
+}
+
+// fromLE reads a little-endian number.
+func fromLE(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i, c := range b {
+		be[len(b)-1-i] = c
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// toLE writes n little-endian, padded to the group size.
+func toLE(n *big.Int) []byte {
+	out := make([]byte, srpBits/8)
+	be := n.Bytes()
+	for i, c := range be {
+		out[len(be)-1-i] = c
+	}
+	return out
+}
+
+// srpProofs are what the client sends to finish an SRP login and the
+// proof it expects back.
+type srpProofs struct {
+	clientEphemeral []byte
+	clientProof     []byte
+	serverProof     []byte
+}
+
+// generateProofs runs the client side of SRP with the server's ephemeral
+// and the hashed password.
+func generateProofs(modulus, serverEphemeral, hashedPassword []byte) (*srpProofs, error) {
+	n := fromLE(modulus)
+	g := big.NewInt(2)
+	b := fromLE(serverEphemeral)
+	if b.Cmp(big.NewInt(1)) <= 0 || b.Cmp(new(big.Int).Sub(n, big.NewInt(1))) >= 0 {
+		return nil, errors.New("invalid SRP server ephemeral")
+	}
+	k := fromLE(expandHash(append(toLE(g), modulus...)))
+	k.Mod(k, n)
+	x := fromLE(hashedPassword)
+
+	var a, bigA, u *big.Int
+	for {
+		secret, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(2*srpBits+1)))
+		if err != nil {
+			return nil, err
+		}
+		a = secret.Add(secret, big.NewInt(2*srpBits))
+		bigA = new(big.Int).Exp(g, a, n)
+		u = fromLE(expandHash(append(toLE(bigA), serverEphemeral...)))
+		if u.Sign() != 0 {
+			break
+		}
+	}
+
+	// S = (B - k·g^x)^(u·x + a) mod N
+	base := new(big.Int).Exp(g, x, n)
+	base.Mul(base, k).Mod(base, n)
+	base.Sub(b, base).Mod(base, n)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, a).Mod(exp, new(big.Int).Sub(n, big.NewInt(1)))
+	shared := toLE(new(big.Int).Exp(base, exp, n))
+
+	clientEphemeral := toLE(bigA)
+	clientProof := expandHash(bytes.Join([][]byte{clientEphemeral, toLE(b), shared}, nil))
+	serverProof := expandHash(bytes.Join([][]byte{clientEphemeral, clientProof, shared}, nil))
+	return &srpProofs{clientEphemeral: clientEphemeral, clientProof: clientProof, serverProof: serverProof}, nil
+}
@@ -0,0 +1,170 @@
+package proton
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/gopenpgp/v2/helper"
+)
+
+// testModulus is the 2048-bit MODP group of RFC 3526, a safe prime, in
+// the little-endian form Proton sends moduli in.
+var testModulus = func() []byte {
+	n, _ := new(big.Int).SetString(strings.Join([]string{
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1",
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD",
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245",
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED",
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3D",
+		"C2007CB8A163BF0598DA48361C55D39A69163FA8FD24CF5F",
+		"83655D23DCA3AD961C62F356208552BB9ED529077096966D",
+		"670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B",
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9",
+		"DE2BCBF6955817183995497CEA956AE515D2261898FA0510",
+		"15728E5A8AACAA68FFFFFFFFFFFFFFFF",
+	}, ""), 16)
+	return toLE(n)
+}()
+
+// signedModulus wraps a modulus in a PGP signed message as the API sends
+// it, signed with a throwaway key.
+func signedModulus(t *testing.T, modulus []byte) string {
+	t.Helper()
+	key, err := crypto.GenerateKey("Modulus", "modulus@example.com", "x25519", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kr, err := crypto.NewKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := helper.SignCleartextMessage(kr, base64.StdEncoding.EncodeToString(modulus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestBcryptHash(t *testing.T) {
+	// From the OpenBSD test vectors; the hash is the same for $2b$.
+	salt, err := bcryptEncoding.DecodeString("CCCCCCCCCCCCCCCCCCCCC.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(bcryptHash([]byte("U*U"), salt, 5))
+	if want := "$2y$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW"; got != want {
+		t.Errorf("bcryptHash = %s, want %s", got, want)
+	}
+}
+
+func TestKeyPassphrase(t *testing.T) {
+	// The hash part of crypt("correct horse", "$2b$10$..CA.uOD/eaGAOmJB.yMBu").
+	got, err := keyPassphrase("correct horse", "AAECAwQFBgcICQoLDA0ODw==")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "vDN6CYo3Tm7ezZ/XWxzm7yB3Os41bhG"; got != want {
+		t.Errorf("keyPassphrase = %s, want %s", got, want)
+	}
+	if _, err := keyPassphrase("x", "c2hvcnQ="); err == nil {
+		t.Error("keyPassphrase accepted a short salt")
+	}
+}
+
+func TestReadModulus(t *testing.T) {
+	got, err := readModulus(signedModulus(t, testModulus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, testModulus) {
+		t.Error("readModulus returned a different modulus")
+	}
+	// A prime that isn't safe would let the server pick a weak group.
+	weak := new(big.Int).Lsh(big.NewInt(1), srpBits-1)
+	for !weak.ProbablyPrime(10) {
+		weak.Add(weak, big.NewInt(1))
+	}
+	if _, err := readModulus(signedModulus(t, toLE(weak))); err == nil || new(big.Int).Rsh(weak, 1).ProbablyPrime(10) {
+		t.Error("readModulus accepted a prime that isn't safe")
+	}
+}
+
+func TestHashPassword_OldVersion(t *testing.T) {
+	if _, err := hashPassword(2, "pw", "AAECAwQFBgcICQ==", testModulus); err == nil {
+		t.Error("hashPassword accepted auth version 2")
+	}
+}
+
+// srpServer is the server side of an SRP login for a password.
+type srpServer struct {
+	modulus []byte
+	salt    string
+	v, b    *big.Int
+	bigB    []byte
+}
+
+func newSRPServer(t *testing.T, password string) *srpServer {
+	t.Helper()
+	salt := make([]byte, 10)
+	rand.Read(salt)
+	s := &srpServer{modulus: testModulus, salt: base64.StdEncoding.EncodeToString(salt)}
+	x, err := hashPassword(4, password, s.salt, s.modulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, g := fromLE(s.modulus), big.NewInt(2)
+	s.v = new(big.Int).Exp(g, fromLE(x), n)
+	s.b, _ = rand.Int(rand.Reader, n)
+	k := fromLE(expandHash(append(toLE(g), s.modulus...)))
+	bigB := new(big.Int).Mul(k, s.v)
+	bigB.Add(bigB, new(big.Int).Exp(g, s.b, n)).Mod(bigB, n)
+	s.bigB = toLE(bigB)
+	return s
+}
+
+// verify checks the client's proof and returns the server's, or nil if
+// the client's is wrong.
+func (s *srpServer) verify(clientEphemeral, clientProof []byte) []byte {
+	n := fromLE(s.modulus)
+	u := fromLE(expandHash(append(clientEphemeral[:len(clientEphemeral):len(clientEphemeral)], s.bigB...)))
+	shared := new(big.Int).Exp(s.v, u, n)
+	shared.Mul(shared, fromLE(clientEphemeral)).Mod(shared, n)
+	shared.Exp(shared, s.b, n)
+	want := expandHash(bytes.Join([][]byte{clientEphemeral, s.bigB, toLE(shared)}, nil))
+	if !bytes.Equal(clientProof, want) {
+		return nil
+	}
+	return expandHash(bytes.Join([][]byte{clientEphemeral, clientProof, toLE(shared)}, nil))
+}
+
+func TestGenerateProofs(t *testing.T) {
+	server := newSRPServer(t, "hunter2")
+	x, err := hashPassword(4, "hunter2", server.salt, server.modulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofs, err := generateProofs(server.modulus, server.bigB, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverProof := server.verify(proofs.clientEphemeral, proofs.clientProof)
+	if serverProof == nil {
+		t.Fatal("server rejected the client proof")
+	}
+	if !bytes.Equal(serverProof, proofs.serverProof) {
+		t.Error("client expects a different server proof")
+	}
+
+	wrong, _ := hashPassword(4, "hunter3", server.salt, server.modulus)
+	if proofs, _ := generateProofs(server.modulus, server.bigB, wrong); server.verify(proofs.clientEphemeral, proofs.clientProof) != nil {
+		t.Error("server accepted a proof for the wrong password")
+	}
+	if _, err := generateProofs(server.modulus, toLE(big.NewInt(1)), x); err == nil {
+		t.Error("generateProofs accepted a server ephemeral of 1")
+	}
+}
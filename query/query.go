@@ -0,0 +1,63 @@
+// Package query runs jq programs over the JSON output of the contacts
+// command.
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// Query is a compiled jq filter over JSON output, so that -o json can be
+// filtered and reshaped without jq installed. Queries are jq programs, run
+// by gojq; see https://jqlang.org/manual for the language.
+type Query struct {
+	src  string
+	code *gojq.Code
+}
+
+// ParseQuery compiles a jq filter; see Query.
+func ParseQuery(src string) (*Query, error) {
+	parsed, err := gojq.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", src, err)
+	}
+	code, err := gojq.Compile(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %q: %w", src, err)
+	}
+	return &Query{src: src, code: code}, nil
+}
+
+// Run applies the query to v, which is first converted to its JSON form
+// (objects become map[string]any, numbers float64), and returns the
+// outputs.
+func (q *Query) Run(v any) ([]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query input: %w", err)
+	}
+	var in any
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, fmt.Errorf("failed to parse query input: %w", err)
+	}
+	var out []any
+	iter := q.code.Run(in)
+	for {
+		result, ok := iter.Next()
+		if !ok {
+			return out, nil
+		}
+		if err, ok := result.(error); ok {
+			// halt stops the query without an error.
+			var halt *gojq.HaltError
+			if errors.As(err, &halt) && halt.Value() == nil {
+				return out, nil
+			}
+			return nil, fmt.Errorf("query %q: %w", q.src, err)
+		}
+		out = append(out, result)
+	}
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"testing"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+func TestQuery_Run(t *testing.T) {
+	input := map[string]any{
+		"name": "Ada Lovelace",
+		"emails": []map[string]string{
+			{"value": "ada@home.example", "type": "home"},
+			{"value": "ada@work.example", "type": "work"},
+		},
+		"tags": []string{"math", "poetry", "math"},
+		"born": 1815,
+		"died": 1852,
+		"pick": 1,
+	}
+	// The wanted outputs are jq's, and are checked against it if it is
+	// installed.
+	jq, _ := exec.LookPath("jq")
+	inputJSON, _ := json.Marshal(input)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.name`, `["Ada Lovelace"]`},
+		{`.emails[] | select(.type=="work") | .value`, `["ada@work.example"]`},
+		{`.emails[-1].type`, `["work"]`},
+		{`.emails | map(.value) | join(", ")`, `["ada@home.example, ada@work.example"]`},
+		{`[.emails[].type]`, `[["home","work"]]`},
+		{`{name, count: (.emails | length)}`, `[{"count":2,"name":"Ada Lovelace"}]`},
+		{`.tags | unique`, `[["math","poetry"]]`},
+		{`.missing, (.name | ascii_upcase)`, `[null,"ADA LOVELACE"]`},
+		{`.emails[] | select((.value | test("@work")) and .type != "home") | .type`, `["work"]`},
+		{`.tags | contains(["poetry"])`, `[true]`},
+		{`.name | startswith("Ada")`, `[true]`},
+		{`1 + 2 > 2`, `[true]`},
+		{`.name[]?`, `null`},
+		{`has("emails"), (.tags | first)`, `[true,"math"]`},
+		{`.emails[] | select(.type == "fax")`, `null`},
+		{`.tags[.pick]`, `["poetry"]`},
+		{`if .died - .born > 30 then "long" else "short" end`, `["long"]`},
+		{`(.died - .born) * 12 / 4`, `[111]`},
+		{`.tags[1:]`, `[["poetry","math"]]`},
+		{`.name[:3]`, `["Ada"]`},
+		{`.emails | sort_by(.type) | reverse | map(.type)`, `[["work","home"]]`},
+		{`{a: 1, b: 2} | to_entries | map(.key)`, `[["a","b"]]`},
+		{`del(.emails, .tags) | keys`, `[["born","died","name","pick"]]`},
+		{`[.born, .died] | min, max`, `[1815,1852]`},
+		{`.emails | min_by(.value) | .type`, `["home"]`},
+		{`.tags | group_by(.) | map(length)`, `[[2,1]]`},
+		{`[.tags[] | ascii_upcase] | join("-")`, `["MATH-POETRY-MATH"]`},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		out, err := q.Run(input)
+		if err != nil {
+			t.Errorf("Run(%q): %v", tt.query, err)
+			continue
+		}
+		got, _ := json.Marshal(out)
+		if string(got) != tt.want {
+			t.Errorf("Run(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+		if jq != "" {
+			cmd := exec.Command(jq, "-c", tt.query)
+			cmd.Stdin = bytes.NewReader(inputJSON)
+			data, err := cmd.Output()
+			if err != nil {
+				t.Errorf("jq %q: %v", tt.query, err)
+				continue
+			}
+			var results []any
+			dec := json.NewDecoder(bytes.NewReader(data))
+			for dec.More() {
+				var r any
+				dec.Decode(&r)
+				results = append(results, r)
+			}
+			if want, _ := json.Marshal(results); string(want) != tt.want {
+				t.Errorf("jq %q = %s, but the test wants %s", tt.query, want, tt.want)
+			}
+		}
+	}
+}
+
+func TestQuery_Card(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@work.example", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	q, err := ParseQuery(`.[] | .emails[] | select(.type=="work") | .value`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := q.Run(contacts.CardsToMaps([]vcard.Card{card}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out[0] != "ada@work.example" {
+		t.Errorf("Run = %v, want the work address", out)
+	}
+}
+
+func TestParseQuery_Errors(t *testing.T) {
+	for _, query := range []string{`.name |`, `select(.a`, `nosuch`, `.a ==`, `"unterminated`, `{(.a)}`, `.a $`} {
+		if _, err := ParseQuery(query); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded, want error", query)
+		}
+	}
+	q, err := ParseQuery(`.name[]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Run(map[string]any{"name": "Ada"}); err == nil {
+		t.Error("iterating a string succeeded, want error")
+	}
+}
@@ -0,0 +1,44 @@
+package contacts
+
+import (
+	"fmt"
+
+	"github.com/emersion/go-vcard"
+)
+
+// ReadOnlyProvider is a ContactProvider that can't store changes, such as
+// a corporate directory. The contacts it syncs can't be edited or deleted
+// locally, and contacts created locally are kept only in the local store.
+type ReadOnlyProvider interface {
+	ContactProvider
+	ReadOnly() bool
+}
+
+func (cm *ContactManager) readOnly() bool {
+	ro, ok := cm.provider.(ReadOnlyProvider)
+	return ok && ro.ReadOnly()
+}
+
+// IsReadOnly reports whether card came from the manager's provider and the
+// provider is read-only.
+func (cm *ContactManager) IsReadOnly(card vcard.Card) bool {
+	return cm.readOnly() && ProviderID(card) != ""
+}
+
+// checkWritable returns ErrReadOnly if card, or the stored contact with its
+// UID, came from a read-only provider.
+func (cm *ContactManager) checkWritable(card vcard.Card) error {
+	if !cm.readOnly() {
+		return nil
+	}
+	stored := card
+	if uid := CardUID(card); uid != "" && uidInStore(uid) {
+		if existing, err := cm.GetContact(uid); err == nil && existing != nil {
+			stored = existing
+		}
+	}
+	if cm.IsReadOnly(card) || cm.IsReadOnly(stored) {
+		return fmt.Errorf("%w: %s comes from %s", ErrReadOnly, CardFullName(card), cm.providerName())
+	}
+	return nil
+}
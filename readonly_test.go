@@ -0,0 +1,87 @@
+package contacts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+// directoryProvider is a read-only provider that fails any write.
+type directoryProvider struct {
+	mockProvider
+}
+
+func (d *directoryProvider) ReadOnly() bool { return true }
+func (d *directoryProvider) WriteContact(vcard.Card) error {
+	return errors.New("unexpected write")
+}
+func (d *directoryProvider) DeleteContact(string) error {
+	return errors.New("unexpected delete")
+}
+
+func TestContactManager_ReadOnlyProvider(t *testing.T) {
+	dir := t.TempDir()
+	entry := NewCard("Directory Entry")
+	cm, err := NewContactManager(&directoryProvider{mockProvider{contacts: []vcard.Card{entry}}}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	synced, err := cm.GetContact(CardUID(entry))
+	if err != nil || synced == nil {
+		t.Fatalf("GetContact = %v, %v", synced, err)
+	}
+	if !cm.IsReadOnly(synced) {
+		t.Error("synced card not read-only")
+	}
+
+	synced.SetValue(vcard.FieldTitle, "Boss")
+	if err := cm.WriteContact(synced); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteContact = %v, want ErrReadOnly", err)
+	}
+	// Dropping the provider ID doesn't get around it.
+	delete(synced, FieldProviderID)
+	if err := cm.WriteContact(synced); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteContact without provider ID = %v, want ErrReadOnly", err)
+	}
+	if err := cm.DeleteContact(CardUID(entry)); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("DeleteContact = %v, want ErrReadOnly", err)
+	}
+	if _, err := cm.ApplyBatch([]BatchOp{{Op: BatchDelete, UID: CardUID(entry)}}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Batch delete = %v, want ErrReadOnly", err)
+	}
+
+	// Local contacts stay local and can be changed.
+	local := NewCard("Local Friend")
+	if err := cm.WriteContact(local); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.DeleteContact(CardUID(local)); err != nil {
+		t.Fatal(err)
+	}
+
+	// An edit made outside the tool is overwritten by the next sync.
+	path := filepath.Join(dir, "people", CardUID(entry)+".vcf")
+	data, err := EncodeCard(synced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("Conflicts = %v, want the directory to win", result.Conflicts)
+	}
+	if card, _ := cm.GetContact(CardUID(entry)); card.Value(vcard.FieldTitle) != "" {
+		t.Errorf("TITLE = %q, want the directory's version", card.Value(vcard.FieldTitle))
+	}
+}
@@ -0,0 +1,137 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// accessEntry records how often and how recently a contact was used.
+type accessEntry struct {
+	Count int       `json:"count"`
+	Last  time.Time `json:"last"`
+}
+
+// recencyHalfLife is how long it takes a past access to count half as much
+// toward a contact's recency score.
+const recencyHalfLife = 14 * 24 * time.Hour
+
+func (cm *ContactManager) recentPath() string {
+	return filepath.Join(cm.dir, "recent.json")
+}
+
+func (cm *ContactManager) loadRecent() (map[string]accessEntry, error) {
+	data, err := os.ReadFile(cm.recentPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]accessEntry{}, nil
+		}
+		return nil, fmt.Errorf("failed to read recent contacts: %w", err)
+	}
+	recent := map[string]accessEntry{}
+	if err := json.Unmarshal(data, &recent); err != nil {
+		return nil, fmt.Errorf("failed to parse recent contacts: %w", err)
+	}
+	return recent, nil
+}
+
+// RecordAccess notes that the contact was viewed or edited, so it ranks
+// higher in RecentContacts and SortByRecency.
+func (cm *ContactManager) RecordAccess(uid string) error {
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	recent, err := cm.loadRecent()
+	if err != nil {
+		return err
+	}
+	entry := recent[uid]
+	entry.Count++
+	entry.Last = time.Now().UTC()
+	recent[uid] = entry
+	data, err := json.Marshal(recent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent contacts: %w", err)
+	}
+	if err := writeFileAtomic(cm.recentPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write recent contacts: %w", err)
+	}
+	return nil
+}
+
+// RecentContacts returns up to limit contacts ordered by when they were
+// last accessed, most recent first. A limit of zero returns them all.
+// Contacts that have since been deleted are skipped.
+func (cm *ContactManager) RecentContacts(limit int) ([]vcard.Card, error) {
+	recent, err := cm.loadRecent()
+	if err != nil {
+		return nil, err
+	}
+	uids := make([]string, 0, len(recent))
+	for uid := range recent {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool { return recent[uids[i]].Last.After(recent[uids[j]].Last) })
+
+	var cards []vcard.Card
+	for _, uid := range uids {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			return nil, err
+		}
+		if card == nil {
+			continue
+		}
+		cards = append(cards, card)
+		if limit > 0 && len(cards) == limit {
+			break
+		}
+	}
+	return cards, nil
+}
+
+// SortByRecency orders cards so frequently and recently used contacts come
+// first. Each access counts for less the longer ago the contact was last
+// used; contacts never accessed keep their relative order at the end.
+func (cm *ContactManager) SortByRecency(cards []vcard.Card) error {
+	score, err := cm.recencyScorer()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(cards, func(i, j int) bool { return score(CardUID(cards[i])) > score(CardUID(cards[j])) })
+	return nil
+}
+
+// SortNamesByRecency orders names the same way SortByRecency orders cards.
+func (cm *ContactManager) SortNamesByRecency(names []NameEntry) error {
+	score, err := cm.recencyScorer()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(names, func(i, j int) bool { return score(names[i].UID) > score(names[j].UID) })
+	return nil
+}
+
+func (cm *ContactManager) recencyScorer() (func(uid string) float64, error) {
+	recent, err := cm.loadRecent()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return func(uid string) float64 {
+		entry, ok := recent[uid]
+		if !ok {
+			return 0
+		}
+		age := now.Sub(entry.Last)
+		return float64(entry.Count) * math.Pow(0.5, float64(age)/float64(recencyHalfLife))
+	}, nil
+}
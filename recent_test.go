@@ -0,0 +1,61 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_Recent(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann, bob, cat := NewCard("Ann"), NewCard("Bob"), NewCard("Cat")
+	if err := cm.WriteContacts([]vcard.Card{ann, bob, cat}); err != nil {
+		t.Fatal(err)
+	}
+	for _, card := range []vcard.Card{bob, bob, bob, ann} {
+		if err := cm.RecordAccess(CardUID(card)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recent, err := cm.RecentContacts(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || CardFullName(recent[0]) != "Ann" || CardFullName(recent[1]) != "Bob" {
+		t.Errorf("RecentContacts = %v, want [Ann Bob]", names(recent))
+	}
+	if limited, _ := cm.RecentContacts(1); len(limited) != 1 {
+		t.Errorf("RecentContacts(1) returned %d cards", len(limited))
+	}
+
+	cards := []vcard.Card{cat, ann, bob}
+	if err := cm.SortByRecency(cards); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(cards); got[0] != "Bob" || got[1] != "Ann" || got[2] != "Cat" {
+		t.Errorf("SortByRecency = %v, want [Bob Ann Cat]", got)
+	}
+
+	if err := cm.DeleteContact(CardUID(ann)); err != nil {
+		t.Fatal(err)
+	}
+	recent, err = cm.RecentContacts(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 {
+		t.Errorf("deleted contact still listed: %v", names(recent))
+	}
+}
+
+func names(cards []vcard.Card) []string {
+	var out []string
+	for _, c := range cards {
+		out = append(out, CardFullName(c))
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+package contacts
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Occasion is the next occurrence of a recurring date on a contact, such as
+// a birthday or anniversary.
+type Occasion struct {
+	Card      vcard.Card
+	Kind      string    // "birthday", "anniversary" or a custom event type
+	Date      time.Time // next occurrence, at midnight in the caller's location
+	Year      int       // original year, or 0 if unknown
+	DaysUntil int
+}
+
+// Years returns how many years the occasion marks (age for birthdays), or 0
+// if the original year is unknown.
+func (o Occasion) Years() int {
+	if o.Year == 0 {
+		return 0
+	}
+	return o.Date.Year() - o.Year
+}
+
+// UpcomingOccasions returns the birthdays, anniversaries and custom events
+// falling within the given number of days after from (inclusive of today),
+// sorted by date.
+func UpcomingOccasions(cards []vcard.Card, from time.Time, days int) []Occasion {
+	today := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	var out []Occasion
+	for _, card := range cards {
+		for _, event := range cardOccasions(card) {
+			year, month, day, ok := parseVCardDate(event.Date)
+			if !ok {
+				continue
+			}
+			next := nextOccurrence(today, month, day)
+			until := daysBetween(today, next)
+			if until > days {
+				continue
+			}
+			out = append(out, Occasion{
+				Card:      card,
+				Kind:      event.Kind,
+				Date:      next,
+				Year:      year,
+				DaysUntil: until,
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].DaysUntil != out[j].DaysUntil {
+			return out[i].DaysUntil < out[j].DaysUntil
+		}
+		return CardFullName(out[i].Card) < CardFullName(out[j].Card)
+	})
+	return out
+}
+
+// StaleContacts returns the contacts whose last modification (REV) is
+// before the cutoff, oldest first. Cards without a REV are skipped since
+// their age is unknown.
+func StaleContacts(cards []vcard.Card, cutoff time.Time) []vcard.Card {
+	type aged struct {
+		card vcard.Card
+		rev  time.Time
+	}
+	var stale []aged
+	for _, card := range cards {
+		rev, ok := CardRevision(card)
+		if !ok || !rev.Before(cutoff) {
+			continue
+		}
+		stale = append(stale, aged{card, rev})
+	}
+	sort.SliceStable(stale, func(i, j int) bool { return stale[i].rev.Before(stale[j].rev) })
+	out := make([]vcard.Card, len(stale))
+	for i, s := range stale {
+		out[i] = s.card
+	}
+	return out
+}
+
+// CardRevision returns the parsed REV timestamp of a card.
+func CardRevision(card vcard.Card) (time.Time, bool) {
+	rev := card.Value(vcard.FieldRevision)
+	if rev == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"20060102T150405Z", time.RFC3339, "2006-01-02T15:04:05Z", "20060102"} {
+		if t, err := time.Parse(layout, rev); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FormatDigest renders a plain-text digest of upcoming occasions and stale
+// contacts, suitable for an email body.
+func FormatDigest(occasions []Occasion, stale []vcard.Card) string {
+	return FormatDigestLocale(occasions, stale, DefaultLocale)
+}
+
+// FormatDigestLocale is FormatDigest with dates rendered for loc.
+func FormatDigestLocale(occasions []Occasion, stale []vcard.Card, loc Locale) string {
+	var b strings.Builder
+	b.WriteString("Upcoming dates\n")
+	b.WriteString("--------------\n")
+	if len(occasions) == 0 {
+		b.WriteString("  Nothing coming up.\n")
+	}
+	for _, o := range occasions {
+		label := o.Kind
+		if n := o.Years(); n > 0 {
+			label = fmt.Sprintf("%s (%d)", o.Kind, n)
+		}
+		b.WriteString(fmt.Sprintf("  %s  %s: %s\n", loc.FormatWeekday(o.Date), CardFullName(o.Card), label))
+	}
+	if len(stale) > 0 {
+		b.WriteString("\nStale contacts\n")
+		b.WriteString("--------------\n")
+		for _, card := range stale {
+			rev, _ := CardRevision(card)
+			b.WriteString(fmt.Sprintf("  %s (last updated %s)\n", CardFullName(card), loc.FormatDate(rev)))
+		}
+	}
+	return b.String()
+}
+
+// nextOccurrence returns the next date on or after today with the given
+// month and day. Feb 29 falls on Feb 28 in non-leap years.
+func nextOccurrence(today time.Time, month time.Month, day int) time.Time {
+	for year := today.Year(); ; year++ {
+		d := day
+		if month == time.February && day == 29 && !isLeap(year) {
+			d = 28
+		}
+		t := time.Date(year, month, d, 0, 0, 0, 0, today.Location())
+		if !t.Before(today) {
+			return t
+		}
+	}
+}
+
+// daysBetween returns the number of calendar days from a to b, ignoring DST.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// parseVCardDate parses the vCard date forms used in BDAY/ANNIVERSARY:
+// YYYYMMDD, YYYY-MM-DD, --MMDD and --MM-DD. Year is 0 when omitted.
+func parseVCardDate(s string) (year int, month time.Month, day int, ok bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, 'T'); i >= 0 {
+		s = s[:i]
+	}
+	noYear := strings.HasPrefix(s, "--")
+	s = strings.ReplaceAll(s, "-", "")
+	switch {
+	case noYear && len(s) == 4:
+		m, err1 := strconv.Atoi(s[:2])
+		d, err2 := strconv.Atoi(s[2:])
+		if err1 != nil || err2 != nil {
+			return 0, 0, 0, false
+		}
+		month, day = time.Month(m), d
+	case len(s) == 8:
+		t, err := time.Parse("20060102", s)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		year, month, day = t.Year(), t.Month(), t.Day()
+	default:
+		return 0, 0, 0, false
+	}
+	if month < time.January || month > time.December || day < 1 || day > 31 {
+		return 0, 0, 0, false
+	}
+	return year, month, day, true
+}
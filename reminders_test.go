@@ -0,0 +1,83 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestUpcomingOccasions(t *testing.T) {
+	alice := NewCard("Alice")
+	alice.SetValue(vcard.FieldBirthday, "19900615")
+	bob := NewCard("Bob")
+	bob.SetValue(vcard.FieldBirthday, "--0610")
+	bob.SetValue(vcard.FieldAnniversary, "2015-06-20")
+	carol := NewCard("Carol")
+	carol.SetValue(vcard.FieldBirthday, "19800101")
+
+	from := time.Date(2024, time.June, 10, 15, 0, 0, 0, time.UTC)
+	got := UpcomingOccasions([]vcard.Card{alice, bob, carol}, from, 7)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 occasions, got %d", len(got))
+	}
+	if CardFullName(got[0].Card) != "Bob" || got[0].DaysUntil != 0 || got[0].Years() != 0 {
+		t.Errorf("first: got %s in %d days (%d years)", CardFullName(got[0].Card), got[0].DaysUntil, got[0].Years())
+	}
+	if CardFullName(got[1].Card) != "Alice" || got[1].DaysUntil != 5 || got[1].Years() != 34 {
+		t.Errorf("second: got %s in %d days (%d years)", CardFullName(got[1].Card), got[1].DaysUntil, got[1].Years())
+	}
+
+	got = UpcomingOccasions([]vcard.Card{alice, bob, carol}, from, 10)
+	if len(got) != 3 || got[2].Kind != "anniversary" {
+		t.Fatalf("expected anniversary within 10 days, got %d occasions", len(got))
+	}
+}
+
+func TestUpcomingOccasions_LeapDay(t *testing.T) {
+	card := NewCard("Leap")
+	card.SetValue(vcard.FieldBirthday, "20000229")
+	from := time.Date(2023, time.February, 20, 0, 0, 0, 0, time.UTC)
+	got := UpcomingOccasions([]vcard.Card{card}, from, 30)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 occasion, got %d", len(got))
+	}
+	if got[0].Date.Month() != time.February || got[0].Date.Day() != 28 {
+		t.Errorf("got %s, want Feb 28", got[0].Date.Format("Jan 2"))
+	}
+}
+
+func TestStaleContacts(t *testing.T) {
+	old := NewCard("Old")
+	old.SetValue(vcard.FieldRevision, "20200101T000000Z")
+	older := NewCard("Older")
+	older.SetValue(vcard.FieldRevision, "20190101T000000Z")
+	fresh := NewCard("Fresh")
+	fresh.SetValue(vcard.FieldRevision, "20240101T000000Z")
+	unknown := NewCard("Unknown")
+
+	cutoff := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	got := StaleContacts([]vcard.Card{old, older, fresh, unknown}, cutoff)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 stale contacts, got %d", len(got))
+	}
+	if CardFullName(got[0]) != "Older" || CardFullName(got[1]) != "Old" {
+		t.Errorf("expected oldest first, got %q, %q", CardFullName(got[0]), CardFullName(got[1]))
+	}
+}
+
+func TestFormatDigest(t *testing.T) {
+	card := NewCard("Alice")
+	card.SetValue(vcard.FieldBirthday, "19900615")
+	stale := NewCard("Bob")
+	stale.SetValue(vcard.FieldRevision, "20200101T000000Z")
+
+	from := time.Date(2024, time.June, 14, 0, 0, 0, 0, time.UTC)
+	out := FormatDigest(UpcomingOccasions([]vcard.Card{card}, from, 7), []vcard.Card{stale})
+	for _, want := range []string{"Sat Jun 15", "Alice: birthday (34)", "Stale contacts", "Bob (last updated Jan 1, 2020)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("digest missing %q:\n%s", want, out)
+		}
+	}
+}
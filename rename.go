@@ -0,0 +1,146 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/emersion/go-vcard"
+)
+
+// RenameUID changes a contact's UID locally: the file is renamed, RELATED
+// and MEMBER references in other contacts are rewritten, and the sync
+// index and access history move to the new UID. The provider is not
+// contacted.
+func (cm *ContactManager) RenameUID(oldUID, newUID string) error {
+	if err := ValidateUID(newUID); err != nil {
+		return err
+	}
+	if oldUID == newUID {
+		return nil
+	}
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	card, err := cm.GetContact(oldUID)
+	if err != nil {
+		return err
+	}
+	if card == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, oldUID)
+	}
+	if existing, err := cm.GetContact(newUID); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("%w: a contact with UID %s already exists", ErrConflict, newUID)
+	}
+	card.SetValue(vcard.FieldUID, newUID)
+	return cm.moveContact(card, oldUID)
+}
+
+// moveContact stores card, whose UID was changed from oldUID, under its new
+// UID. The new file is written before anything else changes and the old
+// one is removed last, so an interruption leaves both copies rather than
+// neither.
+func (cm *ContactManager) moveContact(card vcard.Card, oldUID string) error {
+	if err := ValidateCard(card); err != nil {
+		return err
+	}
+	newUID := CardUID(card)
+	index, err := cm.loadIndex()
+	if err != nil {
+		return err
+	}
+	data, err := EncodeCard(card)
+	if err != nil {
+		return fmt.Errorf("failed to marshal contact: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(cm.storagePath, newUID+".vcf"), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write contact file: %w", err)
+	}
+	delete(index, oldUID)
+	index[newUID] = indexEntry{Hash: hashContent(data)}
+
+	if err := cm.rewriteReferences(oldUID, newUID, index); err != nil {
+		return err
+	}
+	if err := cm.saveIndex(index); err != nil {
+		return err
+	}
+	if err := cm.renameRecent(oldUID, newUID); err != nil {
+		return err
+	}
+	if err := cm.updateIDMap(func(ids IDMap) { ids.RenameUID(oldUID, newUID) }); err != nil {
+		return err
+	}
+	if err := cm.renameLink(oldUID, newUID); err != nil {
+		return err
+	}
+	if err := cm.renameHousehold(oldUID, newUID); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(cm.storagePath, oldUID+".vcf")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old contact file: %w", err)
+	}
+	cm.invalidateNames()
+	entry := AuditEntry{Actor: cm.actor, Action: "rename", UID: newUID, PreviousUID: oldUID, Name: CardFullName(card)}
+	if err := cm.appendAudit(entry); err != nil {
+		return err
+	}
+	cm.notifyContact(entry, card)
+	return nil
+}
+
+// rewriteReferences points RELATED and MEMBER fields that refer to oldUID
+// at newUID.
+func (cm *ContactManager) rewriteReferences(oldUID, newUID string, index map[string]indexEntry) error {
+	list, err := cm.ListContacts()
+	if err != nil {
+		return err
+	}
+	for _, other := range list {
+		if CardUID(other) == oldUID {
+			continue
+		}
+		changed := false
+		for _, key := range []string{vcard.FieldRelated, vcard.FieldMember} {
+			for _, f := range other[key] {
+				if uidFromURI(f.Value) == oldUID {
+					f.Value = "urn:uuid:" + newUID
+					changed = true
+				}
+			}
+		}
+		if changed {
+			if err := cm.writeCardFile(other, index, cm.actor); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renameRecent moves a contact's access history to its new UID.
+func (cm *ContactManager) renameRecent(oldUID, newUID string) error {
+	recent, err := cm.loadRecent()
+	if err != nil {
+		return err
+	}
+	entry, ok := recent[oldUID]
+	if !ok {
+		return nil
+	}
+	delete(recent, oldUID)
+	recent[newUID] = entry
+	data, err := json.Marshal(recent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent contacts: %w", err)
+	}
+	if err := writeFileAtomic(cm.recentPath(), data, cm.fileMode); err != nil {
+		return fmt.Errorf("failed to write recent contacts: %w", err)
+	}
+	return nil
+}
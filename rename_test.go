@@ -0,0 +1,138 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_RenameUID(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	org := NewOrgCard("Analytical Engines")
+	ada := NewCard("Ada Lovelace")
+	if err := LinkToOrg(ada, org); err != nil {
+		t.Fatal(err)
+	}
+	group := NewGroupCard("Book Club")
+	AddMember(group, ada)
+	for _, card := range []vcard.Card{org, ada, group} {
+		if err := cm.WriteContact(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cm.RecordAccess(CardUID(ada)); err != nil {
+		t.Fatal(err)
+	}
+	oldUID := CardUID(ada)
+
+	if err := cm.RenameUID(oldUID, "ada"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cm.GetContact(oldUID); got != nil {
+		t.Error("old file still exists")
+	}
+	if got, _ := cm.GetContact("ada"); got == nil || CardUID(got) != "ada" {
+		t.Errorf("GetContact(ada) = %v", got)
+	}
+	group, _ = cm.GetContact(CardUID(group))
+	if uids := GroupMemberUIDs(group); len(uids) != 1 || uids[0] != "ada" {
+		t.Errorf("group members = %v, want [ada]", uids)
+	}
+	members, err := cm.OrgMembers(org)
+	if err != nil || len(members) != 1 || CardUID(members[0]) != "ada" {
+		t.Errorf("OrgMembers = %v, %v", members, err)
+	}
+	if issues, err := cm.Verify(); err != nil || len(issues) != 0 {
+		t.Errorf("Verify() = %v, %v; want no issues", issues, err)
+	}
+	if recent, _ := cm.RecentContacts(1); len(recent) != 1 || CardUID(recent[0]) != "ada" {
+		t.Errorf("RecentContacts = %v, want the renamed contact", recent)
+	}
+	if entries, _ := cm.AuditLog(oldUID, time.Time{}); len(entries) == 0 || entries[len(entries)-1].Action != "rename" {
+		t.Errorf("audit log for old UID = %+v, want a rename", entries)
+	}
+
+	if err := cm.RenameUID("ada", CardUID(org)); !errors.Is(err, ErrConflict) {
+		t.Errorf("RenameUID onto an existing UID = %v, want ErrConflict", err)
+	}
+	if err := cm.RenameUID("missing", "x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RenameUID(missing) = %v, want ErrNotFound", err)
+	}
+	if err := cm.RenameUID("ada", "../escape"); err == nil {
+		t.Error("RenameUID accepted a path as UID")
+	}
+}
+
+// assigningProvider gives new contacts its own ID, like Google does.
+type assigningProvider struct {
+	mockProvider
+}
+
+func (p *assigningProvider) WriteContact(c vcard.Card) error {
+	c.SetValue(vcard.FieldUID, "c123")
+	return nil
+}
+
+func TestContactManager_WriteContactPromotesUID(t *testing.T) {
+	cm, err := NewContactManager(&assigningProvider{}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ada Lovelace")
+	local := CardUID(card)
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cm.GetContact(local); got != nil {
+		t.Error("contact still stored under its local UID")
+	}
+	if got, _ := cm.GetContact("c123"); got == nil {
+		t.Error("contact not stored under the provider's UID")
+	}
+}
+
+// etagProvider stamps each contact it writes with a new etag and records
+// the etag each write was based on.
+type etagProvider struct {
+	mockProvider
+	seen []string
+}
+
+func (p *etagProvider) WriteContact(c vcard.Card) error {
+	p.seen = append(p.seen, c.Value("X-ETAG"))
+	c.SetValue("X-ETAG", fmt.Sprintf("etag-%d", len(p.seen)))
+	return nil
+}
+
+func TestContactManager_WriteContactKeepsProviderChanges(t *testing.T) {
+	provider := &etagProvider{}
+	cm, err := NewContactManager(provider, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ada Lovelace")
+	card.SetValue(FieldProviderID, "c1")
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := cm.GetContact(CardUID(card))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stored.Value("X-ETAG"); got != "etag-1" {
+		t.Fatalf("stored etag = %q, want the provider's", got)
+	}
+	stored.SetValue(vcard.FieldTitle, "Analyst")
+	if err := cm.WriteContact(stored); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.seen) != 2 || provider.seen[1] != "etag-1" {
+		t.Errorf("etags written against = %q, want the first write's", provider.seen)
+	}
+}
@@ -0,0 +1,125 @@
+package contacts
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// ReportRow counts the contacts sharing one organization or email domain.
+type ReportRow struct {
+	Name     string   `json:"name"`
+	Count    int      `json:"count"`
+	Contacts []string `json:"contacts"`
+}
+
+// OrgReport groups cards by organization (the first ORG component,
+// case-insensitively). Cards without an organization are left out.
+func OrgReport(cards []vcard.Card) []ReportRow {
+	return buildReport(cards, func(card vcard.Card) []string {
+		var orgs []string
+		for _, f := range card[vcard.FieldOrganization] {
+			org, _, _ := strings.Cut(f.Value, ";")
+			if org = strings.TrimSpace(org); org != "" {
+				orgs = append(orgs, org)
+			}
+		}
+		return orgs
+	})
+}
+
+// DomainReport groups cards by the domain of each of their email
+// addresses. A card with addresses in several domains counts toward each.
+func DomainReport(cards []vcard.Card) []ReportRow {
+	return buildReport(cards, func(card vcard.Card) []string {
+		var domains []string
+		for _, f := range card[vcard.FieldEmail] {
+			if i := strings.LastIndexByte(f.Value, '@'); i >= 0 {
+				if domain := strings.TrimSpace(f.Value[i+1:]); domain != "" {
+					domains = append(domains, strings.ToLower(domain))
+				}
+			}
+		}
+		return domains
+	})
+}
+
+// buildReport counts each card once under every distinct key returned by
+// keys, sorted by count (largest first) and then name.
+func buildReport(cards []vcard.Card, keys func(vcard.Card) []string) []ReportRow {
+	rows := map[string]*ReportRow{}
+	for _, card := range cards {
+		seen := map[string]bool{}
+		for _, key := range keys(card) {
+			norm := strings.ToLower(key)
+			if seen[norm] {
+				continue
+			}
+			seen[norm] = true
+			row, ok := rows[norm]
+			if !ok {
+				row = &ReportRow{Name: key}
+				rows[norm] = row
+			}
+			row.Count++
+			row.Contacts = append(row.Contacts, CardFullName(card))
+		}
+	}
+	out := make([]ReportRow, 0, len(rows))
+	for _, row := range rows {
+		sort.Strings(row.Contacts)
+		out = append(out, *row)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out
+}
+
+// DefaultCoverageFields are the fields CoverageReport checks by default.
+var DefaultCoverageFields = []string{"email", "phone", "birthday", "address", "photo"}
+
+// CoverageRow counts the contacts missing one field.
+type CoverageRow struct {
+	Field   string  `json:"field"`
+	Missing int     `json:"missing"`
+	Percent float64 `json:"percent"`
+}
+
+// CoverageReport counts, for each field (a vCard name or alias such as
+// "email"), how many cards lack a non-empty value for it and what
+// percentage of all cards that is.
+func CoverageReport(cards []vcard.Card, fields []string) []CoverageRow {
+	out := make([]CoverageRow, 0, len(fields))
+	for _, field := range fields {
+		row := CoverageRow{Field: field, Missing: len(MissingField(cards, field))}
+		if len(cards) > 0 {
+			row.Percent = 100 * float64(row.Missing) / float64(len(cards))
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// MissingField returns the cards without a non-empty value for field.
+func MissingField(cards []vcard.Card, field string) []vcard.Card {
+	key := resolveFieldKey(field)
+	var out []vcard.Card
+	for _, card := range cards {
+		has := false
+		for _, f := range card[key] {
+			if strings.Trim(f.Value, " ;") != "" {
+				has = true
+				break
+			}
+		}
+		if !has {
+			out = append(out, card)
+		}
+	}
+	return out
+}
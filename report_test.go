@@ -0,0 +1,76 @@
+package contacts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func reportCard(name, org string, emails ...string) vcard.Card {
+	card := NewCard(name)
+	if org != "" {
+		card.SetValue(vcard.FieldOrganization, org)
+	}
+	for _, e := range emails {
+		card.Add(vcard.FieldEmail, &vcard.Field{Value: e})
+	}
+	return card
+}
+
+func TestOrgReport(t *testing.T) {
+	cards := []vcard.Card{
+		reportCard("Ann", "Acme;Sales"),
+		reportCard("Bob", "acme"),
+		reportCard("Cat", "Globex"),
+		reportCard("Dan", ""),
+	}
+	want := []ReportRow{
+		{Name: "Acme", Count: 2, Contacts: []string{"Ann", "Bob"}},
+		{Name: "Globex", Count: 1, Contacts: []string{"Cat"}},
+	}
+	if got := OrgReport(cards); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDomainReport(t *testing.T) {
+	cards := []vcard.Card{
+		reportCard("Ann", "", "ann@Example.com", "ann2@example.com"),
+		reportCard("Bob", "", "bob@example.com", "bob@gmail.com"),
+		reportCard("Cat", "", "not-an-address"),
+	}
+	want := []ReportRow{
+		{Name: "example.com", Count: 2, Contacts: []string{"Ann", "Bob"}},
+		{Name: "gmail.com", Count: 1, Contacts: []string{"Bob"}},
+	}
+	if got := DomainReport(cards); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestCoverageReport(t *testing.T) {
+	full := reportCard("Ann", "Acme", "ann@acme.example")
+	full.SetValue(vcard.FieldTelephone, "+15551234567")
+	noEmail := reportCard("Bob", "")
+	noEmail.SetValue(vcard.FieldTelephone, "+15557654321")
+	blank := reportCard("Cat", "", "")
+	blank.SetValue(vcard.FieldAddress, ";;;;;;")
+	cards := []vcard.Card{full, noEmail, blank, reportCard("Dan", "")}
+
+	got := CoverageReport(cards, []string{"email", "phone", "address"})
+	want := []CoverageRow{
+		{Field: "email", Missing: 3, Percent: 75},
+		{Field: "phone", Missing: 2, Percent: 50},
+		{Field: "address", Missing: 4, Percent: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CoverageReport = %+v, want %+v", got, want)
+	}
+	if missing := MissingField(cards, "TEL"); len(missing) != 2 || CardFullName(missing[0]) != "Cat" {
+		t.Errorf("MissingField(TEL) = %v", missing)
+	}
+	if got := CoverageReport(nil, DefaultCoverageFields); len(got) != 5 || got[0].Percent != 0 {
+		t.Errorf("CoverageReport(nil) = %+v", got)
+	}
+}
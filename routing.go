@@ -0,0 +1,122 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// RoutingRule files contacts pulled in by sync: every contact matching the
+// rule is tagged and/or added to a local group. Rules are defined under
+// "routing" in config.json, e.g.
+//
+//	{"where": ["email~@acme.com"], "tag": "work"}
+//	{"in_group": "friends", "group": "Friends"}
+type RoutingRule struct {
+	// Where lists filter expressions (see ParseWhere) a contact must all
+	// match.
+	Where []string `json:"where,omitempty"`
+	// InGroup matches contacts in a provider group or category (see
+	// InGroup).
+	InGroup string `json:"in_group,omitempty"`
+	// Tag is added to the contact's CATEGORIES.
+	Tag string `json:"tag,omitempty"`
+	// Group is the name of a local group the contact is added to. The
+	// group is created if it does not exist.
+	Group string `json:"group,omitempty"`
+}
+
+// Route is a compiled RoutingRule.
+type Route struct {
+	Match Filter
+	Tag   string
+	Group string
+}
+
+// Compile checks the rule and turns it into a Route.
+func (r RoutingRule) Compile() (Route, error) {
+	if r.Tag == "" && r.Group == "" {
+		return Route{}, fmt.Errorf("invalid routing rule: needs a tag or group")
+	}
+	if len(r.Where) == 0 && r.InGroup == "" {
+		return Route{}, fmt.Errorf("invalid routing rule: needs where or in_group")
+	}
+	var filters []Filter
+	for _, expr := range r.Where {
+		f, err := ParseWhere(expr)
+		if err != nil {
+			return Route{}, fmt.Errorf("invalid routing rule: %w", err)
+		}
+		filters = append(filters, f)
+	}
+	if r.InGroup != "" {
+		filters = append(filters, InGroup(r.InGroup))
+	}
+	return Route{
+		Match: func(card vcard.Card) bool { return len(FilterCards([]vcard.Card{card}, filters...)) == 1 },
+		Tag:   strings.TrimSpace(r.Tag),
+		Group: strings.TrimSpace(r.Group),
+	}, nil
+}
+
+// WithRouting files contacts pulled in by SyncContacts and ApplySyncPlan
+// according to routes. Routes are applied each time a contact changes at
+// the provider, so a contact that comes to match a rule later is filed too.
+func WithRouting(routes ...Route) ManagerOption {
+	return func(cm *ContactManager) {
+		cm.routes = append(cm.routes, routes...)
+	}
+}
+
+// routeTags adds the tags of the routes matching a fetched card. It runs
+// before the card is compared with the stored copy, so tags it adds do not
+// make an unchanged contact look modified.
+func (cm *ContactManager) routeTags(card vcard.Card) {
+	if card.Kind() != vcard.KindIndividual {
+		return
+	}
+	for _, r := range cm.routes {
+		if r.Tag != "" && r.Match(card) {
+			addCategories(card, r.Tag)
+		}
+	}
+}
+
+// routeGroups adds stored synced cards to the local groups of the routes
+// they match, creating groups as needed.
+func (cm *ContactManager) routeGroups(cards []vcard.Card, index map[string]indexEntry) error {
+	groups := map[string]vcard.Card{}
+	changed := map[string]bool{}
+	for _, r := range cm.routes {
+		if r.Group == "" {
+			continue
+		}
+		for _, card := range cards {
+			if card.Kind() != vcard.KindIndividual || CardUID(card) == "" || !r.Match(card) {
+				continue
+			}
+			key := strings.ToLower(r.Group)
+			group, ok := groups[key]
+			if !ok {
+				var err error
+				if group, err = cm.FindGroup(r.Group); err != nil {
+					return err
+				}
+				if group == nil {
+					group = NewGroupCard(r.Group)
+				}
+				groups[key] = group
+			}
+			if AddMember(group, card) {
+				changed[key] = true
+			}
+		}
+	}
+	for key := range changed {
+		if err := cm.writeCardFile(groups[key], index, ActorSync); err != nil {
+			return err
+		}
+	}
+	return nil
+}
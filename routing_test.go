@@ -0,0 +1,85 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestRoutingRule_Compile(t *testing.T) {
+	for _, rule := range []RoutingRule{
+		{Where: []string{"email~@acme.com"}},
+		{Tag: "work"},
+		{Where: []string{"=x"}, Tag: "work"},
+	} {
+		if _, err := rule.Compile(); err == nil {
+			t.Errorf("Compile(%+v) succeeded, want error", rule)
+		}
+	}
+	route, err := RoutingRule{Where: []string{"email~@acme.com"}, InGroup: "colleagues", Tag: "work"}.Compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ada")
+	card.SetValue(vcard.FieldEmail, "ada@acme.com")
+	if route.Match(card) {
+		t.Error("rule matched a card outside in_group")
+	}
+	card.SetCategories([]string{"colleagues"})
+	if !route.Match(card) {
+		t.Error("rule did not match a card meeting every condition")
+	}
+}
+
+func TestContactManager_SyncRouting(t *testing.T) {
+	work := NewCard("Ada Lovelace")
+	work.SetValue(vcard.FieldEmail, "ada@acme.com")
+	friend := NewCard("Charles Babbage")
+	friend.Add("X-GOOGLE-GROUP-MEMBERSHIP", &vcard.Field{Value: "contactGroups/friends"})
+	other := NewCard("Mary Somerville")
+
+	var routes []Route
+	for _, rule := range []RoutingRule{
+		{Where: []string{"email~@acme.com"}, Tag: "work"},
+		{InGroup: "friends", Tag: "friend", Group: "Friends"},
+	} {
+		r, err := rule.Compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		routes = append(routes, r)
+	}
+	provider := &mockProvider{contacts: []vcard.Card{work, friend, other}}
+	cm, err := NewContactManager(provider, t.TempDir(), WithRouting(routes...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	for uid, want := range map[string]bool{CardUID(work): true, CardUID(friend): false, CardUID(other): false} {
+		card, err := cm.GetContact(uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := InGroup("work")(card); got != want {
+			t.Errorf("%s tagged work = %v, want %v", CardFullName(card), got, want)
+		}
+	}
+	group, err := cm.FindGroup("Friends")
+	if err != nil || group == nil {
+		t.Fatalf("FindGroup(Friends) = %v, %v", group, err)
+	}
+	if uids := GroupMemberUIDs(group); len(uids) != 1 || uids[0] != CardUID(friend) {
+		t.Errorf("Friends members = %v, want %s", uids, CardUID(friend))
+	}
+
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 0 || len(result.Conflicts) != 0 {
+		t.Errorf("second sync = %+v, want routed contacts unchanged", result)
+	}
+}
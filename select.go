@@ -0,0 +1,80 @@
+package contacts
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// fieldComponents names the components of structured fields, in order, for
+// SelectField.
+var fieldComponents = map[string][]string{
+	vcard.FieldAddress:      {"pobox", "ext", "street", "city", "region", "postal", "country"},
+	vcard.FieldName:         {"family", "given", "additional", "prefix", "suffix"},
+	vcard.FieldOrganization: {"name", "unit"},
+}
+
+var selectorPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*)(?:\[(\d+)\])?(?:\.([a-z]+))?$`)
+
+// SelectField returns the values of card a selector picks out, for
+// scripting. A selector is a field name or alias, optionally followed by
+// an index and a component:
+//
+//	email         every EMAIL value
+//	tel[0]        the first TEL value
+//	adr.city      the city of every ADR (see fieldComponents for names)
+//	email[1].type the TYPE of the second EMAIL
+//
+// Addresses and organizations without a component are formatted for
+// reading. It returns ErrNotFound if the card has no such value.
+func SelectField(card vcard.Card, selector string) ([]string, error) {
+	m := selectorPattern.FindStringSubmatch(strings.TrimSpace(selector))
+	if m == nil {
+		return nil, fmt.Errorf("invalid field selector %q: expected field, field[index] or field.component", selector)
+	}
+	key, index, component := resolveFieldKey(m[1]), m[2], m[3]
+	fields := card[key]
+	if index != "" {
+		i, _ := strconv.Atoi(index)
+		if i >= len(fields) {
+			return nil, fmt.Errorf("%w: %s has %d %s values", ErrNotFound, CardFullName(card), len(fields), key)
+		}
+		fields = fields[i : i+1]
+	}
+	pos := -1
+	if component != "" && component != "type" {
+		if pos = slices.Index(fieldComponents[key], component); pos < 0 {
+			if names := fieldComponents[key]; len(names) > 0 {
+				return nil, fmt.Errorf("invalid field selector %q: %s components are %s and type", selector, key, strings.Join(names, ", "))
+			}
+			return nil, fmt.Errorf("invalid field selector %q: %s has only a type component", selector, key)
+		}
+	}
+	var out []string
+	for _, f := range fields {
+		var value string
+		switch {
+		case component == "type":
+			value = strings.Join(f.Params.Types(), ",")
+		case pos >= 0:
+			value = structuredParts(f.Value, len(fieldComponents[key]))[pos]
+		case key == vcard.FieldAddress:
+			value = FormatAddress(f.Value)
+		case key == vcard.FieldOrganization:
+			value = strings.Trim(strings.ReplaceAll(f.Value, ";", ", "), ", ")
+		default:
+			value = f.Value
+		}
+		if value != "" {
+			out = append(out, value)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: %s has no %s", ErrNotFound, CardFullName(card), selector)
+	}
+	return out, nil
+}
@@ -0,0 +1,49 @@
+package contacts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestSelectField(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldName, "Lovelace;Ada;;;")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@example.com", Params: vcard.Params{vcard.ParamType: {"home"}}})
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@work.example", Params: vcard.Params{vcard.ParamType: {"work"}}})
+	card.Add(vcard.FieldTelephone, &vcard.Field{Value: "+44 20 7946 0000"})
+	card.Add(vcard.FieldAddress, &vcard.Field{Value: ";;12 St James's Sq;London;;SW1Y 4JH;UK"})
+	card.SetValue(vcard.FieldOrganization, "Analytical Engines;Research")
+
+	tests := map[string]string{
+		"email":         "ada@example.com|ada@work.example",
+		"EMAIL[1]":      "ada@work.example",
+		"email[1].type": "work",
+		"tel[0]":        "+44 20 7946 0000",
+		"adr.city":      "London",
+		"adr[0].postal": "SW1Y 4JH",
+		"address":       "12 St James's Sq, London, SW1Y 4JH, UK",
+		"n.given":       "Ada",
+		"org":           "Analytical Engines, Research",
+		"org.unit":      "Research",
+	}
+	for selector, want := range tests {
+		got, err := SelectField(card, selector)
+		if err != nil || strings.Join(got, "|") != want {
+			t.Errorf("SelectField(%q) = %q, %v; want %q", selector, got, err, want)
+		}
+	}
+
+	for _, selector := range []string{"email[2]", "note", "adr.region"} {
+		if _, err := SelectField(card, selector); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SelectField(%q) = %v, want ErrNotFound", selector, err)
+		}
+	}
+	for _, selector := range []string{"email.city", "tel[x]", ""} {
+		if _, err := SelectField(card, selector); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("SelectField(%q) = %v, want a selector error", selector, err)
+		}
+	}
+}
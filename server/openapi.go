@@ -0,0 +1,335 @@
+package server
+
+// OpenAPISpec returns the OpenAPI 3 document describing Server's API. With
+// auth, API operations require a bearer token.
+func OpenAPISpec(auth bool) map[string]any {
+	typed := func(desc string) map[string]any {
+		return map[string]any{
+			"type":        "array",
+			"description": desc,
+			"items":       schemaRef("TypedValue"),
+		}
+	}
+	strings := func(desc string) map[string]any {
+		return map[string]any{
+			"type":        "array",
+			"description": desc,
+			"items":       map[string]any{"type": "string"},
+		}
+	}
+	str := func(desc string) map[string]any {
+		return map[string]any{"type": "string", "description": desc}
+	}
+
+	uidParam := map[string]any{
+		"name":     "uid",
+		"in":       "path",
+		"required": true,
+		"schema":   map[string]any{"type": "string"},
+	}
+	vcardBody := map[string]any{
+		"required": true,
+		"content": map[string]any{
+			"text/vcard": map[string]any{"schema": map[string]any{"type": "string"}},
+		},
+	}
+	contactResponse := func(desc string) map[string]any {
+		return map[string]any{
+			"description": desc,
+			"headers":     map[string]any{"ETag": map[string]any{"schema": map[string]any{"type": "string"}}},
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaRef("Contact")},
+				"text/vcard":       map[string]any{"schema": map[string]any{"type": "string"}},
+			},
+		}
+	}
+	inboundResponse := func(desc string) map[string]any {
+		return map[string]any{
+			"description": desc,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaRef("InboundResult")},
+			},
+		}
+	}
+	errorResponse := func(desc string) map[string]any {
+		return map[string]any{
+			"description": desc,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaRef("Error")},
+			},
+		}
+	}
+	header := func(name, desc string) map[string]any {
+		return map[string]any{
+			"name":        name,
+			"in":          "header",
+			"description": desc,
+			"schema":      map[string]any{"type": "string"},
+		}
+	}
+	op := func(id, summary string, params []any, body map[string]any, responses map[string]any) map[string]any {
+		o := map[string]any{
+			"operationId": id,
+			"summary":     summary,
+			"responses":   responses,
+		}
+		if len(params) > 0 {
+			o["parameters"] = params
+		}
+		if body != nil {
+			o["requestBody"] = body
+		}
+		responses["429"] = map[string]any{
+			"description": "Rate limited; retry after the Retry-After header's seconds",
+		}
+		if auth {
+			o["security"] = []any{map[string]any{"bearerAuth": []any{}}}
+			responses["401"] = errorResponse("Missing or invalid bearer token")
+		}
+		return o
+	}
+
+	spec := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "contacts",
+			"version": "1.0.0",
+		},
+		"paths": map[string]any{
+			"/contacts": map[string]any{
+				"get": op("listContacts", "List all contacts",
+					[]any{header("If-None-Match", "ETag of a previous response")}, nil,
+					map[string]any{
+						"200": map[string]any{
+							"description": "All contacts",
+							"headers":     map[string]any{"ETag": map[string]any{"schema": map[string]any{"type": "string"}}},
+							"content": map[string]any{
+								"application/json": map[string]any{"schema": map[string]any{"type": "array", "items": schemaRef("Contact")}},
+							},
+						},
+						"304": map[string]any{"description": "Not modified"},
+					}),
+				"post": op("createContact", "Create a contact from a vCard", nil, vcardBody,
+					map[string]any{
+						"201": contactResponse("The created contact"),
+						"400": errorResponse("Invalid vCard"),
+						"409": errorResponse("A contact with the card's UID exists"),
+					}),
+			},
+			"/contacts:batch": map[string]any{
+				"post": op("batchContacts", "Apply creates, updates and deletes as one transaction: all are stored, or none are",
+					nil, map[string]any{
+						"required": true,
+						"content": map[string]any{
+							"application/json": map[string]any{"schema": schemaRef("BatchRequest")},
+						},
+					},
+					map[string]any{
+						"200": map[string]any{
+							"description": "Every operation was applied",
+							"content": map[string]any{
+								"application/json": map[string]any{"schema": schemaRef("BatchResponse")},
+							},
+						},
+						"400": errorResponse("A malformed operation; nothing was applied"),
+						"403": errorResponse("An update or delete of a read-only directory contact; nothing was applied"),
+						"404": errorResponse("An update or delete of a missing contact; nothing was applied"),
+						"409": errorResponse("A create of an existing contact; nothing was applied"),
+						"412": errorResponse("An if_match that failed; nothing was applied"),
+					}),
+			},
+			"/contacts/{uid}": map[string]any{
+				"get": op("getContact", "Get a contact as JSON or, with Accept: text/vcard, as a vCard",
+					[]any{uidParam, header("If-None-Match", "ETag of a previous response")}, nil,
+					map[string]any{
+						"200": contactResponse("The contact"),
+						"304": map[string]any{"description": "Not modified"},
+						"404": errorResponse("No such contact"),
+					}),
+				"put": op("putContact", "Create or replace a contact from a vCard",
+					[]any{uidParam, header("If-Match", "only update this version"), header("If-None-Match", "* to only create")}, vcardBody,
+					map[string]any{
+						"200": contactResponse("The updated contact"),
+						"201": contactResponse("The created contact"),
+						"400": errorResponse("Invalid vCard"),
+						"403": errorResponse("The contact is synced from a read-only directory"),
+						"412": errorResponse("The contact has changed"),
+					}),
+				"delete": op("deleteContact", "Delete a contact",
+					[]any{uidParam, header("If-Match", "only delete this version")}, nil,
+					map[string]any{
+						"204": map[string]any{"description": "Deleted"},
+						"403": errorResponse("The contact is synced from a read-only directory"),
+						"404": errorResponse("No such contact"),
+						"412": errorResponse("The contact has changed"),
+					}),
+			},
+			"/inbound": map[string]any{
+				"post": op("receiveInbound", "Receive a contact from a web form or another service, merging it into an existing contact with the same email address or phone number",
+					nil, map[string]any{
+						"required": true,
+						"content": map[string]any{
+							"application/json":                  map[string]any{"schema": schemaRef("InboundContact")},
+							"application/x-www-form-urlencoded": map[string]any{"schema": schemaRef("InboundContact")},
+							"text/vcard":                        map[string]any{"schema": map[string]any{"type": "string"}},
+						},
+					},
+					map[string]any{
+						"200": inboundResponse("Merged into an existing contact"),
+						"201": inboundResponse("Created a new contact"),
+						"400": errorResponse("Invalid submission"),
+						"415": errorResponse("Unsupported content type"),
+					}),
+			},
+			"/healthz": map[string]any{
+				"get": map[string]any{
+					"operationId": "healthz",
+					"summary":     "Report whether the store is readable",
+					"responses": map[string]any{
+						"200": map[string]any{"description": "Healthy"},
+						"503": map[string]any{"description": "The store is unavailable"},
+					},
+				},
+			},
+			"/contacts/{uid}/photo": map[string]any{
+				"get": op("getPhoto", "Get a contact's photo",
+					[]any{uidParam, map[string]any{
+						"name":        "size",
+						"in":          "query",
+						"description": "scale the photo to fit size×size pixels, as JPEG",
+						"schema":      map[string]any{"type": "integer", "minimum": 1, "maximum": maxPhotoSize},
+					}}, nil,
+					map[string]any{
+						"200": map[string]any{
+							"description": "The photo",
+							"content":     map[string]any{"image/*": map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}},
+						},
+						"400": errorResponse("Invalid size"),
+						"404": errorResponse("No such contact or no photo"),
+						"502": errorResponse("The photo could not be fetched"),
+					}),
+			},
+		},
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"TypedValue": map[string]any{
+					"type":     "object",
+					"required": []any{"value"},
+					"properties": map[string]any{
+						"value": str("The value"),
+						"type":  str("vCard TYPE, e.g. work or home"),
+					},
+				},
+				"Contact": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"uid":          str("Unique identifier"),
+						"name":         str("Formatted name"),
+						"nickname":     str("Nickname"),
+						"organization": str("Organization and units, comma-separated"),
+						"title":        str("Job title"),
+						"phones":       typed("Phone numbers"),
+						"emails":       typed("Email addresses"),
+						"addresses":    typed("Postal addresses, formatted on one line"),
+						"birthday":     str("Birthday, formatted for display"),
+						"anniversary":  str("Anniversary, formatted for display"),
+						"urls":         typed("Web pages"),
+						"im":           strings("Instant messaging URIs"),
+						"related":      typed("Related people"),
+						"timezone":     str("IANA time zone or UTC offset"),
+						"geo": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"lat": map[string]any{"type": "number"},
+								"lon": map[string]any{"type": "number"},
+							},
+						},
+						"gender":       str("vCard GENDER"),
+						"pronouns":     str("Pronouns, e.g. she/her"),
+						"former_names": strings("Names the contact went by before, such as a maiden name"),
+						"notes":        strings("Notes"),
+						"groups":       strings("Group display names"),
+						"starred":      map[string]any{"type": "boolean", "description": "In the starred group"},
+						"blocked":      map[string]any{"type": "boolean", "description": "In the blocked group"},
+						"interests":    strings("Interests"),
+						"skills":       strings("Skills"),
+						"occupations":  strings("Occupations"),
+						"locations":    strings("Locations"),
+					},
+				},
+				"InboundContact": map[string]any{
+					"type":        "object",
+					"description": "A name or an email address is required",
+					"properties": map[string]any{
+						"name":         str("Full name"),
+						"email":        str("Email address"),
+						"phone":        str("Phone number"),
+						"organization": str("Organization"),
+						"message":      str("Recorded as a message interaction"),
+						"tags":         strings("Tags to add, besides inbound"),
+					},
+				},
+				"InboundResult": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"action":  map[string]any{"type": "string", "enum": []any{"created", "merged"}},
+						"contact": schemaRef("Contact"),
+					},
+				},
+				"BatchRequest": map[string]any{
+					"type":     "object",
+					"required": []any{"operations"},
+					"properties": map[string]any{
+						"operations": map[string]any{
+							"type":     "array",
+							"minItems": 1,
+							"maxItems": maxBatchOps,
+							"items": map[string]any{
+								"type":     "object",
+								"required": []any{"op"},
+								"properties": map[string]any{
+									"op":       map[string]any{"type": "string", "enum": []any{"create", "update", "delete"}},
+									"uid":      str("The contact to update or delete"),
+									"card":     str("The vCard to create or update"),
+									"if_match": str("ETag the stored contact must have"),
+								},
+							},
+						},
+					},
+				},
+				"BatchResponse": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"results": map[string]any{
+							"type": "array",
+							"items": map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"op":      str("The operation"),
+									"uid":     str("The contact's UID"),
+									"etag":    str("The stored version"),
+									"pending": map[string]any{"type": "boolean", "description": "Not yet pushed to the provider; retried before the next sync"},
+									"contact": schemaRef("Contact"),
+								},
+							},
+						},
+					},
+				},
+				"Error": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"error": str("What went wrong")},
+				},
+			},
+		},
+	}
+	if auth {
+		spec["components"].(map[string]any)["securitySchemes"] = map[string]any{
+			"bearerAuth": map[string]any{"type": "http", "scheme": "bearer"},
+		}
+	}
+	return spec
+}
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
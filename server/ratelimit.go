@@ -0,0 +1,60 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket per client: each client may make up to
+// perMinute requests in a burst, refilled at perMinute per minute.
+type rateLimiter struct {
+	perMinute float64
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMinute: float64(perMinute), buckets: map[string]*bucket{}}
+}
+
+// allow takes a token from client's bucket. If there is none, it returns
+// false and how long until there will be.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.perMinute, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.perMinute, b.tokens+now.Sub(b.last).Minutes()*l.perMinute)
+	b.last = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perMinute * float64(time.Minute))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune forgets, at most once a minute, the clients whose buckets have
+// refilled completely, so the map does not grow with every address seen.
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= time.Minute {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(60)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 60; i++ {
+		if ok, _ := l.allow("a", now); !ok {
+			t.Fatalf("request %d refused within the burst", i+1)
+		}
+	}
+	ok, wait := l.allow("a", now)
+	if ok || wait != time.Second {
+		t.Errorf("allow after burst = %v, %v; want false, 1s", ok, wait)
+	}
+	if ok, _ := l.allow("b", now); !ok {
+		t.Error("another client was limited")
+	}
+	if ok, _ := l.allow("a", now.Add(time.Second)); !ok {
+		t.Error("no token refilled after a second")
+	}
+
+	l.allow("a", now.Add(2*time.Minute))
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("idle client's bucket was not pruned")
+	}
+}
@@ -0,0 +1,743 @@
+// Package server serves a contacts store over HTTP: the JSON API, its
+// OpenAPI description and the pages of shared contacts.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+)
+
+// Server serves a store over HTTP: a JSON API under /contacts and the
+// pages of contacts shared with ShareToken under /share.
+type Server struct {
+	cm      *contacts.ContactManager
+	mux     *http.ServeMux
+	now     func() time.Time
+	tokens  []string
+	limiter *rateLimiter
+	log     io.Writer
+	proxies []netip.Prefix
+}
+
+// ServerOption configures optional Server behaviour.
+type ServerOption func(*Server)
+
+// WithTokens requires API requests to carry one of tokens as a bearer
+// token (Authorization: Bearer <token>). Share pages stay public, since
+// their links are signed.
+func WithTokens(tokens ...string) ServerOption {
+	return func(s *Server) {
+		s.tokens = append(s.tokens, tokens...)
+	}
+}
+
+// WithRateLimit limits each client to perMinute requests a minute, with
+// bursts of up to perMinute. Further requests get 429 Too Many Requests.
+// /healthz is not limited.
+func WithRateLimit(perMinute int) ServerOption {
+	return func(s *Server) {
+		if perMinute > 0 {
+			s.limiter = newRateLimiter(perMinute)
+		}
+	}
+}
+
+// WithRequestLog writes a line per request to w: the time, client,
+// method, path, status, response size and latency.
+func WithRequestLog(w io.Writer) ServerOption {
+	return func(s *Server) {
+		s.log = w
+	}
+}
+
+// WithTrustedProxies names the reverse proxies whose X-Forwarded-For
+// header is believed when identifying clients for rate limiting and
+// logging. Requests from other addresses are identified by their own.
+func WithTrustedProxies(prefixes ...netip.Prefix) ServerOption {
+	return func(s *Server) {
+		s.proxies = append(s.proxies, prefixes...)
+	}
+}
+
+// NewServer returns a Server for cm.
+func NewServer(cm *contacts.ContactManager, opts ...ServerOption) *Server {
+	s := &Server{cm: cm, mux: http.NewServeMux(), now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("GET /contacts", s.authorized(s.listContacts))
+	s.mux.HandleFunc("POST /contacts", s.authorized(s.createContact))
+	s.mux.HandleFunc("POST /contacts:batch", s.authorized(s.batch))
+	s.mux.HandleFunc("GET /contacts/{uid}", s.authorized(s.getContact))
+	s.mux.HandleFunc("PUT /contacts/{uid}", s.authorized(s.putContact))
+	s.mux.HandleFunc("DELETE /contacts/{uid}", s.authorized(s.deleteContact))
+	s.mux.HandleFunc("GET /contacts/{uid}/photo", s.authorized(s.getPhoto))
+	s.mux.HandleFunc("POST /inbound", s.authorized(s.inbound))
+	s.mux.HandleFunc("GET /share/{token}", s.sharedContact)
+	s.mux.HandleFunc("GET /healthz", s.healthz)
+	s.mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, OpenAPISpec(len(s.tokens) > 0))
+	})
+	return s
+}
+
+// authorized wraps an API handler to check the bearer token, if the server
+// has any.
+func (s *Server) authorized(h http.HandlerFunc) http.HandlerFunc {
+	if len(s.tokens) == 0 {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok && validToken(s.tokens, strings.TrimSpace(token)) {
+			h(w, r)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="contacts"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid bearer token"})
+	}
+}
+
+// validToken compares token with each allowed token in constant time.
+func validToken(allowed []string, token string) bool {
+	ok := false
+	for _, t := range allowed {
+		if t != "" && subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := s.now()
+	rec := &statusRecorder{ResponseWriter: w}
+	client := s.clientAddr(r)
+	if ok, wait := s.allow(client, r, start); ok {
+		s.mux.ServeHTTP(rec, r)
+	} else {
+		rec.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		writeJSON(rec, http.StatusTooManyRequests, map[string]string{"error": "too many requests"})
+	}
+	if s.log != nil {
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		fmt.Fprintf(s.log, "%s %s %s %s %d %d %s\n", start.UTC().Format(time.RFC3339), client, r.Method, r.URL.RequestURI(), rec.status, rec.bytes, s.now().Sub(start).Round(time.Microsecond))
+	}
+}
+
+// allow applies the rate limit, if any, to a request.
+func (s *Server) allow(client string, r *http.Request, now time.Time) (bool, time.Duration) {
+	if s.limiter == nil || r.URL.Path == "/healthz" {
+		return true, 0
+	}
+	return s.limiter.allow(client, now)
+}
+
+// clientAddr identifies the client making a request: its IP address, or
+// for a request through a trusted proxy the last address in
+// X-Forwarded-For that is not itself a trusted proxy.
+func (s *Server) clientAddr(r *http.Request) string {
+	addr, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		// Unix sockets have no peer address.
+		return "-"
+	}
+	client := addr.Addr().Unmap()
+	if !s.trusted(client) {
+		return client.String()
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = hop.Unmap()
+		if !s.trusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func (s *Server) trusted(addr netip.Addr) bool {
+	for _, p := range s.proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusRecorder remembers the status and size of a response for the
+// request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// healthz reports whether the store is readable, for monitoring and
+// reverse proxy health checks. It needs no token.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	if err := s.cm.CheckStore(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) listContacts(w http.ResponseWriter, r *http.Request) {
+	list, err := s.cm.ListContacts()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]map[string]any, 0, len(list))
+	for _, card := range list {
+		out = append(out, contacts.CardToMap(card))
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	etag := contacts.ContentETag(data)
+	w.Header().Set("ETag", etag)
+	if contacts.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+func (s *Server) getContact(w http.ResponseWriter, r *http.Request) {
+	card, err := s.contact(r.PathValue("uid"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	etag, err := contacts.CardETag(card)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	if contacts.ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if wantsVCard(r) {
+		writeVCard(w, card, false)
+		return
+	}
+	writeJSON(w, http.StatusOK, contacts.CardToMap(card))
+}
+
+// createContact stores the vCard in the request body as a new contact and
+// responds with its location.
+func (s *Server) createContact(w http.ResponseWriter, r *http.Request) {
+	card, err := readCard(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if uid := contacts.CardUID(card); uid != "" {
+		existing, err := s.cm.GetContact(uid)
+		if err == nil && existing != nil {
+			err = fmt.Errorf("%w: a contact with UID %s already exists", contacts.ErrConflict, uid)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	s.writeContact(w, card, http.StatusCreated)
+}
+
+// putContact replaces a contact with the vCard in the request body,
+// creating it if it does not exist. If-Match makes the update conditional
+// on the stored version, so concurrent clients cannot overwrite each
+// other's changes; If-None-Match: * only creates.
+func (s *Server) putContact(w http.ResponseWriter, r *http.Request) {
+	uid := r.PathValue("uid")
+	if err := contacts.ValidateUID(uid); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	card, err := readCard(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if got := contacts.CardUID(card); got == "" {
+		card.SetValue(vcard.FieldUID, uid)
+	} else if got != uid {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("card UID %s does not match %s", got, uid)})
+		return
+	}
+	created, err := s.cm.WriteContactIf(card, preconditions(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	s.respondContact(w, contacts.CardUID(card), status)
+}
+
+// maxBatchOps and maxBatchBytes cap the operations in one batch request
+// and its size.
+const (
+	maxBatchOps   = 1000
+	maxBatchBytes = 10 << 20
+)
+
+// batchRequest is the body of POST /contacts:batch.
+type batchRequest struct {
+	Operations []struct {
+		Op      contacts.BatchOpKind `json:"op"`
+		UID     string               `json:"uid"`
+		Card    string               `json:"card"`
+		IfMatch string               `json:"if_match"`
+	} `json:"operations"`
+}
+
+// batch applies a list of creates, updates and deletes with ApplyBatch:
+// either all of them are stored or, if one fails its checks, none are.
+func (s *Server) batch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBatchBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid batch: %v", err)})
+		return
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > maxBatchOps {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("a batch needs 1 to %d operations", maxBatchOps)})
+		return
+	}
+	ops := make([]contacts.BatchOp, len(req.Operations))
+	for i, o := range req.Operations {
+		ops[i] = contacts.BatchOp{Op: o.Op, UID: o.UID, IfMatch: o.IfMatch}
+		if o.Op == contacts.BatchDelete {
+			continue
+		}
+		card, err := contacts.DecodeCard([]byte(o.Card))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("operation %d: %v", i+1, err)})
+			return
+		}
+		if o.Op == contacts.BatchUpdate && contacts.CardUID(card) == "" {
+			card.SetValue(vcard.FieldUID, o.UID)
+		}
+		ops[i].Card = card
+	}
+	results, err := s.cm.ApplyBatch(ops)
+	if errors.Is(err, contacts.ErrInvalidBatch) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	out := make([]map[string]any, len(results))
+	for i, res := range results {
+		out[i] = map[string]any{"op": res.Op, "uid": res.UID}
+		if res.Pending {
+			out[i]["pending"] = true
+		}
+		if res.Card != nil {
+			etag, err := contacts.CardETag(res.Card)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			out[i]["etag"] = etag
+			out[i]["contact"] = contacts.CardToMap(res.Card)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": out})
+}
+
+// inbound receives a contact pushed by another service, as JSON (an
+// contacts.InboundContact), form fields of the same names, or a vCard, and stores
+// it with ReceiveInbound. A vCard's UID is replaced, so a submission can
+// never overwrite an existing contact directly.
+func (s *Server) inbound(w http.ResponseWriter, r *http.Request) {
+	var in contacts.InboundContact
+	var card vcard.Card
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		dec := json.NewDecoder(io.LimitReader(r.Body, maxCardBytes))
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&in); err == nil {
+			card, err = in.Card()
+		}
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		r.Body = http.MaxBytesReader(w, r.Body, maxCardBytes)
+		if err = r.ParseMultipartForm(maxCardBytes); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			break
+		}
+		in = contacts.InboundContact{
+			Name:         r.PostFormValue("name"),
+			Email:        r.PostFormValue("email"),
+			Phone:        r.PostFormValue("phone"),
+			Organization: r.PostFormValue("organization"),
+			Message:      r.PostFormValue("message"),
+			Tags:         r.PostForm["tags"],
+		}
+		card, err = in.Card()
+	case "text/vcard", "text/x-vcard":
+		if card, err = readCard(r); err == nil {
+			card.SetValue(vcard.FieldUID, uuid.New().String())
+		}
+	default:
+		writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "expected JSON, form data or a vCard"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := s.cm.ReceiveInbound(card, in.Message)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status, action := http.StatusCreated, "created"
+	if result.Merged {
+		status, action = http.StatusOK, "merged"
+	}
+	w.Header().Set("Location", "/contacts/"+url.PathEscape(contacts.CardUID(result.Card)))
+	writeJSON(w, status, map[string]any{"action": action, "contact": contacts.CardToMap(result.Card)})
+}
+
+func (s *Server) deleteContact(w http.ResponseWriter, r *http.Request) {
+	if err := s.cm.DeleteContactIf(r.PathValue("uid"), preconditions(r)); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// preconditions returns the request's If-Match and If-None-Match headers,
+// which the manager checks against the stored version of a contact.
+func preconditions(r *http.Request) contacts.Preconditions {
+	return contacts.Preconditions{IfMatch: r.Header.Get("If-Match"), IfNoneMatch: r.Header.Get("If-None-Match")}
+}
+
+// writeContact stores card and responds with its new version.
+func (s *Server) writeContact(w http.ResponseWriter, card vcard.Card, status int) {
+	if err := s.cm.WriteContact(card); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.respondContact(w, contacts.CardUID(card), status)
+}
+
+// respondContact responds with the stored version of a contact.
+func (s *Server) respondContact(w http.ResponseWriter, uid string, status int) {
+	card, err := s.contact(uid)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	etag, err := contacts.CardETag(card)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Location", "/contacts/"+url.PathEscape(contacts.CardUID(card)))
+	writeJSON(w, status, contacts.CardToMap(card))
+}
+
+// maxCardBytes caps the size of a vCard in a request body.
+const maxCardBytes = 1 << 20
+
+// readCard decodes the vCard in a request body.
+func readCard(r *http.Request) (vcard.Card, error) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxCardBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(data) > maxCardBytes {
+		return nil, fmt.Errorf("vcard is larger than %d bytes", maxCardBytes)
+	}
+	return contacts.DecodeCard(data)
+}
+
+// maxPhotoSize caps the ?size of a resized photo.
+const maxPhotoSize = 2048
+
+// getPhoto serves a contact's photo from the photo cache, fetching it on
+// first use. ?size=N scales it down to fit within N×N pixels as JPEG.
+func (s *Server) getPhoto(w http.ResponseWriter, r *http.Request) {
+	card, err := s.contact(r.PathValue("uid"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := s.cm.CachedPhoto(card)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	if data == nil {
+		writeError(w, fmt.Errorf("%w: %s has no photo", contacts.ErrNotFound, contacts.CardUID(card)))
+		return
+	}
+	if v := r.URL.Query().Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 || size > maxPhotoSize {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid size %q: expected 1 to %d", v, maxPhotoSize)})
+			return
+		}
+		if data, err = contacts.ResizeJPEG(data, size); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.Write(data)
+}
+
+// sharedContact serves the contact a share token grants access to: an
+// HTML page with a QR code to scan it from another phone, or the vCard
+// with ?format=vcf.
+func (s *Server) sharedContact(w http.ResponseWriter, r *http.Request) {
+	uid, err := s.cm.VerifyShareToken(r.PathValue("token"), s.now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	card, err := s.contact(uid)
+	if err != nil {
+		http.Error(w, "contact not found", http.StatusNotFound)
+		return
+	}
+	if wantsVCard(r) {
+		writeVCard(w, card, true)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	sharePage.Execute(w, struct {
+		Name, Card string
+		QR         template.URL
+		Fields     []shareField
+	}{contacts.CardFullName(card), "?format=vcf", shareQR(r, card), shareFields(card)})
+}
+
+// qrFields are the fields put in a shared contact's QR code; photos and
+// the rest would make it too dense to scan.
+var qrFields = []string{
+	vcard.FieldVersion,
+	vcard.FieldFormattedName,
+	vcard.FieldName,
+	vcard.FieldOrganization,
+	vcard.FieldTelephone,
+	vcard.FieldEmail,
+	vcard.FieldAddress,
+	vcard.FieldURL,
+}
+
+// shareQR returns a PNG data URL of a QR code holding the shared contact's
+// vCard, or a link to download it if the card is too long for one.
+func shareQR(r *http.Request, card vcard.Card) template.URL {
+	small := make(vcard.Card)
+	for _, name := range qrFields {
+		if fields := card[name]; len(fields) > 0 {
+			small[name] = fields
+		}
+	}
+	data, err := contacts.EncodeCard(small)
+	if err != nil {
+		return ""
+	}
+	code, err := qrcode.New(string(data), qrcode.Low)
+	if err != nil {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		link := scheme + "://" + r.Host + r.URL.Path + "?format=vcf"
+		if code, err = qrcode.New(link, qrcode.Medium); err != nil {
+			return ""
+		}
+	}
+	png, err := code.PNG(256)
+	if err != nil {
+		return ""
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+}
+
+// contact returns the stored contact with uid, or an contacts.ErrNotFound error.
+func (s *Server) contact(uid string) (vcard.Card, error) {
+	card, err := s.cm.GetContact(uid)
+	if err != nil {
+		return nil, err
+	}
+	if card == nil {
+		return nil, fmt.Errorf("%w: %s", contacts.ErrNotFound, uid)
+	}
+	return card, nil
+}
+
+// wantsVCard reports whether the client asked for text/vcard, by Accept
+// header or ?format=vcf.
+func wantsVCard(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "vcf" || strings.Contains(r.Header.Get("Accept"), "text/vcard")
+}
+
+func writeVCard(w http.ResponseWriter, card vcard.Card, download bool) {
+	data, err := contacts.EncodeCard(card)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vcard; charset=utf-8")
+	if download {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", strings.ReplaceAll(contacts.CardFullName(card), `"`, "")+".vcf"))
+	}
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError reports err as a JSON error with the matching status code.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, contacts.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, contacts.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, contacts.ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, contacts.ErrInvalidCard):
+		status = http.StatusBadRequest
+	case errors.Is(err, contacts.ErrReadOnly):
+		status = http.StatusForbidden
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// shareField is one labelled value on a shared contact's page. Link is
+// trusted by the template, so it is only built from known-safe schemes.
+type shareField struct {
+	Label, Value string
+	Link         template.URL
+}
+
+func shareFields(card vcard.Card) []shareField {
+	var out []shareField
+	if org := strings.TrimRight(strings.ReplaceAll(card.Value(vcard.FieldOrganization), ";", ", "), ", "); org != "" {
+		out = append(out, shareField{Label: "Organization", Value: org})
+	}
+	for _, f := range card[vcard.FieldTelephone] {
+		out = append(out, shareField{Label: "Phone", Value: f.Value, Link: template.URL("tel:" + url.PathEscape(strings.ReplaceAll(f.Value, " ", "")))})
+	}
+	for _, f := range card[vcard.FieldEmail] {
+		out = append(out, shareField{Label: "Email", Value: f.Value, Link: template.URL("mailto:" + url.PathEscape(f.Value))})
+	}
+	for _, f := range card[vcard.FieldAddress] {
+		if a := contacts.FormatAddress(f.Value); a != "" {
+			out = append(out, shareField{Label: "Address", Value: a})
+		}
+	}
+	for _, f := range card[vcard.FieldURL] {
+		field := shareField{Label: "Web", Value: f.Value}
+		if u, err := url.Parse(f.Value); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			field.Link = template.URL(u.String())
+		}
+		out = append(out, field)
+	}
+	return out
+}
+
+var sharePage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 28rem; margin: 2rem auto; padding: 0 1rem; }
+dt { color: #666; font-size: 0.85rem; margin-top: 0.75rem; }
+dd { margin: 0; }
+a.button { display: inline-block; margin-top: 1.5rem; padding: 0.6rem 1rem; background: #1a73e8; color: #fff; border-radius: 4px; text-decoration: none; }
+figure { margin: 1.5rem 0 0; }
+figure img { width: 12rem; height: 12rem; image-rendering: pixelated; }
+figcaption { color: #666; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<dl>
+{{range .Fields}}<dt>{{.Label}}</dt><dd>{{if .Link}}<a href="{{.Link}}">{{.Value}}</a>{{else}}{{.Value}}{{end}}</dd>
+{{end}}</dl>
+<a class="button" href="{{.Card}}">Add to contacts</a>
+{{if .QR}}<figure>
+<img src="{{.QR}}" alt="QR code of {{.Name}}">
+<figcaption>Or scan to add on another phone</figcaption>
+</figure>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,503 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/arjungandhi/contacts"
+	"github.com/emersion/go-vcard"
+)
+
+// newTestServer returns a server for a store holding card.
+func newTestServer(t *testing.T, card vcard.Card) (*contacts.ContactManager, *Server) {
+	t.Helper()
+	cm, err := contacts.NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	return cm, NewServer(cm)
+}
+
+func serve(s http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_Contacts(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldEmail, "ada@example.com")
+	_, s := newTestServer(t, card)
+
+	rec := serve(s, "GET", "/contacts", nil)
+	var list []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || rec.Code != http.StatusOK || len(list) != 1 {
+		t.Fatalf("GET /contacts = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(s, "GET", "/contacts/"+contacts.CardUID(card), http.Header{"Accept": {"text/vcard"}})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "FN:Ada Lovelace") {
+		t.Errorf("GET vcard = %d %s", rec.Code, rec.Body)
+	}
+
+	for _, uid := range []string{"missing", "..%2Fetc"} {
+		if rec := serve(s, "GET", "/contacts/"+uid, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("GET /contacts/%s = %d, want 404", uid, rec.Code)
+		}
+	}
+}
+
+func TestServer_Share(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldTelephone, "+44 20 7946 0000")
+	card.SetValue(vcard.FieldURL, "javascript:alert(1)")
+	cm, s := newTestServer(t, card)
+	token, err := cm.ShareToken(contacts.CardUID(card), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serve(s, "GET", "/share/"+token, nil)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "<h1>Ada Lovelace</h1>") || !strings.Contains(body, `href="tel:&#43;4420794600`) {
+		t.Errorf("GET share page = %d\n%s", rec.Code, body)
+	}
+	if strings.Contains(body, `href="javascript`) {
+		t.Error("share page links an unsafe URL")
+	}
+	if !strings.Contains(body, `<img src="data:image/png;base64,`) {
+		t.Error("share page has no QR code")
+	}
+	rec = serve(s, "GET", "/share/"+token+"?format=vcf", nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment") {
+		t.Errorf("GET share vcard = %d %v", rec.Code, rec.Header())
+	}
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if rec := serve(s, "GET", "/share/"+token, nil); rec.Code != http.StatusForbidden {
+		t.Errorf("expired share = %d, want 403", rec.Code)
+	}
+}
+
+// testPNG returns a w×h PNG image.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestServer_Photo(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldPhoto, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(testPNG(t, 64, 32)))
+	_, s := newTestServer(t, card)
+	path := "/contacts/" + contacts.CardUID(card) + "/photo"
+
+	rec := serve(s, "GET", path, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("GET photo = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rec = serve(s, "GET", path+"?size=16", nil)
+	cfg, err := jpeg.DecodeConfig(rec.Body)
+	if rec.Code != http.StatusOK || err != nil || cfg.Width != 16 || cfg.Height != 8 {
+		t.Errorf("GET resized photo = %d, %v, %dx%d", rec.Code, err, cfg.Width, cfg.Height)
+	}
+	if rec := serve(s, "GET", path+"?size=0", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("GET photo?size=0 = %d, want 400", rec.Code)
+	}
+
+	plain := contacts.NewCard("No Photo")
+	_, s = newTestServer(t, plain)
+	if rec := serve(s, "GET", "/contacts/"+contacts.CardUID(plain)+"/photo", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET missing photo = %d, want 404", rec.Code)
+	}
+}
+
+func TestServer_ConditionalRequests(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	_, s := newTestServer(t, card)
+	path := "/contacts/" + contacts.CardUID(card)
+
+	rec := serve(s, "GET", path, nil)
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET returned no ETag")
+	}
+	if rec := serve(s, "GET", path, http.Header{"If-None-Match": {etag}}); rec.Code != http.StatusNotModified {
+		t.Errorf("GET If-None-Match current = %d, want 304", rec.Code)
+	}
+	rec = serve(s, "GET", "/contacts", nil)
+	if rec := serve(s, "GET", "/contacts", http.Header{"If-None-Match": {rec.Header().Get("ETag")}}); rec.Code != http.StatusNotModified {
+		t.Errorf("GET /contacts If-None-Match current = %d, want 304", rec.Code)
+	}
+
+	put := func(name, ifMatch string) *httptest.ResponseRecorder {
+		update := contacts.NewCard(name)
+		update.SetValue(vcard.FieldUID, contacts.CardUID(card))
+		data, err := contacts.EncodeCard(update)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest("PUT", path, strings.NewReader(string(data)))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	rec = put("Ada King", etag)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("PUT If-Match current = %d, ETag %s", rec.Code, rec.Header().Get("ETag"))
+	}
+	newETag := rec.Header().Get("ETag")
+	if rec := serve(s, "GET", path, nil); rec.Header().Get("ETag") != newETag {
+		t.Errorf("GET ETag %s after PUT, want %s", rec.Header().Get("ETag"), newETag)
+	}
+	if rec := put("Ada Byron", etag); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT If-Match stale = %d, want 412", rec.Code)
+	}
+	if rec := serve(s, "DELETE", path, http.Header{"If-Match": {etag}}); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE If-Match stale = %d, want 412", rec.Code)
+	}
+	if rec := serve(s, "DELETE", path, http.Header{"If-Match": {newETag}}); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE If-Match current = %d, want 204", rec.Code)
+	}
+	if rec := serve(s, "GET", path, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET after DELETE = %d, want 404", rec.Code)
+	}
+}
+
+func TestServer_ConcurrentConditionalPuts(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	_, s := newTestServer(t, card)
+	path := "/contacts/" + contacts.CardUID(card)
+	etag := serve(s, "GET", path, nil).Header().Get("ETag")
+
+	// Every writer read the same version; only one may replace it.
+	codes := make([]int, 64)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			update := contacts.NewCard(fmt.Sprintf("Ada %d", i))
+			update.SetValue(vcard.FieldUID, contacts.CardUID(card))
+			data, _ := contacts.EncodeCard(update)
+			req := httptest.NewRequest("PUT", path, strings.NewReader(string(data)))
+			req.Header.Set("If-Match", etag)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			codes[i] = rec.Code
+		}()
+	}
+	wg.Wait()
+	ok := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusPreconditionFailed:
+		default:
+			t.Errorf("PUT = %d", code)
+		}
+	}
+	if ok != 1 {
+		t.Errorf("%d PUTs with the same If-Match succeeded, want 1", ok)
+	}
+}
+
+func TestServer_CreateContact(t *testing.T) {
+	_, s := newTestServer(t, contacts.NewCard("Existing"))
+	data, err := contacts.EncodeCard(contacts.NewCard("Grace Hopper"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/contacts", strings.NewReader(string(data)))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated || !strings.HasPrefix(rec.Header().Get("Location"), "/contacts/") {
+		t.Fatalf("POST = %d %v", rec.Code, rec.Header())
+	}
+	rec2 := httptest.NewRecorder()
+	s.ServeHTTP(rec2, httptest.NewRequest("POST", "/contacts", strings.NewReader(string(data))))
+	if rec2.Code != http.StatusConflict {
+		t.Errorf("POST duplicate UID = %d, want 409", rec2.Code)
+	}
+	if rec := serve(s, "POST", "/contacts", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST empty body = %d, want 400", rec.Code)
+	}
+}
+
+func TestServer_Batch(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	cm, s := newTestServer(t, card)
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("POST", "/contacts:batch", strings.NewReader(body)))
+		return rec
+	}
+	vcf := func(c vcard.Card) string {
+		data, err := contacts.EncodeCard(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(string(data))
+		return string(b)
+	}
+
+	update := contacts.NewCard("Ada King")
+	update.SetValue(vcard.FieldUID, contacts.CardUID(card))
+	rec := post(`{"operations":[{"op":"create","card":` + vcf(contacts.NewCard("Charles Babbage")) + `},{"op":"update","uid":"` + contacts.CardUID(card) + `","card":` + vcf(update) + `}]}`)
+	var got struct {
+		Results []struct {
+			Op   string `json:"op"`
+			UID  string `json:"uid"`
+			ETag string `json:"etag"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || len(got.Results) != 2 || got.Results[1].ETag == "" {
+		t.Fatalf("POST batch = %d %s", rec.Code, rec.Body)
+	}
+	if etag := serve(s, "GET", "/contacts/"+contacts.CardUID(card), nil).Header().Get("ETag"); etag != got.Results[1].ETag {
+		t.Errorf("GET ETag = %s, want the batch's %s", etag, got.Results[1].ETag)
+	}
+
+	rec = post(`{"operations":[{"op":"delete","uid":"` + contacts.CardUID(card) + `"},{"op":"delete","uid":"missing"}]}`)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST batch with a missing contact = %d, want 404", rec.Code)
+	}
+	if c, _ := cm.GetContact(contacts.CardUID(card)); c == nil {
+		t.Error("failed batch deleted a contact")
+	}
+	rec = post(`{"operations":[{"op":"delete","uid":"` + contacts.CardUID(card) + `","if_match":"\"stale\""}]}`)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("POST batch with stale if_match = %d, want 412", rec.Code)
+	}
+	for _, body := range []string{`{}`, `{"operations":[{"op":"create","card":"nope"}]}`, `{"operations":[{"op":"merge","uid":"x"}]}`} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST batch %s = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestServer_Inbound(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldEmail, "ada@example.com")
+	_, s := newTestServer(t, card)
+
+	post := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/inbound", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("application/json", `{"name":"Ada","email":"ada@example.com","message":"hi"}`)
+	var got struct {
+		Action  string         `json:"action"`
+		Contact map[string]any `json:"contact"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || got.Action != "merged" || got.Contact["uid"] != contacts.CardUID(card) {
+		t.Errorf("POST known email = %d %+v, want merged into %s", rec.Code, got, contacts.CardUID(card))
+	}
+
+	rec = post("application/x-www-form-urlencoded", "name=Charles+Babbage&email=charles%40example.com&tags=website")
+	if rec.Code != http.StatusCreated || !strings.HasPrefix(rec.Header().Get("Location"), "/contacts/") {
+		t.Errorf("POST form = %d %s", rec.Code, rec.Body)
+	}
+
+	other := contacts.NewCard("Someone Else")
+	other.SetValue(vcard.FieldUID, contacts.CardUID(card))
+	data, err := contacts.EncodeCard(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = post("text/vcard", string(data))
+	if rec.Code != http.StatusCreated || strings.Contains(rec.Header().Get("Location"), contacts.CardUID(card)) {
+		t.Errorf("POST vcard with existing UID = %d %s, want a new contact", rec.Code, rec.Header().Get("Location"))
+	}
+
+	if rec := post("application/json", `{"phone":"555"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST without name or email = %d, want 400", rec.Code)
+	}
+	if rec := post("text/plain", "hello"); rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("POST text/plain = %d, want 415", rec.Code)
+	}
+}
+
+func TestServer_RateLimitAndLog(t *testing.T) {
+	cm, _ := newTestServer(t, contacts.NewCard("Ada Lovelace"))
+	var log bytes.Buffer
+	s := NewServer(cm, WithRateLimit(2), WithRequestLog(&log), WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8")))
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	request := func(target, remote, forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = remote
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := 0; i < 2; i++ {
+		if rec := request("/contacts", "10.0.0.1:1234", "203.0.113.7, 10.0.0.2"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := request("/contacts", "10.0.0.3:1234", "203.0.113.7")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("third request from client = %d, Retry-After %q; want 429, 30", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := request("/contacts", "10.0.0.1:1234", "198.51.100.1"); rec.Code != http.StatusOK {
+		t.Errorf("other client behind the proxy = %d, want 200", rec.Code)
+	}
+	// An untrusted peer cannot pick its identity with X-Forwarded-For.
+	if rec := request("/contacts", "192.0.2.1:1234", "198.51.100.2"); rec.Code != http.StatusOK {
+		t.Errorf("untrusted peer = %d, want 200", rec.Code)
+	}
+	if rec := request("/contacts", "192.0.2.1:1234", "198.51.100.3"); rec.Code != http.StatusOK {
+		t.Errorf("untrusted peer = %d, want 200", rec.Code)
+	}
+	if rec := request("/contacts", "192.0.2.1:1234", "198.51.100.4"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("untrusted peer spoofing X-Forwarded-For = %d, want 429", rec.Code)
+	}
+	if rec := request("/healthz", "192.0.2.1:1234", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ok"`) {
+		t.Errorf("GET /healthz while limited = %d %s", rec.Code, rec.Body)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("logged %d lines, want 8:\n%s", len(lines), log.String())
+	}
+	if fields := strings.Fields(lines[2]); len(fields) != 7 || fields[1] != "203.0.113.7" || fields[2] != "GET" || fields[3] != "/contacts" || fields[4] != "429" {
+		t.Errorf("log line = %q", lines[2])
+	}
+}
+
+func TestServer_Tokens(t *testing.T) {
+	card := contacts.NewCard("Ada Lovelace")
+	cm, _ := newTestServer(t, card)
+	s := NewServer(cm, WithTokens("s3cret", "other"))
+
+	for _, tt := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Basic s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+		{"Bearer other", http.StatusOK},
+	} {
+		header := http.Header{}
+		if tt.auth != "" {
+			header.Set("Authorization", tt.auth)
+		}
+		if rec := serve(s, "GET", "/contacts", header); rec.Code != tt.want {
+			t.Errorf("GET /contacts with %q = %d, want %d", tt.auth, rec.Code, tt.want)
+		}
+	}
+
+	token, err := cm.ShareToken(contacts.CardUID(card), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(s, "GET", "/share/"+token, nil); rec.Code != http.StatusOK {
+		t.Errorf("share page with tokens = %d, want 200 without auth", rec.Code)
+	}
+}
+
+func TestServer_OpenAPI(t *testing.T) {
+	cm, _ := newTestServer(t, contacts.NewCard("Ada Lovelace"))
+	s := NewServer(cm, WithTokens("s3cret"))
+
+	rec := serve(s, "GET", "/openapi.json", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d, want 200 without auth", rec.Code)
+	}
+	var spec struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas         map[string]any `json:"schemas"`
+			SecuritySchemes map[string]any `json:"securitySchemes"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+	for path, methods := range map[string][]string{
+		"/contacts":             {"get", "post"},
+		"/contacts/{uid}":       {"get", "put", "delete"},
+		"/contacts/{uid}/photo": {"get"},
+		"/inbound":              {"post"},
+	} {
+		for _, m := range methods {
+			if _, ok := spec.Paths[path][m]; !ok {
+				t.Errorf("spec lacks %s %s", m, path)
+			}
+		}
+	}
+	if _, ok := spec.Components.Schemas["Contact"]; !ok {
+		t.Error("spec lacks the Contact schema")
+	}
+	if _, ok := spec.Components.SecuritySchemes["bearerAuth"]; !ok {
+		t.Error("spec lacks bearerAuth with tokens configured")
+	}
+
+	// Every Contact property is one contacts.CardToMap produces.
+	props := OpenAPISpec(false)["components"].(map[string]any)["schemas"].(map[string]any)["Contact"].(map[string]any)["properties"].(map[string]any)
+	card := contacts.NewCard("Ada Lovelace")
+	for key := range contacts.CardToMap(card) {
+		if _, ok := props[key]; !ok {
+			t.Errorf("Contact schema lacks %q", key)
+		}
+	}
+}
+
+func TestShareQR_TooLong(t *testing.T) {
+	// A card too long for a QR code gets one linking to its vCard instead.
+	card := contacts.NewCard("Ada Lovelace")
+	for i := range 200 {
+		card.Add(vcard.FieldEmail, &vcard.Field{Value: fmt.Sprintf("ada.lovelace.%d@example.com", i)})
+	}
+	r := httptest.NewRequest("GET", "/share/token", nil)
+	if got := shareQR(r, card); !strings.HasPrefix(string(got), "data:image/png;base64,") {
+		t.Errorf("shareQR = %.40q, want a PNG data URL", got)
+	}
+}
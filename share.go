@@ -0,0 +1,89 @@
+package contacts
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidShareToken is returned for share tokens that are malformed,
+// forged or expired.
+var ErrInvalidShareToken = errors.New("invalid or expired share link")
+
+func (cm *ContactManager) shareKeyPath() string {
+	return filepath.Join(cm.dir, "share.key")
+}
+
+// shareSecret returns the key share tokens are signed with, creating it on
+// first use.
+func (cm *ContactManager) shareSecret() ([]byte, error) {
+	data, err := os.ReadFile(cm.shareKeyPath())
+	if err == nil && len(data) >= 32 {
+		return data, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read share key: %w", err)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate share key: %w", err)
+	}
+	if err := os.WriteFile(cm.shareKeyPath(), key, cm.fileMode); err != nil {
+		return nil, fmt.Errorf("failed to write share key: %w", err)
+	}
+	return key, nil
+}
+
+// ShareToken returns a signed token granting read access to one contact
+// until expires, for use in a share link served by server.Server.
+func (cm *ContactManager) ShareToken(uid string, expires time.Time) (string, error) {
+	secret, err := cm.shareSecret()
+	if err != nil {
+		return "", err
+	}
+	payload := uid + "|" + strconv.FormatInt(expires.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signShare(secret, payload), nil
+}
+
+// VerifyShareToken returns the UID a share token grants access to at now.
+func (cm *ContactManager) VerifyShareToken(token string, now time.Time) (string, error) {
+	secret, err := cm.shareSecret()
+	if err != nil {
+		return "", err
+	}
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidShareToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", ErrInvalidShareToken
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(sig), []byte(signShare(secret, payload))) {
+		return "", ErrInvalidShareToken
+	}
+	i := strings.LastIndexByte(payload, '|')
+	if i < 0 {
+		return "", ErrInvalidShareToken
+	}
+	expires, err := strconv.ParseInt(payload[i+1:], 10, 64)
+	if err != nil || !now.Before(time.Unix(expires, 0)) {
+		return "", ErrInvalidShareToken
+	}
+	return payload[:i], nil
+}
+
+func signShare(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
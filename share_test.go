@@ -0,0 +1,44 @@
+package contacts
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestContactManager_ShareToken(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	token, err := cm.ShareToken("c123", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, err := cm.VerifyShareToken(token, now); err != nil || uid != "c123" {
+		t.Errorf("VerifyShareToken() = %q, %v; want c123", uid, err)
+	}
+	if _, err := cm.VerifyShareToken(token, now.Add(2*time.Hour)); !errors.Is(err, ErrInvalidShareToken) {
+		t.Errorf("expired token: err = %v, want ErrInvalidShareToken", err)
+	}
+
+	other, err := cm.ShareToken("c456", now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := other[:len(other)-43] + token[len(token)-43:]
+	for _, bad := range []string{"", "garbage", forged, token + "x"} {
+		if _, err := cm.VerifyShareToken(bad, now); !errors.Is(err, ErrInvalidShareToken) {
+			t.Errorf("VerifyShareToken(%q) err = %v, want ErrInvalidShareToken", bad, err)
+		}
+	}
+
+	fresh, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fresh.VerifyShareToken(token, now); !errors.Is(err, ErrInvalidShareToken) {
+		t.Error("token verified against another store's key")
+	}
+}
@@ -0,0 +1,130 @@
+package contacts
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/emersion/go-vcard"
+)
+
+// SimilarContact is an existing contact that may be the same person as a
+// new one, and why.
+type SimilarContact struct {
+	Card vcard.Card
+	// Score is ScoreSimilarity of the two cards.
+	Score float64
+	// Reasons describe what matched, e.g. "same email ada@example.com".
+	Reasons []string
+}
+
+// FindSimilar returns the cards among existing that look like the same
+// person as card: a shared email address, a matching phone number or a
+// near-identical name. The card itself (by UID) is never returned.
+func FindSimilar(card vcard.Card, existing []vcard.Card) []SimilarContact {
+	uid := CardUID(card)
+	name := nameKey(CardFullName(card))
+	var out []SimilarContact
+	for _, other := range existing {
+		if uid != "" && CardUID(other) == uid {
+			continue
+		}
+		var reasons []string
+		if email := sharedEmail(card, other); email != "" {
+			reasons = append(reasons, "same email "+email)
+		}
+		if phone := sharedPhone(card, other); phone != "" {
+			reasons = append(reasons, "same phone "+phone)
+		}
+		if name != "" && namesSimilar(name, nameKey(CardFullName(other))) {
+			reasons = append(reasons, "similar name")
+		}
+		if len(reasons) > 0 {
+			out = append(out, SimilarContact{Card: other, Score: ScoreSimilarity(card, other), Reasons: reasons})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+func sharedEmail(a, b vcard.Card) string {
+	for _, x := range a[vcard.FieldEmail] {
+		for _, y := range b[vcard.FieldEmail] {
+			if x.Value != "" && strings.EqualFold(strings.TrimSpace(x.Value), strings.TrimSpace(y.Value)) {
+				return x.Value
+			}
+		}
+	}
+	return ""
+}
+
+func sharedPhone(a, b vcard.Card) string {
+	for _, x := range a[vcard.FieldTelephone] {
+		for _, y := range b[vcard.FieldTelephone] {
+			if PhonesMatch(x.Value, y.Value) {
+				return x.Value
+			}
+		}
+	}
+	return ""
+}
+
+// nameKey lowercases a name, drops punctuation and sorts its words, so
+// "Smith, John" and "john smith" have the same key.
+func nameKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// namesSimilar reports whether two name keys are equal or differ by a
+// typo: at most one edit for short names and two for longer ones.
+func namesSimilar(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	limit := 1
+	if len(a) > 10 {
+		limit = 2
+	}
+	return editDistance(a, b) <= limit
+}
+
+// editDistance is the Levenshtein distance between a and b in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func hasFieldValue(fields []*vcard.Field, value, key string) bool {
+	for _, f := range fields {
+		switch {
+		case key == vcard.FieldTelephone && PhonesMatch(f.Value, value):
+			return true
+		case strings.EqualFold(f.Value, value):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestFindSimilar(t *testing.T) {
+	ada := NewCard("Ada Lovelace")
+	ada.Add(vcard.FieldEmail, &vcard.Field{Value: "ada@example.com"})
+	alan := NewCard("Alan Turing")
+	alan.Add(vcard.FieldTelephone, &vcard.Field{Value: "+44 20 7946 0000"})
+	grace := NewCard("Grace Hopper")
+	existing := []vcard.Card{ada, alan, grace}
+
+	tests := []struct {
+		name  string
+		card  func() vcard.Card
+		match string
+	}{
+		{"email", func() vcard.Card {
+			c := NewCard("A. King")
+			c.Add(vcard.FieldEmail, &vcard.Field{Value: "ADA@example.com"})
+			return c
+		}, "Ada Lovelace"},
+		{"phone", func() vcard.Card {
+			c := NewCard("Turing")
+			c.Add(vcard.FieldTelephone, &vcard.Field{Value: "020 7946 0000"})
+			return c
+		}, "Alan Turing"},
+		{"reordered name", func() vcard.Card { return NewCard("Hopper, Grace") }, "Grace Hopper"},
+		{"typo", func() vcard.Card { return NewCard("Grace Hoper") }, "Grace Hopper"},
+		{"no match", func() vcard.Card { return NewCard("Linus Torvalds") }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindSimilar(tt.card(), existing)
+			if tt.match == "" {
+				if len(got) != 0 {
+					t.Errorf("got %d matches, want none", len(got))
+				}
+				return
+			}
+			if len(got) != 1 || CardFullName(got[0].Card) != tt.match {
+				t.Fatalf("got %d matches, want only %s", len(got), tt.match)
+			}
+		})
+	}
+
+	if got := FindSimilar(ada, existing); len(got) != 0 {
+		t.Errorf("card matched itself: %v", got)
+	}
+}
@@ -0,0 +1,187 @@
+package contacts
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// FieldSocialProfile is Apple's property for a social network profile. Its
+// TYPE names the service and X-USER holds the user name, as macOS and iOS
+// Contacts write it.
+const FieldSocialProfile = "X-SOCIALPROFILE"
+
+// ParamSocialUser is the X-SOCIALPROFILE parameter holding the user name.
+const ParamSocialUser = "X-USER"
+
+// SocialService is a social network whose profile URLs are recognized.
+type SocialService struct {
+	// Type is the X-SOCIALPROFILE TYPE, such as "github".
+	Type string
+	// Name is the service's display name, such as "GitHub".
+	Name  string
+	hosts []string
+}
+
+// SocialServices are the services DetectSocialProfiles recognizes.
+// Mastodon has no single host: any profile URL of the form
+// https://<instance>/@<user> is taken to be one.
+var SocialServices = []SocialService{
+	{Type: "github", Name: "GitHub", hosts: []string{"github.com"}},
+	{Type: "linkedin", Name: "LinkedIn", hosts: []string{"linkedin.com"}},
+	{Type: "mastodon", Name: "Mastodon"},
+	{Type: "twitter", Name: "Twitter/X", hosts: []string{"twitter.com", "x.com"}},
+	{Type: "instagram", Name: "Instagram", hosts: []string{"instagram.com"}},
+}
+
+// SocialServiceByType returns the service with the given TYPE or name,
+// case-insensitively. "x" finds Twitter/X.
+func SocialServiceByType(typ string) (SocialService, bool) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if typ == "x" {
+		typ = "twitter"
+	}
+	for _, s := range SocialServices {
+		if s.Type == typ || strings.ToLower(s.Name) == typ {
+			return s, true
+		}
+	}
+	return SocialService{}, false
+}
+
+// notMastodon are hosts with /@user profile URLs that are not Mastodon
+// instances.
+var notMastodon = map[string]bool{
+	"medium.com":  true,
+	"youtube.com": true,
+	"tiktok.com":  true,
+	"threads.net": true,
+}
+
+// SocialProfile is a classified social network profile of a contact.
+type SocialProfile struct {
+	Service SocialService
+	User    string
+	URL     string
+}
+
+// ClassifySocialURL reports which service a profile URL belongs to and the
+// user it names. URLs of a service that don't name a user, such as a
+// GitHub repository or a LinkedIn company page, are not profiles.
+func ClassifySocialURL(raw string) (SocialProfile, bool) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return SocialProfile{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "mobile.")
+	segments := strings.FieldsFunc(u.Path, func(r rune) bool { return r == '/' })
+	if len(segments) == 0 {
+		return SocialProfile{}, false
+	}
+	for _, s := range SocialServices {
+		for _, h := range s.hosts {
+			if host != h {
+				continue
+			}
+			user := segments[0]
+			switch s.Type {
+			case "linkedin":
+				if user != "in" || len(segments) < 2 {
+					return SocialProfile{}, false
+				}
+				user = segments[1]
+			case "github":
+				if len(segments) > 1 {
+					return SocialProfile{}, false
+				}
+			}
+			user = strings.TrimPrefix(user, "@")
+			return SocialProfile{Service: s, User: user, URL: raw}, user != ""
+		}
+	}
+	if user, ok := strings.CutPrefix(segments[0], "@"); ok && len(segments) == 1 && user != "" && !notMastodon[host] {
+		mastodon, _ := SocialServiceByType("mastodon")
+		return SocialProfile{Service: mastodon, User: user + "@" + strings.ToLower(u.Host), URL: raw}, true
+	}
+	return SocialProfile{}, false
+}
+
+// SocialProfiles returns the card's X-SOCIALPROFILE fields whose service
+// is known, in order.
+func SocialProfiles(card vcard.Card) []SocialProfile {
+	var out []SocialProfile
+	for _, f := range card[FieldSocialProfile] {
+		p, ok := ClassifySocialURL(f.Value)
+		if typ := f.Params.Get(vcard.ParamType); typ != "" {
+			// A TYPE set by another client wins over the URL.
+			if s, known := SocialServiceByType(typ); known {
+				p.Service, ok = s, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		p.URL = f.Value
+		if user := f.Params.Get(ParamSocialUser); user != "" {
+			p.User = user
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// DetectSocialProfiles adds an X-SOCIALPROFILE field for each URL field
+// that is a profile on a known service, and labels untyped
+// X-SOCIALPROFILE fields with their service. URL fields are kept, since
+// most providers have nowhere else to store profiles. It reports whether
+// the card changed.
+func DetectSocialProfiles(card vcard.Card) bool {
+	changed := false
+	have := map[string]bool{}
+	for _, f := range card[FieldSocialProfile] {
+		have[socialKey(f.Value)] = true
+		if f.Params.Get(vcard.ParamType) != "" {
+			continue
+		}
+		if p, ok := ClassifySocialURL(f.Value); ok {
+			setSocialParams(f, p)
+			changed = true
+		}
+	}
+	for _, f := range card[vcard.FieldURL] {
+		p, ok := ClassifySocialURL(f.Value)
+		if !ok || have[socialKey(f.Value)] {
+			continue
+		}
+		field := &vcard.Field{Value: f.Value}
+		setSocialParams(field, p)
+		card.Add(FieldSocialProfile, field)
+		have[socialKey(f.Value)] = true
+		changed = true
+	}
+	return changed
+}
+
+func setSocialParams(f *vcard.Field, p SocialProfile) {
+	if f.Params == nil {
+		f.Params = vcard.Params{}
+	}
+	f.Params.Set(vcard.ParamType, p.Service.Type)
+	f.Params.Set(ParamSocialUser, p.User)
+}
+
+// socialKey identifies a profile URL regardless of scheme, "www." and a
+// trailing slash.
+func socialKey(raw string) string {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if _, rest, ok := strings.Cut(raw, "://"); ok {
+		raw = rest
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(raw, "www."), "/")
+}
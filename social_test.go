@@ -0,0 +1,106 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestClassifySocialURL(t *testing.T) {
+	tests := []struct {
+		url, service, user string
+	}{
+		{"https://github.com/ada", "github", "ada"},
+		{"github.com/ada/", "github", "ada"},
+		{"https://github.com/ada/engine", "", ""},
+		{"https://www.linkedin.com/in/ada-lovelace/", "linkedin", "ada-lovelace"},
+		{"https://www.linkedin.com/company/analytical", "", ""},
+		{"https://twitter.com/ada", "twitter", "ada"},
+		{"https://x.com/@ada", "twitter", "ada"},
+		{"https://mobile.twitter.com/ada", "twitter", "ada"},
+		{"https://instagram.com/ada", "instagram", "ada"},
+		{"https://mastodon.social/@ada", "mastodon", "ada@mastodon.social"},
+		{"https://medium.com/@ada", "", ""},
+		{"https://example.com/ada", "", ""},
+		{"https://github.com", "", ""},
+	}
+	for _, tt := range tests {
+		p, ok := ClassifySocialURL(tt.url)
+		if ok != (tt.service != "") || p.Service.Type != tt.service || p.User != tt.user {
+			t.Errorf("ClassifySocialURL(%q) = %q %q %v, want %q %q", tt.url, p.Service.Type, p.User, ok, tt.service, tt.user)
+		}
+	}
+}
+
+func TestDetectSocialProfiles(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.AddValue(vcard.FieldURL, "https://github.com/ada")
+	card.AddValue(vcard.FieldURL, "https://analytical.example")
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "https://mastodon.social/@ada"})
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "https://www.github.com/ada/"})
+
+	if !DetectSocialProfiles(card) {
+		t.Fatal("expected the card to change")
+	}
+	profiles := SocialProfiles(card)
+	if len(profiles) != 2 {
+		t.Fatalf("got %d profiles, want 2 (the GitHub URL is already a profile): %+v", len(profiles), profiles)
+	}
+	if f := card[FieldSocialProfile][0]; f.Params.Get(vcard.ParamType) != "mastodon" || f.Params.Get(ParamSocialUser) != "ada@mastodon.social" {
+		t.Errorf("untyped profile not labelled: %+v", f.Params)
+	}
+	if DetectSocialProfiles(card) {
+		t.Error("second run changed the card")
+	}
+
+	out := FormatCard(card)
+	if !strings.Contains(out, "GitHub:    https://www.github.com/ada/ (ada)") || !strings.Contains(out, "Mastodon:  ") {
+		t.Errorf("FormatCard missing profiles:\n%s", out)
+	}
+	if strings.Contains(out, "URL:       https://github.com/ada") {
+		t.Errorf("FormatCard shows the profile URL twice:\n%s", out)
+	}
+	if !strings.Contains(out, "URL:       https://analytical.example") {
+		t.Errorf("FormatCard dropped a plain URL:\n%s", out)
+	}
+	if list, _ := CardToMap(card)["social_profiles"].([]map[string]string); len(list) != 2 || list[0]["service"] != "mastodon" {
+		t.Errorf("CardToMap social_profiles = %v", list)
+	}
+}
+
+func TestSocialProfiles_KeepsForeignType(t *testing.T) {
+	card := NewCard("Ada")
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "https://example.com/ada", Params: vcard.Params{vcard.ParamType: {"GitHub"}, ParamSocialUser: {"ada"}}})
+	card.Add(FieldSocialProfile, &vcard.Field{Value: "https://example.com/bob", Params: vcard.Params{vcard.ParamType: {"myspace"}}})
+	profiles := SocialProfiles(card)
+	if len(profiles) != 1 || profiles[0].Service.Type != "github" || profiles[0].User != "ada" {
+		t.Errorf("SocialProfiles = %+v", profiles)
+	}
+}
+
+func TestSyncAndImport_DetectSocialProfiles(t *testing.T) {
+	synced := NewCard("Synced")
+	synced.AddValue(vcard.FieldURL, "https://instagram.com/synced")
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{synced}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+	imported := NewCard("Imported")
+	imported.AddValue(vcard.FieldURL, "https://twitter.com/imported")
+	if _, err := cm.Import([]vcard.Card{imported}); err != nil {
+		t.Fatal(err)
+	}
+	for uid, service := range map[string]string{CardUID(synced): "instagram", CardUID(imported): "twitter"} {
+		card, err := cm.GetContact(uid)
+		if err != nil || card == nil {
+			t.Fatalf("GetContact(%s) = %v, %v", uid, card, err)
+		}
+		if p := SocialProfiles(card); len(p) != 1 || p[0].Service.Type != service {
+			t.Errorf("%s: profiles = %+v, want %s", CardFullName(card), p, service)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package contacts
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sqliteDB reads the tables of a SQLite database file held in memory. It
+// understands just enough of the file format to read Android's
+// contacts2.db: table b-trees, overflow pages and UTF-8 records. Changes
+// still in a write-ahead log (the -wal file next to the database) are not
+// seen.
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	usable   int
+	tables   map[string]sqliteTable
+}
+
+// sqliteTable is a table named in the database schema.
+type sqliteTable struct {
+	root    int
+	columns []string
+	// rowid is the index of the INTEGER PRIMARY KEY column, whose value is
+	// the row's key rather than part of its record, or -1.
+	rowid int
+}
+
+// sqliteRow maps column names to their values: nil, int64, float64,
+// string or []byte.
+type sqliteRow map[string]any
+
+// openSQLite reads the schema of the database in data.
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < 100 || string(data[:len(sqliteHeader)]) != sqliteHeader {
+		return nil, fmt.Errorf("not a SQLite database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size %d", pageSize)
+	}
+	if enc := binary.BigEndian.Uint32(data[56:60]); enc > 1 {
+		return nil, fmt.Errorf("unsupported SQLite text encoding %d: only UTF-8 is supported", enc)
+	}
+	db := &sqliteDB{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+		tables:   map[string]sqliteTable{},
+	}
+	// sqlite_schema(type, name, tbl_name, rootpage, sql) is rooted at page 1.
+	schema := sqliteTable{root: 1, columns: []string{"type", "name", "tbl_name", "rootpage", "sql"}, rowid: -1}
+	err := db.walk(schema, func(row sqliteRow) error {
+		if row["type"] != "table" {
+			return nil
+		}
+		name, _ := row["name"].(string)
+		root, _ := row["rootpage"].(int64)
+		sql, _ := row["sql"].(string)
+		columns, rowid := sqliteColumns(sql)
+		db.tables[strings.ToLower(name)] = sqliteTable{root: int(root), columns: columns, rowid: rowid}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// rows calls fn for each row of the named table in rowid order.
+func (db *sqliteDB) rows(table string, fn func(sqliteRow) error) error {
+	t, ok := db.tables[strings.ToLower(table)]
+	if !ok {
+		return fmt.Errorf("database has no %s table", table)
+	}
+	return db.walk(t, fn)
+}
+
+// page returns page n, numbered from 1, and the offset of its b-tree
+// header, which follows the file header on page 1.
+func (db *sqliteDB) page(n int) ([]byte, int, error) {
+	start := (n - 1) * db.pageSize
+	if n < 1 || start+db.pageSize > len(db.data) {
+		return nil, 0, fmt.Errorf("SQLite page %d is out of range", n)
+	}
+	p := db.data[start : start+db.pageSize]
+	if n == 1 {
+		return p, 100, nil
+	}
+	return p, 0, nil
+}
+
+// walk visits the leaves of table t's b-tree from left to right.
+func (db *sqliteDB) walk(t sqliteTable, fn func(sqliteRow) error) error {
+	var visit func(n, depth int) error
+	visit = func(n, depth int) error {
+		if depth > 64 {
+			return fmt.Errorf("SQLite b-tree is too deep: the database is damaged")
+		}
+		p, hdr, err := db.page(n)
+		if err != nil {
+			return err
+		}
+		cells := int(binary.BigEndian.Uint16(p[hdr+3:]))
+		if hdr+12+2*cells > len(p) {
+			return fmt.Errorf("SQLite page %d has too many cells: the database is damaged", n)
+		}
+		switch p[hdr] {
+		case 0x05: // interior table page
+			ptrs := p[hdr+12:]
+			for i := range cells {
+				off := int(binary.BigEndian.Uint16(ptrs[2*i:]))
+				if off+4 > len(p) {
+					return fmt.Errorf("SQLite page %d: cell runs past the end of its page", n)
+				}
+				if err := visit(int(binary.BigEndian.Uint32(p[off:])), depth+1); err != nil {
+					return err
+				}
+			}
+			return visit(int(binary.BigEndian.Uint32(p[hdr+8:])), depth+1)
+		case 0x0d: // leaf table page
+			ptrs := p[hdr+8:]
+			for i := range cells {
+				off := int(binary.BigEndian.Uint16(ptrs[2*i:]))
+				row, err := db.cell(t, p, off)
+				if err != nil {
+					return fmt.Errorf("SQLite page %d: %w", n, err)
+				}
+				if err := fn(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("SQLite page %d is not a table page", n)
+		}
+	}
+	return visit(t.root, 0)
+}
+
+// cell decodes the leaf cell at off on page p into a row of table t.
+func (db *sqliteDB) cell(t sqliteTable, p []byte, off int) (sqliteRow, error) {
+	if off >= len(p) {
+		return nil, fmt.Errorf("cell runs past the end of its page")
+	}
+	size, n := sqliteVarint(p[off:])
+	off += n
+	rowid, m := sqliteVarint(p[off:])
+	if n == 0 || m == 0 {
+		return nil, fmt.Errorf("cell runs past the end of its page")
+	}
+	off += m
+	payload, err := db.payload(p, off, int(size))
+	if err != nil {
+		return nil, err
+	}
+	values, err := sqliteRecord(payload)
+	if err != nil {
+		return nil, err
+	}
+	row := sqliteRow{}
+	for i, col := range t.columns {
+		if i < len(values) {
+			row[col] = values[i]
+		} else {
+			row[col] = nil
+		}
+	}
+	if t.rowid >= 0 {
+		row[t.columns[t.rowid]] = int64(rowid)
+	}
+	return row, nil
+}
+
+// payload returns the size bytes of a cell's payload starting at off on
+// page p, following the chain of overflow pages for the part that does
+// not fit on the page.
+func (db *sqliteDB) payload(p []byte, off, size int) ([]byte, error) {
+	u := db.usable
+	local := size
+	if maxLocal := u - 35; size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(p) || (local < size && off+local+4 > len(p)) {
+		return nil, fmt.Errorf("cell runs past the end of its page")
+	}
+	out := make([]byte, 0, size)
+	out = append(out, p[off:off+local]...)
+	if local == size {
+		return out, nil
+	}
+	next := int(binary.BigEndian.Uint32(p[off+local:]))
+	for len(out) < size {
+		op, _, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := min(size-len(out), u-4)
+		out = append(out, op[4:4+chunk]...)
+		next = int(binary.BigEndian.Uint32(op))
+	}
+	return out, nil
+}
+
+// sqliteRecord decodes the values of a record.
+func sqliteRecord(rec []byte) ([]any, error) {
+	hdrSize, n := sqliteVarint(rec)
+	if n == 0 || int(hdrSize) > len(rec) {
+		return nil, fmt.Errorf("invalid record header")
+	}
+	body := rec[hdrSize:]
+	var values []any
+	for pos := n; pos < int(hdrSize); {
+		serial, n := sqliteVarint(rec[pos:int(hdrSize)])
+		if n == 0 {
+			return nil, fmt.Errorf("invalid record header")
+		}
+		pos += n
+		var width int
+		switch {
+		case serial >= 12:
+			width = int(serial-12) / 2
+		case serial >= 1 && serial <= 4:
+			width = int(serial)
+		case serial == 5:
+			width = 6
+		case serial == 6 || serial == 7:
+			width = 8
+		}
+		if width > len(body) {
+			return nil, fmt.Errorf("record runs past its payload")
+		}
+		v := body[:width]
+		body = body[width:]
+		switch {
+		case serial == 0:
+			values = append(values, nil)
+		case serial <= 6:
+			var x int64
+			for _, b := range v {
+				x = x<<8 | int64(b)
+			}
+			// Sign-extend from the stored width.
+			shift := 64 - 8*uint(width)
+			values = append(values, x<<shift>>shift)
+		case serial == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case serial == 8:
+			values = append(values, int64(0))
+		case serial == 9:
+			values = append(values, int64(1))
+		case serial >= 12 && serial%2 == 0:
+			values = append(values, append([]byte(nil), v...))
+		case serial >= 13:
+			values = append(values, string(v))
+		default:
+			return nil, fmt.Errorf("invalid record serial type %d", serial)
+		}
+	}
+	return values, nil
+}
+
+// sqliteVarint decodes a SQLite variable-length integer and returns it
+// with the number of bytes read, or 0 bytes if b is too short.
+func sqliteVarint(b []byte) (uint64, int) {
+	var x uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return x<<8 | uint64(b[i]), 9
+		}
+		x = x<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return x, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// sqliteColumns returns the column names of a CREATE TABLE statement and
+// the index of its INTEGER PRIMARY KEY column, or -1.
+func sqliteColumns(sql string) ([]string, int) {
+	open, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if open < 0 || end < open {
+		return nil, -1
+	}
+	var defs []string
+	depth, start := 0, open+1
+	for i := open + 1; i < end; i++ {
+		switch sql[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, sql[start:i])
+				start = i + 1
+			}
+		}
+	}
+	defs = append(defs, sql[start:end])
+
+	var columns []string
+	rowid := -1
+	for _, def := range defs {
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue
+		}
+		if len(fields) >= 4 && strings.EqualFold(fields[1], "INTEGER") &&
+			strings.EqualFold(fields[2], "PRIMARY") && strings.EqualFold(fields[3], "KEY") {
+			rowid = len(columns)
+		}
+		columns = append(columns, strings.Trim(fields[0], "\"`[]'"))
+	}
+	return columns, rowid
+}
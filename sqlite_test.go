@@ -0,0 +1,52 @@
+package contacts
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSqliteVarint(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want uint64
+		n    int
+	}{
+		{[]byte{0x05}, 5, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 1<<64 - 1, 9},
+		{[]byte{0x81}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got, n := sqliteVarint(tt.in); got != tt.want || n != tt.n {
+			t.Errorf("sqliteVarint(%x) = %d, %d; want %d, %d", tt.in, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestSqliteRecord(t *testing.T) {
+	// A 6-byte header, then NULL, 1-byte -2, text "hi", blob {7}, constant 1.
+	rec := []byte{6, 0, 1, 17, 14, 9, 0xfe, 'h', 'i', 7}
+	values, err := sqliteRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 5 || values[0] != nil || values[1] != int64(-2) || values[2] != "hi" ||
+		string(values[3].([]byte)) != "\x07" || values[4] != int64(1) {
+		t.Errorf("values = %#v", values)
+	}
+	if _, err := sqliteRecord(rec[:8]); err == nil {
+		t.Error("a truncated record decoded without an error")
+	}
+}
+
+func TestSqliteColumns(t *testing.T) {
+	columns, rowid := sqliteColumns(`CREATE TABLE "data" (_id INTEGER PRIMARY KEY AUTOINCREMENT, ` +
+		`mimetype_id INTEGER REFERENCES mimetype(_id) NOT NULL, data1 TEXT, "data2" TEXT, ` +
+		`UNIQUE (mimetype_id, data1))`)
+	if want := []string{"_id", "mimetype_id", "data1", "data2"}; !slices.Equal(columns, want) {
+		t.Errorf("columns = %q, want %q", columns, want)
+	}
+	if rowid != 0 {
+		t.Errorf("rowid column = %d, want 0", rowid)
+	}
+}
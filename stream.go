@@ -0,0 +1,83 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/emersion/go-vcard"
+)
+
+// WalkContacts calls fn with each contact in directory order. Unlike
+// ListContacts it reads one file at a time and keeps none of them, so memory
+// use stays flat however large the store is. It stops at the first error,
+// including one returned by fn.
+func (cm *ContactManager) WalkContacts(fn func(vcard.Card) error) error {
+	names, err := cm.contactFileNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		card, err := cm.loadContactFile(name)
+		if err != nil {
+			return err
+		}
+		if err := fn(card); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CardJSONWriter writes cards as JSON one at a time, so output can start
+// before every card has been read. By default the output is the indented
+// array FormatCardsJSON produces; with lines set it is JSON Lines, one
+// compact CardToMap object per line.
+type CardJSONWriter struct {
+	w     io.Writer
+	lines bool
+	n     int
+}
+
+// NewCardJSONWriter returns a CardJSONWriter writing to w. Close must be
+// called after the last card to finish an array.
+func NewCardJSONWriter(w io.Writer, lines bool) *CardJSONWriter {
+	return &CardJSONWriter{w: w, lines: lines}
+}
+
+// Write writes one card.
+func (cw *CardJSONWriter) Write(card vcard.Card) error {
+	if cw.lines {
+		data, err := json.Marshal(CardToMap(card))
+		if err != nil {
+			return fmt.Errorf("failed to marshal contact: %w", err)
+		}
+		_, err = fmt.Fprintf(cw.w, "%s\n", data)
+		return err
+	}
+	data, err := json.MarshalIndent(CardToMap(card), "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contact: %w", err)
+	}
+	sep := ",\n  "
+	if cw.n == 0 {
+		sep = "[\n  "
+	}
+	cw.n++
+	_, err = fmt.Fprintf(cw.w, "%s%s", sep, data)
+	return err
+}
+
+// Close ends the array, writing [] if there were no cards. It does nothing
+// for JSON Lines and does not close the underlying writer.
+func (cw *CardJSONWriter) Close() error {
+	if cw.lines {
+		return nil
+	}
+	end := "\n]\n"
+	if cw.n == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(cw.w, end)
+	return err
+}
@@ -0,0 +1,93 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_WalkContacts(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ada Lovelace", "Grace Hopper", "Alan Turing"} {
+		if err := cm.WriteContact(NewCard(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var names []string
+	if err := cm.WalkContacts(func(card vcard.Card) error {
+		names = append(names, CardFullName(card))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 {
+		t.Errorf("walked %v, want 3 contacts", names)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = cm.WalkContacts(func(vcard.Card) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("WalkContacts = %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+func TestCardJSONWriter(t *testing.T) {
+	cards := []vcard.Card{NewCard("Ada Lovelace"), NewCard("Grace Hopper")}
+
+	var buf bytes.Buffer
+	cw := NewCardJSONWriter(&buf, false)
+	for _, card := range cards {
+		if err := cw.Write(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want, err := FormatCardsJSON(cards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want+"\n" {
+		t.Errorf("array output =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	cw = NewCardJSONWriter(&buf, false)
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("empty output = %q, want []", buf.String())
+	}
+
+	buf.Reset()
+	cw = NewCardJSONWriter(&buf, true)
+	for _, card := range cards {
+		if err := cw.Write(card); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["name"] != "Grace Hopper" {
+		t.Errorf("second line name = %v, want Grace Hopper", m["name"])
+	}
+}
@@ -0,0 +1,95 @@
+package contacts
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// strengthHalfLife is how long it takes an interaction to count half as
+// much toward relationship strength.
+const strengthHalfLife = 90 * 24 * time.Hour
+
+// strengthScale is the decayed interaction count at which a relationship
+// scores about 63; the score approaches 100 as interactions pile up.
+const strengthScale = 4.0
+
+// fadingWindow is how far back Relationship.Previous looks.
+const fadingWindow = 90 * 24 * time.Hour
+
+// Relationship is a contact's relationship strength, scored from 0 to 100
+// by how often and how recently you interacted with them.
+type Relationship struct {
+	Card  vcard.Card
+	Score int
+	// Previous is the score as it stood 90 days earlier.
+	Previous int
+	// Count is the number of recorded interactions.
+	Count int
+	// Last is the latest interaction, zero if there is none.
+	Last time.Time
+}
+
+// Fading reports whether the relationship is weaker than 90 days earlier.
+func (r Relationship) Fading() bool {
+	return r.Score < r.Previous
+}
+
+// Relationships scores each card's relationship strength at now from the
+// interaction log, strongest first.
+func (cm *ContactManager) Relationships(cards []vcard.Card, now time.Time) ([]Relationship, error) {
+	interactions, err := cm.Interactions("")
+	if err != nil {
+		return nil, err
+	}
+	byUID := map[string][]Interaction{}
+	for _, in := range interactions {
+		byUID[in.UID] = append(byUID[in.UID], in)
+	}
+	out := make([]Relationship, 0, len(cards))
+	for _, card := range cards {
+		list := byUID[CardUID(card)]
+		r := Relationship{
+			Card:     card,
+			Score:    strengthScore(list, now),
+			Previous: strengthScore(list, now.Add(-fadingWindow)),
+			Count:    len(list),
+		}
+		for _, in := range list {
+			if in.Time.After(r.Last) {
+				r.Last = in.Time
+			}
+		}
+		out = append(out, r)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out, nil
+}
+
+// SortByStrength orders cards by relationship strength, strongest first.
+// Ties keep their order.
+func (cm *ContactManager) SortByStrength(cards []vcard.Card) error {
+	rels, err := cm.Relationships(cards, time.Now())
+	if err != nil {
+		return err
+	}
+	for i, r := range rels {
+		cards[i] = r.Card
+	}
+	return nil
+}
+
+// strengthScore scores the interactions that happened by at, each counting
+// for less the longer ago it was.
+func strengthScore(interactions []Interaction, at time.Time) int {
+	var sum float64
+	for _, in := range interactions {
+		if in.Time.After(at) {
+			continue
+		}
+		sum += math.Pow(0.5, float64(at.Sub(in.Time))/float64(strengthHalfLife))
+	}
+	return int(math.Round(100 * (1 - math.Exp(-sum/strengthScale))))
+}
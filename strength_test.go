@@ -0,0 +1,66 @@
+package contacts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactManager_Relationships(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	friend, fading, stranger := NewCard("Close"), NewCard("Fading"), NewCard("Stranger")
+	var log []Interaction
+	for i := 0; i < 6; i++ {
+		log = append(log,
+			Interaction{Time: now.AddDate(0, 0, -7*i), UID: CardUID(friend), Kind: InteractionCall},
+			Interaction{Time: now.AddDate(0, -6, -7*i), UID: CardUID(fading), Kind: InteractionCall},
+		)
+	}
+	if err := cm.RecordInteractions(log...); err != nil {
+		t.Fatal(err)
+	}
+
+	rels, err := cm.Relationships([]vcard.Card{stranger, fading, friend}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range rels {
+		names = append(names, CardFullName(r.Card))
+	}
+	if len(rels) != 3 || names[0] != "Close" || names[1] != "Fading" || names[2] != "Stranger" {
+		t.Fatalf("order = %v, want Close, Fading, Stranger", names)
+	}
+	if rels[0].Score <= rels[1].Score || rels[2].Score != 0 {
+		t.Errorf("scores = %d, %d, %d", rels[0].Score, rels[1].Score, rels[2].Score)
+	}
+	if rels[0].Fading() || !rels[1].Fading() {
+		t.Errorf("Fading() = %v, %v; want false, true", rels[0].Fading(), rels[1].Fading())
+	}
+	if rels[0].Count != 6 || !rels[0].Last.Equal(now) {
+		t.Errorf("Close: count %d, last %v", rels[0].Count, rels[0].Last)
+	}
+}
+
+func TestStrengthScore(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	if s := strengthScore(nil, now); s != 0 {
+		t.Errorf("no interactions scored %d, want 0", s)
+	}
+	var many []Interaction
+	for i := 0; i < 100; i++ {
+		many = append(many, Interaction{Time: now})
+	}
+	if s := strengthScore(many, now); s != 100 {
+		t.Errorf("100 interactions today scored %d, want 100", s)
+	}
+	future := []Interaction{{Time: now.Add(time.Hour)}}
+	if s := strengthScore(future, now); s != 0 {
+		t.Errorf("later interaction scored %d, want 0", s)
+	}
+}
@@ -0,0 +1,208 @@
+package contacts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// PlanAction is what a sync would do with one contact.
+type PlanAction string
+
+const (
+	PlanCreate PlanAction = "create"
+	PlanUpdate PlanAction = "update"
+	PlanDelete PlanAction = "delete"
+	// PlanMerge merges a locally edited contact with its remote version
+	// using the manager's merge strategy.
+	PlanMerge PlanAction = "merge"
+	// PlanConflict keeps a locally edited contact that also changed at the
+	// provider; see SyncResult.Conflicts.
+	PlanConflict PlanAction = "conflict"
+)
+
+// PlannedChange is one contact a sync would change.
+type PlannedChange struct {
+	UID    string     `json:"uid"`
+	Name   string     `json:"name,omitempty"`
+	Action PlanAction `json:"action"`
+	// Fields summarizes the field changes as "+FIELD" (added), "-FIELD"
+	// (removed) and "~FIELD" (changed).
+	Fields []string `json:"fields,omitempty"`
+	// Card is the remote vCard a sync would store. It is empty for
+	// deletes.
+	Card string `json:"card,omitempty"`
+}
+
+// SyncPlan is the change set a sync would apply, as computed by PlanSync.
+type SyncPlan struct {
+	Time    time.Time       `json:"time"`
+	Changes []PlannedChange `json:"changes"`
+	// Skipped counts fetched contacts that match the stored copy.
+	Skipped int `json:"skipped"`
+	// Fetched identifies what the provider returned, so that applying the
+	// plan can tell whether the provider has changed since.
+	Fetched string `json:"fetched,omitempty"`
+}
+
+// PlanSync fetches the provider's changes like SyncContacts but only
+// reports what a sync would do, without touching the store. An incremental
+// provider's sync position is not advanced. Apply the plan, or part of it,
+// with ApplySyncPlan.
+func (cm *ContactManager) PlanSync() (SyncPlan, error) {
+	plan := SyncPlan{Time: time.Now().UTC(), Changes: []PlannedChange{}}
+	if cm.provider == nil {
+		return plan, ErrNotInitialized
+	}
+	remoteContacts, deleted, err := cm.fetchRemote()
+	if err != nil {
+		return plan, err
+	}
+	if plan.Fetched, err = fetchedDigest(remoteContacts, deleted); err != nil {
+		return plan, err
+	}
+	index, err := cm.loadIndex()
+	if err != nil {
+		return plan, err
+	}
+	for _, card := range remoteContacts {
+		action, local := cm.planContact(card, index)
+		if action == "" {
+			plan.Skipped++
+			continue
+		}
+		data, err := EncodeCard(card)
+		if err != nil {
+			return plan, err
+		}
+		plan.Changes = append(plan.Changes, PlannedChange{
+			UID:    CardUID(card),
+			Name:   CardFullName(card),
+			Action: action,
+			Fields: changedFields(local, card),
+			Card:   string(data),
+		})
+	}
+	for _, uid := range deleted {
+		local, err := cm.GetContact(uid)
+		if err != nil || local == nil {
+			continue
+		}
+		plan.Changes = append(plan.Changes, PlannedChange{
+			UID:    uid,
+			Name:   CardFullName(local),
+			Action: PlanDelete,
+			Fields: changedFields(local, nil),
+		})
+	}
+	return plan, nil
+}
+
+// planContact decides what syncContactLocal would do with a fetched card
+// and returns the stored copy it would replace, if any. An empty action
+// means the card is unchanged.
+func (cm *ContactManager) planContact(card vcard.Card, index map[string]indexEntry) (PlanAction, vcard.Card) {
+	uid := CardUID(card)
+	if uid == "" {
+		return PlanCreate, nil
+	}
+	data, err := os.ReadFile(filepath.Join(cm.storagePath, uid+".vcf"))
+	if err != nil {
+		return PlanCreate, nil
+	}
+	local, err := DecodeCard(data)
+	if err != nil {
+		return PlanConflict, nil
+	}
+	if sameSyncedContent(local, card) {
+		return "", local
+	}
+	if entry, tracked := index[uid]; tracked && entry.Hash != hashContent(data) {
+		if cm.mergeWith == "" {
+			return PlanConflict, local
+		}
+		return PlanMerge, local
+	}
+	return PlanUpdate, local
+}
+
+// ApplySyncPlan applies a plan from PlanSync. Entries may have been removed
+// from the plan to skip them. Each change is checked again against the
+// store, so a contact edited since the plan was made is reported as a
+// conflict rather than overwritten.
+//
+// An incremental provider's sync position is advanced past the planned
+// changes, including those removed from the plan, if it still returns
+// what it did when the plan was made. Otherwise it is left for the next
+// sync to fetch the changes again, along with the newer ones.
+func (cm *ContactManager) ApplySyncPlan(plan SyncPlan) (result SyncResult, err error) {
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+	if cm.provider == nil {
+		return result, ErrNotInitialized
+	}
+	var cards []vcard.Card
+	var deleted []string
+	for _, change := range plan.Changes {
+		if change.Action == PlanDelete {
+			deleted = append(deleted, change.UID)
+			continue
+		}
+		card, err := DecodeCard([]byte(change.Card))
+		if err != nil {
+			return result, fmt.Errorf("invalid card for %s in sync plan: %w", change.UID, err)
+		}
+		cards = append(cards, card)
+	}
+	incremental, commit := cm.provider.(IncrementalProvider)
+	if commit {
+		changed, removed, err := cm.fetchRemote()
+		if err != nil {
+			return result, err
+		}
+		fetched, err := fetchedDigest(changed, removed)
+		if err != nil {
+			return result, err
+		}
+		commit = plan.Fetched != "" && fetched == plan.Fetched
+	}
+
+	unlock, err := cm.lockStore()
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
+	if err := cm.applySync(cards, deleted, &result); err != nil {
+		return result, err
+	}
+	if commit {
+		if err := incremental.CommitSync(); err != nil {
+			return result, err
+		}
+	}
+	cm.notify(WebhookPayload{Event: EventSyncCompleted, Actor: ActorSync, Count: result.Created + result.Updated + result.Deleted})
+	return result, nil
+}
+
+// fetchedDigest hashes the changes fetched from a provider, in a stable
+// order.
+func fetchedDigest(changed []vcard.Card, deleted []string) (string, error) {
+	parts := make([]string, 0, len(changed)+len(deleted))
+	for _, card := range changed {
+		data, err := EncodeCard(card)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, string(data))
+	}
+	for _, id := range deleted {
+		parts = append(parts, "-"+id)
+	}
+	sort.Strings(parts)
+	return hashContent([]byte(strings.Join(parts, "\n"))), nil
+}
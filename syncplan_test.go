@@ -0,0 +1,128 @@
+package contacts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+type deletingProvider struct {
+	mockProvider
+	deleted []string
+	commits int
+}
+
+func (p *deletingProvider) FetchChanges() ([]vcard.Card, []string, error) {
+	return p.contacts, p.deleted, nil
+}
+func (p *deletingProvider) CommitSync() error {
+	p.commits++
+	return nil
+}
+
+func TestContactManager_PlanSync(t *testing.T) {
+	dir := t.TempDir()
+	same := NewCard("Same")
+	changed := NewCard("Changed")
+	edited := NewCard("Edited")
+	gone := NewCard("Gone")
+	provider := &deletingProvider{mockProvider: mockProvider{contacts: []vcard.Card{same, changed, edited, gone}}}
+	cm, err := NewContactManager(provider, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	local := NewCard("Edited")
+	local.SetValue(vcard.FieldUID, CardUID(edited))
+	local.SetValue(vcard.FieldNote, "by hand")
+	data, err := EncodeCard(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cm.storagePath, CardUID(edited)+".vcf"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	changed.SetValue(vcard.FieldTitle, "Engineer")
+	edited.SetValue(vcard.FieldTitle, "Engineer")
+	added := NewCard("Added")
+	provider.contacts = []vcard.Card{same, changed, edited, added}
+	provider.deleted = []string{CardUID(gone)}
+
+	plan, err := cm.PlanSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := map[string]PlanAction{}
+	for _, c := range plan.Changes {
+		actions[c.Name] = c.Action
+	}
+	want := map[string]PlanAction{
+		"Changed": PlanUpdate,
+		"Edited":  PlanConflict,
+		"Added":   PlanCreate,
+		"Gone":    PlanDelete,
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("plan actions = %v, want %v", actions, want)
+	}
+	if plan.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", plan.Skipped)
+	}
+	if got, err := cm.GetContact(CardUID(added)); err != nil || got != nil {
+		t.Error("PlanSync changed the store")
+	}
+
+	// Round-trip through JSON and drop the delete before applying.
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded SyncPlan
+	if err := json.Unmarshal(raw, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	var kept []PlannedChange
+	for _, c := range loaded.Changes {
+		if c.Action != PlanDelete {
+			kept = append(kept, c)
+		}
+	}
+	loaded.Changes = kept
+
+	result, err := cm.ApplySyncPlan(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 1 || result.Updated != 1 || result.Deleted != 0 || len(result.Conflicts) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if got, _ := cm.GetContact(CardUID(changed)); got.Value(vcard.FieldTitle) != "Engineer" {
+		t.Error("planned update not applied")
+	}
+	if got, _ := cm.GetContact(CardUID(gone)); got == nil {
+		t.Error("delete removed from the plan was applied")
+	}
+	if provider.commits != 2 {
+		t.Errorf("%d sync commits, want one for the sync and one for the plan", provider.commits)
+	}
+
+	// A provider that changed since the plan was made keeps its position,
+	// so the next sync fetches the change.
+	if plan, err = cm.PlanSync(); err != nil {
+		t.Fatal(err)
+	}
+	added.SetValue(vcard.FieldTitle, "Analyst")
+	if _, err := cm.ApplySyncPlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	if provider.commits != 2 {
+		t.Error("ApplySyncPlan committed a sync position past changes the plan lacks")
+	}
+}
@@ -0,0 +1,124 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Task is a reminder exported to a task manager.
+type Task struct {
+	// ID is stable for the same reminder, so re-exporting updates the
+	// task instead of duplicating it where the format supports that.
+	ID          string
+	Description string
+	Due         time.Time
+	// Tags are "contacts" plus the occasion kind or "keep-in-touch".
+	Tags []string
+}
+
+// taskNamespace is the UUID namespace of Task.ID.
+var taskNamespace = uuid.MustParse("9b0d7f3e-5a41-4c8e-8f26-2d9e1c7b4a60")
+
+// ReminderTasks turns upcoming occasions and overdue keep-in-touch
+// contacts into tasks. Overdue contacts are due today.
+func ReminderTasks(occasions []Occasion, due []DueContact, now time.Time) []Task {
+	var tasks []Task
+	for _, o := range occasions {
+		name := CardFullName(o.Card)
+		desc := fmt.Sprintf("%s: %s", name, o.Kind)
+		switch o.Kind {
+		case "birthday":
+			desc = "Wish " + name + " a happy birthday"
+		case "anniversary":
+			desc = name + "'s anniversary"
+		}
+		if n := o.Years(); n > 0 {
+			if o.Kind == "birthday" {
+				desc += fmt.Sprintf(" (turns %d)", n)
+			} else {
+				desc += fmt.Sprintf(" (%d years)", n)
+			}
+		}
+		tasks = append(tasks, Task{
+			ID:          taskID(CardUID(o.Card), o.Kind, o.Date.Format("2006-01-02")),
+			Description: desc,
+			Due:         o.Date,
+			Tags:        []string{"contacts", o.Kind},
+		})
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, d := range due {
+		// One task per missed interval: a new one once the contact is
+		// touched and falls due again.
+		tasks = append(tasks, Task{
+			ID:          taskID(CardUID(d.Card), "keep-in-touch", d.Due.Format("2006-01-02")),
+			Description: fmt.Sprintf("Get in touch with %s (every %s)", CardFullName(d.Card), d.Cadence),
+			Due:         today,
+			Tags:        []string{"contacts", "keep-in-touch"},
+		})
+	}
+	return tasks
+}
+
+func taskID(parts ...string) string {
+	return uuid.NewSHA1(taskNamespace, []byte(strings.Join(parts, "\x00"))).String()
+}
+
+// WriteTaskwarrior writes tasks in Taskwarrior's import format, a JSON
+// array for `task import`. The task UUIDs are stable, so importing again
+// updates existing tasks.
+func WriteTaskwarrior(w io.Writer, tasks []Task) error {
+	type twTask struct {
+		UUID        string   `json:"uuid"`
+		Description string   `json:"description"`
+		Status      string   `json:"status"`
+		Entry       string   `json:"entry"`
+		Due         string   `json:"due"`
+		Tags        []string `json:"tags,omitempty"`
+	}
+	out := []twTask{}
+	entry := time.Now().UTC().Format("20060102T150405Z")
+	for _, t := range tasks {
+		out = append(out, twTask{
+			UUID:        t.ID,
+			Description: t.Description,
+			Status:      "pending",
+			Entry:       entry,
+			Due:         t.Due.UTC().Format("20060102T150405Z"),
+			Tags:        t.Tags,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+	return nil
+}
+
+// WriteTodoTxt writes tasks as todo.txt lines: the description followed by
+// a +contacts project, a context per other tag and a due: date.
+func WriteTodoTxt(w io.Writer, tasks []Task) error {
+	created := time.Now().Format("2006-01-02")
+	for _, t := range tasks {
+		var b strings.Builder
+		b.WriteString(created + " " + t.Description)
+		for _, tag := range t.Tags {
+			if tag == "contacts" {
+				b.WriteString(" +contacts")
+			} else {
+				b.WriteString(" @" + tag)
+			}
+		}
+		b.WriteString(" due:" + t.Due.Format("2006-01-02"))
+		if _, err := fmt.Fprintln(w, b.String()); err != nil {
+			return fmt.Errorf("failed to write tasks: %w", err)
+		}
+	}
+	return nil
+}
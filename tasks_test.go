@@ -0,0 +1,75 @@
+package contacts
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func reminderFixture(t *testing.T) []Task {
+	t.Helper()
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	ada := NewCard("Ada Lovelace")
+	ada.SetValue(vcard.FieldUID, "ada")
+	ada.SetValue(vcard.FieldBirthday, "1815-06-10")
+	mom := NewCard("Mom")
+	mom.SetValue(vcard.FieldUID, "mom")
+	occasions := UpcomingOccasions([]vcard.Card{ada}, now, 30)
+	due := []DueContact{{Card: mom, Cadence: Cadence{N: 2, Unit: 'w'}, Due: now.AddDate(0, 0, -3)}}
+	return ReminderTasks(occasions, due, now)
+}
+
+func TestReminderTasks(t *testing.T) {
+	tasks := reminderFixture(t)
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2", len(tasks))
+	}
+	if got := tasks[0].Description; got != "Wish Ada Lovelace a happy birthday (turns 209)" {
+		t.Errorf("birthday description = %q", got)
+	}
+	if got := tasks[1].Description; got != "Get in touch with Mom (every 2w)" {
+		t.Errorf("keep-in-touch description = %q", got)
+	}
+	if !tasks[1].Due.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("keep-in-touch due = %v, want today", tasks[1].Due)
+	}
+	again := reminderFixture(t)
+	if tasks[1].ID != again[1].ID || tasks[0].ID == tasks[1].ID {
+		t.Error("task IDs are not stable and distinct")
+	}
+}
+
+func TestWriteTaskwarrior(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTaskwarrior(&buf, reminderFixture(t)); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0]["status"] != "pending" || got[0]["due"] != "20240610T000000Z" || got[0]["uuid"] == "" {
+		t.Errorf("taskwarrior output = %v", got)
+	}
+}
+
+func TestWriteTodoTxt(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTodoTxt(&buf, reminderFixture(t)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if !strings.HasSuffix(lines[0], "Wish Ada Lovelace a happy birthday (turns 209) +contacts @birthday due:2024-06-10") {
+		t.Errorf("line = %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "+contacts @keep-in-touch due:2024-06-01") {
+		t.Errorf("line = %q", lines[1])
+	}
+}
@@ -0,0 +1,80 @@
+package contacts
+
+import (
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// ContactTemplate pre-fills fields of contacts created with `contacts add
+// --template`. Templates are defined under "templates" in config.json.
+type ContactTemplate struct {
+	Org   string `json:"org,omitempty"`
+	Title string `json:"title,omitempty"`
+	// EmailDomain completes email addresses given without a domain, so
+	// "jdoe" becomes "jdoe@example.com".
+	EmailDomain string `json:"email_domain,omitempty"`
+	// Tags are added to the card's CATEGORIES.
+	Tags []string `json:"tags,omitempty"`
+	Note string   `json:"note,omitempty"`
+}
+
+// CompleteEmail appends the template's email domain to an address without
+// one.
+func (t ContactTemplate) CompleteEmail(email string) string {
+	if t.EmailDomain == "" || email == "" || strings.Contains(email, "@") {
+		return email
+	}
+	return email + "@" + strings.TrimPrefix(t.EmailDomain, "@")
+}
+
+// Apply fills in the card's empty fields from the template and adds its
+// tags. Fields already set on the card are kept.
+func (t ContactTemplate) Apply(card vcard.Card) {
+	if t.Org != "" && card.Value(vcard.FieldOrganization) == "" {
+		card.SetValue(vcard.FieldOrganization, t.Org)
+	}
+	if t.Title != "" && card.Value(vcard.FieldTitle) == "" {
+		card.SetValue(vcard.FieldTitle, t.Title)
+	}
+	if t.Note != "" && card.Value(vcard.FieldNote) == "" {
+		card.SetValue(vcard.FieldNote, t.Note)
+	}
+	for _, f := range card[vcard.FieldEmail] {
+		f.Value = t.CompleteEmail(f.Value)
+	}
+	addCategories(card, t.Tags...)
+}
+
+// addCategories adds tags the card's CATEGORIES lack, case-insensitively.
+// It reports whether any were added.
+func addCategories(card vcard.Card, tags ...string) bool {
+	var have []string
+	for _, f := range card[vcard.FieldCategories] {
+		for _, tag := range strings.Split(f.Value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				have = append(have, tag)
+			}
+		}
+	}
+	added := false
+	for _, tag := range tags {
+		if !containsFold(have, tag) {
+			have = append(have, tag)
+			added = true
+		}
+	}
+	if added {
+		card.SetCategories(have)
+	}
+	return added
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
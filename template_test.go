@@ -0,0 +1,59 @@
+package contacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestContactTemplate_Apply(t *testing.T) {
+	tmpl := ContactTemplate{
+		Org:         "Acme Inc",
+		Title:       "Engineer",
+		EmailDomain: "acme.com",
+		Tags:        []string{"coworker", "acme"},
+	}
+	card := NewCard("Wile E. Coyote")
+	card.SetValue(vcard.FieldTitle, "Genius")
+	card.SetValue(vcard.FieldCategories, "Acme")
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "wile"})
+	card.Add(vcard.FieldEmail, &vcard.Field{Value: "wile@home.example"})
+	tmpl.Apply(card)
+
+	if got := card.Value(vcard.FieldOrganization); got != "Acme Inc" {
+		t.Errorf("ORG = %q, want Acme Inc", got)
+	}
+	if got := card.Value(vcard.FieldTitle); got != "Genius" {
+		t.Errorf("TITLE = %q, want the existing value kept", got)
+	}
+	if got := card.Values(vcard.FieldEmail); len(got) != 2 || got[0] != "wile@acme.com" || got[1] != "wile@home.example" {
+		t.Errorf("EMAIL = %v", got)
+	}
+	if got := card.Value(vcard.FieldCategories); got != "Acme,coworker" {
+		t.Errorf("CATEGORIES = %q, want Acme,coworker", got)
+	}
+}
+
+func TestConfig_Template(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"templates": {"coworker": {"org": "Acme Inc", "email_domain": "acme.com", "tags": ["coworker"]}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Dir: dir}
+	if err := cfg.Load(); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := cfg.Template("coworker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.Org != "Acme Inc" || tmpl.CompleteEmail("jdoe") != "jdoe@acme.com" {
+		t.Errorf("got %+v", tmpl)
+	}
+	if _, err := cfg.Template("family"); err == nil {
+		t.Error("missing template returned no error")
+	}
+}
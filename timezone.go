@@ -0,0 +1,155 @@
+package contacts
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// utcOffsetPattern matches a vCard UTC offset such as "-05:00" or "+0530".
+var utcOffsetPattern = regexp.MustCompile(`^([+-])(\d{2}):?(\d{2})$`)
+
+// ParseTimeZone parses a TZ value: an IANA zone name such as
+// "Europe/Berlin" or a UTC offset such as "-05:00".
+func ParseTimeZone(value string) (*time.Location, error) {
+	value = strings.TrimSpace(value)
+	if m := utcOffsetPattern.FindStringSubmatch(value); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone("UTC"+m[1]+m[2]+":"+m[3], offset), nil
+	}
+	if value == "" || value == "Local" {
+		return nil, fmt.Errorf("invalid time zone %q", value)
+	}
+	loc, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", value, err)
+	}
+	return loc, nil
+}
+
+// CardTimeZone returns the location of the card's TZ field.
+func CardTimeZone(card vcard.Card) (*time.Location, bool) {
+	tz := card.Value(vcard.FieldTimezone)
+	if tz == "" {
+		return nil, false
+	}
+	loc, err := ParseTimeZone(tz)
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// countryZones maps countries with a single time zone, by ISO 3166 code,
+// to that zone. Countries spanning several zones are left out.
+var countryZones = map[string]string{
+	"gb": "Europe/London",
+	"ie": "Europe/Dublin",
+	"de": "Europe/Berlin",
+	"fr": "Europe/Paris",
+	"it": "Europe/Rome",
+	"nl": "Europe/Amsterdam",
+	"be": "Europe/Brussels",
+	"ch": "Europe/Zurich",
+	"at": "Europe/Vienna",
+	"se": "Europe/Stockholm",
+	"no": "Europe/Oslo",
+	"dk": "Europe/Copenhagen",
+	"fi": "Europe/Helsinki",
+	"pl": "Europe/Warsaw",
+	"in": "Asia/Kolkata",
+	"cn": "Asia/Shanghai",
+	"jp": "Asia/Tokyo",
+	"kr": "Asia/Seoul",
+	"sg": "Asia/Singapore",
+	"za": "Africa/Johannesburg",
+	"il": "Asia/Jerusalem",
+	"ae": "Asia/Dubai",
+	"ar": "America/Argentina/Buenos_Aires",
+}
+
+// ContactTimeZone returns the card's time zone: its TZ field or, failing
+// that, the zone of the country of its first address that has only one.
+// derived reports that the zone came from an address.
+func ContactTimeZone(card vcard.Card) (loc *time.Location, derived, ok bool) {
+	if loc, ok := CardTimeZone(card); ok {
+		return loc, false, true
+	}
+	for _, f := range card[vcard.FieldAddress] {
+		parts := strings.Split(f.Value, ";")
+		if len(parts) < 7 || strings.TrimSpace(parts[6]) == "" {
+			continue
+		}
+		for _, v := range countryVariants(parts[6]) {
+			if zone, found := countryZones[v]; found {
+				if loc, err := time.LoadLocation(zone); err == nil {
+					return loc, true, true
+				}
+			}
+		}
+	}
+	return nil, false, false
+}
+
+// The hours, in a contact's local time, considered a good time to call.
+const (
+	callWindowStart = 9
+	callWindowEnd   = 21
+)
+
+// CallHint reports whether local, a contact's current local time, falls in
+// the call window (9:00 to 21:00) and describes it.
+func CallHint(local time.Time) (good bool, hint string) {
+	switch h := local.Hour(); {
+	case h >= callWindowStart && h < callWindowEnd:
+		return true, "good time to call"
+	case h >= callWindowStart-2 && h < callWindowStart:
+		return false, "early, maybe send a message"
+	case h >= callWindowEnd && h < callWindowEnd+2:
+		return false, "late, maybe send a message"
+	default:
+		return false, "probably asleep"
+	}
+}
+
+// ParseGeo parses a GEO value: a vCard 4.0 geo URI ("geo:52.52,13.405")
+// or the vCard 3.0 "lat;lon" form.
+func ParseGeo(value string) (lat, lon float64, err error) {
+	s := strings.TrimPrefix(strings.TrimSpace(value), "geo:")
+	s, _, _ = strings.Cut(s, ";u=")
+	sep := ","
+	if !strings.Contains(s, ",") {
+		sep = ";"
+	}
+	latStr, lonStr, ok := strings.Cut(s, sep)
+	if ok {
+		lat, err = strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	}
+	if ok && err == nil {
+		lon, err = strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	}
+	if !ok || err != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid geo position %q: expected geo:lat,lon", value)
+	}
+	return lat, lon, nil
+}
+
+// CardGeo returns the position in the card's GEO field.
+func CardGeo(card vcard.Card) (lat, lon float64, ok bool) {
+	lat, lon, err := ParseGeo(card.Value(vcard.FieldGeolocation))
+	return lat, lon, err == nil
+}
+
+// FormatGeo renders a position as a vCard 4.0 geo URI.
+func FormatGeo(lat, lon float64) string {
+	return "geo:" + strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64)
+}
@@ -0,0 +1,117 @@
+package contacts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestParseTimeZone(t *testing.T) {
+	tests := []struct {
+		value  string
+		offset int
+		ok     bool
+	}{
+		{"UTC", 0, true},
+		{"-05:00", -5 * 3600, true},
+		{"+0530", 5*3600 + 30*60, true},
+		{"Europe/Berlin", 0, true},
+		{"", 0, false},
+		{"Mars/Olympus", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			loc, err := ParseTimeZone(tt.value)
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseTimeZone(%q) error = %v, want ok=%v", tt.value, err, tt.ok)
+			}
+			if err != nil || strings.Contains(tt.value, "/") {
+				return
+			}
+			if _, off := timeIn(loc); off != tt.offset {
+				t.Errorf("offset = %d, want %d", off, tt.offset)
+			}
+		})
+	}
+}
+
+func TestParseGeo(t *testing.T) {
+	tests := []struct {
+		value    string
+		lat, lon float64
+		ok       bool
+	}{
+		{"geo:52.52,13.405", 52.52, 13.405, true},
+		{"geo:37.386013,-122.082932;u=10", 37.386013, -122.082932, true},
+		{"52.52;13.405", 52.52, 13.405, true},
+		{"geo:91,0", 0, 0, false},
+		{"nowhere", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lat, lon, err := ParseGeo(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseGeo(%q) error = %v, want ok=%v", tt.value, err, tt.ok)
+			continue
+		}
+		if lat != tt.lat || lon != tt.lon {
+			t.Errorf("ParseGeo(%q) = %v, %v, want %v, %v", tt.value, lat, lon, tt.lat, tt.lon)
+		}
+	}
+}
+
+func TestFormatCard_TimeZoneAndGeo(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	card.SetValue(vcard.FieldTimezone, "Europe/London")
+	card.SetValue(vcard.FieldGeolocation, FormatGeo(51.5074, -0.1278))
+	out := FormatCard(card)
+	if !strings.Contains(out, "Time zone: Europe/London (now ") {
+		t.Errorf("time zone not shown:\n%s", out)
+	}
+	if !strings.Contains(out, "Geo:       51.5074, -0.1278") {
+		t.Errorf("geo not shown:\n%s", out)
+	}
+}
+
+func timeIn(loc *time.Location) (string, int) {
+	return time.Date(2024, time.January, 1, 0, 0, 0, 0, loc).Zone()
+}
+
+func TestContactTimeZone(t *testing.T) {
+	card := NewCard("Ada Lovelace")
+	if _, _, ok := ContactTimeZone(card); ok {
+		t.Error("ContactTimeZone() found a zone for a card without TZ or address")
+	}
+	card.Add(vcard.FieldAddress, &vcard.Field{Value: ";;1 Main St;Portland;OR;;USA"})
+	card.Add(vcard.FieldAddress, &vcard.Field{Value: ";;10 Downing St;London;;;United Kingdom"})
+	loc, derived, ok := ContactTimeZone(card)
+	if !ok || !derived || loc.String() != "Europe/London" {
+		t.Errorf("ContactTimeZone() = %v, %v, %v; want Europe/London from the address", loc, derived, ok)
+	}
+	card.SetValue(vcard.FieldTimezone, "Asia/Tokyo")
+	if loc, derived, ok = ContactTimeZone(card); !ok || derived || loc.String() != "Asia/Tokyo" {
+		t.Errorf("ContactTimeZone() = %v, %v, %v; want TZ field", loc, derived, ok)
+	}
+}
+
+func TestCallHint(t *testing.T) {
+	tests := []struct {
+		hour int
+		good bool
+		hint string
+	}{
+		{3, false, "probably asleep"},
+		{8, false, "early, maybe send a message"},
+		{9, true, "good time to call"},
+		{20, true, "good time to call"},
+		{21, false, "late, maybe send a message"},
+		{23, false, "probably asleep"},
+	}
+	for _, tt := range tests {
+		good, hint := CallHint(time.Date(2024, time.January, 1, tt.hour, 30, 0, 0, time.UTC))
+		if good != tt.good || hint != tt.hint {
+			t.Errorf("CallHint(%02d:30) = %v, %q; want %v, %q", tt.hour, good, hint, tt.good, tt.hint)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+	"github.com/google/uuid"
+)
+
+// UID schemes for locally created contacts, selected in Config.UIDScheme.
+const (
+	// UIDRandom gives each new contact a random UUID.
+	UIDRandom = "random"
+	// UIDStable derives the UID from the contact's name and email, so the
+	// same data imported on different machines gets the same UID.
+	UIDStable = "stable"
+)
+
+// stableUIDNamespace is the UUID namespace of StableUID.
+var stableUIDNamespace = uuid.MustParse("6f1c7a52-3d0e-4c2b-9a57-0c6a0d3c9e41")
+
+// StableUID derives a UUID from a card's name and primary email address.
+// It returns "" if the card has neither.
+func StableUID(card vcard.Card) string {
+	name := strings.ToLower(strings.Join(strings.Fields(CardFullName(card)), " "))
+	email := strings.ToLower(strings.TrimSpace(PrimaryEmail(card)))
+	if name == "" && email == "" {
+		return ""
+	}
+	return uuid.NewSHA1(stableUIDNamespace, []byte(name+"\x00"+email)).String()
+}
+
+// WithStableUIDs makes WriteContact replace the random UUID of a contact
+// it has not stored before with StableUID, so re-importing the same
+// contacts updates them instead of adding duplicates.
+func WithStableUIDs() ManagerOption {
+	return func(cm *ContactManager) { cm.stableUIDs = true }
+}
+
+// assignStableUID gives a new card with a random UUID its StableUID.
+func (cm *ContactManager) assignStableUID(card vcard.Card) error {
+	id, err := uuid.Parse(CardUID(card))
+	if err != nil || id.Version() != 4 {
+		return nil
+	}
+	stored, err := cm.GetContact(CardUID(card))
+	if err != nil || stored != nil {
+		return err
+	}
+	if uid := StableUID(card); uid != "" {
+		card.SetValue(vcard.FieldUID, uid)
+	}
+	return nil
+}
+
+// parseUIDScheme validates Config.UIDScheme.
+func parseUIDScheme(s string) (stable bool, err error) {
+	switch s {
+	case "", UIDRandom:
+		return false, nil
+	case UIDStable:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid uid_scheme %q: expected random or stable", s)
+}
@@ -0,0 +1,77 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestStableUID(t *testing.T) {
+	a := NewCard("Ada  Lovelace")
+	a.SetValue(vcard.FieldEmail, "Ada@Example.com")
+	b := NewCard("ada lovelace")
+	b.SetValue(vcard.FieldEmail, "ada@example.com ")
+	c := NewCard("Ada Lovelace")
+	c.SetValue(vcard.FieldEmail, "ada@analytical.example")
+
+	if StableUID(a) == "" || StableUID(a) != StableUID(b) {
+		t.Errorf("StableUID differs for the same name and email: %q, %q", StableUID(a), StableUID(b))
+	}
+	if StableUID(a) == StableUID(c) {
+		t.Error("StableUID is the same for different emails")
+	}
+	if uid := StableUID(make(vcard.Card)); uid != "" {
+		t.Errorf("StableUID(empty) = %q, want empty", uid)
+	}
+}
+
+func TestContactManager_WithStableUIDs(t *testing.T) {
+	cm, err := NewContactManager(nil, t.TempDir(), WithStableUIDs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := NewCard("Ada Lovelace")
+	first.SetValue(vcard.FieldEmail, "ada@example.com")
+	if err := cm.WriteContact(first); err != nil {
+		t.Fatal(err)
+	}
+	if CardUID(first) != StableUID(first) {
+		t.Errorf("UID = %q, want StableUID %q", CardUID(first), StableUID(first))
+	}
+
+	// Importing the same contact again updates it.
+	again := NewCard("Ada Lovelace")
+	again.SetValue(vcard.FieldEmail, "ada@example.com")
+	again.SetValue(vcard.FieldTitle, "Analyst")
+	if err := cm.WriteContact(again); err != nil {
+		t.Fatal(err)
+	}
+	list, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Value(vcard.FieldTitle) != "Analyst" {
+		t.Errorf("store has %d contacts, want the one updated contact", len(list))
+	}
+
+	// Stored contacts and non-UUID UIDs keep their UID.
+	first.SetValue(vcard.FieldEmail, "ada@analytical.example")
+	uid := CardUID(first)
+	if err := cm.WriteContact(first); err != nil || CardUID(first) != uid {
+		t.Errorf("rewriting a stored contact changed its UID to %q (%v)", CardUID(first), err)
+	}
+	custom := NewCard("Charles Babbage")
+	custom.SetValue(vcard.FieldUID, "charles")
+	if err := cm.WriteContact(custom); err != nil || CardUID(custom) != "charles" {
+		t.Errorf("custom UID changed to %q (%v)", CardUID(custom), err)
+	}
+}
+
+func TestConfig_UIDScheme(t *testing.T) {
+	for scheme, ok := range map[string]bool{"": true, UIDRandom: true, UIDStable: true, "sequential": false} {
+		cfg := &Config{UIDScheme: scheme}
+		if _, err := cfg.ManagerOptions(); (err == nil) != ok {
+			t.Errorf("uid_scheme %q: err = %v", scheme, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Limits enforced by ValidateCard.
+const (
+	// maxUIDLength keeps a contact's file name well within file system
+	// limits.
+	maxUIDLength = 200
+	// maxFieldBytes caps one field value; maxMediaBytes caps inline
+	// photos, logos, sounds and keys.
+	maxFieldBytes = 64 << 10
+	maxMediaBytes = 1 << 20
+	// maxCardFields caps the number of field values on one card.
+	maxCardFields = 1000
+)
+
+// mediaFields may hold inline data URIs and get the larger size limit.
+var mediaFields = map[string]bool{
+	vcard.FieldPhoto: true,
+	vcard.FieldLogo:  true,
+	vcard.FieldSound: true,
+	vcard.FieldKey:   true,
+}
+
+// ValidateCard checks that a card is safe to store: its UID can be used as
+// a file name in the storage directory, it has a formatted name, and its
+// fields are within size limits. WriteContact, sync and the batch API
+// reject cards that fail it, so cards from imports, the API or a provider
+// cannot write outside the storage directory or fill the disk.
+func ValidateCard(card vcard.Card) error {
+	if err := ValidateUID(CardUID(card)); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCard, err)
+	}
+	if strings.TrimSpace(CardFullName(card)) == "" {
+		return fmt.Errorf("%w: %s has no formatted name (FN)", ErrInvalidCard, CardUID(card))
+	}
+	n := 0
+	for key, fields := range card {
+		limit := maxFieldBytes
+		if mediaFields[key] {
+			limit = maxMediaBytes
+		}
+		for _, f := range fields {
+			n++
+			if len(f.Value) > limit {
+				return fmt.Errorf("%w: %s of %s is longer than %d bytes", ErrInvalidCard, key, CardUID(card), limit)
+			}
+		}
+	}
+	if n > maxCardFields {
+		return fmt.Errorf("%w: %s has more than %d fields", ErrInvalidCard, CardUID(card), maxCardFields)
+	}
+	return nil
+}
+
+// ValidateUID rejects UIDs that cannot safely be used as a contact's file
+// name: only ASCII letters, digits and - _ . @ + = ~ are allowed, and a
+// UID may not start with a dot.
+func ValidateUID(uid string) error {
+	switch {
+	case uid == "":
+		return errors.New("missing UID")
+	case len(uid) > maxUIDLength:
+		return fmt.Errorf("UID %.20q… is longer than %d characters", uid, maxUIDLength)
+	case uid[0] == '.':
+		return fmt.Errorf("invalid UID %q: may not start with a dot", uid)
+	}
+	for _, r := range uid {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || strings.ContainsRune("-_.@+=~", r)) {
+			return fmt.Errorf("invalid UID %q: only letters, digits and - _ . @ + = ~ are allowed", uid)
+		}
+	}
+	return nil
+}
+
+// uidInStore reports whether uid names a file inside the storage
+// directory. It is looser than validUID so contacts stored before UIDs
+// were validated can still be read and deleted.
+func uidInStore(uid string) bool {
+	return strings.TrimSpace(uid) != "" && !strings.ContainsAny(uid, "/\\\x00") && uid != "." && uid != ".."
+}
+
+// normalizeUID strips a urn:uuid: prefix, which RELATED and MEMBER
+// references already treat as the bare UID, so such cards can be stored.
+func normalizeUID(card vcard.Card) {
+	if uid := CardUID(card); strings.HasPrefix(strings.ToLower(uid), "urn:uuid:") {
+		card.SetValue(vcard.FieldUID, uid[len("urn:uuid:"):])
+	}
+}
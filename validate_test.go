@@ -0,0 +1,102 @@
+package contacts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-vcard"
+)
+
+func TestValidateCard(t *testing.T) {
+	withUID := func(uid string) vcard.Card {
+		card := NewCard("Ada Lovelace")
+		card.SetValue(vcard.FieldUID, uid)
+		return card
+	}
+	if err := ValidateCard(NewCard("Ada Lovelace")); err != nil {
+		t.Errorf("ValidateCard(new card) = %v", err)
+	}
+	if err := ValidateCard(withUID("c1234_ab.cd@example.com")); err != nil {
+		t.Errorf("ValidateCard(provider UID) = %v", err)
+	}
+
+	noName := NewCard(" ")
+	bigNote := NewCard("Ada Lovelace")
+	bigNote.SetValue(vcard.FieldNote, strings.Repeat("x", maxFieldBytes+1))
+	bigPhoto := NewCard("Ada Lovelace")
+	bigPhoto.SetValue(vcard.FieldPhoto, strings.Repeat("x", maxFieldBytes+1))
+	if err := ValidateCard(bigPhoto); err != nil {
+		t.Errorf("ValidateCard(inline photo) = %v, want media to get a larger limit", err)
+	}
+	manyFields := NewCard("Ada Lovelace")
+	for i := 0; i <= maxCardFields; i++ {
+		manyFields.Add(vcard.FieldEmail, &vcard.Field{Value: "a@example.com"})
+	}
+
+	for name, card := range map[string]vcard.Card{
+		"traversal":   withUID("../evil"),
+		"separator":   withUID(`a\b`),
+		"dot":         withUID(".hidden"),
+		"space":       withUID("a b"),
+		"empty":       withUID(""),
+		"long":        withUID(strings.Repeat("a", maxUIDLength+1)),
+		"no name":     noName,
+		"big note":    bigNote,
+		"many fields": manyFields,
+	} {
+		if err := ValidateCard(card); !errors.Is(err, ErrInvalidCard) {
+			t.Errorf("ValidateCard(%s) = %v, want ErrInvalidCard", name, err)
+		}
+	}
+}
+
+func TestContactManager_WriteContactValidates(t *testing.T) {
+	dir := t.TempDir()
+	cm, err := NewContactManager(nil, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evil := NewCard("Evil")
+	evil.SetValue(vcard.FieldUID, "../../evil")
+	if err := cm.WriteContact(evil); !errors.Is(err, ErrInvalidCard) {
+		t.Errorf("WriteContact(../../evil) = %v, want ErrInvalidCard", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.vcf")); !os.IsNotExist(err) {
+		t.Error("WriteContact wrote outside the storage directory")
+	}
+	if card, err := cm.GetContact("../index"); card != nil || err != nil {
+		t.Errorf("GetContact(../index) = %v, %v; want nil, nil", card, err)
+	}
+	if err := cm.DeleteContact("../index.json"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteContact(../index.json) = %v, want ErrNotFound", err)
+	}
+
+	urn := NewCard("Ada Lovelace")
+	urn.SetValue(vcard.FieldUID, "urn:uuid:0b8e3ad4-5d7e-4b0c-9d55-3f7c2b1a9e10")
+	if err := cm.WriteContact(urn); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cm.GetContact("0b8e3ad4-5d7e-4b0c-9d55-3f7c2b1a9e10"); got == nil {
+		t.Error("urn:uuid: prefix was not stripped from the UID")
+	}
+}
+
+func TestContactManager_SyncSkipsInvalid(t *testing.T) {
+	evil := NewCard("Evil")
+	evil.SetValue(vcard.FieldUID, "../evil")
+	good := NewCard("Ada Lovelace")
+	cm, err := NewContactManager(&mockProvider{contacts: []vcard.Card{evil, good}}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := cm.SyncContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 1 || len(result.Invalid) != 1 || !strings.Contains(result.Invalid[0], "../evil") {
+		t.Errorf("result = %+v, want one created and ../evil invalid", result)
+	}
+}
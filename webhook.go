@@ -0,0 +1,153 @@
+package contacts
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/emersion/go-vcard"
+)
+
+// Webhook events.
+const (
+	EventCreated       = "contact.created"
+	EventUpdated       = "contact.updated"
+	EventDeleted       = "contact.deleted"
+	EventSyncCompleted = "sync.completed"
+)
+
+// Webhook is an outbound HTTP endpoint notified of contact changes.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret signs each payload; the HMAC-SHA256 of the body is sent in
+	// the X-Contacts-Signature header as "sha256=<hex>".
+	Secret string `json:"secret,omitempty"`
+	// Events limits delivery to the listed events. Empty means all.
+	Events []string `json:"events,omitempty"`
+}
+
+func (w Webhook) wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body POSTed to webhooks.
+type WebhookPayload struct {
+	Event   string         `json:"event"`
+	Time    time.Time      `json:"time"`
+	Actor   string         `json:"actor,omitempty"`
+	UID     string         `json:"uid,omitempty"`
+	Name    string         `json:"name,omitempty"`
+	Fields  []string       `json:"fields,omitempty"`
+	Contact map[string]any `json:"contact,omitempty"`
+	Count   int            `json:"count,omitempty"`
+}
+
+// webhookClient delivers webhooks; a slow endpoint shouldn't stall writes.
+var webhookClient = &http.Client{Timeout: 5 * time.Second}
+
+// WithWebhooks notifies the given webhooks of contact changes made through
+// the manager.
+func WithWebhooks(hooks ...Webhook) ManagerOption {
+	return func(cm *ContactManager) {
+		cm.webhooks = append(cm.webhooks, hooks...)
+	}
+}
+
+// WithWebhookErrorHandler sets a function called when a webhook can't be
+// delivered. Delivery failures never fail the change that triggered them.
+func WithWebhookErrorHandler(fn func(error)) ManagerOption {
+	return func(cm *ContactManager) {
+		cm.webhookErr = fn
+	}
+}
+
+// notifyContact fires a contact event. card is nil for deletions.
+func (cm *ContactManager) notifyContact(entry AuditEntry, card vcard.Card) {
+	event := EventUpdated
+	switch entry.Action {
+	case "create":
+		event = EventCreated
+	case "delete":
+		event = EventDeleted
+	}
+	payload := WebhookPayload{
+		Event:  event,
+		Actor:  entry.Actor,
+		UID:    entry.UID,
+		Name:   entry.Name,
+		Fields: entry.Fields,
+	}
+	if card != nil {
+		payload.Contact = CardToMap(card)
+	}
+	cm.notify(payload)
+}
+
+func (cm *ContactManager) notify(payload WebhookPayload) {
+	if len(cm.webhooks) == 0 {
+		return
+	}
+	payload.Time = time.Now().UTC()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		cm.reportWebhookError(fmt.Errorf("failed to marshal webhook payload: %w", err))
+		return
+	}
+	for _, hook := range cm.webhooks {
+		if !hook.wants(payload.Event) {
+			continue
+		}
+		if err := deliverWebhook(hook, payload.Event, body); err != nil {
+			cm.reportWebhookError(err)
+		}
+	}
+}
+
+func (cm *ContactManager) reportWebhookError(err error) {
+	if cm.webhookErr != nil {
+		cm.webhookErr(err)
+	}
+}
+
+func deliverWebhook(hook Webhook, event string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request for %s: %w", hook.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Contacts-Event", event)
+	if hook.Secret != "" {
+		req.Header.Set("X-Contacts-Signature", "sha256="+SignWebhook(hook.Secret, body))
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook to %s: %w", hook.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", hook.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of body keyed by secret, as sent
+// in the X-Contacts-Signature header. Receivers can use it to verify a
+// payload.
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestContactManager_Webhooks(t *testing.T) {
+	var mu sync.Mutex
+	var got []WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var p WebhookPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("bad payload: %v", err)
+		}
+		mu.Lock()
+		got = append(got, p)
+		mu.Unlock()
+		if want := "sha256=" + SignWebhook("s3cret", body); r.Header.Get("X-Contacts-Signature") != want {
+			t.Errorf("signature = %q, want %q", r.Header.Get("X-Contacts-Signature"), want)
+		}
+	}))
+	defer srv.Close()
+
+	mock := &mockProvider{}
+	cm, err := NewContactManager(mock, t.TempDir(), WithWebhooks(Webhook{URL: srv.URL, Secret: "s3cret"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ann")
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	card.SetValue("TITLE", "Engineer")
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.DeleteContact(CardUID(card)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cm.SyncContacts(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantEvents := []string{EventCreated, EventUpdated, EventDeleted, EventSyncCompleted}
+	if len(got) != len(wantEvents) {
+		t.Fatalf("got %d webhooks, want %d: %+v", len(got), len(wantEvents), got)
+	}
+	for i, want := range wantEvents {
+		if got[i].Event != want {
+			t.Errorf("webhook %d event = %q, want %q", i, got[i].Event, want)
+		}
+	}
+	if got[0].Contact == nil || got[0].UID != CardUID(card) {
+		t.Errorf("created payload missing contact: %+v", got[0])
+	}
+	if len(got[1].Fields) != 1 || got[1].Fields[0] != "+TITLE" {
+		t.Errorf("updated fields = %v, want [+TITLE]", got[1].Fields)
+	}
+}
+
+func TestContactManager_WebhookFilterAndErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	var errs []error
+	cm, err := NewContactManager(nil, t.TempDir(),
+		WithWebhooks(Webhook{URL: srv.URL, Events: []string{EventDeleted}}),
+		WithWebhookErrorHandler(func(err error) { errs = append(errs, err) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := NewCard("Ann")
+	if err := cm.WriteContact(card); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Errorf("create delivered to a delete-only webhook")
+	}
+	if err := cm.DeleteContact(CardUID(card)); err != nil {
+		t.Fatalf("webhook failure should not fail the delete: %v", err)
+	}
+	if calls != 1 || len(errs) != 1 {
+		t.Errorf("calls = %d, errors = %d, want 1 and 1", calls, len(errs))
+	}
+}